@@ -0,0 +1,344 @@
+package stromboli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RunBatch executes multiple requests concurrently with bounded parallelism.
+//
+// Each request is dispatched with [Client.Run]. At most concurrency requests
+// are in flight at any time; values below 1 are treated as 1. The returned
+// slices have the same length and order as reqs: responses[i] and errs[i]
+// hold the result of reqs[i]. A failing request does not abort the batch.
+//
+// If ctx is cancelled, no new requests are dispatched. Requests that were
+// never started get a CANCELLED (or TIMEOUT) error; in-flight requests
+// receive the same context and finish or abort on their own.
+//
+// Example:
+//
+//	reqs := []*stromboli.RunRequest{
+//	    {Prompt: "Summarize", Workdir: "/repo-a"},
+//	    {Prompt: "Summarize", Workdir: "/repo-b"},
+//	}
+//	results, errs := client.RunBatch(ctx, reqs, 4)
+//	for i := range reqs {
+//	    if errs[i] != nil {
+//	        log.Printf("request %d failed: %v", i, errs[i])
+//	        continue
+//	    }
+//	    fmt.Println(results[i].Output)
+//	}
+func (c *Client) RunBatch(ctx context.Context, reqs []*RunRequest, concurrency int) ([]*RunResponse, []error) {
+	responses := make([]*RunResponse, len(reqs))
+	errs := make([]error, len(reqs))
+
+	started := forEachBounded(ctx, len(reqs), concurrency, func(i int) {
+		responses[i], errs[i] = c.Run(ctx, reqs[i])
+	})
+	if started < len(reqs) {
+		cancelErr := c.handleError(ctx.Err(), "batch cancelled")
+		for i := started; i < len(reqs); i++ {
+			errs[i] = cancelErr
+		}
+	}
+	return responses, errs
+}
+
+// DestroyAllSessions destroys every session returned by
+// [Client.ListSessions], with at most concurrency deletions in flight
+// (values below 1 are treated as 1).
+//
+// It returns the number of sessions destroyed and the error of each session
+// that couldn't be, keyed by session ID (nil if all succeeded). Sessions
+// that were already gone ([ErrNotFound]) count as destroyed. If listing the
+// sessions fails, nothing is destroyed and errs holds that error under the
+// empty key.
+//
+// If ctx is cancelled, no new deletions are started: the remaining sessions
+// get a CANCELLED (or TIMEOUT) error, while in-flight deletions finish or
+// abort on their own.
+//
+// Example:
+//
+//	destroyed, errs := client.DestroyAllSessions(ctx, 8)
+//	fmt.Printf("destroyed %d sessions\n", destroyed)
+//	for id, err := range errs {
+//	    log.Printf("failed to destroy %s: %v", id, err)
+//	}
+func (c *Client) DestroyAllSessions(ctx context.Context, concurrency int) (destroyed int, errs map[string]error) {
+	ids, err := c.ListSessions(ctx)
+	if err != nil {
+		return 0, map[string]error{"": err}
+	}
+
+	for i, err := range c.destroySessions(ctx, ids, concurrency) {
+		if err == nil {
+			destroyed++
+			continue
+		}
+		if errs == nil {
+			errs = make(map[string]error)
+		}
+		errs[ids[i]] = err
+	}
+	return destroyed, errs
+}
+
+// destroySessions destroys the sessions ids, with at most concurrency
+// deletions in flight, and returns the error of each (nil if destroyed or
+// already gone). Sessions not started because ctx is done get a CANCELLED
+// (or TIMEOUT) error.
+func (c *Client) destroySessions(ctx context.Context, ids []string, concurrency int) []error {
+	errs := make([]error, len(ids))
+	started := forEachBounded(ctx, len(ids), concurrency, func(i int) {
+		if err := c.DestroySession(ctx, ids[i]); err != nil && !errors.Is(err, ErrNotFound) {
+			errs[i] = err
+		}
+	})
+	if started < len(ids) {
+		cancelErr := c.handleError(ctx.Err(), "session cleanup cancelled")
+		for i := started; i < len(ids); i++ {
+			errs[i] = cancelErr
+		}
+	}
+	return errs
+}
+
+// CleanupSessions destroys stale sessions: those selected by opts among
+// the sessions returned by [Client.ListSessionsDetailed] (a nil opts
+// selects every session, one deletion at a time).
+//
+// With OlderThan set, a session is stale if its UpdatedAt time, or its
+// CreatedAt time if it has none, is at least OlderThan ago. Sessions whose
+// age is unknown (the server doesn't report timestamps) are kept. The
+// least recently used sessions are destroyed first, up to Limit.
+//
+// A failing deletion doesn't abort the cleanup. The result lists the
+// sessions destroyed (sessions already gone, [ErrNotFound], count as
+// destroyed) and the error of each session that couldn't be; the returned
+// error joins these errors, so a non-nil error comes with a result. If
+// listing the sessions fails, nil and that error are returned.
+//
+// If ctx is cancelled, no new deletions are started: the remaining sessions
+// get a CANCELLED (or TIMEOUT) error, while in-flight deletions finish or
+// abort on their own.
+//
+// Example:
+//
+//	result, err := client.CleanupSessions(ctx, &stromboli.CleanupOptions{
+//	    OlderThan:   30 * 24 * time.Hour,
+//	    Concurrency: 4,
+//	})
+//	if result != nil {
+//	    fmt.Printf("destroyed %d stale sessions\n", len(result.Deleted))
+//	}
+//	if err != nil {
+//	    log.Printf("cleanup incomplete: %v", err)
+//	}
+func (c *Client) CleanupSessions(ctx context.Context, opts *CleanupOptions) (*CleanupResult, error) {
+	if opts == nil {
+		opts = &CleanupOptions{}
+	}
+	if opts.OlderThan < 0 || opts.Limit < 0 {
+		return nil, newError("BAD_REQUEST", "older than and limit must not be negative", 400, nil)
+	}
+
+	sessions, err := c.ListSessionsDetailed(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	stale := staleSessions(sessions, opts.OlderThan, c.clock.Now())
+	if opts.Limit > 0 && len(stale) > opts.Limit {
+		stale = stale[:opts.Limit]
+	}
+
+	result := &CleanupResult{DryRun: opts.DryRun}
+	if opts.DryRun {
+		result.Deleted = stale
+		return result, nil
+	}
+
+	errs := c.destroySessions(ctx, stale, opts.Concurrency)
+
+	var failures []error
+	for i, id := range stale {
+		if errs[i] == nil {
+			result.Deleted = append(result.Deleted, id)
+			continue
+		}
+		if result.Errors == nil {
+			result.Errors = make(map[string]error)
+		}
+		result.Errors[id] = errs[i]
+		failures = append(failures, fmt.Errorf("session %s: %w", id, errs[i]))
+	}
+	return result, errors.Join(failures...)
+}
+
+// staleSessions returns the IDs of the sessions last used at least
+// olderThan before now (all sessions if olderThan is zero), the least
+// recently used first. With olderThan set, sessions of unknown age are
+// left out.
+func staleSessions(sessions []*SessionInfo, olderThan time.Duration, now time.Time) []string {
+	type candidate struct {
+		id       string
+		lastUsed time.Time
+	}
+	candidates := make([]candidate, 0, len(sessions))
+	for _, s := range sessions {
+		lastUsed := s.UpdatedAtTime()
+		if lastUsed.IsZero() {
+			lastUsed = s.CreatedAtTime()
+		}
+		if olderThan > 0 && (lastUsed.IsZero() || now.Sub(lastUsed) < olderThan) {
+			continue
+		}
+		candidates = append(candidates, candidate{s.ID, lastUsed})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].lastUsed.Before(candidates[j].lastUsed)
+	})
+
+	ids := make([]string, len(candidates))
+	for i, cand := range candidates {
+		ids[i] = cand.id
+	}
+	return ids
+}
+
+// cancelAllJobsConcurrency is the maximum number of cancellations in flight
+// in [Client.CancelAllJobs].
+const cancelAllJobsConcurrency = 8
+
+// CancelAllJobs cancels every running or pending job returned by
+// [Client.ListJobs] (see [Job.IsRunning]), e.g. for test teardown or an
+// emergency stop.
+//
+// It returns the number of jobs cancelled and the error of each job that
+// couldn't be, keyed by job ID (nil if all succeeded). Jobs that finished
+// between listing and cancelling (409 CONFLICT, or [ErrNotFound] once
+// cleaned up) are neither counted nor errors. If listing the jobs fails,
+// nothing is cancelled and errs holds that error under the empty key.
+//
+// If ctx is cancelled, no new cancellations are started: the remaining jobs
+// get a CANCELLED (or TIMEOUT) error, while in-flight cancellations finish
+// or abort on their own.
+//
+// Example:
+//
+//	cancelled, errs := client.CancelAllJobs(ctx)
+//	fmt.Printf("cancelled %d jobs\n", cancelled)
+//	for id, err := range errs {
+//	    log.Printf("failed to cancel %s: %v", id, err)
+//	}
+func (c *Client) CancelAllJobs(ctx context.Context) (cancelled int, errs map[string]error) {
+	jobs, err := c.ListJobs(ctx)
+	if err != nil {
+		return 0, map[string]error{"": err}
+	}
+	var ids []string
+	for _, job := range jobs {
+		if job.IsRunning() {
+			ids = append(ids, job.ID)
+		}
+	}
+
+	results := make([]error, len(ids))
+	done := make([]bool, len(ids))
+	started := forEachBounded(ctx, len(ids), cancelAllJobsConcurrency, func(i int) {
+		err := c.CancelJob(ctx, ids[i])
+		switch {
+		case err == nil:
+			done[i] = true
+		case isJobAlreadyDone(err):
+		default:
+			results[i] = err
+		}
+	})
+	if started < len(ids) {
+		cancelErr := c.handleError(ctx.Err(), "job cancellation cancelled")
+		for i := started; i < len(ids); i++ {
+			results[i] = cancelErr
+		}
+	}
+
+	for i, err := range results {
+		if done[i] {
+			cancelled++
+		}
+		if err == nil {
+			continue
+		}
+		if errs == nil {
+			errs = make(map[string]error)
+		}
+		errs[ids[i]] = err
+	}
+	return cancelled, errs
+}
+
+// isJobAlreadyDone reports whether err, returned by [Client.CancelJob],
+// means the job finished before it could be cancelled.
+func isJobAlreadyDone(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) && (apiErr.Code == "CONFLICT" || apiErr.Status == http.StatusConflict) {
+		return true
+	}
+	return errors.Is(err, ErrNotFound)
+}
+
+// forEachBounded calls fn(i) for each i in [0, n) in its own goroutine,
+// with at most concurrency calls in flight (values below 1 are treated as
+// 1), and waits for them to return.
+//
+// If ctx is done, no new calls are started. It returns the number of calls
+// started: fn was called for every i below it, and for none from it on.
+func forEachBounded(ctx context.Context, n, concurrency int, fn func(i int)) (started int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for ; started < n; started++ {
+		if !acquireSlot(ctx, sem) {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(started)
+	}
+
+	wg.Wait()
+	return started
+}
+
+// acquireSlot blocks until a slot is available in sem or ctx is done.
+// It returns false without holding a slot if ctx is done, even when a slot
+// happened to be free (select picks randomly when both cases are ready).
+func acquireSlot(ctx context.Context, sem chan struct{}) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	select {
+	case sem <- struct{}{}:
+		if ctx.Err() != nil {
+			<-sem
+			return false
+		}
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
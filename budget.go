@@ -0,0 +1,156 @@
+package stromboli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BudgetTracker caps the total cost of the runs made with
+// [Client.RunWithBudget], e.g. the runs of a batch or of one user.
+//
+// Each run is charged its actual cost as reported by the server
+// ([RunResponse.CostUSD]), or its ClaudeOptions.MaxBudgetUSD if the server
+// doesn't report it. A BudgetTracker is safe for concurrent use, and can be
+// shared between clients.
+type BudgetTracker struct {
+	mu sync.Mutex
+
+	// limit is the total budget in USD.
+	limit float64
+
+	// spent is the cost of the finished runs.
+	spent float64
+
+	// reserved is the MaxBudgetUSD of the runs in flight.
+	reserved float64
+}
+
+// NewBudgetTracker returns a tracker with a total budget of limit USD.
+//
+// Example:
+//
+//	budget := stromboli.NewBudgetTracker(10.0) // $10 for the whole batch
+func NewBudgetTracker(limit float64) *BudgetTracker {
+	return &BudgetTracker{limit: limit}
+}
+
+// Limit returns the total budget in USD.
+func (b *BudgetTracker) Limit() float64 {
+	return b.limit
+}
+
+// Spent returns the cost of the finished runs in USD.
+func (b *BudgetTracker) Spent() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent
+}
+
+// Remaining returns the budget left in USD, not counting the maximum cost
+// of the runs in flight. It is never negative.
+func (b *BudgetTracker) Remaining() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return max(b.limit-b.spent, 0)
+}
+
+// reserve reserves cost for a run and returns true, or returns the
+// available budget and false if cost doesn't fit in it.
+func (b *BudgetTracker) reserve(cost float64) (float64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	available := max(b.limit-b.spent-b.reserved, 0)
+	if cost > available {
+		return available, false
+	}
+	b.reserved += cost
+	return cost, true
+}
+
+// settle releases the reservation of a finished run and charges its cost.
+func (b *BudgetTracker) settle(reserved, cost float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reserved -= reserved
+	b.spent += cost
+}
+
+// RunWithBudget runs req with [Client.Run] if it fits in the budget of bt,
+// and charges its cost to bt.
+//
+// The run is refused with an [ErrBudgetExceeded] error, without sending
+// any request, if the cost already spent plus req.Claude.MaxBudgetUSD
+// would exceed the limit. The MaxBudgetUSD of concurrent runs is reserved
+// until they finish, so they can't overspend together. If req has no
+// MaxBudgetUSD, the one of the client's default Claude options (see
+// [WithDefaultClaudeOptions]) is used.
+//
+// When the run returns a response, successful or not, bt is charged the
+// cost reported by the server, or MaxBudgetUSD if it isn't reported. Runs
+// that fail without a response are not charged. Dry runs (see
+// [RunRequest.DryRun]) are neither checked nor charged.
+//
+// It returns a BAD_REQUEST [Error] if bt is nil, or if MaxBudgetUSD is
+// negative or set neither on req nor in the defaults: a run without a cap
+// could spend the whole budget.
+//
+// Example:
+//
+//	budget := stromboli.NewBudgetTracker(10.0)
+//	for _, prompt := range prompts {
+//	    resp, err := client.RunWithBudget(ctx, &stromboli.RunRequest{
+//	        Prompt: prompt,
+//	        Claude: &stromboli.ClaudeOptions{MaxBudgetUSD: 1.0},
+//	    }, budget)
+//	    if errors.Is(err, stromboli.ErrBudgetExceeded) {
+//	        break
+//	    }
+//	    ...
+//	}
+func (c *Client) RunWithBudget(ctx context.Context, req *RunRequest, bt *BudgetTracker) (_ *RunResponse, err error) {
+	ctx, op := c.observe(ctx, "RunWithBudget")
+	defer op.finish(&err)
+
+	if req == nil {
+		return nil, newError("BAD_REQUEST", "request is required", 400, nil)
+	}
+	if bt == nil {
+		return nil, newError("BAD_REQUEST", "budget tracker is required", 400, nil)
+	}
+	if req.DryRun {
+		return c.Run(ctx, req)
+	}
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	maxCost := 0.0
+	if req.Claude != nil {
+		maxCost = req.Claude.MaxBudgetUSD
+	}
+	if maxCost == 0 && c.defaultClaude != nil {
+		maxCost = c.defaultClaude.MaxBudgetUSD
+	}
+	switch {
+	case maxCost < 0:
+		return nil, newError("BAD_REQUEST", "max budget must not be negative", 400, nil)
+	case maxCost == 0:
+		return nil, newError("BAD_REQUEST", "max budget is required to run with a budget", 400, nil)
+	}
+	if available, ok := bt.reserve(maxCost); !ok {
+		return nil, newError(ErrBudgetExceeded.Code,
+			fmt.Sprintf("run of up to $%.2f would exceed the available budget of $%.2f", maxCost, available), 0, nil)
+	}
+
+	resp, err := c.Run(ctx, req)
+	cost := 0.0
+	if resp != nil {
+		cost = maxCost
+		if reported, ok := resp.CostUSD(); ok {
+			cost = reported
+		}
+	}
+	bt.settle(maxCost, cost)
+	return resp, err
+}
@@ -0,0 +1,285 @@
+package stromboli
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// redacted replaces sensitive values in support bundles and debug logs.
+const redacted = "REDACTED"
+
+// BundleOptions configures [Client.CollectSupportBundle].
+type BundleOptions struct {
+	// Unredacted includes query parameter values in request summaries.
+	// They can contain prompts (e.g. for [Client.Stream]), so by default
+	// they are replaced with "REDACTED". Tokens are never included.
+	Unredacted bool
+}
+
+// CollectSupportBundle writes a zip archive with diagnostics for bug reports.
+//
+// The archive contains:
+//   - sdk_version.json: SDK and target API versions, Go version and platform
+//   - health.json: the raw GET /health response
+//   - claude_status.json: the raw GET /claude/status response
+//   - capabilities.json: the result of [Client.Capabilities]
+//   - clock_skew.json: the difference between the server's Date header and
+//     the local clock
+//   - requests.json: summaries of the last requests, if enabled with
+//     [WithDiagnosticsBuffer]
+//   - errors.json: the errors that prevented collecting any of the above
+//
+// A failure to collect one item doesn't abort the bundle; it is recorded in
+// errors.json instead. Only errors writing to w are returned. Prompts and
+// tokens are redacted unless [BundleOptions.Unredacted] is set (tokens are
+// never included). A nil opts is equivalent to an empty BundleOptions.
+//
+// Example:
+//
+//	f, _ := os.Create("stromboli-support.zip")
+//	defer f.Close()
+//	if err := client.CollectSupportBundle(ctx, f, nil); err != nil {
+//	    log.Fatal(err)
+//	}
+func (c *Client) CollectSupportBundle(ctx context.Context, w io.Writer, opts *BundleOptions) error {
+	if err := c.checkContext(ctx); err != nil {
+		return err
+	}
+	if opts == nil {
+		opts = &BundleOptions{}
+	}
+
+	zw := zip.NewWriter(w)
+	failures := map[string]string{}
+
+	add := func(name string, v interface{}) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	if err := add("sdk_version.json", c.bundleVersionInfo()); err != nil {
+		return err
+	}
+
+	var health json.RawMessage
+	sentAt := c.clock.Now()
+	header, err := c.doJSONWithHeader(ctx, http.MethodGet, "/health", nil, nil, &health)
+	receivedAt := c.clock.Now()
+	if err != nil {
+		failures["health.json"] = err.Error()
+	} else if err := add("health.json", health); err != nil {
+		return err
+	}
+
+	if skew, err := clockSkew(header, sentAt, receivedAt); err != nil {
+		failures["clock_skew.json"] = err.Error()
+	} else if err := add("clock_skew.json", skew); err != nil {
+		return err
+	}
+
+	var status json.RawMessage
+	if err := c.doJSON(ctx, http.MethodGet, "/claude/status", nil, nil, &status); err != nil {
+		failures["claude_status.json"] = err.Error()
+	} else if err := add("claude_status.json", status); err != nil {
+		return err
+	}
+
+	if caps, err := c.Capabilities(ctx); err != nil {
+		failures["capabilities.json"] = err.Error()
+	} else if err := add("capabilities.json", caps); err != nil {
+		return err
+	}
+
+	if c.diagnostics != nil {
+		entries := c.diagnostics.snapshot()
+		if !opts.Unredacted {
+			for i := range entries {
+				entries[i].Query = redactQuery(entries[i].Query)
+			}
+		}
+		if err := add("requests.json", entries); err != nil {
+			return err
+		}
+	}
+
+	if len(failures) > 0 {
+		if err := add("errors.json", failures); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// bundleVersionInfo describes the SDK and its environment.
+func (c *Client) bundleVersionInfo() map[string]string {
+	baseURL := c.baseURL
+	if u, err := url.Parse(c.baseURL); err == nil {
+		u.User = nil // may contain credentials
+		baseURL = u.String()
+	}
+	if c.socketPath != "" {
+		baseURL = "unix://" + c.socketPath
+	}
+	return map[string]string{
+		"sdk_version":       Version,
+		"api_version":       APIVersion,
+		"api_version_range": APIVersionRange,
+		"go_version":        runtime.Version(),
+		"platform":          runtime.GOOS + "/" + runtime.GOARCH,
+		"base_url":          baseURL,
+		"user_agent":        c.userAgent,
+	}
+}
+
+// bundleClockSkew is the clock skew information of a support bundle.
+type bundleClockSkew struct {
+	LocalTime  time.Time `json:"local_time"`
+	ServerTime time.Time `json:"server_time"`
+
+	// SkewSeconds is the server time minus the local time, rounded to
+	// seconds (the resolution of the Date header).
+	SkewSeconds int64 `json:"skew_seconds"`
+}
+
+// clockSkew compares the Date header of a response with the local time
+// halfway between sending the request and receiving the response.
+func clockSkew(header http.Header, sentAt, receivedAt time.Time) (*bundleClockSkew, error) {
+	date := header.Get("Date")
+	if date == "" {
+		return nil, errors.New("server did not send a Date header")
+	}
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return nil, err
+	}
+	local := sentAt.Add(receivedAt.Sub(sentAt) / 2)
+	return &bundleClockSkew{
+		LocalTime:   local.UTC(),
+		ServerTime:  serverTime.UTC(),
+		SkewSeconds: int64(serverTime.Sub(local).Round(time.Second) / time.Second),
+	}, nil
+}
+
+// redactQuery replaces every value of an encoded query string.
+func redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return redacted
+	}
+	for key := range values {
+		values[key] = []string{redacted}
+	}
+	return values.Encode()
+}
+
+// ----------------------------------------------------------------------------
+// Diagnostics Buffer
+// ----------------------------------------------------------------------------
+
+// diagnosticEntry summarizes one HTTP request for support bundles.
+// Bodies and headers are never recorded.
+type diagnosticEntry struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Query      string    `json:"query,omitempty"`
+	Status     int       `json:"status,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// diagnosticsBuffer is a fixed-size ring buffer of the most recent requests.
+type diagnosticsBuffer struct {
+	mu      sync.Mutex
+	entries []diagnosticEntry
+	next    int  // index of the slot to write next
+	full    bool // whether every slot has been written
+}
+
+// newDiagnosticsBuffer creates a buffer holding the last n requests.
+func newDiagnosticsBuffer(n int) *diagnosticsBuffer {
+	return &diagnosticsBuffer{entries: make([]diagnosticEntry, n)}
+}
+
+// add records an entry, overwriting the oldest one if the buffer is full.
+func (b *diagnosticsBuffer) add(entry diagnosticEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// snapshot returns a copy of the recorded entries, oldest first.
+func (b *diagnosticsBuffer) snapshot() []diagnosticEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.full {
+		return append([]diagnosticEntry(nil), b.entries[:b.next]...)
+	}
+	result := make([]diagnosticEntry, 0, len(b.entries))
+	result = append(result, b.entries[b.next:]...)
+	return append(result, b.entries[:b.next]...)
+}
+
+// diagnosticsTransport records a summary of every request in a
+// [diagnosticsBuffer]. It wraps the client's HTTP transport, so requests
+// made by the generated client, streams and raw JSON calls are all covered.
+type diagnosticsTransport struct {
+	base   http.RoundTripper
+	buffer *diagnosticsBuffer
+	clock  Clock
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *diagnosticsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := t.clock.Now()
+	resp, err := base.RoundTrip(req)
+
+	entry := diagnosticEntry{
+		Time:       start.UTC(),
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Query:      req.URL.RawQuery,
+		DurationMS: t.clock.Now().Sub(start).Milliseconds(),
+	}
+	if resp != nil {
+		entry.Status = resp.StatusCode
+	}
+	if err != nil {
+		// Unwrap url.Error so the full URL (and its query) isn't repeated
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			entry.Error = urlErr.Err.Error()
+		} else {
+			entry.Error = err.Error()
+		}
+	}
+	t.buffer.add(entry)
+
+	return resp, err
+}
@@ -0,0 +1,245 @@
+package stromboli
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CallOption configures a single call of a [Client] method, overriding the
+// client's configuration for that call only.
+//
+// The main methods accept call options: [Client.Run], [Client.RunAsync],
+// [Client.Stream], [Client.Health], [Client.Ping], [Client.ListJobs],
+// [Client.GetJob], [Client.CancelJob], [Client.ListImages],
+// [Client.GetImage] and [Client.PullImage]. Options apply to every request
+// the call makes:
+//
+//	result, err := client.Run(ctx, req,
+//	    stromboli.WithCallTimeout(10*time.Minute),
+//	    stromboli.WithCallHeader("X-Tenant", tenantID),
+//	)
+type CallOption func(*callOptions)
+
+// callOptions holds the call options of a call, carried by its context.
+type callOptions struct {
+	// timeout replaces the client timeout (0 if not set).
+	timeout time.Duration
+
+	// header holds the extra headers of every request of the call.
+	header http.Header
+
+	// token is sent with every request of the call ("" if not set).
+	token string
+
+	// idempotency makes Run and RunAsync generate an idempotency key.
+	idempotency bool
+
+	// preflightSecrets makes Run and RunAsync check that the secrets of
+	// SecretsEnv exist.
+	preflightSecrets bool
+}
+
+// idempotencyKeyHeader is the header of [RunRequest.IdempotencyKey].
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// callOptionsKey is the context key of the call options of a call.
+type callOptionsKey struct{}
+
+// WithCallTimeout sets the timeout of the call's requests, replacing the
+// client timeout (see [WithTimeout]) for this call, even if it is longer.
+// For [Client.Stream] it replaces the stream timeout (see
+// [WithStreamTimeout]) instead. A deadline of the context still applies.
+// Zero or negative durations are ignored.
+//
+// Example:
+//
+//	health, err := client.Health(ctx, stromboli.WithCallTimeout(2*time.Second))
+func WithCallTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		if d > 0 {
+			o.timeout = d
+		}
+	}
+}
+
+// WithCallHeader adds a header to every request of the call, such as a
+// tenant or routing header. The header replaces any value set by the SDK,
+// and is set before request hooks run. Calling it several times with the
+// same key sends every value.
+//
+// Headers with an invalid name or a value containing control characters
+// are ignored with a warning.
+//
+// Example:
+//
+//	job, err := client.GetJob(ctx, jobID, stromboli.WithCallHeader("X-Tenant", tenantID))
+func WithCallHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		if !isValidHeaderName(key) || !isValidToken(value) {
+			getLogger().Printf("stromboli: WARNING: WithCallHeader called with invalid header %q, ignoring", key)
+			return
+		}
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Add(key, value)
+	}
+}
+
+// WithCallToken authenticates the call with token instead of the client's
+// token (see [Client.SetToken]). Unlike [ContextWithToken], the token is
+// sent with every request of the call, including requests to endpoints
+// that don't require authentication.
+//
+// An empty token is ignored. Like with SetToken, a token containing
+// control characters is ignored with a warning.
+//
+// Example:
+//
+//	result, err := client.Run(ctx, req, stromboli.WithCallToken(tenantToken))
+func WithCallToken(token string) CallOption {
+	return func(o *callOptions) {
+		if !isValidToken(token) {
+			getLogger().Printf("stromboli: WARNING: WithCallToken called with invalid token (contains control characters), ignoring")
+			return
+		}
+		if token != "" {
+			o.token = token
+		}
+	}
+}
+
+// WithIdempotency makes [Client.Run] and [Client.RunAsync] generate a
+// random [RunRequest.IdempotencyKey] if the request has none, so that the
+// server executes the run at most once. The key is stored in the request:
+// retry a call that failed with the same request, and the retry sends the
+// same key. Don't share the request between concurrent calls.
+//
+// Other methods ignore it.
+//
+// Example:
+//
+//	req := &stromboli.RunRequest{Prompt: "Deploy"}
+//	job, err := client.RunAsync(ctx, req, stromboli.WithIdempotency())
+//	if err != nil {
+//	    // Safe to retry: req.IdempotencyKey is reused
+//	    job, err = client.RunAsync(ctx, req)
+//	}
+func WithIdempotency() CallOption {
+	return func(o *callOptions) {
+		o.idempotency = true
+	}
+}
+
+// WithPreflightSecrets makes [Client.Run] and [Client.RunAsync] check that
+// the secrets referenced by [PodmanOptions.SecretsEnv] (including the
+// client's defaults) exist before submitting the run, with
+// [Client.ResolveSecretsEnv]. A missing secret then fails the call with
+// [ErrNotFound] instead of failing the job when its container starts.
+//
+// Other methods ignore it.
+//
+// Example:
+//
+//	job, err := client.RunAsync(ctx, req, stromboli.WithPreflightSecrets())
+func WithPreflightSecrets() CallOption {
+	return func(o *callOptions) {
+		o.preflightSecrets = true
+	}
+}
+
+// ensureIdempotencyKey generates the idempotency key of req if the call
+// options of ctx ask for one (see WithIdempotency) and it has none.
+func ensureIdempotencyKey(ctx context.Context, req *RunRequest) {
+	if o := callOptionsFrom(ctx); o != nil && o.idempotency && req != nil && req.IdempotencyKey == "" {
+		req.IdempotencyKey = rand.Text()
+	}
+}
+
+// withIdempotencyKey returns a copy of ctx whose requests send key in the
+// Idempotency-Key header, or ctx if key is empty.
+func withIdempotencyKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return withCallOptions(ctx, []CallOption{func(o *callOptions) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Set(idempotencyKeyHeader, key)
+	}})
+}
+
+// withCallOptions returns a copy of ctx carrying opts, applied on top of
+// the call options ctx already carries (e.g. when a method calls another).
+// It returns ctx unchanged if there are no options.
+func withCallOptions(ctx context.Context, opts []CallOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	var o callOptions
+	if parent := callOptionsFrom(ctx); parent != nil {
+		o = *parent
+		o.header = parent.header.Clone()
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	ctx = context.WithValue(ctx, callOptionsKey{}, &o)
+	if o.token != "" {
+		ctx = context.WithValue(ctx, tokenKey{}, o.token)
+	}
+	return ctx
+}
+
+// callOptionsFrom returns the call options carried by ctx, or nil.
+func callOptionsFrom(ctx context.Context) *callOptions {
+	o, _ := ctx.Value(callOptionsKey{}).(*callOptions)
+	return o
+}
+
+// callTimeout returns the timeout set with WithCallTimeout for a call made
+// with ctx, or fallback if none is set.
+func callTimeout(ctx context.Context, fallback time.Duration) time.Duration {
+	if o := callOptionsFrom(ctx); o != nil && o.timeout > 0 {
+		return o.timeout
+	}
+	return fallback
+}
+
+// setCallHeaders sets the headers and token of the call options of req's
+// context on req.
+func setCallHeaders(req *http.Request) {
+	o := callOptionsFrom(req.Context())
+	if o == nil {
+		return
+	}
+	if o.token != "" {
+		req.Header.Set("Authorization", "Bearer "+o.token)
+	}
+	for key, values := range o.header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+}
+
+// isValidHeaderName reports whether name is a valid HTTP header name: a
+// non-empty token of RFC 9110 characters.
+func isValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package stromboli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// capabilitiesTTL is how long a [ServerCapabilities] result is cached.
+// Capabilities only change on server upgrades, which are also detected
+// through [Client.Health], so a few minutes keeps probing cheap.
+const capabilitiesTTL = 5 * time.Minute
+
+// ServerCapabilities describes optional features supported by the server.
+//
+// Use [Client.Capabilities] to retrieve them:
+//
+//	caps, err := client.Capabilities(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if caps.SupportsJobEvents {
+//	    stream, _ := client.StreamJob(ctx, jobID)
+//	    // ...
+//	}
+//
+// Servers that don't expose the capabilities endpoint are assumed to
+// support none of the optional features (Discovered is false).
+type ServerCapabilities struct {
+	// Version is the server version the capabilities apply to.
+	// Example: "0.4.0-alpha"
+	Version string `json:"version,omitempty"`
+
+	// SupportsNDJSON indicates the server can return newline-delimited JSON
+	// (stream-json) output.
+	SupportsNDJSON bool `json:"supports_ndjson"`
+
+	// SupportsCursors indicates paginated endpoints accept opaque cursors
+	// in addition to limit/offset.
+	SupportsCursors bool `json:"supports_cursors"`
+
+	// SupportsJobEvents indicates the server can stream job output
+	// (GET /jobs/{id}/stream).
+	SupportsJobEvents bool `json:"supports_job_events"`
+
+	// SupportsBulkDelete indicates the server accepts bulk delete requests.
+	SupportsBulkDelete bool `json:"supports_bulk_delete"`
+
+	// MaxPromptBytes is the largest prompt the server accepts.
+	// When the server doesn't report a limit, the SDK's own limit is used.
+	MaxPromptBytes int64 `json:"max_prompt_bytes,omitempty"`
+
+	// Discovered is true if the values were reported by the server, and
+	// false if the server has no capabilities endpoint and conservative
+	// defaults are in use.
+	Discovered bool `json:"-"`
+}
+
+// defaultCapabilities returns the conservative capabilities assumed for
+// servers without a capabilities endpoint: no optional features, and the
+// SDK's own prompt size limit.
+func defaultCapabilities() *ServerCapabilities {
+	return &ServerCapabilities{
+		MaxPromptBytes: maxPromptSize,
+	}
+}
+
+// Capabilities returns the optional features supported by the server.
+//
+// The result of GET /capabilities is cached for a few minutes. The cache is
+// also invalidated whenever [Client.Health] observes a different server
+// version, so upgrades are picked up without waiting for the TTL.
+//
+// If the server doesn't expose the endpoint (404, 405 or 501), conservative
+// defaults are returned (and cached) with Discovered set to false. Other
+// failures, such as network errors, are returned and not cached.
+//
+// Example:
+//
+//	caps, err := client.Capabilities(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("cursors: %v, max prompt: %d bytes\n",
+//	    caps.SupportsCursors, caps.MaxPromptBytes)
+func (c *Client) Capabilities(ctx context.Context) (_ *ServerCapabilities, err error) {
+	ctx, op := c.observe(ctx, "Capabilities")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	c.capsMu.Lock()
+	if c.caps != nil && c.clock.Now().Sub(c.capsFetchedAt) < capabilitiesTTL {
+		caps := *c.caps
+		c.capsMu.Unlock()
+		return &caps, nil
+	}
+	c.capsMu.Unlock()
+
+	caps := &ServerCapabilities{}
+	err = c.doJSON(ctx, http.MethodGet, "/capabilities", nil, nil, caps)
+	if err != nil {
+		var apiErr *Error
+		if !errors.As(err, &apiErr) {
+			return nil, err
+		}
+		switch apiErr.Status {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			caps = defaultCapabilities()
+		default:
+			return nil, err
+		}
+	} else {
+		caps.Discovered = true
+		if caps.MaxPromptBytes <= 0 {
+			caps.MaxPromptBytes = maxPromptSize
+		}
+	}
+
+	c.capsMu.Lock()
+	c.caps = caps
+	c.capsFetchedAt = c.clock.Now()
+	c.capsMu.Unlock()
+
+	result := *caps
+	return &result, nil
+}
+
+// capability identifies an optional server feature for [Client.supports].
+type capability int
+
+const (
+	capNDJSON capability = iota
+	capCursors
+	capJobEvents
+	capBulkDelete
+)
+
+// supports reports whether the server supports an optional feature.
+//
+// This is the single place SDK features consult before choosing a code
+// path that depends on server support. Any failure to determine the
+// capabilities is treated as "not supported", so callers always fall back
+// to the conservative path.
+func (c *Client) supports(ctx context.Context, feature capability) bool {
+	caps, err := c.Capabilities(ctx)
+	if err != nil {
+		return false
+	}
+	switch feature {
+	case capNDJSON:
+		return caps.SupportsNDJSON
+	case capCursors:
+		return caps.SupportsCursors
+	case capJobEvents:
+		return caps.SupportsJobEvents
+	case capBulkDelete:
+		return caps.SupportsBulkDelete
+	default:
+		return false
+	}
+}
+
+// observeServerVersion records the server version reported by Health and
+// drops cached capabilities if it changed.
+func (c *Client) observeServerVersion(version string) {
+	if version == "" {
+		return
+	}
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	if c.serverVersion != "" && c.serverVersion != version {
+		c.caps = nil
+	}
+	c.serverVersion = version
+}
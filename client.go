@@ -1,13 +1,21 @@
 package stromboli
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-openapi/runtime"
@@ -40,6 +48,11 @@ const (
 	// 256KB allows for detailed instructions while maintaining safety.
 	maxSystemPromptSize = 256 * 1024 // 256KB
 
+	// defaultPingTimeout is the timeout of [Client.Ping] when the context has
+	// no deadline. Connectivity checks should fail fast, so this is much
+	// shorter than defaultTimeout and independent of WithTimeout.
+	defaultPingTimeout = 5 * time.Second
+
 	// maxJSONSchemaSize limits the maximum JSON schema size.
 	// Most schemas are small (<10KB), but complex nested schemas can be larger.
 	// 64KB accommodates all reasonable use cases.
@@ -100,6 +113,7 @@ func getDefaultTransport() *http.Transport {
 //
 // System:
 //   - [Client.Health]: Check API health status
+//   - [Client.Ping]: Check API reachability
 //   - [Client.ClaudeStatus]: Check Claude configuration status
 //
 // Execution:
@@ -131,6 +145,33 @@ type Client struct {
 	// userAgent is the User-Agent header value.
 	userAgent string
 
+	// parsedBaseURL is baseURL parsed once, for allocation-sensitive
+	// requests (see GetJobInto). It must not be modified.
+	parsedBaseURL *url.URL
+
+	// socketPath is the Unix socket of a unix:// base URL ("" otherwise);
+	// baseURL is then an http URL with a placeholder host.
+	socketPath string
+
+	// dialContext opens connections to the server (nil for the default).
+	dialContext DialContextFunc
+
+	// optionErr is the error of an option that failed to apply, returned
+	// by NewClient.
+	optionErr error
+
+	// closed is set by Close; requests then fail with ErrClientClosed.
+	closed atomic.Bool
+
+	// requestIDHeader is the header of request IDs ("" if disabled), and
+	// requestIDGen generates them.
+	requestIDHeader string
+	requestIDGen    func() string
+
+	// userAgentHeader is the User-Agent header value as a shared header
+	// slice, for allocation-sensitive requests. It must not be modified.
+	userAgentHeader []string
+
 	// mu protects token for concurrent access.
 	mu sync.RWMutex
 
@@ -140,19 +181,129 @@ type Client struct {
 	// api is the generated API client.
 	api *generatedclient.StromboliAPI
 
-	// requestHook is called before each HTTP request (optional).
-	requestHook RequestHook
+	// hooksMu protects requestHooks and responseHooks.
+	hooksMu sync.RWMutex
+
+	// requestHooks are called before each HTTP request, in registration order.
+	requestHooks []RequestHook
+
+	// responseHooks are called after each HTTP response, in registration order.
+	responseHooks []ResponseHook
+
+	// clock is the source of time for caches and wait helpers.
+	clock Clock
+
+	// baseCtx is the parent context of every request (nil if not set).
+	baseCtx context.Context
+
+	// validationMode controls how client-side validation failures are handled.
+	validationMode ValidationMode
+
+	// strictModelValidation rejects models that aren't Model constants.
+	strictModelValidation bool
+
+	// schemaValidator fully validates JSON schemas (nil if not set).
+	schemaValidator func(schema string) error
+
+	// capsMu protects caps, capsFetchedAt, serverVersion and compat.
+	capsMu sync.Mutex
+
+	// images caches GetImage results and the image IDs last returned.
+	images *imageCache
+
+	// imageCacheTTL is how long GetImage results are served from images
+	// (0 if disabled).
+	imageCacheTTL time.Duration
+
+	// caps is the cached result of Capabilities (nil if not fetched).
+	caps *ServerCapabilities
+
+	// capsFetchedAt is when caps was fetched, for TTL expiry.
+	capsFetchedAt time.Time
+
+	// serverVersion is the last server version observed via Health.
+	serverVersion string
+
+	// compatMode controls the compatibility check (see WithCompatibilityCheck).
+	compatMode CompatMode
+
+	// compat is the compatibility of the last server version observed via
+	// Health (nil if not checked yet).
+	compat *CompatibilityResult
+
+	// versionAwareRequests enables dropping fields unsupported by the server.
+	versionAwareRequests bool
+
+	// defaultClaude and defaultPodman are merged into every run request
+	// (nil if not set). They are never modified after creation.
+	defaultClaude *ClaudeOptions
+	defaultPodman *PodmanOptions
+
+	// shapedFields records the request fields already logged as dropped.
+	shapedFields sync.Map
+
+	// outputSanitization controls how outputs and stream data are normalized.
+	outputSanitization OutputSanitization
+
+	// compression asks for compressed JSON responses.
+	compression bool
+
+	// maxResponseSize limits JSON response bodies, in bytes (0 if unlimited).
+	maxResponseSize int64
+
+	// maxSecretSize limits secret values read from readers, in bytes (0 if
+	// unlimited).
+	maxSecretSize int64
+
+	// errorMapper translates errors from server responses (nil if unset).
+	errorMapper ErrorMapper
+
+	// diagnostics records recent requests for support bundles (nil if disabled).
+	diagnostics *diagnosticsBuffer
 
-	// responseHook is called after each HTTP response (optional).
-	responseHook ResponseHook
+	// metrics receives every request (nil if not set).
+	metrics Metrics
+
+	// slogger receives the client's logs (nil to use the SDK's Logger).
+	slogger *slog.Logger
+
+	// debug selects what is logged about each request.
+	debug DebugLevel
+
+	// gate limits the submissions in flight (nil if disabled).
+	gate *submissionGate
+
+	// submissionLease is how long an async job holds its slot of the gate
+	// at most (0 for no limit).
+	submissionLease time.Duration
+
+	// sessionLocks serializes calls per session (nil if disabled).
+	sessionLocks *sessionLocks
+
+	// imageLocks serializes [Client.EnsureImage] calls per image reference.
+	imageLocks *sessionLocks
+
+	// maintenanceMu protects maintenance.
+	maintenanceMu sync.Mutex
+
+	// maintenance is the last maintenance observation (nil if none).
+	maintenance *maintenanceState
+
+	// observer is notified of every operation (nil if not set).
+	observer Observer
+
+	// tracer starts a span for every operation (nil if not set).
+	tracer Tracer
 }
 
 // NewClient creates a new Stromboli API client.
 //
 // The baseURL should be the full URL to the Stromboli API, including
-// the protocol and port. Examples:
+// the protocol and port, or the path of the Unix socket the server
+// listens on. Examples:
 //   - "http://localhost:8585"
 //   - "https://stromboli.example.com"
+//   - "unix:///run/stromboli.sock"
 //
 // Returns an error if the URL is invalid or malformed.
 //
@@ -173,19 +324,35 @@ func NewClient(baseURL string, opts ...Option) (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("stromboli: invalid base URL: %w", err)
 	}
+	// Unix socket URLs are sent over HTTP to a placeholder host
+	var socketPath string
+	if u.Scheme == "unix" {
+		if socketPath, baseURL, err = unixSocketBaseURL(u); err != nil {
+			return nil, err
+		}
+		u, _ = url.Parse(baseURL)
+	}
 	if u.Host == "" {
 		return nil, fmt.Errorf("stromboli: base URL must include host")
 	}
-	// Validate scheme (only http and https are supported)
+	// Validate scheme (only http, https and unix are supported)
 	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
-		return nil, fmt.Errorf("stromboli: unsupported URL scheme %q (use http or https)", u.Scheme)
+		return nil, fmt.Errorf("stromboli: unsupported URL scheme %q (use http, https or unix)", u.Scheme)
 	}
 
 	c := &Client{
-		baseURL:    baseURL,
-		httpClient: &http.Client{},
-		timeout:    defaultTimeout,
-		userAgent:  fmt.Sprintf("stromboli-go/%s", Version),
+		baseURL:         baseURL,
+		parsedBaseURL:   u,
+		socketPath:      socketPath,
+		httpClient:      &http.Client{},
+		timeout:         defaultTimeout,
+		maxResponseSize: defaultMaxResponseSize,
+		maxSecretSize:   defaultMaxSecretSize,
+		submissionLease: defaultSubmissionLease,
+		userAgent:       fmt.Sprintf("stromboli-go/%s", Version),
+		clock:           realClock{},
+		images:          newImageCache(),
+		imageLocks:      newSessionLocks(),
 	}
 
 	// Clone the cached transport to give this client its own connection pool.
@@ -202,30 +369,309 @@ func NewClient(baseURL string, opts ...Option) (*Client, error) {
 	for _, opt := range opts {
 		opt(c)
 	}
+	if c.optionErr != nil {
+		return nil, c.optionErr
+	}
+
+	c.finishInit()
+	return c, nil
+}
+
+// Clone returns a new client with the same configuration as c, with opts
+// applied on top.
+//
+// The clone copies the base URL, HTTP client, current token, hooks and all
+// other options, without re-parsing or re-validating the base URL. It has
+// its own token (SetToken on one client doesn't affect the other), its own
+// hook lists, and fresh capability, image cache and maintenance state. The diagnostics
+// buffer is shared unless opts include [WithDiagnosticsBuffer], so that
+// requests of both clients end up in the same support bundle. Likewise, the
+// submission gate is shared unless opts include [WithSubmissionGate], so
+// that the limit applies to the jobs of both clients, and the session locks
+// unless opts include [WithSessionSerialization], so that calls of both
+// clients to a session are serialized.
+//
+// Example:
+//
+//	streamClient := client.Clone(
+//	    stromboli.WithTimeout(30*time.Minute),
+//	    stromboli.WithUserAgent("my-app-stream/1.0"),
+//	)
+func (c *Client) Clone(opts ...Option) *Client {
+	clone := &Client{
+		baseURL:               c.baseURL,
+		parsedBaseURL:         c.parsedBaseURL,
+		socketPath:            c.socketPath,
+		dialContext:           c.dialContext,
+		requestIDHeader:       c.requestIDHeader,
+		requestIDGen:          c.requestIDGen,
+		httpClient:            c.httpClient,
+		timeout:               c.timeout,
+		streamTimeout:         c.streamTimeout,
+		userAgent:             c.userAgent,
+		token:                 c.getToken(),
+		clock:                 c.clock,
+		images:                newImageCache(),
+		imageCacheTTL:         c.imageCacheTTL,
+		baseCtx:               c.baseCtx,
+		validationMode:        c.validationMode,
+		strictModelValidation: c.strictModelValidation,
+		schemaValidator:       c.schemaValidator,
+		versionAwareRequests:  c.versionAwareRequests,
+		compatMode:            c.compatMode,
+		defaultClaude:         c.defaultClaude,
+		defaultPodman:         c.defaultPodman,
+		outputSanitization:    c.outputSanitization,
+		compression:           c.compression,
+		maxResponseSize:       c.maxResponseSize,
+		maxSecretSize:         c.maxSecretSize,
+		errorMapper:           c.errorMapper,
+		diagnostics:           c.diagnostics,
+		metrics:               c.metrics,
+		slogger:               c.slogger,
+		debug:                 c.debug,
+		gate:                  c.gate,
+		submissionLease:       c.submissionLease,
+		sessionLocks:          c.sessionLocks,
+		imageLocks:            c.imageLocks,
+		observer:              c.observer,
+		tracer:                c.tracer,
+	}
+
+	// Unwrap the compression, response limit, diagnostics, metrics and
+	// debug transports; finishInit adds them back if the clone still uses
+	// them
+	transport := unwrapTransport(c.httpClient.Transport)
+	if transport != c.httpClient.Transport {
+		httpClient := *c.httpClient
+		httpClient.Transport = transport
+		clone.httpClient = &httpClient
+	}
+
+	// Copy the hooks so that hooks added to either client don't leak into
+	// the other
+	c.hooksMu.RLock()
+	clone.requestHooks = append([]RequestHook(nil), c.requestHooks...)
+	clone.responseHooks = append([]ResponseHook(nil), c.responseHooks...)
+	c.hooksMu.RUnlock()
+
+	for _, opt := range opts {
+		opt(clone)
+	}
+	if clone.optionErr != nil {
+		getLogger().Printf("stromboli: WARNING: Clone option ignored: %v", clone.optionErr)
+		clone.optionErr = nil
+	}
+
+	clone.finishInit()
+	return clone
+}
+
+// unwrapTransport returns the transport wrapped by the compression,
+// response limit, diagnostics, metrics and debug transports of finishInit.
+func unwrapTransport(transport http.RoundTripper) http.RoundTripper {
+	for {
+		switch t := transport.(type) {
+		case *compressionTransport:
+			transport = t.base
+		case *responseLimitTransport:
+			transport = t.base
+		case *diagnosticsTransport:
+			transport = t.base
+		case *metricsTransport:
+			transport = t.base
+		case *debugTransport:
+			transport = t.base
+		default:
+			return transport
+		}
+	}
+}
+
+// Close closes the client's idle connections. Calls made after Close fail
+// with [ErrClientClosed]; calls and streams already in flight are not
+// interrupted (use [WithBaseContext] to abort them), and their
+// connections are closed once they finish. Close is safe to call more
+// than once and always returns nil.
+//
+// The client starts no background goroutines, so there is nothing else to
+// stop. Clones share the connection pool of their client, but are not
+// closed with it: closing the pool's idle connections only makes their
+// next request open a new one.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url)
+//	if err != nil {
+//	    return err
+//	}
+//	defer client.Close()
+func (c *Client) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	httpClient := http.Client{Transport: unwrapTransport(c.httpClient.Transport)}
+	httpClient.CloseIdleConnections()
+	return nil
+}
+
+// checkOpen returns [ErrClientClosed] if the client was closed.
+func (c *Client) checkOpen() error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	return nil
+}
+
+// finishInit completes a client once its options are applied: it derives
+// cached header values, configures the dialer, wraps the HTTP client for
+// compression, response limits, diagnostics, metrics and debug logging and
+// creates the generated client.
+func (c *Client) finishInit() {
+	c.userAgentHeader = []string{c.userAgent}
+
+	// Connect through the Unix socket or custom dialer
+	c.configureDialer()
+
+	// Decompress responses, innermost so that every other layer sees
+	// plain bodies
+	if c.compression {
+		httpClient := *c.httpClient
+		httpClient.Transport = &compressionTransport{base: httpClient.Transport}
+		c.httpClient = &httpClient
+	}
+
+	// Limit response bodies as decompressed
+	if c.maxResponseSize > 0 {
+		httpClient := *c.httpClient
+		httpClient.Transport = &responseLimitTransport{base: httpClient.Transport, limit: c.maxResponseSize}
+		c.httpClient = &httpClient
+	}
+
+	// Record requests for support bundles. The http.Client is copied so a
+	// client passed to WithHTTPClient is not modified.
+	if c.diagnostics != nil {
+		httpClient := *c.httpClient
+		httpClient.Transport = &diagnosticsTransport{
+			base:   httpClient.Transport,
+			buffer: c.diagnostics,
+			clock:  c.clock,
+		}
+		c.httpClient = &httpClient
+	}
+
+	// Report requests to metrics
+	if c.metrics != nil {
+		httpClient := *c.httpClient
+		httpClient.Transport = &metricsTransport{
+			base:     httpClient.Transport,
+			metrics:  c.metrics,
+			clock:    c.clock,
+			basePath: strings.TrimSuffix(c.parsedBaseURL.EscapedPath(), "/"),
+		}
+		c.httpClient = &httpClient
+	}
+
+	// Log requests, outermost so that the dumps show requests as sent
+	if c.debug > DebugOff {
+		httpClient := *c.httpClient
+		httpClient.Transport = &debugTransport{
+			base:   httpClient.Transport,
+			level:  c.debug,
+			logger: c.debugLogger(),
+			clock:  c.clock,
+		}
+		c.httpClient = &httpClient
+	}
 
 	// Initialize the generated client
 	c.api = c.newGeneratedClient()
+}
 
-	return c, nil
+// AddRequestHook registers a hook that is called before each HTTP request.
+//
+// Hooks are called in registration order, after any hooks registered with
+// [WithRequestHook]. Unlike most options, hooks can be added at any time:
+// they apply to all requests started after AddRequestHook returns.
+// Nil hooks are ignored.
+//
+// This method is thread-safe.
+func (c *Client) AddRequestHook(hook RequestHook) {
+	if hook == nil {
+		return
+	}
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.requestHooks = append(c.requestHooks, hook)
+}
+
+// AddResponseHook registers a hook that is called after each HTTP response.
+//
+// Hooks are called in registration order, after any hooks registered with
+// [WithResponseHook]. They apply to all responses received after
+// AddResponseHook returns. Nil hooks are ignored.
+//
+// This method is thread-safe.
+func (c *Client) AddResponseHook(hook ResponseHook) {
+	if hook == nil {
+		return
+	}
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.responseHooks = append(c.responseHooks, hook)
+}
+
+// runRequestHooks calls the registered request hooks in order.
+// The hooks are called without holding the lock, so a hook may register
+// further hooks (which take effect for the next request).
+func (c *Client) runRequestHooks(req *http.Request) {
+	c.hooksMu.RLock()
+	hooks := c.requestHooks
+	c.hooksMu.RUnlock()
+	for _, hook := range hooks {
+		hook(req)
+	}
+}
+
+// runResponseHooks calls the registered response hooks in order.
+func (c *Client) runResponseHooks(resp *http.Response) {
+	c.hooksMu.RLock()
+	hooks := c.responseHooks
+	c.hooksMu.RUnlock()
+	for _, hook := range hooks {
+		hook(resp)
+	}
 }
 
 // userAgentTransport wraps http.RoundTripper to add User-Agent header and invoke hooks.
+// Hooks are read from the client on every request, so hooks added after
+// client creation apply to the generated client as well.
 type userAgentTransport struct {
-	base         http.RoundTripper
-	userAgent    string
-	requestHook  RequestHook
-	responseHook ResponseHook
+	base      http.RoundTripper
+	userAgent string
+	client    *Client
 }
 
 // RoundTrip implements http.RoundTripper.
 func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.client.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	mutating := isMutatingMethod(req.Method)
+	if mutating {
+		if err := t.client.failFastInMaintenance(); err != nil {
+			return nil, err
+		}
+	}
+
 	req = req.Clone(req.Context())
 	req.Header.Set("User-Agent", t.userAgent)
+	t.client.setRequestID(req)
+	setCallHeaders(req)
 
-	// Call request hook unconditionally - request is always valid at this point.
-	if t.requestHook != nil {
-		t.requestHook(req)
-	}
+	// Call request hooks unconditionally - request is always valid at this point.
+	t.client.runRequestHooks(req)
 
 	base := t.base
 	if base == nil {
@@ -233,22 +679,56 @@ func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error
 	}
 	resp, err := base.RoundTrip(req)
 
-	// Call response hook only if we have a response.
-	// On network errors, resp may be nil, so we skip the hook.
+	// Keep the start of error bodies around: the generated client closes the
+	// body before returning, but handleAPIError needs it to extract the
+	// server's error code and message.
+	if err == nil && resp.StatusCode >= http.StatusBadRequest {
+		head, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		resp.Body = &errorBody{
+			Reader: io.MultiReader(bytes.NewReader(head), resp.Body),
+			closer: resp.Body,
+			head:   head,
+		}
+		t.client.observeMaintenance(mutating, resp.StatusCode, resp.Header, head)
+	} else if err == nil {
+		t.client.observeMaintenance(mutating, resp.StatusCode, resp.Header, nil)
+		if capture := responseCaptureFrom(req.Context()); capture != nil {
+			resp.Body = &capturedBody{
+				Reader: io.TeeReader(resp.Body, &capture.body),
+				closer: resp.Body,
+			}
+		}
+	}
+
+	// Call response hooks only if we have a response.
+	// On network errors, resp may be nil, so we skip the hooks.
 	// This asymmetry is intentional: request hooks fire for all requests,
 	// response hooks fire only for successful network round-trips.
-	if t.responseHook != nil && resp != nil {
-		t.responseHook(resp)
+	if resp != nil {
+		recordStatus(req.Context(), resp.StatusCode)
+		t.client.runResponseHooks(resp)
 	}
 
 	return resp, err
 }
 
+// errorBody is an error response body that retains its first
+// maxErrorBodySize bytes after being read and closed.
+type errorBody struct {
+	io.Reader
+	closer io.Closer
+	head   []byte
+}
+
+// Close implements io.Closer.
+func (b *errorBody) Close() error {
+	return b.closer.Close()
+}
+
 // newGeneratedClient creates the underlying go-swagger client.
 //
-// NOTE: Request and response hooks are captured at client creation time.
-// Changing hooks after client creation has no effect on the generated API client.
-// To use different hooks, create a new client.
+// Request and response hooks are not captured here: the transport reads
+// them from the client on every request (see [Client.AddRequestHook]).
 func (c *Client) newGeneratedClient() *generatedclient.StromboliAPI {
 	// URL already validated in NewClient
 	u, _ := url.Parse(c.baseURL)
@@ -262,21 +742,21 @@ func (c *Client) newGeneratedClient() *generatedclient.StromboliAPI {
 	// Create transport with user agent and hooks
 	transport := httptransport.New(u.Host, u.Path, schemes)
 	transport.Transport = &userAgentTransport{
-		base:         c.httpClient.Transport,
-		userAgent:    c.userAgent,
-		requestHook:  c.requestHook,
-		responseHook: c.responseHook,
+		base:      c.httpClient.Transport,
+		userAgent: c.userAgent,
+		client:    c,
 	}
 
 	// Create client
 	return generatedclient.New(transport, strfmt.Default)
 }
 
-// effectiveTimeout returns the shorter of the client timeout and context deadline.
+// effectiveTimeout returns the shorter of the client timeout (or the call's
+// timeout, see WithCallTimeout) and context deadline.
 // This ensures the documented behavior where the effective timeout is the minimum
 // of the client's configured timeout and the context's deadline.
 func (c *Client) effectiveTimeout(ctx context.Context) time.Duration {
-	timeout := c.timeout
+	timeout := callTimeout(ctx, c.timeout)
 	if deadline, ok := ctx.Deadline(); ok {
 		remaining := time.Until(deadline)
 		if remaining <= 0 {
@@ -292,6 +772,76 @@ func (c *Client) effectiveTimeout(ctx context.Context) time.Duration {
 	return timeout
 }
 
+// withBaseContext derives a per-call context from ctx that is also
+// cancelled when the client's base context (see [WithBaseContext]) is done.
+//
+// The returned context keeps ctx's values and deadline; if the base context
+// has an earlier deadline, that one applies instead, so the effective
+// deadline is the minimum of both. If the base context is already done, the
+// returned context is cancelled before any request is sent.
+//
+// The returned cancel function must always be called.
+func (c *Client) withBaseContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.baseCtx == nil {
+		return ctx, func() {}
+	}
+
+	var cancel context.CancelFunc
+	if deadline, ok := c.baseCtx.Deadline(); ok {
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	if c.baseCtx.Err() != nil {
+		cancel()
+		return ctx, cancel
+	}
+	stop := context.AfterFunc(c.baseCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// checkContext returns an error if ctx or the client's base context (see
+// [WithBaseContext]) is already done: a CANCELLED or TIMEOUT [Error], as
+// handleError would return for a request made with it. It then checks the
+// server's compatibility (see [WithCompatibilityCheck]).
+//
+// Every API method calls it first, so that calls with a done context fail
+// before preparing or sending any request.
+func (c *Client) checkContext(ctx context.Context) error {
+	if err := c.checkDone(ctx); err != nil {
+		return err
+	}
+	return c.checkCompatibility(ctx)
+}
+
+// checkDone is checkContext without the compatibility check, for the
+// methods that inspect the server: [Client.Health] and [Client.Ping].
+func (c *Client) checkDone(ctx context.Context) error {
+	err := ctx.Err()
+	if err == nil && c.baseCtx != nil {
+		err = c.baseCtx.Err()
+	}
+	if err == nil {
+		return nil
+	}
+	return contextError(err)
+}
+
+// contextError converts a context error (or an error wrapping one) into a
+// CANCELLED or TIMEOUT [Error]. It returns nil for other errors.
+func contextError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return wrapError(err, "CANCELLED", "request was cancelled", 0)
+	case errors.Is(err, context.DeadlineExceeded):
+		return wrapError(err, "TIMEOUT", "request timed out", http.StatusRequestTimeout)
+	}
+	return nil
+}
+
 // ----------------------------------------------------------------------------
 // System Methods
 // ----------------------------------------------------------------------------
@@ -325,9 +875,19 @@ func (c *Client) effectiveTimeout(ctx context.Context) time.Duration {
 //	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 //	defer cancel()
 //	health, err := client.Health(ctx)
-func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
+func (c *Client) Health(ctx context.Context, opts ...CallOption) (_ *HealthResponse, err error) {
+	ctx, op := c.observe(ctx, "Health")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
+
+	if err := c.checkDone(ctx); err != nil {
+		return nil, err
+	}
+
 	// Create request parameters with context
 	params := system.NewGetHealthParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 
@@ -357,6 +917,10 @@ func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
 		}
 	}
 
+	// Drop cached capabilities if the server was upgraded
+	c.observeServerVersion(payload.Version)
+	c.recordCompatibility(payload.Version)
+
 	return &HealthResponse{
 		Name:       payload.Name,
 		Status:     payload.Status,
@@ -365,6 +929,41 @@ func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
 	}, nil
 }
 
+// Ping checks that the Stromboli API is reachable.
+//
+// Unlike [Client.Health], Ping does not decode the response: it issues a
+// GET /health request and returns nil on any 2xx status. This makes it cheap
+// enough to call on a tight interval, e.g. from a load balancer check.
+//
+// If ctx has no deadline, Ping gives up after at most 5 seconds, even if the
+// client timeout configured with [WithTimeout] is longer, unless a call
+// timeout is given with [WithCallTimeout].
+//
+// Example:
+//
+//	if err := client.Ping(ctx); err != nil {
+//	    log.Printf("Stromboli is unreachable: %v", err)
+//	}
+//
+// Non-2xx responses are returned as an [Error] derived from the status
+// (e.g. [ErrUnavailable] for 503); network failures have code REQUEST_FAILED.
+func (c *Client) Ping(ctx context.Context, opts ...CallOption) (err error) {
+	ctx, op := c.observe(ctx, "Ping")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
+
+	if err := c.checkDone(ctx); err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, callTimeout(ctx, defaultPingTimeout))
+		defer cancel()
+	}
+	return c.doJSON(ctx, http.MethodGet, "/health", nil, nil, nil)
+}
+
 // ClaudeStatus returns the Claude configuration status.
 //
 // Use this method to check if the Stromboli server has valid Claude
@@ -388,9 +987,18 @@ func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
 //	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 //	defer cancel()
 //	status, err := client.ClaudeStatus(ctx)
-func (c *Client) ClaudeStatus(ctx context.Context) (*ClaudeStatus, error) {
+func (c *Client) ClaudeStatus(ctx context.Context) (_ *ClaudeStatus, err error) {
+	ctx, op := c.observe(ctx, "ClaudeStatus")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	// Create request parameters with context
 	params := system.NewGetClaudeStatusParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 
@@ -478,45 +1086,79 @@ func (c *Client) ClaudeStatus(ctx context.Context) (*ClaudeStatus, error) {
 //	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 //	defer cancel()
 //	result, err := client.Run(ctx, req)
-func (c *Client) Run(ctx context.Context, req *RunRequest) (*RunResponse, error) {
-	if req == nil {
-		return nil, newError("BAD_REQUEST", "request is required", 400, nil)
-	}
-	if req.Prompt == "" {
-		return nil, newError("BAD_REQUEST", "prompt is required", 400, nil)
-	}
+//
+// # Dry Runs
+//
+// With [RunRequest.DryRun] set, Run validates and resolves the request
+// without executing it, and returns a response with Status "dry_run" and
+// the resolved request in Resolved (see [Client.ResolveRequest]).
+//
+// # Retries
+//
+// A run that failed with a network error may have been executed anyway.
+// Set [RunRequest.IdempotencyKey], or pass [WithIdempotency], to make
+// retrying it with the same request safe on servers supporting idempotency
+// keys.
+func (c *Client) Run(ctx context.Context, req *RunRequest, opts ...CallOption) (_ *RunResponse, err error) {
+	ctx, op := c.observe(ctx, "Run")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
 
-	// Validate request size limits
-	if err := validateRequestSize(req); err != nil {
+	if err := c.checkContext(ctx); err != nil {
 		return nil, err
 	}
 
-	// Validate JSON schema if provided
-	if req.Claude != nil && req.Claude.JSONSchema != "" {
-		if err := validateJSONSchema(req.Claude.JSONSchema); err != nil {
-			return nil, newError("BAD_REQUEST", fmt.Sprintf("invalid JSON schema: %v", err), 400, nil)
+	if req != nil && req.DryRun {
+		resolved, err := c.ResolveRequest(ctx, req)
+		if err != nil {
+			return nil, err
 		}
+		return &RunResponse{Status: RunStatusDryRun, Resolved: resolved}, nil
 	}
+	ensureIdempotencyKey(ctx, req)
+	req, err = c.prepareRunRequest(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.preflightSecrets(ctx, req); err != nil {
+		return nil, err
+	}
+	unlock, err := c.lockSession(ctx, runSessionID(req))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return c.run(ctx, req)
+}
 
-	// Validate Resume requires SessionID
-	if req.Claude != nil && req.Claude.Resume && req.Claude.SessionID == "" {
-		return nil, newError("BAD_REQUEST", "session_id is required when resume is true", 400, nil)
+// run executes a prepared request once its session is locked, taking a
+// slot of the submission gate for the duration of the call.
+func (c *Client) run(ctx context.Context, req *RunRequest) (*RunResponse, error) {
+	if err := c.acquireSubmission(ctx); err != nil {
+		return nil, err
 	}
+	defer c.releaseSubmission()
 
 	// Convert to generated model
-	genReq := toGeneratedRunRequest(req)
+	genReq := c.toGeneratedRunRequest(ctx, req, nil)
 
 	// Create request parameters
+	ctx = withIdempotencyKey(ctx, req.IdempotencyKey)
 	params := execution.NewPostRunParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
+	ctx, capture := withResponseCapture(ctx)
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetRequest(genReq)
 
 	// Execute request
+	started := c.clock.Now()
 	resp, err := c.api.Execution.PostRun(params)
 	if err != nil {
 		return nil, c.handleError(err, "failed to execute Claude")
 	}
+	completed := c.clock.Now()
 
 	// Convert response
 	payload := resp.GetPayload()
@@ -524,13 +1166,22 @@ func (c *Client) Run(ctx context.Context, req *RunRequest) (*RunResponse, error)
 		return nil, newError("INVALID_RESPONSE", "empty run response", 0, nil)
 	}
 
-	return &RunResponse{
-		ID:        payload.ID,
-		Status:    payload.Status,
-		Output:    payload.Output,
-		Error:     payload.Error,
-		SessionID: payload.SessionID,
-	}, nil
+	result := &RunResponse{
+		ID:          payload.ID,
+		Status:      payload.Status,
+		Output:      sanitizeOutput(payload.Output, c.outputSanitization),
+		Error:       payload.Error,
+		SessionID:   payload.SessionID,
+		Usage:       usageFromBody(capture.body.Bytes()),
+		ModelUsed:   modelUsedFromBody(capture.body.Bytes()),
+		StartedAt:   started,
+		CompletedAt: completed,
+	}
+	if result.Status != "" && !RunState(result.Status).IsKnown() {
+		result.Status, result.RawStatus = RunStatusUnknown, result.Status
+	}
+	recordAttribute(ctx, AttributeSessionID, result.SessionID)
+	return result, nil
 }
 
 // RunAsync starts Claude execution asynchronously and returns a job ID.
@@ -538,6 +1189,10 @@ func (c *Client) Run(ctx context.Context, req *RunRequest) (*RunResponse, error)
 // Use this method for long-running tasks. Poll the job status with
 // [Client.GetJob] or configure a webhook to be notified on completion.
 //
+// As with [Client.Run], set [RunRequest.IdempotencyKey] or pass
+// [WithIdempotency] to make retries safe: a retry with the same key
+// doesn't start a second job on servers supporting idempotency keys.
+//
 // Basic usage:
 //
 //	job, err := client.RunAsync(ctx, &stromboli.RunRequest{
@@ -576,36 +1231,41 @@ func (c *Client) Run(ctx context.Context, req *RunRequest) (*RunResponse, error)
 //	        time.Sleep(2 * time.Second)
 //	    }
 //	}
-func (c *Client) RunAsync(ctx context.Context, req *RunRequest) (*AsyncRunResponse, error) {
-	if req == nil {
-		return nil, newError("BAD_REQUEST", "request is required", 400, nil)
-	}
-	if req.Prompt == "" {
-		return nil, newError("BAD_REQUEST", "prompt is required", 400, nil)
-	}
+func (c *Client) RunAsync(ctx context.Context, req *RunRequest, opts ...CallOption) (_ *AsyncRunResponse, err error) {
+	ctx, op := c.observe(ctx, "RunAsync")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
 
-	// Validate request size limits
-	if err := validateRequestSize(req); err != nil {
+	if err := c.checkContext(ctx); err != nil {
 		return nil, err
 	}
 
-	// Validate JSON schema if provided
-	if req.Claude != nil && req.Claude.JSONSchema != "" {
-		if err := validateJSONSchema(req.Claude.JSONSchema); err != nil {
-			return nil, newError("BAD_REQUEST", fmt.Sprintf("invalid JSON schema: %v", err), 400, nil)
-		}
+	ensureIdempotencyKey(ctx, req)
+	req, err = c.prepareRunRequest(req, nil)
+	if err != nil {
+		return nil, err
 	}
-
-	// Validate Resume requires SessionID
-	if req.Claude != nil && req.Claude.Resume && req.Claude.SessionID == "" {
-		return nil, newError("BAD_REQUEST", "session_id is required when resume is true", 400, nil)
+	if err := c.preflightSecrets(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := c.acquireSubmission(ctx); err != nil {
+		return nil, err
 	}
+	return c.runAsync(ctx, req)
+}
+
+// runAsync submits a prepared request once a submission slot is taken. The
+// slot is handed to the job on success and released on failure.
+func (c *Client) runAsync(ctx context.Context, req *RunRequest) (*AsyncRunResponse, error) {
 
 	// Convert to generated model
-	genReq := toGeneratedRunRequest(req)
+	genReq := c.toGeneratedRunRequest(ctx, req, nil)
 
 	// Create request parameters
+	ctx = withIdempotencyKey(ctx, req.IdempotencyKey)
 	params := execution.NewPostRunAsyncParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetRequest(genReq)
@@ -613,23 +1273,76 @@ func (c *Client) RunAsync(ctx context.Context, req *RunRequest) (*AsyncRunRespon
 	// Execute request
 	resp, err := c.api.Execution.PostRunAsync(params)
 	if err != nil {
+		c.releaseSubmission()
 		return nil, c.handleError(err, "failed to start async execution")
 	}
 
 	// Convert response
 	payload := resp.GetPayload()
 	if payload == nil {
+		c.releaseSubmission()
 		return nil, newError("INVALID_RESPONSE", "empty async run response", 0, nil)
 	}
+	c.trackSubmission(payload.JobID)
+	recordAttribute(ctx, AttributeJobID, payload.JobID)
 
 	return &AsyncRunResponse{
 		JobID: payload.JobID,
 	}, nil
 }
 
+// prepareRunRequest returns a request of Run or RunAsync with the client's
+// default options merged in, validated before anything is sent (subject to
+// the client's validation mode for fields). The defaults applied and the
+// failures that don't stop the request are recorded in res.
+func (c *Client) prepareRunRequest(req *RunRequest, res *resolution) (*RunRequest, error) {
+	if err := checkRunRequestRequired(req); err != nil {
+		return nil, err
+	}
+	req = c.withDefaults(req, res)
+	if err := c.validateRunRequest(req, res); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ValidateRunRequest runs every client-side check Run and RunAsync would
+// run on req, after merging in the client's default options, and returns
+// the first failure as a BAD_REQUEST [Error], or nil. Nothing is sent.
+//
+// Unlike Run, it reports failures whatever the client's [ValidationMode],
+// so a request can be checked before submitting an expensive job even on a
+// client that would only warn about it.
+func (c *Client) ValidateRunRequest(req *RunRequest) error {
+	if err := checkRunRequestRequired(req); err != nil {
+		return err
+	}
+	if errs := c.runRequestChecks(c.withDefaults(req, nil), nil, false); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// checkRunRequestRequired checks the fields of a [RunRequest] that are
+// required whatever the client's validation mode.
+func checkRunRequestRequired(req *RunRequest) error {
+	if req == nil {
+		return newError("BAD_REQUEST", "request is required", 400, nil)
+	}
+	if req.Prompt == "" {
+		return newError("BAD_REQUEST", "prompt is required", 400, nil)
+	}
+	if !isValidToken(req.IdempotencyKey) {
+		return newError("BAD_REQUEST", "idempotency key contains control characters", 400, nil)
+	}
+	return nil
+}
+
 // toGeneratedRunRequest converts a RunRequest to the generated model for API calls.
-// It maps all Claude and Podman options to their corresponding generated types.
-func toGeneratedRunRequest(req *RunRequest) *models.RunRequest {
+// It maps all Claude and Podman options to their corresponding generated types,
+// then drops the fields the server doesn't support (see [Client.shapeRunRequest]),
+// recording the changes in res.
+func (c *Client) toGeneratedRunRequest(ctx context.Context, req *RunRequest, res *resolution) *models.RunRequest {
 	prompt := req.Prompt
 	genReq := &models.RunRequest{
 		Prompt:     &prompt,
@@ -649,7 +1362,7 @@ func toGeneratedRunRequest(req *RunRequest) *models.RunRequest {
 			AllowedTools:                    req.Claude.AllowedTools,
 			DisallowedTools:                 req.Claude.DisallowedTools,
 			DangerouslySkipPermissions:      req.Claude.DangerouslySkipPermissions,
-			PermissionMode:                  req.Claude.PermissionMode,
+			PermissionMode:                  string(req.Claude.PermissionMode),
 			OutputFormat:                    req.Claude.OutputFormat,
 			JSONSchema:                      req.Claude.JSONSchema,
 			Verbose:                         req.Claude.Verbose,
@@ -710,6 +1423,8 @@ func toGeneratedRunRequest(req *RunRequest) *models.RunRequest {
 		}
 	}
 
+	c.shapeRunRequest(ctx, genReq, res)
+
 	return genReq
 }
 
@@ -742,11 +1457,24 @@ func toGeneratedRunRequest(req *RunRequest) *models.RunRequest {
 //	        fmt.Printf("Job %s is still running\n", job.ID)
 //	    }
 //	}
-func (c *Client) ListJobs(ctx context.Context) ([]*Job, error) {
-	// Create request parameters with context
-	params := jobs.NewGetJobsParams()
-	params.SetContext(ctx)
-	params.SetTimeout(c.effectiveTimeout(ctx))
+//
+// To filter on the server side, use [Client.ListJobsFiltered].
+func (c *Client) ListJobs(ctx context.Context, opts ...CallOption) (_ []*Job, err error) {
+	ctx, op := c.observe(ctx, "ListJobs")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	// Create request parameters with context
+	params := jobs.NewGetJobsParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
+	ctx, capture := withResponseCapture(ctx)
+	params.SetContext(ctx)
+	params.SetTimeout(c.effectiveTimeout(ctx))
 
 	// Execute request
 	resp, err := c.api.Jobs.GetJobs(params)
@@ -760,17 +1488,159 @@ func (c *Client) ListJobs(ctx context.Context) ([]*Job, error) {
 		return nil, newError("INVALID_RESPONSE", "empty jobs list response", 0, nil)
 	}
 
-	// Map jobs
+	return c.fromGeneratedJobList(payload, capture.body.Bytes()), nil
+}
+
+// fromGeneratedJobList converts a job list response, taking the usage of
+// each job from the raw response body (see usageFromBody). Nil jobs are
+// skipped.
+func (c *Client) fromGeneratedJobList(payload *models.JobListResponse, body []byte) []*Job {
+	usages := jobUsagesFromBody(body)
+	result := make([]*Job, 0, len(payload.Jobs))
+	for i, j := range payload.Jobs {
+		if j == nil {
+			continue
+		}
+		job := c.fromGeneratedJobResponse(j)
+		if i < len(usages) {
+			job.Usage = usages[i]
+		}
+		c.observeJobStatus(job.ID, job.Status)
+		result = append(result, job)
+	}
+	return result
+}
+
+// ListJobsFiltered returns async jobs filtered by status, with pagination.
+//
+// The options are sent to the server as query parameters (status as a
+// comma-separated list, limit and offset), so only the requested jobs are
+// transferred. Servers that don't support these parameters return every
+// job; in that case the SDK applies the same filtering and pagination
+// client-side. Which path applies is decided before looking at the jobs,
+// and logged at debug level:
+//
+//   - The response is paginated if it reports pagination metadata
+//     ("total", "offset" or "limit"), or if the server supports cursors
+//     (see [ServerCapabilities]), which implies limit/offset support. The
+//     page is returned as-is, so Offset is never applied twice.
+//   - Otherwise the response is the full list: offset and limit are applied
+//     client-side.
+//
+// The status filter is always re-applied client-side. On a paginated
+// response from a server that ignored it, the page is filtered after
+// pagination and may hold fewer than Limit jobs.
+//
+// A nil opts behaves like [Client.ListJobs]. Returns a BAD_REQUEST error if
+// a status is not one of the JobStatus* constants or if Limit or Offset is
+// negative.
+//
+// Example:
+//
+//	jobs, err := client.ListJobsFiltered(ctx, &stromboli.ListJobsOptions{
+//	    Status: []string{stromboli.JobStatusFailed},
+//	    Limit:  50,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, job := range jobs {
+//	    fmt.Printf("%s failed: %s\n", job.ID, job.Error)
+//	}
+func (c *Client) ListJobsFiltered(ctx context.Context, opts *ListJobsOptions) (_ []*Job, err error) {
+	ctx, op := c.observe(ctx, "ListJobsFiltered")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if opts == nil {
+		return c.ListJobs(ctx)
+	}
+	if opts.Limit < 0 || opts.Offset < 0 {
+		return nil, newError("BAD_REQUEST", "limit and offset must not be negative", 400, nil)
+	}
+
+	wanted := make(map[string]bool, len(opts.Status))
+	for _, status := range opts.Status {
+		if !isJobStatus(status) {
+			return nil, newError("BAD_REQUEST", fmt.Sprintf("unknown job status %q", status), 400, nil)
+		}
+		wanted[status] = true
+	}
+
+	query := url.Values{}
+	if len(opts.Status) > 0 {
+		query.Set("status", strings.Join(opts.Status, ","))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.FormatInt(opts.Limit, 10))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.FormatInt(opts.Offset, 10))
+	}
+
+	var body json.RawMessage
+	if err := c.doJSON(ctx, http.MethodGet, "/jobs", query, nil, &body); err != nil {
+		return nil, err
+	}
+	var payload models.JobListResponse
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, newError("INVALID_RESPONSE", "failed to decode response", 0, err)
+	}
+
 	result := make([]*Job, 0, len(payload.Jobs))
-	for _, j := range payload.Jobs {
-		if j != nil {
-			result = append(result, fromGeneratedJobResponse(j))
+	for _, job := range c.fromGeneratedJobList(&payload, body) {
+		if len(wanted) > 0 && !wanted[job.Status] {
+			continue
+		}
+		result = append(result, job)
+	}
+
+	if opts.Limit > 0 || opts.Offset > 0 {
+		if hasPaginationMetadata(body) || c.supports(ctx, capCursors) {
+			c.logf(slog.LevelDebug, "ListJobsFiltered: server paginated the response")
+		} else {
+			c.logf(slog.LevelDebug, "ListJobsFiltered: server returned every job, paginating client-side")
+			result = paginate(result, opts.Offset, opts.Limit)
 		}
 	}
 
 	return result, nil
 }
 
+// hasPaginationMetadata reports whether a list response body reports how
+// it was paginated, meaning the server applied limit and offset.
+func hasPaginationMetadata(body []byte) bool {
+	var meta struct {
+		Total  *int64 `json:"total"`
+		Offset *int64 `json:"offset"`
+		Limit  *int64 `json:"limit"`
+	}
+	if json.Unmarshal(body, &meta) != nil {
+		return false
+	}
+	return meta.Total != nil || meta.Offset != nil || meta.Limit != nil
+}
+
+// isJobStatus reports whether status is one of the JobStatus* constants.
+func isJobStatus(status string) bool {
+	return JobState(status).IsKnown()
+}
+
+// paginate applies offset and limit (0 means no limit) to items.
+func paginate[T any](items []T, offset, limit int64) []T {
+	if offset >= int64(len(items)) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit > 0 && limit < int64(len(items)) {
+		items = items[:limit]
+	}
+	return items
+}
+
 // GetJob returns the status and result of an async job.
 //
 // Use this method to poll for job completion or check the status of
@@ -804,13 +1674,25 @@ func (c *Client) ListJobs(ctx context.Context) ([]*Job, error) {
 //	if errors.Is(err, stromboli.ErrNotFound) {
 //	    fmt.Println("Job not found")
 //	}
-func (c *Client) GetJob(ctx context.Context, jobID string) (*Job, error) {
+func (c *Client) GetJob(ctx context.Context, jobID string, opts ...CallOption) (_ *Job, err error) {
+	ctx, op := c.observe(ctx, "GetJob")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
+	op.setAttribute(AttributeJobID, jobID)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	if jobID == "" {
 		return nil, newError("BAD_REQUEST", "job ID is required", 400, nil)
 	}
 
 	// Create request parameters with context
 	params := jobs.NewGetJobsIDParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
+	ctx, capture := withResponseCapture(ctx)
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetID(jobID)
@@ -818,7 +1700,12 @@ func (c *Client) GetJob(ctx context.Context, jobID string) (*Job, error) {
 	// Execute request
 	resp, err := c.api.Jobs.GetJobsID(params)
 	if err != nil {
-		return nil, c.handleError(err, "failed to get job")
+		err = c.handleError(err, "failed to get job")
+		if errors.Is(err, ErrNotFound) {
+			// Purged by the server; it can't be observed finishing anymore
+			c.ReleaseJob(jobID)
+		}
+		return nil, err
 	}
 
 	// Convert response
@@ -827,7 +1714,10 @@ func (c *Client) GetJob(ctx context.Context, jobID string) (*Job, error) {
 		return nil, newError("INVALID_RESPONSE", "empty job response", 0, nil)
 	}
 
-	return fromGeneratedJobResponse(payload), nil
+	job := c.fromGeneratedJobResponse(payload)
+	job.Usage = usageFromBody(capture.body.Bytes())
+	c.observeJobStatus(job.ID, job.Status)
+	return job, nil
 }
 
 // CancelJob cancels a pending or running job.
@@ -857,39 +1747,59 @@ func (c *Client) GetJob(ctx context.Context, jobID string) (*Job, error) {
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
-func (c *Client) CancelJob(ctx context.Context, jobID string) error {
+func (c *Client) CancelJob(ctx context.Context, jobID string, opts ...CallOption) (err error) {
+	ctx, op := c.observe(ctx, "CancelJob")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
+	op.setAttribute(AttributeJobID, jobID)
+
+	if err := c.checkContext(ctx); err != nil {
+		return err
+	}
+
 	if jobID == "" {
 		return newError("BAD_REQUEST", "job ID is required", 400, nil)
 	}
 
 	// Create request parameters with context
 	params := jobs.NewDeleteJobsIDParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetID(jobID)
 
 	// Execute request
-	_, err := c.api.Jobs.DeleteJobsID(params)
+	_, err = c.api.Jobs.DeleteJobsID(params)
 	if err != nil {
-		return c.handleError(err, "failed to cancel job")
+		err = c.handleError(err, "failed to cancel job")
+		if errors.Is(err, ErrNotFound) {
+			c.ReleaseJob(jobID)
+		}
+		return err
 	}
 
+	c.ReleaseJob(jobID)
 	return nil
 }
 
 // fromGeneratedJobResponse converts a generated JobResponse model to the SDK Job type.
-// It handles the mapping of all fields including optional crash info.
-func fromGeneratedJobResponse(j *models.JobResponse) *Job {
+// It handles the mapping of all fields including optional crash info, maps
+// unknown statuses to [JobStatusUnknown], and sanitizes the output (see
+// [WithOutputSanitization]).
+func (c *Client) fromGeneratedJobResponse(j *models.JobResponse) *Job {
 	job := &Job{
 		ID:        j.ID,
 		Status:    string(j.Status),
-		Output:    j.Output,
+		Output:    sanitizeOutput(j.Output, c.outputSanitization),
 		Error:     j.Error,
 		SessionID: j.SessionID,
 		CreatedAt: j.CreatedAt,
 		UpdatedAt: j.UpdatedAt,
 	}
 
+	normalizeJobStatus(job)
+
 	// Convert crash info if present
 	if j.CrashInfo != nil {
 		job.CrashInfo = &CrashInfo{
@@ -904,6 +1814,19 @@ func fromGeneratedJobResponse(j *models.JobResponse) *Job {
 	return job
 }
 
+// normalizeJobStatus replaces a status the SDK doesn't know with
+// [JobStatusUnknown], keeping the server's value in RawStatus.
+//
+// Responses are decoded without validating the generated models against
+// the spec's enums, so statuses added by newer servers end up here rather
+// than failing the call; the same goes for message types.
+func normalizeJobStatus(job *Job) {
+	job.RawStatus = ""
+	if job.Status != "" && !JobState(job.Status).IsKnown() {
+		job.Status, job.RawStatus = JobStatusUnknown, job.Status
+	}
+}
+
 // ----------------------------------------------------------------------------
 // Session Methods
 // ----------------------------------------------------------------------------
@@ -934,9 +1857,18 @@ func fromGeneratedJobResponse(j *models.JobResponse) *Job {
 //	        Resume:    true,
 //	    },
 //	})
-func (c *Client) ListSessions(ctx context.Context) ([]string, error) {
+func (c *Client) ListSessions(ctx context.Context) (_ []string, err error) {
+	ctx, op := c.observe(ctx, "ListSessions")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	// Create request parameters with context
 	params := sessions.NewGetSessionsParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 
@@ -955,6 +1887,136 @@ func (c *Client) ListSessions(ctx context.Context) ([]string, error) {
 	return payload.Sessions, nil
 }
 
+// ListSessionsDetailed is like [Client.ListSessions] but returns the
+// metadata of each session (timestamps, message count, last prompt,
+// workdir and model), so listing sessions doesn't require a
+// [Client.GetMessages] call per session.
+//
+// Limit and Offset of opts (nil means all sessions) are sent to the
+// server. Whether it applied them is decided like in
+// [Client.ListJobsFiltered], before looking at the sessions, and logged at
+// debug level: the response is paginated if it reports pagination metadata
+// ("total", "offset" or "limit") or if the server supports cursors, unless
+// it lists bare session IDs; otherwise it is the full list and Limit and
+// Offset are applied client-side. Servers that only report session IDs
+// return [SessionInfo] values with just the ID set; use
+// [Client.GetSession] to fetch the details of one of them.
+//
+// Returns a BAD_REQUEST error if Limit or Offset is negative.
+//
+// Example:
+//
+//	sessions, err := client.ListSessionsDetailed(ctx, &stromboli.ListSessionsOptions{Limit: 20})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, s := range sessions {
+//	    fmt.Printf("%s  %-3d  %s\n", s.ID, s.MessageCount, s.LastPrompt)
+//	}
+func (c *Client) ListSessionsDetailed(ctx context.Context, opts *ListSessionsOptions) (_ []*SessionInfo, err error) {
+	ctx, op := c.observe(ctx, "ListSessionsDetailed")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if opts == nil {
+		opts = &ListSessionsOptions{}
+	}
+	if opts.Limit < 0 || opts.Offset < 0 {
+		return nil, newError("BAD_REQUEST", "limit and offset must not be negative", 400, nil)
+	}
+
+	query := url.Values{}
+	query.Set("detailed", "true")
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.FormatInt(opts.Limit, 10))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.FormatInt(opts.Offset, 10))
+	}
+
+	var body json.RawMessage
+	if err := c.doJSON(ctx, http.MethodGet, "/sessions", query, nil, &body); err != nil {
+		return nil, err
+	}
+	var payload struct {
+		Sessions []json.RawMessage `json:"sessions"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, newError("INVALID_RESPONSE", "failed to decode response", 0, err)
+	}
+
+	result := make([]*SessionInfo, 0, len(payload.Sessions))
+	idsOnly := false
+	for _, raw := range payload.Sessions {
+		info := &SessionInfo{}
+		// Servers without session details list bare IDs
+		if err := json.Unmarshal(raw, &info.ID); err == nil {
+			idsOnly = true
+		} else if err := json.Unmarshal(raw, info); err != nil {
+			return nil, newError("INVALID_RESPONSE", "failed to decode session", 0, err)
+		}
+		result = append(result, info)
+	}
+
+	if opts.Limit > 0 || opts.Offset > 0 {
+		// Servers listing bare IDs predate pagination
+		if hasPaginationMetadata(body) || (!idsOnly && c.supports(ctx, capCursors)) {
+			c.logf(slog.LevelDebug, "ListSessionsDetailed: server paginated the response")
+		} else {
+			c.logf(slog.LevelDebug, "ListSessionsDetailed: server returned every session, paginating client-side")
+			result = paginate(result, opts.Offset, opts.Limit)
+		}
+	}
+
+	return result, nil
+}
+
+// GetSession returns the metadata of a session: timestamps, message
+// count, a preview of the last prompt, workdir and model.
+//
+// Returns [ErrNotFound] if the session doesn't exist, or an [Error] with
+// code "UNSUPPORTED" if the server doesn't expose session details.
+//
+// Example:
+//
+//	info, err := client.GetSession(ctx, "sess-abc123")
+//	if errors.Is(err, stromboli.ErrNotFound) {
+//	    fmt.Println("Session not found")
+//	} else if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("%d messages since %s\n", info.MessageCount, info.CreatedAtTime().Format(time.Kitchen))
+func (c *Client) GetSession(ctx context.Context, sessionID string) (_ *SessionInfo, err error) {
+	ctx, op := c.observe(ctx, "GetSession")
+	defer op.finish(&err)
+	op.setAttribute(AttributeSessionID, sessionID)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if sessionID == "" {
+		return nil, newError("BAD_REQUEST", "session ID is required", 400, nil)
+	}
+
+	info := &SessionInfo{}
+	if err := c.doJSON(ctx, http.MethodGet, "/sessions/"+url.PathEscape(sessionID), nil, nil, info); err != nil {
+		var apiErr *Error
+		if errors.As(err, &apiErr) &&
+			(apiErr.Status == http.StatusMethodNotAllowed || apiErr.Status == http.StatusNotImplemented) {
+			return nil, newError("UNSUPPORTED", "server does not support session details", http.StatusNotImplemented, err)
+		}
+		return nil, err
+	}
+	if info.ID == "" {
+		info.ID = sessionID
+	}
+	return info, nil
+}
+
 // DestroySession removes a session and all its stored data.
 //
 // Use this method to clean up old sessions that are no longer needed.
@@ -972,27 +2034,30 @@ func (c *Client) ListSessions(ctx context.Context) ([]string, error) {
 //	}
 //	fmt.Println("Session destroyed")
 //
-// Bulk cleanup:
-//
-//	sessions, _ := client.ListSessions(ctx)
-//	for _, id := range sessions {
-//	    if err := client.DestroySession(ctx, id); err != nil {
-//	        log.Printf("Failed to destroy %s: %v\n", id, err)
-//	    }
-//	}
-func (c *Client) DestroySession(ctx context.Context, sessionID string) error {
+// To destroy every session concurrently, use [Client.DestroyAllSessions].
+func (c *Client) DestroySession(ctx context.Context, sessionID string) (err error) {
+	ctx, op := c.observe(ctx, "DestroySession")
+	defer op.finish(&err)
+	op.setAttribute(AttributeSessionID, sessionID)
+
+	if err := c.checkContext(ctx); err != nil {
+		return err
+	}
+
 	if sessionID == "" {
 		return newError("BAD_REQUEST", "session ID is required", 400, nil)
 	}
 
 	// Create request parameters with context
 	params := sessions.NewDeleteSessionsIDParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetID(sessionID)
 
 	// Execute request
-	_, err := c.api.Sessions.DeleteSessionsID(params)
+	_, err = c.api.Sessions.DeleteSessionsID(params)
 	if err != nil {
 		return c.handleError(err, "failed to destroy session")
 	}
@@ -1030,26 +2095,48 @@ func (c *Client) DestroySession(ctx context.Context, sessionID string) error {
 //	        Offset: messages.Offset + messages.Limit,
 //	    })
 //	}
-func (c *Client) GetMessages(ctx context.Context, sessionID string, opts *GetMessagesOptions) (*MessagesResponse, error) {
+//
+// Servers that support cursors (see [ServerCapabilities]) return a
+// NextCursor with each page. Pass it as Cursor to fetch the next page
+// without skipping or repeating messages appended in the meantime; the
+// offset is ignored when a cursor is set. [Client.AllMessages] follows
+// cursors automatically.
+func (c *Client) GetMessages(ctx context.Context, sessionID string, opts *GetMessagesOptions) (_ *MessagesResponse, err error) {
+	ctx, op := c.observe(ctx, "GetMessages")
+	defer op.finish(&err)
+	op.setAttribute(AttributeSessionID, sessionID)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	if sessionID == "" {
 		return nil, newError("BAD_REQUEST", "session ID is required", 400, nil)
 	}
+	if opts != nil {
+		// Validate negative values - catch client-side for better error messages
+		if opts.Limit < 0 {
+			return nil, newError("BAD_REQUEST", "limit cannot be negative", 400, nil)
+		}
+		if opts.Offset < 0 {
+			return nil, newError("BAD_REQUEST", "offset cannot be negative", 400, nil)
+		}
+		if opts.Cursor != "" {
+			return c.getMessagesByCursor(ctx, sessionID, opts)
+		}
+	}
 
 	// Create request parameters with context
 	params := sessions.NewGetSessionsIDMessagesParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
+	ctx, capture := withResponseCapture(ctx)
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetID(sessionID)
 
 	// Apply options if provided
 	if opts != nil {
-		// Validate negative values - catch client-side for better error messages
-		if opts.Limit < 0 {
-			return nil, newError("BAD_REQUEST", "limit cannot be negative", 400, nil)
-		}
-		if opts.Offset < 0 {
-			return nil, newError("BAD_REQUEST", "offset cannot be negative", 400, nil)
-		}
 		if opts.Limit > 0 {
 			params.SetLimit(&opts.Limit)
 		}
@@ -1071,8 +2158,129 @@ func (c *Client) GetMessages(ctx context.Context, sessionID string, opts *GetMes
 	if payload == nil {
 		return nil, newError("INVALID_RESPONSE", "empty messages response", 0, nil)
 	}
+	return fromGeneratedMessages(payload, capture.body.Bytes()), nil
+}
+
+// getMessagesByCursor fetches the page of messages starting at opts.Cursor.
+// The generated client has no cursor parameter, so the request is sent
+// directly; the offset is not sent since the cursor takes precedence.
+func (c *Client) getMessagesByCursor(ctx context.Context, sessionID string, opts *GetMessagesOptions) (*MessagesResponse, error) {
+	query := url.Values{}
+	query.Set("cursor", opts.Cursor)
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.FormatInt(opts.Limit, 10))
+	}
+	return c.getMessagesPage(ctx, sessionID, query)
+}
+
+// knownMessageTypes lists the message types accepted by
+// [Client.GetMessagesByType].
+var knownMessageTypes = []string{"user", "assistant", "queue-operation"}
+
+// GetMessagesByType retrieves a page of messages of one type from a
+// session's history, such as only the "assistant" messages. msgType must be
+// "user", "assistant" or "queue-operation"; other values return a
+// BAD_REQUEST error. opts paginates as in [Client.GetMessages].
+//
+// The type is sent to the server, which filters the messages itself when
+// it supports it. The page is also filtered client-side, so servers that
+// ignore the type still only return messages of that type. In that case,
+// Total and HasMore describe all the session's messages, and a page can
+// hold fewer than Limit messages (even none) while HasMore is true: keep
+// paginating with Offset and Limit as usual.
+//
+// Example:
+//
+//	resp, err := client.GetMessagesByType(ctx, "sess-abc123", "assistant", nil)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, msg := range resp.Messages {
+//	    fmt.Println(msg.UUID)
+//	}
+func (c *Client) GetMessagesByType(ctx context.Context, sessionID string, msgType string, opts *GetMessagesOptions) (_ *MessagesResponse, err error) {
+	ctx, op := c.observe(ctx, "GetMessagesByType")
+	defer op.finish(&err)
+	op.setAttribute(AttributeSessionID, sessionID)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if sessionID == "" {
+		return nil, newError("BAD_REQUEST", "session ID is required", 400, nil)
+	}
+	if !isKnownMessageType(msgType) {
+		return nil, newError("BAD_REQUEST",
+			fmt.Sprintf("invalid message type %q (valid types: %s)", msgType, strings.Join(knownMessageTypes, ", ")),
+			400, nil)
+	}
+	if opts == nil {
+		opts = &GetMessagesOptions{}
+	}
+	if opts.Limit < 0 {
+		return nil, newError("BAD_REQUEST", "limit cannot be negative", 400, nil)
+	}
+	if opts.Offset < 0 {
+		return nil, newError("BAD_REQUEST", "offset cannot be negative", 400, nil)
+	}
 
-	// Map messages
+	// The generated client has no type parameter, so the request is sent
+	// directly
+	query := url.Values{}
+	query.Set("type", msgType)
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.FormatInt(opts.Limit, 10))
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	} else if opts.Offset > 0 {
+		query.Set("offset", strconv.FormatInt(opts.Offset, 10))
+	}
+
+	resp, err := c.getMessagesPage(ctx, sessionID, query)
+	if err != nil {
+		return nil, err
+	}
+	filtered := resp.Messages[:0]
+	for _, m := range resp.Messages {
+		if m.Type == msgType {
+			filtered = append(filtered, m)
+		}
+	}
+	resp.Messages = filtered
+	return resp, nil
+}
+
+// isKnownMessageType reports whether msgType is one of knownMessageTypes.
+func isKnownMessageType(msgType string) bool {
+	for _, known := range knownMessageTypes {
+		if msgType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// getMessagesPage fetches a page of messages with the given query
+// parameters, bypassing the generated client.
+func (c *Client) getMessagesPage(ctx context.Context, sessionID string, query url.Values) (*MessagesResponse, error) {
+	var body json.RawMessage
+	path := "/sessions/" + url.PathEscape(sessionID) + "/messages"
+	if err := c.doJSON(ctx, http.MethodGet, path, query, nil, &body); err != nil {
+		return nil, err
+	}
+	var payload models.SessionMessagesResponse
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, newError("INVALID_RESPONSE", "failed to decode response", 0, err)
+	}
+	return fromGeneratedMessages(&payload, body), nil
+}
+
+// fromGeneratedMessages converts a messages response, taking the next
+// cursor (which the generated model doesn't declare) from the raw response
+// body. Nil messages are skipped.
+func fromGeneratedMessages(payload *models.SessionMessagesResponse, body []byte) *MessagesResponse {
 	messages := make([]*Message, 0, len(payload.Messages))
 	for _, m := range payload.Messages {
 		if m != nil {
@@ -1080,13 +2288,70 @@ func (c *Client) GetMessages(ctx context.Context, sessionID string, opts *GetMes
 		}
 	}
 
+	var extra struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	_ = json.Unmarshal(body, &extra)
+
 	return &MessagesResponse{
-		Messages: messages,
-		Total:    payload.Total,
-		Limit:    payload.Limit,
-		Offset:   payload.Offset,
-		HasMore:  payload.HasMore,
-	}, nil
+		Messages:   messages,
+		Total:      payload.Total,
+		Limit:      payload.Limit,
+		Offset:     payload.Offset,
+		HasMore:    payload.HasMore,
+		NextCursor: extra.NextCursor,
+	}
+}
+
+// AllMessages returns every message of a session, fetching the pages with
+// [Client.GetMessages].
+//
+// opts sets the page size and where to start (nil starts from the first
+// message with the server's default page size). When the server returns a
+// NextCursor, the next page is fetched with it; otherwise the offset is
+// advanced by the number of messages received. Cursors don't skip or
+// repeat messages appended to the session while paging, offsets may.
+//
+// Example:
+//
+//	messages, err := client.AllMessages(ctx, "sess-abc123", &stromboli.GetMessagesOptions{
+//	    Limit: 200,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("%d messages\n", len(messages))
+func (c *Client) AllMessages(ctx context.Context, sessionID string, opts *GetMessagesOptions) ([]*Message, error) {
+	var page GetMessagesOptions
+	if opts != nil {
+		page = *opts
+	}
+
+	var all []*Message
+	for {
+		resp, err := c.GetMessages(ctx, sessionID, &page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Messages...)
+		if !resp.HasMore {
+			return all, nil
+		}
+
+		switch {
+		case resp.NextCursor != "":
+			if resp.NextCursor == page.Cursor {
+				return nil, newError("INVALID_RESPONSE", "server returned the same cursor twice", 0, nil)
+			}
+			page.Cursor = resp.NextCursor
+		case len(resp.Messages) == 0:
+			// No cursor and no progress: stop rather than loop forever
+			return all, nil
+		default:
+			page.Cursor = ""
+			page.Offset = resp.Offset + int64(len(resp.Messages))
+		}
+	}
 }
 
 // GetMessage returns a specific message from session history by UUID.
@@ -1103,7 +2368,15 @@ func (c *Client) GetMessages(ctx context.Context, sessionID string, opts *GetMes
 //
 //	fmt.Printf("Role: %s\n", msg.Type)
 //	fmt.Printf("Content: %v\n", msg.Content)
-func (c *Client) GetMessage(ctx context.Context, sessionID, messageID string) (*Message, error) {
+func (c *Client) GetMessage(ctx context.Context, sessionID, messageID string) (_ *Message, err error) {
+	ctx, op := c.observe(ctx, "GetMessage")
+	defer op.finish(&err)
+	op.setAttribute(AttributeSessionID, sessionID)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	if sessionID == "" {
 		return nil, newError("BAD_REQUEST", "session ID is required", 400, nil)
 	}
@@ -1113,6 +2386,8 @@ func (c *Client) GetMessage(ctx context.Context, sessionID, messageID string) (*
 
 	// Create request parameters with context
 	params := sessions.NewGetSessionsIDMessagesMessageIDParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetID(sessionID)
@@ -1161,33 +2436,75 @@ func fromGeneratedMessage(m *models.StromboliInternalHistoryMessage) *Message {
 //
 // It handles:
 //   - Network errors (connection refused, timeout, etc.)
-//   - HTTP errors (4xx, 5xx responses)
+//   - HTTP errors (4xx, 5xx responses), translated by the error mapper
+//     (see WithErrorMapper)
 //   - Unexpected response formats
 func (c *Client) handleError(err error, message string) error {
 	if err == nil {
 		return nil
 	}
 
+	// Requests rejected locally during a maintenance window (see
+	// failFastInMaintenance) or by a closed client are returned as-is
+	var maintErr *MaintenanceError
+	if errors.As(err, &maintErr) {
+		return maintErr
+	}
+	if errors.Is(err, ErrClientClosed) {
+		return ErrClientClosed
+	}
+	if tooLarge := responseTooLarge(err); tooLarge != nil {
+		return tooLarge
+	}
+
 	// Check for runtime API errors from go-swagger
 	var apiErr *runtime.APIError
 	if errors.As(err, &apiErr) {
-		return c.handleAPIError(apiErr, message)
+		sdkErr := c.handleAPIError(apiErr, message)
+		if resp, ok := apiErr.Response.(runtime.ClientResponse); ok {
+			c.setRetryAfter(sdkErr, resp.GetHeader("Retry-After"))
+		}
+		return c.mapError(c.maintenanceError(sdkErr))
 	}
 
-	// Check for context cancellation
-	if errors.Is(err, context.Canceled) {
-		return wrapError(err, "CANCELLED", "request was cancelled", 0)
+	// Check for typed error responses from the generated client (responses
+	// declared in the OpenAPI spec, e.g. GetJobsIDNotFound). They carry the
+	// HTTP status via Code() but are not runtime.APIError.
+	var typedErr statusCoder
+	if errors.As(err, &typedErr) {
+		if sdkErr := errorFromBody(typedErr.Code(), typedPayload(typedErr), err); sdkErr != nil {
+			return c.mapError(c.maintenanceError(sdkErr))
+		}
+		return c.mapError(c.maintenanceError(wrapError(err, errorFromStatus(typedErr.Code(), message).Code, message, typedErr.Code())))
 	}
 
-	// Check for context deadline exceeded
-	if errors.Is(err, context.DeadlineExceeded) {
-		return wrapError(err, "TIMEOUT", "request timed out", http.StatusRequestTimeout)
+	// Check for context cancellation and deadline exceeded
+	if ctxErr := contextError(err); ctxErr != nil {
+		return ctxErr
 	}
 
 	// Generic error
 	return wrapError(err, "REQUEST_FAILED", message, 0)
 }
 
+// statusCoder is implemented by the generated client's typed response errors.
+type statusCoder interface {
+	Code() int
+}
+
+// typedPayload returns the JSON encoding of a typed error response's
+// decoded body (its Payload field), or nil if it has none.
+func typedPayload(err statusCoder) []byte {
+	var wrapper struct {
+		Payload json.RawMessage
+	}
+	data, _ := json.Marshal(err)
+	if json.Unmarshal(data, &wrapper) != nil {
+		return nil
+	}
+	return wrapper.Payload
+}
+
 // httpStatusToErrorCode maps HTTP status codes to error codes for table-driven error handling.
 var httpStatusToErrorCode = map[int]string{
 	http.StatusBadRequest:          ErrBadRequest.Code,
@@ -1202,12 +2519,23 @@ var httpStatusToErrorCode = map[int]string{
 }
 
 // handleAPIError converts go-swagger API errors into SDK errors.
+// If the server returned a JSON error body, its code and message are used
+// (see errorFromBody); otherwise the error is derived from the status.
 // It wraps sentinel errors so that errors.Is works consistently.
 // The original server error message is preserved in the Cause chain.
-func (c *Client) handleAPIError(apiErr *runtime.APIError, fallbackMsg string) error {
+func (c *Client) handleAPIError(apiErr *runtime.APIError, fallbackMsg string) *Error {
 	status := apiErr.Code
 
-	// Extract the most useful error message:
+	// Prefer the server's structured error body, if any
+	if resp, ok := apiErr.Response.(runtime.ClientResponse); ok {
+		if body, ok := resp.Body().(*errorBody); ok {
+			if sdkErr := errorFromBody(status, body.head, apiErr); sdkErr != nil {
+				return sdkErr
+			}
+		}
+	}
+
+	// Extract the most useful error message:
 	// 1. Try the API error's message if non-empty
 	// 2. Fall back to the provided fallback message
 	serverMsg := fallbackMsg
@@ -1228,18 +2556,51 @@ func (c *Client) handleAPIError(apiErr *runtime.APIError, fallbackMsg string) er
 	return newError("REQUEST_FAILED", serverMsg, status, apiErr)
 }
 
+// setRetryAfter sets the RetryAfter of err from the value of a Retry-After
+// response header, if it has a valid one.
+func (c *Client) setRetryAfter(err *Error, value string) {
+	if wait, ok := parseRetryAfter(value, c.clock.Now()); ok {
+		err.RetryAfter = wait
+	}
+}
+
 // ----------------------------------------------------------------------------
 // Auth Methods
 // ----------------------------------------------------------------------------
 
-// bearerAuth returns a runtime.ClientAuthInfoWriter for Bearer token auth.
+// tokenKey is the context key of a per-call token (see ContextWithToken).
+type tokenKey struct{}
+
+// ContextWithToken returns a copy of ctx carrying token, which calls made
+// with it send instead of the client's token (see [Client.SetToken]). Use
+// it for per-call credentials, such as tenant-scoped tokens, on a client
+// shared between callers.
+//
+// An empty token leaves the client's token in effect. Like with SetToken, a
+// token containing control characters is ignored with a warning, and ctx
+// is returned unchanged.
+//
+// Example:
+//
+//	ctx := stromboli.ContextWithToken(ctx, tenantToken)
+//	validation, err := client.ValidateToken(ctx) // uses tenantToken
+func ContextWithToken(ctx context.Context, token string) context.Context {
+	if !isValidToken(token) {
+		getLogger().Printf("stromboli: WARNING: ContextWithToken called with invalid token (contains control characters), ignoring")
+		return ctx
+	}
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// bearerAuth returns a runtime.ClientAuthInfoWriter for Bearer token auth,
+// for a request made with ctx.
 //
 // The token is read at the time the request is authenticated, not when
 // this method is called. This ensures the most current token is used,
 // which is important if SetToken is called between method calls.
-func (c *Client) bearerAuth() runtime.ClientAuthInfoWriter {
+func (c *Client) bearerAuth(ctx context.Context) runtime.ClientAuthInfoWriter {
 	return runtime.ClientAuthInfoWriterFunc(func(r runtime.ClientRequest, _ strfmt.Registry) error {
-		token := c.getToken() // Read at write time
+		token := c.tokenFor(ctx) // Read at write time
 		if token != "" {
 			return r.SetHeaderParam("Authorization", "Bearer "+token)
 		}
@@ -1254,10 +2615,20 @@ func (c *Client) getToken() string {
 	return c.token
 }
 
+// tokenFor returns the token of a request made with ctx: the token set
+// with ContextWithToken, if any, or else the client's token.
+func (c *Client) tokenFor(ctx context.Context) string {
+	if token, _ := ctx.Value(tokenKey{}).(string); token != "" {
+		return token
+	}
+	return c.getToken()
+}
+
 // SetToken sets the Bearer token for authenticated requests.
 //
 // This token is used for endpoints that require authentication,
-// such as [Client.ValidateToken] and [Client.Logout].
+// such as [Client.ValidateToken] and [Client.Logout], except by calls
+// whose context carries a token of its own (see [ContextWithToken]).
 // SetToken is safe for concurrent use.
 //
 // # Token Validation
@@ -1286,7 +2657,7 @@ func (c *Client) SetToken(token string) {
 	// Validate token to prevent HTTP header injection via CR/LF characters.
 	// Empty string is valid (clears token), but non-empty tokens must be safe.
 	if token != "" && !isValidToken(token) {
-		getLogger().Printf("stromboli: WARNING: SetToken called with invalid token (contains control characters), ignoring")
+		c.logf(slog.LevelWarn, "SetToken called with invalid token (contains control characters), ignoring")
 		return
 	}
 	c.mu.Lock()
@@ -1324,13 +2695,22 @@ func (c *Client) ClearToken() {
 //
 //	// Token expires in tokens.ExpiresIn seconds
 //	fmt.Printf("Token expires in %d seconds\n", tokens.ExpiresIn)
-func (c *Client) GetToken(ctx context.Context, clientID string) (*TokenResponse, error) {
+func (c *Client) GetToken(ctx context.Context, clientID string) (_ *TokenResponse, err error) {
+	ctx, op := c.observe(ctx, "GetToken")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	if clientID == "" {
 		return nil, newError("BAD_REQUEST", "client ID is required", 400, nil)
 	}
 
 	// Create request parameters
 	params := auth.NewPostAuthTokenParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetRequest(&models.TokenRequest{
@@ -1339,7 +2719,7 @@ func (c *Client) GetToken(ctx context.Context, clientID string) (*TokenResponse,
 
 	// Execute request - GetToken uses bearerAuth which only sets header if token exists.
 	// This allows the endpoint to work both with and without prior authentication.
-	resp, err := c.api.Auth.PostAuthToken(params, c.bearerAuth())
+	resp, err := c.api.Auth.PostAuthToken(params, c.bearerAuth(ctx))
 	if err != nil {
 		return nil, c.handleError(err, "failed to get token")
 	}
@@ -1373,13 +2753,22 @@ func (c *Client) GetToken(ctx context.Context, clientID string) (*TokenResponse,
 //	}
 //
 //	client.SetToken(newTokens.AccessToken)
-func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (*TokenResponse, error) {
+func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (_ *TokenResponse, err error) {
+	ctx, op := c.observe(ctx, "RefreshToken")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	if refreshToken == "" {
 		return nil, newError("BAD_REQUEST", "refresh token is required", 400, nil)
 	}
 
 	// Create request parameters
 	params := auth.NewPostAuthRefreshParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetRequest(&models.RefreshRequest{
@@ -1423,18 +2812,27 @@ func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (*TokenR
 //	    fmt.Printf("Token valid for subject: %s\n", validation.Subject)
 //	    fmt.Printf("Expires at: %d\n", validation.ExpiresAt)
 //	}
-func (c *Client) ValidateToken(ctx context.Context) (*TokenValidation, error) {
-	if c.getToken() == "" {
+func (c *Client) ValidateToken(ctx context.Context) (_ *TokenValidation, err error) {
+	ctx, op := c.observe(ctx, "ValidateToken")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if c.tokenFor(ctx) == "" {
 		return nil, newError("UNAUTHORIZED", "no token set, use SetToken() first", 401, nil)
 	}
 
 	// Create request parameters
 	params := auth.NewGetAuthValidateParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 
 	// Execute request with bearer auth
-	resp, err := c.api.Auth.GetAuthValidate(params, c.bearerAuth())
+	resp, err := c.api.Auth.GetAuthValidate(params, c.bearerAuth(ctx))
 	if err != nil {
 		return nil, c.handleError(err, "failed to validate token")
 	}
@@ -1470,18 +2868,27 @@ func (c *Client) ValidateToken(ctx context.Context) (*TokenValidation, error) {
 //	    fmt.Println("Successfully logged out")
 //	    client.SetToken("") // Clear the token
 //	}
-func (c *Client) Logout(ctx context.Context) (*LogoutResponse, error) {
-	if c.getToken() == "" {
+func (c *Client) Logout(ctx context.Context) (_ *LogoutResponse, err error) {
+	ctx, op := c.observe(ctx, "Logout")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if c.tokenFor(ctx) == "" {
 		return nil, newError("UNAUTHORIZED", "no token set, use SetToken() first", 401, nil)
 	}
 
 	// Create request parameters
 	params := auth.NewPostAuthLogoutParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 
 	// Execute request with bearer auth
-	resp, err := c.api.Auth.PostAuthLogout(params, c.bearerAuth())
+	resp, err := c.api.Auth.PostAuthLogout(params, c.bearerAuth(ctx))
 	if err != nil {
 		return nil, c.handleError(err, "failed to logout")
 	}
@@ -1530,9 +2937,18 @@ func (c *Client) Logout(ctx context.Context) (*LogoutResponse, error) {
 //	        },
 //	    },
 //	})
-func (c *Client) ListSecrets(ctx context.Context) ([]*Secret, error) {
+func (c *Client) ListSecrets(ctx context.Context) (_ []*Secret, err error) {
+	ctx, op := c.observe(ctx, "ListSecrets")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	// Create request parameters
 	params := secrets.NewGetSecretsParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 
@@ -1589,12 +3005,25 @@ func (c *Client) ListSecrets(ctx context.Context) ([]*Secret, error) {
 //	if errors.Is(err, stromboli.ErrSecretExists) {
 //	    fmt.Println("Secret already exists")
 //	}
-func (c *Client) CreateSecret(ctx context.Context, req *CreateSecretRequest) error {
+//
+// Names must follow Podman's rules: at most 253 letters, digits, '-', '_'
+// and '.', starting and ending with a letter or digit, and not only
+// digits. Other names return [ErrInvalidSecretName] without a request;
+// [Client.GetSecret], [Client.DeleteSecret] and [Client.UpdateSecret]
+// check names the same way.
+func (c *Client) CreateSecret(ctx context.Context, req *CreateSecretRequest) (err error) {
+	ctx, op := c.observe(ctx, "CreateSecret")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return err
+	}
+
 	if req == nil {
 		return newError("BAD_REQUEST", "request is required", 400, nil)
 	}
-	if req.Name == "" {
-		return newError("BAD_REQUEST", "secret name is required", 400, nil)
+	if err := validateSecretName(req.Name); err != nil {
+		return err
 	}
 	if req.Value == "" {
 		return newError("BAD_REQUEST", "secret value is required", 400, nil)
@@ -1602,6 +3031,8 @@ func (c *Client) CreateSecret(ctx context.Context, req *CreateSecretRequest) err
 
 	// Create request parameters
 	params := secrets.NewPostSecretsParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetRequest(&models.CreateSecretRequest{
@@ -1612,7 +3043,12 @@ func (c *Client) CreateSecret(ctx context.Context, req *CreateSecretRequest) err
 	// Execute request
 	resp, err := c.api.Secrets.PostSecrets(params)
 	if err != nil {
-		// Check for conflict (secret already exists)
+		// Check for conflict (secret already exists). The 409 response is
+		// declared in the API spec, so it arrives as a typed error.
+		var conflict *secrets.PostSecretsConflict
+		if errors.As(err, &conflict) {
+			return ErrSecretExists
+		}
 		var apiErr *runtime.APIError
 		if errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict {
 			return ErrSecretExists
@@ -1657,13 +3093,22 @@ func (c *Client) CreateSecret(ctx context.Context, req *CreateSecretRequest) err
 //	if errors.Is(err, stromboli.ErrNotFound) {
 //	    fmt.Println("Secret not found")
 //	}
-func (c *Client) GetSecret(ctx context.Context, name string) (*Secret, error) {
-	if name == "" {
-		return nil, newError("BAD_REQUEST", "secret name is required", 400, nil)
+func (c *Client) GetSecret(ctx context.Context, name string) (_ *Secret, err error) {
+	ctx, op := c.observe(ctx, "GetSecret")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := validateSecretName(name); err != nil {
+		return nil, err
 	}
 
 	// Create request parameters
 	params := secrets.NewGetSecretsNameParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetName(name)
@@ -1711,19 +3156,28 @@ func (c *Client) GetSecret(ctx context.Context, name string) (*Secret, error) {
 //	if errors.Is(err, stromboli.ErrNotFound) {
 //	    fmt.Println("Secret not found")
 //	}
-func (c *Client) DeleteSecret(ctx context.Context, name string) error {
-	if name == "" {
-		return newError("BAD_REQUEST", "secret name is required", 400, nil)
+func (c *Client) DeleteSecret(ctx context.Context, name string) (err error) {
+	ctx, op := c.observe(ctx, "DeleteSecret")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return err
+	}
+
+	if err := validateSecretName(name); err != nil {
+		return err
 	}
 
 	// Create request parameters
 	params := secrets.NewDeleteSecretsNameParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetName(name)
 
 	// Execute request
-	_, err := c.api.Secrets.DeleteSecretsName(params)
+	_, err = c.api.Secrets.DeleteSecretsName(params)
 	if err != nil {
 		// Check for not found
 		var apiErr *runtime.APIError
@@ -1736,6 +3190,167 @@ func (c *Client) DeleteSecret(ctx context.Context, name string) error {
 	return nil
 }
 
+// UpdateSecret replaces the value of an existing Podman secret.
+//
+// Use this method to rotate credentials without deleting the secret first.
+// The name and value are validated exactly like [Client.CreateSecret].
+//
+// The SDK first tries an atomic replace (PUT /secrets/{name}). If the server
+// does not support that endpoint (405 Method Not Allowed or 501 Not
+// Implemented), it falls back to deleting and recreating the secret. The
+// fallback is NOT atomic: there is a short window where the secret does not
+// exist, and because the API never returns secret values, the original value
+// cannot be restored if the recreate fails. In that case the returned error
+// has code "SECRET_UPDATE_FAILED" and wraps the underlying create error.
+//
+// Example:
+//
+//	err := client.UpdateSecret(ctx, &stromboli.CreateSecretRequest{
+//	    Name:  "github-token",
+//	    Value: "ghp_new...",
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Returns [ErrNotFound] if the secret doesn't exist:
+//
+//	err := client.UpdateSecret(ctx, req)
+//	if errors.Is(err, stromboli.ErrNotFound) {
+//	    fmt.Println("Secret not found, create it first")
+//	}
+func (c *Client) UpdateSecret(ctx context.Context, req *CreateSecretRequest) (err error) {
+	ctx, op := c.observe(ctx, "UpdateSecret")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return err
+	}
+
+	if req == nil {
+		return newError("BAD_REQUEST", "request is required", 400, nil)
+	}
+	if err := validateSecretName(req.Name); err != nil {
+		return err
+	}
+	if req.Value == "" {
+		return newError("BAD_REQUEST", "secret value is required", 400, nil)
+	}
+
+	err = c.doJSON(ctx, http.MethodPut, "/secrets/"+url.PathEscape(req.Name), nil, req, nil)
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Status {
+		case http.StatusNotFound:
+			return ErrNotFound
+		case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			return c.replaceSecret(ctx, req)
+		}
+	}
+	return err
+}
+
+// EnsureSecret creates a secret, or updates its value if it already exists.
+//
+// Use this method to provision credentials idempotently, e.g. on every
+// deployment. It first tries [Client.CreateSecret]; if the server reports
+// [ErrSecretExists], it updates the value with [Client.UpdateSecret]. The
+// name and value are validated exactly like CreateSecret.
+//
+// Example:
+//
+//	err := client.EnsureSecret(ctx, &stromboli.CreateSecretRequest{
+//	    Name:  "github-token",
+//	    Value: os.Getenv("GH_TOKEN"),
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func (c *Client) EnsureSecret(ctx context.Context, req *CreateSecretRequest) error {
+	err := c.CreateSecret(ctx, req)
+	if errors.Is(err, ErrSecretExists) {
+		return c.UpdateSecret(ctx, req)
+	}
+	return err
+}
+
+// replaceSecret updates a secret on servers without an atomic replace endpoint
+// by deleting and recreating it.
+func (c *Client) replaceSecret(ctx context.Context, req *CreateSecretRequest) error {
+	// Make sure the secret exists so a missing secret maps to ErrNotFound
+	// instead of silently creating it.
+	if _, err := c.GetSecret(ctx, req.Name); err != nil {
+		return err
+	}
+
+	if err := c.DeleteSecret(ctx, req.Name); err != nil {
+		return err
+	}
+
+	if err := c.CreateSecret(ctx, req); err != nil {
+		return wrapError(err, "SECRET_UPDATE_FAILED",
+			fmt.Sprintf("secret %q was deleted but could not be recreated", req.Name), 500)
+	}
+	return nil
+}
+
+// maxSecretNameLength is the maximum length of a Podman secret name.
+const maxSecretNameLength = 253
+
+// validateSecretName checks name against Podman's secret name rules, so
+// that invalid names fail before any request instead of with an opaque
+// server error. It returns a BAD_REQUEST error if name is empty, and an
+// [ErrInvalidSecretName] error naming the violated rule otherwise.
+func validateSecretName(name string) error {
+	if name == "" {
+		return newError("BAD_REQUEST", "secret name is required", 400, nil)
+	}
+	if violation := secretNameViolation(name); violation != "" {
+		return newError(ErrInvalidSecretName.Code, fmt.Sprintf("invalid secret name %q: %s", name, violation), 400, nil)
+	}
+	return nil
+}
+
+// secretNameViolation returns the Podman rule that name violates, or "" if
+// it is valid: 1 to 253 ASCII letters, digits, '-', '_' and '.', starting
+// and ending with a letter or digit, and not only digits.
+func secretNameViolation(name string) string {
+	if len(name) > maxSecretNameLength {
+		return fmt.Sprintf("must be at most %d characters (got %d)", maxSecretNameLength, len(name))
+	}
+	digitsOnly := true
+	for _, r := range name {
+		switch {
+		case r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			digitsOnly = false
+		case r == '-' || r == '_' || r == '.':
+			digitsOnly = false
+		default:
+			return fmt.Sprintf("contains %q; only letters, digits, '-', '_' and '.' are allowed", r)
+		}
+	}
+	if !isAlphanumeric(name[0]) {
+		return "must start with a letter or digit"
+	}
+	if !isAlphanumeric(name[len(name)-1]) {
+		return "must end with a letter or digit"
+	}
+	if digitsOnly {
+		return "must not consist only of digits"
+	}
+	return ""
+}
+
+// isAlphanumeric reports whether b is an ASCII letter or digit.
+func isAlphanumeric(b byte) bool {
+	return b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
 // ----------------------------------------------------------------------------
 // Images Methods
 // ----------------------------------------------------------------------------
@@ -1758,9 +3373,19 @@ func (c *Client) DeleteSecret(ctx context.Context, name string) error {
 //	    fmt.Printf("%s:%s (rank %d, compatible: %v)\n",
 //	        img.Repository, img.Tag, img.CompatibilityRank, img.Compatible)
 //	}
-func (c *Client) ListImages(ctx context.Context) ([]*Image, error) {
+func (c *Client) ListImages(ctx context.Context, opts ...CallOption) (_ []*Image, err error) {
+	ctx, op := c.observe(ctx, "ListImages")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	// Create request parameters
 	params := images.NewGetImagesParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 
@@ -1787,6 +3412,80 @@ func (c *Client) ListImages(ctx context.Context) ([]*Image, error) {
 	return result, nil
 }
 
+// ListCompatibleImages returns the local container images compatible with
+// Stromboli (see [Image.Compatible]), in the server's compatibility rank
+// order.
+//
+// Example:
+//
+//	images, err := client.ListCompatibleImages(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, img := range images {
+//	    fmt.Printf("%s:%s (rank %d)\n", img.Repository, img.Tag, img.CompatibilityRank)
+//	}
+func (c *Client) ListCompatibleImages(ctx context.Context) (_ []*Image, err error) {
+	ctx, op := c.observe(ctx, "ListCompatibleImages")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	all, err := c.ListImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	compatible := make([]*Image, 0, len(all))
+	for _, img := range all {
+		if img.Compatible {
+			compatible = append(compatible, img)
+		}
+	}
+	return compatible, nil
+}
+
+// BestImage returns the best ranked local image that is compatible with
+// Stromboli and has the Claude CLI pre-installed, e.g. to pick an image
+// when the caller doesn't care which one. Of images with the same rank,
+// the first listed by the server wins.
+//
+// Returns [ErrImageNotFound] if no image qualifies.
+//
+// Example:
+//
+//	image, err := client.BestImage(ctx)
+//	if errors.Is(err, stromboli.ErrImageNotFound) {
+//	    // Pull an image with the Claude CLI first
+//	}
+//	req.Podman = &stromboli.PodmanOptions{Image: image.Repository + ":" + image.Tag}
+func (c *Client) BestImage(ctx context.Context) (_ *Image, err error) {
+	ctx, op := c.observe(ctx, "BestImage")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	compatible, err := c.ListCompatibleImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *Image
+	for _, img := range compatible {
+		if img.HasClaudeCLI && (best == nil || img.CompatibilityRank < best.CompatibilityRank) {
+			best = img
+		}
+	}
+	if best == nil {
+		return nil, newError(ErrImageNotFound.Code, "no compatible image with the Claude CLI", ErrImageNotFound.Status, nil)
+	}
+	return best, nil
+}
+
 // GetImage returns detailed information about a specific container image.
 //
 // This includes all labels, compatibility information, and available tools.
@@ -1808,13 +3507,33 @@ func (c *Client) ListImages(ctx context.Context) ([]*Image, error) {
 //	if errors.Is(err, stromboli.ErrImageNotFound) {
 //	    fmt.Println("Image not found")
 //	}
-func (c *Client) GetImage(ctx context.Context, name string) (*Image, error) {
+//
+// The name is looked up as given, so "python:3.12" and
+// "docker.io/library/python:3.12" may not match the same image; use
+// [ParseImageRef] and [ImageRef.String] to pass a consistent form.
+//
+// With [WithImageCache], results are cached by name; use
+// [Client.ImageChanged] to detect that an image was re-pulled.
+func (c *Client) GetImage(ctx context.Context, name string, opts ...CallOption) (_ *Image, err error) {
+	ctx, op := c.observe(ctx, "GetImage")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	if name == "" {
 		return nil, newError("BAD_REQUEST", "image name is required", 400, nil)
 	}
+	if image := c.cachedImage(ctx, name); image != nil {
+		return image, nil
+	}
 
 	// Create request parameters
 	params := images.NewGetImagesNameParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetName(name)
@@ -1827,7 +3546,13 @@ func (c *Client) GetImage(ctx context.Context, name string) (*Image, error) {
 		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
 			return nil, ErrImageNotFound
 		}
-		return nil, c.handleError(err, "failed to get image")
+		err = c.handleError(err, "failed to get image")
+		// Typed 404 responses are mapped to ErrNotFound; keep it as the
+		// cause so both sentinels match
+		if errors.Is(err, ErrNotFound) {
+			return nil, newError(ErrImageNotFound.Code, ErrImageNotFound.Message, http.StatusNotFound, err)
+		}
+		return nil, err
 	}
 
 	// Convert response
@@ -1836,7 +3561,9 @@ func (c *Client) GetImage(ctx context.Context, name string) (*Image, error) {
 		return nil, newError("INVALID_RESPONSE", "empty image response", 0, nil)
 	}
 
-	return fromGeneratedImageDetail(payload), nil
+	image := fromGeneratedImageDetail(payload)
+	c.images.store(name, image, c.clock.Now())
+	return image, nil
 }
 
 // SearchImages searches container registries for images matching the query.
@@ -1857,13 +3584,32 @@ func (c *Client) GetImage(ctx context.Context, name string) (*Image, error) {
 //	    fmt.Printf("%s: %s (stars: %d, official: %v)\n",
 //	        r.Name, r.Description, r.Stars, r.Official)
 //	}
-func (c *Client) SearchImages(ctx context.Context, opts *SearchImagesOptions) ([]*ImageSearchResult, error) {
+//
+// With Offset or Deduplicate set, results are fetched with
+// [Client.SearchImagesPage] and the page's results are returned.
+func (c *Client) SearchImages(ctx context.Context, opts *SearchImagesOptions) (_ []*ImageSearchResult, err error) {
+	ctx, op := c.observe(ctx, "SearchImages")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	if opts == nil || opts.Query == "" {
 		return nil, newError("BAD_REQUEST", "search query is required", 400, nil)
 	}
+	if opts.Offset != 0 || opts.Deduplicate {
+		page, err := c.SearchImagesPage(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return page.Results, nil
+	}
 
 	// Create request parameters
 	params := images.NewGetImagesSearchParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 	params.SetQ(opts.Query)
@@ -1905,6 +3651,194 @@ func (c *Client) SearchImages(ctx context.Context, opts *SearchImagesOptions) ([
 	return results, nil
 }
 
+// SearchImagesPage is like [Client.SearchImages] but returns a page of
+// results, with HasMore set if more results can be fetched by advancing
+// opts.Offset.
+//
+// Offset and Limit are sent to the server, with one extra result
+// requested to tell whether more follow. If the server ignores them and
+// returns every result, they are applied client-side. With Deduplicate,
+// duplicates are merged within the page, which may then hold fewer than
+// Limit results.
+//
+// Returns a BAD_REQUEST error if the query is empty or Limit or Offset is
+// negative.
+//
+// Example:
+//
+//	page, err := client.SearchImagesPage(ctx, &stromboli.SearchImagesOptions{
+//	    Query:       "python",
+//	    Limit:       10,
+//	    Offset:      20,
+//	    Deduplicate: true,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, r := range page.Results {
+//	    fmt.Printf("%s (%d stars)\n", r.Name, r.Stars)
+//	}
+func (c *Client) SearchImagesPage(ctx context.Context, opts *SearchImagesOptions) (_ *SearchResultsPage, err error) {
+	ctx, op := c.observe(ctx, "SearchImagesPage")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if opts == nil || opts.Query == "" {
+		return nil, newError("BAD_REQUEST", "search query is required", 400, nil)
+	}
+	if opts.Limit < 0 || opts.Offset < 0 {
+		return nil, newError("BAD_REQUEST", "limit and offset must not be negative", 400, nil)
+	}
+
+	query := url.Values{}
+	query.Set("q", opts.Query)
+	if opts.Limit > 0 {
+		// One more than requested, to tell whether more results follow
+		query.Set("limit", strconv.FormatInt(opts.Limit+1, 10))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.FormatInt(opts.Offset, 10))
+	}
+	if opts.NoTrunc {
+		query.Set("no_trunc", "true")
+	}
+
+	var payload struct {
+		Results []*ImageSearchResult `json:"results"`
+		HasMore bool                 `json:"has_more"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/images/search", query, nil, &payload); err != nil {
+		return nil, err
+	}
+
+	results := make([]*ImageSearchResult, 0, len(payload.Results))
+	for _, r := range payload.Results {
+		if r != nil {
+			results = append(results, r)
+		}
+	}
+
+	// Fall back to client-side pagination if the server ignored it
+	if opts.Limit > 0 && int64(len(results)) > opts.Limit+1 {
+		results = paginate(results, opts.Offset, opts.Limit+1)
+	}
+
+	page := &SearchResultsPage{Offset: opts.Offset, Limit: opts.Limit, HasMore: payload.HasMore}
+	if opts.Limit > 0 && int64(len(results)) > opts.Limit {
+		results = results[:opts.Limit]
+		page.HasMore = true
+	}
+	if opts.Deduplicate {
+		results = deduplicateImages(results)
+	}
+	page.Results = results
+
+	return page, nil
+}
+
+// searchAllPageSize is the page size of [Client.SearchImagesAll].
+const searchAllPageSize = 100
+
+// SearchImagesAll returns up to maxResults search results for query,
+// fetching the pages with [Client.SearchImagesPage].
+//
+// Paging stops when maxResults results are gathered, or when the registry
+// runs out: the server reports no more results, a page holds fewer results
+// than requested, or a page only repeats results already seen. A result
+// returned by several pages (e.g. when results shift while paging) is kept
+// once, identified by its Name and Index; unlike
+// [SearchImagesOptions.Deduplicate], the same image found in different
+// registries is kept for each registry.
+//
+// Returns a BAD_REQUEST error if the query is empty or maxResults is not
+// positive.
+//
+// Example:
+//
+//	results, err := client.SearchImagesAll(ctx, "python", 500)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("%d images\n", len(results))
+func (c *Client) SearchImagesAll(ctx context.Context, query string, maxResults int) ([]*ImageSearchResult, error) {
+	if maxResults <= 0 {
+		return nil, newError("BAD_REQUEST", "max results must be positive", 400, nil)
+	}
+
+	page := SearchImagesOptions{Query: query, Limit: int64(min(maxResults, searchAllPageSize))}
+	seen := make(map[string]bool)
+	var all []*ImageSearchResult
+	for {
+		resp, err := c.SearchImagesPage(ctx, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		added := 0
+		for _, r := range resp.Results {
+			key := r.Index + "\x00" + r.Name
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			all = append(all, r)
+			added++
+			if len(all) == maxResults {
+				return all, nil
+			}
+		}
+
+		// A short page is the last one, even if the server claims more
+		if !resp.HasMore || int64(len(resp.Results)) < page.Limit || added == 0 {
+			return all, nil
+		}
+		page.Offset += int64(len(resp.Results))
+	}
+}
+
+// deduplicateImages merges results with the same normalized name (see
+// normalizeImageName), keeping the one with the most stars (the first one
+// on ties), and sorts them by stars, most starred first. The sort is
+// stable, so results with as many stars keep their order.
+func deduplicateImages(results []*ImageSearchResult) []*ImageSearchResult {
+	index := make(map[string]int, len(results))
+	deduped := make([]*ImageSearchResult, 0, len(results))
+	for _, r := range results {
+		name := normalizeImageName(r.Name)
+		if i, ok := index[name]; ok {
+			if r.Stars > deduped[i].Stars {
+				deduped[i] = r
+			}
+			continue
+		}
+		index[name] = len(deduped)
+		deduped = append(deduped, r)
+	}
+	sort.SliceStable(deduped, func(i, j int) bool {
+		return deduped[i].Stars > deduped[j].Stars
+	})
+	return deduped
+}
+
+// normalizeImageName returns the name of an image without its registry,
+// the implicit "library/" namespace and its tag, in lower case, so that
+// "docker.io/library/python" and "mirror.gcr.io/python:3" are the same
+// image.
+func normalizeImageName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	if first, rest, ok := strings.Cut(name, "/"); ok &&
+		(strings.ContainsAny(first, ".:") || first == "localhost") {
+		name = rest
+	}
+	return strings.TrimPrefix(name, "library/")
+}
+
 // PullImage pulls a container image from a registry.
 //
 // This operation may take some time for large images.
@@ -1922,7 +3856,15 @@ func (c *Client) SearchImages(ctx context.Context, opts *SearchImagesOptions) ([
 //	if result.Success {
 //	    fmt.Printf("Pulled image %s (ID: %s)\n", result.Image, result.ImageID)
 //	}
-func (c *Client) PullImage(ctx context.Context, req *PullImageRequest) (*PullImageResponse, error) {
+func (c *Client) PullImage(ctx context.Context, req *PullImageRequest, opts ...CallOption) (_ *PullImageResponse, err error) {
+	ctx, op := c.observe(ctx, "PullImage")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	if req == nil {
 		return nil, newError("BAD_REQUEST", "request is required", 400, nil)
 	}
@@ -1932,6 +3874,8 @@ func (c *Client) PullImage(ctx context.Context, req *PullImageRequest) (*PullIma
 
 	// Create request parameters
 	params := images.NewPostImagesPullParams()
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
 	params.SetContext(ctx)
 	params.SetTimeout(c.effectiveTimeout(ctx))
 
@@ -1944,6 +3888,7 @@ func (c *Client) PullImage(ctx context.Context, req *PullImageRequest) (*PullIma
 
 	// Execute request
 	resp, err := c.api.Images.PostImagesPull(params)
+	c.images.invalidate(req.Image) // the pull may have started even if it failed
 	if err != nil {
 		return nil, c.handleError(err, "failed to pull image")
 	}
@@ -1961,6 +3906,67 @@ func (c *Client) PullImage(ctx context.Context, req *PullImageRequest) (*PullIma
 	}, nil
 }
 
+// DeleteImage removes a local container image.
+//
+// By default the server refuses to remove an image that is used by a
+// container, even a stopped one. Set [DeleteImageOptions.Force] to remove it
+// anyway. A nil opts is equivalent to an empty DeleteImageOptions.
+//
+// Example:
+//
+//	err := client.DeleteImage(ctx, "python:3.12-slim", nil)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Returns [ErrImageNotFound] if the image doesn't exist locally, and an
+// error with code CONFLICT if the image is in use and Force is false:
+//
+//	err := client.DeleteImage(ctx, "python:3.12-slim", nil)
+//	var apiErr *stromboli.Error
+//	if errors.As(err, &apiErr) && apiErr.Code == "CONFLICT" {
+//	    err = client.DeleteImage(ctx, "python:3.12-slim", &stromboli.DeleteImageOptions{Force: true})
+//	}
+func (c *Client) DeleteImage(ctx context.Context, name string, opts *DeleteImageOptions) (err error) {
+	ctx, op := c.observe(ctx, "DeleteImage")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return err
+	}
+
+	if name == "" {
+		return newError("BAD_REQUEST", "image name is required", 400, nil)
+	}
+
+	query := url.Values{}
+	if opts != nil && opts.Force {
+		query.Set("force", "true")
+	}
+
+	// The generated client has no DELETE /images/{name} operation yet
+	err = c.doJSON(ctx, http.MethodDelete, "/images/"+url.PathEscape(name), query, nil, nil)
+	c.images.invalidate(name)
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Status {
+		case http.StatusNotFound:
+			return ErrImageNotFound
+		case http.StatusConflict:
+			// Keep the server's message but report a stable code, even if
+			// the server sent a more specific one (e.g. IMAGE_IN_USE).
+			if apiErr.Code != "CONFLICT" {
+				return newError("CONFLICT", apiErr.Message, apiErr.Status, apiErr)
+			}
+		}
+	}
+	return err
+}
+
 // fromGeneratedImage converts a generated ImageInfoResponse to our Image type.
 func fromGeneratedImage(img *models.ImageInfoResponse) *Image {
 	return &Image{
@@ -1993,6 +3999,127 @@ func fromGeneratedImageDetail(img *models.ImageDetailResponse) *Image {
 	}
 }
 
+// validateRunRequest runs the client-side checks for a [RunRequest].
+//
+// Each failing check is passed through [Client.applyValidationMode], so
+// depending on the configured [ValidationMode] it either aborts the request,
+// is logged as a warning, or is ignored. Every check runs in Warn mode so all
+// problems are reported, not just the first one.
+func (c *Client) validateRunRequest(req *RunRequest, res *resolution) error {
+	for _, err := range c.runRequestChecks(req, res, true) {
+		if err := c.applyValidationMode(err, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runRequestChecks returns the failures of the client-side checks for a
+// [RunRequest], in the order they are checked. Unknown models are only
+// failures in strict model validation mode; otherwise, if warn is set, they
+// are recorded in res as warnings and logged.
+func (c *Client) runRequestChecks(req *RunRequest, res *resolution, warn bool) []error {
+	var errs []error
+
+	// Validate request size limits
+	if err := validateRequestSize(req); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Validate JSON schema if provided
+	if req.Claude != nil && req.Claude.JSONSchema != "" {
+		err := validateJSONSchema(req.Claude.JSONSchema)
+		if err == nil && c.schemaValidator != nil {
+			err = c.schemaValidator(req.Claude.JSONSchema)
+		}
+		if err != nil {
+			errs = append(errs, newError("BAD_REQUEST", fmt.Sprintf("invalid JSON schema: %v", err), 400, nil))
+		}
+	}
+
+	// Validate Resume requires SessionID
+	if req.Claude != nil && req.Claude.Resume && req.Claude.SessionID == "" {
+		errs = append(errs, newError("BAD_REQUEST", "session_id is required when resume is true", 400, nil))
+	}
+
+	// Validate model name. Unknown models are only rejected in strict model
+	// validation mode, since the server may know models the SDK doesn't.
+	if req.Claude != nil && req.Claude.Model != "" && !req.Claude.Model.IsKnown() {
+		if c.strictModelValidation {
+			errs = append(errs, newError("BAD_REQUEST",
+				fmt.Sprintf("unknown model %q (known models: %s)", req.Claude.Model, knownModelList()),
+				400, nil))
+		} else if warn {
+			res.warn(fmt.Sprintf("unknown model %q (known models: %s)", req.Claude.Model, knownModelList()))
+			if c.validationMode != ValidationOff {
+				c.logf(slog.LevelWarn, "unknown model %q (known models: %s), sending anyway",
+					req.Claude.Model, knownModelList())
+			}
+		}
+	}
+
+	// Validate permission mode
+	if req.Claude != nil && req.Claude.PermissionMode != "" && !req.Claude.PermissionMode.IsValid() {
+		errs = append(errs, newError("BAD_REQUEST",
+			fmt.Sprintf("invalid permission mode %q (valid modes: %s)", req.Claude.PermissionMode, permissionModeList()),
+			400, nil))
+	}
+
+	// Validate Podman option formats
+	if err := req.Podman.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// knownModelList returns the known models as a comma-separated list.
+func knownModelList() string {
+	names := make([]string, len(knownModels))
+	for i, m := range knownModels {
+		names[i] = string(m)
+	}
+	return strings.Join(names, ", ")
+}
+
+// permissionModeList returns the valid permission modes as a
+// comma-separated list.
+func permissionModeList() string {
+	names := make([]string, len(knownPermissionModes))
+	for i, p := range knownPermissionModes {
+		names[i] = string(p)
+	}
+	return strings.Join(names, ", ")
+}
+
+// applyValidationMode filters a client-side validation error through the
+// client's [ValidationMode].
+//
+// In ValidationStrict mode the error is returned unchanged. In ValidationWarn
+// mode it is logged via the SDK logger and nil is returned so the request is
+// sent anyway. In ValidationOff mode it is silently dropped. Errors that
+// don't stop the request are recorded in res as warnings.
+func (c *Client) applyValidationMode(err error, res *resolution) error {
+	if err == nil {
+		return nil
+	}
+	switch c.validationMode {
+	case ValidationWarn, ValidationOff:
+	default:
+		return err
+	}
+	message := err.Error()
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		message = apiErr.Message
+	}
+	res.warn(message)
+	if c.validationMode == ValidationWarn {
+		c.logf(slog.LevelWarn, "request validation failed, sending anyway: %v", err)
+	}
+	return nil
+}
+
 // validateRequestSize checks that request fields don't exceed size limits.
 // This prevents memory exhaustion from excessively large requests.
 func validateRequestSize(req *RunRequest) error {
@@ -2016,6 +4143,77 @@ func validateRequestSize(req *RunRequest) error {
 	return nil
 }
 
+// memorySizePattern matches Podman memory sizes: a number with an optional
+// b, k, m or g unit (e.g. "512m", "2g", "1.5g").
+var memorySizePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?[bkmgBKMG]?$`)
+
+// cpuCountPattern matches decimal CPU counts (e.g. "0.5", "2").
+var cpuCountPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// Validate checks the formats of the options that the server passes to
+// Podman, such as Memory, Cpus, Volumes and the timeouts. It returns a
+// BAD_REQUEST [Error] naming the first offending field, or nil if p is nil.
+func (p *PodmanOptions) Validate() error {
+	if p == nil {
+		return nil
+	}
+	return validatePodmanOptions(p)
+}
+
+// validatePodmanOptions checks the formats of Podman options that the server
+// passes to Podman, which would otherwise fail late with an opaque error.
+// The returned BAD_REQUEST [Error] names the offending field.
+func validatePodmanOptions(p *PodmanOptions) error {
+	if p.Memory != "" && !memorySizePattern.MatchString(p.Memory) {
+		return newError("BAD_REQUEST",
+			fmt.Sprintf("invalid podman.memory %q: expected a size such as \"512m\" or \"2g\"", p.Memory),
+			400, nil)
+	}
+	if err := validateDuration("podman.timeout", p.Timeout); err != nil {
+		return err
+	}
+	if p.Cpus != "" {
+		cpus, err := strconv.ParseFloat(p.Cpus, 64)
+		if err != nil || !cpuCountPattern.MatchString(p.Cpus) || cpus <= 0 {
+			return newError("BAD_REQUEST",
+				fmt.Sprintf("invalid podman.cpus %q: expected a positive decimal such as \"0.5\" or \"2\"", p.Cpus),
+				400, nil)
+		}
+	}
+	for i, volume := range p.Volumes {
+		if _, err := parseVolume(volume); err != nil {
+			return newError("BAD_REQUEST",
+				fmt.Sprintf("invalid podman.volumes[%d] %q: %v", i, volume, err),
+				400, nil)
+		}
+	}
+	if p.Lifecycle != nil {
+		if err := validateDuration("podman.lifecycle.hooks_timeout", p.Lifecycle.HooksTimeout); err != nil {
+			return err
+		}
+	}
+	if p.Environment != nil {
+		if err := validateDuration("podman.environment.build_timeout", p.Environment.BuildTimeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateDuration checks that a non-empty value is a non-negative Go
+// duration (e.g. "30s", "5m", "1h30m").
+func validateDuration(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(value); err != nil || d < 0 {
+		return newError("BAD_REQUEST",
+			fmt.Sprintf("invalid %s %q: expected a duration such as \"30s\", \"5m\" or \"1h\"", field, value),
+			400, nil)
+	}
+	return nil
+}
+
 // validateJSONSchema performs MINIMAL validation of a JSON schema string.
 //
 // WARNING: This does NOT validate JSON Schema compliance. It only checks:
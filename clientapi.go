@@ -0,0 +1,124 @@
+package stromboli
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+var _ ClientAPI = (*Client)(nil)
+
+// ClientAPI is the method set of [Client] that talks to the server, so code
+// using the SDK can depend on an interface and inject fakes in tests. It
+// changes in lockstep with Client: every new API method is added to it.
+//
+// It leaves out the methods that configure or derive a client rather than
+// call the API: Clone, NewConversation, AddRequestHook and
+// AddResponseHook.
+//
+// strombolitest.MockClient is a ready-made implementation:
+//
+//	type Reviewer struct {
+//	    Client stromboli.ClientAPI
+//	}
+//
+//	// In production:
+//	reviewer := &Reviewer{Client: client}
+//
+//	// In tests:
+//	mock := &strombolitest.MockClient{
+//	    RunFunc: func(ctx context.Context, req *stromboli.RunRequest, opts ...stromboli.CallOption) (*stromboli.RunResponse, error) {
+//	        return &stromboli.RunResponse{Status: "completed", Output: "LGTM"}, nil
+//	    },
+//	}
+//	reviewer := &Reviewer{Client: mock}
+type ClientAPI interface {
+	// System
+	Health(ctx context.Context, opts ...CallOption) (*HealthResponse, error)
+	WaitUntilHealthy(ctx context.Context, opts *WaitHealthyOptions) (*HealthResponse, error)
+	Ping(ctx context.Context, opts ...CallOption) error
+	ClaudeStatus(ctx context.Context) (*ClaudeStatus, error)
+	Capabilities(ctx context.Context) (*ServerCapabilities, error)
+	MaintenanceUntil() (time.Time, bool)
+	CollectSupportBundle(ctx context.Context, w io.Writer, opts *BundleOptions) error
+	Close() error
+
+	// Execution
+	Run(ctx context.Context, req *RunRequest, opts ...CallOption) (*RunResponse, error)
+	RunAsync(ctx context.Context, req *RunRequest, opts ...CallOption) (*AsyncRunResponse, error)
+	RunBatch(ctx context.Context, reqs []*RunRequest, concurrency int) ([]*RunResponse, []error)
+	RunWithBudget(ctx context.Context, req *RunRequest, bt *BudgetTracker) (*RunResponse, error)
+	Continue(ctx context.Context, prompt, workdir string, opts *ClaudeOptions) (*RunResponse, error)
+	RunWithFallbacks(ctx context.Context, req *RunRequest, models []Model, classify func(*RunResponse, error) bool) (*RunResponse, error)
+	TryRun(ctx context.Context, req *RunRequest) (*RunResponse, error)
+	TrySubmit(ctx context.Context, req *RunRequest) (*AsyncRunResponse, error)
+	InFlightJobs() int
+	ReleaseJob(jobID string)
+	WebhookHandler(fn func(context.Context, *WebhookPayload) error) http.Handler
+	ResolveRequest(ctx context.Context, req *RunRequest) (*ResolvedRequest, error)
+	ValidateRunRequest(req *RunRequest) error
+	Stream(ctx context.Context, req *StreamRequest, opts ...CallOption) (*Stream, error)
+	StreamJob(ctx context.Context, jobID string) (*Stream, error)
+	StreamFanout(ctx context.Context, base *StreamRequest, variants []PodmanOptions, handler func(variantIndex int, ev *StreamEvent), opts *FanoutOptions) ([]*StreamSummary, error)
+
+	// Jobs
+	ListJobs(ctx context.Context, opts ...CallOption) ([]*Job, error)
+	ListJobsFiltered(ctx context.Context, opts *ListJobsOptions) ([]*Job, error)
+	GetJob(ctx context.Context, jobID string, opts ...CallOption) (*Job, error)
+	GetJobInto(ctx context.Context, jobID string, job *Job) error
+	GetJobLogs(ctx context.Context, jobID string, opts *LogsOptions) (io.ReadCloser, error)
+	GetJobLogsString(ctx context.Context, jobID string, opts *LogsOptions) (string, error)
+	CancelJob(ctx context.Context, jobID string, opts ...CallOption) error
+	CancelAllJobs(ctx context.Context) (int, map[string]error)
+
+	// Sessions
+	ListSessions(ctx context.Context) ([]string, error)
+	ListSessionsDetailed(ctx context.Context, opts *ListSessionsOptions) ([]*SessionInfo, error)
+	GetSession(ctx context.Context, sessionID string) (*SessionInfo, error)
+	DestroySession(ctx context.Context, sessionID string) error
+	DestroySessionAndConfirm(ctx context.Context, sessionID string, within time.Duration) error
+	DestroyAllSessions(ctx context.Context, concurrency int) (int, map[string]error)
+	CleanupSessions(ctx context.Context, opts *CleanupOptions) (*CleanupResult, error)
+	GetMessages(ctx context.Context, sessionID string, opts *GetMessagesOptions) (*MessagesResponse, error)
+	GetMessagesByType(ctx context.Context, sessionID string, msgType string, opts *GetMessagesOptions) (*MessagesResponse, error)
+	AllMessages(ctx context.Context, sessionID string, opts *GetMessagesOptions) ([]*Message, error)
+	GetMessage(ctx context.Context, sessionID, messageID string) (*Message, error)
+
+	// Auth
+	SetToken(token string)
+	ClearToken()
+	GetToken(ctx context.Context, clientID string) (*TokenResponse, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*TokenResponse, error)
+	ValidateToken(ctx context.Context) (*TokenValidation, error)
+	Logout(ctx context.Context) (*LogoutResponse, error)
+
+	// Secrets
+	ListSecrets(ctx context.Context) ([]*Secret, error)
+	CreateSecret(ctx context.Context, req *CreateSecretRequest) error
+	CreateSecretFromReader(ctx context.Context, name string, r io.Reader) error
+	CreateSecretFromFile(ctx context.Context, name, path string) error
+	GetSecret(ctx context.Context, name string) (*Secret, error)
+	DeleteSecret(ctx context.Context, name string) error
+	DeleteSecretAndConfirm(ctx context.Context, name string, within time.Duration) error
+	UpdateSecret(ctx context.Context, req *CreateSecretRequest) error
+	EnsureSecret(ctx context.Context, req *CreateSecretRequest) error
+	EnsureSecrets(ctx context.Context, secrets []*CreateSecretRequest) error
+	EnsureSecretsBeforeRun(ctx context.Context, secrets []*CreateSecretRequest, req *RunRequest) (*RunResponse, error)
+	ResolveSecretsEnv(ctx context.Context, env map[string]string) (map[string]string, error)
+
+	// Images
+	ListImages(ctx context.Context, opts ...CallOption) ([]*Image, error)
+	ListCompatibleImages(ctx context.Context) ([]*Image, error)
+	BestImage(ctx context.Context) (*Image, error)
+	GetImage(ctx context.Context, name string, opts ...CallOption) (*Image, error)
+	ImageChanged(ctx context.Context, name string) (bool, error)
+	SearchImages(ctx context.Context, opts *SearchImagesOptions) ([]*ImageSearchResult, error)
+	SearchImagesPage(ctx context.Context, opts *SearchImagesOptions) (*SearchResultsPage, error)
+	SearchImagesAll(ctx context.Context, query string, maxResults int) ([]*ImageSearchResult, error)
+	PullImage(ctx context.Context, req *PullImageRequest, opts ...CallOption) (*PullImageResponse, error)
+	EnsureImage(ctx context.Context, ref string, opts *EnsureImageOptions) (*Image, error)
+	PullImageWithProgress(ctx context.Context, req *PullImageRequest, progress func(*PullProgressEvent), opts ...CallOption) (*PullImageResponse, error)
+	BuildImage(ctx context.Context, req *BuildImageRequest, opts ...CallOption) (*BuildImageResponse, error)
+	DeleteImage(ctx context.Context, name string, opts *DeleteImageOptions) error
+}
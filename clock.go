@@ -0,0 +1,54 @@
+package stromboli
+
+import "time"
+
+// Clock is the source of time for the SDK's time-dependent behavior, such
+// as capability cache expiry and the polling of wait helpers.
+//
+// The default clock uses the time package. Use [WithClock] to inject a fake
+// clock in tests, such as strombolitest.FakeClock, so that such behavior can
+// be tested deterministically without real sleeps.
+//
+// Request timeouts and stream timeouts are enforced through context
+// deadlines and always use real time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the current
+	// time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+
+	// NewTimer creates a Timer that sends the current time on its channel
+	// after at least duration d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event timer created by a [Clock].
+// It mirrors the methods of [time.Timer].
+type Timer interface {
+	// C returns the channel on which the time is delivered.
+	C() <-chan time.Time
+
+	// Stop prevents the Timer from firing. It returns false if the timer
+	// has already expired or been stopped.
+	Stop() bool
+
+	// Reset changes the timer to expire after duration d. It returns true
+	// if the timer had been active.
+	Reset(d time.Duration) bool
+}
+
+// realClock implements Clock using the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+
+// realTimer implements Timer using a time.Timer.
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
@@ -0,0 +1,99 @@
+package stromboli
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// compressionTransport asks for compressed JSON responses and decompresses
+// them (see [WithCompression]).
+type compressionTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *compressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if req.Header.Get("Accept-Encoding") != "" {
+		return base.RoundTrip(req)
+	}
+	// Only negotiate for JSON responses, which are read whole. Streams are
+	// read as they arrive and compression would hold back their events, so
+	// they opt out of the gzip the standard library would ask for.
+	req = req.Clone(req.Context())
+	if !acceptsJSON(req.Header.Get("Accept")) {
+		req.Header.Set("Accept-Encoding", "identity")
+		return base.RoundTrip(req)
+	}
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.Body == nil || resp.Body == http.NoBody {
+		return resp, err
+	}
+
+	var decompress func(io.Reader) (io.ReadCloser, error)
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		decompress = func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }
+	case "deflate":
+		// HTTP's deflate is the zlib format
+		decompress = zlib.NewReader
+	default:
+		return resp, nil
+	}
+	resp.Body = &decompressingReader{body: resp.Body, decompress: decompress}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// acceptsJSON reports whether the Accept header accept asks for JSON.
+func acceptsJSON(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(part)
+		if err == nil && mediaType == "application/json" {
+			return true
+		}
+	}
+	return false
+}
+
+// decompressingReader decompresses a response body on first read, so that
+// responses which are never read (or are empty) don't fail on a missing
+// compression header.
+type decompressingReader struct {
+	body       io.ReadCloser
+	decompress func(io.Reader) (io.ReadCloser, error)
+	reader     io.ReadCloser
+	err        error
+}
+
+// Read reads decompressed data from the body.
+func (r *decompressingReader) Read(p []byte) (int, error) {
+	if r.reader == nil && r.err == nil {
+		r.reader, r.err = r.decompress(r.body)
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.reader.Read(p)
+}
+
+// Close closes the body.
+func (r *decompressingReader) Close() error {
+	if r.reader != nil {
+		_ = r.reader.Close()
+	}
+	return r.body.Close()
+}
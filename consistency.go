@@ -0,0 +1,127 @@
+package stromboli
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Polling intervals used while waiting for a deleted resource to disappear.
+// The interval starts small and doubles up to the maximum.
+const (
+	confirmPollInterval    = 100 * time.Millisecond
+	confirmMaxPollInterval = time.Second
+)
+
+// DestroySessionAndConfirm destroys a session and waits until it no longer
+// appears in [Client.ListSessions].
+//
+// The server's list endpoints are eventually consistent behind a cache, so a
+// session may still be listed for a short time after [Client.DestroySession]
+// succeeds. This is a workaround for that server-side caching: it polls
+// ListSessions until the session is gone or the within window expires, in
+// which case a [StillVisibleError] (matching [ErrStillVisible]) is returned
+// with the last listing.
+//
+// Errors from DestroySession, such as [ErrNotFound], are returned as-is.
+//
+// Example:
+//
+//	err := client.DestroySessionAndConfirm(ctx, "sess-abc123", 5*time.Second)
+//	if errors.Is(err, stromboli.ErrStillVisible) {
+//	    log.Println("session destroyed but still listed; retry later")
+//	}
+func (c *Client) DestroySessionAndConfirm(ctx context.Context, sessionID string, within time.Duration) error {
+	if err := c.DestroySession(ctx, sessionID); err != nil {
+		return err
+	}
+
+	return c.confirmGone(ctx, "session", sessionID, within, func(ctx context.Context) (interface{}, error) {
+		sessions, err := c.ListSessions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range sessions {
+			if id == sessionID {
+				return sessions, nil
+			}
+		}
+		return nil, nil
+	})
+}
+
+// DeleteSecretAndConfirm deletes a secret and waits until
+// [Client.GetSecret] reports it as not found.
+//
+// Like [Client.DestroySessionAndConfirm], this is a workaround for
+// server-side caching: if the secret is still visible when the within window
+// expires, a [StillVisibleError] (matching [ErrStillVisible]) is returned
+// with the last [Secret] observed.
+//
+// Errors from DeleteSecret, such as [ErrNotFound], are returned as-is.
+//
+// Example:
+//
+//	err := client.DeleteSecretAndConfirm(ctx, "github-token", 5*time.Second)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func (c *Client) DeleteSecretAndConfirm(ctx context.Context, name string, within time.Duration) error {
+	if err := c.DeleteSecret(ctx, name); err != nil {
+		return err
+	}
+
+	return c.confirmGone(ctx, "secret", name, within, func(ctx context.Context) (interface{}, error) {
+		secret, err := c.GetSecret(ctx, name)
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return secret, nil
+	})
+}
+
+// confirmGone polls observe until it reports the resource as gone (a nil
+// observation) or the within window expires.
+func (c *Client) confirmGone(
+	ctx context.Context,
+	resource, id string,
+	within time.Duration,
+	observe func(ctx context.Context) (interface{}, error),
+) error {
+	start := c.clock.Now()
+	deadline := start.Add(within)
+	interval := confirmPollInterval
+
+	for {
+		observation, err := observe(ctx)
+		if err != nil {
+			return err
+		}
+		if observation == nil {
+			return nil
+		}
+
+		remaining := deadline.Sub(c.clock.Now())
+		if remaining <= 0 {
+			return &StillVisibleError{
+				Resource:        resource,
+				ID:              id,
+				Waited:          c.clock.Now().Sub(start),
+				LastObservation: observation,
+			}
+		}
+
+		wait := min(interval, remaining)
+		timer := c.clock.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return c.handleError(ctx.Err(), "confirmation cancelled")
+		case <-timer.C():
+		}
+		interval = min(interval*2, confirmMaxPollInterval)
+	}
+}
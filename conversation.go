@@ -0,0 +1,191 @@
+package stromboli
+
+import (
+	"context"
+	"fmt"
+)
+
+// Conversation runs successive prompts in the same Claude session.
+//
+// The first [Conversation.Send] starts a session; later turns resume it by
+// setting Claude.SessionID and Claude.Resume automatically. Create one with
+// [Client.NewConversation].
+//
+// A Conversation is meant for sequential use: it is not safe to call its
+// methods concurrently.
+type Conversation struct {
+	client *Client
+
+	// base holds the options sent with every turn (Prompt is ignored).
+	base RunRequest
+
+	// sessionID is the session to resume ("" until a turn succeeded).
+	sessionID string
+
+	// fork makes the next turn resume sessionID into a new session.
+	fork bool
+}
+
+// NewConversation creates a conversation whose turns are sent with the
+// options of opts (which may be nil). The Prompt of opts is ignored.
+//
+// If opts.Claude.SessionID is set, the conversation continues that session;
+// otherwise the first turn starts a new one. opts is copied, so it can be
+// reused after the call.
+//
+// Example:
+//
+//	conv := client.NewConversation(&stromboli.RunRequest{
+//	    Workdir: "/workspace",
+//	    Claude:  &stromboli.ClaudeOptions{Model: stromboli.ModelSonnet},
+//	})
+//	first, err := conv.Send(ctx, "My name is Alice. Read the README.")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	second, err := conv.Send(ctx, "What's my name?") // resumes the session
+func (c *Client) NewConversation(opts *RunRequest) *Conversation {
+	conv := &Conversation{client: c}
+	if opts != nil {
+		conv.base = *opts
+		conv.base.Prompt = ""
+		if opts.Claude != nil {
+			claude := *opts.Claude
+			conv.base.Claude = &claude
+			conv.sessionID = claude.SessionID
+		}
+	}
+	return conv
+}
+
+// Send runs prompt as the next turn of the conversation with [Client.Run].
+//
+// The session ID of the first response is kept and resumed by the next
+// turns. If the server answers a turn with a different session ID (e.g.
+// after a fork), the conversation follows it.
+//
+// If the turn that would start the session fails, the error matches
+// [ErrNoSession] as well as the underlying error (e.g. [ErrTimeout]), and
+// the next Send starts a new session. Failures of later turns are returned
+// as-is and keep the session.
+func (conv *Conversation) Send(ctx context.Context, prompt string) (*RunResponse, error) {
+	req := conv.base
+	req.Prompt = prompt
+
+	claude := ClaudeOptions{}
+	if conv.base.Claude != nil {
+		claude = *conv.base.Claude
+	}
+	claude.SessionID = conv.sessionID
+	claude.Resume = conv.sessionID != ""
+	claude.ForkSession = claude.ForkSession || conv.fork
+	req.Claude = &claude
+
+	resp, err := conv.client.Run(ctx, &req)
+	if err != nil {
+		if conv.sessionID == "" {
+			return nil, wrapError(err, ErrNoSession.Code, "first turn failed, no session was established", 0)
+		}
+		return nil, err
+	}
+	if resp.SessionID == "" {
+		if conv.sessionID == "" {
+			message := "first turn returned no session ID"
+			if resp.Error != "" {
+				message = fmt.Sprintf("%s: %s", message, resp.Error)
+			}
+			return nil, newError(ErrNoSession.Code, message, 0, nil)
+		}
+		return resp, nil
+	}
+
+	conv.sessionID = resp.SessionID
+	conv.fork = false
+	return resp, nil
+}
+
+// SessionID returns the ID of the conversation's session, or "" if no
+// turn has established one yet.
+func (conv *Conversation) SessionID() string {
+	return conv.sessionID
+}
+
+// Fork returns a new conversation that branches off this one: its first
+// turn resumes the current session with Claude.ForkSession set, so the
+// server gives it a session of its own and later turns of either
+// conversation don't affect the other.
+//
+// Fork doesn't contact the server. It returns an error matching
+// [ErrNoSession] if no session has been established yet, or the context's
+// error if ctx is done.
+//
+// Example:
+//
+//	alt, err := conv.Fork(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	a, _ := conv.Send(ctx, "Implement it with a map")
+//	b, _ := alt.Send(ctx, "Implement it with a slice")
+func (conv *Conversation) Fork(ctx context.Context) (*Conversation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, conv.client.handleError(err, "fork cancelled")
+	}
+	if conv.sessionID == "" {
+		return nil, newError(ErrNoSession.Code, "cannot fork a conversation without a session", 0, nil)
+	}
+
+	fork := *conv
+	if conv.base.Claude != nil {
+		claude := *conv.base.Claude
+		fork.base.Claude = &claude
+	}
+	fork.fork = true
+	return &fork, nil
+}
+
+// Destroy destroys the conversation's session with [Client.DestroySession].
+//
+// After a successful Destroy the conversation has no session, so the next
+// Send starts a new one. It returns an error matching [ErrNoSession] if no
+// session has been established.
+func (conv *Conversation) Destroy(ctx context.Context) error {
+	if conv.sessionID == "" {
+		return newError(ErrNoSession.Code, "conversation has no session to destroy", 0, nil)
+	}
+	if err := conv.client.DestroySession(ctx, conv.sessionID); err != nil {
+		return err
+	}
+	conv.sessionID = ""
+	conv.fork = false
+	return nil
+}
+
+// Continue runs prompt in workdir with [ClaudeOptions.Continue] set, so it
+// resumes the most recent conversation of that workspace instead of a
+// session identified by ID.
+//
+// opts (which may be nil) sets the other Claude options; it is copied, so
+// it is not modified. Continue returns a BAD_REQUEST [Error] if opts also
+// sets SessionID and Resume, since the run would resume two different
+// sessions.
+//
+// Example:
+//
+//	resp, err := client.Continue(ctx, "Now add tests for it", "/workspace", nil)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(resp.Output)
+func (c *Client) Continue(ctx context.Context, prompt, workdir string, opts *ClaudeOptions) (*RunResponse, error) {
+	claude := ClaudeOptions{}
+	if opts != nil {
+		claude = *opts
+	}
+	if claude.SessionID != "" && claude.Resume {
+		return nil, newError("BAD_REQUEST",
+			"continue can't be combined with resuming session_id "+claude.SessionID, 400, nil)
+	}
+	claude.Continue = true
+	return c.Run(ctx, &RunRequest{Prompt: prompt, Workdir: workdir, Claude: &claude})
+}
@@ -0,0 +1,126 @@
+package stromboli
+
+import (
+	"reflect"
+	"strings"
+)
+
+// withDefaults returns req with the client's default Claude and Podman
+// options merged in (see [WithDefaultClaudeOptions]), recording the fields
+// set from the defaults in res. req itself is never modified; it is
+// returned as-is if the client has no defaults or req is nil.
+func (c *Client) withDefaults(req *RunRequest, res *resolution) *RunRequest {
+	if req == nil || (c.defaultClaude == nil && c.defaultPodman == nil) {
+		return req
+	}
+
+	merged := *req
+	if c.defaultClaude != nil {
+		var claude ClaudeOptions
+		if req.Claude != nil {
+			claude = *req.Claude
+		}
+		mergeOptions(reflect.ValueOf(&claude).Elem(), reflect.ValueOf(c.defaultClaude).Elem(), "claude", res)
+		merged.Claude = &claude
+	}
+	if c.defaultPodman != nil {
+		var podman PodmanOptions
+		if req.Podman != nil {
+			podman = *req.Podman
+		}
+		mergeOptions(reflect.ValueOf(&podman).Elem(), reflect.ValueOf(c.defaultPodman).Elem(), "podman", res)
+		merged.Podman = &podman
+	}
+	return &merged
+}
+
+// copyOptions returns a copy of the options struct opts, whose slices and
+// maps aren't shared with opts, or nil if opts is nil.
+func copyOptions[T ClaudeOptions | PodmanOptions](opts *T) *T {
+	if opts == nil {
+		return nil
+	}
+	var out T
+	mergeOptions(reflect.ValueOf(&out).Elem(), reflect.ValueOf(opts).Elem(), "", nil)
+	return &out
+}
+
+// mergeOptions merges the fields of the options struct defaults into dst,
+// an options struct of the same type, following the precedence documented
+// on [WithDefaultClaudeOptions]. Slices and maps are copied, never shared
+// with either struct. Each field set from defaults is recorded in res
+// under prefix and its JSON name.
+func mergeOptions(dst, defaults reflect.Value, prefix string, res *resolution) {
+	for i := 0; i < dst.NumField(); i++ {
+		def := defaults.Field(i)
+		if def.IsZero() {
+			continue
+		}
+		field := dst.Field(i)
+		name := prefix + "." + strings.Split(dst.Type().Field(i).Tag.Get("json"), ",")[0]
+
+		switch field.Kind() {
+		case reflect.Slice:
+			switch {
+			case field.IsNil():
+				field.Set(appendNew(reflect.MakeSlice(field.Type(), 0, def.Len()), def))
+				res.transform(TransformationDefaultApplied, name, "")
+			case field.Len() > 0:
+				merged := reflect.MakeSlice(field.Type(), 0, def.Len()+field.Len())
+				merged = appendNew(merged, def)
+				if volumes, ok := merged.Interface().([]string); ok && isVolumesField(dst.Type(), i) {
+					// A container path is mounted once: the request's
+					// volume replaces the default mounted there.
+					for _, entry := range field.Interface().([]string) {
+						volumes, _ = addVolume(volumes, entry)
+					}
+					merged = reflect.ValueOf(volumes)
+				} else {
+					merged = appendNew(merged, field)
+				}
+				field.Set(merged)
+				res.transform(TransformationDefaultApplied, name, "defaults followed by request values")
+			}
+			// An empty, non-nil slice explicitly clears the defaults
+		case reflect.Map:
+			merged := reflect.MakeMapWithSize(field.Type(), def.Len()+field.Len())
+			for _, src := range []reflect.Value{def, field} {
+				iter := src.MapRange()
+				for iter.Next() {
+					merged.SetMapIndex(iter.Key(), iter.Value())
+				}
+			}
+			if merged.Len() > field.Len() {
+				res.transform(TransformationDefaultApplied, name, "")
+			}
+			field.Set(merged)
+		default:
+			if field.IsZero() {
+				field.Set(def)
+				res.transform(TransformationDefaultApplied, name, "")
+			}
+		}
+	}
+}
+
+// isVolumesField reports whether field i of the options struct type t is
+// [PodmanOptions.Volumes].
+func isVolumesField(t reflect.Type, i int) bool {
+	return t == reflect.TypeOf(PodmanOptions{}) && t.Field(i).Name == "Volumes"
+}
+
+// appendNew appends the elements of src to dst that dst doesn't contain
+// yet, and returns the extended slice.
+func appendNew(dst, src reflect.Value) reflect.Value {
+	for i := 0; i < src.Len(); i++ {
+		elem := src.Index(i)
+		duplicate := false
+		for j := 0; j < dst.Len() && !duplicate; j++ {
+			duplicate = dst.Index(j).Interface() == elem.Interface()
+		}
+		if !duplicate {
+			dst = reflect.Append(dst, elem)
+		}
+	}
+	return dst
+}
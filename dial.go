@@ -0,0 +1,91 @@
+package stromboli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// DialContextFunc dials a connection to the server (see [WithDialContext]).
+// It has the signature of net.Dialer.DialContext.
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// unixSocketHost is the placeholder host of requests sent over a Unix
+// socket, used in their URL and Host header.
+const unixSocketHost = "localhost"
+
+// unixSocketBaseURL validates a unix:// base URL and returns the path of
+// its socket and the HTTP base URL requests are sent to.
+func unixSocketBaseURL(u *url.URL) (socketPath, baseURL string, err error) {
+	switch {
+	case u.Opaque != "":
+		return "", "", fmt.Errorf("stromboli: invalid unix socket URL %q (use unix:///path/to/socket)", u.String())
+	case u.Host != "":
+		return "", "", fmt.Errorf("stromboli: unix socket URL must not have a host, got %q (use unix:///path/to/socket)", u.Host)
+	case u.Path == "" || u.Path == "/":
+		return "", "", fmt.Errorf("stromboli: unix socket URL must include the socket path (use unix:///path/to/socket)")
+	case u.Path[len(u.Path)-1] == '/' || path.Clean(u.Path) != u.Path:
+		return "", "", fmt.Errorf("stromboli: invalid unix socket path %q", u.Path)
+	case u.RawQuery != "" || u.Fragment != "":
+		return "", "", fmt.Errorf("stromboli: unix socket URL must not have a query or fragment")
+	}
+	return u.Path, "http://" + unixSocketHost, nil
+}
+
+// WithDialContext sets the function used to open connections to the
+// server, for instance to resolve names with a custom DNS server or route
+// through a VPN. It is called with the network and address of each new
+// connection, as net.Dialer.DialContext is; for a unix:// base URL, with
+// "unix" and the socket path.
+//
+// The dialer is set on a copy of the client's *http.Transport, so it
+// applies to every request, streams included, and an HTTP client passed
+// to [WithHTTPClient] is not modified. It is ignored, with a warning, if
+// that client's transport is not an *http.Transport. Pass nil to use the
+// default dialer.
+//
+// Example:
+//
+//	dialer := &net.Dialer{Resolver: &net.Resolver{PreferGo: true, Dial: dialInternalDNS}}
+//	client, err := stromboli.NewClient("http://stromboli.internal:8585",
+//	    stromboli.WithDialContext(dialer.DialContext),
+//	)
+func WithDialContext(dial DialContextFunc) Option {
+	return func(c *Client) {
+		c.dialContext = dial
+	}
+}
+
+// configureDialer sets the client's dialer on its transport, if it has a
+// Unix socket or a custom dialer. The transport is cloned, so a transport
+// shared with other clients is not modified.
+func (c *Client) configureDialer() {
+	if c.socketPath == "" && c.dialContext == nil {
+		return
+	}
+	transport, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok {
+		getLogger().Printf("stromboli: WARNING: HTTP client transport is not *http.Transport, custom dialer ignored")
+		return
+	}
+
+	dial := c.dialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	if socketPath := c.socketPath; socketPath != "" {
+		dialAddr := dial
+		dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialAddr(ctx, "unix", socketPath)
+		}
+	}
+
+	transport = transport.Clone()
+	transport.DialContext = dial
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+}
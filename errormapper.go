@@ -0,0 +1,46 @@
+package stromboli
+
+import "errors"
+
+// ErrorMapper translates an [Error] built from a server response into an
+// application error (see [WithErrorMapper]). It returns nil to keep err.
+type ErrorMapper func(err *Error) error
+
+// mappedError is an error translated by an [ErrorMapper]. It reads as the
+// mapper's error, and matches both it and the original [Error] with
+// errors.Is and errors.As, so that the SDK's own handling of error codes
+// (retries, EnsureSecrets, ...) keeps working.
+type mappedError struct {
+	mapped   error
+	original *Error
+}
+
+// Error returns the message of the mapper's error.
+func (e *mappedError) Error() string {
+	return e.mapped.Error()
+}
+
+// Unwrap returns the mapper's error and the original [Error].
+func (e *mappedError) Unwrap() []error {
+	return []error{e.mapped, e.original}
+}
+
+// mapError translates err with the client's error mapper, if any. Errors
+// that aren't an [Error] or were already translated are returned as-is.
+func (c *Client) mapError(err error) error {
+	if c.errorMapper == nil || err == nil {
+		return err
+	}
+	var mapped *mappedError
+	if errors.As(err, &mapped) {
+		return err
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	if target := c.errorMapper(apiErr); target != nil {
+		return &mappedError{mapped: target, original: apiErr}
+	}
+	return err
+}
@@ -41,9 +41,16 @@ type Error struct {
 	// Use errors.Unwrap or errors.Is to inspect the cause chain.
 	Cause error
 
-	// RetryAfter indicates how long to wait before retrying (for 429 responses).
-	// Zero if no Retry-After header was provided or not applicable.
+	// RetryAfter indicates how long to wait before retrying, parsed from the
+	// response's Retry-After header (typically sent with 429 and 503
+	// responses). Zero if no Retry-After header was provided or not
+	// applicable.
 	RetryAfter time.Duration
+
+	// RequestID is the correlation ID sent with the last request of the
+	// failed call (see [WithRequestIDHeader]), to find it in the server's
+	// logs. Empty if request IDs are not enabled or no request was sent.
+	RequestID string
 }
 
 // Error returns a string representation of the error.
@@ -98,8 +105,9 @@ func (e *Error) Is(target error) bool {
 // Generic errors (ErrNotFound, ErrTimeout, etc.) are used for most resources.
 // Resource-specific errors exist where the failure mode is domain-specific:
 //
-//   - Images: [ErrImageNotFound], [ErrImagePullFailed] - container image operations
-//     have distinct failure modes (local lookup vs registry pull)
+//   - Images: [ErrImageNotFound], [ErrImagePullFailed], [ErrIncompatibleImage] -
+//     container image operations have distinct failure modes (local lookup vs
+//     registry pull vs compatibility)
 //   - Secrets: [ErrSecretExists], [ErrInvalidSecretName] - secret operations have
 //     specific validation and conflict rules
 //
@@ -159,6 +167,17 @@ var (
 		Status:  503,
 	}
 
+	// ErrMaintenance indicates the server is in read-only or maintenance
+	// mode. It is returned as a [MaintenanceError], which carries the
+	// expected end of the maintenance window. Use [Client.MaintenanceUntil]
+	// to check for maintenance before submitting work.
+	// HTTP status: 503.
+	ErrMaintenance = &Error{
+		Code:    "MAINTENANCE",
+		Message: "server is in maintenance mode",
+		Status:  503,
+	}
+
 	// ErrSecretExists indicates a secret with this name already exists.
 	// HTTP status: 409.
 	ErrSecretExists = &Error{
@@ -167,7 +186,9 @@ var (
 		Status:  409,
 	}
 
-	// ErrInvalidSecretName indicates the secret name is invalid.
+	// ErrInvalidSecretName indicates the secret name is invalid. The secret
+	// methods check names against Podman's rules before sending any
+	// request; the Message names the violated rule.
 	// HTTP status: 400.
 	ErrInvalidSecretName = &Error{
 		Code:    "INVALID_SECRET_NAME",
@@ -197,30 +218,160 @@ var (
 	// ErrRateLimited indicates too many requests were made.
 	// HTTP status: 429.
 	//
-	// NOTE: The RetryAfter field is not automatically populated because
-	// the go-swagger client doesn't expose response headers in error responses.
-	// To capture the Retry-After header, use [WithResponseHook] to inspect
-	// the response before the error is returned:
+	// The returned [Error] carries the server's Retry-After delay, in either
+	// delta-seconds or HTTP-date form, in RetryAfter:
 	//
-	//	var retryAfter time.Duration
-	//	client, _ := stromboli.NewClient(url,
-	//	    stromboli.WithResponseHook(func(resp *http.Response) {
-	//	        if resp.StatusCode == 429 {
-	//	            if s := resp.Header.Get("Retry-After"); s != "" {
-	//	                if seconds, err := strconv.Atoi(s); err == nil {
-	//	                    retryAfter = time.Duration(seconds) * time.Second
-	//	                }
-	//	            }
-	//	        }
-	//	    }),
-	//	)
+	//	var apiErr *stromboli.Error
+	//	if errors.As(err, &apiErr) && errors.Is(err, stromboli.ErrRateLimited) {
+	//	    time.Sleep(apiErr.RetryAfter)
+	//	}
 	ErrRateLimited = &Error{
 		Code:    "RATE_LIMITED",
 		Message: "too many requests",
 		Status:  429,
 	}
+
+	// ErrStillVisible indicates a deleted resource was still listed by the
+	// server when a confirmation window expired. It is returned (as a
+	// [StillVisibleError]) by [Client.DestroySessionAndConfirm] and
+	// [Client.DeleteSecretAndConfirm].
+	// HTTP status: none (client-side check).
+	ErrStillVisible = &Error{
+		Code:    "STILL_VISIBLE",
+		Message: "resource still visible after deletion",
+	}
+
+	// ErrNoSession indicates a [Conversation] has no session yet, e.g.
+	// because its first turn failed. The next [Conversation.Send] starts a
+	// new session.
+	// HTTP status: none (client-side check).
+	ErrNoSession = &Error{
+		Code:    "NO_SESSION",
+		Message: "conversation has no session",
+	}
+
+	// ErrSubmissionQueueFull indicates every slot of the client's submission
+	// gate is in use. It is returned by [Client.TrySubmit]; see
+	// [WithSubmissionGate].
+	// HTTP status: none (client-side check).
+	ErrSubmissionQueueFull = &Error{
+		Code:    "SUBMISSION_QUEUE_FULL",
+		Message: "too many jobs in flight",
+	}
+
+	// ErrSessionBusy indicates another call of the client is using the
+	// session. It is returned by [Client.TryRun]; see
+	// [WithSessionSerialization].
+	// HTTP status: none (client-side check).
+	ErrSessionBusy = &Error{
+		Code:    "SESSION_BUSY",
+		Message: "session is in use by another call",
+	}
+
+	// ErrClientClosed indicates the client was closed with [Client.Close].
+	// Create a new client (or a [Client.Clone]) to make further requests.
+	// HTTP status: none (client-side check).
+	ErrClientClosed = &Error{
+		Code:    "CLIENT_CLOSED",
+		Message: "client is closed",
+	}
+
+	// ErrIncompatibleServer indicates the server's API version is outside
+	// [APIVersionRange]. With [WithCompatibilityCheck] in [CompatFail] mode,
+	// calls return it once the server version is known.
+	// HTTP status: none (client-side check).
+	ErrIncompatibleServer = &Error{
+		Code:    "INCOMPATIBLE_SERVER",
+		Message: "server API version is not compatible",
+	}
+
+	// ErrResponseTooLarge indicates a response body was larger than the
+	// client's limit (see [WithMaxResponseSize]). The response is not
+	// read further.
+	// HTTP status: none (client-side check).
+	ErrResponseTooLarge = &Error{
+		Code:    "RESPONSE_TOO_LARGE",
+		Message: "response body is too large",
+	}
+
+	// ErrBudgetExceeded indicates a run was refused by
+	// [Client.RunWithBudget] because it could exceed the limit of its
+	// [BudgetTracker].
+	// HTTP status: none (client-side check).
+	ErrBudgetExceeded = &Error{
+		Code:    "BUDGET_EXCEEDED",
+		Message: "run would exceed the budget",
+	}
+
+	// ErrIncompatibleImage indicates an image is not compatible with
+	// Stromboli (e.g. an Alpine/musl image, compatibility rank 4). It is
+	// returned by [Client.EnsureImage] with
+	// [EnsureImageOptions.RequireCompatible].
+	// HTTP status: none (client-side check).
+	ErrIncompatibleImage = &Error{
+		Code:    "INCOMPATIBLE_IMAGE",
+		Message: "image is not compatible",
+	}
+
+	// ErrNotHealthy indicates the server didn't become healthy within the
+	// MaxWait of [Client.WaitUntilHealthy]. Its cause explains the last
+	// check.
+	// HTTP status: none (client-side check).
+	ErrNotHealthy = &Error{
+		Code:    "NOT_HEALTHY",
+		Message: "server is not healthy",
+	}
+
+	// ErrExecutionFailed indicates the API call succeeded but Claude's
+	// execution failed. It is returned by [RunResponse.Err], with the
+	// response's Error as message.
+	// HTTP status: none (reported in the response body).
+	ErrExecutionFailed = &Error{
+		Code:    "EXECUTION_FAILED",
+		Message: "execution failed",
+	}
 )
 
+// StillVisibleError is returned when a deleted resource is still visible
+// after the confirmation window of [Client.DestroySessionAndConfirm] or
+// [Client.DeleteSecretAndConfirm] expired.
+//
+// It matches [ErrStillVisible] with errors.Is. Use errors.As to inspect the
+// last observation:
+//
+//	var visible *stromboli.StillVisibleError
+//	if errors.As(err, &visible) {
+//	    fmt.Printf("%s %s still visible: %v\n", visible.Resource, visible.ID, visible.LastObservation)
+//	}
+type StillVisibleError struct {
+	// Resource is the kind of resource: "session" or "secret".
+	Resource string
+
+	// ID is the session ID or secret name.
+	ID string
+
+	// Waited is how long the SDK waited for the resource to disappear.
+	Waited time.Duration
+
+	// LastObservation is the last server response that still showed the
+	// resource: the session ID list ([]string) for sessions, or the
+	// [*Secret] for secrets.
+	LastObservation interface{}
+}
+
+// Error returns a string representation of the error.
+func (e *StillVisibleError) Error() string {
+	return fmt.Sprintf("stromboli: %s: %s %q still visible after %s",
+		ErrStillVisible.Code, e.Resource, e.ID, e.Waited)
+}
+
+// Is reports whether target is [ErrStillVisible] (or any [Error] with the
+// same Code).
+func (e *StillVisibleError) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == ErrStillVisible.Code
+}
+
 // newError creates a new Error with the given parameters.
 // This is an internal helper for creating errors from API responses.
 func newError(code, message string, status int, cause error) *Error {
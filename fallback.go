@@ -0,0 +1,112 @@
+package stromboli
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+)
+
+// statusOverloaded is the non-standard status of overloaded model APIs.
+const statusOverloaded = 529
+
+// overloadCodes are the error codes the server uses when a model is
+// overloaded or unavailable.
+var overloadCodes = map[string]bool{
+	ErrUnavailable.Code: true,
+	"OVERLOADED":        true,
+	"MODEL_OVERLOADED":  true,
+	"MODEL_UNAVAILABLE": true,
+}
+
+// IsOverloaded reports whether the outcome of a run failed because the
+// model was overloaded or unavailable, so retrying with another model may
+// succeed. It is the default classifier of [Client.RunWithFallbacks].
+//
+// It recognizes errors with the server's overload codes (UNAVAILABLE,
+// OVERLOADED, MODEL_OVERLOADED, MODEL_UNAVAILABLE) or a 529 status, and
+// error responses whose Error reports an overloaded model. Maintenance
+// ([ErrMaintenance]) is not an overload: every model is affected.
+func IsOverloaded(resp *RunResponse, err error) bool {
+	if err != nil {
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || errors.Is(err, ErrMaintenance) {
+			return false
+		}
+		return overloadCodes[apiErr.Code] || apiErr.Status == statusOverloaded
+	}
+	if resp == nil || resp.IsSuccess() {
+		return false
+	}
+	return strings.Contains(strings.ToLower(resp.Error), "overloaded")
+}
+
+// RunWithFallbacks runs req with each model of models in turn until one
+// isn't overloaded, e.g. to escalate from haiku to sonnet to opus.
+//
+// Each attempt is a [Client.Run] of a copy of req with Claude.Model set to
+// the next model; req itself is not modified. The next model is tried when
+// classify reports the outcome as an overload (nil uses [IsOverloaded]).
+// Any other outcome, success or failure, is returned as-is. When every
+// model is overloaded, the outcome of the last attempt is returned.
+//
+// ModelUsed of the returned response is the model that produced it, as
+// reported by the server or, if it doesn't report it, the model of the
+// attempt. Session options (SessionID, Resume) are sent unchanged with
+// every attempt, so the conversation continues on whichever model
+// answers; sessions created by overloaded attempts are not resumed.
+//
+// It returns a BAD_REQUEST [Error] if models is empty, and stops with the
+// context's error if ctx is done between attempts.
+//
+// Example:
+//
+//	resp, err := client.RunWithFallbacks(ctx, req,
+//	    []stromboli.Model{stromboli.ModelHaiku, stromboli.ModelSonnet, stromboli.ModelOpus},
+//	    nil,
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("answered by %s\n", resp.ModelUsed)
+func (c *Client) RunWithFallbacks(ctx context.Context, req *RunRequest, models []Model, classify func(*RunResponse, error) bool) (*RunResponse, error) {
+	if req == nil {
+		return nil, newError("BAD_REQUEST", "request is required", 400, nil)
+	}
+	if len(models) == 0 {
+		return nil, newError("BAD_REQUEST", "at least one model is required", 400, nil)
+	}
+	if classify == nil {
+		classify = IsOverloaded
+	}
+
+	var (
+		resp *RunResponse
+		err  error
+	)
+	for i, model := range models {
+		if i > 0 {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, c.handleError(ctxErr, "fallback cancelled")
+			}
+			c.logf(slog.LevelInfo, "model %s overloaded, falling back to %s", models[i-1], model)
+		}
+
+		attempt := *req
+		claude := ClaudeOptions{}
+		if req.Claude != nil {
+			claude = *req.Claude
+		}
+		claude.Model = model
+		attempt.Claude = &claude
+
+		resp, err = c.Run(ctx, &attempt)
+		if resp != nil && resp.ModelUsed == "" {
+			resp.ModelUsed = model
+		}
+		if !classify(resp, err) {
+			break
+		}
+	}
+	return resp, err
+}
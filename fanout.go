@@ -0,0 +1,205 @@
+package stromboli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StreamSummary summarizes a consumed stream.
+//
+// It is returned per variant by [Client.StreamFanout].
+type StreamSummary struct {
+	// Output is the concatenated data of all output events
+	// (events with an empty or "message" type).
+	Output string
+
+	// Events is the number of events received, including "error" and
+	// "done" events.
+	Events int
+
+	// Duration is the time from starting the variant (submitting its job,
+	// or opening its stream) until the stream ended.
+	Duration time.Duration
+
+	// Err is the error that ended the stream, if any: a failure to open
+	// the stream, an "error" event sent by the server, or a read error.
+	// Nil if the stream completed normally.
+	Err error
+}
+
+// FanoutOptions configures [Client.StreamFanout].
+type FanoutOptions struct {
+	// Concurrency is the maximum number of variants run at once.
+	// Zero (the default) runs every variant at once.
+	Concurrency int
+
+	// FailFast cancels all remaining variants as soon as one fails.
+	// By default, a failing variant does not affect the others.
+	FailFast bool
+}
+
+// StreamFanout runs the same prompt once per container configuration and
+// streams the output of every run, e.g. to compare images or resource
+// limits side by side.
+//
+// The streaming endpoint doesn't accept container options, so each variant
+// with Podman options set is submitted as an async job with
+// [Client.RunAsync] (base's prompt, working directory and session, with
+// [RunRequest.Podman] set to the variant) and followed with
+// [Client.StreamJob], which requires a server with job streaming. A
+// zero-valued variant uses the server's default container and is streamed
+// with [Client.Stream] directly. Jobs of variants that are cancelled before
+// they finish (see FailFast) are cancelled with [Client.CancelJob].
+//
+// Every event is passed to handler together with the index of its variant.
+// Handler calls are serialized, so handler does not need to be safe for
+// concurrent use; it may be nil.
+//
+// The returned summaries have the same length and order as variants. The
+// returned error joins the errors of all failed variants (each prefixed
+// with its index) and is nil if every variant succeeded. Use the Err field
+// of each summary to tell which variants failed.
+//
+// Example:
+//
+//	variants := []stromboli.PodmanOptions{
+//	    {Image: "python:3.12"},
+//	    {Image: "node:22"},
+//	}
+//	summaries, err := client.StreamFanout(ctx, &stromboli.StreamRequest{
+//	    Prompt: "Write a hello world HTTP server",
+//	}, variants, func(i int, ev *stromboli.StreamEvent) {
+//	    fmt.Printf("[%s] %s\n", variants[i].Image, ev.Data)
+//	}, &stromboli.FanoutOptions{Concurrency: 2})
+//	if err != nil {
+//	    log.Printf("some variants failed: %v", err) // others still completed
+//	}
+//	for i, s := range summaries {
+//	    fmt.Printf("%s: %d events in %s\n", variants[i].Image, s.Events, s.Duration)
+//	}
+func (c *Client) StreamFanout(
+	ctx context.Context,
+	base *StreamRequest,
+	variants []PodmanOptions,
+	handler func(variantIndex int, ev *StreamEvent),
+	opts *FanoutOptions,
+) ([]*StreamSummary, error) {
+	if base == nil {
+		return nil, newError("BAD_REQUEST", "request is required", 400, nil)
+	}
+	if opts == nil {
+		opts = &FanoutOptions{}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 || concurrency > len(variants) {
+		concurrency = len(variants)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	summaries := make([]*StreamSummary, len(variants))
+	var handlerMu sync.Mutex
+
+	started := forEachBounded(ctx, len(variants), concurrency, func(i int) {
+		summaries[i] = c.variantSummary(ctx, base, variants[i], func(ev *StreamEvent) {
+			if handler == nil {
+				return
+			}
+			handlerMu.Lock()
+			defer handlerMu.Unlock()
+			handler(i, ev)
+		})
+		if summaries[i].Err != nil && opts.FailFast {
+			cancel()
+		}
+	})
+	if started < len(variants) {
+		// ctx was cancelled, or a variant failed with FailFast set.
+		cancelErr := c.handleError(ctx.Err(), "fanout cancelled")
+		for i := started; i < len(variants); i++ {
+			summaries[i] = &StreamSummary{Err: cancelErr}
+		}
+	}
+
+	var errs []error
+	for i, s := range summaries {
+		if s.Err != nil {
+			errs = append(errs, fmt.Errorf("variant %d: %w", i, s.Err))
+		}
+	}
+	return summaries, errors.Join(errs...)
+}
+
+// variantSummary runs base with the container options of variant, as
+// described by [Client.StreamFanout], and summarizes its stream.
+func (c *Client) variantSummary(ctx context.Context, base *StreamRequest, variant PodmanOptions, onEvent func(*StreamEvent)) *StreamSummary {
+	if reflect.ValueOf(variant).IsZero() {
+		return c.streamSummary(func() (*Stream, error) {
+			req := *base
+			return c.Stream(ctx, &req)
+		}, onEvent)
+	}
+
+	req := &RunRequest{Prompt: base.Prompt, Workdir: base.Workdir, Podman: &variant}
+	if base.SessionID != "" {
+		req.Claude = &ClaudeOptions{SessionID: base.SessionID, Resume: true}
+	}
+	var jobID string
+	summary := c.streamSummary(func() (*Stream, error) {
+		job, err := c.RunAsync(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		jobID = job.JobID
+		return c.StreamJob(ctx, jobID)
+	}, onEvent)
+
+	if jobID != "" && summary.Err != nil && ctx.Err() != nil {
+		// Don't leave the job running on the server; best effort
+		_ = c.CancelJob(context.WithoutCancel(ctx), jobID)
+	}
+	return summary
+}
+
+// streamSummary opens a stream with open, passes every event to onEvent,
+// and summarizes it. The stream is always closed before returning.
+func (c *Client) streamSummary(open func() (*Stream, error), onEvent func(*StreamEvent)) *StreamSummary {
+	start := c.clock.Now()
+	summary := &StreamSummary{}
+
+	stream, err := open()
+	if err != nil {
+		summary.Err = err
+		summary.Duration = c.clock.Now().Sub(start)
+		return summary
+	}
+	defer func() { _ = stream.Close() }()
+
+	var output strings.Builder
+	for stream.Next() {
+		event := stream.Event()
+		summary.Events++
+		onEvent(event)
+
+		if event.isOutput() {
+			output.WriteString(event.Data)
+		}
+		if summary.Err = event.AsError(); summary.Err != nil || event.IsDone() {
+			break
+		}
+	}
+	if summary.Err == nil {
+		summary.Err = stream.Err()
+	}
+
+	summary.Output = output.String()
+	summary.Duration = c.clock.Now().Sub(start)
+	return summary
+}
@@ -0,0 +1,253 @@
+package stromboli
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultSubmissionLease is how long an async job holds its submission slot
+// at most (see [WithSubmissionLease]).
+const defaultSubmissionLease = time.Hour
+
+// submissionGate limits the number of jobs submitted through a client that
+// are in flight (see [WithSubmissionGate]).
+//
+// A slot is taken for every Run call and every async job. Run gives its
+// slot back when it returns; an async job keeps its slot until the client
+// observes it in a terminal state, or until its lease expires.
+type submissionGate struct {
+	// slots holds one token per slot in use.
+	slots chan struct{}
+
+	// mu protects jobs.
+	mu sync.Mutex
+
+	// jobs holds the lease expiry of the async jobs holding a slot, by job
+	// ID (zero if the lease never expires).
+	jobs map[string]time.Time
+}
+
+// newSubmissionGate creates a gate with maxInFlight slots.
+func newSubmissionGate(maxInFlight int) *submissionGate {
+	return &submissionGate{
+		slots: make(chan struct{}, maxInFlight),
+		jobs:  make(map[string]time.Time),
+	}
+}
+
+// acquire takes a slot, blocking until one is free or ctx is done. Slots
+// of async jobs whose lease expires while waiting are reclaimed.
+func (g *submissionGate) acquire(ctx context.Context, clock Clock) bool {
+	for {
+		next, ok := g.expireLeases(clock.Now())
+		if !ok {
+			return acquireSlot(ctx, g.slots)
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+
+		timer := clock.NewTimer(next)
+		select {
+		case g.slots <- struct{}{}:
+			timer.Stop()
+			if ctx.Err() != nil {
+				<-g.slots
+				return false
+			}
+			return true
+		case <-timer.C():
+			// A lease expired; reclaim it on the next iteration
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		}
+	}
+}
+
+// tryAcquire takes a slot if one is free, without blocking, after
+// reclaiming the slots of expired leases.
+func (g *submissionGate) tryAcquire(now time.Time) bool {
+	g.expireLeases(now)
+	select {
+	case g.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// expireLeases releases the slots of the async jobs whose lease expired at
+// now. It returns the time until the next lease expires, and false if no
+// job holds an expiring lease.
+func (g *submissionGate) expireLeases(now time.Time) (time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var next time.Duration
+	found := false
+	for jobID, expiry := range g.jobs {
+		if expiry.IsZero() {
+			continue
+		}
+		if !now.Before(expiry) {
+			delete(g.jobs, jobID)
+			g.release()
+			continue
+		}
+		if wait := expiry.Sub(now); !found || wait < next {
+			next, found = wait, true
+		}
+	}
+	return next, found
+}
+
+// release gives back a slot taken with acquireSlot or tryAcquire.
+func (g *submissionGate) release() {
+	<-g.slots
+}
+
+// track hands the caller's slot to the async job jobID, to be released by
+// releaseJob or when the lease expires (a zero expiry never does).
+func (g *submissionGate) track(jobID string, expiry time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.jobs[jobID]; ok {
+		// Already holding a slot (the server reused an ID); don't leak ours
+		g.release()
+		return
+	}
+	g.jobs[jobID] = expiry
+}
+
+// releaseJob releases the slot of jobID, if it holds one.
+func (g *submissionGate) releaseJob(jobID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.jobs[jobID]; ok {
+		delete(g.jobs, jobID)
+		g.release()
+	}
+}
+
+// inFlight returns the number of slots in use.
+func (g *submissionGate) inFlight() int {
+	return len(g.slots)
+}
+
+// acquireSubmission takes a slot of the submission gate, blocking until one
+// is free or ctx is done. It does nothing if the client has no gate.
+func (c *Client) acquireSubmission(ctx context.Context) error {
+	if c.gate == nil {
+		return nil
+	}
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
+	if !c.gate.acquire(ctx, c.clock) {
+		return c.handleError(ctx.Err(), "cancelled while waiting for a submission slot")
+	}
+	return nil
+}
+
+// trackSubmission hands the slot taken for a submission to the async job
+// jobID, for the client's lease duration.
+func (c *Client) trackSubmission(jobID string) {
+	if c.gate == nil {
+		return
+	}
+	var expiry time.Time
+	if c.submissionLease > 0 {
+		expiry = c.clock.Now().Add(c.submissionLease)
+	}
+	c.gate.track(jobID, expiry)
+}
+
+// releaseSubmission gives back a slot taken with acquireSubmission.
+func (c *Client) releaseSubmission() {
+	if c.gate != nil {
+		c.gate.release()
+	}
+}
+
+// observeJobStatus releases the submission slot of jobID once it reached a
+// terminal state.
+func (c *Client) observeJobStatus(jobID, status string) {
+	if c.gate == nil {
+		return
+	}
+	if JobState(status).IsTerminal() {
+		c.gate.releaseJob(jobID)
+	}
+}
+
+// TrySubmit is like [Client.RunAsync] but doesn't wait for a free slot of
+// the submission gate (see [WithSubmissionGate]): if all slots are in use,
+// it returns [ErrSubmissionQueueFull] immediately without contacting the
+// server. Without a gate, it is the same as RunAsync.
+//
+// Example:
+//
+//	job, err := client.TrySubmit(ctx, req)
+//	if errors.Is(err, stromboli.ErrSubmissionQueueFull) {
+//	    // Come back later, or fall back to RunAsync to wait for a slot
+//	}
+func (c *Client) TrySubmit(ctx context.Context, req *RunRequest) (_ *AsyncRunResponse, err error) {
+	ctx, op := c.observe(ctx, "TrySubmit")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if c.gate == nil {
+		return c.RunAsync(ctx, req)
+	}
+	req, err = c.prepareRunRequest(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !c.gate.tryAcquire(c.clock.Now()) {
+		return nil, newError(ErrSubmissionQueueFull.Code, ErrSubmissionQueueFull.Message, 0, nil)
+	}
+	return c.runAsync(ctx, req)
+}
+
+// InFlightJobs returns the number of submissions holding a slot of the
+// submission gate: Run calls in progress and async jobs not yet observed in
+// a terminal state, including jobs whose lease expired but whose slot
+// wasn't reclaimed yet (this happens when a submission needs it). It
+// returns 0 if the client has no gate.
+func (c *Client) InFlightJobs() int {
+	if c.gate == nil {
+		return 0
+	}
+	return c.gate.inFlight()
+}
+
+// ReleaseJob releases the submission slot held by an async job, for jobs
+// whose completion the client can't observe itself (e.g. when it is
+// reported by a webhook that doesn't go through [Client.WebhookHandler]).
+// It does nothing if the job doesn't hold a slot.
+func (c *Client) ReleaseJob(jobID string) {
+	if c.gate != nil {
+		c.gate.releaseJob(jobID)
+	}
+}
+
+// WebhookHandler is like the package-level [WebhookHandler], but also
+// releases the submission slot of the job (see [WithSubmissionGate]) when
+// the payload reports it in a terminal state, before calling fn.
+//
+// Example:
+//
+//	http.Handle("/webhook", client.WebhookHandler(
+//	    func(ctx context.Context, p *stromboli.WebhookPayload) error {
+//	        return store.SaveResult(ctx, p.ID, p.Output)
+//	    }))
+func (c *Client) WebhookHandler(fn func(context.Context, *WebhookPayload) error) http.Handler {
+	return WebhookHandler(func(ctx context.Context, payload *WebhookPayload) error {
+		c.observeJobStatus(payload.ID, payload.Status)
+		return fn(ctx, payload)
+	})
+}
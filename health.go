@@ -0,0 +1,432 @@
+package stromboli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHealthPollInterval is the interval of a [HealthPoller] created
+// with a non-positive interval.
+const defaultHealthPollInterval = 30 * time.Second
+
+// Default backoff of [Client.WaitUntilHealthy]: the interval between
+// checks starts at the initial interval and doubles up to the maximum.
+const (
+	defaultWaitHealthyInterval    = 250 * time.Millisecond
+	defaultWaitHealthyMaxInterval = 5 * time.Second
+)
+
+// WaitHealthyOptions configures [Client.WaitUntilHealthy].
+type WaitHealthyOptions struct {
+	// RequiredComponents are components that must be reported and healthy,
+	// in addition to the overall status.
+	// Example: []string{"podman"}
+	RequiredComponents []string
+
+	// MaxWait limits how long to wait. Zero waits until the context is done.
+	MaxWait time.Duration
+
+	// InitialInterval is the wait after the first unsuccessful check.
+	// Default: 250ms.
+	InitialInterval time.Duration
+
+	// MaxInterval caps the wait between checks.
+	// Default: 5s.
+	MaxInterval time.Duration
+}
+
+// WaitUntilHealthy calls [Client.Health] with exponential backoff until the
+// server reports itself healthy, with every component of
+// opts.RequiredComponents reported healthy, and returns that response,
+// e.g. to log the server version. Use it to wait for a server started
+// alongside a test suite or a deployment. opts may be nil.
+//
+// Errors of a server that is still starting (connection refused and other
+// network errors, timeouts, 429 and 5xx responses) count as "not yet
+// healthy". Other errors, such as 401 or a closed client, are returned
+// right away.
+//
+// When MaxWait expires, it returns an [ErrNotHealthy] error whose cause
+// explains the last check, with the last response received (nil if none).
+// When ctx is done, it returns the context's error, also with the last
+// response.
+//
+// Example:
+//
+//	health, err := client.WaitUntilHealthy(ctx, &stromboli.WaitHealthyOptions{
+//	    RequiredComponents: []string{"podman"},
+//	    MaxWait:            time.Minute,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	log.Printf("stromboli %s is up", health.Version)
+func (c *Client) WaitUntilHealthy(ctx context.Context, opts *WaitHealthyOptions) (*HealthResponse, error) {
+	if opts == nil {
+		opts = &WaitHealthyOptions{}
+	}
+	interval := opts.InitialInterval
+	if interval <= 0 {
+		interval = defaultWaitHealthyInterval
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultWaitHealthyMaxInterval
+	}
+
+	// The deadline bounds the waits, and the context bounds requests in
+	// flight when MaxWait expires
+	start := c.clock.Now()
+	var deadline time.Time
+	checkCtx := ctx
+	if opts.MaxWait > 0 {
+		deadline = start.Add(opts.MaxWait)
+		var cancel context.CancelFunc
+		checkCtx, cancel = context.WithTimeout(ctx, opts.MaxWait)
+		defer cancel()
+	}
+
+	var last *HealthResponse
+	var reason error
+	for {
+		health, err := c.Health(checkCtx)
+		if ctx.Err() != nil {
+			return last, c.handleError(ctx.Err(), "cancelled while waiting for the server to be healthy")
+		}
+		switch {
+		case err == nil:
+			last = health
+			reason = unhealthyReason(health, opts.RequiredComponents)
+			if reason == nil {
+				return health, nil
+			}
+		case checkCtx.Err() != nil:
+			// MaxWait expired during the check, which says nothing new
+			if reason == nil {
+				reason = err
+			}
+		case isStartingServerError(err):
+			reason = err
+		default:
+			return last, err
+		}
+
+		wait := interval
+		if !deadline.IsZero() {
+			remaining := deadline.Sub(c.clock.Now())
+			if remaining <= 0 || checkCtx.Err() != nil {
+				waited := c.clock.Now().Sub(start)
+				return last, newError(ErrNotHealthy.Code,
+					fmt.Sprintf("server not healthy after %s", waited), 0, reason)
+			}
+			wait = min(wait, remaining)
+		}
+
+		timer := c.clock.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return last, c.handleError(ctx.Err(), "cancelled while waiting for the server to be healthy")
+		case <-timer.C():
+		}
+		interval = min(interval*2, maxInterval)
+	}
+}
+
+// unhealthyReason returns why health doesn't report the server and every
+// required component as healthy, or nil if it does.
+func unhealthyReason(health *HealthResponse, required []string) error {
+	if !health.IsHealthy() {
+		var failing []string
+		for _, component := range health.Components {
+			if !component.IsHealthy() {
+				failing = append(failing, fmt.Sprintf("%s: %s", component.Name, component.Error))
+			}
+		}
+		if len(failing) > 0 {
+			return fmt.Errorf("status %q (%s)", health.Status, strings.Join(failing, "; "))
+		}
+		return fmt.Errorf("status %q", health.Status)
+	}
+	for _, name := range required {
+		found := false
+		for _, component := range health.Components {
+			if component.Name != name {
+				continue
+			}
+			found = true
+			if !component.IsHealthy() {
+				return fmt.Errorf("component %q is %q: %s", name, component.Status, component.Error)
+			}
+		}
+		if !found {
+			return fmt.Errorf("component %q not reported", name)
+		}
+	}
+	return nil
+}
+
+// isStartingServerError reports whether err may come from a server that is
+// still starting: a network error (no HTTP status), a timeout, 429 or a
+// 5xx response.
+func isStartingServerError(err error) bool {
+	if errors.Is(err, ErrClientClosed) {
+		return false
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	switch {
+	case apiErr.Status == 0, apiErr.Status >= http.StatusInternalServerError:
+		return true
+	case apiErr.Status == http.StatusRequestTimeout, apiErr.Status == http.StatusTooManyRequests:
+		return true
+	default:
+		return apiErr.Code == ErrTimeout.Code
+	}
+}
+
+// HealthSnapshot is the state observed by a [HealthPoller], as returned by
+// [HealthPoller.Snapshot].
+type HealthSnapshot struct {
+	// Health is the last successful health response, or nil if no check
+	// succeeded yet.
+	Health *HealthResponse
+
+	// Err is the error of the last check, or nil if it succeeded.
+	Err error
+
+	// Up reports whether the last check succeeded and the service reported
+	// itself healthy.
+	Up bool
+
+	// Components reports whether each component is up, by name. When a
+	// check fails, every component is reported down.
+	Components map[string]bool
+
+	// Latency is how long the last check took.
+	Latency time.Duration
+
+	// CheckedAt is when the last check completed, or the zero time if no
+	// check completed yet.
+	CheckedAt time.Time
+}
+
+// HealthTransition is a change of state observed by a [HealthPoller].
+type HealthTransition struct {
+	// Component is the name of the component that changed state, or empty
+	// for the service as a whole.
+	Component string
+
+	// Up is the new state.
+	Up bool
+
+	// Error explains why the component is down: the component's error, or
+	// the error of the check. Empty when Up is true.
+	Error string
+}
+
+// HealthPoller calls [Client.Health] in the background and keeps the latest
+// result, e.g. to expose the server's health as metrics.
+//
+// Create it with [NewHealthPoller] and run it with [HealthPoller.Start].
+// Ticks use the client's [Clock], so tests can drive the poller with a fake
+// clock.
+//
+// Example:
+//
+//	poller := stromboli.NewHealthPoller(client, 15*time.Second)
+//	poller.OnChange = func(t stromboli.HealthTransition) {
+//	    log.Printf("component %q up=%v %s", t.Component, t.Up, t.Error)
+//	}
+//	poller.Start(ctx)
+//	defer poller.Stop()
+//
+//	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+//	    snap := poller.Snapshot()
+//	    for name, up := range snap.Components {
+//	        v := 0
+//	        if up {
+//	            v = 1
+//	        }
+//	        fmt.Fprintf(w, "stromboli_component_up{component=%q} %d\n", name, v)
+//	    }
+//	    fmt.Fprintf(w, "stromboli_health_latency_seconds %g\n", snap.Latency.Seconds())
+//	})
+type HealthPoller struct {
+	// OnChange, if not nil, is called for each transition observed after
+	// the first check, from the polling goroutine. Set it before Start.
+	OnChange func(HealthTransition)
+
+	client   *Client
+	interval time.Duration
+
+	mu       sync.RWMutex
+	snapshot HealthSnapshot
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewHealthPoller returns a [HealthPoller] checking the health of the
+// server of c every interval (30 seconds if interval is not positive).
+// It doesn't poll until started.
+func NewHealthPoller(c *Client, interval time.Duration) *HealthPoller {
+	if interval <= 0 {
+		interval = defaultHealthPollInterval
+	}
+	return &HealthPoller{client: c, interval: interval}
+}
+
+// Start starts polling in the background, beginning with an immediate
+// check, until ctx is done or [HealthPoller.Stop] is called. It does
+// nothing if the poller is already running.
+func (p *HealthPoller) Start(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		return
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.done = make(chan struct{})
+	go p.run(ctx, p.done)
+}
+
+// Stop stops polling and waits for the polling goroutine to exit. The last
+// snapshot remains available. It does nothing if the poller isn't running.
+func (p *HealthPoller) Stop() {
+	p.mu.Lock()
+	cancel, done := p.cancel, p.done
+	p.cancel, p.done = nil, nil
+	p.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Snapshot returns a copy of the latest observed state. It is safe to call
+// concurrently with polling, e.g. from a metrics scraper.
+func (p *HealthPoller) Snapshot() HealthSnapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	snap := p.snapshot
+	if snap.Health != nil {
+		health := *snap.Health
+		health.Components = append([]ComponentHealth(nil), health.Components...)
+		snap.Health = &health
+	}
+	if snap.Components != nil {
+		components := make(map[string]bool, len(snap.Components))
+		for name, up := range snap.Components {
+			components[name] = up
+		}
+		snap.Components = components
+	}
+	return snap
+}
+
+// run checks the health every interval until ctx is done, then closes done.
+func (p *HealthPoller) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	for {
+		p.check(ctx)
+
+		timer := p.client.clock.NewTimer(p.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+	}
+}
+
+// check runs one health check, records it and reports the transitions.
+func (p *HealthPoller) check(ctx context.Context) {
+	clock := p.client.clock
+	start := clock.Now()
+	health, err := p.client.Health(ctx)
+	if ctx.Err() != nil {
+		return // stopped; the interrupted check says nothing about the server
+	}
+
+	next := HealthSnapshot{
+		Err:        err,
+		Latency:    clock.Now().Sub(start),
+		CheckedAt:  clock.Now(),
+		Components: make(map[string]bool),
+	}
+	reasons := make(map[string]string)
+
+	p.mu.Lock()
+	prev := p.snapshot
+	if err == nil {
+		next.Health = health
+		next.Up = health.IsHealthy()
+		if !next.Up {
+			reasons[""] = "status " + health.Status
+		}
+		for i := range health.Components {
+			component := &health.Components[i]
+			next.Components[component.Name] = component.IsHealthy()
+			reasons[component.Name] = component.Error
+		}
+	} else {
+		next.Health = prev.Health
+		reasons[""] = err.Error()
+		for name := range prev.Components {
+			next.Components[name] = false
+			reasons[name] = err.Error()
+		}
+	}
+	p.snapshot = next
+	p.mu.Unlock()
+
+	if p.OnChange == nil || prev.CheckedAt.IsZero() {
+		return
+	}
+	for _, t := range healthTransitions(prev, next, reasons) {
+		p.OnChange(t)
+	}
+}
+
+// healthTransitions returns the state changes from prev to next: the
+// service first, then components by name. reasons holds the error of each
+// component (the empty name for the service).
+func healthTransitions(prev, next HealthSnapshot, reasons map[string]string) []HealthTransition {
+	var transitions []HealthTransition
+	add := func(component string, up bool) {
+		t := HealthTransition{Component: component, Up: up}
+		if !up {
+			t.Error = reasons[component]
+		}
+		transitions = append(transitions, t)
+	}
+
+	if prev.Up != next.Up {
+		add("", next.Up)
+	}
+
+	names := make([]string, 0, len(next.Components))
+	for name := range next.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		wasUp, known := prev.Components[name]
+		if up := next.Components[name]; known && up != wasUp {
+			add(name, up)
+		}
+	}
+	return transitions
+}
@@ -0,0 +1,190 @@
+package stromboli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// BuildImageRequest represents a request to build a container image.
+//
+// The build context is either uploaded with Context or read on the server
+// from ContextPath; exactly one of them must be set.
+type BuildImageRequest struct {
+	// Context is a tar archive of the build context, optionally compressed
+	// with gzip. It is streamed to the server, so it can be larger than
+	// memory.
+	Context io.Reader
+
+	// ContextPath is the path of the build context on the server.
+	// Example: "/srv/agents/python"
+	ContextPath string
+
+	// Containerfile is the path of the Containerfile within the build
+	// context. Defaults to the server's default (usually "Containerfile").
+	// Example: "agents/Containerfile.python"
+	Containerfile string
+
+	// Tag is the name given to the built image (required).
+	// Example: "agents/python:1.4"
+	Tag string
+
+	// BuildArgs sets build-time variables (ARG instructions).
+	BuildArgs map[string]string
+
+	// Timeout is the maximum duration of the build on the server.
+	// Example: "15m"
+	Timeout string
+}
+
+// BuildImageResponse represents the result of an image build.
+//
+// Servers that build images as async jobs only return JobID: follow the
+// build with [Client.GetJob] or [Client.StreamJob].
+type BuildImageResponse struct {
+	// ImageID is the built image's ID.
+	// Example: "sha256:abc123def456"
+	ImageID string `json:"image_id,omitempty"`
+
+	// Tag is the name of the built image.
+	// Example: "agents/python:1.4"
+	Tag string `json:"tag,omitempty"`
+
+	// Log is the output of the build.
+	Log string `json:"log,omitempty"`
+
+	// JobID is the ID of the build job, if the server builds images
+	// asynchronously.
+	// Example: "job-abc123def456"
+	JobID string `json:"job_id,omitempty"`
+}
+
+// BuildImage builds a container image from a Containerfile, so custom
+// agent images can be built by the server that runs them.
+//
+// Builds routinely take longer than the client timeout, so like
+// [Client.PullImageWithProgress] the request is bounded by the stream
+// timeout (see [WithStreamTimeout]) rather than the client timeout; use
+// req.Timeout to bound the build itself.
+//
+// It returns a BAD_REQUEST error if the request is invalid, and an [Error]
+// with code "UNSUPPORTED" if the server doesn't build images.
+//
+// Example:
+//
+//	archive, err := os.Open("context.tar.gz")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer archive.Close()
+//
+//	resp, err := client.BuildImage(ctx, &stromboli.BuildImageRequest{
+//	    Context:   archive,
+//	    Tag:       "agents/python:1.4",
+//	    BuildArgs: map[string]string{"PYTHON_VERSION": "3.12"},
+//	    Timeout:   "15m",
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("Built %s\n", resp.ImageID)
+func (c *Client) BuildImage(ctx context.Context, req *BuildImageRequest, opts ...CallOption) (_ *BuildImageResponse, err error) {
+	ctx, op := c.observe(ctx, "BuildImage")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	query, err := buildImageQuery(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
+	if timeout := callTimeout(ctx, c.streamTimeout); timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		defer cancelTimeout()
+	}
+
+	resp, err := c.doRaw(ctx, http.MethodPost, "/images/build", query, req.Context, "application/x-tar", "application/json")
+	c.images.invalidate(req.Tag) // the build may have replaced the image even if it failed
+	if err != nil {
+		var apiErr *Error
+		if errors.As(err, &apiErr) && isUnsupportedBuildStatus(apiErr) {
+			return nil, newError("UNSUPPORTED", "server does not support image builds", http.StatusNotImplemented, err)
+		}
+		return nil, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	var result BuildImageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, newError("INVALID_RESPONSE", "failed to decode build response", resp.StatusCode, err)
+	}
+	if resp.StatusCode == http.StatusAccepted && result.JobID == "" {
+		return nil, newError("INVALID_RESPONSE", "async build response has no job ID", resp.StatusCode, nil)
+	}
+	return &result, nil
+}
+
+// buildImageQuery validates req and returns the query parameters of its
+// build request.
+func buildImageQuery(req *BuildImageRequest) (url.Values, error) {
+	if req == nil {
+		return nil, newError("BAD_REQUEST", "request is required", 400, nil)
+	}
+	if req.Tag == "" {
+		return nil, newError("BAD_REQUEST", "image tag is required", 400, nil)
+	}
+	if (req.Context == nil) == (req.ContextPath == "") {
+		return nil, newError("BAD_REQUEST", "exactly one of context and context path is required", 400, nil)
+	}
+	if err := validateDuration("timeout", req.Timeout); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("tag", req.Tag)
+	if req.ContextPath != "" {
+		query.Set("context_path", req.ContextPath)
+	}
+	if req.Containerfile != "" {
+		query.Set("containerfile", req.Containerfile)
+	}
+	if len(req.BuildArgs) > 0 {
+		// Maps encode with sorted keys, so the query is deterministic
+		args, err := json.Marshal(req.BuildArgs)
+		if err != nil {
+			return nil, newError("BAD_REQUEST", "failed to encode build args", 400, err)
+		}
+		query.Set("build_args", string(args))
+	}
+	if req.Timeout != "" {
+		query.Set("timeout", req.Timeout)
+	}
+	return query, nil
+}
+
+// isUnsupportedBuildStatus reports whether err, returned for a build
+// request, means the server has no build endpoint: a 405 or 501, or a 404
+// without a more specific code than NOT_FOUND.
+func isUnsupportedBuildStatus(err *Error) bool {
+	switch err.Status {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	case http.StatusNotFound:
+		return err.Code == ErrNotFound.Code
+	default:
+		return false
+	}
+}
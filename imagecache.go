@@ -0,0 +1,231 @@
+package stromboli
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// imageCache holds the images returned by [Client.GetImage], by the name
+// they were requested with (see [WithImageCache]), and the image ID last
+// returned for each name, which [Client.ImageChanged] compares against.
+type imageCache struct {
+	// mu protects entries.
+	mu sync.Mutex
+
+	// entries holds the cached images by requested name.
+	entries map[string]*imageCacheEntry
+}
+
+// imageCacheEntry is the cache entry of one image name.
+type imageCacheEntry struct {
+	// ref identifies the image in ListImages results: "repository:tag",
+	// or the requested name if the server didn't report a repository.
+	ref string
+
+	// id is the image ID last returned to the caller.
+	id string
+
+	// image is the cached image, or nil if it must be fetched again.
+	image *Image
+
+	// validatedAt is when image was fetched or last found unchanged.
+	validatedAt time.Time
+}
+
+// newImageCache creates an empty image cache.
+func newImageCache() *imageCache {
+	return &imageCache{entries: make(map[string]*imageCacheEntry)}
+}
+
+// imageRef returns the reference identifying img in ListImages results.
+func imageRef(img *Image, name string) string {
+	if img.Repository == "" {
+		return name
+	}
+	return img.Repository + ":" + img.Tag
+}
+
+// listedID returns the ID of the image of e in images listed by
+// ListImages, and whether it is listed.
+func (e *imageCacheEntry) listedID(listed []*Image) (string, bool) {
+	for _, img := range listed {
+		if imageRef(img, "") == e.ref || img.ID == e.ref {
+			return img.ID, true
+		}
+	}
+	return "", false
+}
+
+// copyImage returns a deep copy of img, so that callers can't modify the
+// cached image.
+func copyImage(img *Image) *Image {
+	dup := *img
+	dup.Tools = append([]string(nil), img.Tools...)
+	return &dup
+}
+
+// get returns a copy of the image cached for name if it was validated
+// less than ttl before now, and whether it must be revalidated: an image is
+// cached but expired.
+func (ic *imageCache) get(name string, ttl time.Duration, now time.Time) (img *Image, expired bool) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	e, ok := ic.entries[name]
+	if !ok || e.image == nil {
+		return nil, false
+	}
+	if now.Sub(e.validatedAt) >= ttl {
+		return nil, true
+	}
+	return copyImage(e.image), false
+}
+
+// known reports whether an image ID was returned for name.
+func (ic *imageCache) known(name string) bool {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	_, ok := ic.entries[name]
+	return ok
+}
+
+// store caches img as the image of name, fetched at now.
+func (ic *imageCache) store(name string, img *Image, now time.Time) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	ic.entries[name] = &imageCacheEntry{
+		ref:         imageRef(img, name),
+		id:          img.ID,
+		image:       copyImage(img),
+		validatedAt: now,
+	}
+}
+
+// revalidate compares the cached images with the images listed by
+// ListImages at now: unchanged images are valid for another TTL, and
+// images with a new ID or no longer listed are dropped.
+func (ic *imageCache) revalidate(listed []*Image, now time.Time) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	for _, e := range ic.entries {
+		if e.image == nil {
+			continue
+		}
+		if id, ok := e.listedID(listed); ok && id == e.id {
+			e.validatedAt = now
+		} else {
+			e.image = nil
+		}
+	}
+}
+
+// invalidate drops the cached image requested as name or known by that
+// reference, keeping its last returned ID for ImageChanged.
+func (ic *imageCache) invalidate(name string) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	for key, e := range ic.entries {
+		if key == name || e.ref == name {
+			e.image = nil
+		}
+	}
+}
+
+// compare compares the ID last returned for name with the images listed by
+// ListImages. It reports whether name was returned before (known), whether
+// the image is still listed, and whether its ID changed, in which case the
+// new ID is recorded and the cached image dropped. An image no longer
+// listed is forgotten.
+func (ic *imageCache) compare(name string, listed []*Image) (known, found, changed bool) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	e, ok := ic.entries[name]
+	if !ok {
+		return false, false, false
+	}
+	id, found := e.listedID(listed)
+	if !found {
+		delete(ic.entries, name)
+		return true, false, false
+	}
+	if id == e.id {
+		return true, true, false
+	}
+	e.id, e.image = id, nil
+	return true, true, true
+}
+
+// cachedImage returns the image cached for name by [WithImageCache], or nil
+// if it must be fetched. An expired image is revalidated with ListImages,
+// along with the other cached images.
+func (c *Client) cachedImage(ctx context.Context, name string) *Image {
+	if c.imageCacheTTL <= 0 {
+		return nil
+	}
+	img, expired := c.images.get(name, c.imageCacheTTL, c.clock.Now())
+	if !expired {
+		return img
+	}
+	listed, err := c.ListImages(ctx)
+	if err != nil {
+		return nil // fetch the image instead
+	}
+	c.images.revalidate(listed, c.clock.Now())
+	img, _ = c.images.get(name, c.imageCacheTTL, c.clock.Now())
+	return img
+}
+
+// ImageChanged reports whether the image name was re-pulled or rebuilt
+// since the client last returned it: whether its ID, as listed by
+// [Client.ListImages], differs from the ID of the last [Client.GetImage]
+// result for name, or of the last ImageChanged call reporting a change.
+// Use it to invalidate state you derived from the image, such as a
+// compatibility check.
+//
+// If the client hasn't returned the image yet, it is fetched with
+// GetImage and false is returned. A change is reported once, and drops the
+// image cached by [WithImageCache]. If the image is no longer listed,
+// [ErrImageNotFound] is returned.
+//
+// Example:
+//
+//	changed, err := client.ImageChanged(ctx, "python:3.12-slim")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if changed {
+//	    image, _ := client.GetImage(ctx, "python:3.12-slim")
+//	    compatible = image.Compatible
+//	}
+func (c *Client) ImageChanged(ctx context.Context, name string) (_ bool, err error) {
+	ctx, op := c.observe(ctx, "ImageChanged")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return false, err
+	}
+
+	if name == "" {
+		return false, newError("BAD_REQUEST", "image name is required", 400, nil)
+	}
+
+	if !c.images.known(name) {
+		_, err := c.GetImage(ctx, name)
+		return false, err
+	}
+
+	listed, err := c.ListImages(ctx)
+	if err != nil {
+		return false, err
+	}
+	known, found, changed := c.images.compare(name, listed)
+	if !known {
+		// Forgotten concurrently: start over from the current image
+		_, err := c.GetImage(ctx, name)
+		return false, err
+	}
+	if !found {
+		return false, ErrImageNotFound
+	}
+	return changed, nil
+}
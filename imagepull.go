@@ -0,0 +1,165 @@
+package stromboli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// pullProgressMediaType is the Accept header sent by
+// [Client.PullImageWithProgress] to ask for a progress stream.
+const pullProgressMediaType = "application/x-ndjson"
+
+// maxPullProgressLine is the longest progress line accepted from the server.
+const maxPullProgressLine = 1 << 20
+
+// PullProgressEvent is a progress update reported by
+// [Client.PullImageWithProgress].
+type PullProgressEvent struct {
+	// ID is the ID of the layer the update is about, or "" for updates
+	// about the whole image.
+	// Example: "a2abf6c4d29d"
+	ID string `json:"id,omitempty"`
+
+	// Status describes the step of the pull.
+	// Example: "Downloading", "Extracting", "Pull complete"
+	Status string `json:"status,omitempty"`
+
+	// Current is the number of bytes of the layer processed so far in this
+	// step, or 0 if unknown.
+	Current int64 `json:"current,omitempty"`
+
+	// Total is the size of the layer in bytes, or 0 if unknown.
+	Total int64 `json:"total,omitempty"`
+}
+
+// pullProgressLine is a line of the progress stream of an image pull.
+//
+// Progress lines carry the fields of [PullProgressEvent]. The last line has
+// Done set and the fields of [PullImageResponse], or Error set if the pull
+// failed.
+type pullProgressLine struct {
+	PullProgressEvent
+
+	Done    bool   `json:"done,omitempty"`
+	Success bool   `json:"success,omitempty"`
+	Image   string `json:"image,omitempty"`
+	ImageID string `json:"image_id,omitempty"`
+
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+}
+
+// PullImageWithProgress pulls a container image like [Client.PullImage],
+// calling progress with per-layer progress while the image downloads and
+// extracts. progress may be nil, and is called from the calling goroutine.
+//
+// Large images can take far longer to pull than the client timeout, so the
+// pull is bounded by the stream timeout (see [WithStreamTimeout]) rather
+// than the client timeout, like [Client.Stream]: with the defaults, only ctx
+// bounds it. Use [WithCallTimeout] to bound a single pull.
+//
+// Servers that don't stream pull progress answer with the result only: the
+// image is pulled without calling progress.
+//
+// Example:
+//
+//	resp, err := client.PullImageWithProgress(ctx, &stromboli.PullImageRequest{
+//	    Image: "python:3.12",
+//	}, func(ev *stromboli.PullProgressEvent) {
+//	    if ev.Total > 0 {
+//	        fmt.Printf("%s %s %d/%d\n", ev.ID, ev.Status, ev.Current, ev.Total)
+//	    }
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("Pulled %s\n", resp.ImageID)
+func (c *Client) PullImageWithProgress(ctx context.Context, req *PullImageRequest, progress func(*PullProgressEvent), opts ...CallOption) (_ *PullImageResponse, err error) {
+	ctx, op := c.observe(ctx, "PullImageWithProgress")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if req == nil {
+		return nil, newError("BAD_REQUEST", "request is required", 400, nil)
+	}
+	if req.Image == "" {
+		return nil, newError("BAD_REQUEST", "image name is required", 400, nil)
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, newError("BAD_REQUEST", "failed to encode request", 400, err)
+	}
+
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
+	if timeout := callTimeout(ctx, c.streamTimeout); timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		defer cancelTimeout()
+	}
+
+	resp, err := c.doRaw(ctx, http.MethodPost, "/images/pull", nil, bytes.NewReader(data), "application/json", pullProgressMediaType)
+	c.images.invalidate(req.Image) // the pull may have started even if it failed
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Servers without progress streams answer with the pull result
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != pullProgressMediaType {
+		var result PullImageResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, newError("INVALID_RESPONSE", "failed to decode pull response", resp.StatusCode, err)
+		}
+		return &result, nil
+	}
+	return c.readPullProgress(resp, progress)
+}
+
+// readPullProgress reads the progress stream of an image pull until its
+// last line, reporting progress lines to progress.
+func (c *Client) readPullProgress(resp *http.Response, progress func(*PullProgressEvent)) (*PullImageResponse, error) {
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxPullProgressLine)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var line pullProgressLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, newError("INVALID_RESPONSE", "failed to decode pull progress", resp.StatusCode, err)
+		}
+		switch {
+		case line.Error != "":
+			code := line.Code
+			if code == "" {
+				code = ErrInternal.Code
+			}
+			return nil, newError(code, fmt.Sprintf("failed to pull image: %s", line.Error), 0, nil)
+		case line.Done:
+			return &PullImageResponse{
+				Success: line.Success,
+				Image:   line.Image,
+				ImageID: line.ImageID,
+			}, nil
+		case progress != nil:
+			event := line.PullProgressEvent
+			progress(&event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, c.handleError(err, "failed to read pull progress")
+	}
+	return nil, newError("INVALID_RESPONSE", "pull progress ended without a result", resp.StatusCode, nil)
+}
@@ -0,0 +1,196 @@
+package stromboli
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Defaults of image references, applied by [ImageRef.Normalize].
+const (
+	defaultImageRegistry  = "docker.io"
+	defaultImageNamespace = "library"
+	defaultImageTag       = "latest"
+)
+
+var (
+	// imageRegistryPattern matches a registry host with an optional port.
+	imageRegistryPattern = regexp.MustCompile(`^(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?$`)
+
+	// imagePathComponentPattern matches a component of a repository path.
+	imagePathComponentPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+
+	// imageTagPattern matches a tag.
+	imageTagPattern = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+
+	// imageDigestPattern matches a digest ("algorithm:hex").
+	imageDigestPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+)
+
+// maxImageNameLength is the maximum length of the registry and repository
+// of a reference.
+const maxImageNameLength = 255
+
+// ImageRef is a parsed container image reference, such as "python:3.12"
+// or "ghcr.io/org/agent:1.4@sha256:...".
+//
+// Image names are passed to the SDK as strings; use [ParseImageRef] to
+// validate them and [ImageRef.Normalize] to compare references written
+// differently. The String form can be used wherever the SDK takes an image
+// name ([Client.GetImage], [PullImageRequest.Image], [PodmanOptions.Image]):
+//
+//	ref, err := stromboli.ParseImageRef("python:3.12")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	img, err := client.GetImage(ctx, ref.String())
+type ImageRef struct {
+	// Registry is the registry host, with its port if any. Empty if the
+	// reference doesn't name one.
+	// Example: "ghcr.io", "localhost:5000"
+	Registry string
+
+	// Repository is the repository path within the registry.
+	// Example: "python", "org/agent"
+	Repository string
+
+	// Tag is the tag, empty if the reference has none.
+	// Example: "3.12-slim"
+	Tag string
+
+	// Digest is the content digest, empty if the reference has none.
+	// Example: "sha256:abc123..."
+	Digest string
+}
+
+// String returns the reference in its canonical form:
+// "[registry/]repository[:tag][@digest]".
+func (r ImageRef) String() string {
+	s := r.Repository
+	if r.Registry != "" {
+		s = r.Registry + "/" + s
+	}
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// Normalize returns the reference with Docker's defaults applied: the
+// "docker.io" registry, the "library/" namespace for its single-component
+// repositories, and the "latest" tag if the reference has neither a tag
+// nor a digest. "index.docker.io" is normalized to "docker.io".
+//
+// Example:
+//
+//	ref, _ := stromboli.ParseImageRef("python")
+//	ref.Normalize().String() // "docker.io/library/python:latest"
+func (r ImageRef) Normalize() ImageRef {
+	if r.Registry == "" || r.Registry == "index.docker.io" {
+		r.Registry = defaultImageRegistry
+	}
+	if r.Registry == defaultImageRegistry && !strings.Contains(r.Repository, "/") {
+		r.Repository = defaultImageNamespace + "/" + r.Repository
+	}
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = defaultImageTag
+	}
+	return r
+}
+
+// ParseImageRef parses an image reference
+// ("[registry/]repository[:tag][@digest]").
+//
+// The first path component is the registry if it contains a "." or ":",
+// or is "localhost"; "localhost:5000/agent" names the "agent" repository of
+// the "localhost:5000" registry. Repositories must be lower case. A
+// reference can have both a tag and a digest. Invalid references return a
+// BAD_REQUEST [Error].
+//
+// Example:
+//
+//	ref, err := stromboli.ParseImageRef("ghcr.io/org/agent:1.4")
+//	// ref == ImageRef{Registry: "ghcr.io", Repository: "org/agent", Tag: "1.4"}
+func ParseImageRef(s string) (ImageRef, error) {
+	ref, err := parseImageRef(s)
+	if err != nil {
+		return ImageRef{}, newError("BAD_REQUEST", fmt.Sprintf("invalid image reference %q: %v", s, err), 400, nil)
+	}
+	return ref, nil
+}
+
+// parseImageRef is ParseImageRef without the [Error] wrapping.
+func parseImageRef(s string) (ImageRef, error) {
+	if s == "" {
+		return ImageRef{}, errors.New("reference is empty")
+	}
+
+	var ref ImageRef
+	name, digest, hasDigest := strings.Cut(s, "@")
+	if hasDigest {
+		if !imageDigestPattern.MatchString(digest) {
+			return ImageRef{}, fmt.Errorf("invalid digest %q", digest)
+		}
+		ref.Digest = digest
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		if !imageTagPattern.MatchString(name[i+1:]) {
+			return ImageRef{}, fmt.Errorf("invalid tag %q", name[i+1:])
+		}
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if len(name) > maxImageNameLength {
+		return ImageRef{}, fmt.Errorf("name is longer than %d characters", maxImageNameLength)
+	}
+	if first, rest, ok := strings.Cut(name, "/"); ok && isImageRegistry(first) {
+		if !imageRegistryPattern.MatchString(first) {
+			return ImageRef{}, fmt.Errorf("invalid registry %q", first)
+		}
+		ref.Registry = first
+		name = rest
+	}
+
+	if name == "" {
+		return ImageRef{}, errors.New("repository is empty")
+	}
+	if name != strings.ToLower(name) {
+		return ImageRef{}, errors.New("repository must be lower case")
+	}
+	for _, component := range strings.Split(name, "/") {
+		if !imagePathComponentPattern.MatchString(component) {
+			return ImageRef{}, fmt.Errorf("invalid repository component %q", component)
+		}
+	}
+	ref.Repository = name
+	return ref, nil
+}
+
+// isImageRegistry reports whether the first path component of a reference
+// names a registry rather than a repository namespace.
+func isImageRegistry(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost"
+}
+
+// Ref returns the reference of the image, parsed from its Repository and
+// Tag, e.g. to compare it with a wanted reference after
+// [ImageRef.Normalize]. Untagged images ("<none>") have no Tag.
+//
+// Example:
+//
+//	want, _ := stromboli.ParseImageRef("python:3.12")
+//	got, err := img.Ref()
+//	if err == nil && got.Normalize() == want.Normalize() {
+//	    fmt.Println("found")
+//	}
+func (i *Image) Ref() (ImageRef, error) {
+	s := i.Repository
+	if i.Tag != "" && i.Tag != "<none>" {
+		s += ":" + i.Tag
+	}
+	return ParseImageRef(s)
+}
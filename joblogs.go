@@ -0,0 +1,154 @@
+package stromboli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// LogsOptions configures [Client.GetJobLogs].
+type LogsOptions struct {
+	// Tail limits the logs to their last Tail lines (0 for all lines).
+	Tail int
+
+	// Since limits the logs to the lines written after Since (zero for all
+	// lines).
+	Since time.Time
+
+	// Follow keeps the logs open while the job runs: the reader returns new
+	// lines as the container writes them, and reaches EOF when the job
+	// finishes.
+	Follow bool
+}
+
+// GetJobLogs returns the raw container logs of a job, e.g. to debug a
+// failed lifecycle hook when [CrashInfo.PartialOutput] is truncated. The
+// returned reader must be closed.
+//
+// With opts.Follow, the reader stays open and streams lines until the job
+// finishes, ctx is cancelled, or the reader is closed; like
+// [Client.Stream], it is bounded by the stream timeout (see
+// [WithStreamTimeout]) rather than the client timeout. Without it, the
+// client timeout covers reading the logs.
+//
+// It returns an error matching [ErrNotFound] if the job doesn't exist, an
+// [Error] with code "UNSUPPORTED" if the server doesn't expose job logs,
+// and a BAD_REQUEST error if jobID is empty or opts.Tail is negative.
+//
+// Example:
+//
+//	logs, err := client.GetJobLogs(ctx, jobID, &stromboli.LogsOptions{Tail: 100})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer logs.Close()
+//	io.Copy(os.Stdout, logs)
+func (c *Client) GetJobLogs(ctx context.Context, jobID string, opts *LogsOptions) (_ io.ReadCloser, err error) {
+	ctx, op := c.observe(ctx, "GetJobLogs")
+	defer op.finish(&err)
+	op.setAttribute(AttributeJobID, jobID)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if jobID == "" {
+		return nil, newError("BAD_REQUEST", "job ID is required", 400, nil)
+	}
+	if opts == nil {
+		opts = &LogsOptions{}
+	}
+	if opts.Tail < 0 {
+		return nil, newError("BAD_REQUEST", "tail must not be negative", 400, nil)
+	}
+
+	query := url.Values{}
+	if opts.Tail > 0 {
+		query.Set("tail", strconv.Itoa(opts.Tail))
+	}
+	if !opts.Since.IsZero() {
+		query.Set("since", opts.Since.UTC().Format(time.RFC3339Nano))
+	}
+	if opts.Follow {
+		query.Set("follow", "true")
+	}
+
+	reqCtx, cancel := c.withBaseContext(ctx)
+	timeout := c.effectiveTimeout(reqCtx)
+	if opts.Follow {
+		timeout = callTimeout(reqCtx, c.streamTimeout)
+	}
+	if timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		reqCtx, cancelTimeout = context.WithTimeout(reqCtx, timeout)
+		cancelBase := cancel
+		cancel = func() {
+			cancelTimeout()
+			cancelBase()
+		}
+	}
+
+	resp, err := c.doRaw(reqCtx, http.MethodGet, "/jobs/"+url.PathEscape(jobID)+"/logs", query, nil, "", "text/plain")
+	if err != nil {
+		cancel()
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+			return nil, err
+		}
+		// The 404 may come from a server without job logs rather than an
+		// unknown job; look the job up to tell the two apart.
+		if _, jobErr := c.GetJob(ctx, jobID); jobErr != nil {
+			return nil, jobErr
+		}
+		return nil, newError("UNSUPPORTED", "server does not support job logs", http.StatusNotImplemented, err)
+	}
+	return &logsReader{body: resp.Body, cancel: cancel}, nil
+}
+
+// GetJobLogsString returns the container logs of a job as a string, read
+// with [Client.GetJobLogs]. It is meant for small logs: use opts.Tail to
+// bound them. With opts.Follow, it returns once the job finishes.
+//
+// Example:
+//
+//	logs, err := client.GetJobLogsString(ctx, jobID, &stromboli.LogsOptions{Tail: 50})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(logs)
+func (c *Client) GetJobLogsString(ctx context.Context, jobID string, opts *LogsOptions) (string, error) {
+	logs, err := c.GetJobLogs(ctx, jobID, opts)
+	if err != nil {
+		return "", err
+	}
+	defer logs.Close()
+
+	data, err := io.ReadAll(logs)
+	if err != nil {
+		return "", c.handleError(err, "failed to read job logs")
+	}
+	return string(data), nil
+}
+
+// logsReader is the body of a job logs response, releasing the request's
+// context when closed.
+type logsReader struct {
+	body   io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Read reads from the response body.
+func (r *logsReader) Read(p []byte) (int, error) {
+	return r.body.Read(p)
+}
+
+// Close closes the response body and cancels the request.
+func (r *logsReader) Close() error {
+	err := r.body.Close()
+	r.cancel()
+	return err
+}
@@ -0,0 +1,257 @@
+package stromboli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+)
+
+// DebugLevel selects what the client logs about its HTTP requests (see
+// [WithDebug]).
+type DebugLevel int
+
+const (
+	// DebugOff logs nothing about requests. This is the default.
+	DebugOff DebugLevel = iota
+
+	// DebugHeaders logs the method, URL, status and duration of every
+	// request.
+	DebugHeaders
+
+	// DebugBody additionally dumps requests and responses, headers and
+	// bodies, with secrets redacted. Streamed bodies (event streams,
+	// followed logs, pull progress, build contexts) are left out.
+	DebugBody
+)
+
+// maxDebugBodySize limits the size of the bodies dumped at [DebugBody].
+const maxDebugBodySize = 4096
+
+// legacyLogger writes slog records to the SDK's [Logger] (see SetLogger),
+// for debug output of clients without [WithSlog].
+var legacyLogger = slog.New(slog.NewTextHandler(loggerWriter{}, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+// loggerWriter writes lines to the SDK's [Logger].
+type loggerWriter struct{}
+
+// Write implements io.Writer.
+func (loggerWriter) Write(p []byte) (int, error) {
+	getLogger().Printf("%s", bytes.TrimSuffix(p, []byte("\n")))
+	return len(p), nil
+}
+
+// logf logs a message of the client at level: to its slog logger if set
+// (see [WithSlog]), or else to the SDK's [Logger], prefixed with
+// "stromboli: " and, for warnings, "WARNING: ". Debug messages are only
+// logged to a slog logger.
+func (c *Client) logf(level slog.Level, format string, args ...interface{}) {
+	if c.slogger != nil {
+		c.slogger.Log(context.Background(), level, fmt.Sprintf(format, args...))
+		return
+	}
+	if level < slog.LevelInfo {
+		return
+	}
+	prefix := "stromboli: "
+	if level >= slog.LevelWarn {
+		prefix += "WARNING: "
+	}
+	getLogger().Printf(prefix+format, args...)
+}
+
+// debugTransport logs the requests of a client (see [WithDebug]). It wraps
+// the client's HTTP transport, so requests made by the generated client,
+// streams and raw JSON calls are all covered.
+type debugTransport struct {
+	base   http.RoundTripper
+	level  DebugLevel
+	logger *slog.Logger
+	clock  Clock
+}
+
+// RoundTrip implements http.RoundTripper. Bodies are buffered before being
+// dumped and handed on, so neither the transport nor the caller misses
+// any of them. Streamed bodies are left out of the dumps rather than
+// buffered: request bodies that can't be replayed (such as image build
+// contexts) and responses to requests that don't accept JSON (event
+// streams, followed logs, pull progress).
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	attrs := []slog.Attr{slog.String("method", req.Method), slog.String("url", req.URL.Redacted())}
+	if t.level >= DebugBody {
+		dump, err := dumpRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, slog.String("request", dump))
+	}
+
+	start := t.clock.Now()
+	resp, err := base.RoundTrip(req)
+	attrs = append(attrs, slog.Duration("duration", t.clock.Now().Sub(start)))
+
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if t.level >= DebugBody {
+			attrs = append(attrs, slog.String("response", dumpResponse(resp, acceptsJSON(req.Header.Get("Accept")))))
+		}
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	t.logger.LogAttrs(req.Context(), slog.LevelDebug, "stromboli: request", attrs...)
+
+	return resp, err
+}
+
+// dumpRequest dumps req with secrets redacted. The body is buffered and
+// put back, so it is still sent in full; it is left out if it can't be
+// replayed (GetBody is nil), as it is then streamed.
+func dumpRequest(req *http.Request) (string, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return "", err
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	clone := req.Clone(req.Context())
+	redactHeaders(clone.Header)
+	clone.Body = nil
+	if body != nil {
+		body = redactBody(body)
+		clone.Body = io.NopCloser(bytes.NewReader(body))
+		clone.ContentLength = int64(len(body))
+	}
+	dump, err := httputil.DumpRequestOut(clone, clone.Body != nil)
+	if err != nil {
+		return "", err
+	}
+	return limitDump(dump), nil
+}
+
+// dumpResponse dumps resp with secrets redacted. The body is buffered and
+// put back for the caller if withBody is true; otherwise, and for event
+// streams, it is left out, as it is read incrementally.
+func dumpResponse(resp *http.Response, withBody bool) string {
+	isStream := !withBody || strings.HasPrefix(strings.ToLower(resp.Header.Get("Content-Type")), "text/event-stream")
+	var body []byte
+	if !isStream && resp.Body != nil {
+		var err error
+		body, err = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			// Hand the error to the caller when it reads the body
+			resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		}
+	}
+
+	clone := *resp
+	clone.Header = resp.Header.Clone()
+	redactHeaders(clone.Header)
+	body = redactBody(body)
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+	if !isStream {
+		clone.ContentLength = int64(len(body))
+	}
+	dump, err := httputil.DumpResponse(&clone, !isStream)
+	if err != nil {
+		return fmt.Sprintf("(dump failed: %v)", err)
+	}
+	return limitDump(dump)
+}
+
+// errReader is an io.Reader failing with err.
+type errReader struct {
+	err error
+}
+
+// Read implements io.Reader.
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// redactHeaders redacts the credentials in h.
+func redactHeaders(h http.Header) {
+	for _, name := range []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"} {
+		if h.Get(name) != "" {
+			h.Set(name, redacted)
+		}
+	}
+}
+
+// redactBody redacts the secrets in a JSON body: the string values of
+// fields named "value" or whose name contains "token" or "secret". Other
+// bodies are returned as-is.
+func redactBody(body []byte) []byte {
+	var v interface{}
+	if len(body) == 0 || json.Unmarshal(body, &v) != nil {
+		return body
+	}
+	out, err := json.Marshal(redactJSON(v))
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// redactJSON redacts the secrets in a decoded JSON value (see redactBody).
+func redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if _, isString := value.(string); isString && isSecretField(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactJSON(value)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactJSON(v[i])
+		}
+	}
+	return v
+}
+
+// isSecretField reports whether a JSON field holds a secret.
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	return name == "value" || strings.Contains(name, "token") || strings.Contains(name, "secret")
+}
+
+// limitDump returns dump as a string, truncated after the headers and
+// maxDebugBodySize bytes of body.
+func limitDump(dump []byte) string {
+	headerEnd := bytes.Index(dump, []byte("\r\n\r\n"))
+	if headerEnd < 0 || len(dump)-headerEnd-4 <= maxDebugBodySize {
+		return string(dump)
+	}
+	limit := headerEnd + 4 + maxDebugBodySize
+	return fmt.Sprintf("%s... (%d bytes truncated)", dump[:limit], len(dump)-limit)
+}
+
+// debugLogger returns the logger of the client's debug output.
+func (c *Client) debugLogger() *slog.Logger {
+	if c.slogger != nil {
+		return c.slogger
+	}
+	return legacyLogger
+}
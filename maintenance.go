@@ -0,0 +1,218 @@
+package stromboli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maintenanceHeader flags 503 responses caused by a maintenance window.
+// Its value is "true" or the expected end time in RFC 3339 format.
+const maintenanceHeader = "X-Maintenance"
+
+// MaintenanceError is returned when the server rejects a request because it
+// is in read-only or maintenance mode.
+//
+// It matches [ErrMaintenance] with errors.Is and, since the server answers
+// with 503, also [ErrUnavailable]. Use errors.As to read the expected end of
+// the maintenance window:
+//
+//	var maint *stromboli.MaintenanceError
+//	if errors.As(err, &maint) && !maint.Until.IsZero() {
+//	    fmt.Printf("maintenance until %s\n", maint.Until)
+//	}
+type MaintenanceError struct {
+	// Err is the underlying [Error], with Code "MAINTENANCE". Its
+	// RetryAfter is the time remaining until Until (zero if unknown), and
+	// its Status is zero if the request was rejected without contacting
+	// the server.
+	Err *Error
+
+	// Until is when the server expects the maintenance to end.
+	// Zero if the server didn't say.
+	Until time.Time
+}
+
+// Error returns a string representation of the error.
+func (e *MaintenanceError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying [Error], so errors.As(err, &apiErr) works
+// for a *Error target.
+func (e *MaintenanceError) Unwrap() error {
+	return e.Err
+}
+
+// maintenanceBody is the maintenance indicator of a 503 error body, e.g.
+// {"error":"read-only mode","maintenance":true,"maintenance_until":"2024-01-15T12:00:00Z"}.
+type maintenanceBody struct {
+	Maintenance      bool   `json:"maintenance"`
+	MaintenanceUntil string `json:"maintenance_until"`
+}
+
+// parseMaintenance reports whether a response indicates maintenance mode and,
+// if the server says so, when it ends.
+//
+// A 503 response is a maintenance response if its body has
+// "maintenance": true or it carries the X-Maintenance header. The end time
+// is taken from the body's maintenance_until field, an RFC 3339
+// X-Maintenance value, or the Retry-After header, in that order.
+func parseMaintenance(status int, header http.Header, body []byte, now time.Time) (time.Time, bool) {
+	if status != http.StatusServiceUnavailable {
+		return time.Time{}, false
+	}
+
+	var mb maintenanceBody
+	_ = json.Unmarshal(body, &mb)
+	flag := strings.TrimSpace(header.Get(maintenanceHeader))
+	flagged := flag != "" && flag != "0" && !strings.EqualFold(flag, "false")
+	if !mb.Maintenance && !flagged {
+		return time.Time{}, false
+	}
+
+	if until, err := time.Parse(time.RFC3339, mb.MaintenanceUntil); err == nil {
+		return until, true
+	}
+	if until, err := time.Parse(time.RFC3339, flag); err == nil {
+		return until, true
+	}
+	if wait, ok := parseRetryAfter(header.Get("Retry-After"), now); ok {
+		return now.Add(wait), true
+	}
+	return time.Time{}, true
+}
+
+// parseRetryAfter parses a Retry-After header value, either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if date.Before(now) {
+			return 0, true
+		}
+		return date.Sub(now), true
+	}
+	return 0, false
+}
+
+// maintenanceState is the last maintenance observation of a client.
+type maintenanceState struct {
+	// until is the expected end of the maintenance (zero if unknown).
+	until time.Time
+}
+
+// observeMaintenance records the maintenance state reported by a response
+// and returns the result of [parseMaintenance].
+//
+// A maintenance response starts (or extends) the maintenance window. Only a
+// successful mutating request ends it early: in read-only mode, reads keep
+// succeeding while writes are rejected.
+func (c *Client) observeMaintenance(mutating bool, status int, header http.Header, body []byte) (time.Time, bool) {
+	until, ok := parseMaintenance(status, header, body, c.clock.Now())
+
+	c.maintenanceMu.Lock()
+	defer c.maintenanceMu.Unlock()
+	switch {
+	case ok:
+		c.maintenance = &maintenanceState{until: until}
+	case mutating && status >= 200 && status < 300:
+		c.maintenance = nil
+	}
+	return until, ok
+}
+
+// MaintenanceUntil reports whether the server was in read-only or
+// maintenance mode at the last observation, and when it expects the
+// maintenance to end (zero if unknown).
+//
+// The state is updated from every response: a maintenance response (see
+// [MaintenanceError]) starts the window, and it ends when the advertised
+// end time passes or a mutating request succeeds. Orchestrators can use it
+// to pause submissions proactively:
+//
+//	if until, ok := client.MaintenanceUntil(); ok {
+//	    log.Printf("stromboli in maintenance until %s, pausing", until)
+//	    return
+//	}
+//
+// While the end time is known and in the future, mutating calls (Run,
+// RunAsync, Stream, CreateSecret, ...) fail fast with a [MaintenanceError]
+// without contacting the server.
+func (c *Client) MaintenanceUntil() (time.Time, bool) {
+	c.maintenanceMu.Lock()
+	state := c.maintenance
+	c.maintenanceMu.Unlock()
+
+	if state == nil {
+		return time.Time{}, false
+	}
+	if !state.until.IsZero() && !c.clock.Now().Before(state.until) {
+		return time.Time{}, false
+	}
+	return state.until, true
+}
+
+// failFastInMaintenance returns a [MaintenanceError] if the server has
+// advertised a maintenance window that hasn't ended yet, so that mutating
+// requests aren't sent only to be rejected.
+func (c *Client) failFastInMaintenance() error {
+	until, ok := c.MaintenanceUntil()
+	if !ok || until.IsZero() {
+		return nil
+	}
+	message := fmt.Sprintf("server is in maintenance until %s", until.Format(time.RFC3339))
+	return newMaintenanceError(message, 0, until, c.clock.Now(), nil)
+}
+
+// maintenanceError converts a 503 error into a [MaintenanceError] if the
+// client observed that the server is in maintenance mode. Other errors are
+// returned unchanged.
+//
+// It is used for errors of the generated client, whose responses are
+// observed by the transport before the error is returned.
+func (c *Client) maintenanceError(err error) error {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusServiceUnavailable {
+		return err
+	}
+	var maint *MaintenanceError
+	if errors.As(err, &maint) {
+		return err
+	}
+	until, ok := c.MaintenanceUntil()
+	if !ok {
+		return err
+	}
+	return newMaintenanceError(apiErr.Message, apiErr.Status, until, c.clock.Now(), err)
+}
+
+// newMaintenanceError creates a [MaintenanceError] whose RetryAfter is the
+// time remaining until the end of the maintenance.
+func newMaintenanceError(message string, status int, until, now time.Time, cause error) *MaintenanceError {
+	err := newError(ErrMaintenance.Code, message, status, cause)
+	if until.After(now) {
+		err.RetryAfter = until.Sub(now)
+	}
+	return &MaintenanceError{Err: err, Until: until}
+}
+
+// isMutatingMethod reports whether an HTTP method may change server state.
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
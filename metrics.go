@@ -0,0 +1,123 @@
+package stromboli
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Metrics receives every HTTP request made by a client, e.g. to export
+// request counts and latencies (see [WithMetrics]). The metrics subpackage
+// provides a ready-made implementation in the Prometheus text format.
+//
+// Unlike an [Observer], which sees client operations, Metrics sees each
+// HTTP request, including retries, internal calls such as capability
+// probes, and stream connections.
+type Metrics interface {
+	// ObserveRequest is called when a request completes, with its HTTP
+	// method, the templated path of the endpoint (e.g. "/jobs/{id}"), the
+	// response status (0 if no response was received) and its duration.
+	// For streams, the duration is the time to receive the response
+	// headers. It must be safe for concurrent use.
+	ObserveRequest(method, path string, status int, duration time.Duration)
+}
+
+// UnmatchedPath is the path reported to [Metrics] for requests to paths
+// that don't match a known endpoint.
+const UnmatchedPath = "{unmatched}"
+
+// endpointTemplates are the templated paths of the API endpoints. Literal
+// segments take precedence over parameters (e.g. "/images/search" over
+// "/images/{name}").
+var endpointTemplates = [][]string{
+	{"auth", "logout"},
+	{"auth", "refresh"},
+	{"auth", "token"},
+	{"auth", "validate"},
+	{"capabilities"},
+	{"claude", "status"},
+	{"health"},
+	{"images"},
+	{"images", "build"},
+	{"images", "pull"},
+	{"images", "search"},
+	{"images", "{name}"},
+	{"jobs"},
+	{"jobs", "{id}"},
+	{"jobs", "{id}", "logs"},
+	{"jobs", "{id}", "stream"},
+	{"run"},
+	{"run", "async"},
+	{"run", "stream"},
+	{"run", "stream", "{id}"},
+	{"secrets"},
+	{"secrets", "{name}"},
+	{"sessions"},
+	{"sessions", "{id}"},
+	{"sessions", "{id}", "messages"},
+	{"sessions", "{id}", "messages", "{message_id}"},
+}
+
+// templatePath returns the templated path of the endpoint that path (an
+// escaped URL path, relative to the base URL) belongs to, or
+// [UnmatchedPath].
+func templatePath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	var best []string
+	bestLiterals := -1
+	for _, template := range endpointTemplates {
+		if len(template) != len(segments) {
+			continue
+		}
+		literals := 0
+		for i, segment := range template {
+			if strings.HasPrefix(segment, "{") {
+				continue
+			}
+			if segment != segments[i] {
+				literals = -1
+				break
+			}
+			literals++
+		}
+		if literals > bestLiterals {
+			best, bestLiterals = template, literals
+		}
+	}
+	if best == nil {
+		return UnmatchedPath
+	}
+	return "/" + strings.Join(best, "/")
+}
+
+// metricsTransport reports every request to a [Metrics]. It wraps the
+// client's HTTP transport, so requests made by the generated client,
+// streams and raw JSON calls are all covered.
+type metricsTransport struct {
+	base     http.RoundTripper
+	metrics  Metrics
+	clock    Clock
+	basePath string // path of the base URL, without trailing slash
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := t.clock.Now()
+	resp, err := base.RoundTrip(req)
+	duration := t.clock.Now().Sub(start)
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	path := templatePath(strings.TrimPrefix(req.URL.EscapedPath(), t.basePath))
+	t.metrics.ObserveRequest(req.Method, path, status, duration)
+
+	return resp, err
+}
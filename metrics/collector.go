@@ -0,0 +1,182 @@
+// Package metrics collects the HTTP requests of Stromboli clients and
+// exposes them in the Prometheus text exposition format.
+//
+// A [Collector] is a [stromboli.Metrics]: install it with
+// [stromboli.WithMetrics] and serve it on a metrics endpoint. It exports:
+//
+//   - stromboli_client_requests_total: a counter of requests by method,
+//     templated path and status ("0" when no response was received)
+//   - stromboli_client_request_duration_seconds: a histogram of request
+//     durations by method and templated path
+//
+// Error rates are derived from the status label, e.g.
+// sum(rate(stromboli_client_requests_total{status=~"5..|0"}[5m])).
+//
+// The package doesn't depend on the Prometheus client library, so it can
+// be used with any scraper that understands the text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the duration
+// histogram buckets of a [Collector] created without buckets. They span
+// quick API calls to long synchronous runs.
+var DefaultBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+var _ stromboli.Metrics = (*Collector)(nil)
+
+// Collector records the requests of Stromboli clients and serves them in
+// the Prometheus text format. It is safe for concurrent use, and can be
+// shared by several clients.
+//
+// Example:
+//
+//	collector := metrics.NewCollector()
+//	http.Handle("/metrics", collector)
+//
+//	client, err := stromboli.NewClient(url, stromboli.WithMetrics(collector))
+type Collector struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[endpointKey]*histogram
+}
+
+// endpointKey identifies an endpoint.
+type endpointKey struct {
+	method string
+	path   string
+}
+
+// requestKey identifies the requests to an endpoint with a given status.
+type requestKey struct {
+	endpointKey
+	status int
+}
+
+// histogram is the duration histogram of an endpoint.
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewCollector returns an empty [Collector] whose duration histograms use
+// the given bucket upper bounds, in seconds, or [DefaultBuckets] if none
+// are given.
+func NewCollector(buckets ...float64) *Collector {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Collector{
+		buckets:   buckets,
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[endpointKey]*histogram),
+	}
+}
+
+// ObserveRequest records a request. It implements [stromboli.Metrics].
+func (c *Collector) ObserveRequest(method, path string, status int, duration time.Duration) {
+	endpoint := endpointKey{method: method, path: path}
+	seconds := duration.Seconds()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests[requestKey{endpointKey: endpoint, status: status}]++
+	h, ok := c.durations[endpoint]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(c.buckets))}
+		c.durations[endpoint] = h
+	}
+	h.count++
+	h.sum += seconds
+	if i := sort.SearchFloat64s(c.buckets, seconds); i < len(c.buckets) {
+		h.counts[i]++
+	}
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = c.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format, series
+// sorted by labels. It implements io.WriterTo.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	c.mu.Lock()
+	requests := make([]requestKey, 0, len(c.requests))
+	for key := range c.requests {
+		requests = append(requests, key)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].endpointKey != requests[j].endpointKey {
+			return requests[i].endpointKey.less(requests[j].endpointKey)
+		}
+		return requests[i].status < requests[j].status
+	})
+	b.WriteString("# HELP stromboli_client_requests_total HTTP requests made by Stromboli clients.\n")
+	b.WriteString("# TYPE stromboli_client_requests_total counter\n")
+	for _, key := range requests {
+		fmt.Fprintf(&b, "stromboli_client_requests_total{method=%s,path=%s,status=\"%d\"} %d\n",
+			strconv.Quote(key.method), strconv.Quote(key.path), key.status, c.requests[key])
+	}
+
+	endpoints := make([]endpointKey, 0, len(c.durations))
+	for key := range c.durations {
+		endpoints = append(endpoints, key)
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].less(endpoints[j]) })
+	b.WriteString("# HELP stromboli_client_request_duration_seconds Duration of HTTP requests made by Stromboli clients.\n")
+	b.WriteString("# TYPE stromboli_client_request_duration_seconds histogram\n")
+	for _, key := range endpoints {
+		h := c.durations[key]
+		labels := fmt.Sprintf("method=%s,path=%s", strconv.Quote(key.method), strconv.Quote(key.path))
+		var cumulative uint64
+		for i, bound := range c.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "stromboli_client_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(&b, "stromboli_client_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&b, "stromboli_client_request_duration_seconds_sum{%s} %s\n", labels, formatFloat(h.sum))
+		fmt.Fprintf(&b, "stromboli_client_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+	c.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// less orders endpoints by path, then method.
+func (k endpointKey) less(other endpointKey) bool {
+	if k.path != other.path {
+		return k.path < other.path
+	}
+	return k.method < other.method
+}
+
+// formatFloat formats v as a Prometheus sample value.
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,183 @@
+package stromboli
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Observer receives the start and end of each API operation of a client,
+// e.g. to record per-operation metrics (see [WithObserver]).
+//
+// Operations are named after the client method, e.g. "Run", "Health" or
+// "GetJob", so metrics can be labelled without parsing URLs. Both methods
+// are called synchronously from the goroutine making the call and must be
+// safe for concurrent use.
+//
+// Example:
+//
+//	type metrics struct{}
+//
+//	func (metrics) OnStart(method string) {
+//	    inFlight.WithLabelValues(method).Inc()
+//	}
+//
+//	func (metrics) OnFinish(method string, status int, dur time.Duration, err error) {
+//	    inFlight.WithLabelValues(method).Dec()
+//	    latency.WithLabelValues(method, strconv.Itoa(status)).Observe(dur.Seconds())
+//	}
+type Observer interface {
+	// OnStart is called when an operation starts.
+	OnStart(method string)
+
+	// OnFinish is called when an operation returns, with the HTTP status
+	// of its last response (0 if no response was received, e.g. for
+	// network errors or requests rejected client-side), its duration and
+	// the error it returns (nil on success).
+	OnFinish(method string, status int, dur time.Duration, err error)
+}
+
+// Tracer starts a trace span for each API operation of a client (see
+// [WithTracer]). It decouples the SDK from tracing libraries: the
+// stromboliotel package provides an OpenTelemetry implementation.
+type Tracer interface {
+	// Start starts a span for the operation, a client method name such as
+	// "Run" (or "Stream.events" for the event loop of a stream), as a child
+	// of the span in ctx. It returns a context carrying the new span.
+	Start(ctx context.Context, operation string) (context.Context, TraceSpan)
+}
+
+// TraceSpan is a span started by a [Tracer].
+type TraceSpan interface {
+	// SetAttribute records an attribute of the operation, such as
+	// "stromboli.job.id".
+	SetAttribute(key, value string)
+
+	// End ends the span, with the HTTP status of the operation's last
+	// response (0 if none) and the error it returns (nil on success).
+	End(status int, err error)
+}
+
+// Attributes recorded on trace spans.
+const (
+	// AttributeJobID is the ID of the job an operation is about.
+	AttributeJobID = "stromboli.job.id"
+
+	// AttributeSessionID is the ID of the session an operation is about.
+	AttributeSessionID = "stromboli.session.id"
+)
+
+// operationKey is the context key of the operation being observed.
+type operationKey struct{}
+
+// operation is an observed operation in progress. A nil operation (no
+// observer, tracer or request IDs) does nothing.
+type operation struct {
+	observer Observer  // nil if not observed
+	span     TraceSpan // nil if not traced
+	clock    Clock
+	method   string
+	start    time.Time
+
+	// status is the HTTP status of the operation's last response.
+	status atomic.Int64
+
+	// requestID is the request ID of the operation's last request (see
+	// WithRequestIDHeader), nil if none was sent.
+	requestID atomic.Pointer[string]
+}
+
+// observe reports the start of the operation method to the client's
+// observer and starts its span with the client's tracer. It returns the
+// context to run the operation with and the operation, whose finish method
+// must be deferred with a pointer to the operation's error result.
+//
+// Operations that other operations run internally (e.g. the capabilities
+// probe of StreamJob) are reported as part of the outer operation. Without
+// an observer, tracer or request ID header, ctx is returned as-is with a
+// nil operation.
+//
+// Usage, with err the method's named error result:
+//
+//	ctx, op := c.observe(ctx, "Health")
+//	defer op.finish(&err)
+func (c *Client) observe(ctx context.Context, method string) (context.Context, *operation) {
+	if (c.observer == nil && c.tracer == nil && c.requestIDHeader == "") || ctx.Value(operationKey{}) != nil {
+		return ctx, nil
+	}
+
+	op := &operation{observer: c.observer, clock: c.clock, method: method}
+	if c.tracer != nil {
+		ctx, op.span = c.tracer.Start(ctx, method)
+	}
+	if op.observer != nil {
+		op.start = c.clock.Now()
+		op.observer.OnStart(method)
+	}
+	return context.WithValue(ctx, operationKey{}, op), op
+}
+
+// finish reports the end of the operation, which returned *err. An [Error]
+// returned by the operation gets the ID of its last request.
+func (op *operation) finish(err *error) {
+	if op == nil {
+		return
+	}
+	if id := op.requestID.Load(); id != nil {
+		if e, ok := (*err).(*Error); ok && e.RequestID == "" {
+			withID := *e // the error may be shared, e.g. a sentinel
+			withID.RequestID = *id
+			*err = &withID
+		}
+	}
+	status := int(op.status.Load())
+	if op.span != nil {
+		op.span.End(status, *err)
+	}
+	if op.observer != nil {
+		op.observer.OnFinish(op.method, status, op.clock.Now().Sub(op.start), *err)
+	}
+}
+
+// setAttribute records an attribute on the operation's span, if traced.
+// Empty values are ignored.
+func (op *operation) setAttribute(key, value string) {
+	if op != nil && op.span != nil && value != "" {
+		op.span.SetAttribute(key, value)
+	}
+}
+
+// recordStatus records the status of a response to a request made with
+// ctx for the operation being observed, if any.
+func recordStatus(ctx context.Context, status int) {
+	if op, ok := ctx.Value(operationKey{}).(*operation); ok {
+		op.status.Store(int64(status))
+	}
+}
+
+// recordRequestID records the ID of a request of the operation running in
+// ctx, if any.
+func recordRequestID(ctx context.Context, id string) {
+	if op, ok := ctx.Value(operationKey{}).(*operation); ok {
+		op.requestID.Store(&id)
+	}
+}
+
+// recordAttribute records an attribute on the span of the operation
+// running in ctx, if any.
+func recordAttribute(ctx context.Context, key, value string) {
+	if op, ok := ctx.Value(operationKey{}).(*operation); ok {
+		op.setAttribute(key, value)
+	}
+}
+
+// traceEvents starts the span covering the event loop of stream s, opened
+// by the operation running in ctx, if the client has a tracer.
+func (c *Client) traceEvents(ctx context.Context, s *Stream) {
+	if c.tracer != nil {
+		_, s.span = c.tracer.Start(ctx, "Stream.events")
+		if s.sessionID != "" {
+			s.span.SetAttribute(AttributeSessionID, s.sessionID)
+		}
+	}
+}
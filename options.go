@@ -1,7 +1,10 @@
 package stromboli
 
 import (
+	"context"
+	"crypto/tls"
 	"log"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -96,6 +99,53 @@ func WithTimeout(d time.Duration) Option {
 	}
 }
 
+// WithBaseContext sets a parent context for every request made by the client.
+//
+// When ctx is cancelled, all in-flight requests from the client are aborted,
+// active streams are closed, and new calls fail fast with a CANCELLED
+// [Error]. This is useful for graceful shutdown.
+//
+// The context passed to each method still applies: a request is cancelled
+// when either context is done, and its effective deadline is the minimum of
+// the per-call context deadline, the base context deadline and the client
+// timeout (see [WithTimeout]). Values are taken from the per-call context.
+//
+// Example:
+//
+//	shutdownCtx, shutdown := context.WithCancel(context.Background())
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithBaseContext(shutdownCtx),
+//	)
+//
+//	// On SIGTERM: abort everything the client is doing
+//	shutdown()
+func WithBaseContext(ctx context.Context) Option {
+	return func(c *Client) {
+		c.baseCtx = ctx // nil is valid (no base context)
+	}
+}
+
+// WithClock sets the clock used for the SDK's time-dependent behavior, such
+// as capability cache expiry and the polling of wait helpers like
+// [Client.DestroySessionAndConfirm].
+//
+// This option is primarily for testing: inject strombolitest.FakeClock to
+// control time deterministically. Passing nil keeps the real clock.
+//
+// Example:
+//
+//	clock := strombolitest.NewFakeClock(time.Now())
+//	client, err := stromboli.NewClient(server.URL, stromboli.WithClock(clock))
+//	// ...
+//	clock.Advance(10 * time.Minute) // expire cached capabilities
+func WithClock(clock Clock) Option {
+	return func(c *Client) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
 // WithStreamTimeout sets the default timeout for streaming requests.
 //
 // Unlike regular requests, streams are long-running connections where data
@@ -157,7 +207,7 @@ func WithRetries(n int) Option {
 // WithHTTPClient sets a custom HTTP client for making requests.
 //
 // Use this option to customize transport settings like:
-//   - TLS configuration
+//   - TLS configuration (or use [WithTLSConfig], which keeps the default transport)
 //   - Proxy settings
 //   - Connection pooling
 //   - Custom transports (e.g., for testing)
@@ -192,6 +242,60 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithTLSConfig sets the TLS configuration used to connect to the server,
+// for instance to trust a private CA or present a client certificate.
+//
+// The client gets a clone of the default transport with a copy of config,
+// so the SDK keeps its own connection pool, and the User-Agent, hooks and
+// other transport features still apply. It replaces the HTTP client set
+// by an earlier [WithHTTPClient], and is replaced by a later one: the last
+// option wins. Pass nil to go back to the default TLS configuration.
+//
+// The configuration is ignored for http:// base URLs.
+//
+// Example:
+//
+//	pool := x509.NewCertPool()
+//	pool.AppendCertsFromPEM(caPEM)
+//	client, err := stromboli.NewClient("https://stromboli.internal:8585",
+//	    stromboli.WithTLSConfig(&tls.Config{RootCAs: pool}),
+//	)
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) {
+		transport := getDefaultTransport().Clone()
+		if config != nil {
+			transport.TLSClientConfig = config.Clone()
+		}
+		c.httpClient = &http.Client{Transport: transport}
+	}
+}
+
+// WithClientCertificate connects to the server with mutual TLS: the client
+// presents the certificate and private key in the PEM files certFile and
+// keyFile, and trusts the CA certificates in the PEM file caFile (the
+// system roots if caFile is empty).
+//
+// It is a shortcut for [WithTLSConfig], with the same behavior: the last
+// of WithClientCertificate, WithTLSConfig and [WithHTTPClient] wins. If a
+// file can't be read or parsed, [NewClient] fails with an error naming it;
+// [Client.Clone] logs a warning and ignores the option.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient("https://stromboli.internal:8585",
+//	    stromboli.WithClientCertificate("client.pem", "client-key.pem", "ca.pem"),
+//	)
+func WithClientCertificate(certFile, keyFile, caFile string) Option {
+	return func(c *Client) {
+		config, err := loadClientTLSConfig(certFile, keyFile, caFile)
+		if err != nil {
+			c.optionErr = err
+			return
+		}
+		WithTLSConfig(config)(c)
+	}
+}
+
 // WithUserAgent sets a custom User-Agent header for all requests.
 //
 // The User-Agent is sent with every request and can be used for
@@ -254,14 +358,13 @@ type RequestHook func(req *http.Request)
 // Use this for logging, metrics, or inspecting response metadata.
 type ResponseHook func(resp *http.Response)
 
-// WithRequestHook sets a hook that is called before each HTTP request.
+// WithRequestHook adds a hook that is called before each HTTP request.
 //
 // Use this for observability (logging, metrics) or to modify requests
-// before they are sent. Pass nil to clear a previously set hook.
+// before they are sent. The option can be given multiple times; hooks are
+// called in registration order. Pass nil to clear the hooks registered so far.
 //
-// IMPORTANT: Hooks are captured at client creation time. Setting this option
-// AFTER calling [NewClient] will NOT affect API calls that use the internal
-// generated client. To use different hooks, create a new client.
+// To add hooks after the client is created, use [Client.AddRequestHook].
 //
 // Example:
 //
@@ -272,19 +375,22 @@ type ResponseHook func(resp *http.Response)
 //	)
 func WithRequestHook(hook RequestHook) Option {
 	return func(c *Client) {
-		c.requestHook = hook // nil is valid (clears hook)
+		if hook == nil {
+			c.requestHooks = nil
+			return
+		}
+		c.requestHooks = append(c.requestHooks, hook)
 	}
 }
 
-// WithResponseHook sets a hook that is called after each HTTP response.
+// WithResponseHook adds a hook that is called after each HTTP response.
 //
 // Use this for observability (logging, metrics) or to inspect response headers
 // and status codes. See [ResponseHook] for important caveats about body availability.
-// Pass nil to clear a previously set hook.
+// The option can be given multiple times; hooks are called in registration
+// order. Pass nil to clear the hooks registered so far.
 //
-// IMPORTANT: Hooks are captured at client creation time. Setting this option
-// AFTER calling [NewClient] will NOT affect API calls that use the internal
-// generated client. To use different hooks, create a new client.
+// To add hooks after the client is created, use [Client.AddResponseHook].
 //
 // Example:
 //
@@ -295,6 +401,707 @@ func WithRequestHook(hook RequestHook) Option {
 //	)
 func WithResponseHook(hook ResponseHook) Option {
 	return func(c *Client) {
-		c.responseHook = hook // nil is valid (clears hook)
+		if hook == nil {
+			c.responseHooks = nil
+			return
+		}
+		c.responseHooks = append(c.responseHooks, hook)
+	}
+}
+
+// ValidationMode controls how the client handles client-side request
+// validation failures (size limits, JSON schema checks, option consistency,
+// Podman option formats).
+//
+// Server-side validation is unaffected: a request sent despite a failed
+// client-side check may still be rejected by the server.
+type ValidationMode int
+
+const (
+	// ValidationStrict rejects invalid requests with a BAD_REQUEST [Error]
+	// before anything is sent. This is the default.
+	ValidationStrict ValidationMode = iota
+
+	// ValidationWarn logs validation failures via the SDK logger (see
+	// [SetLogger]) and sends the request anyway.
+	ValidationWarn
+
+	// ValidationOff skips client-side validation entirely and sends the
+	// request as-is.
+	ValidationOff
+)
+
+// String returns a human-readable representation of the mode.
+func (m ValidationMode) String() string {
+	switch m {
+	case ValidationStrict:
+		return "strict"
+	case ValidationWarn:
+		return "warn"
+	case ValidationOff:
+		return "off"
+	default:
+		return "unknown"
+	}
+}
+
+// WithValidationMode sets how client-side validation failures are handled.
+//
+// Use [ValidationWarn] or [ValidationOff] when migrating from older SDK
+// versions whose requests are accepted by the server but rejected by newer
+// client-side checks. Structural requirements (a non-nil request and a
+// non-empty prompt) are always enforced regardless of mode.
+//
+// Default: [ValidationStrict].
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithValidationMode(stromboli.ValidationWarn),
+//	)
+func WithValidationMode(mode ValidationMode) Option {
+	return func(c *Client) {
+		c.validationMode = mode
+	}
+}
+
+// WithoutClientValidation disables client-side request validation.
+//
+// It is a shorthand for WithValidationMode(ValidationOff), for servers that
+// accept formats the client rejects, such as Podman memory sizes or volume
+// specifications other than the documented ones.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithoutClientValidation(),
+//	)
+func WithoutClientValidation() Option {
+	return WithValidationMode(ValidationOff)
+}
+
+// WithStrictModelValidation makes [Client.Run] and [Client.RunAsync] reject
+// requests whose [ClaudeOptions.Model] is not one of the Model constants
+// (see [Model.IsKnown]) with a BAD_REQUEST [Error].
+//
+// Without this option, unknown models are sent to the server with a warning
+// logged via the SDK logger (see [SetLogger]), so that new server models can
+// be used before the SDK defines them. Like other client-side checks, the
+// rejection is subject to [WithValidationMode].
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithStrictModelValidation(),
+//	)
+func WithStrictModelValidation() Option {
+	return func(c *Client) {
+		c.strictModelValidation = true
+	}
+}
+
+// WithSchemaValidator makes [Client.Run] and [Client.RunAsync] check
+// [ClaudeOptions.JSONSchema] with validate before sending the request, so a
+// broken schema fails before a container is started. A failing schema is
+// rejected with a BAD_REQUEST [Error] wrapping the message of validate's
+// error, subject to [WithValidationMode].
+//
+// Without this option, the client only checks that the schema is a JSON
+// object with a structural keyword. validate is called after that check,
+// and should also check that the schema compiles as a schema. Validator
+// from the strombolischema package is a ready validator; any JSON Schema
+// library can be used instead:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithSchemaValidator(func(schema string) error {
+//	        doc, err := jsonschema.UnmarshalJSON(strings.NewReader(schema))
+//	        if err != nil {
+//	            return err
+//	        }
+//	        c := jsonschema.NewCompiler()
+//	        if err := c.AddResource("schema.json", doc); err != nil {
+//	            return err
+//	        }
+//	        _, err = c.Compile("schema.json")
+//	        return err
+//	    }),
+//	)
+func WithSchemaValidator(validate func(schema string) error) Option {
+	return func(c *Client) {
+		c.schemaValidator = validate
+	}
+}
+
+// WithVersionAwareRequests makes the client omit request fields that the
+// server's version doesn't understand.
+//
+// Some deployments run the server with strict request validation, which
+// rejects unknown fields. With this option, [Client.Run] and
+// [Client.RunAsync] look up the server version (from [Client.Capabilities],
+// or [Client.Health] as a fallback; both are cached) and drop newer fields
+// before sending the request. Each dropped field is logged once via the SDK
+// logger (see [SetLogger]). If the server version can't be determined, the
+// request is sent unchanged.
+//
+// Default: disabled (all fields are sent).
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithVersionAwareRequests(),
+//	)
+func WithVersionAwareRequests() Option {
+	return func(c *Client) {
+		c.versionAwareRequests = true
+	}
+}
+
+// CompatMode controls how the client reacts to a server whose API version
+// is outside [APIVersionRange] (see [WithCompatibilityCheck]).
+type CompatMode int
+
+const (
+	// CompatOff doesn't check the server version. This is the default.
+	CompatOff CompatMode = iota
+
+	// CompatWarn logs a warning via the SDK logger (see [SetLogger]) when
+	// the server version is incompatible or can't be parsed, and sends
+	// requests anyway.
+	CompatWarn
+
+	// CompatFail makes calls fail with [ErrIncompatibleServer] while the
+	// server version is incompatible. Versions that can't be parsed are
+	// logged as with CompatWarn.
+	CompatFail
+)
+
+// String returns a human-readable representation of the mode.
+func (m CompatMode) String() string {
+	switch m {
+	case CompatOff:
+		return "off"
+	case CompatWarn:
+		return "warn"
+	case CompatFail:
+		return "fail"
+	default:
+		return "unknown"
+	}
+}
+
+// WithCompatibilityCheck checks the server's API version against
+// [APIVersionRange] without having to call [CheckCompatibility] by hand.
+//
+// The first call of the client looks the version up with [Client.Health],
+// and every successful Health call records it; the result is cached, so
+// later calls don't check again unless Health reports a new version. If
+// the lookup fails, the call proceeds and the next call tries again.
+// Health and [Client.Ping] are never refused, so an incompatible server
+// can still be inspected.
+//
+// Default: [CompatOff].
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithCompatibilityCheck(stromboli.CompatFail),
+//	)
+//	...
+//	_, err = client.Run(ctx, req)
+//	if errors.Is(err, stromboli.ErrIncompatibleServer) {
+//	    log.Fatal(err) // e.g. the server was upgraded past the SDK
+//	}
+func WithCompatibilityCheck(mode CompatMode) Option {
+	return func(c *Client) {
+		c.compatMode = mode
+	}
+}
+
+// WithDefaultClaudeOptions sets Claude options merged into every request of
+// [Client.Run], [Client.RunAsync] and [Client.TrySubmit], e.g. a model used
+// for all prompts of a project. A nil opts removes the defaults.
+//
+// Per-request fields take precedence over defaults:
+//   - Scalar fields (strings, numbers, booleans, pointers) use the request's
+//     value unless it is the zero value. A default boolean can't be turned
+//     off by a request.
+//   - Slices (AllowedTools, Volumes, ...) are appended: the defaults come
+//     first, followed by the request's values not already present. Set a
+//     slice to an empty, non-nil slice to send none of the defaults.
+//   - Maps (Agents, SecretsEnv) are merged key by key; the request's
+//     entries win.
+//
+// The request passed in is never modified. [Client.ResolveRequest] reports
+// the fields set from defaults.
+//
+// Default: none.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithDefaultClaudeOptions(&stromboli.ClaudeOptions{
+//	        Model:        stromboli.ModelSonnet,
+//	        AllowedTools: []string{"Read", "Grep"},
+//	    }),
+//	    stromboli.WithDefaultPodmanOptions(&stromboli.PodmanOptions{
+//	        Volumes: []string{"/home/user/project:/workspace:ro"},
+//	    }),
+//	)
+func WithDefaultClaudeOptions(opts *ClaudeOptions) Option {
+	return func(c *Client) {
+		c.defaultClaude = copyOptions(opts)
+	}
+}
+
+// WithDefaultPodmanOptions sets Podman options merged into every request
+// of [Client.Run], [Client.RunAsync] and [Client.TrySubmit], e.g. volumes
+// shared by all prompts of a project. A nil opts removes the defaults.
+//
+// Fields are merged like those of [WithDefaultClaudeOptions]: request
+// values win, slices such as Volumes are appended to the defaults, and
+// SecretsEnv is merged key by key. A request volume replaces the default
+// mounted at the same container path, as with [PodmanOptions.AddVolume].
+//
+// Default: none.
+func WithDefaultPodmanOptions(opts *PodmanOptions) Option {
+	return func(c *Client) {
+		c.defaultPodman = copyOptions(opts)
+	}
+}
+
+// OutputSanitization controls how the client normalizes Claude output:
+// [RunResponse.Output], [Job.Output] and [StreamEvent.Data].
+//
+// Modes are flags and can be combined:
+//
+//	stromboli.WithOutputSanitization(stromboli.OutputReplaceInvalid | stromboli.OutputStripANSI)
+type OutputSanitization int
+
+const (
+	// OutputPreserve returns output exactly as received. This is the default.
+	//
+	// Note that JSON responses (Run, GetJob) are decoded by encoding/json,
+	// which already replaces invalid UTF-8 with U+FFFD, while stream data
+	// is passed through byte for byte.
+	OutputPreserve OutputSanitization = 0
+
+	// OutputReplaceInvalid replaces invalid UTF-8 sequences with U+FFFD and
+	// removes a leading byte order mark, so output is always valid UTF-8
+	// regardless of the endpoint it came from.
+	OutputReplaceInvalid OutputSanitization = 1 << iota
+
+	// OutputStripANSI removes ANSI escape sequences (colors, cursor
+	// movement, terminal titles) left by tools that write to a terminal.
+	OutputStripANSI
+)
+
+// WithOutputSanitization sets how output is normalized before it is
+// returned by [Client.Run], [Client.GetJob], [Client.ListJobs] and streams.
+//
+// Use this when tools in the container produce non-UTF-8 bytes (e.g.
+// latin-1) or terminal escape codes that break downstream consumers such as
+// JSON encoders.
+//
+// Default: [OutputPreserve].
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithOutputSanitization(stromboli.OutputReplaceInvalid|stromboli.OutputStripANSI),
+//	)
+func WithOutputSanitization(mode OutputSanitization) Option {
+	return func(c *Client) {
+		c.outputSanitization = mode
+	}
+}
+
+// WithCompression asks the server to compress JSON responses with gzip or
+// deflate, and decompresses them transparently. This saves bandwidth on
+// large responses such as [Client.GetMessages] over slow links, at some CPU
+// cost on both ends.
+//
+// Streams ([Client.Stream], [Client.StreamJob], job logs and pull
+// progress) ask for uncompressed responses, so their events aren't
+// delayed. Requests that already set an Accept-Encoding header (e.g. with
+// [WithCallHeader]) are left as they are.
+//
+// Default: disabled (the standard library still negotiates gzip on its
+// own when the HTTP client's transport doesn't disable compression).
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithCompression(),
+//	)
+func WithCompression() Option {
+	return func(c *Client) {
+		c.compression = true
+	}
+}
+
+// WithMaxResponseSize limits the size of JSON response bodies, such as
+// those of [Client.Run] or [Client.GetMessages], so that a faulty server
+// can't exhaust memory. A larger response fails with
+// [ErrResponseTooLarge] instead of being read whole. The limit applies to
+// decompressed bodies (see [WithCompression]). Streams aren't affected:
+// their events are limited individually. Use 0 to disable the limit;
+// negative values are ignored.
+//
+// Default: 32MB.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithMaxResponseSize(128*1024*1024), // long histories
+//	)
+func WithMaxResponseSize(bytes int64) Option {
+	return func(c *Client) {
+		if bytes >= 0 {
+			c.maxResponseSize = bytes
+		}
+	}
+}
+
+// WithMaxSecretSize limits the size of secret values read by
+// [Client.CreateSecretFromReader] and [Client.CreateSecretFromFile]. A
+// larger value returns a BAD_REQUEST [Error] without a request. Use 0 to
+// disable the limit; negative values are ignored.
+//
+// Default: 4MB.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithMaxSecretSize(16*1024*1024), // large kubeconfigs
+//	)
+func WithMaxSecretSize(bytes int64) Option {
+	return func(c *Client) {
+		if bytes >= 0 {
+			c.maxSecretSize = bytes
+		}
+	}
+}
+
+// WithErrorMapper translates the errors of server responses, such as 4xx
+// and 5xx statuses, into application errors. The mapper receives the
+// [Error] built by the SDK, with its Code, Status and Message set. If it
+// returns a non-nil error, the call returns that error instead; otherwise
+// the [Error] is returned unchanged. Client-side errors (cancellation,
+// validation, network failures) aren't passed to the mapper.
+//
+// The returned error reads as the mapper's error, and matches it with
+// errors.Is and errors.As. The original [Error] stays reachable too, so
+// errors.Is(err, stromboli.ErrNotFound) and the SDK's own handling of
+// error codes (retries, [Client.EnsureSecrets], ...) still work.
+//
+// Example:
+//
+//	var ErrClaudeNotConfigured = errors.New("claude is not configured")
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithErrorMapper(func(e *stromboli.Error) error {
+//	        if strings.Contains(e.Message, "claude not configured") {
+//	            return ErrClaudeNotConfigured
+//	        }
+//	        return nil
+//	    }),
+//	)
+func WithErrorMapper(mapper ErrorMapper) Option {
+	return func(c *Client) {
+		c.errorMapper = mapper
+	}
+}
+
+// WithDiagnosticsBuffer records a summary of the last n requests (method,
+// path, query, status, duration and error) for [Client.CollectSupportBundle].
+//
+// Request and response bodies and headers are never recorded. A value of
+// zero or less disables the buffer.
+//
+// Default: disabled.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithDiagnosticsBuffer(50),
+//	)
+func WithDiagnosticsBuffer(n int) Option {
+	return func(c *Client) {
+		if n <= 0 {
+			c.diagnostics = nil
+			return
+		}
+		c.diagnostics = newDiagnosticsBuffer(n)
+	}
+}
+
+// WithMetrics reports every HTTP request made by the client to m: its
+// method, templated path (e.g. "/jobs/{id}" rather than "/jobs/job-abc123",
+// to keep label cardinality bounded), status and duration. This includes
+// retries, internal calls and stream connections. Pass nil to remove it.
+//
+// The metrics subpackage provides an implementation exposing the
+// requests in the Prometheus text format.
+//
+// Default: none.
+//
+// Example:
+//
+//	collector := metrics.NewCollector()
+//	http.Handle("/metrics", collector)
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithMetrics(collector),
+//	)
+func WithMetrics(m Metrics) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// WithRequestIDHeader sends a correlation ID with every request, streams
+// included, in the given header (or [DefaultRequestIDHeader] if empty), so
+// that calls can be matched with the server's logs. Each request gets a
+// new ID from gen (or a random ID if nil), unless its context carries
+// one (see [ContextWithRequestID]).
+//
+// When a call fails, the ID of its last request is reported in the
+// RequestID field of the returned [Error].
+//
+// Default: no request ID.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithRequestIDHeader("", nil), // X-Request-ID: <random ID>
+//	)
+//	// ...
+//	var apiErr *stromboli.Error
+//	if errors.As(err, &apiErr) {
+//	    log.Printf("request %s failed: %v", apiErr.RequestID, err)
+//	}
+func WithRequestIDHeader(header string, gen func() string) Option {
+	return func(c *Client) {
+		if header == "" {
+			header = DefaultRequestIDHeader
+		}
+		if gen == nil {
+			gen = newRequestID
+		}
+		c.requestIDHeader = http.CanonicalHeaderKey(header)
+		c.requestIDGen = gen
+	}
+}
+
+// WithSlog sends the client's logs to l: warnings (e.g. validation
+// failures in [ValidationWarn] mode), informational messages (e.g. model
+// fallbacks) and the request logs enabled by [WithDebug], with the request
+// details as attributes. Pass nil to use the SDK's [Logger] (see
+// [SetLogger]) again.
+//
+// Messages logged without a client, e.g. by options, still go to the SDK's
+// Logger.
+//
+// Default: the SDK's Logger.
+//
+// Example:
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+//	client, err := stromboli.NewClient(url, stromboli.WithSlog(logger))
+func WithSlog(l *slog.Logger) Option {
+	return func(c *Client) {
+		c.slogger = l
+	}
+}
+
+// WithDebug logs every HTTP request made by the client at the debug
+// level, to the logger set with [WithSlog] or else to the SDK's [Logger].
+// [DebugHeaders] logs the method, URL, status and duration of requests;
+// [DebugBody] also dumps their headers and bodies (up to 4 KiB each).
+//
+// Credentials are redacted from the dumps: the Authorization and cookie
+// headers, and the string values of JSON fields named "value" (e.g. secret
+// values) or whose name contains "token" or "secret". Bodies are buffered
+// so that they still reach the server and the caller in full; streamed
+// bodies are left out rather than buffered: responses to requests that
+// don't accept JSON (event streams, followed logs, pull progress) and
+// request bodies that can't be replayed (e.g. image build contexts).
+//
+// Default: [DebugOff].
+//
+// Example:
+//
+//	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithSlog(logger),
+//	    stromboli.WithDebug(stromboli.DebugBody),
+//	)
+func WithDebug(level DebugLevel) Option {
+	return func(c *Client) {
+		c.debug = level
+	}
+}
+
+// WithSubmissionGate limits the number of jobs submitted through the client
+// that are in flight at once, queueing further submissions locally instead
+// of letting the server reject them.
+//
+// Every [Client.Run] call and every job started with [Client.RunAsync] takes
+// one of maxInFlight slots. When all slots are in use, Run and RunAsync
+// block until one is released or their context is done; [Client.TrySubmit]
+// returns [ErrSubmissionQueueFull] instead. Run releases its slot when it
+// returns. An async job releases its slot when the client sees it in a
+// terminal state through [Client.GetJob], [Client.GetJobInto],
+// [Client.ListJobs], [Client.ListJobsFiltered], the "done" event of
+// [Client.StreamJob] or [Client.WebhookHandler], or when it is cancelled
+// with [Client.CancelJob] or no longer exists. Use [Client.ReleaseJob] for
+// jobs whose completion is only seen elsewhere. Jobs the client never sees
+// finish release their slot when their lease expires (see
+// [WithSubmissionLease]), so the gate can't stay full forever.
+//
+// Jobs submitted by other clients or processes are not counted. A value of
+// zero or less disables the gate.
+//
+// Default: disabled.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithSubmissionGate(4), // the server runs 4 jobs at a time
+//	)
+func WithSubmissionGate(maxInFlight int) Option {
+	return func(c *Client) {
+		if maxInFlight <= 0 {
+			c.gate = nil
+			return
+		}
+		c.gate = newSubmissionGate(maxInFlight)
+	}
+}
+
+// WithSubmissionLease sets how long an async job holds its slot of the
+// submission gate at most (see [WithSubmissionGate]). When the lease
+// expires, the slot is reclaimed as if the job had finished, e.g. for
+// fire-and-forget jobs or jobs stuck in an unknown state. Set it above the
+// longest expected job duration: a job outliving its lease no longer counts
+// against the limit. A value of zero or less disables leases.
+//
+// The lease applies to jobs submitted through the client and its clones.
+//
+// Default: 1 hour.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithSubmissionGate(4),
+//	    stromboli.WithSubmissionLease(4*time.Hour),
+//	)
+func WithSubmissionLease(ttl time.Duration) Option {
+	return func(c *Client) {
+		if ttl < 0 {
+			ttl = 0
+		}
+		c.submissionLease = ttl
+	}
+}
+
+// WithSessionSerialization makes the client serialize the calls that
+// target the same session, so that concurrent goroutines resuming a session
+// don't interleave their turns and corrupt its context.
+//
+// A [Client.Run] call resuming a session (ClaudeOptions.SessionID) waits
+// until no other Run or [Client.Stream] call of the client uses the session,
+// or until its context is done. A Stream holds the session until it ends or
+// is closed, so close streams promptly. [Client.TryRun] returns
+// [ErrSessionBusy] instead of waiting. Calls starting a new session are
+// never delayed.
+//
+// Serialization is client-side: it doesn't coordinate with other processes
+// or with clients created separately (clones share it). Async jobs are not
+// serialized, as the client can't tell when the server is done with them.
+//
+// Default: disabled.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithSessionSerialization(),
+//	)
+func WithSessionSerialization() Option {
+	return func(c *Client) {
+		c.sessionLocks = newSessionLocks()
+	}
+}
+
+// WithImageCache caches the results of [Client.GetImage] by image name for
+// ttl, for agents that check the same image before every run.
+//
+// Once ttl has elapsed, cached images are revalidated with a single
+// [Client.ListImages] call: images whose ID is unchanged are kept for
+// another ttl, and the others are fetched again. [Client.PullImage] and
+// [Client.DeleteImage] drop the cached image of their name, and
+// [Client.ImageChanged] drops images it reports as changed. Changes made
+// by other clients (re-pulls, rebuilt tags) are only picked up at
+// revalidation, so keep ttl short if images change under the client.
+//
+// Default: disabled. A ttl of zero or less disables the cache.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithImageCache(time.Minute),
+//	)
+func WithImageCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		if ttl < 0 {
+			ttl = 0
+		}
+		c.imageCacheTTL = ttl
+	}
+}
+
+// WithObserver sets an [Observer] notified of the start and end of every
+// API operation of the client, with the operation's name (e.g. "Run"),
+// HTTP status, duration and error. Unlike request and response hooks, it
+// reports logical operations rather than HTTP requests, which makes it the
+// simplest way to record per-operation metrics. Pass nil to remove it.
+//
+// Every method calling the API is reported once, including its internal
+// requests (e.g. the capabilities probe of [Client.StreamJob]). Helpers
+// built on other methods, such as [Client.RunBatch] or
+// [Client.AllMessages], report each call they make. For [Client.Stream]
+// and [Client.StreamJob], the operation ends once the stream is open.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithObserver(metrics),
+//	)
+func WithObserver(obs Observer) Option {
+	return func(c *Client) {
+		c.observer = obs
+	}
+}
+
+// WithTracer sets a [Tracer] starting a span for every API operation of
+// the client, covering the same operations as [WithObserver]. Spans record
+// the job or session ID an operation is about (see [AttributeJobID]), and
+// end with the operation's HTTP status and error. Pass nil to remove it.
+//
+// For [Client.Stream] and [Client.StreamJob], the operation's span covers
+// opening the stream, and a child span named "Stream.events" covers reading
+// its events, until the stream ends or is closed.
+//
+// For OpenTelemetry, use WithTracerProvider from the stromboliotel
+// package, which keeps the OpenTelemetry dependency out of this package.
+//
+// Example:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboliotel.WithTracerProvider(otel.GetTracerProvider()),
+//	)
+func WithTracer(tracer Tracer) Option {
+	return func(c *Client) {
+		c.tracer = tracer
 	}
 }
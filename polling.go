@@ -0,0 +1,327 @@
+package stromboli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// jobBufferPool pools the buffers that [Client.GetJobInto] reads job
+// responses into.
+var jobBufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// maxPooledJobBuffer is the largest buffer returned to jobBufferPool, so
+// that a single huge job output doesn't stay pinned in memory.
+const maxPooledJobBuffer = 1 << 20 // 1MB
+
+// acceptJSONHeader is the Accept header value of GetJobInto requests.
+// Header value slices are only ever replaced, or appended to (which copies
+// a full slice), so they can be shared between requests.
+var acceptJSONHeader = []string{"application/json"}
+
+// GetJobInto is like [Client.GetJob] but decodes the job into the caller's
+// struct, for high-frequency polling.
+//
+// It bypasses the generated client for this endpoint, reads the response
+// into a pooled buffer, and keeps the strings already in job when their
+// value is unchanged, so a poll of an unchanged job allocates little beyond
+// the HTTP round trip itself. The resulting job is the same as GetJob's,
+// with fields absent from the response cleared. Errors are the same too,
+// except that a server-specific error code (e.g. JOB_NOT_FOUND) is kept as
+// the Code, with the status-based error (e.g. [ErrNotFound]) as its Cause.
+//
+// job must not be read or written concurrently with the call, and its
+// contents are unspecified if an error is returned.
+//
+// Example:
+//
+//	var job stromboli.Job
+//	for {
+//	    if err := client.GetJobInto(ctx, jobID, &job); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    if !job.IsRunning() {
+//	        break
+//	    }
+//	    time.Sleep(2 * time.Second)
+//	}
+func (c *Client) GetJobInto(ctx context.Context, jobID string, job *Job) (err error) {
+	ctx, op := c.observe(ctx, "GetJobInto")
+	defer op.finish(&err)
+	op.setAttribute(AttributeJobID, jobID)
+
+	if err := c.checkContext(ctx); err != nil {
+		return err
+	}
+
+	if jobID == "" {
+		return newError("BAD_REQUEST", "job ID is required", 400, nil)
+	}
+	if job == nil {
+		return newError("BAD_REQUEST", "job is required", 400, nil)
+	}
+
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
+	if timeout := c.effectiveTimeout(ctx); timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		defer cancelTimeout()
+	}
+
+	// Build the URL from the pre-parsed base URL instead of formatting and
+	// re-parsing a URL string.
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "", http.NoBody)
+	if err != nil {
+		return newError("REQUEST_FAILED", "failed to create request", 0, err)
+	}
+	u := httpReq.URL
+	*u = *c.parsedBaseURL
+	u.RawQuery, u.Fragment = "", ""
+	if escapedID := url.PathEscape(jobID); escapedID == jobID && u.RawPath == "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/jobs/" + jobID
+	} else {
+		u.RawPath = strings.TrimSuffix(u.EscapedPath(), "/") + "/jobs/" + escapedID
+		u.Path, _ = url.PathUnescape(u.RawPath) // both parts are validly escaped
+	}
+	httpReq.Host = u.Host
+
+	httpReq.Header["Accept"] = acceptJSONHeader
+	httpReq.Header["User-Agent"] = c.userAgentHeader
+	c.setRequestID(httpReq)
+	if token := c.tokenFor(ctx); token != "" {
+		httpReq.Header["Authorization"] = []string{"Bearer " + token}
+	}
+	setCallHeaders(httpReq)
+
+	if err := c.checkOpen(); err != nil {
+		return err
+	}
+	c.runRequestHooks(httpReq)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if resp != nil {
+		recordStatus(ctx, resp.StatusCode)
+		c.runResponseHooks(resp)
+	}
+	if err != nil {
+		return c.handleError(err, "failed to get job")
+	}
+	defer func() {
+		// Drain any remaining body to allow HTTP/1.1 connection reuse
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := c.errorFromResponse(resp, false)
+		if resp.StatusCode == http.StatusNotFound {
+			c.ReleaseJob(jobID)
+		}
+		return err
+	}
+	c.observeMaintenance(false, resp.StatusCode, resp.Header, nil)
+
+	buf := jobBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledJobBuffer {
+			jobBufferPool.Put(buf)
+		}
+	}()
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return c.handleError(err, "failed to get job")
+	}
+
+	if !decodeJobFast(buf.Bytes(), job) {
+		// Decode like the generated client does
+		var decoded Job
+		if err := json.NewDecoder(bytes.NewReader(buf.Bytes())).Decode(&decoded); err != nil {
+			return newError("INVALID_RESPONSE", "failed to decode response", resp.StatusCode, err)
+		}
+		*job = decoded
+	}
+	normalizeJobStatus(job)
+	job.Output = sanitizeOutput(job.Output, c.outputSanitization)
+	c.observeJobStatus(jobID, job.Status)
+	return nil
+}
+
+// Fields of a job, as bits of the set of fields seen by decodeJobFast.
+const (
+	jobFieldID = 1 << iota
+	jobFieldStatus
+	jobFieldOutput
+	jobFieldError
+	jobFieldSessionID
+	jobFieldCreatedAt
+	jobFieldUpdatedAt
+	jobFieldCrashInfo
+)
+
+// decodeJobFast decodes the common shape of a job response, a flat JSON
+// object of plain strings, into job without allocating for unchanged values.
+//
+// It reports false, leaving job in an unspecified state, for anything else:
+// strings with escapes, control characters or invalid UTF-8, non-null
+// crash_info, usage, other value types, and keys that aren't exact Job field
+// names (encoding/json also matches keys case-insensitively). The caller
+// then falls back to encoding/json, so the result is always the same as the
+// generated client's.
+func decodeJobFast(data []byte, job *Job) bool {
+	i := skipJSONSpace(data, 0)
+	if i >= len(data) || data[i] != '{' {
+		return false
+	}
+	i = skipJSONSpace(data, i+1)
+
+	seen := 0
+	if i < len(data) && data[i] == '}' {
+		i++
+	} else {
+		for {
+			key, next, ok := scanPlainJSONString(data, i)
+			if !ok {
+				return false
+			}
+			i = skipJSONSpace(data, next)
+			if i >= len(data) || data[i] != ':' {
+				return false
+			}
+			i = skipJSONSpace(data, i+1)
+
+			if string(key) == "crash_info" {
+				// Only null is handled here; crash details take the slow path
+				if !bytes.HasPrefix(data[i:], []byte("null")) {
+					return false
+				}
+				i += len("null")
+				seen |= jobFieldCrashInfo
+				job.CrashInfo = nil
+			} else {
+				value, next, ok := scanPlainJSONString(data, i)
+				if !ok {
+					return false
+				}
+				i = next
+
+				var field *string
+				var bit int
+				switch string(key) {
+				case "id":
+					field, bit = &job.ID, jobFieldID
+				case "status":
+					field, bit = &job.Status, jobFieldStatus
+				case "output":
+					field, bit = &job.Output, jobFieldOutput
+				case "error":
+					field, bit = &job.Error, jobFieldError
+				case "session_id":
+					field, bit = &job.SessionID, jobFieldSessionID
+				case "created_at":
+					field, bit = &job.CreatedAt, jobFieldCreatedAt
+				case "updated_at":
+					field, bit = &job.UpdatedAt, jobFieldUpdatedAt
+				default:
+					return false
+				}
+				if *field != string(value) {
+					*field = string(value)
+				}
+				seen |= bit
+			}
+
+			i = skipJSONSpace(data, i)
+			if i >= len(data) {
+				return false
+			}
+			if data[i] == '}' {
+				i++
+				break
+			}
+			if data[i] != ',' {
+				return false
+			}
+			i = skipJSONSpace(data, i+1)
+		}
+	}
+
+	// Only whitespace may follow the object
+	if skipJSONSpace(data, i) != len(data) {
+		return false
+	}
+
+	// Clear the fields absent from the response
+	if seen&jobFieldID == 0 {
+		job.ID = ""
+	}
+	if seen&jobFieldStatus == 0 {
+		job.Status = ""
+	}
+	if seen&jobFieldOutput == 0 {
+		job.Output = ""
+	}
+	if seen&jobFieldError == 0 {
+		job.Error = ""
+	}
+	if seen&jobFieldSessionID == 0 {
+		job.SessionID = ""
+	}
+	if seen&jobFieldCreatedAt == 0 {
+		job.CreatedAt = ""
+	}
+	if seen&jobFieldUpdatedAt == 0 {
+		job.UpdatedAt = ""
+	}
+	if seen&jobFieldCrashInfo == 0 {
+		job.CrashInfo = nil
+	}
+	job.Usage = nil // usage takes the slow path
+	return true
+}
+
+// scanPlainJSONString scans the JSON string starting at data[i] and returns
+// its contents and the index after the closing quote. It reports false if
+// there is no string at i, or if the string contains escapes, control
+// characters or invalid UTF-8.
+func scanPlainJSONString(data []byte, i int) ([]byte, int, bool) {
+	if i >= len(data) || data[i] != '"' {
+		return nil, 0, false
+	}
+	start := i + 1
+	for j := start; j < len(data); j++ {
+		switch c := data[j]; {
+		case c == '"':
+			s := data[start:j]
+			if !utf8.Valid(s) {
+				return nil, 0, false
+			}
+			return s, j + 1, true
+		case c == '\\' || c < 0x20:
+			return nil, 0, false
+		}
+	}
+	return nil, 0, false
+}
+
+// skipJSONSpace returns the index of the first non-whitespace byte of data
+// at or after i.
+func skipJSONSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
@@ -0,0 +1,284 @@
+package stromboli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+)
+
+// ensureSecretsConcurrency is the maximum number of secrets created at once
+// by [Client.EnsureSecrets].
+const ensureSecretsConcurrency = 4
+
+// EnsureSecrets creates the given secrets, leaving those that already exist
+// untouched.
+//
+// Use this method to provision the secrets a run needs when several workers
+// may do so at the same time: a secret created concurrently by another
+// worker is not an error. Unlike [Client.EnsureSecret], existing values are
+// never overwritten.
+//
+// When the server reports [ErrSecretExists], the secret is looked up with
+// [Client.GetSecret] to confirm it exists; if it was deleted in between, it
+// is created again. At most 4 secrets are created at once.
+//
+// The returned error joins the errors of all secrets that could not be
+// ensured (each prefixed with the secret name) and is nil if every secret
+// exists. Nil entries are rejected as BAD_REQUEST errors.
+//
+// Example:
+//
+//	err := client.EnsureSecrets(ctx, []*stromboli.CreateSecretRequest{
+//	    {Name: "github-token", Value: os.Getenv("GH_TOKEN")},
+//	    {Name: "npm-token", Value: os.Getenv("NPM_TOKEN")},
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func (c *Client) EnsureSecrets(ctx context.Context, secrets []*CreateSecretRequest) error {
+	errs := make([]error, len(secrets))
+
+	started := forEachBounded(ctx, len(secrets), ensureSecretsConcurrency, func(i int) {
+		errs[i] = c.ensureSecretExists(ctx, secrets[i])
+	})
+	if started < len(secrets) {
+		cancelErr := c.handleError(ctx.Err(), "ensure secrets cancelled")
+		for i := started; i < len(secrets); i++ {
+			errs[i] = cancelErr
+		}
+	}
+
+	var failed []error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if secrets[i] == nil {
+			failed = append(failed, fmt.Errorf("secret %d: %w", i, err))
+			continue
+		}
+		failed = append(failed, fmt.Errorf("secret %q: %w", secrets[i].Name, err))
+	}
+	return errors.Join(failed...)
+}
+
+// ensureSecretExists creates a secret unless it already exists.
+func (c *Client) ensureSecretExists(ctx context.Context, req *CreateSecretRequest) error {
+	// A secret deleted between the conflict and the lookup is created
+	// again, once.
+	for attempt := 0; ; attempt++ {
+		err := c.CreateSecret(ctx, req)
+		if !errors.Is(err, ErrSecretExists) {
+			return err
+		}
+
+		_, err = c.GetSecret(ctx, req.Name)
+		if !errors.Is(err, ErrNotFound) || attempt > 0 {
+			return err
+		}
+	}
+}
+
+// EnsureSecretsBeforeRun ensures the given secrets exist (see
+// [Client.EnsureSecrets]) and then runs req with [Client.Run].
+//
+// The request is not sent if any secret could not be ensured; the returned
+// error is then the one of EnsureSecrets.
+//
+// Example:
+//
+//	result, err := client.EnsureSecretsBeforeRun(ctx, []*stromboli.CreateSecretRequest{
+//	    {Name: "github-token", Value: os.Getenv("GH_TOKEN")},
+//	}, &stromboli.RunRequest{
+//	    Prompt: "Open a pull request with the fix",
+//	    Podman: &stromboli.PodmanOptions{
+//	        SecretsEnv: map[string]string{"GH_TOKEN": "github-token"},
+//	    },
+//	})
+func (c *Client) EnsureSecretsBeforeRun(ctx context.Context, secrets []*CreateSecretRequest, req *RunRequest) (*RunResponse, error) {
+	// Check the request first, so secrets aren't created for a run that
+	// can't be sent
+	if req == nil {
+		return nil, newError("BAD_REQUEST", "request is required", 400, nil)
+	}
+	if req.Prompt == "" {
+		return nil, newError("BAD_REQUEST", "prompt is required", 400, nil)
+	}
+
+	if err := c.EnsureSecrets(ctx, secrets); err != nil {
+		return nil, err
+	}
+	return c.Run(ctx, req)
+}
+
+// ResolveSecretsEnv checks that every secret referenced by env, a
+// [PodmanOptions.SecretsEnv] map from environment variable to secret name,
+// exists, and returns a copy of env.
+//
+// A mistyped secret name otherwise only fails when the container starts.
+// The secrets are listed once with [Client.ListSecrets]. The returned
+// error joins an [ErrNotFound] error for every missing secret, prefixed
+// with its variable, in variable order. If the server doesn't allow
+// listing secrets (403, 404, 405 or 501), nothing is checked: a warning is
+// logged and env is returned as if every secret existed.
+//
+// Use [WithPreflightSecrets] to run this check in [Client.Run] and
+// [Client.RunAsync].
+//
+// Example:
+//
+//	env, err := client.ResolveSecretsEnv(ctx, map[string]string{
+//	    "GH_TOKEN":  "github-token",
+//	    "NPM_TOKEN": "npm-tokn",
+//	})
+//	if errors.Is(err, stromboli.ErrNotFound) {
+//	    log.Fatal(err) // NPM_TOKEN: stromboli: NOT_FOUND: secret "npm-tokn" not found
+//	}
+func (c *Client) ResolveSecretsEnv(ctx context.Context, env map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(env))
+	for name, secret := range env {
+		resolved[name] = secret
+	}
+	if len(env) == 0 {
+		return resolved, nil
+	}
+
+	secrets, err := c.ListSecrets(ctx)
+	if err != nil {
+		var apiErr *Error
+		if errors.As(err, &apiErr) && isSecretListingDisabled(apiErr.Status) {
+			c.logf(slog.LevelWarn, "cannot list secrets to check SecretsEnv, skipping the check: %v", err)
+			return resolved, nil
+		}
+		return nil, err
+	}
+	existing := make(map[string]bool, len(secrets))
+	for _, s := range secrets {
+		existing[s.Name] = true
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var missing []error
+	for _, name := range names {
+		if secret := env[name]; !existing[secret] {
+			missing = append(missing, fmt.Errorf("%s: %w", name,
+				newError(ErrNotFound.Code, fmt.Sprintf("secret %q not found", secret), http.StatusNotFound, nil)))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, errors.Join(missing...)
+	}
+	return resolved, nil
+}
+
+// isSecretListingDisabled reports whether status, returned for a request
+// listing secrets, means the server doesn't allow it.
+func isSecretListingDisabled(status int) bool {
+	switch status {
+	case http.StatusForbidden, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+// preflightSecrets checks the secrets referenced by req's SecretsEnv with
+// ResolveSecretsEnv if the call options of ctx ask for it (see
+// WithPreflightSecrets).
+func (c *Client) preflightSecrets(ctx context.Context, req *RunRequest) error {
+	o := callOptionsFrom(ctx)
+	if o == nil || !o.preflightSecrets || req.Podman == nil || len(req.Podman.SecretsEnv) == 0 {
+		return nil
+	}
+	_, err := c.ResolveSecretsEnv(ctx, req.Podman.SecretsEnv)
+	return err
+}
+
+// EnsureImageOptions configures [Client.EnsureImage].
+type EnsureImageOptions struct {
+	// Always pulls the image even if it exists locally, to pick up a newer
+	// version of its tag (like Kubernetes' imagePullPolicy: Always).
+	Always bool
+
+	// RequireCompatible fails with [ErrIncompatibleImage] if the image is
+	// not compatible with Stromboli (e.g. Alpine/musl images, compatibility
+	// rank 4).
+	RequireCompatible bool
+
+	// Platform is the platform pulled for multi-arch images.
+	// Example: "linux/amd64"
+	Platform string
+}
+
+// EnsureImage returns the local image ref, pulling it first if it doesn't
+// exist locally (or always, with opts.Always). A nil opts is equivalent to
+// an empty EnsureImageOptions.
+//
+// Calls of the client (and its clones) for the same ref are serialized, so
+// workers starting at the same time pull an image once: the others wait
+// and then find it locally. With opts.RequireCompatible, an incompatible
+// image, whether pulled or already present, fails with an error matching
+// [ErrIncompatibleImage]; it is left in place.
+//
+// Pull failures are returned as by [Client.PullImage]; a pull reported as
+// unsuccessful returns an error matching [ErrImagePullFailed].
+//
+// Example:
+//
+//	img, err := client.EnsureImage(ctx, "python:3.12", &stromboli.EnsureImageOptions{
+//	    RequireCompatible: true,
+//	})
+//	if errors.Is(err, stromboli.ErrIncompatibleImage) {
+//	    log.Fatal("pick a glibc-based image")
+//	}
+func (c *Client) EnsureImage(ctx context.Context, ref string, opts *EnsureImageOptions) (*Image, error) {
+	if ref == "" {
+		return nil, newError("BAD_REQUEST", "image name is required", 400, nil)
+	}
+	if opts == nil {
+		opts = &EnsureImageOptions{}
+	}
+
+	baseCtx, cancel := c.withBaseContext(ctx)
+	defer cancel()
+	if !c.imageLocks.acquire(baseCtx, ref) {
+		return nil, c.handleError(baseCtx.Err(), "cancelled while waiting for image "+ref)
+	}
+	defer c.imageLocks.release(ref)
+
+	var img *Image
+	if !opts.Always {
+		var err error
+		img, err = c.GetImage(ctx, ref)
+		if err != nil && !errors.Is(err, ErrImageNotFound) {
+			return nil, err
+		}
+	}
+	if img == nil {
+		resp, err := c.PullImage(ctx, &PullImageRequest{Image: ref, Platform: opts.Platform})
+		if err != nil {
+			return nil, err
+		}
+		if !resp.Success {
+			return nil, newError(ErrImagePullFailed.Code,
+				fmt.Sprintf("failed to pull image %q", ref), ErrImagePullFailed.Status, nil)
+		}
+		if img, err = c.GetImage(ctx, ref); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.RequireCompatible && !img.Compatible {
+		return nil, newError(ErrIncompatibleImage.Code,
+			fmt.Sprintf("image %q is not compatible (compatibility rank %d)", ref, img.CompatibilityRank), 0, nil)
+	}
+	return img, nil
+}
@@ -0,0 +1,168 @@
+package stromboli
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultProxyKeepAlive is the default interval of the keepalive comments
+// sent by [ProxyStream].
+const DefaultProxyKeepAlive = 15 * time.Second
+
+// ProxyOptions configures [ProxyStream].
+type ProxyOptions struct {
+	// Transform is called for every event before it is forwarded. It may
+	// modify the event or return a different one; returning nil drops the
+	// event. Nil forwards events unchanged.
+	Transform func(event *StreamEvent) *StreamEvent
+
+	// KeepAlive is the interval of the SSE comments sent while no event is
+	// forwarded, so that proxies and load balancers don't close an idle
+	// connection. Zero uses DefaultProxyKeepAlive; a negative value
+	// disables keepalives.
+	KeepAlive time.Duration
+}
+
+// ProxyStream forwards a stream to a browser (or any SSE client) from an
+// [http.Handler].
+//
+// It sets the SSE response headers, then writes every event of stream to w
+// in SSE format, flushing after each event. Comments (": keepalive") are
+// sent periodically while the stream is idle. When the client disconnects
+// (the request context is done) or a write fails, the stream is closed,
+// which cancels the upstream request.
+//
+// ProxyStream takes ownership of stream and closes it before returning. It
+// returns nil when the stream ends normally, the stream's error if it
+// failed (the response is then simply ended, since its status has already
+// been sent), a CANCELLED [Error] if the client went away, and a
+// BAD_REQUEST [Error] without writing anything if stream is nil or w
+// doesn't support flushing. opts may be nil.
+//
+// Example:
+//
+//	http.HandleFunc("/chat", func(w http.ResponseWriter, r *http.Request) {
+//	    stream, err := client.Stream(r.Context(), &stromboli.StreamRequest{
+//	        Prompt: r.URL.Query().Get("q"),
+//	    })
+//	    if err != nil {
+//	        http.Error(w, "stream unavailable", http.StatusBadGateway)
+//	        return
+//	    }
+//	    if err := stromboli.ProxyStream(w, r, stream, nil); err != nil {
+//	        log.Printf("proxy: %v", err)
+//	    }
+//	})
+func ProxyStream(w http.ResponseWriter, r *http.Request, stream *Stream, opts *ProxyOptions) error {
+	if stream == nil {
+		return newError("BAD_REQUEST", "stream is required", 400, nil)
+	}
+	defer func() { _ = stream.Close() }()
+	if !canFlush(w) {
+		return newError("BAD_REQUEST", "response writer does not support flushing", 400, nil)
+	}
+	if opts == nil {
+		opts = &ProxyOptions{}
+	}
+	keepAlive := opts.KeepAlive
+	if keepAlive == 0 {
+		keepAlive = DefaultProxyKeepAlive
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // disable nginx response buffering
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		return wrapError(err, "CANCELLED", "client disconnected", 0)
+	}
+
+	// A nil channel never fires, which disables keepalives
+	var ticker *time.Ticker
+	var tick <-chan time.Time
+	if keepAlive > 0 {
+		ticker = time.NewTicker(keepAlive)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	ctx := r.Context()
+	events := stream.EventsWithContext(ctx)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return wrapError(ctx.Err(), "CANCELLED", "client disconnected", 0)
+				}
+				return stream.Err()
+			}
+			if opts.Transform != nil {
+				if event = opts.Transform(event); event == nil {
+					continue
+				}
+			}
+			if err := writeSSEEvent(w, event); err != nil {
+				return wrapError(err, "CANCELLED", "client disconnected", 0)
+			}
+			if ticker != nil {
+				ticker.Reset(keepAlive)
+			}
+		case <-tick:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return wrapError(err, "CANCELLED", "client disconnected", 0)
+			}
+		case <-ctx.Done():
+			return wrapError(ctx.Err(), "CANCELLED", "client disconnected", 0)
+		}
+		if err := rc.Flush(); err != nil {
+			return wrapError(err, "CANCELLED", "client disconnected", 0)
+		}
+	}
+}
+
+// canFlush reports whether w, or a writer it wraps (see
+// [http.ResponseController]), implements [http.Flusher].
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		switch t := w.(type) {
+		case http.Flusher:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+// writeSSEEvent writes event in SSE format. Multi-line data is split into
+// one "data:" line per line (CRLF, CR and LF all end a line in SSE).
+func writeSSEEvent(w io.Writer, event *StreamEvent) error {
+	var b strings.Builder
+	if event.ID != "" {
+		b.WriteString("id: " + sseLine(event.ID) + "\n")
+	}
+	if event.Type != "" {
+		b.WriteString("event: " + sseLine(event.Type) + "\n")
+	}
+	data := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(event.Data)
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sseLine strips line breaks from a single-line SSE field value, so that it
+// can't inject fields.
+func sseLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
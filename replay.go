@@ -0,0 +1,103 @@
+package stromboli
+
+// replayBuffer is a ring buffer of the most recent events of a [Stream].
+type replayBuffer struct {
+	events []*StreamEvent
+	start  int // index of the oldest event once the buffer is full
+	max    int
+}
+
+// add records event, evicting the oldest one if the buffer is full.
+func (b *replayBuffer) add(event *StreamEvent) {
+	if len(b.events) < b.max {
+		b.events = append(b.events, event)
+		return
+	}
+	b.events[b.start] = event
+	b.start = (b.start + 1) % b.max
+}
+
+// snapshot returns copies of the buffered events, oldest first.
+func (b *replayBuffer) snapshot() []*StreamEvent {
+	out := make([]*StreamEvent, len(b.events))
+	for i := range out {
+		event := *b.events[(b.start+i)%len(b.events)]
+		out[i] = &event
+	}
+	return out
+}
+
+// EnableReplay makes the stream keep the last maxEvents events it delivers,
+// so that a consumer attached after the stream started can catch up with
+// [Stream.Replay]. A maxEvents of 0 or less disables replay and frees the
+// buffer; calling it again with another size keeps the most recent events
+// that fit.
+//
+// Memory is bounded by maxEvents times the maximum size of an event (1MB).
+// Replay is disabled by default.
+//
+// This method is thread-safe.
+func (s *Stream) EnableReplay(maxEvents int) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	if maxEvents <= 0 {
+		s.replay = nil
+		return
+	}
+	buf := &replayBuffer{max: maxEvents}
+	if s.replay != nil {
+		history := s.replay.snapshot()
+		if len(history) > maxEvents {
+			history = history[len(history)-maxEvents:]
+		}
+		buf.events = append(make([]*StreamEvent, 0, len(history)), history...)
+	}
+	s.replay = buf
+}
+
+// Replay returns a copy of the buffered events, oldest first, or nil if
+// replay is disabled (see [Stream.EnableReplay]).
+//
+// An event is buffered by the time [Stream.Next] returns it, so a consumer
+// attached from the goroutine reading the stream (between two Next calls)
+// receives the replayed history followed by the next live event, without
+// gaps or duplicates.
+//
+// This method is thread-safe.
+//
+// Example:
+//
+//	stream.EnableReplay(500)
+//	var views []chan<- *stromboli.StreamEvent
+//	for stream.Next() {
+//	    select {
+//	    case view := <-attach: // a detailed view opened
+//	        for _, event := range stream.Replay() {
+//	            view <- event
+//	        }
+//	        views = append(views, view)
+//	    default:
+//	    }
+//	    for _, view := range views {
+//	        view <- stream.Event()
+//	    }
+//	}
+func (s *Stream) Replay() []*StreamEvent {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+	if s.replay == nil {
+		return nil
+	}
+	return s.replay.snapshot()
+}
+
+// recordReplay buffers a copy of event if replay is enabled.
+func (s *Stream) recordReplay(event *StreamEvent) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+	if s.replay != nil {
+		copied := *event
+		s.replay.add(&copied)
+	}
+}
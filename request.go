@@ -0,0 +1,235 @@
+package stromboli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// doJSON performs a JSON request against an endpoint that is not (yet)
+// covered by the generated client.
+//
+// It mirrors the behavior of the generated client: the base path is
+// preserved, the User-Agent and Bearer token are set, request/response
+// hooks are invoked, and the base context and effective timeout are applied.
+// The path must already be escaped, so that path parameters can be passed
+// through [url.PathEscape] (e.g. image names containing "/"). If body is
+// non-nil it is encoded as the JSON request body. If out is non-nil and the
+// response is successful, the response body is decoded into it.
+//
+// Non-2xx responses are returned as an [Error] built from the server's JSON
+// error body (see errorFromBody). For other bodies, the Code is derived from
+// the HTTP status (see httpStatusToErrorCode) and the Message contains the
+// raw response body (limited to maxErrorBodySize).
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	_, err := c.doJSONWithHeader(ctx, method, path, query, body, out)
+	return err
+}
+
+// doJSONWithHeader is like doJSON but also returns the response headers,
+// or nil if no response was received.
+func (c *Client) doJSONWithHeader(ctx context.Context, method, path string, query url.Values, body, out interface{}) (http.Header, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, newError("BAD_REQUEST", "failed to encode request", 400, err)
+		}
+	}
+
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
+	if timeout := c.effectiveTimeout(ctx); timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		defer cancelTimeout()
+	}
+
+	var reqBody io.Reader
+	if data != nil {
+		reqBody = bytes.NewReader(data)
+	}
+	resp, err := c.doRaw(ctx, method, path, query, reqBody, "application/json", "application/json")
+	if resp == nil {
+		return nil, err
+	}
+	defer func() {
+		// Drain any remaining body to allow HTTP/1.1 connection reuse
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if err != nil {
+		return resp.Header, err
+	}
+
+	if out == nil {
+		return resp.Header, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		if tooLarge := responseTooLarge(err); tooLarge != nil {
+			return resp.Header, tooLarge
+		}
+		return resp.Header, newError("INVALID_RESPONSE", "failed to decode response", resp.StatusCode, err)
+	}
+	return resp.Header, nil
+}
+
+// doRaw performs a request like doJSON, with body (if non-nil) as the
+// request body of type contentType and accept as the Accept header, and
+// returns the response. The body is streamed, so it can be larger than
+// memory. The base context and timeout must already be applied to ctx.
+//
+// The body of a successful response is left for the caller to read and
+// close. For non-2xx responses, the response is returned with its body
+// consumed along with the [Error] (see errorFromResponse), so callers can
+// still read its headers. The response is nil if none was received.
+func (c *Client) doRaw(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType, accept string) (*http.Response, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, newError("INVALID_URL", "invalid base URL", 0, err)
+	}
+	u.RawPath = strings.TrimSuffix(u.EscapedPath(), "/") + path
+	if u.Path, err = url.PathUnescape(u.RawPath); err != nil {
+		return nil, newError("BAD_REQUEST", "invalid request path", 400, err)
+	}
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+
+	if body == nil {
+		body = http.NoBody
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, newError("REQUEST_FAILED", "failed to create request", 0, err)
+	}
+	httpReq.Header.Set("Accept", accept)
+	if body != http.NoBody {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	c.setRequestID(httpReq)
+	if token := c.tokenFor(ctx); token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	setCallHeaders(httpReq)
+
+	if err := c.checkOpen(); err != nil {
+		return nil, err
+	}
+	mutating := isMutatingMethod(method)
+	if mutating {
+		if err := c.failFastInMaintenance(); err != nil {
+			return nil, err
+		}
+	}
+
+	c.runRequestHooks(httpReq)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if resp != nil {
+		recordStatus(ctx, resp.StatusCode)
+		c.runResponseHooks(resp)
+	}
+	if err != nil {
+		return nil, c.handleError(err, fmt.Sprintf("%s %s failed", method, path))
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := c.errorFromResponse(resp, mutating)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		return resp, err
+	}
+	c.observeMaintenance(mutating, resp.StatusCode, resp.Header, nil)
+	return resp, nil
+}
+
+// errorFromResponse creates the error for a non-2xx response of a raw
+// request, reading (up to maxErrorBodySize of) its body.
+//
+// The error is built from the server's JSON error body (see errorFromBody),
+// or else from the status with the raw body as Message. Maintenance
+// responses are recorded and returned as a [MaintenanceError]. The error
+// is translated by the error mapper (see WithErrorMapper).
+func (c *Client) errorFromResponse(resp *http.Response, mutating bool) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	apiErr := errorFromBody(resp.StatusCode, data, nil)
+	if apiErr == nil {
+		message := strings.TrimSpace(string(data))
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		apiErr = errorFromStatus(resp.StatusCode, message)
+	}
+	c.setRetryAfter(apiErr, resp.Header.Get("Retry-After"))
+	if until, ok := c.observeMaintenance(mutating, resp.StatusCode, resp.Header, data); ok {
+		return c.mapError(newMaintenanceError(apiErr.Message, apiErr.Status, until, c.clock.Now(), apiErr))
+	}
+	return c.mapError(apiErr)
+}
+
+// errorFromStatus creates an [Error] for an HTTP status code.
+//
+// The code is looked up in httpStatusToErrorCode; unmapped 5xx statuses map
+// to INTERNAL and everything else to REQUEST_FAILED.
+func errorFromStatus(status int, message string) *Error {
+	if code, ok := httpStatusToErrorCode[status]; ok {
+		return newError(code, message, status, nil)
+	}
+	if status >= http.StatusInternalServerError {
+		return newError(ErrInternal.Code, message, status, nil)
+	}
+	return newError("REQUEST_FAILED", message, status, nil)
+}
+
+// serverError is the JSON error body returned by the server, e.g.
+// {"error":"session not found","code":"SESSION_NOT_FOUND"}.
+type serverError struct {
+	Error  string `json:"error"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// errorFromBody creates an [Error] from a structured server error body.
+//
+// It returns nil if data is not a JSON object with at least one of the
+// error, code or detail fields, so callers can fall back to status-based
+// handling. The Message is built from the error and detail fields.
+//
+// If the server reports a code, it becomes the Error's Code and the
+// status-based error (e.g. NOT_FOUND) is kept as its Cause, so
+// errors.Is(err, ErrNotFound) keeps working for a 404 with a more specific
+// code such as SESSION_NOT_FOUND.
+func errorFromBody(status int, data []byte, cause error) *Error {
+	var body serverError
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil
+	}
+	if body.Error == "" && body.Code == "" && body.Detail == "" {
+		return nil
+	}
+
+	message := body.Error
+	switch {
+	case message == "":
+		message = body.Detail
+	case body.Detail != "":
+		message += ": " + body.Detail
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	statusErr := errorFromStatus(status, message)
+	statusErr.Cause = cause
+	if body.Code == "" || body.Code == statusErr.Code {
+		return statusErr
+	}
+	return newError(body.Code, message, status, statusErr)
+}
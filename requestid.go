@@ -0,0 +1,59 @@
+package stromboli
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+)
+
+// DefaultRequestIDHeader is the header [WithRequestIDHeader] sets when
+// given no header name.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key of a caller-supplied request ID.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying id, which requests
+// made with it send as their request ID instead of a generated one (see
+// [WithRequestIDHeader]). Use it to correlate a call with an ID you
+// already log, such as the ID of the incoming request being served.
+//
+// Like with [ContextWithToken], an ID containing control characters is
+// ignored with a warning, and ctx is returned unchanged.
+//
+// Example:
+//
+//	ctx := stromboli.ContextWithRequestID(r.Context(), r.Header.Get("X-Request-ID"))
+//	result, err := client.Run(ctx, req)
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	if !isValidToken(id) {
+		getLogger().Printf("stromboli: WARNING: ContextWithRequestID called with invalid ID (contains control characters), ignoring")
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// newRequestID returns a random ID of 26 base32 characters (128 bits),
+// the default request ID.
+func newRequestID() string {
+	return rand.Text()
+}
+
+// setRequestID sets the request ID header of req, if enabled, to the ID
+// of its context or else a generated one, and records it on the
+// operation.
+func (c *Client) setRequestID(req *http.Request) {
+	if c.requestIDHeader == "" {
+		return
+	}
+	ctx := req.Context()
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	if id == "" {
+		id = c.requestIDGen()
+	}
+	if id == "" {
+		return
+	}
+	req.Header.Set(c.requestIDHeader, id)
+	recordRequestID(ctx, id)
+}
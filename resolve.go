@@ -0,0 +1,130 @@
+package stromboli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Kinds of [Transformation] applied to a request.
+const (
+	// TransformationFieldDropped indicates a field was omitted because the
+	// server's version doesn't support it (see [WithVersionAwareRequests]).
+	TransformationFieldDropped = "field_dropped"
+
+	// TransformationDefaultApplied indicates a field was set or extended
+	// from the client's default options (see [WithDefaultClaudeOptions] and
+	// [WithDefaultPodmanOptions]).
+	TransformationDefaultApplied = "default_applied"
+)
+
+// Transformation is a change the SDK made to a request before sending it.
+type Transformation struct {
+	// Kind is the kind of change, one of the Transformation* constants.
+	Kind string `json:"kind"`
+
+	// Field is the JSON path of the field changed.
+	// Example: "podman.environment"
+	Field string `json:"field"`
+
+	// Detail explains the change.
+	// Example: "server 0.3.0 requires >= 0.4.0"
+	Detail string `json:"detail,omitempty"`
+}
+
+// ResolvedRequest is a run request as the SDK would send it, returned by
+// [Client.ResolveRequest] and by dry runs (see [RunRequest.DryRun]).
+//
+// Example:
+//
+//	resolved, err := client.ResolveRequest(ctx, req)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(string(resolved.JSON))
+//	for _, t := range resolved.Transformations {
+//	    fmt.Printf("%s %s: %s\n", t.Kind, t.Field, t.Detail)
+//	}
+type ResolvedRequest struct {
+	// JSON is the body of the execution request, pretty-printed.
+	JSON json.RawMessage `json:"json"`
+
+	// Transformations are the changes made to the request, in order.
+	Transformations []Transformation `json:"transformations,omitempty"`
+
+	// Warnings are the validation failures that would not stop the request
+	// under the client's [ValidationMode], such as an unknown model.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// ServerVersion is the server version the request was shaped for, or
+	// empty if version-aware requests are disabled, no field depends on the
+	// server version, or the version is unknown.
+	ServerVersion string `json:"server_version,omitempty"`
+}
+
+// resolution records how a request is resolved. A nil resolution records
+// nothing.
+type resolution struct {
+	transformations []Transformation
+	warnings        []string
+	serverVersion   string
+}
+
+// transform records a change made to the request.
+func (r *resolution) transform(kind, field, detail string) {
+	if r != nil {
+		r.transformations = append(r.transformations, Transformation{Kind: kind, Field: field, Detail: detail})
+	}
+}
+
+// warn records a validation failure that doesn't stop the request.
+func (r *resolution) warn(message string) {
+	if r != nil {
+		r.warnings = append(r.warnings, message)
+	}
+}
+
+// ResolveRequest returns req as [Client.Run] or [Client.RunAsync] would
+// send it, without executing it: the JSON body after conversion and
+// version-aware shaping, the transformations applied, and the validation
+// warnings that wouldn't stop it.
+//
+// The transformations the SDK applies are merging the client's default
+// options (see [WithDefaultClaudeOptions]) and dropping fields the server
+// doesn't support, with [WithVersionAwareRequests]; otherwise the JSON is
+// the request as given.
+//
+// Validation follows the client's [ValidationMode], so in strict mode a
+// request that would be rejected returns the same error as Run. Shaping
+// may query the server's version (see [WithVersionAwareRequests]); no
+// other request is made.
+//
+// Example:
+//
+//	resolved, err := client.ResolveRequest(ctx, req)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(string(resolved.JSON))
+//	for _, w := range resolved.Warnings {
+//	    fmt.Println("warning:", w)
+//	}
+func (c *Client) ResolveRequest(ctx context.Context, req *RunRequest) (*ResolvedRequest, error) {
+	res := &resolution{}
+	req, err := c.prepareRunRequest(req, res)
+	if err != nil {
+		return nil, err
+	}
+	genReq := c.toGeneratedRunRequest(ctx, req, res)
+
+	body, err := json.MarshalIndent(genReq, "", "  ")
+	if err != nil {
+		return nil, newError("BAD_REQUEST", fmt.Sprintf("failed to encode request: %v", err), 400, err)
+	}
+	return &ResolvedRequest{
+		JSON:            body,
+		Transformations: res.transformations,
+		Warnings:        res.warnings,
+		ServerVersion:   res.serverVersion,
+	}, nil
+}
@@ -0,0 +1,88 @@
+package stromboli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// defaultMaxResponseSize is the default limit of JSON response bodies (see
+// [WithMaxResponseSize]).
+const defaultMaxResponseSize = 32 * 1024 * 1024 // 32MB
+
+// responseLimitTransport fails JSON responses whose body is larger than
+// limit bytes (see [WithMaxResponseSize]).
+type responseLimitTransport struct {
+	base  http.RoundTripper
+	limit int64
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *responseLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.Body == nil || resp.Body == http.NoBody {
+		return resp, err
+	}
+	// Streams are read event by event and bounded by maxEventSize instead
+	if !acceptsJSON(req.Header.Get("Accept")) {
+		return resp, nil
+	}
+	resp.Body = &limitedBody{
+		body:     resp.Body,
+		reader:   io.LimitReader(resp.Body, t.limit+1),
+		limit:    t.limit,
+		declared: resp.ContentLength,
+	}
+	return resp, nil
+}
+
+// limitedBody is a response body that fails with a RESPONSE_TOO_LARGE
+// [Error] once more than limit bytes are read, or on the first read if the
+// declared Content-Length is already larger.
+type limitedBody struct {
+	body     io.ReadCloser
+	reader   io.Reader
+	limit    int64
+	declared int64
+	read     int64
+}
+
+// Read reads from the body, up to the limit.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.declared > b.limit || b.read > b.limit {
+		return 0, b.tooLarge()
+	}
+	n, err := b.reader.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		return n - int(b.read-b.limit), b.tooLarge()
+	}
+	return n, err
+}
+
+// tooLarge returns the error of a body over the limit.
+func (b *limitedBody) tooLarge() error {
+	return newError(ErrResponseTooLarge.Code, fmt.Sprintf("response body exceeds %d bytes", b.limit), 0, nil)
+}
+
+// Close closes the body.
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// responseTooLarge returns the RESPONSE_TOO_LARGE [Error] in err's chain,
+// or nil if there is none.
+func responseTooLarge(err error) *Error {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if apiErr, ok := err.(*Error); ok && apiErr.Code == ErrResponseTooLarge.Code {
+			return apiErr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package stromboli
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ansiEscape matches ANSI escape sequences: CSI sequences such as colors
+// ("\x1b[31m"), OSC sequences such as terminal titles (terminated by BEL or
+// ST), and two-byte escapes.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// utf8BOM is the UTF-8 encoded byte order mark.
+const utf8BOM = "\ufeff"
+
+// sanitizeOutput normalizes s according to mode (see [OutputSanitization]).
+func sanitizeOutput(s string, mode OutputSanitization) string {
+	if mode&OutputReplaceInvalid != 0 {
+		s = strings.TrimPrefix(s, utf8BOM)
+		s = strings.ToValidUTF8(s, "\ufffd")
+	}
+	if mode&OutputStripANSI != 0 && strings.IndexByte(s, '\x1b') >= 0 {
+		s = ansiEscape.ReplaceAllString(s, "")
+	}
+	return s
+}
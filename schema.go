@@ -0,0 +1,372 @@
+package stromboli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaOption configures the schema derived by [SchemaFor].
+type SchemaOption func(*schemaOptions)
+
+// schemaOptions holds the options of [SchemaFor].
+type schemaOptions struct {
+	// title is the title of the root schema ("" to omit it).
+	title string
+
+	// description is the description of the root schema ("" to omit it).
+	description string
+
+	// strict forbids properties not declared by a struct.
+	strict bool
+}
+
+// WithSchemaTitle sets the "title" of the derived schema.
+func WithSchemaTitle(title string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.title = title
+	}
+}
+
+// WithSchemaDescription sets the "description" of the derived schema.
+func WithSchemaDescription(description string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.description = description
+	}
+}
+
+// WithStrictSchema sets "additionalProperties": false on every object
+// derived from a struct, so the output can't contain fields the struct
+// doesn't declare.
+func WithStrictSchema() SchemaOption {
+	return func(o *schemaOptions) {
+		o.strict = true
+	}
+}
+
+// jsonSchemaDraft07 is the "$schema" of the schemas derived by [SchemaFor].
+const jsonSchemaDraft07 = "http://json-schema.org/draft-07/schema#"
+
+// SchemaFor derives a draft-07 JSON schema from the struct type T, for use
+// as [ClaudeOptions.JSONSchema] when the output is unmarshalled into a T.
+//
+// Properties follow encoding/json: they are named by the json tag, fields
+// tagged "-" and unexported fields are skipped, and the fields of embedded
+// structs without a name are promoted. Fields are required unless they are
+// pointers or tagged omitempty. Two more tags are read:
+//
+//   - desc sets the "description" of the property.
+//   - enum lists the allowed values, separated by commas. Values of numeric
+//     and boolean fields are parsed as such.
+//
+// Types map to JSON schema types: bool to boolean, integers to integer,
+// floats to number, strings to string, time.Time to a date-time string,
+// slices and arrays to array, maps with string keys to object, structs to
+// nested objects, and json.RawMessage and []byte to any value and string.
+// Other types (interfaces, channels, functions, complex numbers, maps with
+// other keys) and recursive types are rejected with a BAD_REQUEST [Error]
+// naming the field.
+//
+// Example:
+//
+//	type Review struct {
+//	    Summary string   `json:"summary" desc:"One-sentence summary"`
+//	    Score   int      `json:"score" enum:"1,2,3,4,5"`
+//	    Issues  []string `json:"issues,omitempty"`
+//	}
+//
+//	schema, err := stromboli.SchemaFor[Review]()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	req.Claude = &stromboli.ClaudeOptions{OutputFormat: "json", JSONSchema: schema}
+func SchemaFor[T any](opts ...SchemaOption) (string, error) {
+	o := &schemaOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return "", newError("BAD_REQUEST",
+			fmt.Sprintf("cannot derive JSON schema for %s: not a struct", t), 400, nil)
+	}
+
+	b := &schemaBuilder{opts: o, visiting: map[reflect.Type]bool{}}
+	root, err := b.schemaOf(t, t.Name())
+	if err != nil {
+		return "", newError("BAD_REQUEST",
+			fmt.Sprintf("cannot derive JSON schema for %s: %v", t, err), 400, nil)
+	}
+	root.Schema = jsonSchemaDraft07
+	root.Title = o.title
+	root.Description = o.description
+
+	data, err := json.Marshal(root)
+	if err != nil {
+		return "", newError("BAD_REQUEST",
+			fmt.Sprintf("cannot encode JSON schema for %s: %v", t, err), 400, err)
+	}
+	return string(data), nil
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// jsonSchema is a JSON schema derived by [SchemaFor].
+type jsonSchema struct {
+	Schema               string            `json:"$schema,omitempty"`
+	Title                string            `json:"title,omitempty"`
+	Description          string            `json:"description,omitempty"`
+	Type                 string            `json:"type,omitempty"`
+	Format               string            `json:"format,omitempty"`
+	Enum                 []interface{}     `json:"enum,omitempty"`
+	Properties           *schemaProperties `json:"properties,omitempty"`
+	Required             []string          `json:"required,omitempty"`
+	Items                *jsonSchema       `json:"items,omitempty"`
+	AdditionalProperties interface{}       `json:"additionalProperties,omitempty"`
+}
+
+// schemaProperties holds the properties of an object schema in field
+// order, which encoding/json wouldn't keep for a map.
+type schemaProperties struct {
+	names   []string
+	schemas map[string]*jsonSchema
+}
+
+// set adds or replaces the property name.
+func (p *schemaProperties) set(name string, s *jsonSchema) {
+	if _, ok := p.schemas[name]; !ok {
+		p.names = append(p.names, name)
+	}
+	p.schemas[name] = s
+}
+
+// MarshalJSON encodes the properties as an object in field order.
+func (p *schemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range p.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(p.schemas[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// schemaBuilder derives the schemas of types for [SchemaFor].
+type schemaBuilder struct {
+	opts *schemaOptions
+
+	// visiting holds the struct types being derived, to detect recursion.
+	visiting map[reflect.Type]bool
+}
+
+// schemaOf returns the schema of t. path names the value of type t in
+// errors.
+func (b *schemaBuilder) schemaOf(t reflect.Type, path string) (*jsonSchema, error) {
+	switch t {
+	case timeType:
+		return &jsonSchema{Type: "string", Format: "date-time"}, nil
+	case rawMessageType:
+		return &jsonSchema{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}, nil
+	case reflect.String:
+		return &jsonSchema{Type: "string"}, nil
+	case reflect.Pointer:
+		return b.schemaOf(t.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes []byte as a base64 string
+			return &jsonSchema{Type: "string"}, nil
+		}
+		items, err := b.schemaOf(t.Elem(), path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%s: unsupported map key type %s (keys must be strings)", path, t.Key())
+		}
+		values, err := b.schemaOf(t.Elem(), path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return b.structSchema(t, path)
+	default:
+		return nil, fmt.Errorf("%s: unsupported type %s", path, t)
+	}
+}
+
+// structSchema returns the object schema of the struct type t.
+func (b *schemaBuilder) structSchema(t reflect.Type, path string) (*jsonSchema, error) {
+	if b.visiting[t] {
+		return nil, fmt.Errorf("%s: recursive type %s", path, t)
+	}
+	b.visiting[t] = true
+	defer delete(b.visiting, t)
+
+	s := &jsonSchema{
+		Type:       "object",
+		Properties: &schemaProperties{schemas: map[string]*jsonSchema{}},
+	}
+	if b.opts.strict {
+		s.AdditionalProperties = false
+	}
+	if err := b.addFields(s, t, path); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// addFields adds the properties of the fields of the struct type t to s,
+// promoting the fields of embedded structs.
+func (b *schemaBuilder) addFields(s *jsonSchema, t reflect.Type, path string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			embedded := fieldType
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && embedded != timeType {
+				if err := b.addFields(s, embedded, path); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldPath := path + "." + field.Name
+		prop, err := b.schemaOf(fieldType, fieldPath)
+		if err != nil {
+			return err
+		}
+		if desc := field.Tag.Get("desc"); desc != "" {
+			prop.Description = desc
+		}
+		if enum, ok := field.Tag.Lookup("enum"); ok {
+			// The enum of a list or map applies to its values
+			target, valueType := prop, fieldType
+			for valueType.Kind() == reflect.Pointer {
+				valueType = valueType.Elem()
+			}
+			switch valueType.Kind() {
+			case reflect.Slice, reflect.Array:
+				if prop.Items != nil {
+					target, valueType = prop.Items, valueType.Elem()
+				}
+			case reflect.Map:
+				target, valueType = prop.AdditionalProperties.(*jsonSchema), valueType.Elem()
+			}
+			values, err := enumValues(valueType, enum)
+			if err != nil {
+				return fmt.Errorf("%s: %v", fieldPath, err)
+			}
+			target.Enum = values
+		}
+		s.Properties.set(name, prop)
+
+		optional := fieldType.Kind() == reflect.Pointer || hasTagOption(options, "omitempty")
+		if !optional {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return nil
+}
+
+// enumValues parses the comma-separated values of an enum tag for values
+// of type t.
+func enumValues(t reflect.Type, tag string) ([]interface{}, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	raws := strings.Split(tag, ",")
+	values := make([]interface{}, 0, len(raws))
+	for _, raw := range raws {
+		raw = strings.TrimSpace(raw)
+		switch t.Kind() {
+		case reflect.String:
+			values = append(values, raw)
+		case reflect.Bool:
+			v, err := strconv.ParseBool(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid enum value %q for %s", raw, t)
+			}
+			values = append(values, v)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid enum value %q for %s", raw, t)
+			}
+			values = append(values, v)
+		case reflect.Float32, reflect.Float64:
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid enum value %q for %s", raw, t)
+			}
+			values = append(values, v)
+		default:
+			return nil, fmt.Errorf("enum tag is not supported on %s", t)
+		}
+	}
+	return values, nil
+}
+
+// hasTagOption reports whether the comma-separated json tag options
+// contain option.
+func hasTagOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
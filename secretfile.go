@@ -0,0 +1,86 @@
+package stromboli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// defaultMaxSecretSize is the default limit of secret values read by
+// [Client.CreateSecretFromReader] (see [WithMaxSecretSize]).
+const defaultMaxSecretSize = 4 * 1024 * 1024 // 4MB
+
+// CreateSecretFromReader creates a secret whose value is read from r, such
+// as a TLS key or a kubeconfig, like [Client.CreateSecret].
+//
+// The value is read up to the client's limit (4MB by default, see
+// [WithMaxSecretSize]); a larger value returns a BAD_REQUEST [Error]
+// without a request. The API carries secret values as JSON strings, so
+// values that aren't valid UTF-8 would be altered in transit: they return
+// a BAD_REQUEST error too, and binary data should be encoded first (e.g.
+// with base64). The bytes read are sent unchanged otherwise.
+//
+// Example:
+//
+//	err := client.CreateSecretFromReader(ctx, "kubeconfig", strings.NewReader(config))
+func (c *Client) CreateSecretFromReader(ctx context.Context, name string, r io.Reader) error {
+	if r == nil {
+		return newError("BAD_REQUEST", "secret reader is required", 400, nil)
+	}
+	if err := validateSecretName(name); err != nil {
+		return err
+	}
+
+	value, err := c.readSecretValue(r, "secret value")
+	if err != nil {
+		return err
+	}
+	return c.CreateSecret(ctx, &CreateSecretRequest{Name: name, Value: value})
+}
+
+// CreateSecretFromFile creates a secret whose value is the content of the
+// file at path, like [Client.CreateSecretFromReader]. Errors opening or
+// reading the file name the path, and wrap the os error:
+//
+//	err := client.CreateSecretFromFile(ctx, "tls-key", "/etc/tls/server.key")
+//	if errors.Is(err, fs.ErrNotExist) {
+//	    log.Fatal(err) // ... secret file "/etc/tls/server.key" ...
+//	}
+func (c *Client) CreateSecretFromFile(ctx context.Context, name, path string) error {
+	if err := validateSecretName(name); err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return newError("REQUEST_FAILED", fmt.Sprintf("failed to open secret file %q", path), 0, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	value, err := c.readSecretValue(f, fmt.Sprintf("secret file %q", path))
+	if err != nil {
+		return err
+	}
+	return c.CreateSecret(ctx, &CreateSecretRequest{Name: name, Value: value})
+}
+
+// readSecretValue reads a secret value from r, up to the client's limit.
+// source describes r in error messages.
+func (c *Client) readSecretValue(r io.Reader, source string) (string, error) {
+	if c.maxSecretSize > 0 {
+		r = io.LimitReader(r, c.maxSecretSize+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", newError("REQUEST_FAILED", "failed to read "+source, 0, err)
+	}
+	if c.maxSecretSize > 0 && int64(len(data)) > c.maxSecretSize {
+		return "", newError("BAD_REQUEST", fmt.Sprintf("%s exceeds %d bytes", source, c.maxSecretSize), 400, nil)
+	}
+	if !utf8.Valid(data) {
+		return "", newError("BAD_REQUEST", source+" is not valid UTF-8; encode binary data (e.g. base64)", 400, nil)
+	}
+	return string(data), nil
+}
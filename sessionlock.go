@@ -0,0 +1,156 @@
+package stromboli
+
+import (
+	"context"
+	"sync"
+)
+
+// sessionLocks serializes the calls of a client targeting the same session
+// (see [WithSessionSerialization]). The client also uses one, keyed by image
+// reference, for [Client.EnsureImage].
+//
+// Each session in use has a lock, reference counted by the calls holding or
+// waiting for it. A lock is dropped as soon as no call references it, so
+// the map only holds sessions in use and idle sessions cost nothing.
+type sessionLocks struct {
+	// mu protects locks and the refs of its entries.
+	mu sync.Mutex
+
+	// locks holds the lock of each session in use, by session ID.
+	locks map[string]*sessionLock
+}
+
+// sessionLock is the lock of one session.
+type sessionLock struct {
+	// held holds a token while a call holds the lock.
+	held chan struct{}
+
+	// refs is the number of calls holding or waiting for the lock.
+	refs int
+}
+
+// newSessionLocks creates an empty set of session locks.
+func newSessionLocks() *sessionLocks {
+	return &sessionLocks{locks: make(map[string]*sessionLock)}
+}
+
+// ref returns the lock of sessionID, creating it if needed, and adds a
+// reference to it.
+func (l *sessionLocks) ref(sessionID string) *sessionLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lock, ok := l.locks[sessionID]
+	if !ok {
+		lock = &sessionLock{held: make(chan struct{}, 1)}
+		l.locks[sessionID] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+// unref removes a reference to the lock of sessionID, dropping the lock
+// when it was the last one.
+func (l *sessionLocks) unref(sessionID string, lock *sessionLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lock.refs--
+	if lock.refs == 0 {
+		delete(l.locks, sessionID)
+	}
+}
+
+// acquire takes the lock of sessionID, blocking until it is free or ctx is
+// done. It returns false if ctx is done first.
+func (l *sessionLocks) acquire(ctx context.Context, sessionID string) bool {
+	lock := l.ref(sessionID)
+	if !acquireSlot(ctx, lock.held) {
+		l.unref(sessionID, lock)
+		return false
+	}
+	return true
+}
+
+// tryAcquire takes the lock of sessionID if it is free, without blocking.
+func (l *sessionLocks) tryAcquire(sessionID string) bool {
+	lock := l.ref(sessionID)
+	select {
+	case lock.held <- struct{}{}:
+		return true
+	default:
+		l.unref(sessionID, lock)
+		return false
+	}
+}
+
+// release gives back the lock of sessionID taken with acquire or
+// tryAcquire.
+func (l *sessionLocks) release(sessionID string) {
+	l.mu.Lock()
+	lock := l.locks[sessionID]
+	l.mu.Unlock()
+
+	<-lock.held
+	l.unref(sessionID, lock)
+}
+
+// lockSession takes the client's lock of sessionID, blocking until it is
+// free or ctx is done, and returns the function releasing it. It does
+// nothing if the client doesn't serialize sessions or sessionID is empty.
+func (c *Client) lockSession(ctx context.Context, sessionID string) (unlock func(), err error) {
+	if c.sessionLocks == nil || sessionID == "" {
+		return func() {}, nil
+	}
+	ctx, cancel := c.withBaseContext(ctx)
+	defer cancel()
+	if !c.sessionLocks.acquire(ctx, sessionID) {
+		return nil, c.handleError(ctx.Err(), "cancelled while waiting for session "+sessionID)
+	}
+	return func() { c.sessionLocks.release(sessionID) }, nil
+}
+
+// runSessionID returns the ID of the session a run request targets, or ""
+// if it starts a new session.
+func runSessionID(req *RunRequest) string {
+	if req.Claude == nil {
+		return ""
+	}
+	return req.Claude.SessionID
+}
+
+// TryRun is like [Client.Run] but doesn't wait for the session of the
+// request to be free (see [WithSessionSerialization]): if another call of
+// the client is using it, TryRun returns [ErrSessionBusy] immediately
+// without contacting the server. Without session serialization, or for a
+// request starting a new session, it is the same as Run.
+//
+// Example:
+//
+//	result, err := client.TryRun(ctx, req)
+//	if errors.Is(err, stromboli.ErrSessionBusy) {
+//	    // Another goroutine is talking to this session; try again later
+//	}
+func (c *Client) TryRun(ctx context.Context, req *RunRequest) (_ *RunResponse, err error) {
+	ctx, op := c.observe(ctx, "TryRun")
+	defer op.finish(&err)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if c.sessionLocks == nil || (req != nil && req.DryRun) {
+		return c.Run(ctx, req)
+	}
+	req, err = c.prepareRunRequest(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	sessionID := runSessionID(req)
+	if sessionID == "" {
+		return c.run(ctx, req)
+	}
+	if !c.sessionLocks.tryAcquire(sessionID) {
+		return nil, newError(ErrSessionBusy.Code, ErrSessionBusy.Message, 0, nil)
+	}
+	defer c.sessionLocks.release(sessionID)
+	return c.run(ctx, req)
+}
@@ -0,0 +1,98 @@
+package stromboli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/tomblancdev/stromboli-go/generated/models"
+)
+
+// requestField is an optional [RunRequest] field that is only understood by
+// servers from a given version on.
+type requestField struct {
+	// name is the JSON path of the field, used in log messages.
+	// Example: "podman.environment"
+	name string
+
+	// minVersion is the first server version that accepts the field.
+	minVersion string
+
+	// isSet reports whether the field is set in req.
+	isSet func(req *models.RunRequest) bool
+
+	// clear removes the field from req.
+	clear func(req *models.RunRequest)
+}
+
+// runRequestFields lists the run request fields that older servers reject.
+//
+// This is the single place that maps fields to the server version that
+// introduced them; [WithVersionAwareRequests] uses it to shape requests.
+// When regenerating the models adds a field that servers older than
+// [APIVersion] don't understand, add it here and update the expectations
+// in tests/unit/shaping_test.go.
+//
+// Every field of the current generated model is accepted by all servers in
+// [APIVersionRange], so no field needs shaping yet.
+var runRequestFields = []requestField{}
+
+// shapeRunRequest removes the fields of req that the server doesn't support,
+// if version-aware requests are enabled (see [WithVersionAwareRequests]).
+//
+// The server version comes from the cached capabilities, or from
+// [Client.Health] if the server doesn't report it there. If the version
+// can't be determined or parsed, req is sent unchanged. Each dropped field
+// is logged once per client, and recorded in res.
+func (c *Client) shapeRunRequest(ctx context.Context, req *models.RunRequest, res *resolution) {
+	if !c.versionAwareRequests || len(runRequestFields) == 0 {
+		return
+	}
+
+	version := c.targetServerVersion(ctx)
+	if version == "" {
+		return
+	}
+	sv, err := semver.NewVersion(version)
+	if err != nil {
+		return
+	}
+	if res != nil {
+		res.serverVersion = version
+	}
+
+	for _, field := range runRequestFields {
+		if !field.isSet(req) || !sv.LessThan(semver.MustParse(field.minVersion)) {
+			continue
+		}
+		field.clear(req)
+		res.transform(TransformationFieldDropped, field.name,
+			fmt.Sprintf("server %s requires >= %s", version, field.minVersion))
+		if _, logged := c.shapedFields.LoadOrStore(field.name, struct{}{}); !logged {
+			c.logf(slog.LevelWarn, "server %s does not support %s (requires >= %s), omitting it from requests",
+				version, field.name, field.minVersion)
+		}
+	}
+}
+
+// targetServerVersion returns the version of the server, or "" if unknown.
+func (c *Client) targetServerVersion(ctx context.Context) string {
+	if caps, err := c.Capabilities(ctx); err == nil && caps.Version != "" {
+		return caps.Version
+	}
+
+	c.capsMu.Lock()
+	version := c.serverVersion
+	c.capsMu.Unlock()
+	if version != "" {
+		return version
+	}
+
+	// Health records the version, so this only happens once per client
+	if health, err := c.Health(ctx); err == nil {
+		return health.Version
+	}
+	return ""
+}
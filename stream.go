@@ -3,6 +3,8 @@ package stromboli
 import (
 	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -20,6 +22,10 @@ import (
 // error messages while providing a safety limit.
 const maxErrorBodySize = 4096
 
+// sessionIDHeader is the response header carrying the session ID of a
+// stream, when the server knows it upfront.
+const sessionIDHeader = "X-Session-ID"
+
 // maxEventSize limits the maximum size of a single SSE event to prevent
 // memory exhaustion from malformed or malicious servers that might send
 // events without proper empty line delimiters. 1MB is generous for LLM
@@ -53,7 +59,8 @@ type StreamRequest struct {
 // Most events will have Type empty and Data containing the output.
 type StreamEvent struct {
 	// Type is the event type (from "event:" line).
-	// Common types: "", "message", "error", "done"
+	// Common types: "", "message", "error", "done".
+	// Use [StreamEvent.IsDone] and [StreamEvent.AsError] to interpret them.
 	Type string
 
 	// Data is the event payload (from "data:" line).
@@ -63,6 +70,78 @@ type StreamEvent struct {
 	ID string
 }
 
+// Stream event types sent by the server.
+const (
+	// EventTypeDone marks the end of a stream.
+	EventTypeDone = "done"
+
+	// EventTypeError carries an error message; see [StreamEvent.AsError].
+	EventTypeError = "error"
+
+	// EventTypeSession carries the session ID of the conversation in its
+	// data; see [Stream.SessionID].
+	EventTypeSession = "session"
+)
+
+// IsDone reports whether this is the terminating "done" event.
+//
+// Example:
+//
+//	for stream.Next() {
+//	    event := stream.Event()
+//	    if event.IsDone() {
+//	        break
+//	    }
+//	    if err := event.AsError(); err != nil {
+//	        return err
+//	    }
+//	    fmt.Print(event.Data)
+//	}
+func (e *StreamEvent) IsDone() bool {
+	return e.Type == EventTypeDone
+}
+
+// IsError reports whether this is an "error" event.
+func (e *StreamEvent) IsError() bool {
+	return e.Type == EventTypeError
+}
+
+// isOutput reports whether the event carries output, as opposed to a
+// control event such as "done" or "session".
+func (e *StreamEvent) isOutput() bool {
+	return e.Type == "" || e.Type == "message"
+}
+
+// AsError returns the error carried by an "error" event, or nil for any
+// other event.
+//
+// The returned [Error] has Code "STREAM_ERROR". If Data is a JSON object
+// with a "message" or "error" field, that field is used as the Message;
+// otherwise Data is used as-is.
+func (e *StreamEvent) AsError() error {
+	if !e.IsError() {
+		return nil
+	}
+
+	message := strings.TrimSpace(e.Data)
+	var payload struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal([]byte(message), &payload) == nil {
+		switch {
+		case payload.Message != "":
+			message = payload.Message
+		case payload.Error != "":
+			message = payload.Error
+		}
+	}
+	if message == "" {
+		message = "stream error"
+	}
+	return newError("STREAM_ERROR", message, 0, nil)
+}
+
 // Stream represents an active SSE stream from Claude.
 //
 // Use [Client.Stream] to create a stream, then iterate over events:
@@ -92,6 +171,38 @@ type Stream struct {
 	err       error        // use setErr/getErr for thread-safe access
 	closed    atomic.Bool
 	cancel    context.CancelFunc // context cancel function for stream timeout
+
+	sessionMu  sync.RWMutex // protects sessionID
+	sessionID  string       // use setSessionID/SessionID for thread-safe access
+	eventsRead int          // number of events read; only touched by readEvent
+
+	sanitize OutputSanitization // applied to event data (see WithOutputSanitization)
+
+	replayMu sync.Mutex    // protects replay
+	replay   *replayBuffer // delivered events; nil unless EnableReplay was called
+
+	id     string  // from the X-Stream-ID header; empty if the server doesn't report it
+	client *Client // sends the abort request; nil for replayed job streams
+
+	onDone func() // called when the "done" event is read; nil if unused
+
+	span    TraceSpan // covers the event loop; nil if not traced (see WithTracer)
+	unlock  func()    // releases the session lock; nil if not held (see WithSessionSerialization)
+	endOnce sync.Once // ends span and calls unlock
+}
+
+// end is called when the stream ends, with the error that ended it (nil if
+// it ended normally or was closed). The first call ends the span of the
+// event loop and releases the session lock.
+func (s *Stream) end(err error) {
+	s.endOnce.Do(func() {
+		if s.span != nil {
+			s.span.End(0, err)
+		}
+		if s.unlock != nil {
+			s.unlock()
+		}
+	})
 }
 
 // setCurrent sets the current event (thread-safe).
@@ -146,10 +257,17 @@ func (s *Stream) Next() bool {
 	if err != nil {
 		if err != io.EOF {
 			s.setErr(err)
+			s.end(err)
+		} else {
+			s.end(nil)
 		}
 		return false
 	}
 
+	if event.IsDone() && s.onDone != nil {
+		s.onDone()
+	}
+	s.recordReplay(event)
 	s.setCurrent(event)
 	return true
 }
@@ -183,6 +301,84 @@ func (s *Stream) Err() error {
 	return s.getErr()
 }
 
+// SessionID returns the session ID of the conversation, for follow-up
+// requests with [StreamRequest.SessionID].
+//
+// The ID is taken from wherever the server exposes it: the X-Session-ID
+// response header, known as soon as the stream is opened; the "id:" field
+// of the first event; a "session" event (whose data is the session ID); or
+// the session_id field of a JSON "done" event or stream-json "result"
+// message. Session events and final payloads override the header and
+// first event ID.
+//
+// It may be empty until the first session-bearing event has been
+// consumed, so call it after [Stream.Next] has returned (typically once
+// the stream is done).
+//
+// This method is thread-safe.
+//
+// Example:
+//
+//	for stream.Next() {
+//	    fmt.Print(stream.Event().Data)
+//	}
+//	next, _ := client.Stream(ctx, &stromboli.StreamRequest{
+//	    Prompt:    "And now in French",
+//	    SessionID: stream.SessionID(),
+//	})
+func (s *Stream) SessionID() string {
+	s.sessionMu.RLock()
+	defer s.sessionMu.RUnlock()
+	return s.sessionID
+}
+
+// setSessionID sets the session ID (thread-safe).
+func (s *Stream) setSessionID(id string) {
+	s.sessionMu.Lock()
+	s.sessionID = id
+	s.sessionMu.Unlock()
+}
+
+// captureSessionID records the session ID carried by event, if any.
+// A "session" event or a final payload always wins; the "id:" field is
+// only used for the first event of the stream, and only if no session ID
+// is known yet.
+func (s *Stream) captureSessionID(event *StreamEvent) {
+	s.eventsRead++
+	if event.Type == EventTypeSession {
+		if id := strings.TrimSpace(event.Data); id != "" {
+			s.setSessionID(id)
+		}
+		return
+	}
+	if id := finalPayloadSessionID(event); id != "" {
+		s.setSessionID(id)
+		return
+	}
+	if s.eventsRead == 1 && event.ID != "" && s.SessionID() == "" {
+		s.setSessionID(event.ID)
+	}
+}
+
+// finalPayloadSessionID returns the session_id field of a JSON "done"
+// event or stream-json "result" message, or "" for any other event.
+func finalPayloadSessionID(event *StreamEvent) string {
+	if !strings.Contains(event.Data, `"session_id"`) {
+		return ""
+	}
+	var payload struct {
+		Type      string `json:"type"`
+		SessionID string `json:"session_id"`
+	}
+	if json.Unmarshal([]byte(event.Data), &payload) != nil {
+		return ""
+	}
+	if event.Type != EventTypeDone && payload.Type != StreamMessageResult {
+		return ""
+	}
+	return strings.TrimSpace(payload.SessionID)
+}
+
 // Close closes the stream and releases resources.
 //
 // Always call Close when done with the stream, preferably with defer.
@@ -202,6 +398,7 @@ func (s *Stream) Close() error {
 	if s.closed.Swap(true) {
 		return nil // Already closed
 	}
+	s.end(nil)
 	// Call cancel first to release context resources.
 	// This prevents the context from leaking if streamTimeout was applied.
 	if s.cancel != nil {
@@ -322,17 +519,65 @@ func (s *Stream) Events() <-chan *StreamEvent {
 	return s.EventsWithContext(context.Background())
 }
 
+// Collect reads the rest of the stream, closes it, and returns the output:
+// the concatenated data of its output events. Control events such as
+// "done" and "session" are skipped.
+//
+// Reading stops at an "error" event, in which case the output read so far
+// is returned with the event's error (see [StreamEvent.AsError]).
+// Otherwise the error is that of [Stream.Err]. If ctx is done before the
+// stream ends, the stream is closed and ctx's error is returned.
+//
+// Example:
+//
+//	stream, err := client.Stream(ctx, req)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	output, err := stream.Collect(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(output)
+func (s *Stream) Collect(ctx context.Context) (string, error) {
+	defer func() { _ = s.Close() }()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	// Closing the stream interrupts a blocked read
+	stop := context.AfterFunc(ctx, func() {
+		s.setErr(ctx.Err())
+		_ = s.Close()
+	})
+	defer stop()
+
+	var output strings.Builder
+	for s.Next() {
+		event := s.Event()
+		if err := event.AsError(); err != nil {
+			return output.String(), err
+		}
+		if event.IsDone() {
+			break
+		}
+		if event.isOutput() {
+			output.WriteString(event.Data)
+		}
+	}
+	return output.String(), s.Err()
+}
+
 // readEvent reads the next SSE event from the stream.
 //
 // NOTE: This method blocks on network I/O until a complete event is received.
-// Go's context cancellation does not interrupt blocking reads. To implement
-// timeouts, use one of these approaches:
+// A blocked read is interrupted (with the context's error) when the request
+// context is done, so use one of these approaches to bound it:
 //   - [WithStreamTimeout]: Applies timeout if no context deadline is set
 //   - [context.WithTimeout]: Pass a context with deadline to [Client.Stream]
 //   - Call [Stream.Close] from another goroutine to unblock the reader
 //
-// The [Stream.EventsWithContext] method handles this automatically by watching
-// for context cancellation and closing the stream.
+// The [Stream.EventsWithContext] method also watches a separate context and
+// closes the stream when it is cancelled.
 func (s *Stream) readEvent() (*StreamEvent, error) {
 	event := &StreamEvent{}
 	var dataBuilder strings.Builder
@@ -344,7 +589,8 @@ func (s *Stream) readEvent() (*StreamEvent, error) {
 		if err != nil {
 			if err == io.EOF && hasData {
 				// Return the event we have so far
-				event.Data = dataBuilder.String()
+				event.Data = sanitizeOutput(dataBuilder.String(), s.sanitize)
+				s.captureSessionID(event)
 				return event, nil
 			}
 			return nil, err
@@ -368,7 +614,8 @@ func (s *Stream) readEvent() (*StreamEvent, error) {
 		// Empty line marks end of event
 		if line == "" {
 			if hasData {
-				event.Data = dataBuilder.String()
+				event.Data = sanitizeOutput(dataBuilder.String(), s.sanitize)
+				s.captureSessionID(event)
 				return event, nil
 			}
 			continue
@@ -465,7 +712,15 @@ func (s *Stream) readEvent() (*StreamEvent, error) {
 //	    Prompt:    "What's my name?",
 //	    SessionID: sessionID,
 //	})
-func (c *Client) Stream(ctx context.Context, req *StreamRequest) (*Stream, error) {
+func (c *Client) Stream(ctx context.Context, req *StreamRequest, opts ...CallOption) (_ *Stream, err error) {
+	ctx, op := c.observe(ctx, "Stream")
+	defer op.finish(&err)
+	ctx = withCallOptions(ctx, opts)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
 	if req == nil {
 		return nil, newError("BAD_REQUEST", "request is required", 400, nil)
 	}
@@ -473,21 +728,63 @@ func (c *Client) Stream(ctx context.Context, req *StreamRequest) (*Stream, error
 		return nil, newError("BAD_REQUEST", "prompt is required", 400, nil)
 	}
 	if len(req.Prompt) > maxPromptSize {
-		return nil, newError("BAD_REQUEST",
+		err := newError("BAD_REQUEST",
 			fmt.Sprintf("prompt exceeds maximum size of %d bytes (got %d)", maxPromptSize, len(req.Prompt)),
 			400, nil)
+		if err := c.applyValidationMode(err, nil); err != nil {
+			return nil, err
+		}
 	}
 
-	// Apply stream timeout if set and context deadline is missing or longer.
-	// This prevents indefinite hangs when the server stops responding.
+	query := url.Values{}
+	query.Set("prompt", req.Prompt)
+	if req.Workdir != "" {
+		query.Set("workdir", req.Workdir)
+	}
+	if req.SessionID != "" {
+		query.Set("session_id", req.SessionID)
+	}
+
+	unlock, err := c.lockSession(ctx, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := c.openStream(ctx, "/run/stream", query)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	stream.unlock = unlock
+	return stream, nil
+}
+
+// openStream connects to an SSE endpoint and returns a [Stream] reading from it.
+//
+// The path is appended to the base URL (preserving any base path), and query
+// is encoded as the URL query string. Non-200 responses are returned as an
+// [Error] with Code "STREAM_ERROR" and Status set to the HTTP status code, so
+// callers can map specific statuses to sentinel errors.
+func (c *Client) openStream(ctx context.Context, path string, query url.Values) (*Stream, error) {
+	// Inherit cancellation from the client's base context, so that
+	// cancelling it also closes active streams.
 	// The cancel function is stored in the Stream and called in Close().
-	var cancel context.CancelFunc
-	if c.streamTimeout > 0 {
+	ctx, cancel := c.withBaseContext(ctx)
+
+	// Apply stream timeout (or the call's timeout) if set and context
+	// deadline is missing or longer.
+	// This prevents indefinite hangs when the server stops responding.
+	if streamTimeout := callTimeout(ctx, c.streamTimeout); streamTimeout > 0 {
 		deadline, hasDeadline := ctx.Deadline()
 		// Apply stream timeout if no deadline exists OR if the existing deadline
 		// is further away than our stream timeout (prefer the shorter timeout)
-		if !hasDeadline || time.Until(deadline) > c.streamTimeout {
-			ctx, cancel = context.WithTimeout(ctx, c.streamTimeout)
+		if !hasDeadline || time.Until(deadline) > streamTimeout {
+			var cancelTimeout context.CancelFunc
+			ctx, cancelTimeout = context.WithTimeout(ctx, streamTimeout)
+			cancelBase := cancel
+			cancel = func() {
+				cancelTimeout()
+				cancelBase()
+			}
 		}
 	}
 
@@ -508,16 +805,7 @@ func (c *Client) Stream(ctx context.Context, req *StreamRequest) (*Stream, error
 	// Use explicit forward slash concatenation instead of path.Join to avoid
 	// Windows path separator issues (path.Join uses OS-specific separator).
 	basePath := strings.TrimSuffix(u.Path, "/")
-	u.Path = basePath + "/run/stream"
-
-	query := u.Query()
-	query.Set("prompt", req.Prompt)
-	if req.Workdir != "" {
-		query.Set("workdir", req.Workdir)
-	}
-	if req.SessionID != "" {
-		query.Set("session_id", req.SessionID)
-	}
+	u.Path = basePath + path
 	u.RawQuery = query.Encode()
 
 	// Create HTTP request
@@ -532,31 +820,47 @@ func (c *Client) Stream(ctx context.Context, req *StreamRequest) (*Stream, error
 	httpReq.Header.Set("Cache-Control", "no-cache")
 	httpReq.Header.Set("Connection", "keep-alive")
 	httpReq.Header.Set("User-Agent", c.userAgent)
+	c.setRequestID(httpReq)
 
-	// Add auth if token is set (thread-safe access).
-	// Note: Token is captured at this point. If SetToken is called concurrently,
+	// Add auth if token is set (thread-safe access), preferring the
+	// context's token. Note: Token is captured at this point. If SetToken is called concurrently,
 	// this request may use the previous token. Call SetToken before Stream if
 	// you need to ensure the latest token is used.
-	if token := c.getToken(); token != "" {
+	if token := c.tokenFor(ctx); token != "" {
 		httpReq.Header.Set("Authorization", "Bearer "+token)
 	}
+	setCallHeaders(httpReq)
 
-	// Call request hook if set (before executing request)
-	if c.requestHook != nil {
-		c.requestHook(httpReq)
+	if err := c.checkOpen(); err != nil {
+		cancelOnError()
+		return nil, err
 	}
 
+	// Streaming a run starts an execution, so it is rejected during an
+	// advertised maintenance window like other mutating calls
+	startsRun := path == "/run/stream"
+	if startsRun {
+		if err := c.failFastInMaintenance(); err != nil {
+			cancelOnError()
+			return nil, err
+		}
+	}
+
+	// Call request hooks (before executing request)
+	c.runRequestHooks(httpReq)
+
 	// Execute request.
 	// Per Go http.Client docs: on error, any non-nil response can be ignored.
 	// The client handles cleanup of any partial response internally.
 	resp, err := c.httpClient.Do(httpReq)
 
-	// Call response hook if set and we got a response.
-	// On network errors, resp may be nil, so we skip the hook.
+	// Call response hooks if we got a response.
+	// On network errors, resp may be nil, so we skip the hooks.
 	// This asymmetry is intentional: request hooks fire for all requests,
 	// response hooks fire only for successful network round-trips.
-	if c.responseHook != nil && resp != nil {
-		c.responseHook(resp)
+	if resp != nil {
+		recordStatus(ctx, resp.StatusCode)
+		c.runResponseHooks(resp)
 	}
 	if err != nil {
 		cancelOnError()
@@ -571,13 +875,19 @@ func (c *Client) Stream(ctx context.Context, req *StreamRequest) (*Stream, error
 		_, _ = io.Copy(io.Discard, resp.Body)
 		_ = resp.Body.Close() // Close explicitly instead of defer for clarity
 		cancelOnError()
-		return nil, newError(
+		streamErr := newError(
 			"STREAM_ERROR",
 			fmt.Sprintf("stream request failed: %s", string(body)),
 			resp.StatusCode,
 			nil,
 		)
+		c.setRetryAfter(streamErr, resp.Header.Get("Retry-After"))
+		if until, ok := c.observeMaintenance(startsRun, resp.StatusCode, resp.Header, body); ok {
+			return nil, newMaintenanceError(streamErr.Message, streamErr.Status, until, c.clock.Now(), streamErr)
+		}
+		return nil, streamErr
 	}
+	c.observeMaintenance(startsRun, resp.StatusCode, resp.Header, nil)
 
 	// Verify content type (case-insensitive per HTTP spec)
 	contentType := resp.Header.Get("Content-Type")
@@ -594,9 +904,165 @@ func (c *Client) Stream(ctx context.Context, req *StreamRequest) (*Stream, error
 		)
 	}
 
+	stream := &Stream{
+		resp:      resp,
+		reader:    bufio.NewReader(resp.Body),
+		cancel:    cancel,
+		sanitize:  c.outputSanitization,
+		sessionID: strings.TrimSpace(resp.Header.Get(sessionIDHeader)),
+		id:        strings.TrimSpace(resp.Header.Get(streamIDHeader)),
+		client:    c,
+	}
+	recordAttribute(ctx, AttributeSessionID, stream.sessionID)
+	c.traceEvents(ctx, stream)
+	return stream, nil
+}
+
+// StreamJob follows the output of an async job in real-time.
+//
+// This method connects to the job's SSE endpoint (GET /jobs/{id}/stream) and
+// returns a [Stream] that yields events as the job produces output. Use it
+// instead of polling [Client.GetJob] to tail long-running executions live.
+//
+// If the job has already finished, the server reports the stream as gone
+// (409 Conflict or 410 Gone). In that case the SDK fetches the final job
+// state with [Client.GetJob] and returns a [Stream] that replays the buffered
+// output as a single event, followed by an "error" event if the job failed,
+// and finally a "done" event. Consumers therefore see the same event shape
+// whether they attach before or after completion.
+//
+// Returns [ErrNotFound] if the job doesn't exist, or an [Error] with code
+// "UNSUPPORTED" if the server does not implement job streaming (see
+// [Client.Capabilities]).
+//
+// The timeout behavior is identical to [Client.Stream]: [WithTimeout] does
+// not apply, so use [WithStreamTimeout] or a context deadline.
+//
+// Example:
+//
+//	job, _ := client.RunAsync(ctx, req)
+//
+//	stream, err := client.StreamJob(ctx, job.JobID)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer stream.Close()
+//
+//	for stream.Next() {
+//	    event := stream.Event()
+//	    if event.Type == "done" {
+//	        break
+//	    }
+//	    fmt.Print(event.Data)
+//	}
+func (c *Client) StreamJob(ctx context.Context, jobID string) (_ *Stream, err error) {
+	ctx, op := c.observe(ctx, "StreamJob")
+	defer op.finish(&err)
+	op.setAttribute(AttributeJobID, jobID)
+
+	if err := c.checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if jobID == "" {
+		return nil, newError("BAD_REQUEST", "job ID is required", 400, nil)
+	}
+
+	stream, err := c.openStream(ctx, "/jobs/"+url.PathEscape(jobID)+"/stream", url.Values{})
+	if err == nil {
+		stream.onDone = func() { c.ReleaseJob(jobID) }
+		return stream, nil
+	}
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return nil, err
+	}
+	switch apiErr.Status {
+	case http.StatusNotFound:
+		if c.supports(ctx, capJobEvents) {
+			c.ReleaseJob(jobID)
+			return nil, ErrNotFound
+		}
+		// The 404 may come from a server without job streaming rather than
+		// an unknown job; look the job up to tell the two apart.
+		if _, jobErr := c.GetJob(ctx, jobID); jobErr != nil {
+			return nil, jobErr
+		}
+		return nil, newError("UNSUPPORTED", "server does not support job streaming", http.StatusNotImplemented, err)
+	case http.StatusConflict, http.StatusGone:
+		// Job already finished; replay its final state.
+		job, jobErr := c.GetJob(ctx, jobID)
+		if jobErr != nil {
+			return nil, jobErr
+		}
+		return newJobReplayStream(job), nil
+	default:
+		return nil, err
+	}
+}
+
+// newJobReplayStream builds a [Stream] that replays a finished job's result.
+//
+// The job output is encoded as SSE so the regular event parser is reused:
+// one data event with the output (if any), an "error" event if the job
+// failed, and a terminating "done" event.
+func newJobReplayStream(job *Job) *Stream {
+	var events []*StreamEvent
+	if job.Output != "" {
+		events = append(events, &StreamEvent{Data: job.Output})
+	}
+	if job.IsFailed() {
+		events = append(events, &StreamEvent{Type: EventTypeError, Data: job.Error})
+	}
+	events = append(events, &StreamEvent{Type: EventTypeDone})
+
+	return &Stream{
+		reader:    bufio.NewReader(strings.NewReader(encodeEvents(events))),
+		sessionID: job.SessionID,
+	}
+}
+
+// NewStreamFromEvents returns a [Stream] that delivers events in order, as
+// if received from the server, e.g. to fake [Client.Stream] in tests. Nil
+// events are skipped. A "session" event sets [Stream.SessionID]; the
+// stream doesn't end with a "done" event unless events contains one.
+//
+// Example:
+//
+//	stream := stromboli.NewStreamFromEvents([]*stromboli.StreamEvent{
+//	    {Data: "Hello"},
+//	    {Type: stromboli.EventTypeDone},
+//	})
+func NewStreamFromEvents(events []*StreamEvent) *Stream {
 	return &Stream{
-		resp:   resp,
-		reader: bufio.NewReader(resp.Body),
-		cancel: cancel,
-	}, nil
+		reader: bufio.NewReader(strings.NewReader(encodeEvents(events))),
+	}
+}
+
+// encodeEvents encodes events as SSE, skipping nil events.
+func encodeEvents(events []*StreamEvent) string {
+	var b strings.Builder
+	for _, ev := range events {
+		if ev == nil {
+			continue
+		}
+		if ev.Type != "" {
+			b.WriteString("event: ")
+			b.WriteString(ev.Type)
+			b.WriteByte('\n')
+		}
+		if ev.ID != "" {
+			b.WriteString("id: ")
+			b.WriteString(ev.ID)
+			b.WriteByte('\n')
+		}
+		for _, line := range strings.Split(ev.Data, "\n") {
+			b.WriteString("data: ")
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
 }
@@ -0,0 +1,118 @@
+package stromboli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// streamIDHeader is the response header carrying the ID of a stream, when
+// the server supports aborting it (see [Stream.Abort]).
+const streamIDHeader = "X-Stream-ID"
+
+// streamAbortDrainTimeout bounds how long [Stream.Abort] reads the final
+// events of an aborted stream.
+const streamAbortDrainTimeout = 5 * time.Second
+
+// ID returns the ID the server assigned to the stream, from the
+// X-Stream-ID response header, or "" if the server doesn't report one (it
+// then doesn't support [Stream.Abort]).
+func (s *Stream) ID() string {
+	return s.id
+}
+
+// Abort asks the server to stop the generation, reads the final events
+// it sends, and closes the stream.
+//
+// Unlike [Stream.Close], which only drops the connection and leaves the
+// server to notice, Abort lets the server stop the container promptly. It
+// sends DELETE /run/stream/{id} with the stream's [Stream.ID], then reads
+// the remaining events (available through [Stream.Event] and
+// [Stream.Replay]) for up to 5 seconds, or until ctx is done, before
+// closing. A stream the server no longer knows about (404) is only
+// drained and closed.
+//
+// Abort must not be called concurrently with [Stream.Next]. If the server
+// reported no stream ID, the stream is closed and an [Error] with code
+// "UNSUPPORTED" is returned; it is closed as well if the abort request
+// fails. Calling Abort on a closed stream does nothing.
+//
+// Example:
+//
+//	for stream.Next() {
+//	    if tooLong(stream.Event()) {
+//	        if err := stream.Abort(ctx); err != nil {
+//	            log.Printf("abort: %v", err)
+//	        }
+//	        break
+//	    }
+//	}
+func (s *Stream) Abort(ctx context.Context) (err error) {
+	if s.closed.Load() {
+		return nil
+	}
+	defer func() {
+		if closeErr := s.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	if s.id == "" || s.client == nil {
+		return newError("UNSUPPORTED", "server does not support aborting streams", http.StatusNotImplemented, nil)
+	}
+
+	ctx, op := s.client.observe(ctx, "Stream.Abort")
+	defer op.finish(&err)
+
+	err = s.client.doJSON(ctx, http.MethodDelete, "/run/stream/"+url.PathEscape(s.id), nil, nil, nil)
+	var apiErr *Error
+	switch {
+	case errors.Is(err, ErrNotFound):
+		// The stream already ended on the server side
+	case errors.As(err, &apiErr) && (apiErr.Status == http.StatusMethodNotAllowed || apiErr.Status == http.StatusNotImplemented):
+		return newError("UNSUPPORTED", "server does not support aborting streams", http.StatusNotImplemented, err)
+	case err != nil:
+		return err
+	}
+
+	s.drain(ctx)
+	return nil
+}
+
+// drain reads the remaining events of the stream until it ends, ctx is
+// done or streamAbortDrainTimeout elapses. The stream is closed to
+// interrupt a blocked read; such an interruption isn't reported by Err.
+func (s *Stream) drain(ctx context.Context) {
+	var interrupted atomic.Bool
+	timer := s.client.clock.NewTimer(streamAbortDrainTimeout)
+	stop := make(chan struct{})
+	defer func() {
+		timer.Stop()
+		close(stop)
+	}()
+	go func() {
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+		case <-stop:
+			return
+		}
+		interrupted.Store(true)
+		_ = s.Close()
+	}()
+
+	for !s.closed.Load() && s.getErr() == nil {
+		event, err := s.readEvent()
+		if err != nil {
+			if err != io.EOF && !interrupted.Load() {
+				s.setErr(err)
+			}
+			return
+		}
+		s.recordReplay(event)
+		s.setCurrent(event)
+	}
+}
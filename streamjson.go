@@ -0,0 +1,470 @@
+package stromboli
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Decode decodes the event's data as JSON into v, which must be a pointer.
+//
+// It returns an INVALID_RESPONSE [Error] if the data is not valid JSON or
+// doesn't match v. To get one of the typed stream-json messages, use
+// [ParseStreamMessage] instead.
+//
+// Example:
+//
+//	var payload struct {
+//	    Type string `json:"type"`
+//	}
+//	if err := stream.Event().Decode(&payload); err != nil {
+//	    log.Printf("not JSON: %v", err)
+//	}
+func (e *StreamEvent) Decode(v any) error {
+	if err := json.Unmarshal([]byte(e.Data), v); err != nil {
+		return newError("INVALID_RESPONSE", "failed to decode event data", 0, err)
+	}
+	return nil
+}
+
+// Types of the stream-json messages returned by [ParseStreamMessage].
+const (
+	// StreamMessageSystem identifies a [SystemEvent].
+	StreamMessageSystem = "system"
+
+	// StreamMessageAssistant identifies an [AssistantDelta] built from a
+	// complete assistant message.
+	StreamMessageAssistant = "assistant"
+
+	// StreamMessageToolUse identifies a [ToolUseEvent].
+	StreamMessageToolUse = "tool_use"
+
+	// StreamMessageResult identifies a [ResultEvent].
+	StreamMessageResult = "result"
+
+	// StreamMessagePartial is the type of the partial messages sent with
+	// Claude's IncludePartialMessages option. Text deltas and tool calls are
+	// returned as [AssistantDelta] and [ToolUseEvent]; other partial
+	// messages as [RawStreamMessage].
+	StreamMessagePartial = "stream_event"
+)
+
+// StreamMessage is a typed stream-json message returned by
+// [ParseStreamMessage]: a [*SystemEvent], [*AssistantDelta],
+// [*ToolUseEvent], [*ResultEvent] or, for any other message,
+// [*RawStreamMessage].
+//
+// Use a type switch to handle each kind of message:
+//
+//	msg, err := stromboli.ParseStreamMessage(event.Data)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	switch m := msg.(type) {
+//	case *stromboli.AssistantDelta:
+//	    fmt.Print(m.Text)
+//	case *stromboli.ToolUseEvent:
+//	    fmt.Printf("\n[%s]\n", m.Name)
+//	case *stromboli.ResultEvent:
+//	    fmt.Printf("\nsession %s, $%.4f\n", m.SessionID, m.CostUSD)
+//	}
+type StreamMessage interface {
+	// MessageType returns the message's "type" field, e.g. "assistant".
+	MessageType() string
+}
+
+// SystemEvent is a system message, such as the "init" message sent when
+// Claude starts.
+type SystemEvent struct {
+	// Subtype is the kind of system message.
+	// Example: "init"
+	Subtype string `json:"subtype"`
+
+	// SessionID is the session the stream runs in.
+	SessionID string `json:"session_id"`
+
+	// Model is the model in use (init messages only).
+	// Example: "claude-sonnet-4-20250514"
+	Model string `json:"model,omitempty"`
+
+	// CWD is the working directory (init messages only).
+	CWD string `json:"cwd,omitempty"`
+
+	// Tools lists the available tools (init messages only).
+	Tools []string `json:"tools,omitempty"`
+
+	// Raw is the message as received.
+	Raw json.RawMessage `json:"-"`
+}
+
+// MessageType implements [StreamMessage].
+func (m *SystemEvent) MessageType() string { return StreamMessageSystem }
+
+// AssistantDelta is text produced by the assistant: either a complete
+// assistant message, or a partial text delta (Partial is true).
+type AssistantDelta struct {
+	// MessageID is the ID of the assistant message (empty for deltas).
+	MessageID string
+
+	// SessionID is the session the stream runs in.
+	SessionID string
+
+	// Text is the text of the message or delta. Text blocks of a complete
+	// message are concatenated.
+	Text string
+
+	// ToolUses are the tool calls of a complete message.
+	ToolUses []*ToolUseBlock
+
+	// Partial indicates a text delta rather than a complete message.
+	Partial bool
+
+	// Raw is the message as received.
+	Raw json.RawMessage
+}
+
+// MessageType implements [StreamMessage]. It returns StreamMessagePartial
+// for deltas.
+func (m *AssistantDelta) MessageType() string {
+	if m.Partial {
+		return StreamMessagePartial
+	}
+	return StreamMessageAssistant
+}
+
+// ToolUseEvent is a tool call by the assistant, sent as a message of its
+// own or as the start of a partial tool_use block.
+type ToolUseEvent struct {
+	// ID is the tool use identifier.
+	// Example: "toolu_01A09q90qw90lq917835lq9"
+	ID string `json:"id"`
+
+	// Name is the name of the tool.
+	// Example: "Bash"
+	Name string `json:"name"`
+
+	// Input contains the tool arguments (empty at the start of a partial
+	// block; the arguments follow as deltas).
+	Input map[string]interface{} `json:"input,omitempty"`
+
+	// SessionID is the session the stream runs in.
+	SessionID string `json:"session_id,omitempty"`
+
+	// Partial indicates the start of a partial tool_use block rather than
+	// a complete tool call.
+	Partial bool `json:"-"`
+
+	// Raw is the message as received.
+	Raw json.RawMessage `json:"-"`
+}
+
+// MessageType implements [StreamMessage]. It returns StreamMessagePartial
+// for the start of a partial block.
+func (m *ToolUseEvent) MessageType() string {
+	if m.Partial {
+		return StreamMessagePartial
+	}
+	return StreamMessageToolUse
+}
+
+// ResultEvent is the final message of a run. For streams, it is the only
+// place the session ID, cost and usage are reported.
+type ResultEvent struct {
+	// Subtype is the outcome.
+	// Example: "success", "error_max_turns"
+	Subtype string `json:"subtype"`
+
+	// IsError indicates the run failed.
+	IsError bool `json:"is_error"`
+
+	// Result is the final output of the run.
+	Result string `json:"result"`
+
+	// SessionID is the session of the run, to resume it later.
+	SessionID string `json:"session_id"`
+
+	// CostUSD is the total cost of the run in US dollars.
+	CostUSD float64 `json:"total_cost_usd"`
+
+	// DurationMS is the duration of the run in milliseconds.
+	DurationMS int64 `json:"duration_ms"`
+
+	// DurationAPIMS is the time spent in API calls in milliseconds.
+	DurationAPIMS int64 `json:"duration_api_ms"`
+
+	// NumTurns is the number of agentic turns.
+	NumTurns int64 `json:"num_turns"`
+
+	// Usage is the token usage of the run, including CostUSD and NumTurns.
+	// Nil if the message has no usage.
+	Usage *Usage `json:"usage,omitempty"`
+
+	// Raw is the message as received.
+	Raw json.RawMessage `json:"-"`
+}
+
+// MessageType implements [StreamMessage].
+func (m *ResultEvent) MessageType() string { return StreamMessageResult }
+
+// RawStreamMessage is a message of a type the SDK doesn't decode (such as
+// user messages carrying tool results), preserved as raw JSON.
+type RawStreamMessage struct {
+	// Type is the message's "type" field (empty if it has none).
+	Type string
+
+	// Raw is the message as received.
+	Raw json.RawMessage
+}
+
+// MessageType implements [StreamMessage].
+func (m *RawStreamMessage) MessageType() string { return m.Type }
+
+// ParseStreamMessage parses the data of an event of a stream-json stream
+// (a run with Claude's OutputFormat set to "stream-json") into a typed
+// message.
+//
+// Messages are dispatched on their "type" field; see [StreamMessage] for
+// the returned types. Messages of other types, and partial messages other
+// than text deltas and tool calls, are returned as [*RawStreamMessage], so
+// new message types are never lost. An INVALID_RESPONSE [Error] is returned
+// if data is not a JSON object or a known message doesn't have the expected
+// shape.
+//
+// Example:
+//
+//	for stream.Next() {
+//	    msg, err := stromboli.ParseStreamMessage(stream.Event().Data)
+//	    if err != nil {
+//	        continue // not a stream-json message, e.g. the done event
+//	    }
+//	    if result, ok := msg.(*stromboli.ResultEvent); ok {
+//	        fmt.Printf("session %s cost $%.4f\n", result.SessionID, result.CostUSD)
+//	    }
+//	}
+func ParseStreamMessage(data string) (StreamMessage, error) {
+	raw := json.RawMessage(strings.TrimSpace(data))
+	var header struct {
+		Type string `json:"type"`
+	}
+	if !strings.HasPrefix(string(raw), "{") {
+		return nil, newError("INVALID_RESPONSE", "stream message is not a JSON object", 0, nil)
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, newError("INVALID_RESPONSE", "stream message is not a JSON object", 0, err)
+	}
+
+	switch header.Type {
+	case StreamMessageSystem:
+		msg := &SystemEvent{Raw: raw}
+		return msg, decodeStreamMessage(raw, msg)
+	case StreamMessageAssistant:
+		return parseAssistantMessage(raw)
+	case StreamMessageToolUse:
+		msg := &ToolUseEvent{Raw: raw}
+		return msg, decodeStreamMessage(raw, msg)
+	case StreamMessageResult:
+		msg := &ResultEvent{Raw: raw}
+		if err := decodeStreamMessage(raw, msg); err != nil {
+			return nil, err
+		}
+		if msg.Usage != nil {
+			msg.Usage.TotalCostUSD = msg.CostUSD
+			msg.Usage.NumTurns = msg.NumTurns
+		}
+		return msg, nil
+	case StreamMessagePartial:
+		return parsePartialMessage(raw)
+	default:
+		return &RawStreamMessage{Type: header.Type, Raw: raw}, nil
+	}
+}
+
+// decodeStreamMessage decodes a stream-json message into msg.
+func decodeStreamMessage(raw json.RawMessage, msg StreamMessage) error {
+	if err := json.Unmarshal(raw, msg); err != nil {
+		return newError("INVALID_RESPONSE", "failed to decode "+msg.MessageType()+" stream message", 0, err)
+	}
+	return nil
+}
+
+// parseAssistantMessage parses a complete assistant message. Content
+// blocks other than text and tool calls (e.g. thinking) are ignored; they
+// remain available in Raw.
+func parseAssistantMessage(raw json.RawMessage) (StreamMessage, error) {
+	var body struct {
+		SessionID string `json:"session_id"`
+		Message   struct {
+			ID      string            `json:"id"`
+			Content []json.RawMessage `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, newError("INVALID_RESPONSE", "failed to decode assistant stream message", 0, err)
+	}
+
+	msg := &AssistantDelta{MessageID: body.Message.ID, SessionID: body.SessionID, Raw: raw}
+	var text strings.Builder
+	for _, block := range body.Message.Content {
+		var b struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+			ToolUseBlock
+		}
+		if err := json.Unmarshal(block, &b); err != nil {
+			return nil, newError("INVALID_RESPONSE", "failed to decode assistant content block", 0, err)
+		}
+		switch b.Type {
+		case BlockTypeText:
+			text.WriteString(b.Text)
+		case BlockTypeToolUse:
+			toolUse := b.ToolUseBlock
+			msg.ToolUses = append(msg.ToolUses, &toolUse)
+		}
+	}
+	msg.Text = text.String()
+	return msg, nil
+}
+
+// parsePartialMessage parses a partial message: text deltas become an
+// AssistantDelta and the start of a tool_use block a ToolUseEvent.
+func parsePartialMessage(raw json.RawMessage) (StreamMessage, error) {
+	var body struct {
+		SessionID string `json:"session_id"`
+		Event     struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			ContentBlock struct {
+				Type string `json:"type"`
+				ToolUseBlock
+			} `json:"content_block"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, newError("INVALID_RESPONSE", "failed to decode partial stream message", 0, err)
+	}
+
+	switch {
+	case body.Event.Type == "content_block_delta" && body.Event.Delta.Type == "text_delta":
+		return &AssistantDelta{
+			SessionID: body.SessionID,
+			Text:      body.Event.Delta.Text,
+			Partial:   true,
+			Raw:       raw,
+		}, nil
+	case body.Event.Type == "content_block_start" && body.Event.ContentBlock.Type == BlockTypeToolUse:
+		block := body.Event.ContentBlock.ToolUseBlock
+		return &ToolUseEvent{
+			ID:        block.ID,
+			Name:      block.Name,
+			Input:     block.Input,
+			SessionID: body.SessionID,
+			Partial:   true,
+			Raw:       raw,
+		}, nil
+	default:
+		return &RawStreamMessage{Type: StreamMessagePartial, Raw: raw}, nil
+	}
+}
+
+// NextMessage advances to the next message of a stream-json stream (a run
+// with Claude's OutputFormat set to "stream-json") and returns it as a
+// [Message]. It returns false when the stream ends or fails; call
+// [Stream.Err] to tell a normal end (nil) from a failure.
+//
+// Events that aren't stream-json messages are skipped: keepalives (empty
+// data), non-JSON data, JSON without a "type" field and the "done" event.
+// An event whose data looks like a JSON object but can't be decoded ends
+// the stream with an INVALID_RESPONSE [Error], and an "error" event with a
+// STREAM_ERROR [Error] (see [StreamEvent.AsError]).
+//
+// The message's Content is the content of its "message" field (assistant
+// and user messages) or its "result" (result messages), and ToolResult
+// its "tool_use_result". Use [ParseStreamMessage] on [Stream.Event] for
+// typed messages instead. NextMessage and [Stream.Next] share the stream:
+// each event is returned by only one of them.
+//
+// Example:
+//
+//	for {
+//	    msg, ok := stream.NextMessage()
+//	    if !ok {
+//	        break
+//	    }
+//	    fmt.Println(msg.Type, msg.Content)
+//	}
+//	if err := stream.Err(); err != nil {
+//	    log.Fatal(err) // malformed stream or error event
+//	}
+func (s *Stream) NextMessage() (*Message, bool) {
+	for s.Next() {
+		event := s.Event()
+		if err := event.AsError(); err != nil {
+			s.fail(err)
+			return nil, false
+		}
+		if event.IsDone() {
+			continue
+		}
+		msg, err := messageFromStreamEvent(event)
+		if err != nil {
+			s.fail(err)
+			return nil, false
+		}
+		if msg != nil {
+			return msg, true
+		}
+	}
+	return nil, false
+}
+
+// fail ends the stream with err, reported by [Stream.Err].
+func (s *Stream) fail(err error) {
+	s.setErr(err)
+	s.end(err)
+}
+
+// messageFromStreamEvent maps the stream-json message carried by event to
+// a Message, like fromGeneratedMessage does for session history. It
+// returns nil if the event isn't a stream-json message.
+func messageFromStreamEvent(event *StreamEvent) (*Message, error) {
+	data := strings.TrimSpace(event.Data)
+	if !strings.HasPrefix(data, "{") {
+		return nil, nil // keepalive or plain text
+	}
+	var m struct {
+		Type           string `json:"type"`
+		UUID           string `json:"uuid"`
+		SessionID      string `json:"session_id"`
+		Cwd            string `json:"cwd"`
+		PermissionMode string `json:"permissionMode"`
+		Message        *struct {
+			Content interface{} `json:"content"`
+		} `json:"message"`
+		Result        *string     `json:"result"`
+		ToolUseResult interface{} `json:"tool_use_result"`
+	}
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		return nil, newError("INVALID_RESPONSE", "failed to decode stream message", 0, err)
+	}
+	if m.Type == "" {
+		return nil, nil
+	}
+
+	msg := &Message{
+		UUID:           m.UUID,
+		Type:           m.Type,
+		SessionID:      m.SessionID,
+		Cwd:            m.Cwd,
+		PermissionMode: m.PermissionMode,
+		ToolResult:     m.ToolUseResult,
+	}
+	switch {
+	case m.Message != nil:
+		msg.Content = m.Message.Content
+	case m.Result != nil:
+		msg.Content = *m.Result
+	}
+	return msg, nil
+}
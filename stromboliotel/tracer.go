@@ -0,0 +1,83 @@
+// Package stromboliotel traces Stromboli SDK operations with OpenTelemetry.
+//
+// It is a separate package so that the core SDK doesn't depend on
+// OpenTelemetry. Install it on a client with [WithTracerProvider]:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboliotel.WithTracerProvider(otel.GetTracerProvider()),
+//	)
+//
+// Each API operation gets a span named "stromboli.<Method>", e.g.
+// "stromboli.Run", recording the HTTP status of its last response and the
+// job or session ID it is about. Failed operations mark their span as an
+// error. Streams get a "stromboli.Stream.events" child span covering the
+// event loop.
+package stromboliotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// instrumentationName is the name of the tracer obtained from providers.
+const instrumentationName = "github.com/tomblancdev/stromboli-go"
+
+// spanPrefix is prepended to operation names to name spans.
+const spanPrefix = "stromboli."
+
+// statusCodeKey is the attribute recording the HTTP status of the last
+// response of an operation, following the OpenTelemetry HTTP conventions.
+const statusCodeKey = attribute.Key("http.response.status_code")
+
+var _ stromboli.Tracer = (*Tracer)(nil)
+
+// Tracer is a [stromboli.Tracer] creating OpenTelemetry spans.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer returns a [Tracer] creating spans with a tracer of tp.
+func NewTracer(tp trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: tp.Tracer(instrumentationName)}
+}
+
+// WithTracerProvider returns a client option tracing the client's
+// operations with a tracer of tp (see [stromboli.WithTracer]).
+func WithTracerProvider(tp trace.TracerProvider) stromboli.Option {
+	return stromboli.WithTracer(NewTracer(tp))
+}
+
+// Start starts a client span named after operation, as a child of the span
+// in ctx.
+func (t *Tracer) Start(ctx context.Context, operation string) (context.Context, stromboli.TraceSpan) {
+	ctx, s := t.tracer.Start(ctx, spanPrefix+operation, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, &span{span: s}
+}
+
+// span adapts an OpenTelemetry span to [stromboli.TraceSpan].
+type span struct {
+	span trace.Span
+}
+
+// SetAttribute records a string attribute on the span.
+func (s *span) SetAttribute(key, value string) {
+	s.span.SetAttributes(attribute.String(key, value))
+}
+
+// End records the status and error of the operation and ends the span,
+// marking it as an error if err is not nil.
+func (s *span) End(status int, err error) {
+	if status != 0 {
+		s.span.SetAttributes(statusCodeKey.Int(status))
+	}
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,490 @@
+package strombolischema
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDepth bounds the nesting of schemas evaluated for one value, so that a
+// "$ref" cycle (such as {"$ref": "#"}) fails instead of recursing forever.
+const maxDepth = 512
+
+// evaluator evaluates JSON Schema draft-07 schemas against values decoded
+// by encoding/json. root is the schema document "$ref"s are resolved in.
+type evaluator struct {
+	root interface{}
+}
+
+// validate returns an error describing the first keyword of schema the
+// value v, found at the JSON pointer path, doesn't satisfy.
+func (e *evaluator) validate(schema, v interface{}, path string, depth int) error {
+	if depth > maxDepth {
+		return errorAt(path, "schemas nest too deeply (is there a $ref cycle?)")
+	}
+	var s map[string]interface{}
+	switch schema := schema.(type) {
+	case bool:
+		if !schema {
+			return errorAt(path, "no value is allowed here")
+		}
+		return nil
+	case map[string]interface{}:
+		s = schema
+	default:
+		return errorAt(path, "schema must be an object or a boolean")
+	}
+
+	// In draft-07, the keywords next to a "$ref" are ignored.
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := e.resolve(ref)
+		if err != nil {
+			return errorAt(path, "%v", err)
+		}
+		return e.validate(target, v, path, depth+1)
+	}
+
+	for _, check := range []func(map[string]interface{}, interface{}, string, int) error{
+		e.validateGeneric,
+		e.validateNumber,
+		e.validateString,
+		e.validateArray,
+		e.validateObject,
+		e.validateApplicators,
+	} {
+		if err := check(s, v, path, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// valid reports whether v is valid against schema.
+func (e *evaluator) valid(schema, v interface{}, path string, depth int) bool {
+	return e.validate(schema, v, path, depth) == nil
+}
+
+// validateGeneric checks "type", "enum", "const" and "format".
+func (e *evaluator) validateGeneric(s map[string]interface{}, v interface{}, path string, _ int) error {
+	switch types := s["type"].(type) {
+	case string:
+		if !hasType(v, types) {
+			return errorAt(path, "expected %s, got %s", types, typeOf(v))
+		}
+	case []interface{}:
+		matched := false
+		names := make([]string, 0, len(types))
+		for _, t := range types {
+			name, _ := t.(string)
+			names = append(names, name)
+			matched = matched || hasType(v, name)
+		}
+		if !matched {
+			return errorAt(path, "expected one of %s, got %s", strings.Join(names, ", "), typeOf(v))
+		}
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		matched := false
+		for _, allowed := range enum {
+			matched = matched || reflect.DeepEqual(v, allowed)
+		}
+		if !matched {
+			return errorAt(path, "value is not one of the enum values")
+		}
+	}
+	if allowed, ok := s["const"]; ok && !reflect.DeepEqual(v, allowed) {
+		return errorAt(path, "value is not the const value")
+	}
+
+	// Formats are annotations in draft-07; only "regex" is asserted, since
+	// it is what keeps the patterns of a schema compilable.
+	if format, _ := s["format"].(string); format == "regex" {
+		if str, ok := v.(string); ok {
+			if _, err := regexp.Compile(str); err != nil {
+				return errorAt(path, "%q is not a valid regular expression", str)
+			}
+		}
+	}
+	return nil
+}
+
+// validateNumber checks the numeric keywords.
+func (e *evaluator) validateNumber(s map[string]interface{}, v interface{}, path string, _ int) error {
+	n, ok := v.(float64)
+	if !ok {
+		return nil
+	}
+	if limit, ok := s["minimum"].(float64); ok && n < limit {
+		return errorAt(path, "%v is less than the minimum %v", n, limit)
+	}
+	if limit, ok := s["maximum"].(float64); ok && n > limit {
+		return errorAt(path, "%v is greater than the maximum %v", n, limit)
+	}
+	if limit, ok := s["exclusiveMinimum"].(float64); ok && n <= limit {
+		return errorAt(path, "%v is not greater than the exclusive minimum %v", n, limit)
+	}
+	if limit, ok := s["exclusiveMaximum"].(float64); ok && n >= limit {
+		return errorAt(path, "%v is not less than the exclusive maximum %v", n, limit)
+	}
+	if divisor, ok := s["multipleOf"].(float64); ok && divisor > 0 {
+		q := n / divisor
+		if math.IsInf(q, 0) || math.Abs(q-math.Round(q)) > 1e-9 {
+			return errorAt(path, "%v is not a multiple of %v", n, divisor)
+		}
+	}
+	return nil
+}
+
+// validateString checks the string keywords.
+func (e *evaluator) validateString(s map[string]interface{}, v interface{}, path string, _ int) error {
+	str, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	length := float64(utf8.RuneCountInString(str))
+	if limit, ok := s["minLength"].(float64); ok && length < limit {
+		return errorAt(path, "string is shorter than %v characters", limit)
+	}
+	if limit, ok := s["maxLength"].(float64); ok && length > limit {
+		return errorAt(path, "string is longer than %v characters", limit)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return errorAt(path, "pattern %q doesn't compile: %v", pattern, err)
+		}
+		if !re.MatchString(str) {
+			return errorAt(path, "string doesn't match the pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// validateArray checks the array keywords.
+func (e *evaluator) validateArray(s map[string]interface{}, v interface{}, path string, depth int) error {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	count := float64(len(items))
+	if limit, ok := s["minItems"].(float64); ok && count < limit {
+		return errorAt(path, "array has fewer than %v items", limit)
+	}
+	if limit, ok := s["maxItems"].(float64); ok && count > limit {
+		return errorAt(path, "array has more than %v items", limit)
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if reflect.DeepEqual(items[i], items[j]) {
+					return errorAt(path, "items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+
+	switch itemSchemas := s["items"].(type) {
+	case []interface{}:
+		for i, item := range items {
+			schema, ok := s["additionalItems"]
+			if i < len(itemSchemas) {
+				schema, ok = itemSchemas[i], true
+			}
+			if !ok {
+				break
+			}
+			if err := e.validate(schema, item, pointer(path, strconv.Itoa(i)), depth+1); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		for i, item := range items {
+			if err := e.validate(itemSchemas, item, pointer(path, strconv.Itoa(i)), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	if contains, ok := s["contains"]; ok {
+		matched := false
+		for i, item := range items {
+			if e.valid(contains, item, pointer(path, strconv.Itoa(i)), depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return errorAt(path, "no item matches the contains schema")
+		}
+	}
+	return nil
+}
+
+// validateObject checks the object keywords.
+func (e *evaluator) validateObject(s map[string]interface{}, v interface{}, path string, depth int) error {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	count := float64(len(object))
+	if limit, ok := s["minProperties"].(float64); ok && count < limit {
+		return errorAt(path, "object has fewer than %v properties", limit)
+	}
+	if limit, ok := s["maxProperties"].(float64); ok && count > limit {
+		return errorAt(path, "object has more than %v properties", limit)
+	}
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, present := object[name]; !present {
+					return errorAt(path, "missing required property %q", name)
+				}
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+	patterns, _ := s["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+	propertyNames, hasPropertyNames := s["propertyNames"]
+	dependencies, _ := s["dependencies"].(map[string]interface{})
+
+	// Walk the properties in order, so that the reported error is stable.
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := object[name]
+		at := pointer(path, name)
+
+		if hasPropertyNames {
+			if err := e.validate(propertyNames, name, at, depth+1); err != nil {
+				return err
+			}
+		}
+
+		matched := false
+		if schema, ok := properties[name]; ok {
+			matched = true
+			if err := e.validate(schema, value, at, depth+1); err != nil {
+				return err
+			}
+		}
+		for pattern, schema := range patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return errorAt(path, "pattern %q doesn't compile: %v", pattern, err)
+			}
+			if !re.MatchString(name) {
+				continue
+			}
+			matched = true
+			if err := e.validate(schema, value, at, depth+1); err != nil {
+				return err
+			}
+		}
+		if !matched && hasAdditional {
+			if err := e.validate(additional, value, at, depth+1); err != nil {
+				return err
+			}
+		}
+
+		switch dependency := dependencies[name].(type) {
+		case nil:
+		case []interface{}:
+			for _, other := range dependency {
+				if other, ok := other.(string); ok {
+					if _, present := object[other]; !present {
+						return errorAt(path, "property %q requires property %q", name, other)
+					}
+				}
+			}
+		default:
+			if err := e.validate(dependency, v, path, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateApplicators checks "allOf", "anyOf", "oneOf", "not" and
+// "if"/"then"/"else".
+func (e *evaluator) validateApplicators(s map[string]interface{}, v interface{}, path string, depth int) error {
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, schema := range all {
+			if err := e.validate(schema, v, path, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, schema := range anyOf {
+			if e.valid(schema, v, path, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return errorAt(path, "value doesn't match any schema of anyOf")
+		}
+	}
+	if one, ok := s["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, schema := range one {
+			if e.valid(schema, v, path, depth+1) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return errorAt(path, "value matches %d schemas of oneOf, not exactly one", matches)
+		}
+	}
+	if not, ok := s["not"]; ok && e.valid(not, v, path, depth+1) {
+		return errorAt(path, "value matches the not schema")
+	}
+	if cond, ok := s["if"]; ok {
+		branch, ok := s["else"]
+		if e.valid(cond, v, path, depth+1) {
+			branch, ok = s["then"]
+		}
+		if ok {
+			if err := e.validate(branch, v, path, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolve returns the schema the local reference ref points to: either a
+// JSON pointer ("#/definitions/name") or a plain name declared with an
+// "$id" ("#name").
+func (e *evaluator) resolve(ref string) (interface{}, error) {
+	fragment, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("$ref %q is not within the schema", ref)
+	}
+	fragment, err := url.PathUnescape(fragment)
+	if err != nil {
+		return nil, fmt.Errorf("$ref %q is not a valid reference: %w", ref, err)
+	}
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		if target := findAnchor(e.root, "#"+fragment); target != nil {
+			return target, nil
+		}
+		return nil, fmt.Errorf("$ref %q doesn't resolve", ref)
+	}
+
+	target := e.root
+	for _, token := range strings.Split(fragment, "/")[1:] {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch node := target.(type) {
+		case map[string]interface{}:
+			target, ok = node[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			ok = err == nil && i >= 0 && i < len(node)
+			if ok {
+				target = node[i]
+			}
+		default:
+			ok = false
+		}
+		if !ok {
+			return nil, fmt.Errorf("$ref %q doesn't resolve", ref)
+		}
+	}
+	return target, nil
+}
+
+// findAnchor returns the subschema of v whose "$id" is anchor, or nil.
+func findAnchor(v interface{}, anchor string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if id, _ := v["$id"].(string); id == anchor {
+			return v
+		}
+		for key, value := range v {
+			if isData(key) {
+				continue
+			}
+			if found := findAnchor(value, anchor); found != nil {
+				return found
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if found := findAnchor(item, anchor); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// isData reports whether the value of the keyword key is data rather than
+// schemas, so that it mustn't be searched for "$ref"s and "$id"s.
+func isData(key string) bool {
+	switch key {
+	case "const", "enum", "default", "examples":
+		return true
+	}
+	return false
+}
+
+// hasType reports whether v is of the JSON Schema type name.
+func hasType(v interface{}, name string) bool {
+	switch name {
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	}
+	return typeOf(v) == name
+}
+
+// typeOf returns the JSON Schema type of v.
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// pointer returns the JSON pointer of the child token of path.
+func pointer(path, token string) string {
+	return path + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// errorAt returns an error for the value at the JSON pointer path.
+func errorAt(path, format string, args ...interface{}) error {
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,79 @@
+// Package strombolischema fully validates the JSON schemas of structured
+// output requests (see [stromboli.ClaudeOptions.JSONSchema]).
+//
+// It is a separate package so that the core SDK doesn't depend on a JSON
+// Schema implementation. Install it on a client with
+// [stromboli.WithSchemaValidator]:
+//
+//	client, err := stromboli.NewClient(url,
+//	    stromboli.WithSchemaValidator(strombolischema.Validator()),
+//	)
+//
+// Schemas are checked against the JSON Schema draft-07 meta-schema, the
+// draft [stromboli.SchemaFor] declares: "const", "if"/"then"/"else" and a
+// numeric "exclusiveMinimum" are checked, while the boolean
+// "exclusiveMinimum" of draft 04 is rejected. Formats are annotations, as
+// in draft-07, except "regex", so that every "pattern" must compile (with
+// Go's RE2 syntax). Use another library with [stromboli.WithSchemaValidator]
+// for later drafts.
+package strombolischema
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Validator returns a schema validator for [stromboli.WithSchemaValidator].
+//
+// It checks that the schema is valid against the JSON Schema meta-schema,
+// and that it compiles: every "$ref" must point within the schema ("#..."),
+// and resolve. Other references are rejected rather than loaded, since the
+// schema is resolved by the server.
+func Validator() func(schema string) error {
+	var metaSchema interface{}
+	if err := json.Unmarshal([]byte(draft07MetaSchema), &metaSchema); err != nil {
+		panic(fmt.Sprintf("strombolischema: invalid meta-schema: %v", err))
+	}
+	meta := &evaluator{root: metaSchema}
+	return func(schema string) error {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(schema), &doc); err != nil {
+			return fmt.Errorf("not valid JSON: %w", err)
+		}
+		if err := meta.validate(metaSchema, doc, "", 0); err != nil {
+			return fmt.Errorf("not a valid JSON schema: %w", err)
+		}
+		if err := checkRefs(&evaluator{root: doc}, doc); err != nil {
+			return fmt.Errorf("schema doesn't compile: %w", err)
+		}
+		return nil
+	}
+}
+
+// checkRefs returns an error if a "$ref" in the decoded schema v doesn't
+// point within the schema, or doesn't resolve in it.
+func checkRefs(e *evaluator, v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isData(key) {
+				continue
+			}
+			if ref, ok := value.(string); ok && key == "$ref" {
+				if _, err := e.resolve(ref); err != nil {
+					return err
+				}
+			}
+			if err := checkRefs(e, value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := checkRefs(e, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Package strombolitest provides utilities for testing code that uses the
+// Stromboli SDK.
+package strombolitest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+var _ stromboli.Clock = (*FakeClock)(nil)
+
+// FakeClock is a [stromboli.Clock] whose time only moves when Advance is
+// called. Use it with [stromboli.WithClock] to test time-dependent SDK
+// behavior without real sleeps.
+//
+// Because SDK code runs in other goroutines, use BlockUntil to wait until
+// the code under test is waiting on a timer before advancing the clock:
+//
+//	clock := strombolitest.NewFakeClock(time.Now())
+//	client, _ := stromboli.NewClient(url, stromboli.WithClock(clock))
+//
+//	done := make(chan error)
+//	go func() { done <- client.DestroySessionAndConfirm(ctx, id, time.Second) }()
+//
+//	clock.BlockUntil(1)        // the helper is waiting to poll again
+//	clock.Advance(time.Second) // expire the confirmation window
+//	err := <-done
+//
+// FakeClock is safe for concurrent use.
+type FakeClock struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	timers  []*fakeTimer
+}
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the fake current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives the fake time once the clock has
+// been advanced by at least d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// NewTimer returns a timer that fires once the clock has been advanced by
+// at least d.
+func (c *FakeClock) NewTimer(d time.Duration) stromboli.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+	c.schedule(t, d)
+	return t
+}
+
+// Advance moves the clock forward by d and fires all timers that expire
+// in that time, in deadline order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+
+	sort.Slice(c.timers, func(i, j int) bool {
+		return c.timers[i].deadline.Before(c.timers[j].deadline)
+	})
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.active = false
+		select {
+		case t.ch <- c.now:
+		default: // previous tick was never received, like time.Timer
+		}
+	}
+	c.timers = pending
+	c.changed.Broadcast()
+}
+
+// BlockUntil blocks until at least n timers are waiting to fire.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.changed.Wait()
+	}
+}
+
+// schedule arms t to fire after d. The caller must hold c.mu.
+func (c *FakeClock) schedule(t *fakeTimer, d time.Duration) {
+	t.deadline = c.now.Add(d)
+	if d <= 0 {
+		t.active = false
+		select {
+		case t.ch <- c.now:
+		default:
+		}
+		return
+	}
+	t.active = true
+	c.timers = append(c.timers, t)
+	c.changed.Broadcast()
+}
+
+// remove disarms t. The caller must hold c.mu.
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	if !t.active {
+		return false
+	}
+	t.active = false
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			break
+		}
+	}
+	c.changed.Broadcast()
+	return true
+}
+
+// fakeTimer is a stromboli.Timer driven by a FakeClock.
+type fakeTimer struct {
+	clock    *FakeClock
+	ch       chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasActive := t.clock.remove(t)
+	t.clock.schedule(t, d)
+	return wasActive
+}
@@ -0,0 +1,819 @@
+package strombolitest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+var _ stromboli.ClientAPI = (*MockClient)(nil)
+
+// ErrNotMocked is returned by the methods of a [MockClient] whose function
+// field is not set. Match it with errors.Is.
+var ErrNotMocked = errors.New("strombolitest: method not mocked")
+
+// MockClient is a [stromboli.ClientAPI] whose methods call the function
+// fields of the same name, e.g. Run calls RunFunc. Set the fields the code
+// under test uses:
+//
+//	mock := &strombolitest.MockClient{
+//	    GetJobFunc: func(ctx context.Context, jobID string, opts ...stromboli.CallOption) (*stromboli.Job, error) {
+//	        return &stromboli.Job{ID: jobID, Status: stromboli.JobStatusCompleted}, nil
+//	    },
+//	}
+//	job, err := waitForJob(ctx, mock, "job-1")
+//	assert.Equal(t, []string{"GetJob"}, mock.Calls())
+//
+// A method whose field is nil returns zero values and an error matching
+// [ErrNotMocked]; methods that can't return an error just return zero
+// values. Use [stromboli.NewStreamFromEvents] to return a fake stream
+// from StreamFunc.
+//
+// Every call is recorded (see Calls). The fields must be set before the
+// mock is used; a MockClient is then safe for concurrent use.
+type MockClient struct {
+	// System
+	HealthFunc               func(ctx context.Context, opts ...stromboli.CallOption) (*stromboli.HealthResponse, error)
+	WaitUntilHealthyFunc     func(ctx context.Context, opts *stromboli.WaitHealthyOptions) (*stromboli.HealthResponse, error)
+	PingFunc                 func(ctx context.Context, opts ...stromboli.CallOption) error
+	ClaudeStatusFunc         func(ctx context.Context) (*stromboli.ClaudeStatus, error)
+	CapabilitiesFunc         func(ctx context.Context) (*stromboli.ServerCapabilities, error)
+	MaintenanceUntilFunc     func() (time.Time, bool)
+	CollectSupportBundleFunc func(ctx context.Context, w io.Writer, opts *stromboli.BundleOptions) error
+	CloseFunc                func() error
+
+	// Execution
+	RunFunc                func(ctx context.Context, req *stromboli.RunRequest, opts ...stromboli.CallOption) (*stromboli.RunResponse, error)
+	RunAsyncFunc           func(ctx context.Context, req *stromboli.RunRequest, opts ...stromboli.CallOption) (*stromboli.AsyncRunResponse, error)
+	RunBatchFunc           func(ctx context.Context, reqs []*stromboli.RunRequest, concurrency int) ([]*stromboli.RunResponse, []error)
+	RunWithBudgetFunc      func(ctx context.Context, req *stromboli.RunRequest, bt *stromboli.BudgetTracker) (*stromboli.RunResponse, error)
+	ContinueFunc           func(ctx context.Context, prompt, workdir string, opts *stromboli.ClaudeOptions) (*stromboli.RunResponse, error)
+	RunWithFallbacksFunc   func(ctx context.Context, req *stromboli.RunRequest, models []stromboli.Model, classify func(*stromboli.RunResponse, error) bool) (*stromboli.RunResponse, error)
+	TryRunFunc             func(ctx context.Context, req *stromboli.RunRequest) (*stromboli.RunResponse, error)
+	TrySubmitFunc          func(ctx context.Context, req *stromboli.RunRequest) (*stromboli.AsyncRunResponse, error)
+	InFlightJobsFunc       func() int
+	ReleaseJobFunc         func(jobID string)
+	WebhookHandlerFunc     func(fn func(context.Context, *stromboli.WebhookPayload) error) http.Handler
+	ResolveRequestFunc     func(ctx context.Context, req *stromboli.RunRequest) (*stromboli.ResolvedRequest, error)
+	ValidateRunRequestFunc func(req *stromboli.RunRequest) error
+	StreamFunc             func(ctx context.Context, req *stromboli.StreamRequest, opts ...stromboli.CallOption) (*stromboli.Stream, error)
+	StreamJobFunc          func(ctx context.Context, jobID string) (*stromboli.Stream, error)
+	StreamFanoutFunc       func(ctx context.Context, base *stromboli.StreamRequest, variants []stromboli.PodmanOptions, handler func(variantIndex int, ev *stromboli.StreamEvent), opts *stromboli.FanoutOptions) ([]*stromboli.StreamSummary, error)
+
+	// Jobs
+	ListJobsFunc         func(ctx context.Context, opts ...stromboli.CallOption) ([]*stromboli.Job, error)
+	ListJobsFilteredFunc func(ctx context.Context, opts *stromboli.ListJobsOptions) ([]*stromboli.Job, error)
+	GetJobFunc           func(ctx context.Context, jobID string, opts ...stromboli.CallOption) (*stromboli.Job, error)
+	GetJobIntoFunc       func(ctx context.Context, jobID string, job *stromboli.Job) error
+	GetJobLogsFunc       func(ctx context.Context, jobID string, opts *stromboli.LogsOptions) (io.ReadCloser, error)
+	GetJobLogsStringFunc func(ctx context.Context, jobID string, opts *stromboli.LogsOptions) (string, error)
+	CancelJobFunc        func(ctx context.Context, jobID string, opts ...stromboli.CallOption) error
+	CancelAllJobsFunc    func(ctx context.Context) (int, map[string]error)
+
+	// Sessions
+	ListSessionsFunc             func(ctx context.Context) ([]string, error)
+	ListSessionsDetailedFunc     func(ctx context.Context, opts *stromboli.ListSessionsOptions) ([]*stromboli.SessionInfo, error)
+	GetSessionFunc               func(ctx context.Context, sessionID string) (*stromboli.SessionInfo, error)
+	DestroySessionFunc           func(ctx context.Context, sessionID string) error
+	DestroySessionAndConfirmFunc func(ctx context.Context, sessionID string, within time.Duration) error
+	DestroyAllSessionsFunc       func(ctx context.Context, concurrency int) (int, map[string]error)
+	CleanupSessionsFunc          func(ctx context.Context, opts *stromboli.CleanupOptions) (*stromboli.CleanupResult, error)
+	GetMessagesFunc              func(ctx context.Context, sessionID string, opts *stromboli.GetMessagesOptions) (*stromboli.MessagesResponse, error)
+	GetMessagesByTypeFunc        func(ctx context.Context, sessionID string, msgType string, opts *stromboli.GetMessagesOptions) (*stromboli.MessagesResponse, error)
+	AllMessagesFunc              func(ctx context.Context, sessionID string, opts *stromboli.GetMessagesOptions) ([]*stromboli.Message, error)
+	GetMessageFunc               func(ctx context.Context, sessionID string, messageID string) (*stromboli.Message, error)
+
+	// Auth
+	SetTokenFunc      func(token string)
+	ClearTokenFunc    func()
+	GetTokenFunc      func(ctx context.Context, clientID string) (*stromboli.TokenResponse, error)
+	RefreshTokenFunc  func(ctx context.Context, refreshToken string) (*stromboli.TokenResponse, error)
+	ValidateTokenFunc func(ctx context.Context) (*stromboli.TokenValidation, error)
+	LogoutFunc        func(ctx context.Context) (*stromboli.LogoutResponse, error)
+
+	// Secrets
+	ListSecretsFunc            func(ctx context.Context) ([]*stromboli.Secret, error)
+	CreateSecretFunc           func(ctx context.Context, req *stromboli.CreateSecretRequest) error
+	CreateSecretFromReaderFunc func(ctx context.Context, name string, r io.Reader) error
+	CreateSecretFromFileFunc   func(ctx context.Context, name, path string) error
+	GetSecretFunc              func(ctx context.Context, name string) (*stromboli.Secret, error)
+	DeleteSecretFunc           func(ctx context.Context, name string) error
+	DeleteSecretAndConfirmFunc func(ctx context.Context, name string, within time.Duration) error
+	UpdateSecretFunc           func(ctx context.Context, req *stromboli.CreateSecretRequest) error
+	EnsureSecretFunc           func(ctx context.Context, req *stromboli.CreateSecretRequest) error
+	EnsureSecretsFunc          func(ctx context.Context, secrets []*stromboli.CreateSecretRequest) error
+	EnsureSecretsBeforeRunFunc func(ctx context.Context, secrets []*stromboli.CreateSecretRequest, req *stromboli.RunRequest) (*stromboli.RunResponse, error)
+	ResolveSecretsEnvFunc      func(ctx context.Context, env map[string]string) (map[string]string, error)
+
+	// Images
+	ListImagesFunc            func(ctx context.Context, opts ...stromboli.CallOption) ([]*stromboli.Image, error)
+	ListCompatibleImagesFunc  func(ctx context.Context) ([]*stromboli.Image, error)
+	BestImageFunc             func(ctx context.Context) (*stromboli.Image, error)
+	GetImageFunc              func(ctx context.Context, name string, opts ...stromboli.CallOption) (*stromboli.Image, error)
+	ImageChangedFunc          func(ctx context.Context, name string) (bool, error)
+	SearchImagesFunc          func(ctx context.Context, opts *stromboli.SearchImagesOptions) ([]*stromboli.ImageSearchResult, error)
+	SearchImagesPageFunc      func(ctx context.Context, opts *stromboli.SearchImagesOptions) (*stromboli.SearchResultsPage, error)
+	SearchImagesAllFunc       func(ctx context.Context, query string, maxResults int) ([]*stromboli.ImageSearchResult, error)
+	PullImageFunc             func(ctx context.Context, req *stromboli.PullImageRequest, opts ...stromboli.CallOption) (*stromboli.PullImageResponse, error)
+	EnsureImageFunc           func(ctx context.Context, ref string, opts *stromboli.EnsureImageOptions) (*stromboli.Image, error)
+	PullImageWithProgressFunc func(ctx context.Context, req *stromboli.PullImageRequest, progress func(*stromboli.PullProgressEvent), opts ...stromboli.CallOption) (*stromboli.PullImageResponse, error)
+	BuildImageFunc            func(ctx context.Context, req *stromboli.BuildImageRequest, opts ...stromboli.CallOption) (*stromboli.BuildImageResponse, error)
+	DeleteImageFunc           func(ctx context.Context, name string, opts *stromboli.DeleteImageOptions) error
+
+	mu    sync.Mutex
+	calls []string
+}
+
+// Calls returns the names of the methods called so far, in order.
+func (m *MockClient) Calls() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.calls...)
+}
+
+// record records a call of the method name.
+func (m *MockClient) record(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, name)
+}
+
+// notMocked returns the error of a call of the method name without a
+// function.
+func notMocked(name string) error {
+	return fmt.Errorf("%w: MockClient.%sFunc is nil", ErrNotMocked, name)
+}
+
+// Health calls HealthFunc.
+func (m *MockClient) Health(ctx context.Context, opts ...stromboli.CallOption) (*stromboli.HealthResponse, error) {
+	m.record("Health")
+	if m.HealthFunc != nil {
+		return m.HealthFunc(ctx, opts...)
+	}
+	return nil, notMocked("Health")
+}
+
+// WaitUntilHealthy calls WaitUntilHealthyFunc.
+func (m *MockClient) WaitUntilHealthy(ctx context.Context, opts *stromboli.WaitHealthyOptions) (*stromboli.HealthResponse, error) {
+	m.record("WaitUntilHealthy")
+	if m.WaitUntilHealthyFunc != nil {
+		return m.WaitUntilHealthyFunc(ctx, opts)
+	}
+	return nil, notMocked("WaitUntilHealthy")
+}
+
+// Ping calls PingFunc.
+func (m *MockClient) Ping(ctx context.Context, opts ...stromboli.CallOption) error {
+	m.record("Ping")
+	if m.PingFunc != nil {
+		return m.PingFunc(ctx, opts...)
+	}
+	return notMocked("Ping")
+}
+
+// ClaudeStatus calls ClaudeStatusFunc.
+func (m *MockClient) ClaudeStatus(ctx context.Context) (*stromboli.ClaudeStatus, error) {
+	m.record("ClaudeStatus")
+	if m.ClaudeStatusFunc != nil {
+		return m.ClaudeStatusFunc(ctx)
+	}
+	return nil, notMocked("ClaudeStatus")
+}
+
+// Capabilities calls CapabilitiesFunc.
+func (m *MockClient) Capabilities(ctx context.Context) (*stromboli.ServerCapabilities, error) {
+	m.record("Capabilities")
+	if m.CapabilitiesFunc != nil {
+		return m.CapabilitiesFunc(ctx)
+	}
+	return nil, notMocked("Capabilities")
+}
+
+// MaintenanceUntil calls MaintenanceUntilFunc.
+func (m *MockClient) MaintenanceUntil() (time.Time, bool) {
+	m.record("MaintenanceUntil")
+	if m.MaintenanceUntilFunc != nil {
+		return m.MaintenanceUntilFunc()
+	}
+	return time.Time{}, false
+}
+
+// CollectSupportBundle calls CollectSupportBundleFunc.
+func (m *MockClient) CollectSupportBundle(ctx context.Context, w io.Writer, opts *stromboli.BundleOptions) error {
+	m.record("CollectSupportBundle")
+	if m.CollectSupportBundleFunc != nil {
+		return m.CollectSupportBundleFunc(ctx, w, opts)
+	}
+	return notMocked("CollectSupportBundle")
+}
+
+// Close calls CloseFunc.
+func (m *MockClient) Close() error {
+	m.record("Close")
+	if m.CloseFunc != nil {
+		return m.CloseFunc()
+	}
+	return notMocked("Close")
+}
+
+// Run calls RunFunc.
+func (m *MockClient) Run(ctx context.Context, req *stromboli.RunRequest, opts ...stromboli.CallOption) (*stromboli.RunResponse, error) {
+	m.record("Run")
+	if m.RunFunc != nil {
+		return m.RunFunc(ctx, req, opts...)
+	}
+	return nil, notMocked("Run")
+}
+
+// RunAsync calls RunAsyncFunc.
+func (m *MockClient) RunAsync(ctx context.Context, req *stromboli.RunRequest, opts ...stromboli.CallOption) (*stromboli.AsyncRunResponse, error) {
+	m.record("RunAsync")
+	if m.RunAsyncFunc != nil {
+		return m.RunAsyncFunc(ctx, req, opts...)
+	}
+	return nil, notMocked("RunAsync")
+}
+
+// RunBatch calls RunBatchFunc.
+func (m *MockClient) RunBatch(ctx context.Context, reqs []*stromboli.RunRequest, concurrency int) ([]*stromboli.RunResponse, []error) {
+	m.record("RunBatch")
+	if m.RunBatchFunc != nil {
+		return m.RunBatchFunc(ctx, reqs, concurrency)
+	}
+	errs := make([]error, len(reqs))
+	for i := range errs {
+		errs[i] = notMocked("RunBatch")
+	}
+	return make([]*stromboli.RunResponse, len(reqs)), errs
+}
+
+// RunWithBudget calls RunWithBudgetFunc.
+func (m *MockClient) RunWithBudget(ctx context.Context, req *stromboli.RunRequest, bt *stromboli.BudgetTracker) (*stromboli.RunResponse, error) {
+	m.record("RunWithBudget")
+	if m.RunWithBudgetFunc != nil {
+		return m.RunWithBudgetFunc(ctx, req, bt)
+	}
+	return nil, notMocked("RunWithBudget")
+}
+
+// Continue calls ContinueFunc.
+func (m *MockClient) Continue(ctx context.Context, prompt, workdir string, opts *stromboli.ClaudeOptions) (*stromboli.RunResponse, error) {
+	m.record("Continue")
+	if m.ContinueFunc != nil {
+		return m.ContinueFunc(ctx, prompt, workdir, opts)
+	}
+	return nil, notMocked("Continue")
+}
+
+// RunWithFallbacks calls RunWithFallbacksFunc.
+func (m *MockClient) RunWithFallbacks(ctx context.Context, req *stromboli.RunRequest, models []stromboli.Model, classify func(*stromboli.RunResponse, error) bool) (*stromboli.RunResponse, error) {
+	m.record("RunWithFallbacks")
+	if m.RunWithFallbacksFunc != nil {
+		return m.RunWithFallbacksFunc(ctx, req, models, classify)
+	}
+	return nil, notMocked("RunWithFallbacks")
+}
+
+// TryRun calls TryRunFunc.
+func (m *MockClient) TryRun(ctx context.Context, req *stromboli.RunRequest) (*stromboli.RunResponse, error) {
+	m.record("TryRun")
+	if m.TryRunFunc != nil {
+		return m.TryRunFunc(ctx, req)
+	}
+	return nil, notMocked("TryRun")
+}
+
+// TrySubmit calls TrySubmitFunc.
+func (m *MockClient) TrySubmit(ctx context.Context, req *stromboli.RunRequest) (*stromboli.AsyncRunResponse, error) {
+	m.record("TrySubmit")
+	if m.TrySubmitFunc != nil {
+		return m.TrySubmitFunc(ctx, req)
+	}
+	return nil, notMocked("TrySubmit")
+}
+
+// InFlightJobs calls InFlightJobsFunc.
+func (m *MockClient) InFlightJobs() int {
+	m.record("InFlightJobs")
+	if m.InFlightJobsFunc != nil {
+		return m.InFlightJobsFunc()
+	}
+	return 0
+}
+
+// ReleaseJob calls ReleaseJobFunc.
+func (m *MockClient) ReleaseJob(jobID string) {
+	m.record("ReleaseJob")
+	if m.ReleaseJobFunc != nil {
+		m.ReleaseJobFunc(jobID)
+	}
+}
+
+// WebhookHandler calls WebhookHandlerFunc, or returns
+// [stromboli.WebhookHandler] for fn if it is not set.
+func (m *MockClient) WebhookHandler(fn func(context.Context, *stromboli.WebhookPayload) error) http.Handler {
+	m.record("WebhookHandler")
+	if m.WebhookHandlerFunc != nil {
+		return m.WebhookHandlerFunc(fn)
+	}
+	return stromboli.WebhookHandler(fn)
+}
+
+// ResolveRequest calls ResolveRequestFunc.
+func (m *MockClient) ResolveRequest(ctx context.Context, req *stromboli.RunRequest) (*stromboli.ResolvedRequest, error) {
+	m.record("ResolveRequest")
+	if m.ResolveRequestFunc != nil {
+		return m.ResolveRequestFunc(ctx, req)
+	}
+	return nil, notMocked("ResolveRequest")
+}
+
+// ValidateRunRequest calls ValidateRunRequestFunc.
+func (m *MockClient) ValidateRunRequest(req *stromboli.RunRequest) error {
+	m.record("ValidateRunRequest")
+	if m.ValidateRunRequestFunc != nil {
+		return m.ValidateRunRequestFunc(req)
+	}
+	return notMocked("ValidateRunRequest")
+}
+
+// Stream calls StreamFunc.
+func (m *MockClient) Stream(ctx context.Context, req *stromboli.StreamRequest, opts ...stromboli.CallOption) (*stromboli.Stream, error) {
+	m.record("Stream")
+	if m.StreamFunc != nil {
+		return m.StreamFunc(ctx, req, opts...)
+	}
+	return nil, notMocked("Stream")
+}
+
+// StreamJob calls StreamJobFunc.
+func (m *MockClient) StreamJob(ctx context.Context, jobID string) (*stromboli.Stream, error) {
+	m.record("StreamJob")
+	if m.StreamJobFunc != nil {
+		return m.StreamJobFunc(ctx, jobID)
+	}
+	return nil, notMocked("StreamJob")
+}
+
+// StreamFanout calls StreamFanoutFunc.
+func (m *MockClient) StreamFanout(ctx context.Context, base *stromboli.StreamRequest, variants []stromboli.PodmanOptions, handler func(variantIndex int, ev *stromboli.StreamEvent), opts *stromboli.FanoutOptions) ([]*stromboli.StreamSummary, error) {
+	m.record("StreamFanout")
+	if m.StreamFanoutFunc != nil {
+		return m.StreamFanoutFunc(ctx, base, variants, handler, opts)
+	}
+	return nil, notMocked("StreamFanout")
+}
+
+// ListJobs calls ListJobsFunc.
+func (m *MockClient) ListJobs(ctx context.Context, opts ...stromboli.CallOption) ([]*stromboli.Job, error) {
+	m.record("ListJobs")
+	if m.ListJobsFunc != nil {
+		return m.ListJobsFunc(ctx, opts...)
+	}
+	return nil, notMocked("ListJobs")
+}
+
+// ListJobsFiltered calls ListJobsFilteredFunc.
+func (m *MockClient) ListJobsFiltered(ctx context.Context, opts *stromboli.ListJobsOptions) ([]*stromboli.Job, error) {
+	m.record("ListJobsFiltered")
+	if m.ListJobsFilteredFunc != nil {
+		return m.ListJobsFilteredFunc(ctx, opts)
+	}
+	return nil, notMocked("ListJobsFiltered")
+}
+
+// GetJob calls GetJobFunc.
+func (m *MockClient) GetJob(ctx context.Context, jobID string, opts ...stromboli.CallOption) (*stromboli.Job, error) {
+	m.record("GetJob")
+	if m.GetJobFunc != nil {
+		return m.GetJobFunc(ctx, jobID, opts...)
+	}
+	return nil, notMocked("GetJob")
+}
+
+// GetJobInto calls GetJobIntoFunc.
+func (m *MockClient) GetJobInto(ctx context.Context, jobID string, job *stromboli.Job) error {
+	m.record("GetJobInto")
+	if m.GetJobIntoFunc != nil {
+		return m.GetJobIntoFunc(ctx, jobID, job)
+	}
+	return notMocked("GetJobInto")
+}
+
+// GetJobLogs calls GetJobLogsFunc.
+func (m *MockClient) GetJobLogs(ctx context.Context, jobID string, opts *stromboli.LogsOptions) (io.ReadCloser, error) {
+	m.record("GetJobLogs")
+	if m.GetJobLogsFunc != nil {
+		return m.GetJobLogsFunc(ctx, jobID, opts)
+	}
+	return nil, notMocked("GetJobLogs")
+}
+
+// GetJobLogsString calls GetJobLogsStringFunc.
+func (m *MockClient) GetJobLogsString(ctx context.Context, jobID string, opts *stromboli.LogsOptions) (string, error) {
+	m.record("GetJobLogsString")
+	if m.GetJobLogsStringFunc != nil {
+		return m.GetJobLogsStringFunc(ctx, jobID, opts)
+	}
+	return "", notMocked("GetJobLogsString")
+}
+
+// CancelJob calls CancelJobFunc.
+func (m *MockClient) CancelJob(ctx context.Context, jobID string, opts ...stromboli.CallOption) error {
+	m.record("CancelJob")
+	if m.CancelJobFunc != nil {
+		return m.CancelJobFunc(ctx, jobID, opts...)
+	}
+	return notMocked("CancelJob")
+}
+
+// CancelAllJobs calls CancelAllJobsFunc.
+func (m *MockClient) CancelAllJobs(ctx context.Context) (int, map[string]error) {
+	m.record("CancelAllJobs")
+	if m.CancelAllJobsFunc != nil {
+		return m.CancelAllJobsFunc(ctx)
+	}
+	return 0, nil
+}
+
+// ListSessions calls ListSessionsFunc.
+func (m *MockClient) ListSessions(ctx context.Context) ([]string, error) {
+	m.record("ListSessions")
+	if m.ListSessionsFunc != nil {
+		return m.ListSessionsFunc(ctx)
+	}
+	return nil, notMocked("ListSessions")
+}
+
+// ListSessionsDetailed calls ListSessionsDetailedFunc.
+func (m *MockClient) ListSessionsDetailed(ctx context.Context, opts *stromboli.ListSessionsOptions) ([]*stromboli.SessionInfo, error) {
+	m.record("ListSessionsDetailed")
+	if m.ListSessionsDetailedFunc != nil {
+		return m.ListSessionsDetailedFunc(ctx, opts)
+	}
+	return nil, notMocked("ListSessionsDetailed")
+}
+
+// GetSession calls GetSessionFunc.
+func (m *MockClient) GetSession(ctx context.Context, sessionID string) (*stromboli.SessionInfo, error) {
+	m.record("GetSession")
+	if m.GetSessionFunc != nil {
+		return m.GetSessionFunc(ctx, sessionID)
+	}
+	return nil, notMocked("GetSession")
+}
+
+// DestroySession calls DestroySessionFunc.
+func (m *MockClient) DestroySession(ctx context.Context, sessionID string) error {
+	m.record("DestroySession")
+	if m.DestroySessionFunc != nil {
+		return m.DestroySessionFunc(ctx, sessionID)
+	}
+	return notMocked("DestroySession")
+}
+
+// DestroySessionAndConfirm calls DestroySessionAndConfirmFunc.
+func (m *MockClient) DestroySessionAndConfirm(ctx context.Context, sessionID string, within time.Duration) error {
+	m.record("DestroySessionAndConfirm")
+	if m.DestroySessionAndConfirmFunc != nil {
+		return m.DestroySessionAndConfirmFunc(ctx, sessionID, within)
+	}
+	return notMocked("DestroySessionAndConfirm")
+}
+
+// DestroyAllSessions calls DestroyAllSessionsFunc.
+func (m *MockClient) DestroyAllSessions(ctx context.Context, concurrency int) (int, map[string]error) {
+	m.record("DestroyAllSessions")
+	if m.DestroyAllSessionsFunc != nil {
+		return m.DestroyAllSessionsFunc(ctx, concurrency)
+	}
+	return 0, nil
+}
+
+// CleanupSessions calls CleanupSessionsFunc.
+func (m *MockClient) CleanupSessions(ctx context.Context, opts *stromboli.CleanupOptions) (*stromboli.CleanupResult, error) {
+	m.record("CleanupSessions")
+	if m.CleanupSessionsFunc != nil {
+		return m.CleanupSessionsFunc(ctx, opts)
+	}
+	return nil, notMocked("CleanupSessions")
+}
+
+// GetMessages calls GetMessagesFunc.
+func (m *MockClient) GetMessages(ctx context.Context, sessionID string, opts *stromboli.GetMessagesOptions) (*stromboli.MessagesResponse, error) {
+	m.record("GetMessages")
+	if m.GetMessagesFunc != nil {
+		return m.GetMessagesFunc(ctx, sessionID, opts)
+	}
+	return nil, notMocked("GetMessages")
+}
+
+// GetMessagesByType calls GetMessagesByTypeFunc.
+func (m *MockClient) GetMessagesByType(ctx context.Context, sessionID string, msgType string, opts *stromboli.GetMessagesOptions) (*stromboli.MessagesResponse, error) {
+	m.record("GetMessagesByType")
+	if m.GetMessagesByTypeFunc != nil {
+		return m.GetMessagesByTypeFunc(ctx, sessionID, msgType, opts)
+	}
+	return nil, notMocked("GetMessagesByType")
+}
+
+// AllMessages calls AllMessagesFunc.
+func (m *MockClient) AllMessages(ctx context.Context, sessionID string, opts *stromboli.GetMessagesOptions) ([]*stromboli.Message, error) {
+	m.record("AllMessages")
+	if m.AllMessagesFunc != nil {
+		return m.AllMessagesFunc(ctx, sessionID, opts)
+	}
+	return nil, notMocked("AllMessages")
+}
+
+// GetMessage calls GetMessageFunc.
+func (m *MockClient) GetMessage(ctx context.Context, sessionID string, messageID string) (*stromboli.Message, error) {
+	m.record("GetMessage")
+	if m.GetMessageFunc != nil {
+		return m.GetMessageFunc(ctx, sessionID, messageID)
+	}
+	return nil, notMocked("GetMessage")
+}
+
+// SetToken calls SetTokenFunc.
+func (m *MockClient) SetToken(token string) {
+	m.record("SetToken")
+	if m.SetTokenFunc != nil {
+		m.SetTokenFunc(token)
+	}
+}
+
+// ClearToken calls ClearTokenFunc.
+func (m *MockClient) ClearToken() {
+	m.record("ClearToken")
+	if m.ClearTokenFunc != nil {
+		m.ClearTokenFunc()
+	}
+}
+
+// GetToken calls GetTokenFunc.
+func (m *MockClient) GetToken(ctx context.Context, clientID string) (*stromboli.TokenResponse, error) {
+	m.record("GetToken")
+	if m.GetTokenFunc != nil {
+		return m.GetTokenFunc(ctx, clientID)
+	}
+	return nil, notMocked("GetToken")
+}
+
+// RefreshToken calls RefreshTokenFunc.
+func (m *MockClient) RefreshToken(ctx context.Context, refreshToken string) (*stromboli.TokenResponse, error) {
+	m.record("RefreshToken")
+	if m.RefreshTokenFunc != nil {
+		return m.RefreshTokenFunc(ctx, refreshToken)
+	}
+	return nil, notMocked("RefreshToken")
+}
+
+// ValidateToken calls ValidateTokenFunc.
+func (m *MockClient) ValidateToken(ctx context.Context) (*stromboli.TokenValidation, error) {
+	m.record("ValidateToken")
+	if m.ValidateTokenFunc != nil {
+		return m.ValidateTokenFunc(ctx)
+	}
+	return nil, notMocked("ValidateToken")
+}
+
+// Logout calls LogoutFunc.
+func (m *MockClient) Logout(ctx context.Context) (*stromboli.LogoutResponse, error) {
+	m.record("Logout")
+	if m.LogoutFunc != nil {
+		return m.LogoutFunc(ctx)
+	}
+	return nil, notMocked("Logout")
+}
+
+// ListSecrets calls ListSecretsFunc.
+func (m *MockClient) ListSecrets(ctx context.Context) ([]*stromboli.Secret, error) {
+	m.record("ListSecrets")
+	if m.ListSecretsFunc != nil {
+		return m.ListSecretsFunc(ctx)
+	}
+	return nil, notMocked("ListSecrets")
+}
+
+// CreateSecret calls CreateSecretFunc.
+func (m *MockClient) CreateSecret(ctx context.Context, req *stromboli.CreateSecretRequest) error {
+	m.record("CreateSecret")
+	if m.CreateSecretFunc != nil {
+		return m.CreateSecretFunc(ctx, req)
+	}
+	return notMocked("CreateSecret")
+}
+
+// CreateSecretFromReader calls CreateSecretFromReaderFunc.
+func (m *MockClient) CreateSecretFromReader(ctx context.Context, name string, r io.Reader) error {
+	m.record("CreateSecretFromReader")
+	if m.CreateSecretFromReaderFunc != nil {
+		return m.CreateSecretFromReaderFunc(ctx, name, r)
+	}
+	return notMocked("CreateSecretFromReader")
+}
+
+// CreateSecretFromFile calls CreateSecretFromFileFunc.
+func (m *MockClient) CreateSecretFromFile(ctx context.Context, name, path string) error {
+	m.record("CreateSecretFromFile")
+	if m.CreateSecretFromFileFunc != nil {
+		return m.CreateSecretFromFileFunc(ctx, name, path)
+	}
+	return notMocked("CreateSecretFromFile")
+}
+
+// GetSecret calls GetSecretFunc.
+func (m *MockClient) GetSecret(ctx context.Context, name string) (*stromboli.Secret, error) {
+	m.record("GetSecret")
+	if m.GetSecretFunc != nil {
+		return m.GetSecretFunc(ctx, name)
+	}
+	return nil, notMocked("GetSecret")
+}
+
+// DeleteSecret calls DeleteSecretFunc.
+func (m *MockClient) DeleteSecret(ctx context.Context, name string) error {
+	m.record("DeleteSecret")
+	if m.DeleteSecretFunc != nil {
+		return m.DeleteSecretFunc(ctx, name)
+	}
+	return notMocked("DeleteSecret")
+}
+
+// DeleteSecretAndConfirm calls DeleteSecretAndConfirmFunc.
+func (m *MockClient) DeleteSecretAndConfirm(ctx context.Context, name string, within time.Duration) error {
+	m.record("DeleteSecretAndConfirm")
+	if m.DeleteSecretAndConfirmFunc != nil {
+		return m.DeleteSecretAndConfirmFunc(ctx, name, within)
+	}
+	return notMocked("DeleteSecretAndConfirm")
+}
+
+// UpdateSecret calls UpdateSecretFunc.
+func (m *MockClient) UpdateSecret(ctx context.Context, req *stromboli.CreateSecretRequest) error {
+	m.record("UpdateSecret")
+	if m.UpdateSecretFunc != nil {
+		return m.UpdateSecretFunc(ctx, req)
+	}
+	return notMocked("UpdateSecret")
+}
+
+// EnsureSecret calls EnsureSecretFunc.
+func (m *MockClient) EnsureSecret(ctx context.Context, req *stromboli.CreateSecretRequest) error {
+	m.record("EnsureSecret")
+	if m.EnsureSecretFunc != nil {
+		return m.EnsureSecretFunc(ctx, req)
+	}
+	return notMocked("EnsureSecret")
+}
+
+// EnsureSecrets calls EnsureSecretsFunc.
+func (m *MockClient) EnsureSecrets(ctx context.Context, secrets []*stromboli.CreateSecretRequest) error {
+	m.record("EnsureSecrets")
+	if m.EnsureSecretsFunc != nil {
+		return m.EnsureSecretsFunc(ctx, secrets)
+	}
+	return notMocked("EnsureSecrets")
+}
+
+// EnsureSecretsBeforeRun calls EnsureSecretsBeforeRunFunc.
+func (m *MockClient) EnsureSecretsBeforeRun(ctx context.Context, secrets []*stromboli.CreateSecretRequest, req *stromboli.RunRequest) (*stromboli.RunResponse, error) {
+	m.record("EnsureSecretsBeforeRun")
+	if m.EnsureSecretsBeforeRunFunc != nil {
+		return m.EnsureSecretsBeforeRunFunc(ctx, secrets, req)
+	}
+	return nil, notMocked("EnsureSecretsBeforeRun")
+}
+
+// ResolveSecretsEnv calls ResolveSecretsEnvFunc.
+func (m *MockClient) ResolveSecretsEnv(ctx context.Context, env map[string]string) (map[string]string, error) {
+	m.record("ResolveSecretsEnv")
+	if m.ResolveSecretsEnvFunc != nil {
+		return m.ResolveSecretsEnvFunc(ctx, env)
+	}
+	return nil, notMocked("ResolveSecretsEnv")
+}
+
+// ListImages calls ListImagesFunc.
+func (m *MockClient) ListImages(ctx context.Context, opts ...stromboli.CallOption) ([]*stromboli.Image, error) {
+	m.record("ListImages")
+	if m.ListImagesFunc != nil {
+		return m.ListImagesFunc(ctx, opts...)
+	}
+	return nil, notMocked("ListImages")
+}
+
+// ListCompatibleImages calls ListCompatibleImagesFunc.
+func (m *MockClient) ListCompatibleImages(ctx context.Context) ([]*stromboli.Image, error) {
+	m.record("ListCompatibleImages")
+	if m.ListCompatibleImagesFunc != nil {
+		return m.ListCompatibleImagesFunc(ctx)
+	}
+	return nil, notMocked("ListCompatibleImages")
+}
+
+// BestImage calls BestImageFunc.
+func (m *MockClient) BestImage(ctx context.Context) (*stromboli.Image, error) {
+	m.record("BestImage")
+	if m.BestImageFunc != nil {
+		return m.BestImageFunc(ctx)
+	}
+	return nil, notMocked("BestImage")
+}
+
+// GetImage calls GetImageFunc.
+func (m *MockClient) GetImage(ctx context.Context, name string, opts ...stromboli.CallOption) (*stromboli.Image, error) {
+	m.record("GetImage")
+	if m.GetImageFunc != nil {
+		return m.GetImageFunc(ctx, name, opts...)
+	}
+	return nil, notMocked("GetImage")
+}
+
+// ImageChanged calls ImageChangedFunc.
+func (m *MockClient) ImageChanged(ctx context.Context, name string) (bool, error) {
+	m.record("ImageChanged")
+	if m.ImageChangedFunc != nil {
+		return m.ImageChangedFunc(ctx, name)
+	}
+	return false, notMocked("ImageChanged")
+}
+
+// SearchImages calls SearchImagesFunc.
+func (m *MockClient) SearchImages(ctx context.Context, opts *stromboli.SearchImagesOptions) ([]*stromboli.ImageSearchResult, error) {
+	m.record("SearchImages")
+	if m.SearchImagesFunc != nil {
+		return m.SearchImagesFunc(ctx, opts)
+	}
+	return nil, notMocked("SearchImages")
+}
+
+// SearchImagesPage calls SearchImagesPageFunc.
+func (m *MockClient) SearchImagesPage(ctx context.Context, opts *stromboli.SearchImagesOptions) (*stromboli.SearchResultsPage, error) {
+	m.record("SearchImagesPage")
+	if m.SearchImagesPageFunc != nil {
+		return m.SearchImagesPageFunc(ctx, opts)
+	}
+	return nil, notMocked("SearchImagesPage")
+}
+
+// SearchImagesAll calls SearchImagesAllFunc.
+func (m *MockClient) SearchImagesAll(ctx context.Context, query string, maxResults int) ([]*stromboli.ImageSearchResult, error) {
+	m.record("SearchImagesAll")
+	if m.SearchImagesAllFunc != nil {
+		return m.SearchImagesAllFunc(ctx, query, maxResults)
+	}
+	return nil, notMocked("SearchImagesAll")
+}
+
+// PullImage calls PullImageFunc.
+func (m *MockClient) PullImage(ctx context.Context, req *stromboli.PullImageRequest, opts ...stromboli.CallOption) (*stromboli.PullImageResponse, error) {
+	m.record("PullImage")
+	if m.PullImageFunc != nil {
+		return m.PullImageFunc(ctx, req, opts...)
+	}
+	return nil, notMocked("PullImage")
+}
+
+// EnsureImage calls EnsureImageFunc.
+func (m *MockClient) EnsureImage(ctx context.Context, ref string, opts *stromboli.EnsureImageOptions) (*stromboli.Image, error) {
+	m.record("EnsureImage")
+	if m.EnsureImageFunc != nil {
+		return m.EnsureImageFunc(ctx, ref, opts)
+	}
+	return nil, notMocked("EnsureImage")
+}
+
+// PullImageWithProgress calls PullImageWithProgressFunc.
+func (m *MockClient) PullImageWithProgress(ctx context.Context, req *stromboli.PullImageRequest, progress func(*stromboli.PullProgressEvent), opts ...stromboli.CallOption) (*stromboli.PullImageResponse, error) {
+	m.record("PullImageWithProgress")
+	if m.PullImageWithProgressFunc != nil {
+		return m.PullImageWithProgressFunc(ctx, req, progress, opts...)
+	}
+	return nil, notMocked("PullImageWithProgress")
+}
+
+// BuildImage calls BuildImageFunc.
+func (m *MockClient) BuildImage(ctx context.Context, req *stromboli.BuildImageRequest, opts ...stromboli.CallOption) (*stromboli.BuildImageResponse, error) {
+	m.record("BuildImage")
+	if m.BuildImageFunc != nil {
+		return m.BuildImageFunc(ctx, req, opts...)
+	}
+	return nil, notMocked("BuildImage")
+}
+
+// DeleteImage calls DeleteImageFunc.
+func (m *MockClient) DeleteImage(ctx context.Context, name string, opts *stromboli.DeleteImageOptions) error {
+	m.record("DeleteImage")
+	if m.DeleteImageFunc != nil {
+		return m.DeleteImageFunc(ctx, name, opts)
+	}
+	return notMocked("DeleteImage")
+}
@@ -0,0 +1,1258 @@
+package strombolitest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// Run is a run received by a [Server], from [stromboli.Client.Run],
+// [stromboli.Client.RunAsync] or [stromboli.Client.Stream].
+type Run struct {
+	// Prompt is the prompt of the run.
+	Prompt string
+
+	// SessionID is the session the run belongs to. The server creates the
+	// session before calling the run handler if it's new.
+	SessionID string
+
+	// Workdir is the working directory requested for the run.
+	Workdir string
+
+	// Model is the model requested for the run ("" if none).
+	Model stromboli.Model
+
+	// Async is true for runs started with RunAsync.
+	Async bool
+
+	// Streamed is true for runs started with Stream.
+	Streamed bool
+}
+
+// Result is the outcome of a [Run], returned by the run handler of a
+// [Server] (see Server.HandleRun).
+type Result struct {
+	// Output is the output of the run.
+	Output string
+
+	// Error fails the run with this message if non-empty.
+	Error string
+
+	// Usage is reported with the run if non-nil.
+	Usage *stromboli.Usage
+
+	// Events are the events of a streamed run. If nil, the stream sends one
+	// data event per line of Output, an "error" event if Error is set, and
+	// a "done" event.
+	Events []stromboli.StreamEvent
+}
+
+// Server is an in-process fake Stromboli server for testing code that uses
+// the SDK without a real server or a Prism mock.
+//
+// It implements the API in memory: runs return canned results (see
+// HandleRun), async runs create jobs that finish after a configurable
+// duration (see SetJobDuration), runs create and resume sessions whose
+// messages are kept, /run/stream serves SSE, and secrets, images and
+// tokens support their CRUD operations. Endpoints don't require
+// authentication.
+//
+//	server := strombolitest.NewServer()
+//	defer server.Close()
+//	server.HandleRun(func(run *strombolitest.Run) *strombolitest.Result {
+//	    return &strombolitest.Result{Output: "LGTM"}
+//	})
+//
+//	client, _ := stromboli.NewClient(server.URL)
+//	result, err := client.Run(ctx, &stromboli.RunRequest{Prompt: "Review this"})
+//
+// Error paths and timeouts are tested with FailNext and SetLatency:
+//
+//	server.FailNext(http.StatusServiceUnavailable)
+//	_, err := client.Run(ctx, req) // fails with stromboli.ErrUnavailable
+//
+// A Server is safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the server, e.g. "http://127.0.0.1:54321".
+	URL string
+
+	server *httptest.Server
+	cancel context.CancelFunc
+
+	mu           sync.Mutex
+	clock        stromboli.Clock
+	handler      func(*Run) *Result
+	latency      time.Duration
+	jobDuration  time.Duration
+	failures     []int
+	seq          int
+	jobs         map[string]*serverJob
+	jobOrder     []string
+	sessions     map[string]*serverSession
+	sessionOrder []string
+	secrets      map[string]*serverSecret
+	images       []*stromboli.Image
+	tokens       map[string]*serverToken
+	refresh      map[string]string
+	streams      map[string]context.CancelFunc
+}
+
+// serverJob is an async run.
+type serverJob struct {
+	id        string
+	sessionID string
+	result    *Result
+	created   time.Time
+	finishes  time.Time
+	cancelled time.Time
+}
+
+// serverSession is a session and its messages.
+type serverSession struct {
+	info     stromboli.SessionInfo
+	messages []map[string]interface{}
+}
+
+// serverSecret is a secret.
+type serverSecret struct {
+	id      string
+	value   string
+	created time.Time
+}
+
+// serverToken is an access token.
+type serverToken struct {
+	clientID string
+	expires  time.Time
+}
+
+// defaultMessagesLimit is the default page size of session messages.
+const defaultMessagesLimit = 50
+
+// tokenLifetime is the lifetime of access tokens.
+const tokenLifetime = time.Hour
+
+// NewServer starts a Server. Close it when done.
+//
+// Runs succeed with the prompt as output until HandleRun is called, async
+// runs finish immediately until SetJobDuration is called, and there are no
+// sessions, secrets or images.
+func NewServer() *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		cancel:   cancel,
+		clock:    realClock{},
+		jobs:     make(map[string]*serverJob),
+		sessions: make(map[string]*serverSession),
+		secrets:  make(map[string]*serverSecret),
+		tokens:   make(map[string]*serverToken),
+		refresh:  make(map[string]string),
+		streams:  make(map[string]context.CancelFunc),
+	}
+	s.server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	// Cancel pending requests (streams, latency) when the server is closed
+	s.server.Config.BaseContext = func(net.Listener) context.Context { return ctx }
+	s.server.Start()
+	s.URL = s.server.URL
+	return s
+}
+
+// Close cancels pending requests and shuts the server down.
+func (s *Server) Close() {
+	s.cancel()
+	s.server.Close()
+}
+
+// HandleRun sets the function computing the result of every run. It is
+// called concurrently for concurrent runs. A nil result is a successful run
+// without output.
+func (s *Server) HandleRun(fn func(run *Run) *Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = fn
+}
+
+// FailNext makes the next request fail with status code and a JSON error
+// body, before it is handled. Calling it several times fails as many
+// requests, in order.
+//
+// Note that some SDK methods make several requests (e.g. StreamJob may look
+// the job up); the failure applies to the first of them.
+func (s *Server) FailNext(code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, code)
+}
+
+// SetLatency delays every response by d, e.g. to test timeouts. A request
+// whose context is done while waiting is abandoned.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// SetJobDuration makes async jobs run for d before they finish, measured
+// with the server's clock (see SetClock). Jobs run while d hasn't elapsed
+// can be cancelled.
+func (s *Server) SetJobDuration(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobDuration = d
+}
+
+// SetClock sets the clock used for job durations and timestamps, such as a
+// [FakeClock], so job completion can be tested without real sleeps.
+// Latency (see SetLatency) always uses real time.
+func (s *Server) SetClock(clock stromboli.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
+// AddImage adds a local image, as if it had been pulled. Its ID is
+// generated if empty.
+func (s *Server) AddImage(img stromboli.Image) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if img.ID == "" {
+		img.ID = s.nextImageID()
+	}
+	s.images = append(s.images, &img)
+}
+
+// Secret returns the value of a secret, and false if it doesn't exist.
+func (s *Server) Secret(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secret, ok := s.secrets[name]
+	if !ok {
+		return "", false
+	}
+	return secret.value, true
+}
+
+// serveHTTP applies the latency and the failures set with FailNext, and
+// routes the request.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	latency := s.latency
+	failure := 0
+	if len(s.failures) > 0 {
+		failure, s.failures = s.failures[0], s.failures[1:]
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if failure != 0 {
+		writeError(w, failure, http.StatusText(failure))
+		return
+	}
+	s.route(w, r)
+}
+
+// route dispatches a request to its handler.
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	segs := pathSegments(r)
+	route := r.Method + " /" + strings.Join(routePattern(segs), "/")
+	switch route {
+	case "GET /health":
+		s.health(w)
+	case "GET /claude/status":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"configured": true, "message": "Claude is configured"})
+	case "GET /capabilities":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"supports_job_events": true})
+	case "POST /run":
+		s.run(w, r, false)
+	case "POST /run/async":
+		s.run(w, r, true)
+	case "GET /run/stream":
+		s.stream(w, r)
+	case "DELETE /run/stream/*":
+		s.abortStream(w, segs[2])
+	case "GET /jobs":
+		s.listJobs(w, r)
+	case "GET /jobs/*":
+		s.getJob(w, segs[1])
+	case "DELETE /jobs/*":
+		s.cancelJob(w, segs[1])
+	case "GET /jobs/*/stream":
+		s.streamJob(w, r, segs[1])
+	case "GET /sessions":
+		s.listSessions(w, r)
+	case "GET /sessions/*":
+		s.getSession(w, segs[1])
+	case "DELETE /sessions/*":
+		s.destroySession(w, segs[1])
+	case "GET /sessions/*/messages":
+		s.getMessages(w, r, segs[1])
+	case "GET /sessions/*/messages/*":
+		s.getMessage(w, segs[1], segs[3])
+	case "GET /secrets":
+		s.listSecrets(w)
+	case "POST /secrets":
+		s.createSecret(w, r)
+	case "GET /secrets/*":
+		s.getSecret(w, segs[1])
+	case "PUT /secrets/*":
+		s.updateSecret(w, r, segs[1])
+	case "DELETE /secrets/*":
+		s.deleteSecret(w, segs[1])
+	case "GET /images":
+		s.listImages(w)
+	case "GET /images/search":
+		s.searchImages(w, r)
+	case "POST /images/pull":
+		s.pullImage(w, r)
+	case "GET /images/*":
+		s.getImage(w, segs[1])
+	case "DELETE /images/*":
+		s.deleteImage(w, segs[1])
+	case "POST /auth/token":
+		s.issueToken(w, r)
+	case "POST /auth/refresh":
+		s.refreshToken(w, r)
+	case "GET /auth/validate":
+		s.validateToken(w, r)
+	case "POST /auth/logout":
+		s.logout(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// literalSegments are the path segments that are part of a route rather
+// than parameters, by resource and position.
+var literalSegments = map[string]map[int][]string{
+	"claude":   {1: {"status"}},
+	"run":      {1: {"async", "stream"}},
+	"jobs":     {2: {"stream"}},
+	"sessions": {2: {"messages"}},
+	"images":   {1: {"search", "pull"}},
+	"auth":     {1: {"token", "refresh", "validate", "logout"}},
+}
+
+// routePattern replaces the parameters of a path with "*", e.g.
+// "/sessions/abc/messages" becomes "/sessions/*/messages".
+func routePattern(segs []string) []string {
+	pattern := make([]string, len(segs))
+	for i, seg := range segs {
+		pattern[i] = "*"
+		if i == 0 || slices.Contains(literalSegments[segs[0]][i], seg) {
+			pattern[i] = seg
+		}
+	}
+	return pattern
+}
+
+// pathSegments returns the unescaped segments of the request path, so that
+// parameters may contain escaped slashes (e.g. image names).
+func pathSegments(r *http.Request) []string {
+	var segs []string
+	for _, seg := range strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/") {
+		if unescaped, err := url.PathUnescape(seg); err == nil {
+			seg = unescaped
+		}
+		segs = append(segs, seg)
+	}
+	return segs
+}
+
+// health serves GET /health.
+func (s *Server) health(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":    "stromboli",
+		"status":  "ok",
+		"version": stromboli.APIVersion,
+		"components": []map[string]string{
+			{"name": "podman", "status": "ok"},
+			{"name": "claude", "status": "ok"},
+		},
+	})
+}
+
+// runBody is the body of POST /run and POST /run/async.
+type runBody struct {
+	Prompt  string `json:"prompt"`
+	Workdir string `json:"workdir"`
+	Claude  *struct {
+		SessionID string          `json:"session_id"`
+		Resume    bool            `json:"resume"`
+		Model     stromboli.Model `json:"model"`
+	} `json:"claude"`
+}
+
+// run serves POST /run and POST /run/async.
+func (s *Server) run(w http.ResponseWriter, r *http.Request, async bool) {
+	var body runBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if body.Prompt == "" {
+		writeError(w, http.StatusBadRequest, "prompt is required")
+		return
+	}
+	run := &Run{Prompt: body.Prompt, Workdir: body.Workdir, Async: async}
+	resume := false
+	if body.Claude != nil {
+		run.SessionID, resume, run.Model = body.Claude.SessionID, body.Claude.Resume, body.Claude.Model
+	}
+	if !s.openSession(w, run, resume) {
+		return
+	}
+	result := s.execute(run)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if async {
+		now := s.clock.Now()
+		job := &serverJob{
+			id:        s.nextID("job"),
+			sessionID: run.SessionID,
+			result:    result,
+			created:   now,
+			finishes:  now.Add(s.jobDuration),
+		}
+		s.jobs[job.id] = job
+		s.jobOrder = append(s.jobOrder, job.id)
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{"job_id": job.id})
+		return
+	}
+
+	resp := map[string]interface{}{
+		"id":         s.nextID("run"),
+		"status":     stromboli.JobStatusCompleted,
+		"output":     result.Output,
+		"session_id": run.SessionID,
+	}
+	if result.Error != "" {
+		resp["status"], resp["error"] = stromboli.JobStatusFailed, result.Error
+	}
+	if result.Usage != nil {
+		resp["usage"] = result.Usage
+	}
+	if run.Model != "" {
+		resp["model_used"] = run.Model
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// openSession creates the session of run, or checks that the session to
+// resume exists. It writes an error and returns false if it doesn't.
+func (s *Server) openSession(w http.ResponseWriter, run *Run, resume bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run.SessionID != "" {
+		if _, ok := s.sessions[run.SessionID]; ok {
+			return true
+		}
+		if resume {
+			writeError(w, http.StatusNotFound, "session not found")
+			return false
+		}
+	} else {
+		run.SessionID = rand.Text()
+	}
+	now := s.timestamp()
+	s.sessions[run.SessionID] = &serverSession{info: stromboli.SessionInfo{
+		ID:        run.SessionID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Workdir:   run.Workdir,
+		Model:     run.Model,
+	}}
+	s.sessionOrder = append(s.sessionOrder, run.SessionID)
+	return true
+}
+
+// execute calls the run handler, and records the prompt and output in the
+// run's session.
+func (s *Server) execute(run *Run) *Result {
+	s.mu.Lock()
+	handler := s.handler
+	s.mu.Unlock()
+
+	var result *Result
+	if handler != nil {
+		result = handler(run)
+	} else {
+		result = &Result{Output: run.Prompt}
+	}
+	if result == nil {
+		result = &Result{}
+	}
+
+	output := result.Output
+	if output == "" && result.Events != nil {
+		var b strings.Builder
+		for _, ev := range result.Events {
+			if ev.Type == "" || ev.Type == "message" {
+				b.WriteString(ev.Data)
+			}
+		}
+		output = b.String()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[run.SessionID]
+	if !ok {
+		// Destroyed while the handler ran
+		return result
+	}
+	now := s.timestamp()
+	parent := ""
+	for _, msg := range []struct{ role, text string }{{"user", run.Prompt}, {"assistant", output}} {
+		id := rand.Text()
+		message := map[string]interface{}{
+			"uuid":       id,
+			"type":       msg.role,
+			"session_id": run.SessionID,
+			"timestamp":  now,
+			"content": map[string]interface{}{
+				"role":    msg.role,
+				"content": []map[string]string{{"type": "text", "text": msg.text}},
+			},
+		}
+		if parent != "" {
+			message["parent_uuid"] = parent
+		}
+		if run.Workdir != "" {
+			message["cwd"] = run.Workdir
+		}
+		session.messages = append(session.messages, message)
+		parent = id
+	}
+	session.info.MessageCount = int64(len(session.messages))
+	session.info.LastPrompt = run.Prompt
+	session.info.UpdatedAt = now
+	if run.Model != "" {
+		session.info.Model = run.Model
+	}
+	return result
+}
+
+// stream serves GET /run/stream.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	run := &Run{
+		Prompt:    query.Get("prompt"),
+		SessionID: query.Get("session_id"),
+		Workdir:   query.Get("workdir"),
+		Streamed:  true,
+	}
+	if run.Prompt == "" {
+		writeError(w, http.StatusBadRequest, "prompt is required")
+		return
+	}
+	if !s.openSession(w, run, run.SessionID != "") {
+		return
+	}
+	result := s.execute(run)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	s.mu.Lock()
+	id := s.nextID("stream")
+	s.streams[id] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, id)
+		s.mu.Unlock()
+	}()
+
+	events := result.Events
+	if events == nil {
+		events = resultEvents(result.Output, result.Error)
+	}
+	w.Header().Set("X-Session-ID", run.SessionID)
+	w.Header().Set("X-Stream-ID", id)
+	startEvents(w)
+	writeEvents(ctx, w, events)
+}
+
+// abortStream serves DELETE /run/stream/{id}.
+func (s *Server) abortStream(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	cancel, ok := s.streams[id]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "stream not found")
+		return
+	}
+	cancel()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// resultEvents returns the events of a streamed result: one data event per
+// line of output, an "error" event if errMsg is set, and a "done" event.
+func resultEvents(output, errMsg string) []stromboli.StreamEvent {
+	var events []stromboli.StreamEvent
+	if output != "" {
+		for _, line := range strings.Split(output, "\n") {
+			events = append(events, stromboli.StreamEvent{Data: line})
+		}
+	}
+	if errMsg != "" {
+		events = append(events, stromboli.StreamEvent{Type: stromboli.EventTypeError, Data: errMsg})
+	}
+	return append(events, stromboli.StreamEvent{Type: stromboli.EventTypeDone})
+}
+
+// startEvents writes and flushes the headers of an SSE response.
+func startEvents(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// writeEvents writes events as SSE, flushing each, until ctx is done.
+func writeEvents(ctx context.Context, w http.ResponseWriter, events []stromboli.StreamEvent) {
+	flusher, _ := w.(http.Flusher)
+	for _, ev := range events {
+		if ctx.Err() != nil {
+			return
+		}
+		if ev.Type != "" {
+			fmt.Fprintf(w, "event: %s\n", ev.Type)
+		}
+		if ev.ID != "" {
+			fmt.Fprintf(w, "id: %s\n", ev.ID)
+		}
+		for _, line := range strings.Split(ev.Data, "\n") {
+			fmt.Fprintf(w, "data: %s\n", line)
+		}
+		fmt.Fprint(w, "\n")
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// jobStatus returns the status of job at now.
+func jobStatus(job *serverJob, now time.Time) string {
+	switch {
+	case !job.cancelled.IsZero():
+		return stromboli.JobStatusCancelled
+	case now.Before(job.finishes):
+		return stromboli.JobStatusRunning
+	case job.result.Error != "":
+		return stromboli.JobStatusFailed
+	default:
+		return stromboli.JobStatusCompleted
+	}
+}
+
+// jobJSON returns the JSON representation of job at now.
+func jobJSON(job *serverJob, now time.Time) map[string]interface{} {
+	status := jobStatus(job, now)
+	updated := job.created
+	switch status {
+	case stromboli.JobStatusCancelled:
+		updated = job.cancelled
+	case stromboli.JobStatusCompleted, stromboli.JobStatusFailed:
+		updated = job.finishes
+	}
+	resp := map[string]interface{}{
+		"id":         job.id,
+		"status":     status,
+		"session_id": job.sessionID,
+		"created_at": job.created.UTC().Format(time.RFC3339),
+		"updated_at": updated.UTC().Format(time.RFC3339),
+	}
+	switch status {
+	case stromboli.JobStatusCompleted:
+		resp["output"] = job.result.Output
+	case stromboli.JobStatusFailed:
+		resp["output"], resp["error"] = job.result.Output, job.result.Error
+	case stromboli.JobStatusCancelled:
+		resp["error"] = "job cancelled"
+	}
+	if job.result.Usage != nil && (status == stromboli.JobStatusCompleted || status == stromboli.JobStatusFailed) {
+		resp["usage"] = job.result.Usage
+	}
+	return resp
+}
+
+// listJobs serves GET /jobs, with the status, limit and offset filters.
+func (s *Server) listJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	wanted := map[string]bool{}
+	if status := query.Get("status"); status != "" {
+		for _, st := range strings.Split(status, ",") {
+			wanted[st] = true
+		}
+	}
+
+	s.mu.Lock()
+	now := s.clock.Now()
+	jobs := make([]map[string]interface{}, 0, len(s.jobOrder))
+	for _, id := range s.jobOrder {
+		job := jobJSON(s.jobs[id], now)
+		if len(wanted) == 0 || wanted[job["status"].(string)] {
+			jobs = append(jobs, job)
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"jobs": page(jobs, query)})
+}
+
+// getJob serves GET /jobs/{id}.
+func (s *Server) getJob(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, jobJSON(job, s.clock.Now()))
+}
+
+// cancelJob serves DELETE /jobs/{id}.
+func (s *Server) cancelJob(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	now := s.clock.Now()
+	if jobStatus(job, now) != stromboli.JobStatusRunning {
+		writeError(w, http.StatusConflict, "job already finished")
+		return
+	}
+	job.cancelled = now
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "status": stromboli.JobStatusCancelled})
+}
+
+// streamJob serves GET /jobs/{id}/stream: the events of a running job,
+// sent when it finishes, or 409 if it has already finished.
+func (s *Server) streamJob(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	job, ok := s.jobs[id]
+	clock := s.clock
+	var status string
+	if ok {
+		status = jobStatus(job, clock.Now())
+	}
+	s.mu.Unlock()
+	switch {
+	case !ok:
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	case status != stromboli.JobStatusRunning:
+		writeError(w, http.StatusConflict, "job already finished")
+		return
+	}
+
+	startEvents(w)
+	for status == stromboli.JobStatusRunning {
+		select {
+		case <-clock.After(job.finishes.Sub(clock.Now())):
+		case <-r.Context().Done():
+			return
+		}
+		s.mu.Lock()
+		status = jobStatus(job, clock.Now())
+		s.mu.Unlock()
+	}
+
+	switch status {
+	case stromboli.JobStatusCancelled:
+		writeEvents(r.Context(), w, resultEvents("", "job cancelled"))
+	default:
+		writeEvents(r.Context(), w, resultEvents(job.result.Output, job.result.Error))
+	}
+}
+
+// listSessions serves GET /sessions: IDs, or details with detailed=true.
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if query.Get("detailed") != "true" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": append([]string{}, s.sessionOrder...)})
+		return
+	}
+	sessions := make([]stromboli.SessionInfo, 0, len(s.sessionOrder))
+	for _, id := range s.sessionOrder {
+		sessions = append(sessions, s.sessions[id].info)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": page(sessions, query)})
+}
+
+// getSession serves GET /sessions/{id}.
+func (s *Server) getSession(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, session.info)
+}
+
+// destroySession serves DELETE /sessions/{id}.
+func (s *Server) destroySession(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[id]; !ok {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	delete(s.sessions, id)
+	s.sessionOrder = removeString(s.sessionOrder, id)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "session_id": id})
+}
+
+// getMessages serves GET /sessions/{id}/messages, with limit and offset.
+func (s *Server) getMessages(w http.ResponseWriter, r *http.Request, id string) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	limit, offset := queryInt(query, "limit", defaultMessagesLimit), queryInt(query, "offset", 0)
+	if limit <= 0 {
+		limit = defaultMessagesLimit
+	}
+	messages := paginate(session.messages, offset, limit)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"messages": messages,
+		"total":    len(session.messages),
+		"limit":    limit,
+		"offset":   offset,
+		"has_more": offset+len(messages) < len(session.messages),
+	})
+}
+
+// getMessage serves GET /sessions/{id}/messages/{message_id}.
+func (s *Server) getMessage(w http.ResponseWriter, id, messageID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	for _, msg := range session.messages {
+		if msg["uuid"] == messageID {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"message": msg})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "message not found")
+}
+
+// secretBody is the body of POST /secrets and PUT /secrets/{name}.
+type secretBody struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// secretJSON returns the JSON representation of a secret.
+func secretJSON(name string, secret *serverSecret) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         secret.id,
+		"name":       name,
+		"created_at": secret.created.UTC().Format(time.RFC3339),
+	}
+}
+
+// listSecrets serves GET /secrets, sorted by name.
+func (s *Server) listSecrets(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	secrets := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		secrets = append(secrets, secretJSON(name, s.secrets[name]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"secrets": secrets})
+}
+
+// createSecret serves POST /secrets.
+func (s *Server) createSecret(w http.ResponseWriter, r *http.Request) {
+	var body secretBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" || body.Value == "" {
+		writeError(w, http.StatusBadRequest, "name and value are required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.secrets[body.Name]; ok {
+		writeError(w, http.StatusConflict, "secret already exists")
+		return
+	}
+	s.secrets[body.Name] = &serverSecret{id: s.nextID("secret"), value: body.Value, created: s.clock.Now()}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"success": true, "name": body.Name})
+}
+
+// getSecret serves GET /secrets/{name}.
+func (s *Server) getSecret(w http.ResponseWriter, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secret, ok := s.secrets[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "secret not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, secretJSON(name, secret))
+}
+
+// updateSecret serves PUT /secrets/{name}.
+func (s *Server) updateSecret(w http.ResponseWriter, r *http.Request, name string) {
+	var body secretBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Value == "" {
+		writeError(w, http.StatusBadRequest, "value is required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secret, ok := s.secrets[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "secret not found")
+		return
+	}
+	secret.value = body.Value
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "name": name})
+}
+
+// deleteSecret serves DELETE /secrets/{name}.
+func (s *Server) deleteSecret(w http.ResponseWriter, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.secrets[name]; !ok {
+		writeError(w, http.StatusNotFound, "secret not found")
+		return
+	}
+	delete(s.secrets, name)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "name": name})
+}
+
+// listImages serves GET /images.
+func (s *Server) listImages(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"images": s.images})
+}
+
+// findImage returns the index of the image with the given name ("repo",
+// "repo:tag") or ID, or -1.
+func (s *Server) findImage(name string) int {
+	repo, tag := splitImageName(name)
+	for i, img := range s.images {
+		if img.ID == name || (img.Repository == repo && img.Tag == tag) {
+			return i
+		}
+	}
+	return -1
+}
+
+// getImage serves GET /images/{name}.
+func (s *Server) getImage(w http.ResponseWriter, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.findImage(name)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.images[i])
+}
+
+// searchImages serves GET /images/search, matching the query against the
+// repositories of the local images.
+func (s *Server) searchImages(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := strings.ToLower(query.Get("q"))
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results := []map[string]interface{}{}
+	seen := map[string]bool{}
+	for _, img := range s.images {
+		if !strings.Contains(strings.ToLower(img.Repository), q) || seen[img.Repository] {
+			continue
+		}
+		seen[img.Repository] = true
+		results = append(results, map[string]interface{}{
+			"name":        img.Repository,
+			"description": img.Description,
+			"index":       "docker.io",
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"results": page(results, query)})
+}
+
+// pullImage serves POST /images/pull, adding the image if it is new.
+func (s *Server) pullImage(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Image string `json:"image"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Image == "" {
+		writeError(w, http.StatusBadRequest, "image is required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.findImage(body.Image)
+	if i < 0 {
+		repo, tag := splitImageName(body.Image)
+		s.images = append(s.images, &stromboli.Image{
+			ID:         s.nextImageID(),
+			Repository: repo,
+			Tag:        tag,
+			Created:    s.timestamp(),
+		})
+		i = len(s.images) - 1
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"image":    body.Image,
+		"image_id": s.images[i].ID,
+	})
+}
+
+// deleteImage serves DELETE /images/{name}.
+func (s *Server) deleteImage(w http.ResponseWriter, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.findImage(name)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+	s.images = append(s.images[:i], s.images[i+1:]...)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// splitImageName splits an image name into repository and tag ("latest"
+// if none).
+func splitImageName(name string) (repo, tag string) {
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		return name[:i], name[i+1:]
+	}
+	return name, "latest"
+}
+
+// issueToken serves POST /auth/token.
+func (s *Server) issueToken(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ClientID string `json:"client_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ClientID == "" {
+		writeError(w, http.StatusBadRequest, "client_id is required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.newTokens(body.ClientID))
+}
+
+// refreshToken serves POST /auth/refresh. Refresh tokens are single-use.
+func (s *Server) refreshToken(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.RefreshToken == "" {
+		writeError(w, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clientID, ok := s.refresh[body.RefreshToken]
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "invalid refresh token")
+		return
+	}
+	delete(s.refresh, body.RefreshToken)
+	writeJSON(w, http.StatusOK, s.newTokens(clientID))
+}
+
+// newTokens issues an access and a refresh token for clientID.
+func (s *Server) newTokens(clientID string) map[string]interface{} {
+	access, refresh := rand.Text(), rand.Text()
+	s.tokens[access] = &serverToken{clientID: clientID, expires: s.clock.Now().Add(tokenLifetime)}
+	s.refresh[refresh] = clientID
+	return map[string]interface{}{
+		"access_token":  access,
+		"refresh_token": refresh,
+		"token_type":    "Bearer",
+		"expires_in":    int64(tokenLifetime / time.Second),
+	}
+}
+
+// bearerToken returns the bearer token of r, or "".
+func bearerToken(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return strings.TrimSpace(token)
+}
+
+// validateToken serves GET /auth/validate. Tokens the server didn't issue,
+// or that expired or were logged out, are reported as invalid.
+func (s *Server) validateToken(w http.ResponseWriter, r *http.Request) {
+	token := bearerToken(r)
+	if token == "" {
+		writeError(w, http.StatusUnauthorized, "missing token")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issued, ok := s.tokens[token]
+	if !ok || !s.clock.Now().Before(issued.expires) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"valid": false})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"valid":      true,
+		"subject":    issued.clientID,
+		"expires_at": issued.expires.Unix(),
+	})
+}
+
+// logout serves POST /auth/logout, revoking the access token.
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	token := bearerToken(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[token]; !ok {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+	delete(s.tokens, token)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "message": "logged out"})
+}
+
+// nextID returns a new ID with the given prefix. The caller must hold s.mu.
+func (s *Server) nextID(prefix string) string {
+	s.seq++
+	return prefix + "-" + strconv.Itoa(s.seq)
+}
+
+// nextImageID returns a new image ID. The caller must hold s.mu.
+func (s *Server) nextImageID() string {
+	s.seq++
+	return fmt.Sprintf("sha256:%064x", s.seq)
+}
+
+// timestamp returns the current time of the server's clock in RFC 3339
+// format. The caller must hold s.mu.
+func (s *Server) timestamp() string {
+	return s.clock.Now().UTC().Format(time.RFC3339)
+}
+
+// page applies the limit and offset query parameters to items.
+func page[T any](items []T, query url.Values) []T {
+	return paginate(items, queryInt(query, "offset", 0), queryInt(query, "limit", 0))
+}
+
+// paginate applies offset and limit (0 means no limit) to items.
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// queryInt returns the non-negative integer query parameter key, or
+// fallback if it is missing or invalid.
+func queryInt(query url.Values, key string, fallback int) int {
+	n, err := strconv.Atoi(query.Get(key))
+	if err != nil || n < 0 {
+		return fallback
+	}
+	return n
+}
+
+// removeString returns items without the first occurrence of item.
+func removeString(items []string, item string) []string {
+	for i, v := range items {
+		if v == item {
+			return append(items[:i], items[i+1:]...)
+		}
+	}
+	return items
+}
+
+// writeJSON writes v as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// realClock is a [stromboli.Clock] using the time package.
+type realClock struct{}
+
+// Now implements stromboli.Clock.
+func (realClock) Now() time.Time { return time.Now() }
+
+// After implements stromboli.Clock.
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// NewTimer implements stromboli.Clock.
+func (realClock) NewTimer(d time.Duration) stromboli.Timer { return realTimer{time.NewTimer(d)} }
+
+// realTimer is a [stromboli.Timer] wrapping a [time.Timer].
+type realTimer struct {
+	t *time.Timer
+}
+
+// C implements stromboli.Timer.
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+
+// Stop implements stromboli.Timer.
+func (t realTimer) Stop() bool { return t.t.Stop() }
+
+// Reset implements stromboli.Timer.
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
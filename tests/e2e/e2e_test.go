@@ -17,6 +17,11 @@
 //	# Terminal 2: Run E2E tests
 //	make test-e2e
 //
+// To run against the in-process fake server of the strombolitest package,
+// without any external server:
+//
+//	STROMBOLI_FAKE=1 go test -tags=e2e ./tests/e2e/...
+//
 // Note: Some tests may fail with Prism because it generates mock data that
 // doesn't always match complex nested struct types. Set STROMBOLI_REAL=1 to
 // run tests that require a real Stromboli server.
@@ -29,21 +34,48 @@ import (
 	"time"
 
 	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
 )
 
+// fakeServer is the in-process server used when STROMBOLI_FAKE=1, or nil.
+var fakeServer *strombolitest.Server
+
+// TestMain starts the fake server if STROMBOLI_FAKE=1.
+func TestMain(m *testing.M) {
+	if os.Getenv("STROMBOLI_FAKE") != "1" {
+		os.Exit(m.Run())
+	}
+	fakeServer = strombolitest.NewServer()
+	fakeServer.AddImage(stromboli.Image{
+		Repository:        "python",
+		Tag:               "3.12",
+		Description:       "Python with Claude CLI",
+		Compatible:        true,
+		CompatibilityRank: 1,
+		HasClaudeCLI:      true,
+	})
+	code := m.Run()
+	fakeServer.Close()
+	os.Exit(code)
+}
+
 // getBaseURL returns the Stromboli API base URL.
 // It reads from STROMBOLI_URL environment variable, defaulting to Prism's port.
 func getBaseURL() string {
+	if fakeServer != nil {
+		return fakeServer.URL
+	}
 	if url := os.Getenv("STROMBOLI_URL"); url != "" {
 		return url
 	}
 	return "http://localhost:4010" // Prism default
 }
 
-// isRealServer returns true if running against a real Stromboli server.
+// isRealServer returns true if running against a real Stromboli server, or
+// the fake server which behaves like one.
 // Set STROMBOLI_REAL=1 to indicate a real server.
 func isRealServer() bool {
-	return os.Getenv("STROMBOLI_REAL") == "1"
+	return fakeServer != nil || os.Getenv("STROMBOLI_REAL") == "1"
 }
 
 // skipIfMock skips the test if running against a mock server.
@@ -117,3 +117,31 @@ func TestPullImage_E2E(t *testing.T) {
 
 	t.Logf("Pulled image: %s (ID: %s)", result.Image, result.ImageID)
 }
+
+// TestDeleteImage_E2E tests pulling and then deleting an image.
+//
+// Skip by default as this requires real Podman and network access.
+func TestDeleteImage_E2E(t *testing.T) {
+	skipIfMock(t, "DeleteImage requires real Podman")
+
+	if testing.Short() {
+		t.Skip("Skipping DeleteImage in short mode (pulls an image)")
+	}
+
+	client := newTestClient()
+	ctx := newTestContext(t)
+
+	const image = "docker.io/library/busybox:latest"
+	_, err := client.PullImage(ctx, &stromboli.PullImageRequest{
+		Image: image,
+		Quiet: true,
+	})
+	require.NoError(t, err, "PullImage should succeed")
+
+	err = client.DeleteImage(ctx, image, nil)
+	require.NoError(t, err, "DeleteImage should succeed")
+
+	// Deleting again should report the image as missing
+	err = client.DeleteImage(ctx, image, nil)
+	assert.ErrorIs(t, err, stromboli.ErrImageNotFound)
+}
@@ -53,16 +53,25 @@ func TestStream_WithSession_E2E(t *testing.T) {
 	require.NoError(t, err, "First stream should connect")
 
 	// Consume stream1
-	var output1 string
-	for stream1.Next() {
-		output1 += stream1.Event().Data
-	}
-	stream1.Close()
-	require.NoError(t, stream1.Err(), "First stream should complete")
+	output1, err := stream1.Collect(ctx)
+	require.NoError(t, err, "First stream should complete")
 	t.Logf("First response: %s", output1)
 
-	// Note: Session ID would come from stream metadata in real implementation
-	// For now, this test validates the basic streaming flow
+	sessionID := stream1.SessionID()
+	if sessionID == "" {
+		t.Skip("Server did not report a session ID on the stream")
+	}
+
+	// Continue the conversation
+	stream2, err := client.Stream(ctx, &stromboli.StreamRequest{
+		Prompt:    "What is my name?",
+		SessionID: sessionID,
+	})
+	require.NoError(t, err, "Second stream should connect")
+
+	output2, err := stream2.Collect(ctx)
+	require.NoError(t, err, "Second stream should complete")
+	t.Logf("Second response: %s", output2)
 }
 
 // TestStream_ChannelIteration_E2E tests the Events() channel method.
@@ -0,0 +1,137 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// authRecorder records the Authorization header of each request.
+type authRecorder struct {
+	mu      sync.Mutex
+	headers []string
+}
+
+// record records the Authorization header of r.
+func (a *authRecorder) record(r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.headers = append(a.headers, r.Header.Get("Authorization"))
+}
+
+// recorded returns the recorded headers.
+func (a *authRecorder) recorded() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.headers...)
+}
+
+// TestContextWithToken_ConcurrentCalls tests that a context token is used
+// for one call while the client token applies to a concurrent call.
+func TestContextWithToken_ConcurrentCalls(t *testing.T) {
+	// Arrange
+	auth := &authRecorder{}
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.record(r)
+		arrived.Done()
+		arrived.Wait() // both calls are in flight at once
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"valid": true, "subject": "user"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithToken("client-token"))
+	require.NoError(t, err)
+	tenantCtx := stromboli.ContextWithToken(context.Background(), "tenant-token")
+
+	// Act
+	errs := make(chan error, 2)
+	for _, ctx := range []context.Context{tenantCtx, context.Background()} {
+		go func() {
+			_, err := client.ValidateToken(ctx)
+			errs <- err
+		}()
+	}
+	for range 2 {
+		select {
+		case err := <-errs:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("calls did not complete")
+		}
+	}
+
+	// Assert
+	assert.ElementsMatch(t, []string{"Bearer tenant-token", "Bearer client-token"}, auth.recorded())
+}
+
+// TestContextWithToken_WithoutClientToken tests that a context token is
+// enough for calls requiring a token, and that it is sent on raw requests
+// such as streams.
+func TestContextWithToken_WithoutClientToken(t *testing.T) {
+	// Arrange
+	auth := &authRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.record(r)
+		if r.URL.Path == "/run/stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: Hello\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"success": true, "message": "logged out"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := stromboli.ContextWithToken(context.Background(), "tenant-token")
+
+	// Act
+	_, logoutErr := client.Logout(ctx)
+	stream, streamErr := client.Stream(ctx, &stromboli.StreamRequest{Prompt: "Hi"})
+	require.NoError(t, streamErr)
+	_ = stream.Close()
+	_, noTokenErr := client.Logout(context.Background())
+
+	// Assert
+	require.NoError(t, logoutErr)
+	assert.Equal(t, []string{"Bearer tenant-token", "Bearer tenant-token"}, auth.recorded())
+	assert.ErrorIs(t, noTokenErr, stromboli.ErrUnauthorized, "the context token is not kept by the client")
+}
+
+// TestContextWithToken_Invalid tests that a token containing control
+// characters is ignored in favor of the client token.
+func TestContextWithToken_Invalid(t *testing.T) {
+	// Arrange
+	stromboli.SetLogger(&captureLogger{})
+	defer stromboli.SetLogger(nil)
+
+	auth := &authRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.record(r)
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"valid": true})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithToken("client-token"))
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.ValidateToken(stromboli.ContextWithToken(context.Background(), "bad\r\nX-Injected: 1"))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer client-token"}, auth.recorded())
+}
@@ -0,0 +1,465 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// TestRunBatch_PreservesOrder tests that results and errors are returned in
+// input order and that a failing request does not abort the batch.
+func TestRunBatch_PreservesOrder(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		mustDecode(r, &req)
+
+		w.Header().Set("Content-Type", "application/json")
+		if req["prompt"] == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			mustEncode(w, map[string]string{"error": "boom"})
+			return
+		}
+		mustEncode(w, map[string]interface{}{
+			"id":     "run-" + req["prompt"].(string),
+			"status": "completed",
+			"output": req["prompt"],
+		})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	reqs := []*stromboli.RunRequest{
+		{Prompt: "a"},
+		{Prompt: "fail"},
+		{Prompt: "c"},
+		{Prompt: ""}, // client-side validation error
+	}
+
+	// Act
+	results, errs := client.RunBatch(context.Background(), reqs, 2)
+
+	// Assert
+	require.Len(t, results, 4)
+	require.Len(t, errs, 4)
+
+	require.NoError(t, errs[0])
+	assert.Equal(t, "a", results[0].Output)
+
+	require.Error(t, errs[1])
+	assert.Nil(t, results[1])
+	assert.NotErrorIs(t, errs[1], stromboli.ErrBadRequest)
+
+	require.NoError(t, errs[2])
+	assert.Equal(t, "c", results[2].Output)
+
+	require.Error(t, errs[3])
+	assert.True(t, errors.Is(errs[3], stromboli.ErrBadRequest))
+}
+
+// TestRunBatch_BoundedConcurrency tests that no more than the requested
+// number of requests are in flight at once.
+func TestRunBatch_BoundedConcurrency(t *testing.T) {
+	// Arrange
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"id": "run", "status": "completed"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	reqs := make([]*stromboli.RunRequest, 8)
+	for i := range reqs {
+		reqs[i] = &stromboli.RunRequest{Prompt: "test"}
+	}
+
+	// Act
+	_, errs := client.RunBatch(context.Background(), reqs, 3)
+
+	// Assert
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(3))
+}
+
+// TestRunBatch_ContextCancelled tests that no work is dispatched once the
+// context is cancelled.
+func TestRunBatch_ContextCancelled(t *testing.T) {
+	// Arrange
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"id": "run", "status": "completed"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reqs := []*stromboli.RunRequest{{Prompt: "a"}, {Prompt: "b"}}
+
+	// Act
+	results, errs := client.RunBatch(ctx, reqs, 1)
+
+	// Assert
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	for i := range reqs {
+		assert.Nil(t, results[i])
+		var apiErr *stromboli.Error
+		require.ErrorAs(t, errs[i], &apiErr)
+		assert.Equal(t, "CANCELLED", apiErr.Code)
+	}
+}
+
+// sessionCleanupServer lists the given sessions (IDs or session details)
+// and deletes them. Deleting "sess-gone" returns 404 and "sess-broken"
+// returns 500. onDelete, if not nil, is called for every deletion.
+func sessionCleanupServer(sessions interface{}, onDelete func(id string)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			mustEncode(w, map[string]interface{}{"sessions": sessions})
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, "/sessions/")
+		if onDelete != nil {
+			onDelete(id)
+		}
+		switch id {
+		case "sess-gone":
+			w.WriteHeader(http.StatusNotFound)
+			mustEncode(w, map[string]string{"error": "session not found"})
+		case "sess-broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			mustEncode(w, map[string]string{"error": "disk full"})
+		default:
+			mustEncode(w, map[string]interface{}{"success": true})
+		}
+	}))
+}
+
+// TestDestroyAllSessions tests that missing sessions count as destroyed,
+// that failures are keyed by session ID and that concurrency is bounded.
+func TestDestroyAllSessions(t *testing.T) {
+	// Arrange
+	sessions := []string{"sess-1", "sess-2", "sess-gone", "sess-broken", "sess-3", "sess-4"}
+	var inFlight, maxInFlight int32
+	server := sessionCleanupServer(sessions, func(string) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	})
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	destroyed, errs := client.DestroyAllSessions(context.Background(), 2)
+
+	// Assert
+	assert.Equal(t, 5, destroyed)
+	require.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs["sess-broken"], stromboli.ErrInternal))
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+}
+
+// TestDestroyAllSessions_ListFails tests that a listing failure is returned
+// under the empty key.
+func TestDestroyAllSessions_ListFails(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		mustEncode(w, map[string]string{"error": "podman unavailable"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	destroyed, errs := client.DestroyAllSessions(context.Background(), 4)
+
+	// Assert
+	assert.Zero(t, destroyed)
+	require.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[""], stromboli.ErrInternal))
+}
+
+// TestDestroyAllSessions_ContextCancelled tests that no deletion is started
+// once the context is cancelled.
+func TestDestroyAllSessions_ContextCancelled(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var deletes int32
+	server := sessionCleanupServer([]string{"sess-1", "sess-2", "sess-3", "sess-4"}, func(string) {
+		atomic.AddInt32(&deletes, 1)
+		cancel()
+	})
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	destroyed, errs := client.DestroyAllSessions(ctx, 1)
+
+	// Assert
+	assert.Equal(t, int32(1), atomic.LoadInt32(&deletes))
+	assert.Equal(t, 4, destroyed+len(errs))
+	for _, id := range []string{"sess-2", "sess-3", "sess-4"} {
+		var apiErr *stromboli.Error
+		require.ErrorAs(t, errs[id], &apiErr, id)
+		assert.Equal(t, "CANCELLED", apiErr.Code)
+	}
+}
+
+// staleSessionList lists sessions last used 1 to 40 days before
+// cleanupNow, one without timestamps and one only with a creation time.
+var staleSessionList = []map[string]interface{}{
+	{"id": "sess-recent", "updated_at": "2024-03-30T10:00:00Z"},
+	{"id": "sess-broken", "updated_at": "2024-02-20T10:00:00Z"},
+	{"id": "sess-old", "updated_at": "2024-02-25T10:00:00Z"},
+	{"id": "sess-unknown"},
+	{"id": "sess-gone", "created_at": "2024-02-21T10:00:00Z"},
+	{"id": "sess-oldest", "created_at": "2024-01-01T10:00:00Z", "updated_at": "2024-02-10T10:00:00Z"},
+}
+
+// cleanupNow is the current time of the cleanup tests.
+var cleanupNow = time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC)
+
+// TestCleanupSessions_PartialFailure tests that stale sessions are
+// destroyed least recently used first, and that a failure doesn't abort
+// the cleanup but is reported per ID and in the joined error.
+func TestCleanupSessions_PartialFailure(t *testing.T) {
+	// Arrange
+	var mu sync.Mutex
+	var deleted []string
+	server := sessionCleanupServer(staleSessionList, func(id string) {
+		mu.Lock()
+		defer mu.Unlock()
+		deleted = append(deleted, id)
+	})
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClock(strombolitest.NewFakeClock(cleanupNow)))
+	require.NoError(t, err)
+
+	// Act
+	result, err := client.CleanupSessions(context.Background(), &stromboli.CleanupOptions{
+		OlderThan:   30 * 24 * time.Hour,
+		Concurrency: 2,
+	})
+
+	// Assert
+	require.NotNil(t, result)
+	assert.False(t, result.DryRun)
+	assert.Equal(t, []string{"sess-oldest", "sess-gone", "sess-old"}, result.Deleted)
+	require.Len(t, result.Errors, 1)
+	assert.True(t, errors.Is(result.Errors["sess-broken"], stromboli.ErrInternal))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, stromboli.ErrInternal))
+	assert.Contains(t, err.Error(), "sess-broken")
+	assert.ElementsMatch(t, []string{"sess-oldest", "sess-broken", "sess-gone", "sess-old"}, deleted)
+}
+
+// TestCleanupSessions_DryRunAndLimit tests that a dry run destroys nothing
+// and that Limit keeps the least recently used sessions.
+func TestCleanupSessions_DryRunAndLimit(t *testing.T) {
+	// Arrange
+	var deletes int32
+	server := sessionCleanupServer(staleSessionList, func(string) { atomic.AddInt32(&deletes, 1) })
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClock(strombolitest.NewFakeClock(cleanupNow)))
+	require.NoError(t, err)
+
+	// Act
+	limited, err := client.CleanupSessions(context.Background(), &stromboli.CleanupOptions{
+		OlderThan: 30 * 24 * time.Hour,
+		Limit:     2,
+		DryRun:    true,
+	})
+	require.NoError(t, err)
+	all, err := client.CleanupSessions(context.Background(), &stromboli.CleanupOptions{DryRun: true})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Zero(t, atomic.LoadInt32(&deletes))
+	assert.True(t, limited.DryRun)
+	assert.Equal(t, []string{"sess-oldest", "sess-broken"}, limited.Deleted)
+	assert.Len(t, all.Deleted, 6, "without OlderThan, sessions of unknown age are included")
+	assert.Nil(t, all.Errors)
+}
+
+// TestCleanupSessions_ContextCancelled tests that no deletion is started
+// once the context is cancelled, and that the remaining sessions report
+// the cancellation.
+func TestCleanupSessions_ContextCancelled(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var deletes int32
+	server := sessionCleanupServer([]string{"sess-1", "sess-2", "sess-3"}, func(string) {
+		atomic.AddInt32(&deletes, 1)
+		cancel()
+	})
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	result, err := client.CleanupSessions(ctx, nil)
+
+	// Assert
+	assert.Equal(t, int32(1), atomic.LoadInt32(&deletes))
+	assert.Equal(t, 3, len(result.Deleted)+len(result.Errors))
+	assert.True(t, errors.Is(err, &stromboli.Error{Code: "CANCELLED"}))
+	for _, id := range []string{"sess-2", "sess-3"} {
+		var apiErr *stromboli.Error
+		require.ErrorAs(t, result.Errors[id], &apiErr, id)
+		assert.Equal(t, "CANCELLED", apiErr.Code)
+	}
+}
+
+// TestCleanupSessions_Errors tests that invalid options and listing
+// failures are returned without a result.
+func TestCleanupSessions_Errors(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		mustEncode(w, map[string]string{"error": "podman unavailable"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	listResult, listErr := client.CleanupSessions(context.Background(), nil)
+	badResult, badErr := client.CleanupSessions(context.Background(), &stromboli.CleanupOptions{Limit: -1})
+
+	// Assert
+	assert.Nil(t, listResult)
+	assert.True(t, errors.Is(listErr, stromboli.ErrInternal))
+	assert.Nil(t, badResult)
+	assert.True(t, errors.Is(badErr, stromboli.ErrBadRequest))
+}
+
+// TestCancelAllJobs tests that only running and pending jobs are cancelled,
+// that jobs finished in the meantime are not errors and that failures are
+// keyed by job ID.
+func TestCancelAllJobs(t *testing.T) {
+	// Arrange
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			mustEncode(w, map[string]interface{}{"jobs": []map[string]interface{}{
+				{"id": "job-running", "status": "running"},
+				{"id": "job-pending", "status": "pending"},
+				{"id": "job-done", "status": "completed"},
+				{"id": "job-finished", "status": "running"},
+				{"id": "job-broken", "status": "running"},
+			}})
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+		mu.Lock()
+		deleted = append(deleted, id)
+		mu.Unlock()
+		switch id {
+		case "job-finished":
+			w.WriteHeader(http.StatusConflict)
+			mustEncode(w, map[string]string{"error": "job already completed"})
+		case "job-broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			mustEncode(w, map[string]string{"error": "podman unavailable"})
+		default:
+			mustEncode(w, map[string]interface{}{"success": true})
+		}
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	cancelled, errs := client.CancelAllJobs(context.Background())
+
+	// Assert
+	assert.Equal(t, 2, cancelled)
+	require.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs["job-broken"], stromboli.ErrInternal))
+	assert.ElementsMatch(t, []string{"job-running", "job-pending", "job-finished", "job-broken"}, deleted)
+}
+
+// TestCancelAllJobs_ListFails tests that a listing failure is returned under
+// the empty key.
+func TestCancelAllJobs_ListFails(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		mustEncode(w, map[string]string{"error": "podman unavailable"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	cancelled, errs := client.CancelAllJobs(context.Background())
+
+	// Assert
+	assert.Zero(t, cancelled)
+	require.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[""], stromboli.ErrInternal))
+}
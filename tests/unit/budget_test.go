@@ -0,0 +1,207 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// newBudgetServer returns a server answering runs with a completed run
+// costing cost USD (no usage if cost is negative), and records the
+// max_budget_usd of each run.
+func newBudgetServer(t *testing.T, cost float64) (*httptest.Server, *[]float64) {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		budgets []float64
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Claude struct {
+				MaxBudgetUSD float64 `json:"max_budget_usd"`
+			} `json:"claude"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		budgets = append(budgets, body.Claude.MaxBudgetUSD)
+		mu.Unlock()
+
+		resp := map[string]interface{}{"id": "run-1", "status": "completed", "output": "done"}
+		if cost >= 0 {
+			resp["usage"] = map[string]interface{}{"total_cost_usd": cost}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, resp)
+	}))
+	t.Cleanup(server.Close)
+	return server, &budgets
+}
+
+// budgetRun returns a run request with a MaxBudgetUSD of maxCost.
+func budgetRun(maxCost float64) *stromboli.RunRequest {
+	return &stromboli.RunRequest{Prompt: "Hi", Claude: &stromboli.ClaudeOptions{MaxBudgetUSD: maxCost}}
+}
+
+// TestRunWithBudget tests that runs are charged their reported cost and
+// refused once their MaxBudgetUSD no longer fits in the budget.
+func TestRunWithBudget(t *testing.T) {
+	// Arrange
+	server, budgets := newBudgetServer(t, 0.75)
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	bt := stromboli.NewBudgetTracker(2.0)
+	ctx := context.Background()
+
+	// Act
+	_, firstErr := client.RunWithBudget(ctx, budgetRun(1.0), bt)
+	_, secondErr := client.RunWithBudget(ctx, budgetRun(1.0), bt)
+	_, refusedErr := client.RunWithBudget(ctx, budgetRun(1.0), bt)
+
+	// Assert
+	require.NoError(t, firstErr)
+	require.NoError(t, secondErr)
+	assert.ErrorIs(t, refusedErr, stromboli.ErrBudgetExceeded)
+	assert.InDelta(t, 1.5, bt.Spent(), 1e-9)
+	assert.InDelta(t, 0.5, bt.Remaining(), 1e-9)
+	assert.Len(t, *budgets, 2, "the refused run is not sent")
+}
+
+// TestRunWithBudget_UnreportedCost tests that a run whose cost isn't
+// reported is charged its MaxBudgetUSD.
+func TestRunWithBudget_UnreportedCost(t *testing.T) {
+	// Arrange
+	server, _ := newBudgetServer(t, -1)
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	bt := stromboli.NewBudgetTracker(2.0)
+
+	// Act
+	_, err = client.RunWithBudget(context.Background(), budgetRun(1.5), bt)
+
+	// Assert
+	require.NoError(t, err)
+	assert.InDelta(t, 1.5, bt.Spent(), 1e-9)
+}
+
+// TestRunWithBudget_NoMaxBudget tests that a run without MaxBudgetUSD is
+// refused without sending a request, unless the client's default Claude
+// options set one.
+func TestRunWithBudget_NoMaxBudget(t *testing.T) {
+	// Arrange
+	server, budgets := newBudgetServer(t, 0.25)
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	defaulted, err := stromboli.NewClient(server.URL,
+		stromboli.WithDefaultClaudeOptions(&stromboli.ClaudeOptions{MaxBudgetUSD: 0.5}))
+	require.NoError(t, err)
+	bt := stromboli.NewBudgetTracker(2.0)
+	req := &stromboli.RunRequest{Prompt: "Hi"}
+
+	// Act
+	_, refusedErr := client.RunWithBudget(context.Background(), req, bt)
+	_, defaultedErr := defaulted.RunWithBudget(context.Background(), req, bt)
+
+	// Assert
+	assert.ErrorIs(t, refusedErr, stromboli.ErrBadRequest)
+	require.NoError(t, defaultedErr)
+	assert.Nil(t, req.Claude)
+	require.Len(t, *budgets, 1)
+	assert.InDelta(t, 0.5, (*budgets)[0], 1e-9)
+	assert.InDelta(t, 0.25, bt.Spent(), 1e-9)
+}
+
+// TestRunWithBudget_Concurrent tests that concurrent runs can't overspend
+// the budget together.
+func TestRunWithBudget_Concurrent(t *testing.T) {
+	// Arrange
+	release := make(chan struct{})
+	var started atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed", "usage": map[string]interface{}{"total_cost_usd": 1.0}})
+	}))
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	bt := stromboli.NewBudgetTracker(3.0)
+
+	// Act
+	var (
+		wg       sync.WaitGroup
+		refused  atomic.Int32
+		accepted atomic.Int32
+	)
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.RunWithBudget(context.Background(), budgetRun(1.0), bt)
+			if err != nil {
+				assert.ErrorIs(t, err, stromboli.ErrBudgetExceeded)
+				refused.Add(1)
+				return
+			}
+			accepted.Add(1)
+		}()
+	}
+	require.Eventually(t, func() bool { return started.Load()+refused.Load() == 5 }, 5*time.Second, 5*time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, int32(3), accepted.Load())
+	assert.Equal(t, int32(2), refused.Load())
+	assert.InDelta(t, 3.0, bt.Spent(), 1e-9)
+}
+
+// TestRunWithBudget_Invalid tests that a nil tracker and a negative
+// MaxBudgetUSD are rejected.
+func TestRunWithBudget_Invalid(t *testing.T) {
+	// Arrange
+	client, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, nilErr := client.RunWithBudget(ctx, budgetRun(1.0), nil)
+	_, negativeErr := client.RunWithBudget(ctx, budgetRun(-1.0), stromboli.NewBudgetTracker(1.0))
+
+	// Assert
+	assert.ErrorIs(t, nilErr, stromboli.ErrBadRequest)
+	assert.ErrorIs(t, negativeErr, stromboli.ErrBadRequest)
+}
+
+// TestRunWithBudget_Observed tests that a run with a budget is reported as
+// one RunWithBudget operation, including refused runs.
+func TestRunWithBudget_Observed(t *testing.T) {
+	// Arrange
+	server, _ := newBudgetServer(t, 0.25)
+	obs := &recordingObserver{}
+	client, err := stromboli.NewClient(server.URL, stromboli.WithObserver(obs))
+	require.NoError(t, err)
+	bt := stromboli.NewBudgetTracker(1.0)
+
+	// Act
+	_, runErr := client.RunWithBudget(context.Background(), budgetRun(1.0), bt)
+	_, refusedErr := client.RunWithBudget(context.Background(), budgetRun(1.0), bt)
+
+	// Assert
+	require.NoError(t, runErr)
+	assert.ErrorIs(t, refusedErr, stromboli.ErrBudgetExceeded)
+	assert.Equal(t, []string{"RunWithBudget", "RunWithBudget"}, obs.started)
+	require.Len(t, obs.finished, 2)
+	assert.Equal(t, 200, obs.finished[0].status)
+	assert.Equal(t, refusedErr, obs.finished[1].err)
+}
@@ -0,0 +1,201 @@
+package unit
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// bundleServer serves the endpoints collected in support bundles and a
+// streaming endpoint, with a fixed Date header.
+func bundleServer(date time.Time) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", date.Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/health":
+			mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok", "version": "0.4.0-alpha"})
+		case "/claude/status":
+			mustEncode(w, map[string]interface{}{"configured": true, "message": "ready"})
+		case "/capabilities":
+			mustEncode(w, map[string]interface{}{"version": "0.4.0-alpha", "supports_job_events": true})
+		case "/run/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, "data: hi\n\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// readBundle collects a support bundle and returns its files by name.
+func readBundle(t *testing.T, client *stromboli.Client, opts *stromboli.BundleOptions) (map[string][]byte, []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, client.CollectSupportBundle(context.Background(), &buf, opts))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		_ = rc.Close()
+		files[f.Name] = data
+	}
+	return files, buf.Bytes()
+}
+
+// TestCollectSupportBundle_Contents tests the files of a support bundle.
+func TestCollectSupportBundle_Contents(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	server := bundleServer(now.Add(90 * time.Second))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithClock(strombolitest.NewFakeClock(now)),
+	)
+	require.NoError(t, err)
+
+	// Act
+	files, _ := readBundle(t, client, nil)
+
+	// Assert
+	assert.ElementsMatch(t, []string{
+		"sdk_version.json", "health.json", "claude_status.json", "capabilities.json", "clock_skew.json",
+	}, keys(files))
+
+	var version map[string]string
+	require.NoError(t, json.Unmarshal(files["sdk_version.json"], &version))
+	assert.Equal(t, stromboli.Version, version["sdk_version"])
+	assert.Equal(t, stromboli.APIVersion, version["api_version"])
+
+	var health map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["health.json"], &health))
+	assert.Equal(t, "0.4.0-alpha", health["version"])
+
+	var caps map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["capabilities.json"], &caps))
+	assert.Equal(t, true, caps["supports_job_events"])
+
+	var skew map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["clock_skew.json"], &skew))
+	assert.Equal(t, float64(90), skew["skew_seconds"])
+}
+
+// TestCollectSupportBundle_Failures tests that collection failures are
+// recorded instead of aborting the bundle.
+func TestCollectSupportBundle_Failures(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	files, _ := readBundle(t, client, nil)
+
+	// Assert
+	require.Contains(t, files, "sdk_version.json")
+	require.Contains(t, files, "errors.json")
+	assert.NotContains(t, files, "health.json")
+
+	var failures map[string]string
+	require.NoError(t, json.Unmarshal(files["errors.json"], &failures))
+	assert.Contains(t, failures, "health.json")
+	assert.Contains(t, failures, "claude_status.json")
+	assert.Contains(t, failures, "capabilities.json")
+}
+
+// TestCollectSupportBundle_Redaction tests that prompts and tokens are
+// redacted from request summaries by default.
+func TestCollectSupportBundle_Redaction(t *testing.T) {
+	// Arrange
+	server := bundleServer(time.Now())
+	defer server.Close()
+
+	client, err := stromboli.NewClient("http://user:hunter2@"+server.Listener.Addr().String(),
+		stromboli.WithDiagnosticsBuffer(10),
+		stromboli.WithToken("secret-token"),
+	)
+	require.NoError(t, err)
+
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "my private prompt"})
+	require.NoError(t, err)
+	_ = stream.Close()
+
+	// Act
+	files, raw := readBundle(t, client, nil)
+	unredacted, _ := readBundle(t, client, &stromboli.BundleOptions{Unredacted: true})
+
+	// Assert
+	require.Contains(t, files, "requests.json")
+	assert.Contains(t, string(files["requests.json"]), "/run/stream")
+	assert.Contains(t, string(files["requests.json"]), "REDACTED")
+	for name, data := range files {
+		assert.NotContains(t, string(data), "private", name)
+		assert.NotContains(t, string(data), "secret-token", name)
+		assert.NotContains(t, string(data), "hunter2", name)
+	}
+	assert.NotContains(t, string(raw), "secret-token")
+
+	assert.Contains(t, string(unredacted["requests.json"]), "private")
+	assert.NotContains(t, string(unredacted["requests.json"]), "secret-token")
+}
+
+// TestCollectSupportBundle_BufferBounded tests that only the last n
+// requests are kept.
+func TestCollectSupportBundle_BufferBounded(t *testing.T) {
+	// Arrange
+	server := bundleServer(time.Now())
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithDiagnosticsBuffer(5))
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err := client.GetJob(context.Background(), fmt.Sprintf("job-%d", i))
+		require.Error(t, err)
+	}
+
+	// Act
+	files, _ := readBundle(t, client, nil)
+
+	// Assert: the bundle's own requests are recorded before the buffer is read
+	var entries []map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["requests.json"], &entries))
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		paths = append(paths, entry["path"].(string))
+	}
+	assert.Equal(t, []string{"/jobs/job-3", "/jobs/job-4", "/health", "/claude/status", "/capabilities"}, paths)
+	assert.Equal(t, float64(http.StatusNotFound), entries[0]["status"])
+}
+
+// keys returns the keys of m.
+func keys(m map[string][]byte) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
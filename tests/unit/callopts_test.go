@@ -0,0 +1,162 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// TestWithCallTimeout tests that the call timeout replaces the client
+// timeout, whether it is shorter or longer.
+func TestWithCallTimeout(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok", "version": "0.3.0"})
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	shortClient, err := stromboli.NewClient(server.URL, stromboli.WithTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+	longClient, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	_, defaultErr := shortClient.Health(ctx)
+	_, longerErr := shortClient.Health(ctx, stromboli.WithCallTimeout(5*time.Second))
+	_, shorterErr := longClient.Health(ctx, stromboli.WithCallTimeout(50*time.Millisecond))
+	pingErr := longClient.Ping(ctx, stromboli.WithCallTimeout(50*time.Millisecond))
+
+	// Assert
+	assert.ErrorIs(t, defaultErr, stromboli.ErrTimeout)
+	assert.NoError(t, longerErr, "the call timeout overrides the client timeout")
+	assert.ErrorIs(t, shorterErr, stromboli.ErrTimeout)
+	assert.Error(t, pingErr)
+}
+
+// TestWithCallTimeout_Stream tests that the call timeout limits a stream.
+func TestWithCallTimeout_Stream(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Hi"},
+		stromboli.WithCallTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	defer stream.Close()
+	start := time.Now()
+	for stream.Next() {
+	}
+
+	// Assert
+	assert.Error(t, stream.Err())
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+// TestWithCallHeader tests that call headers and tokens reach the wire for
+// generated and raw requests, and only for the call they are given to.
+func TestWithCallHeader(t *testing.T) {
+	// Arrange
+	var mu sync.Mutex
+	headers := map[string]http.Header{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers[r.Method+" "+r.URL.Path] = r.Header.Clone()
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/run":
+			w.Header().Set("Content-Type", "application/json")
+			mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed", "output": "done"})
+		case "/run/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: Hello\n\n"))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok", "version": "0.3.0"})
+		}
+	}))
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL, stromboli.WithToken("client-token"))
+	require.NoError(t, err)
+	ctx := context.Background()
+	opts := []stromboli.CallOption{
+		stromboli.WithCallHeader("x-tenant", "acme"),
+		stromboli.WithCallToken("tenant-token"),
+	}
+
+	// Act
+	_, runErr := client.Run(ctx, &stromboli.RunRequest{Prompt: "Hi"}, opts...)
+	pingErr := client.Ping(ctx, opts...)
+	stream, streamErr := client.Stream(ctx, &stromboli.StreamRequest{Prompt: "Hi"}, opts...)
+	require.NoError(t, streamErr)
+	_ = stream.Close()
+	_, healthErr := client.Health(ctx)
+
+	// Assert
+	require.NoError(t, runErr)
+	require.NoError(t, pingErr)
+	require.NoError(t, healthErr)
+	mu.Lock()
+	defer mu.Unlock()
+	for _, key := range []string{"POST /run", "GET /run/stream"} {
+		require.Contains(t, headers, key)
+		assert.Equal(t, "acme", headers[key].Get("X-Tenant"), key)
+		assert.Equal(t, "Bearer tenant-token", headers[key].Get("Authorization"), key)
+	}
+	assert.Empty(t, headers["GET /health"].Get("X-Tenant"), "the last health check has no call options")
+	assert.Empty(t, headers["GET /health"].Get("Authorization"))
+}
+
+// TestWithCallHeader_Invalid tests that invalid call headers are ignored
+// with a warning.
+func TestWithCallHeader_Invalid(t *testing.T) {
+	// Arrange
+	logger := &captureLogger{}
+	stromboli.SetLogger(logger)
+	defer stromboli.SetLogger(nil)
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok", "version": "0.3.0"})
+	}))
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Health(context.Background(),
+		stromboli.WithCallHeader("X Bad", "value"),
+		stromboli.WithCallHeader("X-Injected", "a\r\nX-Evil: 1"),
+		stromboli.WithCallHeader("X-Good", "ok"),
+	)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "ok", got.Get("X-Good"))
+	assert.Empty(t, got.Get("X-Injected"))
+	assert.Empty(t, got.Get("X-Evil"))
+	assert.Len(t, logger.Messages(), 2)
+}
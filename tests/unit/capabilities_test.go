@@ -0,0 +1,184 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// TestCapabilities_Present tests reading and caching server capabilities.
+func TestCapabilities_Present(t *testing.T) {
+	// Arrange
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/capabilities", r.URL.Path)
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{
+			"version":             "0.4.0-alpha",
+			"supports_ndjson":     true,
+			"supports_cursors":    true,
+			"supports_job_events": false,
+			"max_prompt_bytes":    2048,
+		})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	caps, err := client.Capabilities(context.Background())
+	require.NoError(t, err)
+	again, err := client.Capabilities(context.Background())
+	require.NoError(t, err)
+
+	// Assert
+	assert.True(t, caps.Discovered)
+	assert.True(t, caps.SupportsNDJSON)
+	assert.True(t, caps.SupportsCursors)
+	assert.False(t, caps.SupportsJobEvents)
+	assert.Equal(t, int64(2048), caps.MaxPromptBytes)
+	assert.Equal(t, caps, again)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "second call should be served from cache")
+}
+
+// TestCapabilities_Absent tests the conservative defaults for servers
+// without a capabilities endpoint.
+func TestCapabilities_Absent(t *testing.T) {
+	// Arrange
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	caps, err := client.Capabilities(context.Background())
+	require.NoError(t, err)
+	_, err = client.Capabilities(context.Background())
+	require.NoError(t, err)
+
+	// Assert
+	assert.False(t, caps.Discovered)
+	assert.False(t, caps.SupportsNDJSON)
+	assert.False(t, caps.SupportsCursors)
+	assert.False(t, caps.SupportsJobEvents)
+	assert.False(t, caps.SupportsBulkDelete)
+	assert.Positive(t, caps.MaxPromptBytes)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "absent endpoint should be cached too")
+}
+
+// TestCapabilities_InvalidatedOnVersionChange tests that a server version
+// change observed via Health drops the cached capabilities.
+func TestCapabilities_InvalidatedOnVersionChange(t *testing.T) {
+	// Arrange
+	var version atomic.Value
+	version.Store("0.4.0-alpha")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		v := version.Load().(string)
+		switch r.URL.Path {
+		case "/health":
+			mustEncode(w, map[string]interface{}{
+				"name": "stromboli", "status": "ok", "version": v,
+			})
+		case "/capabilities":
+			mustEncode(w, map[string]interface{}{
+				"version":          v,
+				"supports_cursors": v != "0.4.0-alpha",
+			})
+		}
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = client.Health(ctx)
+	require.NoError(t, err)
+	caps, err := client.Capabilities(ctx)
+	require.NoError(t, err)
+	require.False(t, caps.SupportsCursors)
+
+	// Act: server is upgraded
+	version.Store("0.4.1-alpha")
+	_, err = client.Health(ctx)
+	require.NoError(t, err)
+	caps, err = client.Capabilities(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "0.4.1-alpha", caps.Version)
+	assert.True(t, caps.SupportsCursors)
+}
+
+// TestCapabilities_ServerError tests that unexpected failures are returned.
+func TestCapabilities_ServerError(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	caps, err := client.Capabilities(context.Background())
+
+	// Assert
+	require.Error(t, err)
+	assert.Nil(t, caps)
+	assert.ErrorIs(t, err, stromboli.ErrInternal)
+}
+
+// TestCapabilities_TTLExpiry tests that cached capabilities are refetched
+// once the cache TTL has elapsed.
+func TestCapabilities_TTLExpiry(t *testing.T) {
+	// Arrange
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"version": "0.4.0-alpha"})
+	}))
+	defer server.Close()
+
+	clock := strombolitest.NewFakeClock(time.Now())
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClock(clock))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = client.Capabilities(ctx)
+	require.NoError(t, err)
+
+	// Act
+	clock.Advance(4 * time.Minute)
+	_, err = client.Capabilities(ctx)
+	require.NoError(t, err)
+	cachedCalls := atomic.LoadInt32(&calls)
+
+	clock.Advance(2 * time.Minute)
+	_, err = client.Capabilities(ctx)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, int32(1), cachedCalls, "capabilities should be cached within the TTL")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "capabilities should be refetched after the TTL")
+}
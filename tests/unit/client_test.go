@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
 )
 
 // mustEncode encodes v as JSON and writes it to w.
@@ -130,6 +133,134 @@ func TestHealth_ServerError(t *testing.T) {
 	assert.Equal(t, "INTERNAL", apiErr.Code)
 }
 
+// TestHealth_StructuredError tests that the server's error code and message
+// are propagated from a JSON error body.
+func TestHealth_StructuredError(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		mustEncode(w, map[string]string{
+			"error":  "image not allowed by policy",
+			"code":   "IMAGE_NOT_ALLOWED",
+			"detail": "registry evil.example.com is blocked",
+		})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Health(context.Background())
+
+	// Assert
+	var apiErr *stromboli.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "IMAGE_NOT_ALLOWED", apiErr.Code)
+	assert.Equal(t, "image not allowed by policy: registry evil.example.com is blocked", apiErr.Message)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status)
+	assert.True(t, errors.Is(err, &stromboli.Error{Code: "FORBIDDEN"}), "status-based code should stay in the chain")
+}
+
+// TestHealth_StructuredErrorWithoutCode tests that a JSON error body without
+// a code keeps the status-based code and uses the server's message.
+func TestHealth_StructuredErrorWithoutCode(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		mustEncode(w, map[string]string{"error": "podman unavailable"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Health(context.Background())
+
+	// Assert
+	var apiErr *stromboli.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "INTERNAL", apiErr.Code)
+	assert.Equal(t, "podman unavailable", apiErr.Message)
+	assert.ErrorIs(t, err, stromboli.ErrInternal)
+}
+
+// TestHealth_PlainTextError tests the fallback for non-JSON error bodies.
+func TestHealth_PlainTextError(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("upstream connect error"))
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Health(context.Background())
+
+	// Assert
+	var apiErr *stromboli.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "INTERNAL", apiErr.Code)
+	assert.Equal(t, http.StatusBadGateway, apiErr.Status)
+}
+
+// TestGetJob_ServerMessage tests that the server's message is used for
+// error responses declared in the API spec.
+func TestGetJob_ServerMessage(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		mustEncode(w, map[string]string{"error": "job expired"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.GetJob(context.Background(), "job-1")
+
+	// Assert
+	var apiErr *stromboli.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.ErrorIs(t, err, stromboli.ErrNotFound)
+	assert.Equal(t, "job expired", apiErr.Message)
+}
+
+// TestGetJob_TypedErrorStatus tests that the generated client's typed error
+// responses (here GetJobsIDNotFound) are mapped from their status when the
+// body isn't a structured error.
+func TestGetJob_TypedErrorStatus(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.GetJob(context.Background(), "job-1")
+
+	// Assert
+	var apiErr *stromboli.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.ErrorIs(t, err, stromboli.ErrNotFound)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+	assert.Equal(t, "failed to get job", apiErr.Message)
+}
+
 // TestHealth_ContextCancellation tests that context cancellation is handled correctly.
 func TestHealth_ContextCancellation(t *testing.T) {
 	// Arrange: Create a server that delays response
@@ -152,6 +283,79 @@ func TestHealth_ContextCancellation(t *testing.T) {
 	assert.Nil(t, health)
 }
 
+// TestPing_Success tests that Ping accepts any 2xx response without decoding it.
+func TestPing_Success(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"ok with json", http.StatusOK, `{"status":"ok"}`},
+		{"ok with text", http.StatusOK, "pong"},
+		{"no content", http.StatusNoContent, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/health", r.URL.Path)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			// Act
+			client, err := stromboli.NewClient(server.URL)
+			require.NoError(t, err)
+			err = client.Ping(context.Background())
+
+			// Assert
+			require.NoError(t, err)
+		})
+	}
+}
+
+// TestPing_Unavailable tests that Ping maps non-2xx responses to typed errors.
+func TestPing_Unavailable(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// Act
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	err = client.Ping(context.Background())
+
+	// Assert
+	require.Error(t, err)
+	assert.ErrorIs(t, err, stromboli.ErrUnavailable)
+}
+
+// TestPing_ContextDeadline tests that Ping honors the context deadline.
+func TestPing_ContextDeadline(t *testing.T) {
+	// Arrange: a server that never answers
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Act
+	client, err := stromboli.NewClient(server.URL, stromboli.WithTimeout(time.Hour))
+	require.NoError(t, err)
+	start := time.Now()
+	err = client.Ping(ctx)
+
+	// Assert
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
 // TestClaudeStatus_Configured tests ClaudeStatus when Claude is configured.
 func TestClaudeStatus_Configured(t *testing.T) {
 	// Arrange
@@ -307,6 +511,101 @@ func TestClient_ConcurrentTokenAccess(t *testing.T) {
 	// Test passes if no race detected (run with -race flag)
 }
 
+// TestClient_Clone tests that a clone keeps the configuration, applies its
+// overrides, and doesn't share the token or hooks with the original.
+func TestClient_Clone(t *testing.T) {
+	// Arrange
+	type seen struct{ userAgent, auth, hook string }
+	var got seen
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = seen{r.Header.Get("User-Agent"), r.Header.Get("Authorization"), r.Header.Get("X-Hook")}
+		mustEncode(w, map[string]interface{}{"id": "job-1", "status": "running"})
+	}))
+	defer server.Close()
+
+	original, err := stromboli.NewClient(server.URL+"/api",
+		stromboli.WithToken("token-a"),
+		stromboli.WithUserAgent("app/1.0"),
+		stromboli.WithRequestHook(func(req *http.Request) { req.Header.Set("X-Hook", "original") }),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+	var job stromboli.Job
+
+	// Act
+	clone := original.Clone(stromboli.WithUserAgent("app-stream/1.0"))
+
+	// Assert
+	err = clone.GetJobInto(ctx, "job-1", &job)
+	require.NoError(t, err)
+	assert.Equal(t, seen{"app-stream/1.0", "Bearer token-a", "original"}, got)
+
+	// Token and hooks are independent after cloning
+	clone.SetToken("token-b")
+	clone.AddRequestHook(func(req *http.Request) { req.Header.Set("X-Hook", "clone") })
+
+	err = original.GetJobInto(ctx, "job-1", &job)
+	require.NoError(t, err)
+	assert.Equal(t, seen{"app/1.0", "Bearer token-a", "original"}, got)
+
+	err = clone.GetJobInto(ctx, "job-1", &job)
+	require.NoError(t, err)
+	assert.Equal(t, seen{"app-stream/1.0", "Bearer token-b", "clone"}, got)
+}
+
+// TestClient_CloneTimeout tests that a clone can override the timeout
+// without affecting the original.
+func TestClient_CloneTimeout(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok"})
+	}))
+	defer server.Close()
+
+	original, err := stromboli.NewClient(server.URL, stromboli.WithTimeout(5*time.Second))
+	require.NoError(t, err)
+
+	// Act
+	clone := original.Clone(stromboli.WithTimeout(10 * time.Millisecond))
+	_, cloneErr := clone.Health(context.Background())
+	_, originalErr := original.Health(context.Background())
+
+	// Assert
+	assert.True(t, errors.Is(cloneErr, stromboli.ErrTimeout))
+	assert.NoError(t, originalErr)
+}
+
+// TestClient_CloneDiagnostics tests that a clone shares the diagnostics
+// buffer without recording its requests twice.
+func TestClient_CloneDiagnostics(t *testing.T) {
+	// Arrange
+	server := bundleServer(time.Now())
+	defer server.Close()
+
+	original, err := stromboli.NewClient(server.URL, stromboli.WithDiagnosticsBuffer(10))
+	require.NoError(t, err)
+	clone := original.Clone()
+
+	// Act
+	_, err = original.GetJob(context.Background(), "job-a")
+	require.Error(t, err)
+	_, err = clone.GetJob(context.Background(), "job-b")
+	require.Error(t, err)
+
+	// Assert
+	files, _ := readBundle(t, original, nil)
+	var entries []map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["requests.json"], &entries))
+	require.GreaterOrEqual(t, len(entries), 2)
+	assert.Equal(t, "/jobs/job-a", entries[0]["path"])
+	assert.Equal(t, "/jobs/job-b", entries[1]["path"])
+	for _, entry := range entries[2:] {
+		assert.NotContains(t, entry["path"], "/jobs/")
+	}
+}
+
 // TestError_Is tests the Error.Is implementation.
 func TestError_Is(t *testing.T) {
 	tests := []struct {
@@ -415,6 +714,135 @@ func TestRun_Success(t *testing.T) {
 	assert.True(t, result.IsSuccess())
 }
 
+// TestRun_UnknownStatus tests that statuses the SDK doesn't know are
+// reported as "unknown", with the server's value in RawStatus.
+func TestRun_UnknownStatus(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"id": "run-abc123", "status": "success"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	result, err := client.Run(context.Background(), &stromboli.RunRequest{Prompt: "Hello"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, stromboli.RunStatusUnknown, result.Status)
+	assert.Equal(t, "success", result.RawStatus)
+	assert.Equal(t, stromboli.RunStateUnknown, result.State())
+	assert.False(t, result.IsSuccess())
+}
+
+// TestRun_Timestamps tests that Run stamps when the request was sent and
+// the response received, and that they carry over to AsJob.
+func TestRun_Timestamps(t *testing.T) {
+	// Arrange
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	clock := strombolitest.NewFakeClock(start)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock.Advance(90 * time.Second)
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"id": "run-abc123", "status": "completed", "output": "Hi"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClock(clock))
+	require.NoError(t, err)
+
+	// Act
+	result, err := client.Run(context.Background(), &stromboli.RunRequest{Prompt: "Hello"})
+	dryRun, dryRunErr := client.Run(context.Background(), &stromboli.RunRequest{Prompt: "Hello", DryRun: true})
+
+	// Assert
+	require.NoError(t, err)
+	require.NoError(t, dryRunErr)
+	assert.Equal(t, start, result.StartedAt)
+	assert.Equal(t, start.Add(90*time.Second), result.CompletedAt)
+	d, ok := result.AsJob().Duration()
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Second, d)
+	assert.True(t, dryRun.StartedAt.IsZero())
+	assert.True(t, dryRun.CompletedAt.IsZero())
+}
+
+// TestRun_Usage tests that reported usage is returned, and that a missing
+// usage is nil rather than zero.
+func TestRun_Usage(t *testing.T) {
+	tests := []struct {
+		name      string
+		usage     interface{}
+		wantUsage *stromboli.Usage
+	}{
+		{
+			name: "reported",
+			usage: map[string]interface{}{
+				"input_tokens":                1200,
+				"output_tokens":               350,
+				"cache_creation_input_tokens": 100,
+				"cache_read_input_tokens":     800,
+				"total_cost_usd":              0.0123,
+				"num_turns":                   3,
+				"duration_ms":                 4200,
+			},
+			wantUsage: &stromboli.Usage{
+				InputTokens:              1200,
+				OutputTokens:             350,
+				CacheCreationInputTokens: 100,
+				CacheReadInputTokens:     800,
+				TotalCostUSD:             0.0123,
+				NumTurns:                 3,
+				DurationMS:               4200,
+			},
+		},
+		{
+			name:      "free",
+			usage:     map[string]interface{}{"total_cost_usd": 0},
+			wantUsage: &stromboli.Usage{},
+		},
+		{
+			name:      "not reported",
+			usage:     nil,
+			wantUsage: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp := map[string]interface{}{"id": "run-abc123", "status": "completed", "output": "done"}
+				if tt.usage != nil {
+					resp["usage"] = tt.usage
+				}
+				w.Header().Set("Content-Type", "application/json")
+				mustEncode(w, resp)
+			}))
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL)
+			require.NoError(t, err)
+
+			// Act
+			result, err := client.Run(context.Background(), &stromboli.RunRequest{Prompt: "Hello"})
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, "done", result.Output)
+			assert.Equal(t, tt.wantUsage, result.Usage)
+			cost, ok := result.CostUSD()
+			assert.Equal(t, tt.wantUsage != nil, ok)
+			if ok {
+				assert.Equal(t, tt.wantUsage.TotalCostUSD, cost)
+			}
+		})
+	}
+}
+
 // TestRun_WithOptions tests Run with Claude and Podman options.
 func TestRun_WithOptions(t *testing.T) {
 	// Arrange
@@ -969,17 +1397,238 @@ func TestListJobs_Empty(t *testing.T) {
 	assert.Empty(t, jobs)
 }
 
-// TestGetJob_Success tests the GetJob method with a completed job.
-func TestGetJob_Success(t *testing.T) {
+// TestListJobsFiltered_ServerSide tests that filters are sent as query
+// parameters and the result of a server supporting cursors is returned
+// as-is.
+func TestListJobsFiltered_ServerSide(t *testing.T) {
 	// Arrange
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Verify request
-		assert.Equal(t, "/jobs/job-abc123", r.URL.Path)
-		assert.Equal(t, http.MethodGet, r.Method)
+		if r.URL.Path == "/capabilities" {
+			w.Header().Set("Content-Type", "application/json")
+			mustEncode(w, map[string]interface{}{"supports_cursors": true})
+			return
+		}
+		assert.Equal(t, "/jobs", r.URL.Path)
+		assert.Equal(t, "running,pending", r.URL.Query().Get("status"))
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+		assert.Equal(t, "4", r.URL.Query().Get("offset"))
 
-		// Return mock response
-		resp := map[string]interface{}{
-			"id":         "job-abc123",
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{
+			"jobs": []map[string]interface{}{
+				{"id": "job-5", "status": "running"},
+				{"id": "job-6", "status": "pending"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	jobs, err := client.ListJobsFiltered(context.Background(), &stromboli.ListJobsOptions{
+		Status: []string{stromboli.JobStatusRunning, stromboli.JobStatusPending},
+		Limit:  2,
+		Offset: 4,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "job-5", jobs[0].ID)
+	assert.Equal(t, "job-6", jobs[1].ID)
+}
+
+// TestListJobsFiltered_ClientSideFallback tests that filtering and
+// pagination are applied client-side when the server ignores them.
+func TestListJobsFiltered_ClientSideFallback(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jobs" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{
+			"jobs": []map[string]interface{}{
+				{"id": "job-1", "status": "completed"},
+				{"id": "job-2", "status": "failed"},
+				{"id": "job-3", "status": "completed"},
+				{"id": "job-4", "status": "running"},
+				{"id": "job-5", "status": "failed"},
+				{"id": "job-6", "status": "failed"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	jobs, err := client.ListJobsFiltered(context.Background(), &stromboli.ListJobsOptions{
+		Status: []string{stromboli.JobStatusFailed},
+		Limit:  1,
+		Offset: 1,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "job-5", jobs[0].ID)
+}
+
+// TestListJobsFiltered_ServerIgnoresOffset tests that an unpaginated
+// response is paginated client-side even when the status filter was
+// honored and no limit was set.
+func TestListJobsFiltered_ServerIgnoresOffset(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jobs" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{
+			"jobs": []map[string]interface{}{
+				{"id": "job-2", "status": "failed"},
+				{"id": "job-5", "status": "failed"},
+				{"id": "job-6", "status": "failed"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	logger, buf := newDebugLogger()
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSlog(logger))
+	require.NoError(t, err)
+
+	// Act
+	jobs, err := client.ListJobsFiltered(context.Background(), &stromboli.ListJobsOptions{
+		Status: []string{stromboli.JobStatusFailed},
+		Offset: 2,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "job-6", jobs[0].ID)
+	assert.Contains(t, buf.String(), "paginating client-side")
+}
+
+// TestListJobsFiltered_ServerIgnoresStatus tests that a page from a server
+// that paginated but ignored the status filter is filtered without
+// applying the offset a second time.
+func TestListJobsFiltered_ServerIgnoresStatus(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jobs" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{
+			"jobs": []map[string]interface{}{
+				{"id": "job-3", "status": "completed"},
+				{"id": "job-4", "status": "failed"},
+				{"id": "job-5", "status": "failed"},
+			},
+			"total":  6,
+			"offset": 2,
+		})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	jobs, err := client.ListJobsFiltered(context.Background(), &stromboli.ListJobsOptions{
+		Status: []string{stromboli.JobStatusFailed},
+		Limit:  3,
+		Offset: 2,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "job-4", jobs[0].ID)
+	assert.Equal(t, "job-5", jobs[1].ID)
+}
+
+// TestListJobsFiltered_InvalidStatus tests status validation.
+func TestListJobsFiltered_InvalidStatus(t *testing.T) {
+	// Arrange
+	client, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+
+	// Act
+	jobs, err := client.ListJobsFiltered(context.Background(), &stromboli.ListJobsOptions{
+		Status: []string{stromboli.JobStatusRunning, "done"},
+	})
+
+	// Assert
+	require.Error(t, err)
+	assert.Nil(t, jobs)
+	assert.ErrorIs(t, err, stromboli.ErrBadRequest)
+	assert.Contains(t, err.Error(), `"done"`)
+}
+
+// TestListJobs_Usage tests that each listed job gets its own usage, nil
+// when not reported.
+func TestListJobs_Usage(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{
+			"jobs": []interface{}{
+				map[string]interface{}{"id": "job-1", "status": "completed", "usage": map[string]interface{}{"total_cost_usd": 0.25}},
+				nil,
+				map[string]interface{}{"id": "job-2", "status": "running"},
+				map[string]interface{}{"id": "job-3", "status": "completed", "usage": map[string]interface{}{"total_cost_usd": 1.5}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	list := map[string]func() ([]*stromboli.Job, error){
+		"ListJobs": func() ([]*stromboli.Job, error) {
+			return client.ListJobs(context.Background())
+		},
+		"ListJobsFiltered": func() ([]*stromboli.Job, error) {
+			return client.ListJobsFiltered(context.Background(), &stromboli.ListJobsOptions{Limit: 10})
+		},
+	}
+	for name, fn := range list {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			jobs, err := fn()
+
+			// Assert
+			require.NoError(t, err)
+			require.Len(t, jobs, 3)
+			assert.Equal(t, &stromboli.Usage{TotalCostUSD: 0.25}, jobs[0].Usage)
+			assert.Nil(t, jobs[1].Usage)
+			assert.Equal(t, &stromboli.Usage{TotalCostUSD: 1.5}, jobs[2].Usage)
+		})
+	}
+}
+
+// TestGetJob_Success tests the GetJob method with a completed job.
+func TestGetJob_Success(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify request
+		assert.Equal(t, "/jobs/job-abc123", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		// Return mock response
+		resp := map[string]interface{}{
+			"id":         "job-abc123",
 			"status":     "completed",
 			"output":     "Hello from Claude!",
 			"session_id": "sess-xyz789",
@@ -1007,6 +1656,30 @@ func TestGetJob_Success(t *testing.T) {
 	assert.False(t, job.IsFailed())
 }
 
+// TestGetJob_Usage tests that the usage of a finished job is returned.
+func TestGetJob_Usage(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{
+			"id":     "job-abc123",
+			"status": "completed",
+			"usage":  map[string]interface{}{"input_tokens": 10, "output_tokens": 20, "total_cost_usd": 0.5, "num_turns": 1, "duration_ms": 1500},
+		})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	job, err := client.GetJob(context.Background(), "job-abc123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &stromboli.Usage{InputTokens: 10, OutputTokens: 20, TotalCostUSD: 0.5, NumTurns: 1, DurationMS: 1500}, job.Usage)
+}
+
 // TestGetJob_Failed tests GetJob with a failed job.
 func TestGetJob_Failed(t *testing.T) {
 	// Arrange
@@ -1816,6 +2489,73 @@ func TestCreateSecret_EmptyName(t *testing.T) {
 	assert.Equal(t, "BAD_REQUEST", apiErr.Code)
 }
 
+// TestSecretNameValidation tests that the secret methods check names
+// against Podman's rules before sending any request, returning
+// ErrInvalidSecretName with the violated rule.
+func TestSecretNameValidation(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		mustEncode(w, map[string]interface{}{"success": true, "id": "abc123", "name": "secret"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		secret    string
+		violation string // empty if valid
+	}{
+		{"simple", "github-token", ""},
+		{"dots and underscores", "db.password_v2", ""},
+		{"upper case", "GH_TOKEN", ""},
+		{"single character", "a", ""},
+		{"digits with letters", "2fa-seed", ""},
+		{"max length", strings.Repeat("a", 253), ""},
+		{"slash", "team/token", `contains '/'`},
+		{"space", "my token", `contains ' '`},
+		{"equals", "key=value", `contains '='`},
+		{"non-ASCII", "clé", `contains 'é'`},
+		{"too long", strings.Repeat("a", 254), "at most 253 characters"},
+		{"leading dot", ".token", "must start with a letter or digit"},
+		{"trailing dash", "token-", "must end with a letter or digit"},
+		{"digits only", "12345", "must not consist only of digits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+
+			errs := map[string]error{
+				"CreateSecret": client.CreateSecret(ctx, &stromboli.CreateSecretRequest{Name: tt.secret, Value: "v"}),
+				"DeleteSecret": client.DeleteSecret(ctx, tt.secret),
+				"UpdateSecret": client.UpdateSecret(ctx, &stromboli.CreateSecretRequest{Name: tt.secret, Value: "v"}),
+			}
+			_, errs["GetSecret"] = client.GetSecret(ctx, tt.secret)
+
+			for method, err := range errs {
+				if tt.violation == "" {
+					assert.NoError(t, err, method)
+					continue
+				}
+				require.Error(t, err, method)
+				assert.True(t, errors.Is(err, stromboli.ErrInvalidSecretName), "%s: got %v", method, err)
+				assert.Contains(t, err.Error(), tt.violation, method)
+			}
+			if tt.violation != "" {
+				assert.Zero(t, calls.Load(), "invalid names must not reach the server")
+			}
+		})
+	}
+}
+
 // TestGetSecret_Success tests the GetSecret method.
 func TestGetSecret_Success(t *testing.T) {
 	// Arrange
@@ -1905,122 +2645,408 @@ func TestDeleteSecret_EmptyName(t *testing.T) {
 	assert.Equal(t, "BAD_REQUEST", apiErr.Code)
 }
 
-// ============================================================================
-// Images Tests
-// ============================================================================
-
-// TestListImages_Success tests the ListImages method.
-func TestListImages_Success(t *testing.T) {
+// TestUpdateSecret_Success tests the atomic UpdateSecret path.
+func TestUpdateSecret_Success(t *testing.T) {
 	// Arrange
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify request
-		assert.Equal(t, "/images", r.URL.Path)
-		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/secrets/github-token", r.URL.Path)
+		assert.Equal(t, http.MethodPut, r.Method)
+
+		var req map[string]interface{}
+		mustDecode(r, &req)
+		assert.Equal(t, "ghp_new", req["value"])
 
-		// Return mock response
-		resp := map[string]interface{}{
-			"images": []map[string]interface{}{
-				{
-					"id":                 "sha256:abc123",
-					"repository":         "python",
-					"tag":                "3.12-slim",
-					"size":               125000000,
-					"compatible":         true,
-					"compatibility_rank": 2,
-				},
-				{
-					"id":                 "sha256:def456",
-					"repository":         "alpine",
-					"tag":                "latest",
-					"compatible":         false,
-					"compatibility_rank": 4,
-				},
-			},
-		}
 		w.Header().Set("Content-Type", "application/json")
-		mustEncode(w, resp)
+		mustEncode(w, map[string]interface{}{"success": true})
 	}))
 	defer server.Close()
 
 	// Act
 	client, err := stromboli.NewClient(server.URL)
 	require.NoError(t, err)
-	images, err := client.ListImages(context.Background())
+	err = client.UpdateSecret(context.Background(), &stromboli.CreateSecretRequest{
+		Name:  "github-token",
+		Value: "ghp_new",
+	})
 
 	// Assert
 	require.NoError(t, err)
-	assert.Len(t, images, 2)
-	assert.Equal(t, "python", images[0].Repository)
-	assert.Equal(t, "3.12-slim", images[0].Tag)
-	assert.True(t, images[0].Compatible)
-	assert.Equal(t, int64(2), images[0].CompatibilityRank)
-	assert.False(t, images[1].Compatible)
 }
 
-// TestListImages_Empty tests ListImages when no images exist.
-func TestListImages_Empty(t *testing.T) {
+// TestUpdateSecret_Fallback tests delete-then-create when PUT is unsupported.
+func TestUpdateSecret_Fallback(t *testing.T) {
 	// Arrange
+	var calls []string
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		resp := map[string]interface{}{
-			"images": []map[string]interface{}{},
-		}
+		calls = append(calls, r.Method+" "+r.URL.Path)
 		w.Header().Set("Content-Type", "application/json")
-		mustEncode(w, resp)
+		switch r.Method {
+		case http.MethodPut:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case http.MethodGet:
+			mustEncode(w, map[string]interface{}{"id": "abc", "name": "github-token"})
+		case http.MethodDelete:
+			mustEncode(w, map[string]interface{}{"success": true})
+		case http.MethodPost:
+			var req map[string]interface{}
+			mustDecode(r, &req)
+			assert.Equal(t, "ghp_new", req["value"])
+			w.WriteHeader(http.StatusCreated)
+			mustEncode(w, map[string]interface{}{"success": true, "name": "github-token"})
+		}
 	}))
 	defer server.Close()
 
 	// Act
 	client, err := stromboli.NewClient(server.URL)
 	require.NoError(t, err)
-	images, err := client.ListImages(context.Background())
+	err = client.UpdateSecret(context.Background(), &stromboli.CreateSecretRequest{
+		Name:  "github-token",
+		Value: "ghp_new",
+	})
 
 	// Assert
 	require.NoError(t, err)
-	assert.Empty(t, images)
+	assert.Equal(t, []string{
+		"PUT /secrets/github-token",
+		"GET /secrets/github-token",
+		"DELETE /secrets/github-token",
+		"POST /secrets",
+	}, calls)
 }
 
-// TestGetImage_Success tests the GetImage method.
-func TestGetImage_Success(t *testing.T) {
+// TestUpdateSecret_NotFound tests that a missing secret maps to ErrNotFound.
+func TestUpdateSecret_NotFound(t *testing.T) {
 	// Arrange
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Verify request
-		assert.Equal(t, "/images/python:3.12", r.URL.Path)
-		assert.Equal(t, http.MethodGet, r.Method)
-
-		// Return mock response
-		resp := map[string]interface{}{
-			"id":                 "sha256:abc123def456",
-			"repository":         "python",
-			"tag":                "3.12",
-			"size":               125000000,
-			"compatible":         true,
-			"compatibility_rank": 2,
-			"tools":              []string{"python", "pip"},
-		}
-		w.Header().Set("Content-Type", "application/json")
-		mustEncode(w, resp)
+		w.WriteHeader(http.StatusNotFound)
+		mustEncode(w, map[string]string{"error": "secret not found"})
 	}))
 	defer server.Close()
 
 	// Act
 	client, err := stromboli.NewClient(server.URL)
 	require.NoError(t, err)
-	image, err := client.GetImage(context.Background(), "python:3.12")
+	err = client.UpdateSecret(context.Background(), &stromboli.CreateSecretRequest{
+		Name:  "unknown",
+		Value: "value",
+	})
 
 	// Assert
-	require.NoError(t, err)
-	assert.Equal(t, "sha256:abc123def456", image.ID)
-	assert.Equal(t, "python", image.Repository)
-	assert.True(t, image.Compatible)
-	assert.Contains(t, image.Tools, "python")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, stromboli.ErrNotFound))
 }
 
-// TestGetImage_NotFound tests GetImage with a non-existent image.
-func TestGetImage_NotFound(t *testing.T) {
-	// Arrange
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusNotFound)
-		mustEncode(w, map[string]string{"error": "image not found"})
+// TestUpdateSecret_Validation tests UpdateSecret input validation.
+func TestUpdateSecret_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *stromboli.CreateSecretRequest
+	}{
+		{"nil request", nil},
+		{"empty name", &stromboli.CreateSecretRequest{Value: "value"}},
+		{"empty value", &stromboli.CreateSecretRequest{Name: "name"}},
+	}
+
+	client, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.UpdateSecret(context.Background(), tt.req)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+		})
+	}
+}
+
+// TestEnsureSecret_Create tests that EnsureSecret creates a missing secret.
+func TestEnsureSecret_Create(t *testing.T) {
+	// Arrange
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		assert.Equal(t, http.MethodPost, r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		mustEncode(w, map[string]interface{}{"success": true, "name": "github-token"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	err = client.EnsureSecret(context.Background(), &stromboli.CreateSecretRequest{
+		Name:  "github-token",
+		Value: "ghp_xxx",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{http.MethodPost}, methods)
+}
+
+// TestEnsureSecret_Update tests that EnsureSecret updates an existing secret.
+func TestEnsureSecret_Update(t *testing.T) {
+	// Arrange
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusConflict)
+			mustEncode(w, map[string]string{"error": "secret already exists"})
+		case http.MethodPut:
+			assert.Equal(t, "/secrets/github-token", r.URL.Path)
+			var body map[string]string
+			mustDecode(r, &body)
+			assert.Equal(t, "ghp_new", body["value"])
+			mustEncode(w, map[string]interface{}{"success": true})
+		}
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	err = client.EnsureSecret(context.Background(), &stromboli.CreateSecretRequest{
+		Name:  "github-token",
+		Value: "ghp_new",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{http.MethodPost, http.MethodPut}, methods)
+}
+
+// TestEnsureSecret_EmptyValue tests that an empty value is rejected
+// client-side.
+func TestEnsureSecret_EmptyValue(t *testing.T) {
+	client, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+
+	err = client.EnsureSecret(context.Background(), &stromboli.CreateSecretRequest{Name: "github-token"})
+
+	assert.ErrorIs(t, err, stromboli.ErrBadRequest)
+}
+
+// TestCreateSecret_Conflict tests that a 409 maps to ErrSecretExists.
+func TestCreateSecret_Conflict(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		mustEncode(w, map[string]string{"error": "secret already exists"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	err = client.CreateSecret(context.Background(), &stromboli.CreateSecretRequest{
+		Name:  "github-token",
+		Value: "ghp_xxx",
+	})
+
+	// Assert
+	assert.ErrorIs(t, err, stromboli.ErrSecretExists)
+}
+
+// ============================================================================
+// Images Tests
+// ============================================================================
+
+// TestListImages_Success tests the ListImages method.
+func TestListImages_Success(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify request
+		assert.Equal(t, "/images", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		// Return mock response
+		resp := map[string]interface{}{
+			"images": []map[string]interface{}{
+				{
+					"id":                 "sha256:abc123",
+					"repository":         "python",
+					"tag":                "3.12-slim",
+					"size":               125000000,
+					"compatible":         true,
+					"compatibility_rank": 2,
+				},
+				{
+					"id":                 "sha256:def456",
+					"repository":         "alpine",
+					"tag":                "latest",
+					"compatible":         false,
+					"compatibility_rank": 4,
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, resp)
+	}))
+	defer server.Close()
+
+	// Act
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	images, err := client.ListImages(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, images, 2)
+	assert.Equal(t, "python", images[0].Repository)
+	assert.Equal(t, "3.12-slim", images[0].Tag)
+	assert.True(t, images[0].Compatible)
+	assert.Equal(t, int64(2), images[0].CompatibilityRank)
+	assert.False(t, images[1].Compatible)
+}
+
+// TestListImages_Empty tests ListImages when no images exist.
+func TestListImages_Empty(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := map[string]interface{}{
+			"images": []map[string]interface{}{},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, resp)
+	}))
+	defer server.Close()
+
+	// Act
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	images, err := client.ListImages(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, images)
+}
+
+// imagesServer serves images as the image list.
+func imagesServer(images []map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"images": images})
+	}))
+}
+
+// rankedImages is an image list in the server's compatibility rank order.
+var rankedImages = []map[string]interface{}{
+	{"id": "sha256:a", "repository": "stromboli-base", "compatible": true, "compatibility_rank": 1},
+	{"id": "sha256:b", "repository": "node", "compatible": true, "compatibility_rank": 2, "has_claude_cli": true},
+	{"id": "sha256:c", "repository": "python", "compatible": true, "compatibility_rank": 2, "has_claude_cli": true},
+	{"id": "sha256:d", "repository": "debian", "compatible": true, "compatibility_rank": 3},
+	{"id": "sha256:e", "repository": "alpine", "compatible": false, "compatibility_rank": 4, "has_claude_cli": true},
+}
+
+// TestListCompatibleImages tests that only compatible images are returned,
+// in the server's order.
+func TestListCompatibleImages(t *testing.T) {
+	// Arrange
+	server := imagesServer(rankedImages)
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	images, err := client.ListCompatibleImages(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	repositories := make([]string, len(images))
+	for i, img := range images {
+		repositories[i] = img.Repository
+	}
+	assert.Equal(t, []string{"stromboli-base", "node", "python", "debian"}, repositories)
+}
+
+// TestBestImage tests that the best ranked compatible image with the
+// Claude CLI is returned, and ErrImageNotFound if none qualifies.
+func TestBestImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		images   []map[string]interface{}
+		wantRepo string
+	}{
+		{name: "first of the best rank with the CLI", images: rankedImages, wantRepo: "node"},
+		{name: "incompatible images with the CLI are skipped", images: rankedImages[3:]},
+		{name: "no images"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := imagesServer(tt.images)
+			defer server.Close()
+			client, err := stromboli.NewClient(server.URL)
+			require.NoError(t, err)
+
+			// Act
+			image, err := client.BestImage(context.Background())
+
+			// Assert
+			if tt.wantRepo == "" {
+				assert.ErrorIs(t, err, stromboli.ErrImageNotFound)
+				assert.Nil(t, image)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRepo, image.Repository)
+		})
+	}
+}
+
+// TestGetImage_Success tests the GetImage method.
+func TestGetImage_Success(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify request
+		assert.Equal(t, "/images/python:3.12", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		// Return mock response
+		resp := map[string]interface{}{
+			"id":                 "sha256:abc123def456",
+			"repository":         "python",
+			"tag":                "3.12",
+			"size":               125000000,
+			"compatible":         true,
+			"compatibility_rank": 2,
+			"tools":              []string{"python", "pip"},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, resp)
+	}))
+	defer server.Close()
+
+	// Act
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	image, err := client.GetImage(context.Background(), "python:3.12")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:abc123def456", image.ID)
+	assert.Equal(t, "python", image.Repository)
+	assert.True(t, image.Compatible)
+	assert.Contains(t, image.Tools, "python")
+}
+
+// TestGetImage_NotFound tests GetImage with a non-existent image.
+func TestGetImage_NotFound(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		mustEncode(w, map[string]string{"error": "image not found"})
 	}))
 	defer server.Close()
 
@@ -2156,42 +3182,155 @@ func TestPullImage_EmptyImage(t *testing.T) {
 	assert.Equal(t, "BAD_REQUEST", apiErr.Code)
 }
 
-// TestRun_WithLifecycleHooks tests Run with lifecycle hooks.
-func TestRun_WithLifecycleHooks(t *testing.T) {
+// TestDeleteImage_Success tests the DeleteImage method.
+func TestDeleteImage_Success(t *testing.T) {
 	// Arrange
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Parse request body
-		var req map[string]interface{}
-		mustDecode(r, &req)
-
-		// Verify Podman options with lifecycle
-		podman, ok := req["podman"].(map[string]interface{})
-		require.True(t, ok)
-		lifecycle, ok := podman["lifecycle"].(map[string]interface{})
-		require.True(t, ok)
-
-		onCreate, ok := lifecycle["on_create_command"].([]interface{})
-		require.True(t, ok)
-		assert.Contains(t, onCreate, "pip install -r requirements.txt")
+		// Verify request
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/images/docker.io%2Flibrary%2Fpython:3.12-slim", r.URL.EscapedPath())
+		assert.Empty(t, r.URL.Query().Get("force"))
 
-		// Return mock response
-		resp := map[string]interface{}{
-			"id":     "run-hooks123",
-			"status": "completed",
-			"output": "Task completed",
-		}
 		w.Header().Set("Content-Type", "application/json")
-		mustEncode(w, resp)
+		mustEncode(w, map[string]interface{}{"success": true})
 	}))
 	defer server.Close()
 
 	// Act
 	client, err := stromboli.NewClient(server.URL)
 	require.NoError(t, err)
-	result, err := client.Run(context.Background(), &stromboli.RunRequest{
-		Prompt: "Run with hooks",
-		Podman: &stromboli.PodmanOptions{
-			Lifecycle: &stromboli.LifecycleHooks{
+	err = client.DeleteImage(context.Background(), "docker.io/library/python:3.12-slim", nil)
+
+	// Assert
+	require.NoError(t, err)
+}
+
+// TestDeleteImage_Force tests that Force is sent as a query parameter.
+func TestDeleteImage_Force(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("force"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// Act
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	err = client.DeleteImage(context.Background(), "python:3.12-slim", &stromboli.DeleteImageOptions{
+		Force: true,
+	})
+
+	// Assert
+	require.NoError(t, err)
+}
+
+// TestDeleteImage_NotFound tests that a missing image maps to ErrImageNotFound.
+func TestDeleteImage_NotFound(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		mustEncode(w, map[string]string{"error": "image not found"})
+	}))
+	defer server.Close()
+
+	// Act
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	err = client.DeleteImage(context.Background(), "nonexistent:latest", nil)
+
+	// Assert
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, stromboli.ErrImageNotFound))
+}
+
+// TestDeleteImage_InUse tests that an image in use maps to a CONFLICT error.
+func TestDeleteImage_InUse(t *testing.T) {
+	tests := []struct {
+		name string
+		body map[string]string
+	}{
+		{"plain error", map[string]string{"error": "image is in use by container abc123"}},
+		{"specific code", map[string]string{"error": "image is in use by container abc123", "code": "IMAGE_IN_USE"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusConflict)
+				mustEncode(w, tt.body)
+			}))
+			defer server.Close()
+
+			// Act
+			client, err := stromboli.NewClient(server.URL)
+			require.NoError(t, err)
+			err = client.DeleteImage(context.Background(), "python:3.12-slim", nil)
+
+			// Assert
+			var apiErr *stromboli.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, "CONFLICT", apiErr.Code)
+			assert.Equal(t, http.StatusConflict, apiErr.Status)
+			assert.Contains(t, apiErr.Message, "in use")
+		})
+	}
+}
+
+// TestDeleteImage_EmptyName tests DeleteImage with an empty image name.
+func TestDeleteImage_EmptyName(t *testing.T) {
+	// Arrange
+	client, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+
+	// Act
+	err = client.DeleteImage(context.Background(), "", nil)
+
+	// Assert
+	require.Error(t, err)
+	var apiErr *stromboli.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "BAD_REQUEST", apiErr.Code)
+}
+
+// TestRun_WithLifecycleHooks tests Run with lifecycle hooks.
+func TestRun_WithLifecycleHooks(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Parse request body
+		var req map[string]interface{}
+		mustDecode(r, &req)
+
+		// Verify Podman options with lifecycle
+		podman, ok := req["podman"].(map[string]interface{})
+		require.True(t, ok)
+		lifecycle, ok := podman["lifecycle"].(map[string]interface{})
+		require.True(t, ok)
+
+		onCreate, ok := lifecycle["on_create_command"].([]interface{})
+		require.True(t, ok)
+		assert.Contains(t, onCreate, "pip install -r requirements.txt")
+
+		// Return mock response
+		resp := map[string]interface{}{
+			"id":     "run-hooks123",
+			"status": "completed",
+			"output": "Task completed",
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, resp)
+	}))
+	defer server.Close()
+
+	// Act
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	result, err := client.Run(context.Background(), &stromboli.RunRequest{
+		Prompt: "Run with hooks",
+		Podman: &stromboli.PodmanOptions{
+			Lifecycle: &stromboli.LifecycleHooks{
 				OnCreateCommand: []string{"pip install -r requirements.txt"},
 				PostStart:       []string{"redis-server --daemonize yes"},
 				HooksTimeout:    "5m",
@@ -2439,6 +3578,145 @@ func TestStream_NilRequest(t *testing.T) {
 	assert.Equal(t, "BAD_REQUEST", apiErr.Code)
 }
 
+// TestStreamEvent_Helpers tests the done/error helpers of StreamEvent.
+func TestStreamEvent_Helpers(t *testing.T) {
+	tests := []struct {
+		name        string
+		event       stromboli.StreamEvent
+		isDone      bool
+		isError     bool
+		wantMessage string
+	}{
+		{name: "output", event: stromboli.StreamEvent{Data: "Hello"}},
+		{name: "done", event: stromboli.StreamEvent{Type: "done"}, isDone: true},
+		{
+			name:        "plain error",
+			event:       stromboli.StreamEvent{Type: "error", Data: "container crashed"},
+			isError:     true,
+			wantMessage: "container crashed",
+		},
+		{
+			name:        "JSON error with message",
+			event:       stromboli.StreamEvent{Type: "error", Data: `{"message":"podman unavailable"}`},
+			isError:     true,
+			wantMessage: "podman unavailable",
+		},
+		{
+			name:        "JSON error with error field",
+			event:       stromboli.StreamEvent{Type: "error", Data: `{"error":"image not allowed"}`},
+			isError:     true,
+			wantMessage: "image not allowed",
+		},
+		{
+			name:        "empty error",
+			event:       stromboli.StreamEvent{Type: "error"},
+			isError:     true,
+			wantMessage: "stream error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.isDone, tt.event.IsDone())
+			assert.Equal(t, tt.isError, tt.event.IsError())
+
+			err := tt.event.AsError()
+			if !tt.isError {
+				assert.NoError(t, err)
+				return
+			}
+			var apiErr *stromboli.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, "STREAM_ERROR", apiErr.Code)
+			assert.Equal(t, tt.wantMessage, apiErr.Message)
+		})
+	}
+}
+
+// TestStream_SessionID tests capturing the session ID from the stream.
+func TestStream_SessionID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		body   string
+		want   string
+	}{
+		{
+			name: "session event",
+			body: "data: Hello\n\nevent: session\ndata: sess-abc123\n\nevent: done\ndata: \n\n",
+			want: "sess-abc123",
+		},
+		{
+			name: "id on first event",
+			body: "id: sess-def456\ndata: Hello\n\nid: 2\ndata: World\n\n",
+			want: "sess-def456",
+		},
+		{
+			name: "id on later event is ignored",
+			body: "data: Hello\n\nid: 2\ndata: World\n\n",
+			want: "",
+		},
+		{
+			name: "session event overrides first id",
+			body: "id: 1\ndata: Hello\n\nevent: session\ndata: sess-ghi789\n\n",
+			want: "sess-ghi789",
+		},
+		{
+			name:   "response header",
+			header: "sess-hdr001",
+			body:   "data: Hello\n\nevent: done\ndata: \n\n",
+			want:   "sess-hdr001",
+		},
+		{
+			name: "done payload",
+			body: "data: Hello\n\nevent: done\ndata: {\"session_id\":\"sess-done01\"}\n\n",
+			want: "sess-done01",
+		},
+		{
+			name:   "result message overrides header",
+			header: "sess-hdr001",
+			body:   "data: {\"type\":\"result\",\"result\":\"Hi\",\"session_id\":\"sess-res001\"}\n\nevent: done\ndata: \n\n",
+			want:   "sess-res001",
+		},
+		{
+			name: "session_id of other messages is ignored",
+			body: "data: {\"type\":\"assistant\",\"session_id\":\"sess-other\"}\n\n",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				if tt.header != "" {
+					w.Header().Set("X-Session-ID", tt.header)
+				}
+				w.WriteHeader(http.StatusOK)
+				_, _ = fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL)
+			require.NoError(t, err)
+			stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Hello"})
+			require.NoError(t, err)
+			defer func() { _ = stream.Close() }()
+
+			// Act
+			assert.Equal(t, tt.header, stream.SessionID(), "only the header is known before any event")
+			for stream.Next() {
+				// Drain the stream
+			}
+
+			// Assert
+			require.NoError(t, stream.Err())
+			assert.Equal(t, tt.want, stream.SessionID())
+		})
+	}
+}
+
 // TestStream_ServerError tests Stream when the server returns an error.
 func TestStream_ServerError(t *testing.T) {
 	// Arrange
@@ -2601,6 +3879,101 @@ func TestStream_ContextCancellation(t *testing.T) {
 	assert.False(t, stream.Next())
 }
 
+// stalledStreamServer sends one event and then stalls until the request is
+// cancelled, which it reports on cancelled.
+func stalledStreamServer(cancelled chan<- struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprintf(w, "data: First\n\n")
+		w.(http.Flusher).Flush()
+
+		<-r.Context().Done()
+		close(cancelled)
+	}))
+}
+
+// TestStream_StreamTimeout tests that WithStreamTimeout aborts a stalled
+// stream when the caller's context has no deadline.
+func TestStream_StreamTimeout(t *testing.T) {
+	// Arrange
+	cancelled := make(chan struct{})
+	server := stalledStreamServer(cancelled)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithStreamTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Test"})
+	require.NoError(t, err)
+	defer func() { _ = stream.Close() }()
+	require.True(t, stream.Next())
+
+	// Act
+	start := time.Now()
+	next := stream.Next()
+
+	// Assert
+	assert.False(t, next)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	require.Error(t, stream.Err())
+	assert.True(t, errors.Is(stream.Err(), context.DeadlineExceeded))
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server request was not cancelled")
+	}
+}
+
+// TestStream_StreamTimeoutShorterDeadline tests that a caller's deadline
+// shorter than the stream timeout is kept.
+func TestStream_StreamTimeoutShorterDeadline(t *testing.T) {
+	// Arrange
+	cancelled := make(chan struct{})
+	server := stalledStreamServer(cancelled)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithStreamTimeout(time.Hour))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	stream, err := client.Stream(ctx, &stromboli.StreamRequest{Prompt: "Test"})
+	require.NoError(t, err)
+	defer func() { _ = stream.Close() }()
+	require.True(t, stream.Next())
+
+	// Act
+	next := stream.Next()
+
+	// Assert
+	assert.False(t, next)
+	assert.True(t, errors.Is(stream.Err(), context.DeadlineExceeded))
+}
+
+// TestStream_CloseCancelsTimeoutContext tests that Close releases the
+// context derived for the stream timeout.
+func TestStream_CloseCancelsTimeoutContext(t *testing.T) {
+	// Arrange
+	cancelled := make(chan struct{})
+	server := stalledStreamServer(cancelled)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithStreamTimeout(time.Hour))
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Test"})
+	require.NoError(t, err)
+	require.True(t, stream.Next())
+
+	// Act
+	require.NoError(t, stream.Close())
+
+	// Assert
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server request was not cancelled by Close")
+	}
+}
+
 // TestStream_CloseMultipleTimes tests that Stream.Close is safe to call multiple times.
 func TestStream_CloseMultipleTimes(t *testing.T) {
 	// Arrange
@@ -2676,33 +4049,198 @@ func TestStream_EventsWithContext(t *testing.T) {
 	assert.Equal(t, 2, count)
 }
 
-// ============================================================================
-// Code Review Fix Tests
-// ============================================================================
-
-// TestNewClient_SafeTransportCloning tests that NewClient doesn't panic
-// when DefaultTransport is not a *http.Transport.
-func TestNewClient_SafeTransportCloning(t *testing.T) {
-	// Save original transport
-	original := http.DefaultTransport
-	defer func() { http.DefaultTransport = original }()
-
-	// Set a non-*http.Transport transport
-	http.DefaultTransport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
-		return nil, errors.New("mock transport")
+// TestStream_Collect tests that Collect concatenates the output events of a
+// stream, skips control events, and stops at an error event.
+func TestStream_Collect(t *testing.T) {
+	// Arrange
+	complete := stromboli.NewStreamFromEvents([]*stromboli.StreamEvent{
+		{Type: stromboli.EventTypeSession, Data: "sess-1"},
+		{Data: "Hello, "},
+		{Type: "message", Data: "world"},
+		{Type: stromboli.EventTypeDone, Data: `{"session_id":"sess-1"}`},
+	})
+	failed := stromboli.NewStreamFromEvents([]*stromboli.StreamEvent{
+		{Data: "partial"},
+		{Type: stromboli.EventTypeError, Data: `{"message":"container exited"}`},
+		{Data: "ignored"},
 	})
 
-	// This should NOT panic
-	client, err := stromboli.NewClient("http://localhost:8585")
+	// Act
+	output, err := complete.Collect(context.Background())
+	partial, failedErr := failed.Collect(context.Background())
+
+	// Assert
 	require.NoError(t, err)
-	assert.NotNil(t, client)
+	assert.Equal(t, "Hello, world", output)
+	assert.Equal(t, "sess-1", complete.SessionID())
+	assert.False(t, complete.Next(), "stream should be closed")
+	assert.Equal(t, "partial", partial)
+	var apiErr *stromboli.Error
+	require.True(t, errors.As(failedErr, &apiErr))
+	assert.Equal(t, "container exited", apiErr.Message)
 }
 
-// roundTripperFunc adapts a function to http.RoundTripper.
-type roundTripperFunc func(*http.Request) (*http.Response, error)
+// TestStream_Collect_ContextCancellation tests that Collect returns the
+// context's error and closes a stalled stream when its context is done.
+func TestStream_Collect_ContextCancellation(t *testing.T) {
+	// Arrange
+	cancelled := make(chan struct{})
+	server := stalledStreamServer(cancelled)
+	defer server.Close()
 
-func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
-	return f(req)
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Test"})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Act
+	output, err := stream.Collect(ctx)
+
+	// Assert
+	assert.Equal(t, "First", output)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not cancelled")
+	}
+}
+
+// TestStreamJob_Success tests following a running job's output.
+func TestStreamJob_Success(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/jobs/job-123/stream", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprintf(w, "data: Analyzing\n\n")
+		_, _ = fmt.Fprintf(w, "event: done\ndata: \n\n")
+	}))
+	defer server.Close()
+
+	// Act
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	stream, err := client.StreamJob(context.Background(), "job-123")
+
+	// Assert
+	require.NoError(t, err)
+	defer func() { _ = stream.Close() }()
+
+	var events []*stromboli.StreamEvent
+	for stream.Next() {
+		events = append(events, stream.Event())
+	}
+	require.NoError(t, stream.Err())
+	require.Len(t, events, 2)
+	assert.Equal(t, "Analyzing", events[0].Data)
+	assert.Equal(t, "done", events[1].Type)
+}
+
+// TestStreamJob_NotFound tests that unknown jobs return ErrNotFound.
+func TestStreamJob_NotFound(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		mustEncode(w, map[string]string{"error": "job not found"})
+	}))
+	defer server.Close()
+
+	// Act
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	stream, err := client.StreamJob(context.Background(), "job-missing")
+
+	// Assert
+	require.Error(t, err)
+	assert.Nil(t, stream)
+	assert.True(t, errors.Is(err, stromboli.ErrNotFound))
+}
+
+// TestStreamJob_AlreadyFinished tests that a finished job replays its output
+// followed by a done event.
+func TestStreamJob_AlreadyFinished(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jobs/job-123/stream":
+			w.WriteHeader(http.StatusGone)
+		case "/jobs/job-123":
+			w.Header().Set("Content-Type", "application/json")
+			mustEncode(w, map[string]interface{}{
+				"id":     "job-123",
+				"status": "completed",
+				"output": "line 1\nline 2",
+			})
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	// Act
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	stream, err := client.StreamJob(context.Background(), "job-123")
+
+	// Assert
+	require.NoError(t, err)
+	defer func() { _ = stream.Close() }()
+
+	var events []*stromboli.StreamEvent
+	for stream.Next() {
+		events = append(events, stream.Event())
+	}
+	require.NoError(t, stream.Err())
+	require.Len(t, events, 2)
+	assert.Equal(t, "line 1\nline 2", events[0].Data)
+	assert.Equal(t, "done", events[1].Type)
+}
+
+// TestStreamJob_EmptyID tests StreamJob with an empty job ID.
+func TestStreamJob_EmptyID(t *testing.T) {
+	client, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+
+	stream, err := client.StreamJob(context.Background(), "")
+
+	require.Error(t, err)
+	assert.Nil(t, stream)
+	assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+}
+
+// ============================================================================
+// Code Review Fix Tests
+// ============================================================================
+
+// TestNewClient_SafeTransportCloning tests that NewClient doesn't panic
+// when DefaultTransport is not a *http.Transport.
+func TestNewClient_SafeTransportCloning(t *testing.T) {
+	// Save original transport
+	original := http.DefaultTransport
+	defer func() { http.DefaultTransport = original }()
+
+	// Set a non-*http.Transport transport
+	http.DefaultTransport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("mock transport")
+	})
+
+	// This should NOT panic
+	client, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+	assert.NotNil(t, client)
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
 }
 
 // TestValidateJSONSchema_ValidSchemas tests JSON schema validation with valid schemas.
@@ -2810,6 +4348,97 @@ func TestError_RateLimited(t *testing.T) {
 	var apiErr *stromboli.Error
 	require.ErrorAs(t, err, &apiErr)
 	assert.Equal(t, "RATE_LIMITED", apiErr.Code)
+	assert.Equal(t, 60*time.Second, apiErr.RetryAfter)
+}
+
+// TestError_RetryAfter tests that the Retry-After header of 429 responses
+// is parsed into RetryAfter, in both forms and on every request path.
+func TestError_RetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		retryAfter string
+		call       func(*stromboli.Client) error
+		want       time.Duration
+		wantCode   string
+	}{
+		{
+			name:       "delta seconds",
+			retryAfter: "5",
+			call: func(c *stromboli.Client) error {
+				_, err := c.Health(context.Background())
+				return err
+			},
+			want:     5 * time.Second,
+			wantCode: "RATE_LIMITED",
+		},
+		{
+			name:       "HTTP date",
+			retryAfter: now.Add(90 * time.Second).Format(http.TimeFormat),
+			call: func(c *stromboli.Client) error {
+				_, err := c.Health(context.Background())
+				return err
+			},
+			want:     90 * time.Second,
+			wantCode: "RATE_LIMITED",
+		},
+		{
+			name: "no header",
+			call: func(c *stromboli.Client) error {
+				_, err := c.Health(context.Background())
+				return err
+			},
+			want:     0,
+			wantCode: "RATE_LIMITED",
+		},
+		{
+			name:       "raw request",
+			retryAfter: "5",
+			call: func(c *stromboli.Client) error {
+				_, err := c.GetSession(context.Background(), "sess-abc123")
+				return err
+			},
+			want:     5 * time.Second,
+			wantCode: "RATE_LIMITED",
+		},
+		{
+			name:       "stream",
+			retryAfter: "5",
+			call: func(c *stromboli.Client) error {
+				_, err := c.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Hello"})
+				return err
+			},
+			want:     5 * time.Second,
+			wantCode: "STREAM_ERROR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				mustEncode(w, map[string]string{"error": "rate limited"})
+			}))
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL, stromboli.WithClock(strombolitest.NewFakeClock(now)))
+			require.NoError(t, err)
+
+			// Act
+			err = tt.call(client)
+
+			// Assert
+			var apiErr *stromboli.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.want, apiErr.RetryAfter)
+			assert.Equal(t, tt.wantCode, apiErr.Code)
+		})
+	}
 }
 
 // TestWithRequestHook tests that request hooks are called.
@@ -2885,6 +4514,98 @@ func TestWithResponseHook(t *testing.T) {
 	assert.Equal(t, http.StatusOK, capturedStatusCode)
 }
 
+// TestHooks_Order tests that multiple hooks are called in registration
+// order, including hooks added after client creation.
+func TestHooks_Order(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok"})
+	}))
+	defer server.Close()
+
+	var calls []string
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithRequestHook(func(*http.Request) { calls = append(calls, "req-1") }),
+		stromboli.WithRequestHook(func(*http.Request) { calls = append(calls, "req-2") }),
+		stromboli.WithResponseHook(func(*http.Response) { calls = append(calls, "resp-1") }),
+	)
+	require.NoError(t, err)
+
+	// Act
+	client.AddRequestHook(func(*http.Request) { calls = append(calls, "req-3") })
+	client.AddResponseHook(func(*http.Response) { calls = append(calls, "resp-2") })
+	_, err = client.Health(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"req-1", "req-2", "req-3", "resp-1", "resp-2"}, calls)
+}
+
+// TestHooks_AddAfterCreation tests that hooks added after client creation
+// apply to both generated and hand-written endpoints.
+func TestHooks_AddAfterCreation(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/run/stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	_, err = client.Health(context.Background())
+	require.NoError(t, err)
+
+	var paths []string
+	var mu sync.Mutex
+	client.AddRequestHook(func(req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, req.URL.Path)
+	})
+
+	// Act
+	_, err = client.Health(context.Background())
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "hi"})
+	require.NoError(t, err)
+	_ = stream.Close()
+
+	// Assert
+	assert.Equal(t, []string{"/health", "/run/stream"}, paths)
+}
+
+// TestWithRequestHook_NilClears tests that a nil hook clears previously
+// registered hooks.
+func TestWithRequestHook_NilClears(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok"})
+	}))
+	defer server.Close()
+
+	called := false
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithRequestHook(func(*http.Request) { called = true }),
+		stromboli.WithRequestHook(nil),
+	)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Health(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, called)
+}
+
 // TestWithRetries_LogsWarning tests that WithRetries logs a deprecation warning.
 // Note: We can't easily test log output, so we just verify it doesn't panic.
 func TestWithRetries_LogsWarning(t *testing.T) {
@@ -2915,3 +4636,480 @@ func TestRunResponse_IsSuccess_UsesConstants(t *testing.T) {
 		})
 	}
 }
+
+// ============================================================================
+// Validation Mode Tests
+// ============================================================================
+
+// captureLogger records log messages for assertions.
+type captureLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *captureLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func (l *captureLogger) Messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.messages...)
+}
+
+// TestWithValidationMode tests that an invalid request is rejected, warned
+// about, or silently sent depending on the validation mode.
+func TestWithValidationMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        stromboli.ValidationMode
+		expectErr   bool
+		expectWarn  bool
+		expectCalls int
+	}{
+		{"strict rejects", stromboli.ValidationStrict, true, false, 0},
+		{"warn logs and sends", stromboli.ValidationWarn, false, true, 1},
+		{"off sends silently", stromboli.ValidationOff, false, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			logger := &captureLogger{}
+			stromboli.SetLogger(logger)
+			defer stromboli.SetLogger(nil)
+
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("Content-Type", "application/json")
+				mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed"})
+			}))
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL, stromboli.WithValidationMode(tt.mode))
+			require.NoError(t, err)
+
+			// Act: schema without structural keywords and resume without session
+			_, err = client.Run(context.Background(), &stromboli.RunRequest{
+				Prompt: "test",
+				Claude: &stromboli.ClaudeOptions{
+					JSONSchema: `{"title":"no structure"}`,
+					Resume:     true,
+				},
+			})
+
+			// Assert
+			if tt.expectErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectCalls, calls)
+			if tt.expectWarn {
+				assert.Len(t, logger.Messages(), 2, "each failed check should be logged")
+			} else {
+				assert.Empty(t, logger.Messages())
+			}
+		})
+	}
+}
+
+// TestWithValidationMode_RequiredFieldsAlwaysEnforced tests that an empty
+// prompt is rejected even when validation is off.
+func TestWithValidationMode_RequiredFieldsAlwaysEnforced(t *testing.T) {
+	client, err := stromboli.NewClient("http://localhost:8585",
+		stromboli.WithValidationMode(stromboli.ValidationOff),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Run(context.Background(), &stromboli.RunRequest{})
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+}
+
+// TestRun_PodmanOptionsValidation tests client-side validation of Podman
+// option formats.
+func TestRun_PodmanOptionsValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		podman    *stromboli.PodmanOptions
+		wantField string
+	}{
+		{"valid", &stromboli.PodmanOptions{
+			Memory:    "512m",
+			Timeout:   "1h30m",
+			Cpus:      "0.5",
+			Volumes:   []string{"/data:/data:ro", "/code:/workspace"},
+			Lifecycle: &stromboli.LifecycleHooks{HooksTimeout: "5m"},
+			Environment: &stromboli.EnvironmentConfig{
+				Type: "compose", Path: "/app/compose.yml", Service: "dev", BuildTimeout: "15m",
+			},
+		}, ""},
+		{"memory with GB suffix", &stromboli.PodmanOptions{Memory: "2GB"}, "podman.memory"},
+		{"memory without number", &stromboli.PodmanOptions{Memory: "g"}, "podman.memory"},
+		{"timeout in words", &stromboli.PodmanOptions{Timeout: "10 minutes"}, "podman.timeout"},
+		{"negative timeout", &stromboli.PodmanOptions{Timeout: "-5m"}, "podman.timeout"},
+		{"zero cpus", &stromboli.PodmanOptions{Cpus: "0"}, "podman.cpus"},
+		{"non-decimal cpus", &stromboli.PodmanOptions{Cpus: "Inf"}, "podman.cpus"},
+		{"relative host path", &stromboli.PodmanOptions{Volumes: []string{"data:/data"}}, "podman.volumes[0]"},
+		{"relative container path", &stromboli.PodmanOptions{Volumes: []string{"/ok:/ok", "/data:data"}}, "podman.volumes[1]"},
+		{"missing container path", &stromboli.PodmanOptions{Volumes: []string{"/data"}}, "podman.volumes[0]"},
+		{"too many parts", &stromboli.PodmanOptions{Volumes: []string{"/a:/b:ro:z"}}, "podman.volumes[0]"},
+		{"hooks timeout", &stromboli.PodmanOptions{
+			Lifecycle: &stromboli.LifecycleHooks{HooksTimeout: "soon"},
+		}, "podman.lifecycle.hooks_timeout"},
+		{"build timeout", &stromboli.PodmanOptions{
+			Environment: &stromboli.EnvironmentConfig{BuildTimeout: "5"},
+		}, "podman.environment.build_timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed"})
+			}))
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL)
+			require.NoError(t, err)
+
+			// Act
+			_, err = client.Run(context.Background(), &stromboli.RunRequest{
+				Prompt: "test",
+				Podman: tt.podman,
+			})
+
+			// Assert
+			if tt.wantField == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+			assert.Contains(t, err.Error(), tt.wantField)
+		})
+	}
+}
+
+// TestRun_ModelValidation tests that unknown models are warned about by
+// default and rejected with WithStrictModelValidation.
+func TestRun_ModelValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []stromboli.Option
+		model       stromboli.Model
+		expectErr   bool
+		expectWarn  bool
+		expectCalls int32
+	}{
+		{"known model", nil, stromboli.ModelSonnet, false, false, 1},
+		{"unknown model warns", nil, "sonet", false, true, 1},
+		{"unknown model strict", []stromboli.Option{stromboli.WithStrictModelValidation()}, "sonet", true, false, 0},
+		{"known model strict", []stromboli.Option{stromboli.WithStrictModelValidation()}, stromboli.ModelOpus, false, false, 1},
+		{"unknown model validation off", []stromboli.Option{stromboli.WithoutClientValidation()}, "sonet", false, false, 1},
+		{"unknown model strict with warn mode", []stromboli.Option{
+			stromboli.WithStrictModelValidation(),
+			stromboli.WithValidationMode(stromboli.ValidationWarn),
+		}, "sonet", false, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			logger := &captureLogger{}
+			stromboli.SetLogger(logger)
+			defer stromboli.SetLogger(nil)
+
+			var calls int32
+			var sentModel interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				var body map[string]interface{}
+				mustDecode(r, &body)
+				if claude, ok := body["claude"].(map[string]interface{}); ok {
+					sentModel = claude["model"]
+				}
+				w.Header().Set("Content-Type", "application/json")
+				mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed"})
+			}))
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL, tt.opts...)
+			require.NoError(t, err)
+
+			// Act
+			_, err = client.Run(context.Background(), &stromboli.RunRequest{
+				Prompt: "test",
+				Claude: &stromboli.ClaudeOptions{Model: tt.model},
+			})
+
+			// Assert
+			if tt.expectErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+				assert.Contains(t, err.Error(), `"sonet"`)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, string(tt.model), sentModel)
+			}
+			assert.Equal(t, tt.expectCalls, atomic.LoadInt32(&calls))
+			if tt.expectWarn {
+				require.Len(t, logger.Messages(), 1)
+				assert.Contains(t, logger.Messages()[0], "sonet")
+			} else {
+				assert.Empty(t, logger.Messages())
+			}
+		})
+	}
+}
+
+// TestPodmanOptionsValidation_AllEntryPoints tests that RunAsync validates
+// Podman options, and that WithoutClientValidation disables it.
+func TestPodmanOptionsValidation_AllEntryPoints(t *testing.T) {
+	// Arrange
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		mustEncode(w, map[string]interface{}{"job_id": "job-1"})
+	}))
+	defer server.Close()
+
+	podman := &stromboli.PodmanOptions{Memory: "2GB"}
+	ctx := context.Background()
+
+	strict, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	lenient, err := stromboli.NewClient(server.URL, stromboli.WithoutClientValidation())
+	require.NoError(t, err)
+
+	// Act & Assert: rejected before sending
+	_, err = strict.RunAsync(ctx, &stromboli.RunRequest{Prompt: "test", Podman: podman})
+	assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	// Act & Assert: sent as-is without client validation
+	_, err = lenient.RunAsync(ctx, &stromboli.RunRequest{Prompt: "test", Podman: podman})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestValidateRunRequest tests that ValidateRunRequest reports the first
+// client-side validation failure without sending anything, whatever the
+// client's validation mode.
+func TestValidateRunRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *stromboli.RunRequest
+		wantMsg string
+	}{
+		{"valid", &stromboli.RunRequest{
+			Prompt: "test",
+			Claude: &stromboli.ClaudeOptions{SessionID: "sess-1", Resume: true, PermissionMode: stromboli.PermissionModePlan},
+			Podman: &stromboli.PodmanOptions{Memory: "512m"},
+		}, ""},
+		{"nil request", nil, "request is required"},
+		{"empty prompt", &stromboli.RunRequest{}, "prompt is required"},
+		{"prompt too large", &stromboli.RunRequest{Prompt: string(make([]byte, 2<<20))}, "prompt exceeds"},
+		{"invalid JSON schema", &stromboli.RunRequest{
+			Prompt: "test",
+			Claude: &stromboli.ClaudeOptions{JSONSchema: "{"},
+		}, "invalid JSON schema"},
+		{"resume without session", &stromboli.RunRequest{
+			Prompt: "test",
+			Claude: &stromboli.ClaudeOptions{Resume: true},
+		}, "session_id is required"},
+		{"invalid permission mode", &stromboli.RunRequest{
+			Prompt: "test",
+			Claude: &stromboli.ClaudeOptions{PermissionMode: "acceptedits"},
+		}, `invalid permission mode "acceptedits"`},
+		{"invalid podman options", &stromboli.RunRequest{
+			Prompt: "test",
+			Podman: &stromboli.PodmanOptions{Memory: "2GB"},
+		}, "podman.memory"},
+		{"first failure", &stromboli.RunRequest{
+			Prompt: "test",
+			Claude: &stromboli.ClaudeOptions{Resume: true},
+			Podman: &stromboli.PodmanOptions{Memory: "2GB"},
+		}, "session_id is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+			}))
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL,
+				stromboli.WithValidationMode(stromboli.ValidationWarn),
+			)
+			require.NoError(t, err)
+
+			// Act
+			err = client.ValidateRunRequest(tt.req)
+
+			// Assert
+			assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+			if tt.wantMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+			assert.Contains(t, err.Error(), tt.wantMsg)
+		})
+	}
+}
+
+// TestValidateRunRequest_Defaults tests that ValidateRunRequest validates
+// the request with the client's default options merged in, and rejects
+// unknown models only with WithStrictModelValidation.
+func TestValidateRunRequest_Defaults(t *testing.T) {
+	// Arrange
+	req := &stromboli.RunRequest{Prompt: "test", Claude: &stromboli.ClaudeOptions{Model: "sonet"}}
+	lenient, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+	strict, err := stromboli.NewClient("http://localhost:8585", stromboli.WithStrictModelValidation())
+	require.NoError(t, err)
+	withDefaults, err := stromboli.NewClient("http://localhost:8585",
+		stromboli.WithDefaultPodmanOptions(&stromboli.PodmanOptions{Cpus: "0"}),
+	)
+	require.NoError(t, err)
+
+	// Act
+	lenientErr := lenient.ValidateRunRequest(req)
+	strictErr := strict.ValidateRunRequest(req)
+	defaultsErr := withDefaults.ValidateRunRequest(&stromboli.RunRequest{Prompt: "test"})
+
+	// Assert
+	assert.NoError(t, lenientErr)
+	assert.True(t, errors.Is(strictErr, stromboli.ErrBadRequest))
+	assert.True(t, errors.Is(defaultsErr, stromboli.ErrBadRequest))
+	assert.Contains(t, defaultsErr.Error(), "podman.cpus")
+}
+
+// ----------------------------------------------------------------------------
+// Base Context Tests
+// ----------------------------------------------------------------------------
+
+// TestWithBaseContext_FailsFastWhenDone tests that new calls fail with
+// CANCELLED once the base context is cancelled.
+func TestWithBaseContext_FailsFastWhenDone(t *testing.T) {
+	// Arrange
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok"})
+	}))
+	defer server.Close()
+
+	baseCtx, cancel := context.WithCancel(context.Background())
+	client, err := stromboli.NewClient(server.URL, stromboli.WithBaseContext(baseCtx))
+	require.NoError(t, err)
+
+	_, err = client.Health(context.Background())
+	require.NoError(t, err)
+
+	// Act
+	cancel()
+	_, healthErr := client.Health(context.Background())
+	_, capsErr := client.Capabilities(context.Background())
+	_, streamErr := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "hi"})
+
+	// Assert
+	for _, err := range []error{healthErr, capsErr, streamErr} {
+		var apiErr *stromboli.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "CANCELLED", apiErr.Code)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "no request should reach the server")
+}
+
+// TestWithBaseContext_AbortsInFlight tests that cancelling the base context
+// aborts in-flight requests and closes active streams.
+func TestWithBaseContext_AbortsInFlight(t *testing.T) {
+	// Arrange
+	started := make(chan struct{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/run/stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+		}
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	baseCtx, cancel := context.WithCancel(context.Background())
+	client, err := stromboli.NewClient(server.URL, stromboli.WithBaseContext(baseCtx))
+	require.NoError(t, err)
+
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "hi"})
+	require.NoError(t, err)
+	defer func() { _ = stream.Close() }()
+	<-started
+
+	healthErr := make(chan error, 1)
+	go func() {
+		_, err := client.Health(context.Background())
+		healthErr <- err
+	}()
+	<-started
+
+	// Act
+	cancel()
+
+	// Assert
+	select {
+	case err := <-healthErr:
+		var apiErr *stromboli.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "CANCELLED", apiErr.Code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("in-flight request was not aborted")
+	}
+	assert.False(t, stream.Next())
+	assert.Error(t, stream.Err())
+}
+
+// TestWithBaseContext_DeadlineIsMinimum tests that the earlier of the base
+// and per-call deadlines applies.
+func TestWithBaseContext_DeadlineIsMinimum(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	baseCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client, err := stromboli.NewClient(server.URL, stromboli.WithBaseContext(baseCtx))
+	require.NoError(t, err)
+
+	callCtx, callCancel := context.WithTimeout(context.Background(), time.Minute)
+	defer callCancel()
+
+	// Act
+	start := time.Now()
+	_, err = client.Health(callCtx)
+
+	// Assert
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
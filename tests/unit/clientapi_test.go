@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// TestClientAPI_CoversClient tests that ClientAPI has every exported
+// method of Client except those that configure or derive a client, so new
+// methods can't be forgotten.
+func TestClientAPI_CoversClient(t *testing.T) {
+	// Arrange
+	excluded := map[string]bool{
+		"Clone":           true,
+		"NewConversation": true,
+		"AddRequestHook":  true,
+		"AddResponseHook": true,
+	}
+	api := reflect.TypeOf((*stromboli.ClientAPI)(nil)).Elem()
+	client := reflect.TypeOf((*stromboli.Client)(nil))
+
+	// Act
+	var missing []string
+	for i := 0; i < client.NumMethod(); i++ {
+		name := client.Method(i).Name
+		if _, ok := api.MethodByName(name); !ok && !excluded[name] {
+			missing = append(missing, name)
+		}
+	}
+
+	// Assert
+	assert.Empty(t, missing, "methods of Client missing from ClientAPI")
+}
+
+// TestMockClient tests that MockClient calls the function fields, records
+// calls, and fails unmocked methods with ErrNotMocked.
+func TestMockClient(t *testing.T) {
+	// Arrange
+	mock := &strombolitest.MockClient{
+		RunFunc: func(ctx context.Context, req *stromboli.RunRequest, opts ...stromboli.CallOption) (*stromboli.RunResponse, error) {
+			return &stromboli.RunResponse{Status: "completed", Output: "echo: " + req.Prompt}, nil
+		},
+	}
+	var api stromboli.ClientAPI = mock
+	ctx := context.Background()
+
+	// Act
+	resp, runErr := api.Run(ctx, &stromboli.RunRequest{Prompt: "Hi"})
+	_, jobErr := api.GetJob(ctx, "job-1")
+	results, batchErrs := api.RunBatch(ctx, []*stromboli.RunRequest{{Prompt: "a"}, {Prompt: "b"}}, 2)
+
+	// Assert
+	require.NoError(t, runErr)
+	assert.Equal(t, "echo: Hi", resp.Output)
+	assert.ErrorIs(t, jobErr, strombolitest.ErrNotMocked)
+	assert.Len(t, results, 2)
+	require.Len(t, batchErrs, 2)
+	assert.ErrorIs(t, batchErrs[1], strombolitest.ErrNotMocked)
+	assert.Equal(t, []string{"Run", "GetJob", "RunBatch"}, mock.Calls())
+}
+
+// TestNewStreamFromEvents tests that a stream built from events delivers
+// them in order and reports the session of a session event.
+func TestNewStreamFromEvents(t *testing.T) {
+	// Arrange
+	stream := stromboli.NewStreamFromEvents([]*stromboli.StreamEvent{
+		{Type: stromboli.EventTypeSession, Data: "sess-1"},
+		{Data: "line 1\nline 2", ID: "1"},
+		nil,
+		{Type: stromboli.EventTypeDone},
+	})
+	defer stream.Close()
+
+	// Act
+	var events []stromboli.StreamEvent
+	for stream.Next() {
+		events = append(events, *stream.Event())
+	}
+
+	// Assert
+	require.NoError(t, stream.Err())
+	require.Len(t, events, 3)
+	assert.Equal(t, stromboli.StreamEvent{Data: "line 1\nline 2", ID: "1"}, events[1])
+	assert.True(t, events[2].IsDone())
+	assert.Equal(t, "sess-1", stream.SessionID())
+}
@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// TestFakeClock_Advance tests that timers fire only once the clock has
+// been advanced past their deadline, in deadline order.
+func TestFakeClock_Advance(t *testing.T) {
+	// Arrange
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	clock := strombolitest.NewFakeClock(start)
+	late := clock.NewTimer(2 * time.Second)
+	early := clock.After(time.Second)
+
+	// Act & Assert
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, start.Add(500*time.Millisecond), clock.Now())
+	assert.Empty(t, early)
+	assert.Empty(t, late.C())
+
+	clock.Advance(time.Second)
+	require.Len(t, early, 1)
+	assert.Equal(t, start.Add(1500*time.Millisecond), <-early)
+	assert.Empty(t, late.C())
+
+	clock.Advance(time.Second)
+	require.Len(t, late.C(), 1)
+}
+
+// TestFakeClock_StopAndReset tests stopping and resetting timers.
+func TestFakeClock_StopAndReset(t *testing.T) {
+	// Arrange
+	clock := strombolitest.NewFakeClock(time.Now())
+	timer := clock.NewTimer(time.Second)
+
+	// Act & Assert
+	assert.True(t, timer.Stop())
+	assert.False(t, timer.Stop())
+	clock.Advance(time.Second)
+	assert.Empty(t, timer.C())
+
+	assert.False(t, timer.Reset(time.Second))
+	clock.Advance(time.Second)
+	assert.Len(t, timer.C(), 1)
+}
+
+// TestFakeClock_BlockUntil tests waiting for a goroutine to start a timer.
+func TestFakeClock_BlockUntil(t *testing.T) {
+	// Arrange
+	clock := strombolitest.NewFakeClock(time.Now())
+	fired := make(chan struct{})
+	go func() {
+		<-clock.After(time.Minute)
+		close(fired)
+	}()
+
+	// Act
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+
+	// Assert
+	<-fired
+}
@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// TestClient_Close_ClosedClientFails tests that every kind of call fails
+// with ErrClientClosed after Close, without sending a request, and that
+// clones keep working.
+func TestClient_Close_ClosedClientFails(t *testing.T) {
+	// Arrange
+	var requests atomic.Int32
+	handler := healthAndStreamHandler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL, stromboli.WithDebug(stromboli.DebugHeaders))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	closeErr := client.Close()
+	_, healthErr := client.Health(ctx)
+	pingErr := client.Ping(ctx)
+	var job stromboli.Job
+	intoErr := client.GetJobInto(ctx, "job-abc123", &job)
+	_, streamErr := client.Stream(ctx, &stromboli.StreamRequest{Prompt: "Hi"})
+	_, cloneErr := client.Clone().Health(ctx)
+
+	// Assert
+	require.NoError(t, closeErr)
+	assert.NoError(t, client.Close(), "Close can be called again")
+	for name, err := range map[string]error{"Health": healthErr, "Ping": pingErr, "GetJobInto": intoErr, "Stream": streamErr} {
+		assert.ErrorIs(t, err, stromboli.ErrClientClosed, name)
+		var apiErr *stromboli.Error
+		require.True(t, errors.As(err, &apiErr), name)
+		assert.Equal(t, "CLIENT_CLOSED", apiErr.Code, name)
+	}
+	require.NoError(t, cloneErr, "clones are not closed")
+	assert.Equal(t, int32(1), requests.Load(), "only the clone sends a request")
+}
+
+// TestClient_Close_ClosesIdleConnections tests that Close closes the idle
+// connections of the client's pool, also behind the SDK's own transports.
+func TestClient_Close_ClosesIdleConnections(t *testing.T) {
+	// Arrange
+	var mu sync.Mutex
+	states := map[net.Conn]http.ConnState{}
+	server := httptest.NewUnstartedServer(healthAndStreamHandler())
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		mu.Lock()
+		states[conn] = state
+		mu.Unlock()
+	}
+	server.Start()
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithMetrics(&recordingMetrics{}),
+		stromboli.WithDebug(stromboli.DebugHeaders),
+	)
+	require.NoError(t, err)
+	_, err = client.Health(context.Background())
+	require.NoError(t, err)
+
+	// Act
+	require.NoError(t, client.Close())
+
+	// Assert
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, state := range states {
+			if state != http.StateClosed {
+				return false
+			}
+		}
+		return len(states) > 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+// TestClient_Close_InFlightStream tests that Close doesn't interrupt a
+// stream that is already open.
+func TestClient_Close_InFlightStream(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(healthAndStreamHandler())
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Hi"})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	// Act
+	require.NoError(t, client.Close())
+	var events []string
+	for stream.Next() {
+		events = append(events, stream.Event().Data)
+	}
+
+	// Assert
+	require.NoError(t, stream.Err())
+	assert.Equal(t, []string{"Hello"}, events)
+}
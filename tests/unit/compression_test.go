@@ -0,0 +1,185 @@
+package unit
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// compressingServer returns a server that compresses its JSON responses
+// with the encoding the request accepts (gzip preferred), and records the
+// Accept-Encoding header of each request by path.
+func compressingServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, func(path string) string) {
+	t.Helper()
+	var mu sync.Mutex
+	encodings := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepted := r.Header.Get("Accept-Encoding")
+		mu.Lock()
+		encodings[r.URL.Path] = accepted
+		mu.Unlock()
+
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		for key, values := range rec.Header() {
+			w.Header()[key] = values
+		}
+
+		var out io.WriteCloser
+		switch {
+		case strings.Contains(accepted, "gzip"):
+			w.Header().Set("Content-Encoding", "gzip")
+			out = gzip.NewWriter(w)
+		case strings.Contains(accepted, "deflate"):
+			w.Header().Set("Content-Encoding", "deflate")
+			out = zlib.NewWriter(w)
+		default:
+			w.WriteHeader(rec.Code)
+			_, _ = w.Write(rec.Body.Bytes())
+			return
+		}
+		w.WriteHeader(rec.Code)
+		_, _ = out.Write(rec.Body.Bytes())
+		_ = out.Close()
+	}))
+	return server, func(path string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		return encodings[path]
+	}
+}
+
+// TestWithCompression tests that compressed responses are decoded for both
+// generated and hand-written endpoints, including error responses.
+func TestWithCompression(t *testing.T) {
+	// Arrange
+	server, encoding := compressingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/sessions/sess-1/messages":
+			mustEncode(w, map[string]interface{}{
+				"messages": []map[string]interface{}{{"uuid": "msg-1", "type": "user"}},
+				"total":    1,
+			})
+		case "/sessions/sess-1":
+			mustEncode(w, map[string]interface{}{"id": "sess-1"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			mustEncode(w, map[string]string{"error": "session not found"})
+		}
+	})
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithCompression())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	messages, messagesErr := client.GetMessages(ctx, "sess-1", nil)
+	session, sessionErr := client.GetSession(ctx, "sess-1")
+	_, notFound := client.GetSession(ctx, "sess-missing")
+
+	// Assert
+	require.NoError(t, messagesErr)
+	require.Len(t, messages.Messages, 1)
+	assert.Equal(t, "msg-1", messages.Messages[0].UUID)
+	require.NoError(t, sessionErr)
+	assert.Equal(t, "sess-1", session.ID)
+	assert.True(t, errors.Is(notFound, stromboli.ErrNotFound))
+	assert.Contains(t, notFound.Error(), "session not found")
+	assert.Equal(t, "gzip, deflate", encoding("/sessions/sess-1/messages"))
+	assert.Equal(t, "gzip, deflate", encoding("/sessions/sess-1"))
+}
+
+// TestWithCompression_Deflate tests that deflate-encoded responses are
+// decoded.
+func TestWithCompression_Deflate(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "deflate")
+		zw := zlib.NewWriter(w)
+		require.NoError(t, json.NewEncoder(zw).Encode(map[string]interface{}{"id": "sess-1"}))
+		require.NoError(t, zw.Close())
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithCompression())
+	require.NoError(t, err)
+
+	// Act
+	session, err := client.GetSession(context.Background(), "sess-1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "sess-1", session.ID)
+}
+
+// TestWithCompression_Streams tests that streams ask for uncompressed
+// responses and are read as usual.
+func TestWithCompression_Streams(t *testing.T) {
+	// Arrange
+	server, encoding := compressingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: hello\n\nevent: done\ndata: \n\n")
+	})
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithCompression())
+	require.NoError(t, err)
+
+	// Act
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "hi"})
+	require.NoError(t, err)
+	defer func() { _ = stream.Close() }()
+	var data []string
+	for stream.Next() {
+		data = append(data, stream.Event().Data)
+	}
+
+	// Assert
+	require.NoError(t, stream.Err())
+	assert.Equal(t, "hello", data[0])
+	assert.Equal(t, "identity", encoding("/run/stream"))
+}
+
+// TestWithCompression_Clone tests that clones keep compression, and that
+// clients without the option leave encoding to the standard library.
+func TestWithCompression_Clone(t *testing.T) {
+	// Arrange
+	server, encoding := compressingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"id": strings.TrimPrefix(r.URL.Path, "/sessions/")})
+	})
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithCompression())
+	require.NoError(t, err)
+	plain, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	clone := client.Clone(stromboli.WithDebug(stromboli.DebugHeaders))
+	ctx := context.Background()
+
+	// Act
+	_, cloneErr := clone.GetSession(ctx, "cloned")
+	_, plainErr := plain.GetSession(ctx, "plain")
+
+	// Assert
+	require.NoError(t, cloneErr)
+	require.NoError(t, plainErr)
+	assert.Equal(t, "gzip, deflate", encoding("/sessions/cloned"))
+	assert.Equal(t, "gzip", encoding("/sessions/plain"))
+}
@@ -0,0 +1,188 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// TestDestroySessionAndConfirm_DelayedDisappearance tests that the session
+// list is polled until the destroyed session disappears.
+func TestDestroySessionAndConfirm_DelayedDisappearance(t *testing.T) {
+	// Arrange
+	var lists int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodDelete:
+			mustEncode(w, map[string]string{"status": "destroyed"})
+		case http.MethodGet:
+			sessions := []string{"sess-other"}
+			if atomic.AddInt32(&lists, 1) <= 2 {
+				sessions = append(sessions, "sess-abc123") // stale cache
+			}
+			mustEncode(w, map[string]interface{}{"sessions": sessions})
+		}
+	}))
+	defer server.Close()
+
+	clock := strombolitest.NewFakeClock(time.Now())
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClock(clock))
+	require.NoError(t, err)
+
+	// Act
+	done := make(chan error, 1)
+	go func() {
+		done <- client.DestroySessionAndConfirm(context.Background(), "sess-abc123", 5*time.Second)
+	}()
+	for i := 0; i < 2; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+	}
+	err = <-done
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&lists))
+}
+
+// TestDestroySessionAndConfirm_StillVisible tests the timeout case.
+func TestDestroySessionAndConfirm_StillVisible(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			mustEncode(w, map[string]string{"status": "destroyed"})
+			return
+		}
+		mustEncode(w, map[string]interface{}{"sessions": []string{"sess-abc123"}})
+	}))
+	defer server.Close()
+
+	clock := strombolitest.NewFakeClock(time.Now())
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClock(clock))
+	require.NoError(t, err)
+
+	// Act
+	done := make(chan error, 1)
+	go func() {
+		done <- client.DestroySessionAndConfirm(context.Background(), "sess-abc123", 2*time.Second)
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(5 * time.Second) // past the confirmation window
+	err = <-done
+
+	// Assert
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, stromboli.ErrStillVisible))
+
+	var visible *stromboli.StillVisibleError
+	require.ErrorAs(t, err, &visible)
+	assert.Equal(t, "session", visible.Resource)
+	assert.Equal(t, "sess-abc123", visible.ID)
+	assert.Equal(t, 5*time.Second, visible.Waited)
+	assert.Equal(t, []string{"sess-abc123"}, visible.LastObservation)
+}
+
+// TestDeleteSecretAndConfirm_DelayedDisappearance tests that the secret is
+// polled until it is reported as not found.
+func TestDeleteSecretAndConfirm_DelayedDisappearance(t *testing.T) {
+	// Arrange
+	var gets int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodDelete:
+			mustEncode(w, map[string]interface{}{"success": true})
+		case http.MethodGet:
+			if atomic.AddInt32(&gets, 1) <= 1 {
+				mustEncode(w, map[string]interface{}{"id": "abc123", "name": "github-token"})
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			mustEncode(w, map[string]string{"error": "secret not found"})
+		}
+	}))
+	defer server.Close()
+
+	clock := strombolitest.NewFakeClock(time.Now())
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClock(clock))
+	require.NoError(t, err)
+
+	// Act
+	done := make(chan error, 1)
+	go func() {
+		done <- client.DeleteSecretAndConfirm(context.Background(), "github-token", 5*time.Second)
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	err = <-done
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&gets))
+}
+
+// TestDeleteSecretAndConfirm_StillVisible tests the timeout case.
+func TestDeleteSecretAndConfirm_StillVisible(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			mustEncode(w, map[string]interface{}{"success": true})
+			return
+		}
+		mustEncode(w, map[string]interface{}{"id": "abc123", "name": "github-token"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	err = client.DeleteSecretAndConfirm(context.Background(), "github-token", 0)
+
+	// Assert
+	var visible *stromboli.StillVisibleError
+	require.ErrorAs(t, err, &visible)
+	assert.ErrorIs(t, err, stromboli.ErrStillVisible)
+	secret, ok := visible.LastObservation.(*stromboli.Secret)
+	require.True(t, ok)
+	assert.Equal(t, "abc123", secret.ID)
+}
+
+// TestDeleteSecretAndConfirm_DeleteFails tests that delete errors are
+// returned without polling.
+func TestDeleteSecretAndConfirm_DeleteFails(t *testing.T) {
+	// Arrange
+	var gets int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			atomic.AddInt32(&gets, 1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		mustEncode(w, map[string]string{"error": "secret not found"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	err = client.DeleteSecretAndConfirm(context.Background(), "github-token", time.Second)
+
+	// Assert
+	assert.ErrorIs(t, err, stromboli.ErrNotFound)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&gets))
+}
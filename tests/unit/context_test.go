@@ -0,0 +1,210 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// countingTransport counts the requests sent through it and answers each
+// with an empty JSON object.
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Request:    req,
+	}, nil
+}
+
+// contextMethods returns a call of every public method of the client that
+// takes a context, by name.
+func contextMethods(client *stromboli.Client) map[string]func(ctx context.Context) error {
+	run := func() *stromboli.RunRequest { return &stromboli.RunRequest{Prompt: "Hi"} }
+	secret := &stromboli.CreateSecretRequest{Name: "api-key", Value: "s3cr3t"}
+	return map[string]func(ctx context.Context) error{
+		"Health":         func(ctx context.Context) error { _, err := client.Health(ctx); return err },
+		"Ping":           func(ctx context.Context) error { return client.Ping(ctx) },
+		"ClaudeStatus":   func(ctx context.Context) error { _, err := client.ClaudeStatus(ctx); return err },
+		"Capabilities":   func(ctx context.Context) error { _, err := client.Capabilities(ctx); return err },
+		"Run":            func(ctx context.Context) error { _, err := client.Run(ctx, run()); return err },
+		"RunAsync":       func(ctx context.Context) error { _, err := client.RunAsync(ctx, run()); return err },
+		"TrySubmit":      func(ctx context.Context) error { _, err := client.TrySubmit(ctx, run()); return err },
+		"TryRun":         func(ctx context.Context) error { _, err := client.TryRun(ctx, run()); return err },
+		"ListJobs":       func(ctx context.Context) error { _, err := client.ListJobs(ctx); return err },
+		"GetJob":         func(ctx context.Context) error { _, err := client.GetJob(ctx, "job-1"); return err },
+		"GetJobInto":     func(ctx context.Context) error { return client.GetJobInto(ctx, "job-1", &stromboli.Job{}) },
+		"CancelJob":      func(ctx context.Context) error { return client.CancelJob(ctx, "job-1") },
+		"StreamJob":      func(ctx context.Context) error { _, err := client.StreamJob(ctx, "job-1"); return err },
+		"ListSessions":   func(ctx context.Context) error { _, err := client.ListSessions(ctx); return err },
+		"GetSession":     func(ctx context.Context) error { _, err := client.GetSession(ctx, "sess-1"); return err },
+		"DestroySession": func(ctx context.Context) error { return client.DestroySession(ctx, "sess-1") },
+		"GetMessage":     func(ctx context.Context) error { _, err := client.GetMessage(ctx, "sess-1", "msg-1"); return err },
+		"GetToken":       func(ctx context.Context) error { _, err := client.GetToken(ctx, "client-1"); return err },
+		"RefreshToken":   func(ctx context.Context) error { _, err := client.RefreshToken(ctx, "refresh"); return err },
+		"ValidateToken":  func(ctx context.Context) error { _, err := client.ValidateToken(ctx); return err },
+		"Logout":         func(ctx context.Context) error { _, err := client.Logout(ctx); return err },
+		"ListSecrets":    func(ctx context.Context) error { _, err := client.ListSecrets(ctx); return err },
+		"CreateSecret":   func(ctx context.Context) error { return client.CreateSecret(ctx, secret) },
+		"GetSecret":      func(ctx context.Context) error { _, err := client.GetSecret(ctx, "api-key"); return err },
+		"DeleteSecret":   func(ctx context.Context) error { return client.DeleteSecret(ctx, "api-key") },
+		"UpdateSecret":   func(ctx context.Context) error { return client.UpdateSecret(ctx, secret) },
+		"EnsureSecret":   func(ctx context.Context) error { return client.EnsureSecret(ctx, secret) },
+		"ListImages":     func(ctx context.Context) error { _, err := client.ListImages(ctx); return err },
+		"BestImage":      func(ctx context.Context) error { _, err := client.BestImage(ctx); return err },
+		"GetImage":       func(ctx context.Context) error { _, err := client.GetImage(ctx, "python:3.12"); return err },
+		"ImageChanged":   func(ctx context.Context) error { _, err := client.ImageChanged(ctx, "python:3.12"); return err },
+		"DeleteImage":    func(ctx context.Context) error { return client.DeleteImage(ctx, "python:3.12", nil) },
+		"Stream": func(ctx context.Context) error {
+			_, err := client.Stream(ctx, &stromboli.StreamRequest{Prompt: "Hi"})
+			return err
+		},
+		"ListJobsFiltered": func(ctx context.Context) error {
+			_, err := client.ListJobsFiltered(ctx, &stromboli.ListJobsOptions{Limit: 10})
+			return err
+		},
+		"ListSessionsDetailed": func(ctx context.Context) error {
+			_, err := client.ListSessionsDetailed(ctx, nil)
+			return err
+		},
+		"GetMessages": func(ctx context.Context) error {
+			_, err := client.GetMessages(ctx, "sess-1", nil)
+			return err
+		},
+		"AllMessages": func(ctx context.Context) error {
+			_, err := client.AllMessages(ctx, "sess-1", nil)
+			return err
+		},
+		"ListCompatibleImages": func(ctx context.Context) error {
+			_, err := client.ListCompatibleImages(ctx)
+			return err
+		},
+		"SearchImages": func(ctx context.Context) error {
+			_, err := client.SearchImages(ctx, &stromboli.SearchImagesOptions{Query: "python"})
+			return err
+		},
+		"SearchImagesPage": func(ctx context.Context) error {
+			_, err := client.SearchImagesPage(ctx, &stromboli.SearchImagesOptions{Query: "python"})
+			return err
+		},
+		"PullImage": func(ctx context.Context) error {
+			_, err := client.PullImage(ctx, &stromboli.PullImageRequest{Image: "python:3.12"})
+			return err
+		},
+		"CleanupSessions": func(ctx context.Context) error {
+			_, err := client.CleanupSessions(ctx, nil)
+			return err
+		},
+		"DestroySessionAndConfirm": func(ctx context.Context) error {
+			return client.DestroySessionAndConfirm(ctx, "sess-1", time.Second)
+		},
+		"DeleteSecretAndConfirm": func(ctx context.Context) error {
+			return client.DeleteSecretAndConfirm(ctx, "api-key", time.Second)
+		},
+		"RunWithFallbacks": func(ctx context.Context) error {
+			_, err := client.RunWithFallbacks(ctx, run(), []stromboli.Model{stromboli.ModelHaiku}, nil)
+			return err
+		},
+		"RunWithBudget": func(ctx context.Context) error {
+			_, err := client.RunWithBudget(ctx, run(), stromboli.NewBudgetTracker(1))
+			return err
+		},
+		"EnsureSecrets": func(ctx context.Context) error {
+			return client.EnsureSecrets(ctx, []*stromboli.CreateSecretRequest{secret})
+		},
+		"EnsureSecretsBeforeRun": func(ctx context.Context) error {
+			_, err := client.EnsureSecretsBeforeRun(ctx, []*stromboli.CreateSecretRequest{secret}, run())
+			return err
+		},
+		"CollectSupportBundle": func(ctx context.Context) error {
+			return client.CollectSupportBundle(ctx, io.Discard, nil)
+		},
+	}
+}
+
+// TestDoneContext_FailsFast tests that every public method called with a
+// done context returns a CANCELLED or TIMEOUT error without sending any
+// request.
+func TestDoneContext_FailsFast(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	contexts := []struct {
+		name     string
+		ctx      context.Context
+		wantCode string
+		sentinel error
+	}{
+		{"cancelled", cancelled, "CANCELLED", nil},
+		{"expired", expired, "TIMEOUT", stromboli.ErrTimeout},
+	}
+
+	for _, tc := range contexts {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			transport := &countingTransport{}
+			client, err := stromboli.NewClient("http://localhost:8585",
+				stromboli.WithHTTPClient(&http.Client{Transport: transport}),
+				stromboli.WithToken("token"),
+			)
+			require.NoError(t, err)
+
+			for name, call := range contextMethods(client) {
+				// Act
+				err := call(tc.ctx)
+
+				// Assert
+				var apiErr *stromboli.Error
+				require.True(t, errors.As(err, &apiErr), "%s: got %v", name, err)
+				assert.Equal(t, tc.wantCode, apiErr.Code, name)
+				if tc.sentinel != nil {
+					assert.ErrorIs(t, err, tc.sentinel, name)
+				}
+			}
+			assert.Zero(t, transport.requests.Load(), "no request is sent")
+		})
+	}
+}
+
+// TestDoneBaseContext_FailsFast tests that methods of a client whose base
+// context is done fail with CANCELLED without sending any request.
+func TestDoneBaseContext_FailsFast(t *testing.T) {
+	// Arrange
+	base, cancel := context.WithCancel(context.Background())
+	cancel()
+	transport := &countingTransport{}
+	client, err := stromboli.NewClient("http://localhost:8585",
+		stromboli.WithHTTPClient(&http.Client{Transport: transport}),
+		stromboli.WithBaseContext(base),
+	)
+	require.NoError(t, err)
+
+	for name, call := range contextMethods(client) {
+		// Act
+		err := call(context.Background())
+
+		// Assert
+		var apiErr *stromboli.Error
+		require.True(t, errors.As(err, &apiErr), "%s: got %v", name, err)
+		assert.Equal(t, "CANCELLED", apiErr.Code, name)
+	}
+	assert.Zero(t, transport.requests.Load())
+}
@@ -0,0 +1,299 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// conversationServer answers runs with a session ID: the requested one when
+// resuming, a new one otherwise or when forking. It records the Claude
+// options of every run and the destroyed sessions. Runs whose prompt is
+// "fail" get a 500.
+type conversationServer struct {
+	claude    []map[string]interface{}
+	destroyed []string
+	sessions  int
+}
+
+// start starts the server.
+func (s *conversationServer) start() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			s.destroyed = append(s.destroyed, r.URL.Path)
+			mustEncode(w, map[string]interface{}{"success": true})
+			return
+		}
+
+		var body struct {
+			Prompt string                 `json:"prompt"`
+			Claude map[string]interface{} `json:"claude"`
+		}
+		mustDecode(r, &body)
+		s.claude = append(s.claude, body.Claude)
+		if body.Prompt == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			mustEncode(w, map[string]string{"error": "container crashed"})
+			return
+		}
+
+		sessionID, _ := body.Claude["session_id"].(string)
+		if sessionID == "" || body.Claude["fork_session"] == true {
+			s.sessions++
+			sessionID = fmt.Sprintf("sess-%d", s.sessions)
+		}
+		mustEncode(w, map[string]interface{}{
+			"id":         "run-1",
+			"status":     "completed",
+			"output":     "reply to " + body.Prompt,
+			"session_id": sessionID,
+		})
+	}))
+}
+
+// TestConversation_Send tests that turns after the first resume its session
+// and keep the conversation's options.
+func TestConversation_Send(t *testing.T) {
+	// Arrange
+	recorder := &conversationServer{}
+	server := recorder.start()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	opts := &stromboli.RunRequest{
+		Prompt: "ignored",
+		Claude: &stromboli.ClaudeOptions{Model: stromboli.ModelHaiku},
+	}
+	conv := client.NewConversation(opts)
+
+	// Act
+	first, err := conv.Send(ctx, "hello")
+	require.NoError(t, err)
+	second, err := conv.Send(ctx, "again")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "reply to hello", first.Output)
+	assert.Equal(t, "sess-1", second.SessionID)
+	assert.Equal(t, "sess-1", conv.SessionID())
+
+	require.Len(t, recorder.claude, 2)
+	assert.Nil(t, recorder.claude[0]["session_id"])
+	assert.Nil(t, recorder.claude[0]["resume"])
+	assert.Equal(t, "sess-1", recorder.claude[1]["session_id"])
+	assert.Equal(t, true, recorder.claude[1]["resume"])
+	assert.Equal(t, "haiku", recorder.claude[1]["model"])
+
+	// The options passed to NewConversation are not modified
+	assert.Equal(t, "ignored", opts.Prompt)
+	assert.Empty(t, opts.Claude.SessionID)
+	assert.False(t, opts.Claude.Resume)
+}
+
+// TestConversation_FirstTurnFails tests that a failing first turn returns
+// ErrNoSession and that the next turn starts a new session.
+func TestConversation_FirstTurnFails(t *testing.T) {
+	// Arrange
+	recorder := &conversationServer{}
+	server := recorder.start()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+	conv := client.NewConversation(nil)
+
+	// Act
+	_, err = conv.Send(ctx, "fail")
+
+	// Assert
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, stromboli.ErrNoSession))
+	assert.True(t, errors.Is(err, stromboli.ErrInternal))
+	assert.Empty(t, conv.SessionID())
+
+	resp, err := conv.Send(ctx, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "sess-1", resp.SessionID)
+	assert.Nil(t, recorder.claude[1]["resume"])
+}
+
+// TestConversation_LaterTurnFails tests that a failing later turn keeps the
+// session.
+func TestConversation_LaterTurnFails(t *testing.T) {
+	// Arrange
+	recorder := &conversationServer{}
+	server := recorder.start()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+	conv := client.NewConversation(nil)
+	_, err = conv.Send(ctx, "hello")
+	require.NoError(t, err)
+
+	// Act
+	_, err = conv.Send(ctx, "fail")
+
+	// Assert
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, stromboli.ErrNoSession))
+	assert.Equal(t, "sess-1", conv.SessionID())
+}
+
+// TestConversation_ExistingSession tests continuing a session given in the
+// conversation's options.
+func TestConversation_ExistingSession(t *testing.T) {
+	// Arrange
+	recorder := &conversationServer{}
+	server := recorder.start()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	conv := client.NewConversation(&stromboli.RunRequest{
+		Claude: &stromboli.ClaudeOptions{SessionID: "sess-existing"},
+	})
+
+	// Act
+	resp, err := conv.Send(context.Background(), "hello")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "sess-existing", resp.SessionID)
+	assert.Equal(t, true, recorder.claude[0]["resume"])
+}
+
+// TestConversation_Fork tests that a fork resumes the session into a new
+// one once, without affecting the original conversation.
+func TestConversation_Fork(t *testing.T) {
+	// Arrange
+	recorder := &conversationServer{}
+	server := recorder.start()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+	conv := client.NewConversation(nil)
+
+	_, err = conv.Fork(ctx)
+	assert.True(t, errors.Is(err, stromboli.ErrNoSession))
+
+	_, err = conv.Send(ctx, "hello")
+	require.NoError(t, err)
+
+	// Act
+	fork, err := conv.Fork(ctx)
+	require.NoError(t, err)
+	forked, err := fork.Send(ctx, "branch")
+	require.NoError(t, err)
+	_, err = fork.Send(ctx, "branch again")
+	require.NoError(t, err)
+	original, err := conv.Send(ctx, "main line")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "sess-2", forked.SessionID)
+	assert.Equal(t, "sess-2", fork.SessionID())
+	assert.Equal(t, "sess-1", original.SessionID)
+
+	require.Len(t, recorder.claude, 4)
+	assert.Equal(t, "sess-1", recorder.claude[1]["session_id"])
+	assert.Equal(t, true, recorder.claude[1]["fork_session"])
+	assert.Equal(t, "sess-2", recorder.claude[2]["session_id"])
+	assert.Nil(t, recorder.claude[2]["fork_session"])
+	assert.Nil(t, recorder.claude[3]["fork_session"])
+}
+
+// TestConversation_Destroy tests destroying the session and starting over.
+func TestConversation_Destroy(t *testing.T) {
+	// Arrange
+	recorder := &conversationServer{}
+	server := recorder.start()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+	conv := client.NewConversation(nil)
+
+	err = conv.Destroy(ctx)
+	assert.True(t, errors.Is(err, stromboli.ErrNoSession))
+
+	_, err = conv.Send(ctx, "hello")
+	require.NoError(t, err)
+
+	// Act
+	err = conv.Destroy(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/sessions/sess-1"}, recorder.destroyed)
+	assert.Empty(t, conv.SessionID())
+
+	resp, err := conv.Send(ctx, "new topic")
+	require.NoError(t, err)
+	assert.Equal(t, "sess-2", resp.SessionID)
+}
+
+// TestContinue tests that Continue runs the prompt in the workdir with
+// continue set and the other options kept, without modifying them.
+func TestContinue(t *testing.T) {
+	// Arrange
+	recorder := &conversationServer{}
+	server := recorder.start()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	opts := &stromboli.ClaudeOptions{Model: stromboli.ModelSonnet}
+
+	// Act
+	resp, err := client.Continue(context.Background(), "hello", "/workspace", opts)
+	_, nilOptsErr := client.Continue(context.Background(), "again", "/workspace", nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "reply to hello", resp.Output)
+	require.NoError(t, nilOptsErr)
+	require.Len(t, recorder.claude, 2)
+	assert.Equal(t, true, recorder.claude[0]["continue"])
+	assert.Equal(t, "sonnet", recorder.claude[0]["model"])
+	assert.Equal(t, true, recorder.claude[1]["continue"])
+	assert.False(t, opts.Continue)
+}
+
+// TestContinue_WithResume tests that Continue rejects options that also
+// resume a session, without sending the run.
+func TestContinue_WithResume(t *testing.T) {
+	// Arrange
+	recorder := &conversationServer{}
+	server := recorder.start()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Continue(context.Background(), "hello", "/workspace",
+		&stromboli.ClaudeOptions{SessionID: "sess-1", Resume: true})
+
+	// Assert
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+	assert.Empty(t, recorder.claude)
+}
@@ -0,0 +1,183 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// defaultOptionsClient returns a client with default Claude and Podman
+// options, sending run requests to a server recording their bodies.
+func defaultOptionsClient(t *testing.T, bodies *[]map[string]interface{}, opts ...stromboli.Option) *stromboli.Client {
+	t.Helper()
+	server := runShapingServer(t, "0.4.2", bodies)
+	t.Cleanup(server.Close)
+
+	opts = append([]stromboli.Option{
+		stromboli.WithDefaultClaudeOptions(&stromboli.ClaudeOptions{
+			Model:        stromboli.ModelSonnet,
+			AllowedTools: []string{"Read", "Grep"},
+			MaxBudgetUSD: 2,
+		}),
+		stromboli.WithDefaultPodmanOptions(&stromboli.PodmanOptions{
+			Memory:     "2g",
+			Volumes:    []string{"/project:/workspace:ro"},
+			SecretsEnv: map[string]string{"GH_TOKEN": "github-token", "NPM_TOKEN": "npm-token"},
+		}),
+	}, opts...)
+	client, err := stromboli.NewClient(server.URL, opts...)
+	require.NoError(t, err)
+	return client
+}
+
+// TestDefaultOptions_Merge tests the precedence of request fields over
+// defaults: scalars override, slices append (a volume replacing the default
+// mounted at its container path) and maps merge.
+func TestDefaultOptions_Merge(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *stromboli.RunRequest
+		wantClaude map[string]interface{}
+		wantPodman map[string]interface{}
+	}{
+		{
+			name: "defaults only",
+			req:  &stromboli.RunRequest{Prompt: "Hello"},
+			wantClaude: map[string]interface{}{
+				"model": "sonnet", "allowed_tools": []interface{}{"Read", "Grep"}, "max_budget_usd": 2.0,
+			},
+			wantPodman: map[string]interface{}{
+				"memory": "2g", "volumes": []interface{}{"/project:/workspace:ro"},
+				"secrets_env": map[string]interface{}{"GH_TOKEN": "github-token", "NPM_TOKEN": "npm-token"},
+			},
+		},
+		{
+			name: "request overrides and appends",
+			req: &stromboli.RunRequest{
+				Prompt: "Hello",
+				Claude: &stromboli.ClaudeOptions{
+					Model:        stromboli.ModelOpus,
+					AllowedTools: []string{"Write", "Read"},
+				},
+				Podman: &stromboli.PodmanOptions{
+					Memory:     "4g",
+					Volumes:    []string{"/cache:/cache"},
+					SecretsEnv: map[string]string{"GH_TOKEN": "other-token"},
+				},
+			},
+			wantClaude: map[string]interface{}{
+				"model": "opus", "allowed_tools": []interface{}{"Read", "Grep", "Write"}, "max_budget_usd": 2.0,
+			},
+			wantPodman: map[string]interface{}{
+				"memory": "4g", "volumes": []interface{}{"/project:/workspace:ro", "/cache:/cache"},
+				"secrets_env": map[string]interface{}{"GH_TOKEN": "other-token", "NPM_TOKEN": "npm-token"},
+			},
+		},
+		{
+			name: "request volume replaces default at same container path",
+			req: &stromboli.RunRequest{
+				Prompt: "Hello",
+				Podman: &stromboli.PodmanOptions{
+					Volumes: []string{"/other-project:/workspace/", "/cache:/cache"},
+				},
+			},
+			wantClaude: map[string]interface{}{
+				"model": "sonnet", "allowed_tools": []interface{}{"Read", "Grep"}, "max_budget_usd": 2.0,
+			},
+			wantPodman: map[string]interface{}{
+				"volumes": []interface{}{"/other-project:/workspace/", "/cache:/cache"},
+			},
+		},
+		{
+			name: "empty slices clear defaults",
+			req: &stromboli.RunRequest{
+				Prompt: "Hello",
+				Claude: &stromboli.ClaudeOptions{AllowedTools: []string{}},
+				Podman: &stromboli.PodmanOptions{Volumes: []string{}},
+			},
+			wantClaude: map[string]interface{}{"model": "sonnet", "max_budget_usd": 2.0},
+			wantPodman: map[string]interface{}{
+				"memory": "2g", "volumes": nil,
+				"secrets_env": map[string]interface{}{"GH_TOKEN": "github-token", "NPM_TOKEN": "npm-token"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var bodies []map[string]interface{}
+			client := defaultOptionsClient(t, &bodies)
+
+			// Act
+			_, err := client.Run(context.Background(), tt.req)
+
+			// Assert
+			require.NoError(t, err)
+			require.Len(t, bodies, 1)
+			assert.Equal(t, tt.wantClaude, bodies[0]["claude"])
+			podman := bodies[0]["podman"].(map[string]interface{})
+			for key, want := range tt.wantPodman {
+				assert.Equal(t, want, podman[key], key)
+			}
+		})
+	}
+}
+
+// TestDefaultOptions_Isolation tests that neither the request nor the
+// options passed to the client are shared with the merged request.
+func TestDefaultOptions_Isolation(t *testing.T) {
+	// Arrange
+	var bodies []map[string]interface{}
+	defaults := &stromboli.ClaudeOptions{AllowedTools: []string{"Read"}}
+	client := defaultOptionsClient(t, &bodies, stromboli.WithDefaultClaudeOptions(defaults))
+	defaults.AllowedTools[0] = "Bash"
+	req := &stromboli.RunRequest{
+		Prompt: "Hello",
+		Claude: &stromboli.ClaudeOptions{AllowedTools: []string{"Write"}},
+	}
+
+	// Act
+	_, err := client.Run(context.Background(), req)
+	_, cloneErr := client.Clone(stromboli.WithDefaultClaudeOptions(nil)).Run(context.Background(), req)
+
+	// Assert
+	require.NoError(t, err)
+	require.NoError(t, cloneErr)
+	assert.Equal(t, []string{"Write"}, req.Claude.AllowedTools)
+	assert.Nil(t, req.Podman)
+	require.Len(t, bodies, 2)
+	assert.Equal(t, []interface{}{"Read", "Write"}, bodies[0]["claude"].(map[string]interface{})["allowed_tools"])
+	assert.Equal(t, []interface{}{"Write"}, bodies[1]["claude"].(map[string]interface{})["allowed_tools"])
+	assert.Contains(t, bodies[1], "podman", "the clone keeps the Podman defaults")
+}
+
+// TestDefaultOptions_Resolve tests that the fields set from defaults are
+// reported as transformations.
+func TestDefaultOptions_Resolve(t *testing.T) {
+	// Arrange
+	var bodies []map[string]interface{}
+	client := defaultOptionsClient(t, &bodies)
+	req := &stromboli.RunRequest{
+		Prompt: "Hello",
+		Claude: &stromboli.ClaudeOptions{Model: stromboli.ModelOpus, AllowedTools: []string{"Write"}},
+		Podman: &stromboli.PodmanOptions{SecretsEnv: map[string]string{"GH_TOKEN": "other-token", "NPM_TOKEN": "npm"}},
+	}
+
+	// Act
+	resolved, err := client.ResolveRequest(context.Background(), req)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, bodies)
+	assert.Equal(t, []stromboli.Transformation{
+		{Kind: stromboli.TransformationDefaultApplied, Field: "claude.max_budget_usd"},
+		{Kind: stromboli.TransformationDefaultApplied, Field: "claude.allowed_tools", Detail: "defaults followed by request values"},
+		{Kind: stromboli.TransformationDefaultApplied, Field: "podman.memory"},
+		{Kind: stromboli.TransformationDefaultApplied, Field: "podman.volumes"},
+	}, resolved.Transformations)
+}
@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// healthAndStreamHandler answers health checks and streams one event.
+func healthAndStreamHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/run/stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: Hello\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok", "version": "0.3.0"})
+	})
+}
+
+// TestNewClient_UnixSocket tests that requests and streams are sent over
+// the Unix socket of a unix:// base URL.
+func TestNewClient_UnixSocket(t *testing.T) {
+	// Arrange
+	socketPath := filepath.Join(t.TempDir(), "stromboli.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	server := &httptest.Server{Listener: listener, Config: &http.Server{Handler: healthAndStreamHandler()}}
+	server.Start()
+	defer server.Close()
+
+	client, err := stromboli.NewClient("unix://" + socketPath)
+	require.NoError(t, err)
+
+	// Act
+	health, healthErr := client.Health(context.Background())
+	stream, streamErr := client.Clone().Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Hi"})
+	require.NoError(t, streamErr)
+	defer stream.Close()
+	var events []string
+	for stream.Next() {
+		events = append(events, stream.Event().Data)
+	}
+
+	// Assert
+	require.NoError(t, healthErr)
+	assert.Equal(t, "ok", health.Status)
+	require.NoError(t, stream.Err())
+	assert.Equal(t, []string{"Hello"}, events)
+}
+
+// TestNewClient_InvalidUnixSocket tests the errors for malformed unix://
+// base URLs.
+func TestNewClient_InvalidUnixSocket(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		wantErr string
+	}{
+		{"no path", "unix://", "must include the socket path"},
+		{"root path", "unix:///", "must include the socket path"},
+		{"relative path", "unix://run/stromboli.sock", "must not have a host"},
+		{"opaque", "unix:run/stromboli.sock", "use unix:///path/to/socket"},
+		{"directory", "unix:///run/", "invalid unix socket path"},
+		{"unclean path", "unix:///run/../stromboli.sock", "invalid unix socket path"},
+		{"query", "unix:///run/stromboli.sock?x=1", "must not have a query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			client, err := stromboli.NewClient(tt.baseURL)
+
+			// Assert
+			assert.Nil(t, client)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// TestWithDialContext tests that connections are opened with the custom
+// dialer, without modifying the HTTP client passed to WithHTTPClient.
+func TestWithDialContext(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(healthAndStreamHandler())
+	defer server.Close()
+
+	var mu sync.Mutex
+	var dialed []string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		mu.Lock()
+		dialed = append(dialed, network+" "+addr)
+		mu.Unlock()
+		var d net.Dialer
+		return d.DialContext(ctx, network, server.Listener.Addr().String())
+	}
+	transport := &http.Transport{}
+	client, err := stromboli.NewClient("http://stromboli.internal:8585",
+		stromboli.WithHTTPClient(&http.Client{Transport: transport}),
+		stromboli.WithDialContext(dial),
+	)
+	require.NoError(t, err)
+
+	// Act
+	_, healthErr := client.Health(context.Background())
+	stream, streamErr := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Hi"})
+	require.NoError(t, streamErr)
+	_ = stream.Close()
+
+	// Assert
+	require.NoError(t, healthErr)
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, dialed)
+	assert.Equal(t, "tcp stromboli.internal:8585", dialed[0])
+	assert.Nil(t, transport.DialContext, "the caller's transport is not modified")
+}
+
+// TestWithDialContext_UnixSocket tests that the custom dialer is used to
+// connect to the Unix socket.
+func TestWithDialContext_UnixSocket(t *testing.T) {
+	// Arrange
+	socketPath := filepath.Join(t.TempDir(), "stromboli.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	server := &httptest.Server{Listener: listener, Config: &http.Server{Handler: healthAndStreamHandler()}}
+	server.Start()
+	defer server.Close()
+
+	var dialed []string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, network+" "+addr)
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+	client, err := stromboli.NewClient("unix://"+socketPath, stromboli.WithDialContext(dial))
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Health(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unix " + socketPath}, dialed)
+}
@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// errClaudeNotConfigured is the application error of the mapper tests.
+var errClaudeNotConfigured = errors.New("claude is not configured")
+
+// claudeNotConfiguredMapper maps "claude not configured" errors to
+// errClaudeNotConfigured and records the errors it receives.
+func claudeNotConfiguredMapper(received *[]*stromboli.Error) stromboli.ErrorMapper {
+	return func(e *stromboli.Error) error {
+		*received = append(*received, e)
+		if strings.Contains(e.Message, "claude not configured") {
+			return errClaudeNotConfigured
+		}
+		return nil
+	}
+}
+
+// TestWithErrorMapper tests that server errors are translated by the
+// mapper for generated and hand-written endpoints, and that the original
+// Error stays reachable.
+func TestWithErrorMapper(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		mustEncode(w, map[string]string{"error": "claude not configured"})
+	}))
+	defer server.Close()
+	var received []*stromboli.Error
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithRetries(0),
+		stromboli.WithErrorMapper(claudeNotConfiguredMapper(&received)),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, runErr := client.Run(ctx, &stromboli.RunRequest{Prompt: "Hello"})
+	_, listErr := client.ListJobs(ctx)
+
+	// Assert
+	for _, err := range []error{runErr, listErr} {
+		require.Error(t, err)
+		assert.ErrorIs(t, err, errClaudeNotConfigured)
+		assert.Equal(t, errClaudeNotConfigured.Error(), err.Error())
+		assert.ErrorIs(t, err, stromboli.ErrUnavailable)
+		var apiErr *stromboli.Error
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusServiceUnavailable, apiErr.Status)
+	}
+	require.Len(t, received, 2)
+	assert.Equal(t, stromboli.ErrUnavailable.Code, received[0].Code)
+	assert.Equal(t, http.StatusServiceUnavailable, received[0].Status)
+	assert.Equal(t, "claude not configured", received[0].Message)
+}
+
+// TestWithErrorMapper_Unmapped tests that errors the mapper ignores and
+// client-side errors are returned unchanged.
+func TestWithErrorMapper_Unmapped(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		mustEncode(w, map[string]string{"error": "job not found"})
+	}))
+	defer server.Close()
+	var received []*stromboli.Error
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithErrorMapper(claudeNotConfiguredMapper(&received)),
+	)
+	require.NoError(t, err)
+
+	// Act
+	_, notFoundErr := client.GetJob(context.Background(), "job-1")
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, cancelErr := client.GetJob(cancelled, "job-1")
+
+	// Assert
+	var apiErr *stromboli.Error
+	require.True(t, errors.As(notFoundErr, &apiErr))
+	assert.Equal(t, apiErr.Error(), notFoundErr.Error())
+	assert.ErrorIs(t, notFoundErr, stromboli.ErrNotFound)
+	assert.NotErrorIs(t, notFoundErr, errClaudeNotConfigured)
+	assert.Error(t, cancelErr)
+	assert.Len(t, received, 1)
+}
+
+// TestWithErrorMapper_EnsureSecrets tests that the SDK's own handling of
+// error codes still works when the mapper translates every error.
+func TestWithErrorMapper_EnsureSecrets(t *testing.T) {
+	// Arrange
+	store := newSecretStore()
+	store.values["github-token"] = "ghp_xxx"
+	server := store.server()
+	defer server.Close()
+	errDomain := errors.New("domain error")
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithErrorMapper(func(*stromboli.Error) error { return errDomain }),
+	)
+	require.NoError(t, err)
+
+	// Act
+	err = client.EnsureSecrets(context.Background(), []*stromboli.CreateSecretRequest{
+		{Name: "github-token", Value: "ghp_yyy"},
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "ghp_xxx", store.values["github-token"])
+}
@@ -0,0 +1,256 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// modelScript is a fake server answering runs according to the requested
+// model: "overloaded" fails with a 503 OVERLOADED error, "overloaded-run"
+// returns an error response reporting an overloaded API, "rate-limited"
+// fails with a 429, "bad" fails with a 400 and "ok" succeeds in the
+// requested session (or "sess-new"). It records the Claude options of
+// every run.
+type modelScript struct {
+	mu        sync.Mutex
+	outcomes  map[string]string
+	modelUsed string // reported in successful responses, if set
+	claude    []map[string]interface{}
+}
+
+// server starts the server.
+func (s *modelScript) server() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Claude map[string]interface{} `json:"claude"`
+		}
+		mustDecode(r, &body)
+		s.mu.Lock()
+		s.claude = append(s.claude, body.Claude)
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		model, _ := body.Claude["model"].(string)
+		switch s.outcomes[model] {
+		case "overloaded":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			mustEncode(w, map[string]string{"error": "model overloaded", "code": "OVERLOADED"})
+		case "overloaded-run":
+			mustEncode(w, map[string]interface{}{
+				"id": "run-1", "status": "error", "session_id": "sess-lost",
+				"error": `API Error: 529 {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			})
+		case "rate-limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+			mustEncode(w, map[string]string{"error": "slow down"})
+		case "bad":
+			w.WriteHeader(http.StatusBadRequest)
+			mustEncode(w, map[string]string{"error": "invalid agent"})
+		default:
+			sessionID, _ := body.Claude["session_id"].(string)
+			if sessionID == "" {
+				sessionID = "sess-new"
+			}
+			resp := map[string]interface{}{
+				"id": "run-1", "status": "completed", "output": "answer from " + model, "session_id": sessionID,
+			}
+			if s.modelUsed != "" {
+				resp["model_used"] = s.modelUsed
+			}
+			mustEncode(w, resp)
+		}
+	}))
+}
+
+// models returns the models requested, in order.
+func (s *modelScript) models() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	models := make([]string, len(s.claude))
+	for i, claude := range s.claude {
+		models[i], _ = claude["model"].(string)
+	}
+	return models
+}
+
+// escalation is the haiku -> sonnet -> opus fallback chain.
+var escalation = []stromboli.Model{stromboli.ModelHaiku, stromboli.ModelSonnet, stromboli.ModelOpus}
+
+// TestRunWithFallbacks_Escalates tests that overloaded models are skipped
+// until one answers.
+func TestRunWithFallbacks_Escalates(t *testing.T) {
+	// Arrange
+	script := &modelScript{outcomes: map[string]string{"haiku": "overloaded", "sonnet": "overloaded-run", "opus": "ok"}}
+	server := script.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	req := &stromboli.RunRequest{
+		Prompt: "Review this",
+		Claude: &stromboli.ClaudeOptions{Model: stromboli.ModelHaiku, MaxBudgetUSD: 2},
+	}
+
+	// Act
+	resp, err := client.RunWithFallbacks(context.Background(), req, escalation, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "answer from opus", resp.Output)
+	assert.Equal(t, stromboli.ModelOpus, resp.ModelUsed)
+	assert.Equal(t, []string{"haiku", "sonnet", "opus"}, script.models())
+	assert.Equal(t, 2.0, script.claude[2]["max_budget_usd"])
+	assert.Equal(t, stromboli.ModelHaiku, req.Claude.Model, "request is not modified")
+}
+
+// TestRunWithFallbacks_KeepsSession tests that every attempt continues the
+// requested session, and that sessions of overloaded attempts are dropped.
+func TestRunWithFallbacks_KeepsSession(t *testing.T) {
+	// Arrange
+	script := &modelScript{outcomes: map[string]string{"haiku": "overloaded-run", "sonnet": "ok"}}
+	server := script.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	resumed, err := client.RunWithFallbacks(context.Background(), &stromboli.RunRequest{
+		Prompt: "Continue",
+		Claude: &stromboli.ClaudeOptions{SessionID: "sess-abc123", Resume: true},
+	}, escalation, nil)
+	require.NoError(t, err)
+	fresh, err := client.RunWithFallbacks(context.Background(), &stromboli.RunRequest{Prompt: "Start"}, escalation, nil)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "sess-abc123", resumed.SessionID)
+	assert.Equal(t, "sess-new", fresh.SessionID)
+	require.Len(t, script.claude, 4)
+	for _, claude := range script.claude[:2] {
+		assert.Equal(t, "sess-abc123", claude["session_id"])
+		assert.Equal(t, true, claude["resume"])
+	}
+	assert.Nil(t, script.claude[3]["session_id"], "session of the overloaded attempt is not resumed")
+}
+
+// TestRunWithFallbacks_StopsOnOtherOutcomes tests that failures other than
+// overloads are returned without trying other models.
+func TestRunWithFallbacks_StopsOnOtherOutcomes(t *testing.T) {
+	// Arrange
+	script := &modelScript{outcomes: map[string]string{"haiku": "bad", "sonnet": "ok"}}
+	server := script.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	resp, err := client.RunWithFallbacks(context.Background(), &stromboli.RunRequest{Prompt: "Hi"}, escalation, nil)
+
+	// Assert
+	assert.Nil(t, resp)
+	assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+	assert.Equal(t, []string{"haiku"}, script.models())
+}
+
+// TestRunWithFallbacks_AllOverloaded tests that the last outcome is
+// returned when every model is overloaded.
+func TestRunWithFallbacks_AllOverloaded(t *testing.T) {
+	// Arrange
+	script := &modelScript{outcomes: map[string]string{"haiku": "overloaded", "sonnet": "overloaded", "opus": "overloaded-run"}}
+	server := script.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	resp, err := client.RunWithFallbacks(context.Background(), &stromboli.RunRequest{Prompt: "Hi"}, escalation, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, resp.IsSuccess())
+	assert.Equal(t, stromboli.ModelOpus, resp.ModelUsed)
+	assert.Len(t, script.models(), 3)
+}
+
+// TestRunWithFallbacks_Classifier tests a custom classifier and a model
+// reported by the server.
+func TestRunWithFallbacks_Classifier(t *testing.T) {
+	// Arrange
+	script := &modelScript{
+		outcomes:  map[string]string{"haiku": "rate-limited", "sonnet": "ok"},
+		modelUsed: "claude-sonnet-4-20250514",
+	}
+	server := script.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	classify := func(resp *stromboli.RunResponse, err error) bool {
+		return errors.Is(err, stromboli.ErrRateLimited) || stromboli.IsOverloaded(resp, err)
+	}
+
+	// Act
+	defaultResp, defaultErr := client.RunWithFallbacks(context.Background(), &stromboli.RunRequest{Prompt: "Hi"}, escalation, nil)
+	resp, err := client.RunWithFallbacks(context.Background(), &stromboli.RunRequest{Prompt: "Hi"}, escalation, classify)
+
+	// Assert
+	assert.Nil(t, defaultResp)
+	assert.True(t, errors.Is(defaultErr, stromboli.ErrRateLimited))
+	require.NoError(t, err)
+	assert.Equal(t, stromboli.Model("claude-sonnet-4-20250514"), resp.ModelUsed)
+	assert.Equal(t, []string{"haiku", "haiku", "sonnet"}, script.models())
+}
+
+// TestRunWithFallbacks_InvalidArguments tests that a missing request or an
+// empty model list is rejected.
+func TestRunWithFallbacks_InvalidArguments(t *testing.T) {
+	// Arrange
+	client, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+
+	// Act
+	_, noModels := client.RunWithFallbacks(context.Background(), &stromboli.RunRequest{Prompt: "Hi"}, nil, nil)
+	_, noRequest := client.RunWithFallbacks(context.Background(), nil, escalation, nil)
+
+	// Assert
+	assert.True(t, errors.Is(noModels, stromboli.ErrBadRequest))
+	assert.True(t, errors.Is(noRequest, stromboli.ErrBadRequest))
+}
+
+// TestIsOverloaded tests the default classifier.
+func TestIsOverloaded(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *stromboli.RunResponse
+		err  error
+		want bool
+	}{
+		{"success", &stromboli.RunResponse{Status: "completed"}, nil, false},
+		{"overloaded response", &stromboli.RunResponse{Status: "error", Error: "529 Overloaded"}, nil, true},
+		{"other error response", &stromboli.RunResponse{Status: "error", Error: "max turns reached"}, nil, false},
+		{"unavailable", nil, &stromboli.Error{Code: "UNAVAILABLE", Status: 503}, true},
+		{"model overloaded code", nil, &stromboli.Error{Code: "MODEL_OVERLOADED", Status: 500}, true},
+		{"status 529", nil, &stromboli.Error{Code: "INTERNAL", Status: 529}, true},
+		{"maintenance", nil, &stromboli.MaintenanceError{Err: &stromboli.Error{Code: "MAINTENANCE", Status: 503}}, false},
+		{"bad request", nil, &stromboli.Error{Code: "BAD_REQUEST", Status: 400}, false},
+		{"network error", nil, errors.New("connection refused"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, stromboli.IsOverloaded(tt.resp, tt.err))
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// sseHandler returns a handler that streams the given data events followed
+// by a done event.
+func sseHandler(data ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, d := range data {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", d)
+		}
+		_, _ = fmt.Fprintf(w, "event: done\ndata: \n\n")
+	}
+}
+
+// newSequenceClient creates a client whose n-th request is answered by
+// handlers[n], so that variants run one at a time get their own handler.
+func newSequenceClient(t *testing.T, handlers ...http.HandlerFunc) *stromboli.Client {
+	t.Helper()
+	var mu sync.Mutex
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		handler := handlers[min(calls, len(handlers)-1)]
+		calls++
+		mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	return client
+}
+
+// failingHandler answers with a 500.
+func failingHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
+// TestStreamFanout_TagsEventsByVariant tests that events are routed to the
+// handler with the index of the variant that produced them.
+func TestStreamFanout_TagsEventsByVariant(t *testing.T) {
+	// Arrange
+	client := newSequenceClient(t, sseHandler("py-1", "py-2"), sseHandler("node-1"))
+	variants := make([]stromboli.PodmanOptions, 2)
+
+	var mu sync.Mutex
+	got := map[int][]string{}
+
+	// Act
+	summaries, err := client.StreamFanout(context.Background(),
+		&stromboli.StreamRequest{Prompt: "hello"}, variants,
+		func(i int, ev *stromboli.StreamEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			if ev.Type == "" {
+				got[i] = append(got[i], ev.Data)
+			}
+		}, &stromboli.FanoutOptions{Concurrency: 1})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, []string{"py-1", "py-2"}, got[0])
+	assert.Equal(t, []string{"node-1"}, got[1])
+
+	assert.NoError(t, summaries[0].Err)
+	assert.Equal(t, "py-1py-2", summaries[0].Output)
+	assert.Equal(t, 3, summaries[0].Events)
+	assert.NoError(t, summaries[1].Err)
+	assert.Equal(t, "node-1", summaries[1].Output)
+}
+
+// TestStreamFanout_FailingVariantIsIsolated tests that a failing variant is
+// reported without aborting the others.
+func TestStreamFanout_FailingVariantIsIsolated(t *testing.T) {
+	// Arrange
+	client := newSequenceClient(t, failingHandler, sseHandler("ok"))
+	variants := make([]stromboli.PodmanOptions, 2)
+
+	// Act
+	summaries, err := client.StreamFanout(context.Background(),
+		&stromboli.StreamRequest{Prompt: "hello"}, variants, nil,
+		&stromboli.FanoutOptions{Concurrency: 1})
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "variant 0")
+	require.Len(t, summaries, 2)
+	assert.Error(t, summaries[0].Err)
+	assert.NoError(t, summaries[1].Err)
+	assert.Equal(t, "ok", summaries[1].Output)
+}
+
+// TestStreamFanout_ErrorEvent tests that an "error" event fails its variant.
+func TestStreamFanout_ErrorEvent(t *testing.T) {
+	// Arrange
+	client := newSequenceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprintf(w, "event: error\ndata: image not allowed\n\n")
+	})
+
+	// Act
+	summaries, err := client.StreamFanout(context.Background(),
+		&stromboli.StreamRequest{Prompt: "hello"},
+		make([]stromboli.PodmanOptions, 1), nil, nil)
+
+	// Assert
+	require.Error(t, err)
+	var apiErr *stromboli.Error
+	require.ErrorAs(t, summaries[0].Err, &apiErr)
+	assert.Equal(t, "STREAM_ERROR", apiErr.Code)
+	assert.Equal(t, "image not allowed", apiErr.Message)
+}
+
+// TestStreamFanout_FailFast tests that FailFast cancels variants that have
+// not started yet.
+func TestStreamFanout_FailFast(t *testing.T) {
+	// Arrange
+	client := newSequenceClient(t, failingHandler, sseHandler("ok"))
+	variants := make([]stromboli.PodmanOptions, 2)
+
+	// Act
+	summaries, err := client.StreamFanout(context.Background(),
+		&stromboli.StreamRequest{Prompt: "hello"}, variants, nil,
+		&stromboli.FanoutOptions{Concurrency: 1, FailFast: true})
+
+	// Assert
+	require.Error(t, err)
+	require.Len(t, summaries, 2)
+	assert.Error(t, summaries[0].Err)
+	var apiErr *stromboli.Error
+	require.ErrorAs(t, summaries[1].Err, &apiErr)
+	assert.Equal(t, "CANCELLED", apiErr.Code)
+}
+
+// imageJobServer is a fake server for fanouts with Podman variants: each
+// async run starts a job named after its image, whose stream is served by
+// the handler of that image. Zero-valued variants are served by the
+// "default" handler on the streaming endpoint.
+type imageJobServer struct {
+	mu        sync.Mutex
+	runs      []*stromboli.RunRequest
+	cancelled []string
+}
+
+// server serves the jobs with the given stream handlers, by image.
+func (s *imageJobServer) server(t *testing.T, streams map[string]http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/run/async":
+			var req stromboli.RunRequest
+			mustDecode(r, &req)
+			s.mu.Lock()
+			s.runs = append(s.runs, &req)
+			s.mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			mustEncode(w, map[string]interface{}{"job_id": "job-" + req.Podman.Image})
+		case r.URL.Path == "/run/stream":
+			streams["default"](w, r)
+		case r.Method == http.MethodDelete:
+			s.mu.Lock()
+			s.cancelled = append(s.cancelled, strings.TrimPrefix(r.URL.Path, "/jobs/"))
+			s.mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			mustEncode(w, map[string]interface{}{"success": true})
+		case strings.HasSuffix(r.URL.Path, "/stream"):
+			image := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/jobs/job-"), "/stream")
+			streams[image](w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestStreamFanout_PodmanVariants tests that variants with Podman options
+// run as async jobs with their own container options and are followed with
+// StreamJob, while zero-valued variants use the streaming endpoint.
+func TestStreamFanout_PodmanVariants(t *testing.T) {
+	// Arrange
+	fake := &imageJobServer{}
+	server := fake.server(t, map[string]http.HandlerFunc{
+		"python:3.12": sseHandler("py-1"),
+		"node:22":     sseHandler("node-1"),
+		"default":     sseHandler("default-1"),
+	})
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	variants := []stromboli.PodmanOptions{{Image: "python:3.12"}, {Image: "node:22"}, {}}
+
+	var mu sync.Mutex
+	outputs := make([][]string, len(variants))
+
+	// Act
+	summaries, err := client.StreamFanout(context.Background(),
+		&stromboli.StreamRequest{Prompt: "hello", Workdir: "/src", SessionID: "sess-1"},
+		variants, func(i int, ev *stromboli.StreamEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			if ev.Data != "" {
+				outputs[i] = append(outputs[i], ev.Data)
+			}
+		}, nil)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, summaries, 3)
+	assert.Equal(t, []string{"py-1"}, outputs[0])
+	assert.Equal(t, []string{"node-1"}, outputs[1])
+	assert.Equal(t, []string{"default-1"}, outputs[2])
+	assert.Equal(t, "py-1", summaries[0].Output)
+
+	require.Len(t, fake.runs, 2)
+	for _, run := range fake.runs {
+		assert.Equal(t, "hello", run.Prompt)
+		assert.Equal(t, "/src", run.Workdir)
+		require.NotNil(t, run.Claude)
+		assert.Equal(t, "sess-1", run.Claude.SessionID)
+		assert.True(t, run.Claude.Resume)
+	}
+	assert.Empty(t, fake.cancelled)
+}
+
+// TestStreamFanout_CancelsJobsOfCancelledVariants tests that the jobs of
+// variants cancelled by FailFast don't keep running on the server.
+func TestStreamFanout_CancelsJobsOfCancelledVariants(t *testing.T) {
+	// Arrange
+	fake := &imageJobServer{}
+	slowStarted := make(chan struct{})
+	server := fake.server(t, map[string]http.HandlerFunc{
+		"broken": func(w http.ResponseWriter, r *http.Request) {
+			<-slowStarted // fail once the other job is being followed
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprintf(w, "event: error\ndata: image not allowed\n\n")
+		},
+		"slow": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.(http.Flusher).Flush()
+			close(slowStarted)
+			<-r.Context().Done()
+		},
+	})
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	variants := []stromboli.PodmanOptions{{Image: "slow"}, {Image: "broken"}}
+
+	// Act
+	summaries, err := client.StreamFanout(context.Background(),
+		&stromboli.StreamRequest{Prompt: "hello"}, variants, nil,
+		&stromboli.FanoutOptions{FailFast: true})
+
+	// Assert
+	require.Error(t, err)
+	require.Len(t, summaries, 2)
+	assert.Error(t, summaries[0].Err)
+	assert.Error(t, summaries[1].Err)
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	assert.Equal(t, []string{"job-slow"}, fake.cancelled)
+}
@@ -0,0 +1,476 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// jobQueue is a fake job server: async runs create running jobs whose
+// status can be changed with finish, and sync runs block until release is
+// closed. It counts the runs received.
+type jobQueue struct {
+	mu       sync.Mutex
+	statuses map[string]string
+	runs     int
+	release  chan struct{}
+}
+
+// newJobQueue creates an empty jobQueue whose sync runs return at once.
+func newJobQueue() *jobQueue {
+	q := &jobQueue{statuses: map[string]string{}, release: make(chan struct{})}
+	close(q.release)
+	return q
+}
+
+// finish sets the status of a job.
+func (q *jobQueue) finish(jobID, status string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.statuses[jobID] = status
+}
+
+// purge forgets a job, as the server does once it cleaned it up.
+func (q *jobQueue) purge(jobID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.statuses, jobID)
+}
+
+// runCount returns the number of runs received.
+func (q *jobQueue) runCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.runs
+}
+
+// server serves the queue.
+func (q *jobQueue) server() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/run":
+			q.mu.Lock()
+			q.runs++
+			q.mu.Unlock()
+			<-q.release
+			mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed"})
+		case r.URL.Path == "/run/async":
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.runs++
+			jobID := fmt.Sprintf("job-%d", q.runs)
+			q.statuses[jobID] = stromboli.JobStatusRunning
+			w.WriteHeader(http.StatusAccepted)
+			mustEncode(w, map[string]interface{}{"job_id": jobID})
+		case r.URL.Path == "/jobs" && r.Method == http.MethodGet:
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			jobs := []map[string]interface{}{}
+			for id, status := range q.statuses {
+				jobs = append(jobs, map[string]interface{}{"id": id, "status": status})
+			}
+			mustEncode(w, map[string]interface{}{"jobs": jobs})
+		case strings.HasPrefix(r.URL.Path, "/jobs/"):
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			jobID := strings.TrimPrefix(r.URL.Path, "/jobs/")
+			status, ok := q.statuses[jobID]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				mustEncode(w, map[string]string{"error": "job not found"})
+				return
+			}
+			if r.Method == http.MethodDelete {
+				q.statuses[jobID] = stromboli.JobStatusCancelled
+				mustEncode(w, map[string]interface{}{"success": true})
+				return
+			}
+			mustEncode(w, map[string]interface{}{"id": jobID, "status": status})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// runAsyncInBackground starts RunAsync in a goroutine and returns the
+// channel of its error.
+func runAsyncInBackground(ctx context.Context, client *stromboli.Client) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.RunAsync(ctx, &stromboli.RunRequest{Prompt: "queued"})
+		done <- err
+	}()
+	return done
+}
+
+// TestSubmissionGate_RunAsync tests that RunAsync blocks while the gate is
+// full and proceeds once a job is seen completed.
+func TestSubmissionGate_RunAsync(t *testing.T) {
+	// Arrange
+	queue := newJobQueue()
+	server := queue.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSubmissionGate(2))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.RunAsync(ctx, &stromboli.RunRequest{Prompt: "work"})
+		require.NoError(t, err)
+	}
+
+	// Act
+	done := runAsyncInBackground(ctx, client)
+
+	// Assert
+	select {
+	case err := <-done:
+		t.Fatalf("RunAsync returned while the gate was full: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, 2, queue.runCount())
+	assert.Equal(t, 2, client.InFlightJobs())
+
+	// A running job doesn't release its slot
+	_, err = client.GetJob(ctx, "job-2")
+	require.NoError(t, err)
+	assert.Equal(t, 2, client.InFlightJobs())
+
+	queue.finish("job-1", stromboli.JobStatusCompleted)
+	_, err = client.GetJob(ctx, "job-1")
+	require.NoError(t, err)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunAsync still blocked after a job completed")
+	}
+	assert.Equal(t, 3, queue.runCount())
+	assert.Equal(t, 2, client.InFlightJobs())
+}
+
+// TestSubmissionGate_Release tests the other ways async jobs release their
+// slot, and that failed submissions don't keep one.
+func TestSubmissionGate_Release(t *testing.T) {
+	// Arrange
+	queue := newJobQueue()
+	server := queue.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSubmissionGate(4))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		_, err := client.RunAsync(ctx, &stromboli.RunRequest{Prompt: "work"})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 4, client.InFlightJobs())
+
+	// Act & Assert
+	queue.finish("job-1", stromboli.JobStatusFailed)
+	_, err = client.ListJobs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, client.InFlightJobs(), "terminal job seen by ListJobs")
+
+	queue.finish("job-2", stromboli.JobStatusCompleted)
+	var job stromboli.Job
+	require.NoError(t, client.GetJobInto(ctx, "job-2", &job))
+	assert.Equal(t, 2, client.InFlightJobs(), "terminal job seen by GetJobInto")
+
+	require.NoError(t, client.CancelJob(ctx, "job-3"))
+	assert.Equal(t, 1, client.InFlightJobs(), "cancelled job")
+
+	client.ReleaseJob("job-4")
+	client.ReleaseJob("job-4")
+	assert.Equal(t, 0, client.InFlightJobs(), "released job")
+
+	_, err = client.RunAsync(ctx, &stromboli.RunRequest{
+		Prompt: "bad",
+		Podman: &stromboli.PodmanOptions{Memory: "lots"},
+	})
+	require.Error(t, err)
+	assert.Equal(t, 0, client.InFlightJobs(), "invalid request")
+}
+
+// TestSubmissionGate_Run tests that Run holds a slot while it runs.
+func TestSubmissionGate_Run(t *testing.T) {
+	// Arrange
+	queue := newJobQueue()
+	queue.release = make(chan struct{})
+	server := queue.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSubmissionGate(1))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	running := make(chan error, 1)
+	go func() {
+		_, err := client.Run(ctx, &stromboli.RunRequest{Prompt: "slow"})
+		running <- err
+	}()
+	require.Eventually(t, func() bool { return queue.runCount() == 1 }, 5*time.Second, 5*time.Millisecond)
+
+	// Act
+	done := runAsyncInBackground(ctx, client)
+
+	// Assert
+	select {
+	case err := <-done:
+		t.Fatalf("RunAsync returned while Run held the slot: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(queue.release)
+	require.NoError(t, <-running)
+	require.NoError(t, <-done)
+	assert.Equal(t, 2, queue.runCount())
+}
+
+// TestSubmissionGate_CancelledWhileBlocked tests that a blocked submission
+// returns when its context is done, without sending anything.
+func TestSubmissionGate_CancelledWhileBlocked(t *testing.T) {
+	// Arrange
+	queue := newJobQueue()
+	server := queue.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSubmissionGate(1))
+	require.NoError(t, err)
+	_, err = client.RunAsync(context.Background(), &stromboli.RunRequest{Prompt: "work"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Act
+	done := runAsyncInBackground(ctx, client)
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	// Assert
+	select {
+	case err := <-done:
+		var apiErr *stromboli.Error
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "CANCELLED", apiErr.Code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunAsync still blocked after its context was cancelled")
+	}
+	assert.Equal(t, 1, queue.runCount())
+	assert.Equal(t, 1, client.InFlightJobs())
+}
+
+// TestTrySubmit tests that TrySubmit fails fast while the gate is full.
+func TestTrySubmit(t *testing.T) {
+	// Arrange
+	queue := newJobQueue()
+	server := queue.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSubmissionGate(1))
+	require.NoError(t, err)
+	ctx := context.Background()
+	req := &stromboli.RunRequest{Prompt: "work"}
+
+	// Act
+	first, err := client.TrySubmit(ctx, req)
+	require.NoError(t, err)
+	_, fullErr := client.TrySubmit(ctx, req)
+	require.NoError(t, client.CancelJob(ctx, first.JobID))
+	_, err = client.TrySubmit(ctx, req)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, errors.Is(fullErr, stromboli.ErrSubmissionQueueFull))
+	assert.Equal(t, 2, queue.runCount())
+}
+
+// TestSubmissionGate_SharedByClones tests that clones count against the
+// same limit.
+func TestSubmissionGate_SharedByClones(t *testing.T) {
+	// Arrange
+	queue := newJobQueue()
+	server := queue.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSubmissionGate(1))
+	require.NoError(t, err)
+	clone := client.Clone()
+	unlimited := client.Clone(stromboli.WithSubmissionGate(0))
+
+	// Act
+	_, err = client.TrySubmit(context.Background(), &stromboli.RunRequest{Prompt: "work"})
+	require.NoError(t, err)
+	_, cloneErr := clone.TrySubmit(context.Background(), &stromboli.RunRequest{Prompt: "work"})
+	_, unlimitedErr := unlimited.TrySubmit(context.Background(), &stromboli.RunRequest{Prompt: "work"})
+
+	// Assert
+	assert.True(t, errors.Is(cloneErr, stromboli.ErrSubmissionQueueFull))
+	assert.NoError(t, unlimitedErr)
+}
+
+// TestSubmissionGate_GetJobNotFound tests that a job purged by the server
+// releases its slot when GetJob reports it not found.
+func TestSubmissionGate_GetJobNotFound(t *testing.T) {
+	// Arrange
+	queue := newJobQueue()
+	server := queue.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSubmissionGate(1))
+	require.NoError(t, err)
+	ctx := context.Background()
+	job, err := client.RunAsync(ctx, &stromboli.RunRequest{Prompt: "work"})
+	require.NoError(t, err)
+	queue.purge(job.JobID)
+
+	// Act
+	_, err = client.GetJob(ctx, job.JobID)
+
+	// Assert
+	assert.True(t, errors.Is(err, stromboli.ErrNotFound))
+	assert.Equal(t, 0, client.InFlightJobs())
+}
+
+// TestSubmissionGate_StreamJobDone tests that following a job with
+// StreamJob releases its slot once the "done" event is read.
+func TestSubmissionGate_StreamJobDone(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/run/async":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			mustEncode(w, map[string]interface{}{"job_id": "job-1"})
+		case "/jobs/job-1/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprintf(w, "data: working\n\nevent: done\ndata: \n\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSubmissionGate(1))
+	require.NoError(t, err)
+	ctx := context.Background()
+	job, err := client.RunAsync(ctx, &stromboli.RunRequest{Prompt: "work"})
+	require.NoError(t, err)
+
+	stream, err := client.StreamJob(ctx, job.JobID)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	// Act & Assert
+	require.True(t, stream.Next())
+	assert.Equal(t, "working", stream.Event().Data)
+	assert.Equal(t, 1, client.InFlightJobs(), "output event")
+
+	require.True(t, stream.Next())
+	assert.True(t, stream.Event().IsDone())
+	assert.Equal(t, 0, client.InFlightJobs(), "done event")
+}
+
+// TestSubmissionGate_WebhookHandler tests that the client's webhook handler
+// releases the slot of jobs reported finished.
+func TestSubmissionGate_WebhookHandler(t *testing.T) {
+	// Arrange
+	queue := newJobQueue()
+	server := queue.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSubmissionGate(2))
+	require.NoError(t, err)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := client.RunAsync(ctx, &stromboli.RunRequest{Prompt: "work"})
+		require.NoError(t, err)
+	}
+
+	var received []string
+	handler := client.WebhookHandler(func(ctx context.Context, p *stromboli.WebhookPayload) error {
+		received = append(received, p.ID)
+		return nil
+	})
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	// Act
+	runningCode := post(`{"id": "job-1", "status": "running"}`)
+	completedCode := post(`{"id": "job-2", "status": "completed"}`)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, runningCode)
+	assert.Equal(t, http.StatusNoContent, completedCode)
+	assert.Equal(t, []string{"job-1", "job-2"}, received)
+	assert.Equal(t, 1, client.InFlightJobs())
+}
+
+// TestSubmissionGate_LeaseExpiry tests that the slot of a job the client
+// never sees finish is reclaimed once its lease expires, both by TrySubmit
+// and by a submission blocked on the gate.
+func TestSubmissionGate_LeaseExpiry(t *testing.T) {
+	// Arrange
+	queue := newJobQueue()
+	server := queue.server()
+	defer server.Close()
+
+	clock := strombolitest.NewFakeClock(time.Now())
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithClock(clock),
+		stromboli.WithSubmissionGate(1),
+		stromboli.WithSubmissionLease(time.Minute),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+	req := &stromboli.RunRequest{Prompt: "fire and forget"}
+
+	_, err = client.RunAsync(ctx, req)
+	require.NoError(t, err)
+
+	// Act & Assert: TrySubmit
+	_, err = client.TrySubmit(ctx, req)
+	assert.True(t, errors.Is(err, stromboli.ErrSubmissionQueueFull), "lease still held")
+
+	clock.Advance(time.Minute)
+	_, err = client.TrySubmit(ctx, req)
+	require.NoError(t, err, "lease expired")
+
+	// Act & Assert: blocked RunAsync
+	done := runAsyncInBackground(ctx, client)
+	clock.BlockUntil(1)
+	select {
+	case err := <-done:
+		t.Fatalf("RunAsync returned while the lease was held: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clock.Advance(time.Minute)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunAsync still blocked after the lease expired")
+	}
+	assert.Equal(t, 3, queue.runCount())
+	assert.Equal(t, 1, client.InFlightJobs())
+}
@@ -0,0 +1,294 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// flappingHealthServer serves the given health responses in turn, an entry
+// of nil failing with a 500. Each request advances clock by 50ms.
+func flappingHealthServer(clock *strombolitest.FakeClock, responses []map[string]interface{}) *httptest.Server {
+	var mu sync.Mutex
+	calls := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		resp := responses[calls%len(responses)]
+		calls++
+		mu.Unlock()
+
+		clock.Advance(50 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		if resp == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			mustEncode(w, map[string]string{"error": "database locked"})
+			return
+		}
+		mustEncode(w, resp)
+	}))
+}
+
+// healthBody returns a health response with the given overall and podman
+// statuses and a healthy claude component.
+func healthBody(status, podman string) map[string]interface{} {
+	podmanHealth := map[string]interface{}{"name": "podman", "status": podman}
+	if podman != stromboli.StatusOK {
+		podmanHealth["error"] = "podman unavailable"
+	}
+	return map[string]interface{}{
+		"name":    "stromboli",
+		"status":  status,
+		"version": "0.4.0",
+		"components": []map[string]interface{}{
+			{"name": "claude", "status": "ok"},
+			podmanHealth,
+		},
+	}
+}
+
+// TestHealthPoller_Transitions tests that transitions are reported when
+// components flap and that snapshots reflect the last check.
+func TestHealthPoller_Transitions(t *testing.T) {
+	// Arrange
+	clock := strombolitest.NewFakeClock(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	server := flappingHealthServer(clock, []map[string]interface{}{
+		healthBody("ok", "ok"),
+		healthBody("error", "error"),
+		nil,
+		healthBody("ok", "ok"),
+	})
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClock(clock))
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var transitions []stromboli.HealthTransition
+	poller := stromboli.NewHealthPoller(client, time.Minute)
+	poller.OnChange = func(t stromboli.HealthTransition) {
+		mu.Lock()
+		defer mu.Unlock()
+		transitions = append(transitions, t)
+	}
+	// next runs the next check and returns the transitions it reported.
+	next := func() []stromboli.HealthTransition {
+		clock.Advance(time.Minute)
+		clock.BlockUntil(1)
+		mu.Lock()
+		defer mu.Unlock()
+		got := transitions
+		transitions = nil
+		return got
+	}
+
+	// Act
+	poller.Start(context.Background())
+	defer poller.Stop()
+	clock.BlockUntil(1)
+	first := poller.Snapshot()
+	degraded := next()
+	failed := next()
+	failedSnapshot := poller.Snapshot()
+	recovered := next()
+
+	// Assert
+	assert.True(t, first.Up)
+	assert.NoError(t, first.Err)
+	assert.Equal(t, map[string]bool{"claude": true, "podman": true}, first.Components)
+	assert.Equal(t, 50*time.Millisecond, first.Latency)
+	assert.Equal(t, "0.4.0", first.Health.Version)
+
+	assert.Equal(t, []stromboli.HealthTransition{
+		{Component: "", Up: false, Error: "status error"},
+		{Component: "podman", Up: false, Error: "podman unavailable"},
+	}, degraded)
+
+	require.Len(t, failed, 1)
+	assert.Equal(t, "claude", failed[0].Component)
+	assert.False(t, failed[0].Up)
+	assert.Contains(t, failed[0].Error, "database locked")
+	assert.False(t, failedSnapshot.Up)
+	assert.Error(t, failedSnapshot.Err)
+	assert.Equal(t, map[string]bool{"claude": false, "podman": false}, failedSnapshot.Components)
+	assert.Equal(t, "error", failedSnapshot.Health.Status, "last successful response is kept")
+
+	assert.Equal(t, []stromboli.HealthTransition{
+		{Component: "", Up: true},
+		{Component: "claude", Up: true},
+		{Component: "podman", Up: true},
+	}, recovered)
+}
+
+// TestHealthPoller_Lifecycle tests that Stop keeps the last snapshot, that
+// snapshots are copies and that the poller can be restarted.
+func TestHealthPoller_Lifecycle(t *testing.T) {
+	// Arrange
+	clock := strombolitest.NewFakeClock(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	server := flappingHealthServer(clock, []map[string]interface{}{healthBody("ok", "ok")})
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClock(clock))
+	require.NoError(t, err)
+	poller := stromboli.NewHealthPoller(client, 0)
+
+	// Act
+	before := poller.Snapshot()
+	poller.Start(context.Background())
+	poller.Start(context.Background()) // already running
+	clock.BlockUntil(1)
+	poller.Stop()
+	stopped := poller.Snapshot()
+	stopped.Components["podman"] = false
+	stopped.Health.Components[0].Status = "error"
+	poller.Start(context.Background())
+	clock.BlockUntil(1)
+	poller.Stop()
+	poller.Stop() // not running
+
+	// Assert
+	assert.True(t, before.CheckedAt.IsZero())
+	assert.Nil(t, before.Health)
+	snap := poller.Snapshot()
+	assert.True(t, snap.Up)
+	assert.True(t, snap.Components["podman"])
+	assert.Equal(t, "ok", snap.Health.Components[0].Status)
+	assert.Equal(t, clock.Now(), snap.CheckedAt)
+}
+
+// fastWait returns WaitHealthyOptions polling every few milliseconds.
+func fastWait(required ...string) *stromboli.WaitHealthyOptions {
+	return &stromboli.WaitHealthyOptions{
+		RequiredComponents: required,
+		MaxWait:            5 * time.Second,
+		InitialInterval:    time.Millisecond,
+		MaxInterval:        5 * time.Millisecond,
+	}
+}
+
+// TestWaitUntilHealthy_ConnectionRefused tests that WaitUntilHealthy keeps
+// waiting while the server refuses connections, and returns the first
+// healthy response once it is up.
+func TestWaitUntilHealthy_ConnectionRefused(t *testing.T) {
+	// Arrange
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	client, err := stromboli.NewClient("http://" + addr)
+	require.NoError(t, err)
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, healthBody("ok", "ok"))
+	})}
+	defer server.Close()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		_ = server.Serve(l)
+	}()
+
+	// Act
+	health, err := client.WaitUntilHealthy(context.Background(), fastWait("podman"))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "0.4.0", health.Version)
+}
+
+// TestWaitUntilHealthy_RequiredComponents tests that WaitUntilHealthy
+// waits for the overall status and the required components, and fails
+// with ErrNotHealthy when MaxWait expires.
+func TestWaitUntilHealthy_RequiredComponents(t *testing.T) {
+	// Arrange
+	var mu sync.Mutex
+	responses := []map[string]interface{}{
+		nil,
+		healthBody("error", "error"),
+		healthBody("ok", "error"),
+		healthBody("ok", "ok"),
+	}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		resp := responses[min(calls, len(responses)-1)]
+		calls++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if resp == nil {
+			w.WriteHeader(http.StatusBadGateway)
+			mustEncode(w, map[string]string{"error": "upstream not ready"})
+			return
+		}
+		mustEncode(w, resp)
+	}))
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	health, err := client.WaitUntilHealthy(ctx, fastWait("podman"))
+	checks := calls
+	opts := fastWait("docker")
+	opts.MaxWait = 30 * time.Millisecond
+	last, waitErr := client.WaitUntilHealthy(ctx, opts)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, health.IsHealthy())
+	assert.Equal(t, 4, checks)
+	require.Error(t, waitErr)
+	assert.True(t, errors.Is(waitErr, stromboli.ErrNotHealthy))
+	assert.Contains(t, waitErr.Error(), `component "docker" not reported`)
+	require.NotNil(t, last)
+	assert.Equal(t, "0.4.0", last.Version)
+}
+
+// TestWaitUntilHealthy_Errors tests that errors of a misconfigured client
+// are returned right away, and that a done context stops the wait.
+func TestWaitUntilHealthy_Errors(t *testing.T) {
+	// Arrange
+	var mu sync.Mutex
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		mustEncode(w, map[string]string{"error": "invalid token"})
+	}))
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	unstarted, err := stromboli.NewClient("http://127.0.0.1:1")
+	require.NoError(t, err)
+	opts := fastWait()
+	opts.MaxWait = 0
+
+	// Act
+	_, unauthorizedErr := client.WaitUntilHealthy(context.Background(), nil)
+	_, cancelErr := unstarted.WaitUntilHealthy(ctx, opts)
+
+	// Assert
+	assert.True(t, errors.Is(unauthorizedErr, stromboli.ErrUnauthorized))
+	assert.Equal(t, 1, calls)
+	assert.True(t, errors.Is(cancelErr, stromboli.ErrTimeout) || errors.Is(cancelErr, context.DeadlineExceeded), cancelErr)
+}
@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// idempotencyServer answers Run and RunAsync requests and records their
+// Idempotency-Key headers in order.
+type idempotencyServer struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+// start starts the server.
+func (s *idempotencyServer) start(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.keys = append(s.keys, r.Header.Get("Idempotency-Key"))
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/run/async" {
+			w.WriteHeader(http.StatusAccepted)
+			mustEncode(w, map[string]interface{}{"job_id": "job-1"})
+			return
+		}
+		mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed", "output": "done"})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// received returns the keys received so far.
+func (s *idempotencyServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.keys...)
+}
+
+// TestIdempotencyKey_Header tests that the idempotency key of a request is
+// sent in the Idempotency-Key header by Run and RunAsync, and that no
+// header is sent without a key.
+func TestIdempotencyKey_Header(t *testing.T) {
+	// Arrange
+	s := &idempotencyServer{}
+	client, err := stromboli.NewClient(s.start(t).URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, runErr := client.Run(ctx, &stromboli.RunRequest{Prompt: "Hi", IdempotencyKey: "run-key"})
+	_, asyncErr := client.RunAsync(ctx, &stromboli.RunRequest{Prompt: "Hi", IdempotencyKey: "async-key"})
+	_, plainErr := client.Run(ctx, &stromboli.RunRequest{Prompt: "Hi"})
+
+	// Assert
+	require.NoError(t, runErr)
+	require.NoError(t, asyncErr)
+	require.NoError(t, plainErr)
+	assert.Equal(t, []string{"run-key", "async-key", ""}, s.received())
+}
+
+// TestWithIdempotency tests that WithIdempotency generates a key stored in
+// the request, which a retry with the same request sends again, and keeps
+// a key set by the caller.
+func TestWithIdempotency(t *testing.T) {
+	// Arrange
+	s := &idempotencyServer{}
+	client, err := stromboli.NewClient(s.start(t).URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+	req := &stromboli.RunRequest{Prompt: "Deploy"}
+	other := &stromboli.RunRequest{Prompt: "Deploy"}
+	preset := &stromboli.RunRequest{Prompt: "Deploy", IdempotencyKey: "caller-key"}
+
+	// Act
+	_, firstErr := client.RunAsync(ctx, req, stromboli.WithIdempotency())
+	_, retryErr := client.RunAsync(ctx, req, stromboli.WithIdempotency())
+	_, otherErr := client.Run(ctx, other, stromboli.WithIdempotency())
+	_, presetErr := client.Run(ctx, preset, stromboli.WithIdempotency())
+
+	// Assert
+	require.NoError(t, firstErr)
+	require.NoError(t, retryErr)
+	require.NoError(t, otherErr)
+	require.NoError(t, presetErr)
+	assert.Regexp(t, randomIDPattern, req.IdempotencyKey)
+	assert.NotEqual(t, req.IdempotencyKey, other.IdempotencyKey)
+	assert.Equal(t, []string{req.IdempotencyKey, req.IdempotencyKey, other.IdempotencyKey, "caller-key"}, s.received())
+}
+
+// TestIdempotencyKey_Invalid tests that a key containing control
+// characters is rejected before any request is sent.
+func TestIdempotencyKey_Invalid(t *testing.T) {
+	// Arrange
+	s := &idempotencyServer{}
+	client, err := stromboli.NewClient(s.start(t).URL)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Run(context.Background(), &stromboli.RunRequest{Prompt: "Hi", IdempotencyKey: "key\r\nX-Evil: 1"})
+
+	// Assert
+	assert.ErrorIs(t, err, stromboli.ErrBadRequest)
+	assert.Empty(t, s.received())
+}
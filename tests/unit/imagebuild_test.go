@@ -0,0 +1,175 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// TestBuildImage_Context tests that BuildImage streams the context archive
+// while it is being written, and sends the build options as query
+// parameters.
+func TestBuildImage_Context(t *testing.T) {
+	// Arrange
+	firstChunk := make(chan struct{})
+	var query url.Values
+	var contentType string
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/images/build", r.URL.Path)
+		query = r.URL.Query()
+		contentType = r.Header.Get("Content-Type")
+		chunk := make([]byte, len("chunk-1;"))
+		_, err := io.ReadFull(r.Body, chunk)
+		assert.NoError(t, err)
+		close(firstChunk)
+		rest, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		received = append(chunk, rest...)
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{
+			"image_id": "sha256:abc",
+			"tag":      "agents/python:1.4",
+			"log":      "STEP 1/2: FROM python:3.12\n",
+		})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// The rest of the archive is only written once the server received
+	// the first chunk, which requires streaming
+	archive, writer := io.Pipe()
+	go func() {
+		_, _ = io.WriteString(writer, "chunk-1;")
+		select {
+		case <-firstChunk:
+		case <-time.After(5 * time.Second):
+		}
+		_, _ = io.WriteString(writer, "chunk-2")
+		_ = writer.Close()
+	}()
+
+	// Act
+	resp, err := client.BuildImage(context.Background(), &stromboli.BuildImageRequest{
+		Context:       archive,
+		Containerfile: "agents/Containerfile",
+		Tag:           "agents/python:1.4",
+		BuildArgs:     map[string]string{"PYTHON_VERSION": "3.12", "EXTRAS": "dev"},
+		Timeout:       "15m",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &stromboli.BuildImageResponse{
+		ImageID: "sha256:abc",
+		Tag:     "agents/python:1.4",
+		Log:     "STEP 1/2: FROM python:3.12\n",
+	}, resp)
+	assert.Equal(t, "chunk-1;chunk-2", string(received))
+	assert.Equal(t, "application/x-tar", contentType)
+	assert.Equal(t, "agents/python:1.4", query.Get("tag"))
+	assert.Equal(t, "agents/Containerfile", query.Get("containerfile"))
+	assert.Equal(t, `{"EXTRAS":"dev","PYTHON_VERSION":"3.12"}`, query.Get("build_args"))
+	assert.Equal(t, "15m", query.Get("timeout"))
+	assert.False(t, query.Has("context_path"))
+}
+
+// TestBuildImage_AsyncJob tests that a build accepted as an async job
+// returns the job ID, and that a server-side context sends no body.
+func TestBuildImage_AsyncJob(t *testing.T) {
+	// Arrange
+	var query url.Values
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		mustEncode(w, map[string]interface{}{"job_id": "job-build-1"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	resp, err := client.BuildImage(context.Background(), &stromboli.BuildImageRequest{
+		ContextPath: "/srv/agents/python",
+		Tag:         "agents/python:1.4",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "job-build-1", resp.JobID)
+	assert.Empty(t, resp.ImageID)
+	assert.Equal(t, "/srv/agents/python", query.Get("context_path"))
+	assert.Empty(t, body)
+}
+
+// TestBuildImage_Errors tests the errors of BuildImage: invalid requests,
+// servers without builds and build failures.
+func TestBuildImage_Errors(t *testing.T) {
+	// Arrange
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("tag") {
+		case "old-server":
+			w.WriteHeader(http.StatusNotFound)
+			mustEncode(w, map[string]string{"error": "not found"})
+		case "missing-path":
+			w.WriteHeader(http.StatusNotFound)
+			mustEncode(w, map[string]string{"error": "context path not found", "code": "PATH_NOT_FOUND"})
+		default:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			mustEncode(w, map[string]string{"error": "build failed: step 3 exited with 1"})
+		}
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+	build := func(req *stromboli.BuildImageRequest) error {
+		_, err := client.BuildImage(ctx, req)
+		return err
+	}
+
+	// Act
+	nilErr := build(nil)
+	noTagErr := build(&stromboli.BuildImageRequest{ContextPath: "/srv"})
+	noContextErr := build(&stromboli.BuildImageRequest{Tag: "a"})
+	bothErr := build(&stromboli.BuildImageRequest{Tag: "a", ContextPath: "/srv", Context: http.NoBody})
+	timeoutErr := build(&stromboli.BuildImageRequest{Tag: "a", ContextPath: "/srv", Timeout: "15 minutes"})
+	validationCalls := calls
+	unsupportedErr := build(&stromboli.BuildImageRequest{Tag: "old-server", ContextPath: "/srv"})
+	missingPathErr := build(&stromboli.BuildImageRequest{Tag: "missing-path", ContextPath: "/srv"})
+	failedErr := build(&stromboli.BuildImageRequest{Tag: "broken", ContextPath: "/srv"})
+
+	// Assert
+	for _, err := range []error{nilErr, noTagErr, noContextErr, bothErr, timeoutErr} {
+		assert.True(t, errors.Is(err, stromboli.ErrBadRequest), "got %v", err)
+	}
+	assert.Zero(t, validationCalls)
+	var apiErr *stromboli.Error
+	require.True(t, errors.As(unsupportedErr, &apiErr))
+	assert.Equal(t, "UNSUPPORTED", apiErr.Code)
+	require.True(t, errors.As(missingPathErr, &apiErr))
+	assert.Equal(t, "PATH_NOT_FOUND", apiErr.Code)
+	assert.True(t, errors.Is(missingPathErr, stromboli.ErrNotFound))
+	require.Error(t, failedErr)
+	assert.Contains(t, failedErr.Error(), "step 3 exited with 1")
+}
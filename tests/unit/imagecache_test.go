@@ -0,0 +1,204 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// imageServer serves a single image, python:3.12-slim, whose ID changes
+// when it is pulled, and counts the requests of each endpoint. The image
+// isn't listed while its ID is empty.
+type imageServer struct {
+	mu       sync.Mutex
+	id       string
+	requests map[string]int
+}
+
+// start starts the server.
+func (s *imageServer) start(t *testing.T) *httptest.Server {
+	t.Helper()
+	s.id = "sha256:aaa"
+	s.requests = make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests[r.Method+" "+r.URL.Path]++
+		image := map[string]interface{}{
+			"id":         s.id,
+			"repository": "python",
+			"tag":        "3.12-slim",
+			"compatible": true,
+			"tools":      []string{"python"},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/images":
+			listed := []interface{}{}
+			if s.id != "" {
+				listed = append(listed, image)
+			}
+			mustEncode(w, map[string]interface{}{"images": listed})
+		case "/images/python:3.12-slim":
+			mustEncode(w, image)
+		case "/images/pull":
+			s.id = "sha256:bbb"
+			mustEncode(w, map[string]interface{}{"success": true, "image": "python:3.12-slim", "image_id": s.id})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			mustEncode(w, map[string]string{"error": "image not found"})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// count returns the number of requests received for method and path.
+func (s *imageServer) count(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method+" "+path]
+}
+
+// repull changes the image ID, as if the image was pulled by someone else
+// (or deleted if id is empty).
+func (s *imageServer) repull(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.id = id
+}
+
+// TestWithImageCache_Hit tests that GetImage is served from the cache
+// within the TTL, with copies the caller can modify.
+func TestWithImageCache_Hit(t *testing.T) {
+	// Arrange
+	s := &imageServer{}
+	server := s.start(t)
+	client, err := stromboli.NewClient(server.URL, stromboli.WithImageCache(time.Minute))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	first, err1 := client.GetImage(ctx, "python:3.12-slim")
+	require.NoError(t, err1)
+	first.Tools[0] = "modified"
+	second, err2 := client.GetImage(ctx, "python:3.12-slim")
+
+	// Assert
+	require.NoError(t, err2)
+	assert.Equal(t, "sha256:aaa", second.ID)
+	assert.Equal(t, []string{"python"}, second.Tools)
+	assert.Equal(t, 1, s.count(http.MethodGet, "/images/python:3.12-slim"))
+	assert.Zero(t, s.count(http.MethodGet, "/images"))
+}
+
+// TestWithImageCache_Revalidation tests that expired images are
+// revalidated with ListImages and fetched again only if their ID changed.
+func TestWithImageCache_Revalidation(t *testing.T) {
+	// Arrange
+	s := &imageServer{}
+	server := s.start(t)
+	clock := strombolitest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithImageCache(time.Minute),
+		stromboli.WithClock(clock),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = client.GetImage(ctx, "python:3.12-slim")
+	require.NoError(t, err)
+
+	// Act: expire the unchanged image
+	clock.Advance(2 * time.Minute)
+	unchanged, err := client.GetImage(ctx, "python:3.12-slim")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "sha256:aaa", unchanged.ID)
+	assert.Equal(t, 1, s.count(http.MethodGet, "/images"))
+	assert.Equal(t, 1, s.count(http.MethodGet, "/images/python:3.12-slim"))
+
+	// Act: expire the image after it was re-pulled
+	s.repull("sha256:ccc")
+	clock.Advance(2 * time.Minute)
+	changed, err := client.GetImage(ctx, "python:3.12-slim")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "sha256:ccc", changed.ID)
+	assert.Equal(t, 2, s.count(http.MethodGet, "/images"))
+	assert.Equal(t, 2, s.count(http.MethodGet, "/images/python:3.12-slim"))
+}
+
+// TestWithImageCache_PullInvalidates tests that PullImage drops the cached
+// image, and that ImageChanged then reports the new ID once.
+func TestWithImageCache_PullInvalidates(t *testing.T) {
+	// Arrange
+	s := &imageServer{}
+	server := s.start(t)
+	client, err := stromboli.NewClient(server.URL, stromboli.WithImageCache(time.Hour))
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = client.GetImage(ctx, "python:3.12-slim")
+	require.NoError(t, err)
+
+	// Act
+	_, pullErr := client.PullImage(ctx, &stromboli.PullImageRequest{Image: "python:3.12-slim"})
+	changed, changedErr := client.ImageChanged(ctx, "python:3.12-slim")
+	again, againErr := client.ImageChanged(ctx, "python:3.12-slim")
+	image, getErr := client.GetImage(ctx, "python:3.12-slim")
+
+	// Assert
+	require.NoError(t, pullErr)
+	require.NoError(t, changedErr)
+	require.NoError(t, againErr)
+	require.NoError(t, getErr)
+	assert.True(t, changed)
+	assert.False(t, again, "a change is reported once")
+	assert.Equal(t, "sha256:bbb", image.ID)
+	assert.Equal(t, 2, s.count(http.MethodGet, "/images/python:3.12-slim"))
+}
+
+// TestImageChanged tests ImageChanged without the cache: the first call
+// fetches the image, and later calls compare its ID with ListImages until
+// the image is deleted.
+func TestImageChanged(t *testing.T) {
+	// Arrange
+	s := &imageServer{}
+	server := s.start(t)
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	first, err1 := client.ImageChanged(ctx, "python:3.12-slim")
+	unchanged, err2 := client.ImageChanged(ctx, "python:3.12-slim")
+	s.repull("sha256:ddd")
+	changed, err3 := client.ImageChanged(ctx, "python:3.12-slim")
+	s.repull("")
+	_, deletedErr := client.ImageChanged(ctx, "python:3.12-slim")
+	_, missingErr := client.ImageChanged(ctx, "node:20")
+	_, emptyErr := client.ImageChanged(ctx, "")
+
+	// Assert
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	require.NoError(t, err3)
+	assert.False(t, first)
+	assert.False(t, unchanged)
+	assert.True(t, changed)
+	assert.ErrorIs(t, deletedErr, stromboli.ErrImageNotFound)
+	assert.Error(t, missingErr)
+	assert.ErrorIs(t, emptyErr, stromboli.ErrBadRequest)
+	assert.Equal(t, 3, s.count(http.MethodGet, "/images"))
+}
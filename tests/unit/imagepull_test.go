@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// TestPullImageWithProgress tests that per-layer progress is reported in
+// order and the final line becomes the result, beyond the client timeout.
+func TestPullImageWithProgress(t *testing.T) {
+	// Arrange
+	var accept string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/images/pull", r.URL.Path)
+		accept = r.Header.Get("Accept")
+		mustDecode(r, &body)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = io.WriteString(w, `{"status":"Pulling from library/python"}`+"\n")
+		_, _ = io.WriteString(w, `{"id":"a2abf6c4d29d","status":"Downloading","current":1024,"total":4096}`+"\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond) // Past the client timeout
+		_, _ = io.WriteString(w, `{"id":"a2abf6c4d29d","status":"Pull complete"}`+"\n\n")
+		_, _ = io.WriteString(w, `{"done":true,"success":true,"image":"python:3.12","image_id":"sha256:abc"}`+"\n")
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+	var events []stromboli.PullProgressEvent
+
+	// Act
+	resp, err := client.PullImageWithProgress(context.Background(),
+		&stromboli.PullImageRequest{Image: "python:3.12", Platform: "linux/amd64"},
+		func(ev *stromboli.PullProgressEvent) { events = append(events, *ev) },
+	)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &stromboli.PullImageResponse{Success: true, Image: "python:3.12", ImageID: "sha256:abc"}, resp)
+	assert.Equal(t, []stromboli.PullProgressEvent{
+		{Status: "Pulling from library/python"},
+		{ID: "a2abf6c4d29d", Status: "Downloading", Current: 1024, Total: 4096},
+		{ID: "a2abf6c4d29d", Status: "Pull complete"},
+	}, events)
+	assert.Equal(t, "application/x-ndjson", accept)
+	assert.Equal(t, "python:3.12", body["image"])
+	assert.Equal(t, "linux/amd64", body["platform"])
+}
+
+// TestPullImageWithProgress_PlainResponse tests that servers without
+// progress streams still pull the image, without progress.
+func TestPullImageWithProgress_PlainResponse(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"success": true, "image": "alpine", "image_id": "sha256:def"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	calls := 0
+
+	// Act
+	resp, err := client.PullImageWithProgress(context.Background(),
+		&stromboli.PullImageRequest{Image: "alpine"},
+		func(*stromboli.PullProgressEvent) { calls++ },
+	)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:def", resp.ImageID)
+	assert.True(t, resp.Success)
+	assert.Zero(t, calls)
+}
+
+// TestPullImageWithProgress_Errors tests the errors of
+// PullImageWithProgress: failures reported in the stream, streams ending
+// without a result, HTTP errors and invalid requests.
+func TestPullImageWithProgress_Errors(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req stromboli.PullImageRequest
+		mustDecode(r, &req)
+		switch req.Image {
+		case "missing":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			_, _ = io.WriteString(w, `{"status":"Trying to pull docker.io/library/missing"}`+"\n")
+			_, _ = io.WriteString(w, `{"error":"manifest unknown","code":"NOT_FOUND"}`+"\n")
+		case "truncated":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			_, _ = io.WriteString(w, `{"id":"a2abf6c4d29d","status":"Downloading"}`+"\n")
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			mustEncode(w, map[string]string{"error": "unauthorized"})
+		}
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	pull := func(image string) error {
+		_, err := client.PullImageWithProgress(context.Background(), &stromboli.PullImageRequest{Image: image}, nil)
+		return err
+	}
+
+	// Act
+	streamErr := pull("missing")
+	truncatedErr := pull("truncated")
+	httpErr := pull("private")
+	emptyErr := pull("")
+	_, nilErr := client.PullImageWithProgress(context.Background(), nil, nil)
+
+	// Assert
+	assert.True(t, errors.Is(streamErr, stromboli.ErrNotFound))
+	assert.Contains(t, streamErr.Error(), "manifest unknown")
+	var apiErr *stromboli.Error
+	require.True(t, errors.As(truncatedErr, &apiErr))
+	assert.Equal(t, "INVALID_RESPONSE", apiErr.Code)
+	assert.True(t, errors.Is(httpErr, stromboli.ErrUnauthorized))
+	assert.True(t, errors.Is(emptyErr, stromboli.ErrBadRequest))
+	assert.True(t, errors.Is(nilErr, stromboli.ErrBadRequest))
+}
@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// testDigest is a valid sha256 digest.
+const testDigest = "sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// TestParseImageRef tests parsing references and round-tripping them.
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		input string
+		want  stromboli.ImageRef
+	}{
+		{"python", stromboli.ImageRef{Repository: "python"}},
+		{"python:3.12-slim", stromboli.ImageRef{Repository: "python", Tag: "3.12-slim"}},
+		{"docker.io/library/python:3.12", stromboli.ImageRef{Registry: "docker.io", Repository: "library/python", Tag: "3.12"}},
+		{"org/agent:1.4", stromboli.ImageRef{Repository: "org/agent", Tag: "1.4"}},
+		{"ghcr.io/org/team/agent:v1.4_RC", stromboli.ImageRef{Registry: "ghcr.io", Repository: "org/team/agent", Tag: "v1.4_RC"}},
+		{"localhost/agent", stromboli.ImageRef{Registry: "localhost", Repository: "agent"}},
+		{"localhost:5000/agent:dev", stromboli.ImageRef{Registry: "localhost:5000", Repository: "agent", Tag: "dev"}},
+		{"registry.example.com:443/a/b", stromboli.ImageRef{Registry: "registry.example.com:443", Repository: "a/b"}},
+		{"python@" + testDigest, stromboli.ImageRef{Repository: "python", Digest: testDigest}},
+		{"python:3.12@" + testDigest, stromboli.ImageRef{Repository: "python", Tag: "3.12", Digest: testDigest}},
+		{"my_org/my-agent__x", stromboli.ImageRef{Repository: "my_org/my-agent__x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := stromboli.ParseImageRef(tt.input)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.input, got.String())
+		})
+	}
+}
+
+// TestParseImageRef_Invalid tests that malformed references are rejected.
+func TestParseImageRef_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"uppercase repository", "Python:3.12"},
+		{"uppercase namespace", "ghcr.io/Org/agent"},
+		{"empty tag", "python:"},
+		{"invalid tag", "python:-3"},
+		{"empty repository", "ghcr.io/"},
+		{"empty component", "org//agent"},
+		{"invalid registry port", "localhost:port/agent"},
+		{"short digest", "python@sha256:abc"},
+		{"digest without algorithm", "python@0123456789abcdef0123456789abcdef"},
+		{"whitespace", "python 3"},
+		{"too long", strings.Repeat("a", 256)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := stromboli.ParseImageRef(tt.input)
+
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+		})
+	}
+}
+
+// TestImageRef_Normalize tests that references written differently
+// normalize to the same form.
+func TestImageRef_Normalize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"python", "docker.io/library/python:latest"},
+		{"python:3.12", "docker.io/library/python:3.12"},
+		{"docker.io/python:3.12", "docker.io/library/python:3.12"},
+		{"index.docker.io/library/python:3.12", "docker.io/library/python:3.12"},
+		{"org/agent", "docker.io/org/agent:latest"},
+		{"ghcr.io/agent", "ghcr.io/agent:latest"},
+		{"localhost:5000/agent:dev", "localhost:5000/agent:dev"},
+		{"python@" + testDigest, "docker.io/library/python@" + testDigest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ref, err := stromboli.ParseImageRef(tt.input)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, ref.Normalize().String())
+			assert.Equal(t, ref.Normalize(), ref.Normalize().Normalize())
+		})
+	}
+}
+
+// TestImage_Ref tests parsing the reference of a server image.
+func TestImage_Ref(t *testing.T) {
+	// Arrange
+	tagged := &stromboli.Image{Repository: "docker.io/library/python", Tag: "3.12"}
+	untagged := &stromboli.Image{Repository: "localhost/agent", Tag: "<none>"}
+	want, err := stromboli.ParseImageRef("python:3.12")
+	require.NoError(t, err)
+
+	// Act
+	taggedRef, taggedErr := tagged.Ref()
+	untaggedRef, untaggedErr := untagged.Ref()
+
+	// Assert
+	require.NoError(t, taggedErr)
+	assert.Equal(t, want.Normalize(), taggedRef.Normalize())
+	require.NoError(t, untaggedErr)
+	assert.Equal(t, stromboli.ImageRef{Registry: "localhost", Repository: "agent"}, untaggedRef)
+}
@@ -0,0 +1,284 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// crossRegistryResults are search results for "python" from docker.io and
+// two mirrors, with duplicates of the same images.
+var crossRegistryResults = []map[string]interface{}{
+	{"name": "docker.io/library/python", "stars": 9000, "official": true, "index": "docker.io"},
+	{"name": "quay.io/fedora/python-311", "stars": 40, "index": "quay.io"},
+	{"name": "mirror.gcr.io/library/python", "stars": 12, "index": "mirror.gcr.io"},
+	{"name": "docker.io/bitnami/python", "stars": 40, "index": "docker.io"},
+	{"name": "registry.example.com:5000/Bitnami/Python:3.12", "stars": 75, "index": "registry.example.com:5000"},
+	{"name": "docker.io/circleci/python", "stars": 40, "index": "docker.io"},
+}
+
+// searchServer starts a server answering searches with results, recording
+// the query of each request.
+func searchServer(results []map[string]interface{}, queries *[]url.Values) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*queries = append(*queries, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"results": results})
+	}))
+}
+
+// names returns the names of results.
+func names(results []*stromboli.ImageSearchResult) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.Name
+	}
+	return out
+}
+
+// TestSearchImagesPage_Deduplicate tests that duplicates across registries
+// are merged, keeping the most starred entry, and results are sorted by
+// stars with ties in server order.
+func TestSearchImagesPage_Deduplicate(t *testing.T) {
+	// Arrange
+	var queries []url.Values
+	server := searchServer(crossRegistryResults, &queries)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	page, err := client.SearchImagesPage(context.Background(), &stromboli.SearchImagesOptions{
+		Query:       "python",
+		Deduplicate: true,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"docker.io/library/python",
+		"registry.example.com:5000/Bitnami/Python:3.12",
+		"quay.io/fedora/python-311",
+		"docker.io/circleci/python",
+	}, names(page.Results))
+	assert.False(t, page.HasMore)
+}
+
+// TestSearchImagesPage_Paging tests the paging parameters sent to the
+// server and HasMore.
+func TestSearchImagesPage_Paging(t *testing.T) {
+	// Arrange
+	var queries []url.Values
+	server := searchServer(crossRegistryResults[:3], &queries)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	page, err := client.SearchImagesPage(context.Background(), &stromboli.SearchImagesOptions{
+		Query:   "python",
+		Limit:   2,
+		Offset:  4,
+		NoTrunc: true,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+	assert.Equal(t, url.Values{
+		"q":        {"python"},
+		"limit":    {"3"},
+		"offset":   {"4"},
+		"no_trunc": {"true"},
+	}, queries[0])
+	assert.Equal(t, []string{"docker.io/library/python", "quay.io/fedora/python-311"}, names(page.Results))
+	assert.True(t, page.HasMore)
+	assert.Equal(t, int64(4), page.Offset)
+	assert.Equal(t, int64(2), page.Limit)
+}
+
+// TestSearchImagesPage_ClientSidePaging tests that paging is applied
+// client-side when the server ignores it.
+func TestSearchImagesPage_ClientSidePaging(t *testing.T) {
+	// Arrange
+	var queries []url.Values
+	server := searchServer(crossRegistryResults, &queries)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	middle, err := client.SearchImagesPage(context.Background(), &stromboli.SearchImagesOptions{Query: "python", Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	last, err := client.SearchImagesPage(context.Background(), &stromboli.SearchImagesOptions{Query: "python", Limit: 2, Offset: 4})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, []string{"mirror.gcr.io/library/python", "docker.io/bitnami/python"}, names(middle.Results))
+	assert.True(t, middle.HasMore)
+	assert.Equal(t, []string{"registry.example.com:5000/Bitnami/Python:3.12", "docker.io/circleci/python"}, names(last.Results))
+	assert.False(t, last.HasMore)
+}
+
+// TestSearchImages_Deduplicate tests that SearchImages honors Deduplicate.
+func TestSearchImages_Deduplicate(t *testing.T) {
+	// Arrange
+	var queries []url.Values
+	server := searchServer(crossRegistryResults, &queries)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	results, err := client.SearchImages(context.Background(), &stromboli.SearchImagesOptions{Query: "python", Deduplicate: true})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, results, 4)
+	assert.Equal(t, int64(9000), results[0].Stars)
+}
+
+// TestSearchImagesPage_InvalidOptions tests that a missing query and
+// negative paging values are rejected.
+func TestSearchImagesPage_InvalidOptions(t *testing.T) {
+	// Arrange
+	client, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+
+	// Act
+	_, noQuery := client.SearchImagesPage(context.Background(), &stromboli.SearchImagesOptions{})
+	_, negative := client.SearchImagesPage(context.Background(), &stromboli.SearchImagesOptions{Query: "python", Offset: -1})
+
+	// Assert
+	assert.True(t, errors.Is(noQuery, stromboli.ErrBadRequest))
+	assert.True(t, errors.Is(negative, stromboli.ErrBadRequest))
+}
+
+// pagedSearchServer starts a server paging through total results named
+// "image-<n>", honoring limit and offset, and recording the query of each
+// request. shift is added to every offset, to simulate results shifting
+// between pages.
+func pagedSearchServer(total, shift int, queries *[]url.Values) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*queries = append(*queries, r.URL.Query())
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		offset = max(offset+shift, 0)
+		results := []map[string]interface{}{}
+		for i := offset; i < total && i < offset+limit; i++ {
+			results = append(results, map[string]interface{}{"name": fmt.Sprintf("image-%d", i), "index": "docker.io"})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"results": results})
+	}))
+}
+
+// TestSearchImagesAll tests that pages are fetched until max results are
+// gathered or the registry runs out.
+func TestSearchImagesAll(t *testing.T) {
+	tests := []struct {
+		name        string
+		total       int
+		max         int
+		wantCount   int
+		wantOffsets []string
+	}{
+		{"stops at max", 250, 230, 230, []string{"", "100", "200"}},
+		{"registry runs out", 150, 500, 150, []string{"", "100"}},
+		{"registry runs out on page boundary", 200, 500, 200, []string{"", "100"}},
+		{"max smaller than a page", 250, 10, 10, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var queries []url.Values
+			server := pagedSearchServer(tt.total, 0, &queries)
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL)
+			require.NoError(t, err)
+
+			// Act
+			results, err := client.SearchImagesAll(context.Background(), "python", tt.max)
+
+			// Assert
+			require.NoError(t, err)
+			require.Len(t, results, tt.wantCount)
+			assert.Equal(t, "image-0", results[0].Name)
+			assert.Equal(t, fmt.Sprintf("image-%d", tt.wantCount-1), results[tt.wantCount-1].Name)
+			offsets := make([]string, len(queries))
+			for i, q := range queries {
+				offsets[i] = q.Get("offset")
+			}
+			assert.Equal(t, tt.wantOffsets, offsets)
+		})
+	}
+}
+
+// TestSearchImagesAll_Duplicates tests that results repeated across pages
+// are kept once, and that paging stops when a page brings nothing new.
+func TestSearchImagesAll_Duplicates(t *testing.T) {
+	// Arrange
+	var shiftedQueries, stuckQueries []url.Values
+	shifted := pagedSearchServer(150, -5, &shiftedQueries)
+	defer shifted.Close()
+	stuck := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Ignores offset and always returns a full page
+		stuckQueries = append(stuckQueries, r.URL.Query())
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		results := make([]map[string]interface{}, limit)
+		for i := range results {
+			results[i] = map[string]interface{}{"name": fmt.Sprintf("image-%d", i), "index": "docker.io"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"results": results, "has_more": true})
+	}))
+	defer stuck.Close()
+
+	shiftedClient, err := stromboli.NewClient(shifted.URL)
+	require.NoError(t, err)
+	stuckClient, err := stromboli.NewClient(stuck.URL)
+	require.NoError(t, err)
+
+	// Act
+	shiftedResults, shiftedErr := shiftedClient.SearchImagesAll(context.Background(), "python", 500)
+	stuckResults, stuckErr := stuckClient.SearchImagesAll(context.Background(), "python", 500)
+
+	// Assert
+	require.NoError(t, shiftedErr)
+	assert.Len(t, shiftedResults, 150, "the 5 results repeated by the second page are kept once")
+	require.NoError(t, stuckErr)
+	assert.Len(t, stuckResults, 100)
+	assert.Len(t, stuckQueries, 2)
+}
+
+// TestSearchImagesAll_InvalidOptions tests that an empty query and a
+// non-positive max are rejected.
+func TestSearchImagesAll_InvalidOptions(t *testing.T) {
+	// Arrange
+	client, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+
+	// Act
+	_, noQueryErr := client.SearchImagesAll(context.Background(), "", 10)
+	_, noMaxErr := client.SearchImagesAll(context.Background(), "python", 0)
+
+	// Assert
+	assert.True(t, errors.Is(noQueryErr, stromboli.ErrBadRequest))
+	assert.True(t, errors.Is(noMaxErr, stromboli.ErrBadRequest))
+}
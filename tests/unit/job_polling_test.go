@@ -0,0 +1,312 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// jobFixtures are job responses decoded by both GetJob and GetJobInto,
+// covering the fast path and the shapes that fall back to encoding/json.
+var jobFixtures = []struct {
+	name string
+	body string
+}{
+	{"plain", `{"id":"job-1","status":"running","output":"partial output","session_id":"sess-1","created_at":"2024-01-15T10:30:00Z","updated_at":"2024-01-15T10:31:00Z"}`},
+	{"minimal", `{"id":"job-1","status":"pending"}`},
+	{"empty object", `{}`},
+	{"null", `null`},
+	{"whitespace", " {\n\t\"id\" : \"job-1\" ,\r\n \"status\":\"completed\" } \n"},
+	{"escapes", `{"id":"job-1","status":"completed","output":"line 1\nline \"2\"\té😀"}`},
+	{"unicode", `{"id":"job-1","status":"completed","output":"héllo wörld 🌋"}`},
+	{"invalid utf-8", "{\"id\":\"job-1\",\"status\":\"completed\",\"output\":\"bad \xff byte\"}"},
+	{"failed", `{"id":"job-1","status":"failed","error":"container exited"}`},
+	{"crash info", `{"id":"job-1","status":"crashed","crash_info":{"reason":"oom","exit_code":137,"partial_output":"half","signal":"SIGKILL","task_completed":false}}`},
+	{"usage", `{"id":"job-1","status":"completed","usage":{"input_tokens":10,"output_tokens":20,"total_cost_usd":0.5,"num_turns":1}}`},
+	{"null usage", `{"id":"job-1","status":"completed","usage":null}`},
+	{"null crash info", `{"id":"job-1","status":"running","crash_info":null}`},
+	{"null field", `{"id":"job-1","status":"running","output":null}`},
+	{"unknown field", `{"id":"job-1","status":"running","worker":"w-3","attempts":2}`},
+	{"case-insensitive key", `{"ID":"job-1","Status":"running","Session_ID":"sess-1"}`},
+	{"duplicate key", `{"id":"job-1","status":"pending","status":"running"}`},
+}
+
+// jobFixtureServer serves body for every job request.
+func jobFixtureServer(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+// TestGetJobInto_MatchesGetJob tests that GetJobInto decodes every fixture
+// exactly like GetJob, including when reusing a populated job.
+func TestGetJobInto_MatchesGetJob(t *testing.T) {
+	for _, tt := range jobFixtures {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := jobFixtureServer(tt.body)
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL)
+			require.NoError(t, err)
+			ctx := context.Background()
+
+			want, err := client.GetJob(ctx, "job-1")
+			require.NoError(t, err)
+
+			stale := stromboli.Job{
+				ID:        "job-old",
+				Status:    "pending",
+				Output:    "old output",
+				Error:     "old error",
+				SessionID: "sess-old",
+				CreatedAt: "2020-01-01T00:00:00Z",
+				UpdatedAt: "2020-01-01T00:00:00Z",
+				CrashInfo: &stromboli.CrashInfo{Reason: "old"},
+				Usage:     &stromboli.Usage{TotalCostUSD: 1},
+			}
+
+			for _, start := range []stromboli.Job{{}, stale, *want} {
+				job := start
+
+				// Act
+				err := client.GetJobInto(ctx, "job-1", &job)
+
+				// Assert
+				require.NoError(t, err)
+				assert.Equal(t, *want, job)
+			}
+		})
+	}
+}
+
+// TestGetJob_UnknownStatus tests that statuses the SDK doesn't know are
+// reported as "unknown", with the server's value in RawStatus.
+func TestGetJob_UnknownStatus(t *testing.T) {
+	// Arrange
+	server := jobFixtureServer(`{"id":"job-1","status":"paused"}`)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	job, err := client.GetJob(ctx, "job-1")
+	require.NoError(t, err)
+	into := stromboli.Job{RawStatus: "stale"}
+	intoErr := client.GetJobInto(ctx, "job-1", &into)
+
+	// Assert
+	require.NoError(t, intoErr)
+	for _, got := range []*stromboli.Job{job, &into} {
+		assert.Equal(t, stromboli.JobStatusUnknown, got.Status)
+		assert.Equal(t, "paused", got.RawStatus)
+		assert.Equal(t, stromboli.JobStateUnknown, got.State())
+		assert.False(t, got.IsTerminal())
+		assert.False(t, got.IsRunning())
+	}
+}
+
+// TestGetJobInto_OutputSanitization tests that output is sanitized like
+// GetJob's.
+func TestGetJobInto_OutputSanitization(t *testing.T) {
+	// Arrange
+	server := jobFixtureServer(`{"id":"job-1","status":"completed","output":"\u001b[31mred\u001b[0m text"}`)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithOutputSanitization(stromboli.OutputStripANSI))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	want, err := client.GetJob(ctx, "job-1")
+	require.NoError(t, err)
+
+	// Act
+	var job stromboli.Job
+	err = client.GetJobInto(ctx, "job-1", &job)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, *want, job)
+	assert.Equal(t, "red text", job.Output)
+}
+
+// TestGetJobInto_Errors tests that GetJobInto returns the same errors as
+// GetJob for error responses, except that server-specific codes are kept.
+func TestGetJobInto_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		target   error
+		wantCode string // if different from GetJob's
+	}{
+		{"not found", http.StatusNotFound, `{"error":"job not found"}`, stromboli.ErrNotFound, ""},
+		{"server code", http.StatusNotFound, `{"error":"job not found","code":"JOB_NOT_FOUND"}`, stromboli.ErrNotFound, "JOB_NOT_FOUND"},
+		{"unauthorized", http.StatusUnauthorized, `{"error":"invalid token"}`, stromboli.ErrUnauthorized, ""},
+		{"internal", http.StatusInternalServerError, `{"error":"database unavailable"}`, stromboli.ErrInternal, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL)
+			require.NoError(t, err)
+			ctx := context.Background()
+
+			_, want := client.GetJob(ctx, "job-1")
+			require.Error(t, want)
+
+			// Act
+			var job stromboli.Job
+			err = client.GetJobInto(ctx, "job-1", &job)
+
+			// Assert
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.target))
+			assert.True(t, errors.Is(want, tt.target))
+			var gotErr, wantErr *stromboli.Error
+			require.True(t, errors.As(err, &gotErr))
+			require.True(t, errors.As(want, &wantErr))
+			if tt.wantCode == "" {
+				tt.wantCode = wantErr.Code
+			}
+			assert.Equal(t, tt.wantCode, gotErr.Code)
+			assert.Equal(t, wantErr.Status, gotErr.Status)
+			assert.Equal(t, wantErr.Message, gotErr.Message)
+		})
+	}
+}
+
+// TestGetJobInto_InvalidArguments tests that an empty job ID or a nil job
+// is rejected without sending a request.
+func TestGetJobInto_InvalidArguments(t *testing.T) {
+	// Arrange
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	errNoID := client.GetJobInto(ctx, "", &stromboli.Job{})
+	errNoJob := client.GetJobInto(ctx, "job-1", nil)
+
+	// Assert
+	assert.True(t, errors.Is(errNoID, stromboli.ErrBadRequest))
+	assert.True(t, errors.Is(errNoJob, stromboli.ErrBadRequest))
+	assert.False(t, called)
+}
+
+// TestGetJobInto_RequestPath tests that the job ID is escaped and that the
+// base path and auth token are kept.
+func TestGetJobInto_RequestPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		jobID   string
+		wantRaw string
+	}{
+		{"plain", "", "job-1", "/jobs/job-1"},
+		{"base path", "/api/v1/", "job-1", "/api/v1/jobs/job-1"},
+		{"escaped id", "", "job/1 x", "/jobs/job%2F1%20x"},
+		{"escaped base and id", "/my%20api", "job?1", "/my%20api/jobs/job%3F1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var gotPath, gotAuth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.EscapedPath()
+				gotAuth = r.Header.Get("Authorization")
+				mustEncode(w, map[string]interface{}{"id": "job-1", "status": "running"})
+			}))
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL+tt.base, stromboli.WithToken("secret"))
+			require.NoError(t, err)
+
+			// Act
+			var job stromboli.Job
+			err = client.GetJobInto(context.Background(), tt.jobID, &job)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRaw, gotPath)
+			assert.Equal(t, "Bearer secret", gotAuth)
+			assert.Equal(t, "running", job.Status)
+		})
+	}
+}
+
+// cannedTransport answers every request with the same in-memory response,
+// so that benchmarks only measure the client's allocations.
+type cannedTransport struct {
+	resp   http.Response
+	header http.Header
+	reader bytes.Reader
+	body   []byte
+}
+
+func (t *cannedTransport) Read(p []byte) (int, error) { return t.reader.Read(p) }
+func (t *cannedTransport) Close() error               { return nil }
+
+func (t *cannedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.reader.Reset(t.body)
+	t.resp = http.Response{StatusCode: http.StatusOK, Header: t.header, Body: t, Request: req}
+	return &t.resp, nil
+}
+
+// BenchmarkGetJob compares the allocations of GetJob and GetJobInto when
+// polling an unchanged job.
+func BenchmarkGetJob(b *testing.B) {
+	transport := &cannedTransport{
+		header: http.Header{"Content-Type": {"application/json"}},
+		body:   []byte(jobFixtures[0].body),
+	}
+	client, err := stromboli.NewClient("http://localhost:8585",
+		stromboli.WithHTTPClient(&http.Client{Transport: transport}),
+	)
+	require.NoError(b, err)
+	ctx := context.Background()
+
+	b.Run("GetJob", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.GetJob(ctx, "job-1"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("GetJobInto", func(b *testing.B) {
+		b.ReportAllocs()
+		var job stromboli.Job
+		for i := 0; i < b.N; i++ {
+			if err := client.GetJobInto(ctx, "job-1", &job); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// TestGetJobLogs_Success tests that GetJobLogs sends its options as query
+// parameters and returns the raw logs.
+func TestGetJobLogs_Success(t *testing.T) {
+	// Arrange
+	var query url.Values
+	var accept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/jobs/job-123/logs", r.URL.Path)
+		query = r.URL.Query()
+		accept = r.Header.Get("Accept")
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, "hook: npm install\nhook failed: exit status 1\n")
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	since := time.Date(2026, 1, 2, 15, 4, 5, 0, time.FixedZone("CET", 3600))
+
+	// Act
+	logs, err := client.GetJobLogs(context.Background(), "job-123", &stromboli.LogsOptions{Tail: 50, Since: since})
+	require.NoError(t, err)
+	data, readErr := io.ReadAll(logs)
+	closeErr := logs.Close()
+
+	// Assert
+	require.NoError(t, readErr)
+	assert.NoError(t, closeErr)
+	assert.Equal(t, "hook: npm install\nhook failed: exit status 1\n", string(data))
+	assert.Equal(t, "50", query.Get("tail"))
+	assert.Equal(t, "2026-01-02T14:04:05Z", query.Get("since"))
+	assert.False(t, query.Has("follow"))
+	assert.Equal(t, "text/plain", accept)
+}
+
+// TestGetJobLogsString tests that GetJobLogsString reads the whole logs, and
+// that nil options send no query parameters.
+func TestGetJobLogsString(t *testing.T) {
+	// Arrange
+	var rawQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawQuery = r.URL.RawQuery
+		_, _ = io.WriteString(w, "line 1\nline 2\n")
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	logs, err := client.GetJobLogsString(context.Background(), "job-123", nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "line 1\nline 2\n", logs)
+	assert.Empty(t, rawQuery)
+}
+
+// TestGetJobLogs_Errors tests the errors of GetJobLogs: missing jobs,
+// servers without the endpoint and invalid arguments.
+func TestGetJobLogs_Errors(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/jobs/job-old-server" {
+			mustEncode(w, map[string]interface{}{"id": "job-old-server", "status": "completed"})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		mustEncode(w, map[string]string{"error": "not found"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, notFound := client.GetJobLogs(ctx, "job-missing", nil)
+	_, unsupported := client.GetJobLogs(ctx, "job-old-server", nil)
+	_, empty := client.GetJobLogs(ctx, "", nil)
+	_, negative := client.GetJobLogs(ctx, "job-123", &stromboli.LogsOptions{Tail: -1})
+	_, stringErr := client.GetJobLogsString(ctx, "job-missing", nil)
+
+	// Assert
+	assert.True(t, errors.Is(notFound, stromboli.ErrNotFound))
+	var apiErr *stromboli.Error
+	require.True(t, errors.As(unsupported, &apiErr))
+	assert.Equal(t, "UNSUPPORTED", apiErr.Code)
+	assert.Equal(t, http.StatusNotImplemented, apiErr.Status)
+	assert.True(t, errors.Is(empty, stromboli.ErrBadRequest))
+	assert.True(t, errors.Is(negative, stromboli.ErrBadRequest))
+	assert.True(t, errors.Is(stringErr, stromboli.ErrNotFound))
+}
+
+// TestGetJobLogs_Follow tests that followed logs stream lines as they are
+// written, beyond the client timeout, and stop when the context is
+// cancelled.
+func TestGetJobLogs_Follow(t *testing.T) {
+	// Arrange
+	release := make(chan struct{})
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		assert.Equal(t, "true", r.URL.Query().Get("follow"))
+		_, _ = io.WriteString(w, "first\n")
+		w.(http.Flusher).Flush()
+		<-release
+		_, _ = io.WriteString(w, "second\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Act
+	logs, err := client.GetJobLogs(ctx, "job-123", &stromboli.LogsOptions{Follow: true})
+	require.NoError(t, err)
+	defer func() { _ = logs.Close() }()
+
+	first := make([]byte, len("first\n"))
+	_, firstErr := io.ReadFull(logs, first)
+	time.Sleep(100 * time.Millisecond) // Past the client timeout
+	close(release)
+	second := make([]byte, len("second\n"))
+	_, secondErr := io.ReadFull(logs, second)
+	cancel()
+	_, cancelErr := io.ReadAll(logs)
+
+	// Assert
+	require.NoError(t, firstErr)
+	assert.Equal(t, "first\n", string(first))
+	require.NoError(t, secondErr)
+	assert.Equal(t, "second\n", string(second))
+	assert.Error(t, cancelErr)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server request was not cancelled")
+	}
+}
@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// newerServer answers like a server newer than the SDK, with job statuses
+// and message types that are not in the API spec.
+func newerServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/jobs":
+			_, _ = w.Write([]byte(`{"jobs":[{"id":"job-1","status":"queued"},{"id":"job-2","status":"running"}]}`))
+		case "/jobs/job-1":
+			_, _ = w.Write([]byte(`{"id":"job-1","status":"queued"}`))
+		case "/sessions/sess-1/messages":
+			_, _ = w.Write([]byte(`{"messages":[{"uuid":"msg-1","type":"summary"},{"uuid":"msg-2","type":"user"}],` +
+				`"total":2,"limit":50,"offset":0,"has_more":false}`))
+		case "/sessions/sess-1/messages/msg-1":
+			_, _ = w.Write([]byte(`{"message":{"uuid":"msg-1","type":"summary"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestUnknownJobStatus_Decodes tests that GetJob and ListJobs don't fail
+// on job statuses the SDK doesn't know, and that the status helpers treat
+// them conservatively.
+func TestUnknownJobStatus_Decodes(t *testing.T) {
+	// Arrange
+	client, err := stromboli.NewClient(newerServer(t).URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	job, getErr := client.GetJob(ctx, "job-1")
+	jobs, listErr := client.ListJobs(ctx)
+
+	// Assert
+	require.NoError(t, getErr)
+	require.NoError(t, listErr)
+	require.Len(t, jobs, 2)
+	for _, got := range []*stromboli.Job{job, jobs[0]} {
+		assert.Equal(t, "job-1", got.ID)
+		assert.Equal(t, stromboli.JobStatusUnknown, got.Status)
+		assert.Equal(t, "queued", got.RawStatus)
+		assert.False(t, got.IsRunning())
+		assert.False(t, got.IsTerminal(), "an unknown job may still be running")
+	}
+	assert.Equal(t, stromboli.JobStatusRunning, jobs[1].Status)
+	assert.Empty(t, jobs[1].RawStatus)
+}
+
+// TestUnknownMessageType_Decodes tests that GetMessages and GetMessage
+// keep message types the SDK doesn't know as-is.
+func TestUnknownMessageType_Decodes(t *testing.T) {
+	// Arrange
+	client, err := stromboli.NewClient(newerServer(t).URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	page, listErr := client.GetMessages(ctx, "sess-1", nil)
+	msg, getErr := client.GetMessage(ctx, "sess-1", "msg-1")
+
+	// Assert
+	require.NoError(t, listErr)
+	require.NoError(t, getErr)
+	require.Len(t, page.Messages, 2)
+	assert.Equal(t, "summary", page.Messages[0].Type)
+	assert.Equal(t, "user", page.Messages[1].Type)
+	assert.Equal(t, "summary", msg.Type)
+}
@@ -0,0 +1,328 @@
+package unit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write implements io.Writer.
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// String returns the buffered text.
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newDebugLogger returns a JSON slog logger at the debug level writing to
+// the returned buffer.
+func newDebugLogger() (*slog.Logger, *syncBuffer) {
+	buf := &syncBuffer{}
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})), buf
+}
+
+// logRecords decodes the JSON records logged to buf.
+func logRecords(t *testing.T, buf *syncBuffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+// TestWithDebug_Body tests that DebugBody dumps requests and responses
+// with credentials redacted, without consuming the bodies.
+func TestWithDebug_Body(t *testing.T) {
+	// Arrange
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		mustEncode(w, map[string]interface{}{"success": true, "name": "github-token"})
+	}))
+	defer server.Close()
+
+	logger, buf := newDebugLogger()
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithToken("my-api-token"),
+		stromboli.WithSlog(logger),
+		stromboli.WithDebug(stromboli.DebugBody),
+	)
+	require.NoError(t, err)
+
+	// Act
+	err = client.CreateSecret(context.Background(), &stromboli.CreateSecretRequest{
+		Name:  "github-token",
+		Value: "ghp_supersecret",
+	})
+	_, _ = client.ValidateToken(context.Background()) // sends the token
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "ghp_supersecret", received["value"], "the server receives the full body")
+	records := logRecords(t, buf)
+	require.Len(t, records, 2)
+	record := records[0]
+	assert.Equal(t, "DEBUG", record["level"])
+	assert.Equal(t, "POST", record["method"])
+	assert.Equal(t, server.URL+"/secrets", record["url"])
+	assert.EqualValues(t, 201, record["status"])
+	assert.Contains(t, record, "duration")
+	assert.Contains(t, record["request"], `"name":"github-token"`)
+	assert.Contains(t, record["request"], `"value":"REDACTED"`)
+	assert.Contains(t, records[1]["request"], "Authorization: REDACTED")
+	assert.Contains(t, record["response"], `"success":true`)
+	assert.NotContains(t, buf.String(), "ghp_supersecret")
+	assert.NotContains(t, buf.String(), "my-api-token")
+}
+
+// TestWithDebug_Stream tests that stream responses are logged without
+// their body, which is left for the stream to read.
+func TestWithDebug_Stream(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: Hello\n\n"))
+	}))
+	defer server.Close()
+
+	logger, buf := newDebugLogger()
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithSlog(logger),
+		stromboli.WithDebug(stromboli.DebugBody),
+	)
+	require.NoError(t, err)
+
+	// Act
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Hi"})
+	require.NoError(t, err)
+	defer stream.Close()
+	var events []string
+	for stream.Next() {
+		events = append(events, stream.Event().Data)
+	}
+
+	// Assert
+	require.NoError(t, stream.Err())
+	assert.Equal(t, []string{"Hello"}, events)
+	records := logRecords(t, buf)
+	require.Len(t, records, 1)
+	assert.Contains(t, records[0]["response"], "Content-Type: text/event-stream")
+	assert.NotContains(t, records[0]["response"], "Hello")
+}
+
+// TestWithDebug_FollowedLogs tests that responses to requests that don't
+// accept JSON, such as followed job logs, are logged without their body,
+// so that the caller reads it as it arrives.
+func TestWithDebug_FollowedLogs(t *testing.T) {
+	// Arrange
+	finish := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("line 1\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-finish:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(finish)
+
+	logger, buf := newDebugLogger()
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithSlog(logger),
+		stromboli.WithDebug(stromboli.DebugBody),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Act
+	logs, err := client.GetJobLogs(ctx, "job-1", &stromboli.LogsOptions{Follow: true})
+	require.NoError(t, err)
+	defer logs.Close()
+	line := make(chan string, 1)
+	go func() {
+		data, _ := bufio.NewReader(logs).ReadString('\n')
+		line <- data
+	}()
+
+	// Assert
+	select {
+	case data := <-line:
+		assert.Equal(t, "line 1\n", data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("first log line not delivered before the response ended")
+	}
+	records := logRecords(t, buf)
+	require.Len(t, records, 1)
+	assert.Contains(t, records[0]["response"], "Content-Type: text/plain")
+	assert.NotContains(t, records[0]["response"], "line 1")
+}
+
+// TestWithDebug_StreamedRequestBody tests that request bodies that can't
+// be replayed, such as image build contexts, are sent without being
+// buffered or dumped.
+func TestWithDebug_StreamedRequestBody(t *testing.T) {
+	// Arrange
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"success": true, "image": "agents/python:1.4"})
+	}))
+	defer server.Close()
+
+	logger, buf := newDebugLogger()
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithSlog(logger),
+		stromboli.WithDebug(stromboli.DebugBody),
+	)
+	require.NoError(t, err)
+	buildContext := io.MultiReader(strings.NewReader("tar-context-bytes"))
+
+	// Act
+	_, err = client.BuildImage(context.Background(), &stromboli.BuildImageRequest{
+		Context: buildContext,
+		Tag:     "agents/python:1.4",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "tar-context-bytes", string(received))
+	records := logRecords(t, buf)
+	require.Len(t, records, 1)
+	assert.Contains(t, records[0]["request"], "POST /images/build")
+	assert.NotContains(t, records[0]["request"], "tar-context-bytes")
+	assert.Contains(t, records[0]["response"], `"success":true`)
+}
+
+// TestWithDebug_Headers tests that DebugHeaders logs requests without
+// dumps, and that clones keep logging them.
+func TestWithDebug_Headers(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"status": "ok"})
+	}))
+	defer server.Close()
+
+	logger, buf := newDebugLogger()
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithSlog(logger),
+		stromboli.WithDebug(stromboli.DebugHeaders),
+	)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Clone().Health(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	records := logRecords(t, buf)
+	require.Len(t, records, 1, "clones log each request once")
+	assert.Equal(t, "GET", records[0]["method"])
+	assert.EqualValues(t, 200, records[0]["status"])
+	assert.NotContains(t, records[0], "request")
+	assert.NotContains(t, records[0], "response")
+}
+
+// TestWithDebug_SDKLogger tests that requests are logged to the SDK's
+// Logger when no slog logger is set.
+func TestWithDebug_SDKLogger(t *testing.T) {
+	// Arrange
+	logger := &captureLogger{}
+	stromboli.SetLogger(logger)
+	defer stromboli.SetLogger(nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"status": "ok"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithDebug(stromboli.DebugHeaders))
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Health(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	messages := logger.Messages()
+	require.Len(t, messages, 1)
+	assert.Contains(t, messages[0], "method=GET")
+	assert.Contains(t, messages[0], "status=200")
+}
+
+// TestWithSlog_Warnings tests that client warnings go to the slog logger
+// instead of the SDK's Logger.
+func TestWithSlog_Warnings(t *testing.T) {
+	// Arrange
+	sdkLogger := &captureLogger{}
+	stromboli.SetLogger(sdkLogger)
+	defer stromboli.SetLogger(nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed"})
+	}))
+	defer server.Close()
+
+	logger, buf := newDebugLogger()
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithSlog(logger),
+		stromboli.WithValidationMode(stromboli.ValidationWarn),
+	)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Run(context.Background(), &stromboli.RunRequest{
+		Prompt: "test",
+		Claude: &stromboli.ClaudeOptions{Resume: true},
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, sdkLogger.Messages())
+	records := logRecords(t, buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Contains(t, records[0]["msg"], "request validation failed, sending anyway")
+}
@@ -0,0 +1,237 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// maintenanceServer answers mutating requests with a maintenance 503 while
+// *inMaintenance is set, and serves reads normally. It counts the requests
+// it receives in *calls.
+func maintenanceServer(inMaintenance *atomic.Bool, calls *int32, respond func(w http.ResponseWriter)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet && inMaintenance.Load() {
+			respond(w)
+			return
+		}
+		switch r.URL.Path {
+		case "/health":
+			mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok"})
+		case "/run":
+			mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed"})
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+}
+
+// TestMaintenance_BodyEndTime tests that a maintenance body is returned as
+// a MaintenanceError and that mutating calls fail fast until it ends.
+func TestMaintenance_BodyEndTime(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	until := now.Add(10 * time.Minute)
+	clock := strombolitest.NewFakeClock(now)
+
+	var inMaintenance atomic.Bool
+	inMaintenance.Store(true)
+	var calls int32
+	server := maintenanceServer(&inMaintenance, &calls, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		mustEncode(w, map[string]interface{}{
+			"error":             "server is read-only",
+			"maintenance":       true,
+			"maintenance_until": until.Format(time.RFC3339),
+		})
+	})
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClock(clock))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, err = client.Run(ctx, &stromboli.RunRequest{Prompt: "test"})
+
+	// Assert
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, stromboli.ErrMaintenance))
+	assert.True(t, errors.Is(err, stromboli.ErrUnavailable))
+	var maint *stromboli.MaintenanceError
+	require.True(t, errors.As(err, &maint))
+	assert.True(t, until.Equal(maint.Until))
+	assert.Equal(t, 10*time.Minute, maint.Err.RetryAfter)
+	assert.Equal(t, http.StatusServiceUnavailable, maint.Err.Status)
+
+	got, ok := client.MaintenanceUntil()
+	assert.True(t, ok)
+	assert.True(t, until.Equal(got))
+
+	// Reads are still sent and don't end the window
+	_, err = client.Health(ctx)
+	require.NoError(t, err)
+	_, ok = client.MaintenanceUntil()
+	assert.True(t, ok)
+
+	// Mutating calls fail fast within the window
+	sent := atomic.LoadInt32(&calls)
+	clock.Advance(4 * time.Minute)
+	_, err = client.RunAsync(ctx, &stromboli.RunRequest{Prompt: "test"})
+	require.True(t, errors.As(err, &maint))
+	assert.Equal(t, 6*time.Minute, maint.Err.RetryAfter)
+	assert.Equal(t, 0, maint.Err.Status)
+	_, err = client.Stream(ctx, &stromboli.StreamRequest{Prompt: "test"})
+	assert.True(t, errors.Is(err, stromboli.ErrMaintenance))
+	err = client.DeleteImage(ctx, "python:3.12", nil)
+	assert.True(t, errors.Is(err, stromboli.ErrMaintenance))
+	assert.Equal(t, sent, atomic.LoadInt32(&calls), "no request should be sent")
+
+	// Requests are sent again once the window has passed
+	inMaintenance.Store(false)
+	clock.Advance(6 * time.Minute)
+	_, ok = client.MaintenanceUntil()
+	assert.False(t, ok)
+	_, err = client.Run(ctx, &stromboli.RunRequest{Prompt: "test"})
+	require.NoError(t, err)
+}
+
+// TestMaintenance_HeaderHints tests maintenance responses flagged by the
+// X-Maintenance header, with and without an end time.
+func TestMaintenance_HeaderHints(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		headers   map[string]string
+		wantUntil time.Time
+	}{
+		{
+			"retry-after seconds",
+			map[string]string{"X-Maintenance": "true", "Retry-After": "120"},
+			now.Add(2 * time.Minute),
+		},
+		{
+			"retry-after date",
+			map[string]string{"X-Maintenance": "true", "Retry-After": now.Add(time.Hour).Format(http.TimeFormat)},
+			now.Add(time.Hour),
+		},
+		{
+			"end time in header",
+			map[string]string{"X-Maintenance": now.Add(30 * time.Minute).Format(time.RFC3339)},
+			now.Add(30 * time.Minute),
+		},
+		{
+			"no end time",
+			map[string]string{"X-Maintenance": "true"},
+			time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var inMaintenance atomic.Bool
+			inMaintenance.Store(true)
+			var calls int32
+			server := maintenanceServer(&inMaintenance, &calls, func(w http.ResponseWriter) {
+				for k, v := range tt.headers {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+			})
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL,
+				stromboli.WithClock(strombolitest.NewFakeClock(now)),
+			)
+			require.NoError(t, err)
+
+			// Act
+			err = client.DeleteImage(context.Background(), "python:3.12", nil)
+
+			// Assert
+			var maint *stromboli.MaintenanceError
+			require.True(t, errors.As(err, &maint))
+			assert.True(t, tt.wantUntil.Equal(maint.Until))
+			if tt.wantUntil.IsZero() {
+				assert.Zero(t, maint.Err.RetryAfter)
+			} else {
+				assert.Equal(t, tt.wantUntil.Sub(now), maint.Err.RetryAfter)
+			}
+			got, ok := client.MaintenanceUntil()
+			assert.True(t, ok)
+			assert.True(t, tt.wantUntil.Equal(got))
+		})
+	}
+}
+
+// TestMaintenance_UnknownEndTime tests that without an end time, requests
+// keep being sent and a successful mutating request ends the maintenance.
+func TestMaintenance_UnknownEndTime(t *testing.T) {
+	// Arrange
+	var inMaintenance atomic.Bool
+	inMaintenance.Store(true)
+	var calls int32
+	server := maintenanceServer(&inMaintenance, &calls, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		mustEncode(w, map[string]interface{}{"error": "maintenance", "maintenance": true})
+	})
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = client.Run(ctx, &stromboli.RunRequest{Prompt: "test"})
+	require.True(t, errors.Is(err, stromboli.ErrMaintenance))
+	until, ok := client.MaintenanceUntil()
+	require.True(t, ok)
+	assert.True(t, until.IsZero())
+
+	// Act
+	inMaintenance.Store(false)
+	_, err = client.Run(ctx, &stromboli.RunRequest{Prompt: "test"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	_, ok = client.MaintenanceUntil()
+	assert.False(t, ok)
+}
+
+// TestMaintenance_PlainUnavailable tests that a 503 without a maintenance
+// indicator stays a plain UNAVAILABLE error.
+func TestMaintenance_PlainUnavailable(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Run(context.Background(), &stromboli.RunRequest{Prompt: "test"})
+
+	// Assert
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, stromboli.ErrUnavailable))
+	assert.False(t, errors.Is(err, stromboli.ErrMaintenance))
+	_, ok := client.MaintenanceUntil()
+	assert.False(t, ok)
+}
@@ -0,0 +1,322 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// messageLog is a fake session history. Newest messages come first, like
+// a chat UI, so messages appended while paging shift offsets. With cursors
+// enabled, pages carry a next_cursor naming the first message of the next
+// page. It records the query of every request.
+type messageLog struct {
+	mu      sync.Mutex
+	uuids   []string // newest first
+	cursors bool
+	queries []string
+
+	// onPage is called after each page is served, under the lock.
+	onPage func(l *messageLog)
+}
+
+// newMessageLog creates a log with n messages, msg-n (newest) to msg-1.
+func newMessageLog(n int, cursors bool) *messageLog {
+	l := &messageLog{cursors: cursors}
+	for i := n; i > 0; i-- {
+		l.uuids = append(l.uuids, fmt.Sprintf("msg-%d", i))
+	}
+	return l
+}
+
+// server serves the log at /sessions/sess-abc123/messages.
+func (l *messageLog) server() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.queries = append(l.queries, r.URL.RawQuery)
+
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		if limit == 0 {
+			limit = 50
+		}
+		start, _ := strconv.Atoi(query.Get("offset"))
+		if cursor := query.Get("cursor"); cursor != "" {
+			start = -1
+			for i, uuid := range l.uuids {
+				if uuid == strings.TrimPrefix(cursor, "c-") {
+					start = i
+				}
+			}
+			if start < 0 {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				mustEncode(w, map[string]string{"error": "invalid cursor"})
+				return
+			}
+		}
+		end := min(start+limit, len(l.uuids))
+
+		messages := []map[string]interface{}{}
+		for _, uuid := range l.uuids[start:end] {
+			messages = append(messages, map[string]interface{}{
+				"uuid": uuid, "type": "user", "session_id": "sess-abc123",
+			})
+		}
+		resp := map[string]interface{}{
+			"messages": messages,
+			"total":    len(l.uuids),
+			"limit":    limit,
+			"offset":   start,
+			"has_more": end < len(l.uuids),
+		}
+		if l.cursors && end < len(l.uuids) {
+			resp["next_cursor"] = "c-" + l.uuids[end]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, resp)
+
+		if l.onPage != nil {
+			l.onPage(l)
+		}
+	}))
+}
+
+// prependOnce returns an onPage hook adding a new message at the top of
+// the log after the first page.
+func prependOnce(uuid string) func(l *messageLog) {
+	done := false
+	return func(l *messageLog) {
+		if !done {
+			l.uuids = append([]string{uuid}, l.uuids...)
+			done = true
+		}
+	}
+}
+
+// uuids returns the UUIDs of messages.
+func uuids(messages []*stromboli.Message) []string {
+	result := make([]string, len(messages))
+	for i, m := range messages {
+		result[i] = m.UUID
+	}
+	return result
+}
+
+// TestGetMessages_Cursor tests that the cursor is sent instead of the
+// offset and that the next cursor is returned.
+func TestGetMessages_Cursor(t *testing.T) {
+	// Arrange
+	log := newMessageLog(5, true)
+	server := log.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	first, err := client.GetMessages(context.Background(), "sess-abc123", &stromboli.GetMessagesOptions{Limit: 2})
+	require.NoError(t, err)
+	second, err := client.GetMessages(context.Background(), "sess-abc123", &stromboli.GetMessagesOptions{
+		Limit:  2,
+		Offset: 99,
+		Cursor: first.NextCursor,
+	})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "c-msg-3", first.NextCursor)
+	assert.Equal(t, []string{"msg-3", "msg-2"}, uuids(second.Messages))
+	assert.Equal(t, "c-msg-1", second.NextCursor)
+	assert.Equal(t, []string{"limit=2", "cursor=c-msg-3&limit=2"}, log.queries)
+}
+
+// TestGetMessages_NoCursor tests that NextCursor is empty for servers that
+// only support offsets.
+func TestGetMessages_NoCursor(t *testing.T) {
+	// Arrange
+	server := newMessageLog(5, false).server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	page, err := client.GetMessages(context.Background(), "sess-abc123", &stromboli.GetMessagesOptions{Limit: 2})
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, page.HasMore)
+	assert.Empty(t, page.NextCursor)
+}
+
+// TestAllMessages_FollowsCursor tests that messages appended while paging
+// don't cause duplicates when the server returns cursors.
+func TestAllMessages_FollowsCursor(t *testing.T) {
+	// Arrange
+	log := newMessageLog(5, true)
+	log.onPage = prependOnce("msg-6")
+	server := log.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	messages, err := client.AllMessages(context.Background(), "sess-abc123", &stromboli.GetMessagesOptions{Limit: 2})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"msg-5", "msg-4", "msg-3", "msg-2", "msg-1"}, uuids(messages))
+	assert.Equal(t, []string{"limit=2", "cursor=c-msg-3&limit=2", "cursor=c-msg-1&limit=2"}, log.queries)
+}
+
+// TestAllMessages_Offset tests that offsets are advanced for servers that
+// don't return cursors.
+func TestAllMessages_Offset(t *testing.T) {
+	// Arrange
+	log := newMessageLog(5, false)
+	server := log.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	messages, err := client.AllMessages(context.Background(), "sess-abc123", &stromboli.GetMessagesOptions{Limit: 2})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"msg-5", "msg-4", "msg-3", "msg-2", "msg-1"}, uuids(messages))
+	assert.Equal(t, []string{"limit=2", "limit=2&offset=2", "limit=2&offset=4"}, log.queries)
+}
+
+// TestAllMessages_Errors tests that a failing page and a server returning
+// the same cursor twice fail the whole call.
+func TestAllMessages_Errors(t *testing.T) {
+	t.Run("invalid cursor", func(t *testing.T) {
+		// Arrange
+		server := newMessageLog(5, true).server()
+		defer server.Close()
+		client, err := stromboli.NewClient(server.URL)
+		require.NoError(t, err)
+
+		// Act
+		messages, err := client.AllMessages(context.Background(), "sess-abc123", &stromboli.GetMessagesOptions{
+			Cursor: "c-unknown",
+		})
+
+		// Assert
+		assert.Nil(t, messages)
+		assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+	})
+
+	t.Run("repeated cursor", func(t *testing.T) {
+		// Arrange
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			mustEncode(w, map[string]interface{}{
+				"messages":    []map[string]interface{}{{"uuid": "msg-1", "type": "user"}},
+				"has_more":    true,
+				"next_cursor": "stuck",
+			})
+		}))
+		defer server.Close()
+		client, err := stromboli.NewClient(server.URL)
+		require.NoError(t, err)
+
+		// Act
+		_, err = client.AllMessages(context.Background(), "sess-abc123", nil)
+
+		// Assert
+		var apiErr *stromboli.Error
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "INVALID_RESPONSE", apiErr.Code)
+	})
+}
+
+// typedMessagesServer serves a page of messages of alternating types,
+// filtering them by the type query parameter if filter is set. It records
+// the query of the last request.
+func typedMessagesServer(filter bool, query *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*query = r.URL.RawQuery
+		messages := []map[string]interface{}{}
+		for i, msgType := range []string{"user", "assistant", "user", "assistant", "queue-operation"} {
+			if filter && msgType != r.URL.Query().Get("type") {
+				continue
+			}
+			messages = append(messages, map[string]interface{}{
+				"uuid": fmt.Sprintf("msg-%d", i+1), "type": msgType,
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{
+			"messages": messages,
+			"total":    len(messages),
+			"limit":    5,
+			"has_more": false,
+		})
+	}))
+}
+
+// TestGetMessagesByType tests that the type is sent to the server, and
+// that messages are filtered client-side when the server ignores it.
+func TestGetMessagesByType(t *testing.T) {
+	for _, filter := range []bool{true, false} {
+		t.Run(fmt.Sprintf("server filters %v", filter), func(t *testing.T) {
+			// Arrange
+			var query string
+			server := typedMessagesServer(filter, &query)
+			defer server.Close()
+			client, err := stromboli.NewClient(server.URL)
+			require.NoError(t, err)
+
+			// Act
+			resp, err := client.GetMessagesByType(context.Background(), "sess-abc123", "assistant",
+				&stromboli.GetMessagesOptions{Limit: 5, Offset: 10})
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, []string{"msg-2", "msg-4"}, uuids(resp.Messages))
+			assert.Equal(t, "limit=5&offset=10&type=assistant", query)
+		})
+	}
+}
+
+// TestGetMessagesByType_Errors tests that invalid arguments are rejected
+// before any request.
+func TestGetMessagesByType_Errors(t *testing.T) {
+	// Arrange
+	var query string
+	server := typedMessagesServer(true, &query)
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, unknownType := client.GetMessagesByType(ctx, "sess-abc123", "tool", nil)
+	_, emptyType := client.GetMessagesByType(ctx, "sess-abc123", "", nil)
+	_, emptySession := client.GetMessagesByType(ctx, "", "user", nil)
+	_, negativeLimit := client.GetMessagesByType(ctx, "sess-abc123", "user", &stromboli.GetMessagesOptions{Limit: -1})
+
+	// Assert
+	for _, err := range []error{unknownType, emptyType, emptySession, negativeLimit} {
+		assert.True(t, errors.Is(err, stromboli.ErrBadRequest), "got %v", err)
+	}
+	assert.Contains(t, unknownType.Error(), `invalid message type "tool"`)
+	assert.Empty(t, query)
+}
@@ -0,0 +1,223 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/metrics"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// observedRequest is a request reported to recordingMetrics.
+type observedRequest struct {
+	method   string
+	path     string
+	status   int
+	duration time.Duration
+}
+
+// recordingMetrics records the requests it is notified of.
+type recordingMetrics struct {
+	mu       sync.Mutex
+	requests []observedRequest
+}
+
+// ObserveRequest implements stromboli.Metrics.
+func (m *recordingMetrics) ObserveRequest(method, path string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, observedRequest{method, path, status, duration})
+}
+
+// TestWithMetrics_TemplatedPaths tests that every request, including
+// stream connections, is reported with the templated path of its endpoint
+// relative to the base URL.
+func TestWithMetrics_TemplatedPaths(t *testing.T) {
+	// Arrange
+	clock := strombolitest.NewFakeClock(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock.Advance(250 * time.Millisecond)
+		switch strings.TrimPrefix(r.URL.Path, "/api/v1") {
+		case "/jobs/job-abc123":
+			w.Header().Set("Content-Type", "application/json")
+			mustEncode(w, map[string]interface{}{"id": "job-abc123", "status": "running"})
+		case "/sessions/sess-1/messages/msg-1":
+			w.Header().Set("Content-Type", "application/json")
+			mustEncode(w, map[string]interface{}{"message": map[string]interface{}{"uuid": "msg-1"}})
+		case "/jobs/job-abc123/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: Hello\n\n"))
+		case "/images/search":
+			w.Header().Set("Content-Type", "application/json")
+			mustEncode(w, map[string]interface{}{"results": []interface{}{}})
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			mustEncode(w, map[string]string{"error": "not found"})
+		}
+	}))
+	defer server.Close()
+
+	m := &recordingMetrics{}
+	client, err := stromboli.NewClient(server.URL+"/api/v1/", stromboli.WithClock(clock), stromboli.WithMetrics(m))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, _ = client.GetJob(ctx, "job-abc123")
+	_, _ = client.GetMessage(ctx, "sess-1", "msg-1")
+	stream, streamErr := client.StreamJob(ctx, "job-abc123")
+	_, _ = client.SearchImages(ctx, &stromboli.SearchImagesOptions{Query: "python"})
+	_, _ = client.GetImage(ctx, "library/python:3.12")
+
+	// Assert
+	require.NoError(t, streamErr)
+	require.NoError(t, stream.Close())
+	assert.Equal(t, []observedRequest{
+		{"GET", "/jobs/{id}", 200, 250 * time.Millisecond},
+		{"GET", "/sessions/{id}/messages/{message_id}", 200, 250 * time.Millisecond},
+		{"GET", "/jobs/{id}/stream", 200, 250 * time.Millisecond},
+		{"GET", "/images/search", 200, 250 * time.Millisecond},
+		{"GET", "/images/{name}", 404, 250 * time.Millisecond},
+	}, m.requests)
+}
+
+// TestWithMetrics_HandRolledPaths tests that the requests the SDK builds
+// itself, rather than through the generated client, are reported with the
+// template of their own endpoint.
+func TestWithMetrics_HandRolledPaths(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/run/stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("X-Stream-ID", "stream-1")
+			_, _ = w.Write([]byte("data: Hello\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		mustEncode(w, map[string]string{"error": "not found"})
+	}))
+	defer server.Close()
+
+	m := &recordingMetrics{}
+	client, err := stromboli.NewClient(server.URL, stromboli.WithMetrics(m))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, _ = client.Health(ctx)
+	_, _ = client.Capabilities(ctx)
+	_, _ = client.ListJobsFiltered(ctx, &stromboli.ListJobsOptions{Limit: 1})
+	_ = client.GetJobInto(ctx, "job-1", &stromboli.Job{})
+	_, _ = client.GetJobLogs(ctx, "job-1", nil)
+	_, _ = client.StreamJob(ctx, "job-1")
+	_, _ = client.ListSessionsDetailed(ctx, nil)
+	_, _ = client.GetSession(ctx, "sess-1")
+	_, _ = client.GetMessages(ctx, "sess-1", nil)
+	_ = client.UpdateSecret(ctx, &stromboli.CreateSecretRequest{Name: "token", Value: "v"})
+	_, _ = client.SearchImages(ctx, &stromboli.SearchImagesOptions{Query: "python"})
+	_, _ = client.PullImageWithProgress(ctx, &stromboli.PullImageRequest{Image: "python:3.12"}, nil)
+	_, _ = client.BuildImage(ctx, &stromboli.BuildImageRequest{Context: strings.NewReader("tar"), Tag: "agents/python:1.4"})
+	_ = client.DeleteImage(ctx, "python:3.12", nil)
+	stream, err := client.Stream(ctx, &stromboli.StreamRequest{Prompt: "Hi"})
+	require.NoError(t, err)
+	_ = stream.Abort(ctx)
+
+	// Assert
+	reported := map[string]bool{}
+	for _, r := range m.requests {
+		reported[r.method+" "+r.path] = true
+	}
+	for _, want := range []string{
+		"GET /health",
+		"GET /capabilities",
+		"GET /jobs",
+		"GET /jobs/{id}",
+		"GET /jobs/{id}/logs",
+		"GET /jobs/{id}/stream",
+		"GET /sessions",
+		"GET /sessions/{id}",
+		"GET /sessions/{id}/messages",
+		"PUT /secrets/{name}",
+		"GET /images/search",
+		"POST /images/pull",
+		"POST /images/build",
+		"DELETE /images/{name}",
+		"GET /run/stream",
+		"DELETE /run/stream/{id}",
+	} {
+		assert.True(t, reported[want], "%s not reported", want)
+	}
+	for _, r := range m.requests {
+		assert.NotEqual(t, stromboli.UnmatchedPath, r.path, "%s request not matched", r.method)
+	}
+}
+
+// TestWithMetrics_NoResponse tests that requests without a response are
+// reported with status 0, and that clones keep the metrics.
+func TestWithMetrics_NoResponse(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	m := &recordingMetrics{}
+	client, err := stromboli.NewClient(server.URL, stromboli.WithMetrics(m), stromboli.WithRetries(0))
+	require.NoError(t, err)
+
+	// Act
+	_, healthErr := client.Health(context.Background())
+	_, sessionErr := client.Clone().GetSession(context.Background(), "sess-1")
+
+	// Assert
+	assert.Error(t, healthErr)
+	assert.Error(t, sessionErr)
+	require.Len(t, m.requests, 2, "clones keep the metrics without reporting twice")
+	assert.Equal(t, "/health", m.requests[0].path)
+	assert.Equal(t, 0, m.requests[0].status)
+	assert.Equal(t, "/sessions/{id}", m.requests[1].path)
+}
+
+// TestMetricsCollector tests the Prometheus text output of the collector.
+func TestMetricsCollector(t *testing.T) {
+	// Arrange
+	collector := metrics.NewCollector(0.1, 1)
+	collector.ObserveRequest("GET", "/jobs/{id}", 200, 50*time.Millisecond)
+	collector.ObserveRequest("GET", "/jobs/{id}", 200, 500*time.Millisecond)
+	collector.ObserveRequest("GET", "/jobs/{id}", 404, 2*time.Second)
+	collector.ObserveRequest("POST", "/run", 0, 100*time.Millisecond)
+	recorder := httptest.NewRecorder()
+
+	// Act
+	collector.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Assert
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `# HELP stromboli_client_requests_total HTTP requests made by Stromboli clients.
+# TYPE stromboli_client_requests_total counter
+stromboli_client_requests_total{method="GET",path="/jobs/{id}",status="200"} 2
+stromboli_client_requests_total{method="GET",path="/jobs/{id}",status="404"} 1
+stromboli_client_requests_total{method="POST",path="/run",status="0"} 1
+# HELP stromboli_client_request_duration_seconds Duration of HTTP requests made by Stromboli clients.
+# TYPE stromboli_client_request_duration_seconds histogram
+stromboli_client_request_duration_seconds_bucket{method="GET",path="/jobs/{id}",le="0.1"} 1
+stromboli_client_request_duration_seconds_bucket{method="GET",path="/jobs/{id}",le="1"} 2
+stromboli_client_request_duration_seconds_bucket{method="GET",path="/jobs/{id}",le="+Inf"} 3
+stromboli_client_request_duration_seconds_sum{method="GET",path="/jobs/{id}"} 2.55
+stromboli_client_request_duration_seconds_count{method="GET",path="/jobs/{id}"} 3
+stromboli_client_request_duration_seconds_bucket{method="POST",path="/run",le="0.1"} 1
+stromboli_client_request_duration_seconds_bucket{method="POST",path="/run",le="1"} 1
+stromboli_client_request_duration_seconds_bucket{method="POST",path="/run",le="+Inf"} 1
+stromboli_client_request_duration_seconds_sum{method="POST",path="/run"} 0.1
+stromboli_client_request_duration_seconds_count{method="POST",path="/run"} 1
+`, recorder.Body.String())
+}
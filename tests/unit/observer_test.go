@@ -0,0 +1,151 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// observation is an operation reported to a recordingObserver.
+type observation struct {
+	method string
+	status int
+	dur    time.Duration
+	err    error
+}
+
+// recordingObserver records the operations it is notified of.
+type recordingObserver struct {
+	mu       sync.Mutex
+	started  []string
+	finished []observation
+}
+
+// OnStart implements stromboli.Observer.
+func (o *recordingObserver) OnStart(method string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.started = append(o.started, method)
+}
+
+// OnFinish implements stromboli.Observer.
+func (o *recordingObserver) OnFinish(method string, status int, dur time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.finished = append(o.finished, observation{method, status, dur, err})
+}
+
+// TestWithObserver tests that operations are reported with their name,
+// status, duration and error.
+func TestWithObserver(t *testing.T) {
+	// Arrange
+	clock := strombolitest.NewFakeClock(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock.Advance(2 * time.Second)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/health":
+			mustEncode(w, map[string]interface{}{"status": "ok", "version": "0.4.0"})
+		case "/sessions/sess-abc123":
+			mustEncode(w, sessionDetails)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			mustEncode(w, map[string]string{"error": "not found"})
+		}
+	}))
+	defer server.Close()
+
+	obs := &recordingObserver{}
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClock(clock), stromboli.WithObserver(obs))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, healthErr := client.Health(ctx)
+	_, sessionErr := client.GetSession(ctx, "sess-abc123")
+	_, jobErr := client.GetJob(ctx, "job-missing")
+	_, runErr := client.Run(ctx, nil)
+
+	// Assert
+	require.NoError(t, healthErr)
+	require.NoError(t, sessionErr)
+	assert.Equal(t, []string{"Health", "GetSession", "GetJob", "Run"}, obs.started)
+	require.Len(t, obs.finished, 4)
+	assert.Equal(t, observation{"Health", 200, 2 * time.Second, nil}, obs.finished[0])
+	assert.Equal(t, observation{"GetSession", 200, 2 * time.Second, nil}, obs.finished[1])
+	assert.Equal(t, observation{"GetJob", 404, 2 * time.Second, jobErr}, obs.finished[2])
+	assert.True(t, errors.Is(obs.finished[2].err, stromboli.ErrNotFound))
+	assert.Equal(t, observation{"Run", 0, 0, runErr}, obs.finished[3], "no response for requests rejected client-side")
+}
+
+// TestWithObserver_InternalCalls tests that calls made internally by an
+// operation are reported as part of it, while helpers report each call.
+func TestWithObserver_InternalCalls(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/sessions":
+			mustEncode(w, map[string]interface{}{"sessions": []string{"sess-1", "sess-2"}})
+		case "/sessions/sess-1", "/sessions/sess-2":
+			mustEncode(w, map[string]string{"status": "destroyed"})
+		default:
+			// Job streaming, capabilities and job lookup
+			w.WriteHeader(http.StatusNotFound)
+			mustEncode(w, map[string]string{"error": "not found"})
+		}
+	}))
+	defer server.Close()
+
+	obs := &recordingObserver{}
+	client, err := stromboli.NewClient(server.URL, stromboli.WithObserver(obs))
+	require.NoError(t, err)
+
+	// Act
+	_, streamErr := client.StreamJob(context.Background(), "job-missing")
+	destroyed, errs := client.DestroyAllSessions(context.Background(), 1)
+
+	// Assert
+	assert.True(t, errors.Is(streamErr, stromboli.ErrNotFound))
+	assert.Equal(t, 2, destroyed)
+	assert.Nil(t, errs)
+	assert.Equal(t, []string{"StreamJob", "ListSessions", "DestroySession", "DestroySession"}, obs.started)
+	require.Len(t, obs.finished, 4)
+	assert.Equal(t, 404, obs.finished[0].status)
+	for _, o := range obs.finished[1:] {
+		assert.Equal(t, 200, o.status)
+		assert.NoError(t, o.err)
+	}
+}
+
+// TestWithObserver_Clone tests that clones keep the observer, and that it
+// can be removed.
+func TestWithObserver_Clone(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"status": "ok", "version": "0.4.0"})
+	}))
+	defer server.Close()
+
+	obs := &recordingObserver{}
+	client, err := stromboli.NewClient(server.URL, stromboli.WithObserver(obs))
+	require.NoError(t, err)
+
+	// Act
+	require.NoError(t, client.Clone().Ping(context.Background()))
+	require.NoError(t, client.Clone(stromboli.WithObserver(nil)).Ping(context.Background()))
+
+	// Assert
+	assert.Equal(t, []string{"Ping"}, obs.started)
+}
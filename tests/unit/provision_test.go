@@ -0,0 +1,512 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// secretStore is a fake secrets API: creating an existing secret returns
+// 409. It records the values created and counts create, list and run
+// requests.
+type secretStore struct {
+	mu         sync.Mutex
+	values     map[string]string
+	creates    int
+	lists      int
+	runs       int
+	fail       map[string]int // secret name -> status returned on create
+	listStatus int            // status returned on list, if set
+}
+
+// newSecretStore creates an empty secretStore.
+func newSecretStore() *secretStore {
+	return &secretStore{values: map[string]string{}, fail: map[string]int{}}
+}
+
+// server serves the store's secrets and answers runs.
+func (s *secretStore) server() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/secrets":
+			var body map[string]string
+			mustDecode(r, &body)
+			s.creates++
+			if status, ok := s.fail[body["name"]]; ok {
+				w.WriteHeader(status)
+				mustEncode(w, map[string]string{"error": "podman unavailable"})
+				return
+			}
+			if _, ok := s.values[body["name"]]; ok {
+				w.WriteHeader(http.StatusConflict)
+				mustEncode(w, map[string]string{"error": "secret already exists"})
+				return
+			}
+			s.values[body["name"]] = body["value"]
+			w.WriteHeader(http.StatusCreated)
+			mustEncode(w, map[string]interface{}{"success": true, "name": body["name"]})
+		case r.Method == http.MethodGet && r.URL.Path == "/secrets":
+			s.lists++
+			if s.listStatus != 0 {
+				w.WriteHeader(s.listStatus)
+				mustEncode(w, map[string]string{"error": "secrets disabled"})
+				return
+			}
+			list := make([]map[string]string, 0, len(s.values))
+			for name := range s.values {
+				list = append(list, map[string]string{"id": "id-" + name, "name": name})
+			}
+			mustEncode(w, map[string]interface{}{"secrets": list})
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/secrets/"):
+			name := strings.TrimPrefix(r.URL.Path, "/secrets/")
+			if _, ok := s.values[name]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				mustEncode(w, map[string]string{"error": "secret not found"})
+				return
+			}
+			mustEncode(w, map[string]interface{}{"id": "id-" + name, "name": name})
+		case r.Method == http.MethodPost && r.URL.Path == "/run":
+			s.runs++
+			mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed", "output": "done"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// TestEnsureSecrets_Concurrent tests that concurrent EnsureSecrets calls
+// for the same secrets all succeed and create each secret once.
+func TestEnsureSecrets_Concurrent(t *testing.T) {
+	// Arrange
+	store := newSecretStore()
+	server := store.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	secrets := []*stromboli.CreateSecretRequest{
+		{Name: "github-token", Value: "ghp_xxx"},
+		{Name: "npm-token", Value: "npm_xxx"},
+		{Name: "pypi-token", Value: "pypi_xxx"},
+		{Name: "aws-key", Value: "AKIA_xxx"},
+		{Name: "gcp-key", Value: "gcp_xxx"},
+	}
+
+	// Act
+	const workers = 8
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = client.EnsureSecrets(context.Background(), secrets)
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	for i, err := range errs {
+		assert.NoError(t, err, "worker %d", i)
+	}
+	assert.Len(t, store.values, len(secrets))
+	for _, s := range secrets {
+		assert.Equal(t, s.Value, store.values[s.Name])
+	}
+	assert.Equal(t, workers*len(secrets), store.creates)
+}
+
+// TestEnsureSecrets_KeepsExistingValue tests that an existing secret is
+// not overwritten.
+func TestEnsureSecrets_KeepsExistingValue(t *testing.T) {
+	// Arrange
+	store := newSecretStore()
+	store.values["github-token"] = "ghp_old"
+	server := store.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	err = client.EnsureSecrets(context.Background(), []*stromboli.CreateSecretRequest{
+		{Name: "github-token", Value: "ghp_new"},
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "ghp_old", store.values["github-token"])
+}
+
+// TestEnsureSecrets_Failures tests that only real failures are returned,
+// each prefixed with the secret name, and that other secrets are created.
+func TestEnsureSecrets_Failures(t *testing.T) {
+	// Arrange
+	store := newSecretStore()
+	store.values["github-token"] = "ghp_xxx"
+	store.fail["npm-token"] = http.StatusInternalServerError
+	server := store.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	err = client.EnsureSecrets(context.Background(), []*stromboli.CreateSecretRequest{
+		{Name: "github-token", Value: "ghp_xxx"},
+		{Name: "npm-token", Value: "npm_xxx"},
+		{Name: "pypi-token"},
+		{Name: "aws-key", Value: "AKIA_xxx"},
+	})
+
+	// Assert
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, stromboli.ErrInternal))
+	assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+	assert.Contains(t, err.Error(), `secret "npm-token"`)
+	assert.Contains(t, err.Error(), `secret "pypi-token"`)
+	assert.NotContains(t, err.Error(), "github-token")
+	assert.Equal(t, "AKIA_xxx", store.values["aws-key"])
+}
+
+// TestEnsureSecrets_Cancelled tests that a cancelled context fails every
+// secret without sending requests.
+func TestEnsureSecrets_Cancelled(t *testing.T) {
+	// Arrange
+	store := newSecretStore()
+	server := store.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	err = client.EnsureSecrets(ctx, []*stromboli.CreateSecretRequest{
+		{Name: "github-token", Value: "ghp_xxx"},
+	})
+
+	// Assert
+	var apiErr *stromboli.Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "CANCELLED", apiErr.Code)
+	assert.Zero(t, store.creates)
+}
+
+// TestEnsureSecretsBeforeRun tests that the run is sent once the secrets
+// exist, and not at all if a secret fails.
+func TestEnsureSecretsBeforeRun(t *testing.T) {
+	// Arrange
+	store := newSecretStore()
+	store.fail["broken"] = http.StatusInternalServerError
+	server := store.server()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+	req := &stromboli.RunRequest{
+		Prompt: "Open a pull request",
+		Podman: &stromboli.PodmanOptions{SecretsEnv: map[string]string{"GH_TOKEN": "github-token"}},
+	}
+
+	// Act
+	result, err := client.EnsureSecretsBeforeRun(ctx, []*stromboli.CreateSecretRequest{
+		{Name: "github-token", Value: "ghp_xxx"},
+	}, req)
+	_, failErr := client.EnsureSecretsBeforeRun(ctx, []*stromboli.CreateSecretRequest{
+		{Name: "broken", Value: "xxx"},
+	}, req)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "done", result.Output)
+	assert.Equal(t, "ghp_xxx", store.values["github-token"])
+	assert.True(t, errors.Is(failErr, stromboli.ErrInternal))
+	assert.Equal(t, 1, store.runs)
+}
+
+// TestResolveSecretsEnv tests that ResolveSecretsEnv lists every missing
+// secret with its variable.
+func TestResolveSecretsEnv(t *testing.T) {
+	// Arrange
+	store := newSecretStore()
+	store.values["github-token"] = "ghp_xxx"
+	server := store.server()
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	env, err := client.ResolveSecretsEnv(ctx, map[string]string{"GH_TOKEN": "github-token"})
+	_, missingErr := client.ResolveSecretsEnv(ctx, map[string]string{
+		"GH_TOKEN":  "github-token",
+		"NPM_TOKEN": "npm-tokn",
+		"AWS_KEY":   "aws-key",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"GH_TOKEN": "github-token"}, env)
+	require.Error(t, missingErr)
+	assert.True(t, errors.Is(missingErr, stromboli.ErrNotFound))
+	assert.Equal(t, "AWS_KEY: stromboli: NOT_FOUND: secret \"aws-key\" not found\n"+
+		"NPM_TOKEN: stromboli: NOT_FOUND: secret \"npm-tokn\" not found", missingErr.Error())
+	assert.Equal(t, 2, store.lists)
+}
+
+// TestResolveSecretsEnv_ListingDisabled tests that ResolveSecretsEnv skips
+// the check with a warning if the server doesn't allow listing secrets,
+// and returns other errors.
+func TestResolveSecretsEnv_ListingDisabled(t *testing.T) {
+	// Arrange
+	logger := &captureLogger{}
+	stromboli.SetLogger(logger)
+	defer stromboli.SetLogger(nil)
+	store := newSecretStore()
+	store.listStatus = http.StatusNotFound
+	server := store.server()
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+	env := map[string]string{"GH_TOKEN": "github-token"}
+
+	// Act
+	got, err := client.ResolveSecretsEnv(ctx, env)
+	store.listStatus = http.StatusInternalServerError
+	_, failErr := client.ResolveSecretsEnv(ctx, env)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, env, got)
+	assert.Contains(t, strings.Join(logger.Messages(), "\n"), "skipping the check")
+	assert.True(t, errors.Is(failErr, stromboli.ErrInternal))
+}
+
+// TestRun_WithPreflightSecrets tests that Run with WithPreflightSecrets
+// doesn't submit a run referencing a missing secret, and that Run doesn't
+// list secrets without it.
+func TestRun_WithPreflightSecrets(t *testing.T) {
+	// Arrange
+	store := newSecretStore()
+	server := store.server()
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+	req := &stromboli.RunRequest{
+		Prompt: "Open a pull request",
+		Podman: &stromboli.PodmanOptions{SecretsEnv: map[string]string{"GH_TOKEN": "github-token"}},
+	}
+
+	// Act
+	_, preflightErr := client.Run(ctx, req, stromboli.WithPreflightSecrets())
+	runsAfterPreflight := store.runs
+	_, err = client.Run(ctx, req)
+
+	// Assert
+	assert.True(t, errors.Is(preflightErr, stromboli.ErrNotFound))
+	assert.Equal(t, 0, runsAfterPreflight)
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.runs)
+	assert.Equal(t, 1, store.lists)
+}
+
+// imageStore is a fake images API: pulling an image makes it available
+// locally. It counts pull requests.
+type imageStore struct {
+	mu         sync.Mutex
+	local      map[string]bool
+	registry   map[string]int // image -> compatibility rank when pulled
+	pulls      int
+	platforms  []string
+	pullFailed bool
+}
+
+// newImageStore creates a store with the given local images and an
+// image registry holding them and the given ones.
+func newImageStore(local []string, registry map[string]int) *imageStore {
+	s := &imageStore{local: map[string]bool{}, registry: registry}
+	for _, name := range local {
+		s.local[name] = true
+	}
+	return s
+}
+
+// server serves the store's images.
+func (s *imageStore) server() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/images/pull":
+			var body map[string]string
+			mustDecode(r, &body)
+			s.pulls++
+			s.platforms = append(s.platforms, body["platform"])
+			if s.pullFailed {
+				mustEncode(w, map[string]interface{}{"success": false, "image": body["image"]})
+				return
+			}
+			if _, ok := s.registry[body["image"]]; !ok {
+				w.WriteHeader(http.StatusInternalServerError)
+				mustEncode(w, map[string]string{"error": "manifest unknown"})
+				return
+			}
+			s.local[body["image"]] = true
+			mustEncode(w, map[string]interface{}{"success": true, "image": body["image"], "image_id": "sha256:" + body["image"]})
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/images/"):
+			name := strings.TrimPrefix(r.URL.Path, "/images/")
+			if !s.local[name] {
+				w.WriteHeader(http.StatusNotFound)
+				mustEncode(w, map[string]string{"error": "image not found"})
+				return
+			}
+			rank := s.registry[name]
+			mustEncode(w, map[string]interface{}{
+				"id":                 "sha256:" + name,
+				"compatible":         rank < 4,
+				"compatibility_rank": rank,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// TestEnsureImage tests that EnsureImage pulls missing images once, uses
+// local images as they are, and pulls again with Always.
+func TestEnsureImage(t *testing.T) {
+	tests := []struct {
+		name      string
+		local     []string
+		opts      *stromboli.EnsureImageOptions
+		wantPulls int
+	}{
+		{"not present", nil, nil, 1},
+		{"already present", []string{"python:3.12"}, nil, 0},
+		{"always", []string{"python:3.12"}, &stromboli.EnsureImageOptions{Always: true, Platform: "linux/arm64"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			store := newImageStore(tt.local, map[string]int{"python:3.12": 2})
+			server := store.server()
+			defer server.Close()
+			client, err := stromboli.NewClient(server.URL)
+			require.NoError(t, err)
+
+			// Act
+			img, err := client.EnsureImage(context.Background(), "python:3.12", tt.opts)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, "sha256:python:3.12", img.ID)
+			assert.Equal(t, tt.wantPulls, store.pulls)
+			if tt.opts != nil {
+				assert.Equal(t, []string{tt.opts.Platform}, store.platforms)
+			}
+		})
+	}
+}
+
+// TestEnsureImage_Concurrent tests that workers ensuring the same image at
+// once pull it only once.
+func TestEnsureImage_Concurrent(t *testing.T) {
+	// Arrange
+	store := newImageStore(nil, map[string]int{"python:3.12": 2})
+	server := store.server()
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	const workers = 8
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = client.EnsureImage(context.Background(), "python:3.12", nil)
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	for i, err := range errs {
+		assert.NoError(t, err, "worker %d", i)
+	}
+	assert.Equal(t, 1, store.pulls)
+}
+
+// TestEnsureImage_Incompatible tests that RequireCompatible rejects
+// incompatible images, pulled or already present.
+func TestEnsureImage_Incompatible(t *testing.T) {
+	// Arrange
+	store := newImageStore([]string{"node:22-alpine"}, map[string]int{"node:22-alpine": 4, "python:3.12-alpine": 4})
+	server := store.server()
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	strict := &stromboli.EnsureImageOptions{RequireCompatible: true}
+	ctx := context.Background()
+
+	// Act
+	pulled, pulledErr := client.EnsureImage(ctx, "python:3.12-alpine", strict)
+	_, presentErr := client.EnsureImage(ctx, "node:22-alpine", strict)
+	lenient, lenientErr := client.EnsureImage(ctx, "node:22-alpine", nil)
+
+	// Assert
+	assert.Nil(t, pulled)
+	assert.True(t, errors.Is(pulledErr, stromboli.ErrIncompatibleImage))
+	assert.Contains(t, pulledErr.Error(), "compatibility rank 4")
+	assert.True(t, errors.Is(presentErr, stromboli.ErrIncompatibleImage))
+	require.NoError(t, lenientErr)
+	assert.EqualValues(t, 4, lenient.CompatibilityRank)
+	assert.Equal(t, 1, store.pulls)
+}
+
+// TestEnsureImage_Errors tests that pull failures and invalid arguments
+// are returned.
+func TestEnsureImage_Errors(t *testing.T) {
+	// Arrange
+	store := newImageStore(nil, map[string]int{})
+	server := store.server()
+	defer server.Close()
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, unknownErr := client.EnsureImage(ctx, "missing:latest", nil)
+	store.pullFailed = true
+	_, unsuccessfulErr := client.EnsureImage(ctx, "missing:latest", nil)
+	_, emptyErr := client.EnsureImage(ctx, "", nil)
+
+	// Assert
+	require.Error(t, unknownErr)
+	assert.Contains(t, unknownErr.Error(), "manifest unknown")
+	assert.True(t, errors.Is(unsuccessfulErr, stromboli.ErrImagePullFailed))
+	assert.True(t, errors.Is(emptyErr, stromboli.ErrBadRequest))
+}
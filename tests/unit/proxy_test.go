@@ -0,0 +1,277 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// flushRecorder is a ResponseRecorder that records the body at every flush.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes []string
+}
+
+// Flush implements http.Flusher.
+func (r *flushRecorder) Flush() {
+	r.flushes = append(r.flushes, r.Body.String())
+	r.ResponseRecorder.Flush()
+}
+
+// plainWriter is a ResponseWriter that doesn't support flushing.
+type plainWriter struct {
+	header http.Header
+}
+
+// Header implements http.ResponseWriter.
+func (w *plainWriter) Header() http.Header { return w.header }
+
+// Write implements http.ResponseWriter.
+func (w *plainWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+// WriteHeader implements http.ResponseWriter.
+func (w *plainWriter) WriteHeader(int) {}
+
+// proxyUpstream opens a stream from an upstream server sending the given
+// raw SSE events.
+func proxyUpstream(t *testing.T, events ...string) *stromboli.Stream {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, event := range events {
+			_, _ = fmt.Fprint(w, event)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Test"})
+	require.NoError(t, err)
+	return stream
+}
+
+// TestProxyStream_ForwardsEvents tests the SSE headers and that every event
+// is written and flushed.
+func TestProxyStream_ForwardsEvents(t *testing.T) {
+	// Arrange
+	stream := proxyUpstream(t,
+		"data: Hello\n\n",
+		"id: 7\nevent: message\ndata: line one\ndata: line two\n\n",
+		"event: done\ndata: \n\n",
+	)
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r := httptest.NewRequest(http.MethodGet, "/chat", nil)
+
+	// Act
+	err := stromboli.ProxyStream(w, r, stream, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "no", w.Header().Get("X-Accel-Buffering"))
+	assert.Equal(t,
+		"data: Hello\n\n"+
+			"id: 7\nevent: message\ndata: line one\ndata: line two\n\n"+
+			"event: done\ndata: \n\n",
+		w.Body.String())
+
+	// One flush for the headers, then one per event
+	require.Len(t, w.flushes, 4)
+	assert.Empty(t, w.flushes[0])
+	assert.Equal(t, "data: Hello\n\n", w.flushes[1])
+}
+
+// TestProxyStream_Transform tests re-wrapping events as JSON and dropping
+// events.
+func TestProxyStream_Transform(t *testing.T) {
+	// Arrange
+	stream := proxyUpstream(t,
+		"data: Hello\n\n",
+		"event: tool_use\ndata: internal\n\n",
+		"data: World\n\n",
+	)
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r := httptest.NewRequest(http.MethodGet, "/chat", nil)
+
+	opts := &stromboli.ProxyOptions{
+		Transform: func(event *stromboli.StreamEvent) *stromboli.StreamEvent {
+			if event.Type == "tool_use" {
+				return nil
+			}
+			data, _ := json.Marshal(map[string]string{"text": event.Data})
+			return &stromboli.StreamEvent{Type: "chunk", Data: string(data)}
+		},
+	}
+
+	// Act
+	err := stromboli.ProxyStream(w, r, stream, opts)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t,
+		"event: chunk\ndata: {\"text\":\"Hello\"}\n\n"+
+			"event: chunk\ndata: {\"text\":\"World\"}\n\n",
+		w.Body.String())
+}
+
+// TestProxyStream_SanitizesFields tests that line breaks in transformed
+// events can't inject SSE fields.
+func TestProxyStream_SanitizesFields(t *testing.T) {
+	// Arrange
+	stream := proxyUpstream(t, "data: Hello\n\n")
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r := httptest.NewRequest(http.MethodGet, "/chat", nil)
+
+	opts := &stromboli.ProxyOptions{
+		Transform: func(event *stromboli.StreamEvent) *stromboli.StreamEvent {
+			return &stromboli.StreamEvent{
+				ID:   "1\nevent: admin",
+				Type: "chunk\r\n",
+				Data: "a\r\nb\rc",
+			}
+		},
+	}
+
+	// Act
+	err := stromboli.ProxyStream(w, r, stream, opts)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "id: 1event: admin\nevent: chunk\ndata: a\ndata: b\ndata: c\n\n", w.Body.String())
+}
+
+// TestProxyStream_KeepAlive tests that comments are sent while the stream
+// is idle.
+func TestProxyStream_KeepAlive(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, "data: First\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(150 * time.Millisecond)
+		_, _ = fmt.Fprint(w, "data: Second\n\n")
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Test"})
+	require.NoError(t, err)
+
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r := httptest.NewRequest(http.MethodGet, "/chat", nil)
+
+	// Act
+	err = stromboli.ProxyStream(w, r, stream, &stromboli.ProxyOptions{KeepAlive: 20 * time.Millisecond})
+
+	// Assert
+	require.NoError(t, err)
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "data: First\n\n: keepalive\n\n"), body)
+	assert.True(t, strings.HasSuffix(body, ": keepalive\n\ndata: Second\n\n"), body)
+}
+
+// TestProxyStream_InvalidArguments tests that a nil stream or a writer that
+// can't flush is rejected without writing anything.
+func TestProxyStream_InvalidArguments(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/chat", nil)
+
+	t.Run("nil stream", func(t *testing.T) {
+		// Act
+		w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		err := stromboli.ProxyStream(w, r, nil, nil)
+
+		// Assert
+		assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+		assert.Empty(t, w.flushes)
+	})
+
+	t.Run("writer without flush", func(t *testing.T) {
+		// Arrange
+		stream := proxyUpstream(t, "data: Hello\n\n")
+		w := &plainWriter{header: http.Header{}}
+
+		// Act
+		err := stromboli.ProxyStream(w, r, stream, nil)
+
+		// Assert
+		assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+		assert.Empty(t, w.header)
+		assert.False(t, stream.Next(), "stream should be closed")
+	})
+}
+
+// TestProxyStream_EndToEnd tests proxying a stream through a real server
+// to an HTTP client, and that a client disconnect cancels the upstream
+// request.
+func TestProxyStream_EndToEnd(t *testing.T) {
+	// Arrange
+	upstreamCancelled := make(chan struct{})
+	upstream := stalledStreamServer(upstreamCancelled)
+	defer upstream.Close()
+
+	client, err := stromboli.NewClient(upstream.URL)
+	require.NoError(t, err)
+
+	proxyErr := make(chan error, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream, err := client.Stream(r.Context(), &stromboli.StreamRequest{Prompt: "Test"})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		proxyErr <- stromboli.ProxyStream(w, r, stream, nil)
+	}))
+	defer proxy.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, proxy.URL, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	cancel()
+
+	// Assert
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "data: First\n", line)
+
+	select {
+	case err := <-proxyErr:
+		var apiErr *stromboli.Error
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "CANCELLED", apiErr.Code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ProxyStream did not return after the client disconnected")
+	}
+
+	select {
+	case <-upstreamCancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream request was not cancelled")
+	}
+}
@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// countingStream opens a stream whose events carry the data "0" to "n-1".
+func countingStream(t *testing.T, n int) *stromboli.Stream {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < n; i++ {
+			_, _ = fmt.Fprintf(w, "data: %d\n\n", i)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Count"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = stream.Close() })
+	return stream
+}
+
+// eventData returns the data of each event.
+func eventData(events []*stromboli.StreamEvent) []string {
+	data := make([]string, len(events))
+	for i, event := range events {
+		data[i] = event.Data
+	}
+	return data
+}
+
+// TestStream_Replay_Wraparound tests that only the most recent events are
+// kept, oldest first, and that the history can't be modified.
+func TestStream_Replay_Wraparound(t *testing.T) {
+	// Arrange
+	stream := countingStream(t, 7)
+	stream.EnableReplay(3)
+
+	// Act
+	for stream.Next() {
+	}
+	history := stream.Replay()
+	want := eventData(history)
+	history[0].Data = "changed"
+
+	// Assert
+	require.NoError(t, stream.Err())
+	assert.Equal(t, []string{"4", "5", "6"}, want)
+	assert.Equal(t, want, eventData(stream.Replay()))
+}
+
+// TestStream_Replay_Resize tests that resizing keeps the most recent events
+// and that disabling replay drops the history.
+func TestStream_Replay_Resize(t *testing.T) {
+	// Arrange
+	stream := countingStream(t, 5)
+	stream.EnableReplay(4)
+	for i := 0; i < 5; i++ {
+		require.True(t, stream.Next())
+	}
+
+	// Act
+	stream.EnableReplay(2)
+	shrunk := stream.Replay()
+	stream.EnableReplay(0)
+	disabled := stream.Replay()
+
+	// Assert
+	assert.Equal(t, []string{"3", "4"}, eventData(shrunk))
+	assert.Nil(t, disabled)
+}
+
+// TestStream_Replay_CatchUp tests that a consumer attached mid-stream
+// receives the history followed by the live events, in order and without
+// gaps or duplicates.
+func TestStream_Replay_CatchUp(t *testing.T) {
+	// Arrange
+	stream := countingStream(t, 10)
+	stream.EnableReplay(100)
+	var primary, late []string
+	attached := false
+
+	// Act
+	for stream.Next() {
+		event := stream.Event()
+		primary = append(primary, event.Data)
+		if !attached && event.Data == "4" {
+			late = append(late, eventData(stream.Replay())...)
+			attached = true
+			continue
+		}
+		if attached {
+			late = append(late, event.Data)
+		}
+	}
+
+	// Assert
+	require.NoError(t, stream.Err())
+	want := make([]string, 10)
+	for i := range want {
+		want[i] = strconv.Itoa(i)
+	}
+	assert.Equal(t, want, primary)
+	assert.Equal(t, want, late)
+}
+
+// TestStream_Replay_DisabledByDefault tests that no history is kept unless
+// replay is enabled.
+func TestStream_Replay_DisabledByDefault(t *testing.T) {
+	// Arrange
+	stream := countingStream(t, 3)
+
+	// Act
+	for stream.Next() {
+	}
+
+	// Assert
+	require.NoError(t, stream.Err())
+	assert.Nil(t, stream.Replay())
+}
@@ -0,0 +1,171 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// randomIDPattern matches the random IDs generated by the client: 26
+// characters of the base32 alphabet (128 bits).
+var randomIDPattern = `^[A-Z2-7]{26}$`
+
+// requestIDServer answers health checks, streams, jobs and sessions, and
+// records the given header of each request by path. Unknown jobs are not
+// found.
+type requestIDServer struct {
+	header string
+
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+// start starts the server.
+func (s *requestIDServer) start(t *testing.T) *httptest.Server {
+	t.Helper()
+	s.ids = make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.ids[r.URL.Path] = r.Header.Get(s.header)
+		s.mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/run/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: Hello\n\n"))
+		case r.URL.Path == "/jobs/job-abc123":
+			w.Header().Set("Content-Type", "application/json")
+			mustEncode(w, map[string]interface{}{"id": "job-abc123", "status": "running"})
+		case strings.HasPrefix(r.URL.Path, "/jobs/"):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			mustEncode(w, map[string]string{"error": "job not found"})
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok", "version": "0.3.0"})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// id returns the request ID received for path.
+func (s *requestIDServer) id(path string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ids[path]
+}
+
+// TestWithRequestIDHeader_Default tests that every kind of request gets
+// its own random UUID in the X-Request-ID header.
+func TestWithRequestIDHeader_Default(t *testing.T) {
+	// Arrange
+	s := &requestIDServer{header: "X-Request-ID"}
+	server := s.start(t)
+	client, err := stromboli.NewClient(server.URL, stromboli.WithRequestIDHeader("", nil))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, healthErr := client.Health(ctx)
+	_, jobErr := client.GetJob(ctx, "job-abc123")
+	stream, streamErr := client.Stream(ctx, &stromboli.StreamRequest{Prompt: "Hi"})
+	require.NoError(t, streamErr)
+	_ = stream.Close()
+	var job stromboli.Job
+	intoErr := client.GetJobInto(ctx, "job-def456", &job)
+
+	// Assert
+	require.NoError(t, healthErr)
+	require.NoError(t, jobErr)
+	assert.Error(t, intoErr)
+	seen := map[string]bool{}
+	for _, path := range []string{"/health", "/jobs/job-abc123", "/run/stream", "/jobs/job-def456"} {
+		id := s.id(path)
+		assert.Regexp(t, randomIDPattern, id, "%s: request ID", path)
+		assert.False(t, seen[id], "%s: request IDs are unique", path)
+		seen[id] = true
+	}
+}
+
+// TestWithRequestIDHeader_CustomAndContext tests a custom header and
+// generator, and that a context ID takes precedence over the generator.
+func TestWithRequestIDHeader_CustomAndContext(t *testing.T) {
+	// Arrange
+	s := &requestIDServer{header: "X-Correlation-ID"}
+	server := s.start(t)
+	var n int
+	gen := func() string {
+		n++
+		return fmt.Sprintf("req-%d", n)
+	}
+	client, err := stromboli.NewClient(server.URL, stromboli.WithRequestIDHeader("x-correlation-id", gen))
+	require.NoError(t, err)
+
+	// Act
+	_, healthErr := client.Health(context.Background())
+	stream, streamErr := client.Clone().Stream(
+		stromboli.ContextWithRequestID(context.Background(), "incoming-42"),
+		&stromboli.StreamRequest{Prompt: "Hi"})
+	require.NoError(t, streamErr)
+	_ = stream.Close()
+
+	// Assert
+	require.NoError(t, healthErr)
+	assert.Equal(t, "req-1", s.id("/health"))
+	assert.Equal(t, "incoming-42", s.id("/run/stream"))
+}
+
+// TestWithRequestIDHeader_ErrorRequestID tests that a failed call reports
+// the ID of the request that failed, for generated and raw requests.
+func TestWithRequestIDHeader_ErrorRequestID(t *testing.T) {
+	// Arrange
+	s := &requestIDServer{header: "X-Request-ID"}
+	server := s.start(t)
+	client, err := stromboli.NewClient(server.URL, stromboli.WithRequestIDHeader("", nil))
+	require.NoError(t, err)
+	var job stromboli.Job
+
+	// Act
+	_, getErr := client.GetJob(context.Background(), "job-missing")
+	intoErr := client.GetJobInto(context.Background(), "job-gone", &job)
+
+	// Assert
+	for path, err := range map[string]error{"/jobs/job-missing": getErr, "/jobs/job-gone": intoErr} {
+		var apiErr *stromboli.Error
+		require.True(t, errors.As(err, &apiErr), "%s: got %v", path, err)
+		assert.Equal(t, "NOT_FOUND", apiErr.Code)
+		assert.NotEmpty(t, apiErr.RequestID)
+		assert.Equal(t, s.id(path), apiErr.RequestID, path)
+	}
+	assert.Empty(t, stromboli.ErrNotFound.RequestID, "sentinel errors are not modified")
+}
+
+// TestWithRequestIDHeader_Disabled tests that no request ID is sent by
+// default.
+func TestWithRequestIDHeader_Disabled(t *testing.T) {
+	// Arrange
+	s := &requestIDServer{header: "X-Request-ID"}
+	server := s.start(t)
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	_, getErr := client.GetJob(context.Background(), "job-missing")
+
+	// Assert
+	var apiErr *stromboli.Error
+	require.True(t, errors.As(getErr, &apiErr))
+	assert.Empty(t, apiErr.RequestID)
+	assert.Empty(t, s.id("/jobs/job-missing"))
+}
@@ -0,0 +1,129 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// TestResolveRequest_MatchesRunBody tests that the resolved JSON is the body
+// Run sends, with the transformations of the server's version.
+//
+// Update the expectations together with the field table in shaping.go when
+// regenerated models add fields that older servers reject.
+func TestResolveRequest_MatchesRunBody(t *testing.T) {
+	tests := []struct {
+		version         string
+		transformations []stromboli.Transformation
+	}{
+		{version: "0.3.0-alpha", transformations: nil},
+		{version: "0.4.2", transformations: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			// Arrange
+			var bodies []map[string]interface{}
+			server := runShapingServer(t, tt.version, &bodies)
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL, stromboli.WithVersionAwareRequests())
+			require.NoError(t, err)
+
+			// Act
+			resolved, err := client.ResolveRequest(context.Background(), fullRunRequest())
+			require.NoError(t, err)
+			_, err = client.Run(context.Background(), fullRunRequest())
+			require.NoError(t, err)
+
+			// Assert
+			require.Len(t, bodies, 1)
+			var got map[string]interface{}
+			require.NoError(t, json.Unmarshal(resolved.JSON, &got))
+			assert.Equal(t, bodies[0], got)
+			assert.Equal(t, tt.transformations, resolved.Transformations)
+			assert.Empty(t, resolved.Warnings)
+		})
+	}
+}
+
+// TestResolveRequest_Warnings tests that validation failures that don't stop
+// the request are reported as warnings.
+func TestResolveRequest_Warnings(t *testing.T) {
+	// Arrange
+	client, err := stromboli.NewClient("http://localhost:8585", stromboli.WithValidationMode(stromboli.ValidationWarn))
+	require.NoError(t, err)
+	req := &stromboli.RunRequest{
+		Prompt: "Hello",
+		Claude: &stromboli.ClaudeOptions{Model: "claude-future"},
+		Podman: &stromboli.PodmanOptions{Memory: "lots"},
+	}
+
+	// Act
+	resolved, err := client.ResolveRequest(context.Background(), req)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, resolved.Warnings, 2)
+	assert.Contains(t, resolved.Warnings[0], `unknown model "claude-future"`)
+	assert.Contains(t, resolved.Warnings[1], "memory")
+	assert.Empty(t, resolved.ServerVersion, "version-aware requests are disabled")
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(resolved.JSON, &body))
+	assert.Equal(t, "Hello", body["prompt"])
+	assert.Equal(t, "claude-future", body["claude"].(map[string]interface{})["model"])
+	assert.Equal(t, "lots", body["podman"].(map[string]interface{})["memory"])
+}
+
+// TestResolveRequest_Strict tests that requests rejected in strict mode
+// return the same error as Run.
+func TestResolveRequest_Strict(t *testing.T) {
+	// Arrange
+	client, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+	req := &stromboli.RunRequest{
+		Prompt: "Hello",
+		Podman: &stromboli.PodmanOptions{Memory: "lots"},
+	}
+
+	// Act
+	resolved, resolveErr := client.ResolveRequest(context.Background(), req)
+	_, runErr := client.Run(context.Background(), req)
+
+	// Assert
+	assert.Nil(t, resolved)
+	require.Error(t, resolveErr)
+	assert.True(t, errors.Is(resolveErr, stromboli.ErrBadRequest))
+	assert.Equal(t, runErr.Error(), resolveErr.Error())
+}
+
+// TestRun_DryRun tests that dry runs resolve the request without executing
+// it.
+func TestRun_DryRun(t *testing.T) {
+	// Arrange
+	var bodies []map[string]interface{}
+	server := runShapingServer(t, "0.4.2", &bodies)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	req := fullRunRequest()
+	req.DryRun = true
+
+	// Act
+	resp, err := client.Run(context.Background(), req)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, bodies, "dry runs must not reach /run")
+	assert.Equal(t, stromboli.RunStatusDryRun, resp.Status)
+	require.NotNil(t, resp.Resolved)
+	assert.Contains(t, string(resp.Resolved.JSON), `"prompt": "Hello"`)
+	assert.NotContains(t, string(resp.Resolved.JSON), "dry", "DryRun is never sent")
+}
@@ -0,0 +1,139 @@
+package unit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// endlessJSONServer returns a server that answers with a JSON object whose
+// "output" string never ends, written until the client goes away.
+func endlessJSONServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id":"run-1","status":"completed","output":"`)
+		chunk := strings.Repeat("x", 32*1024)
+		for {
+			if _, err := fmt.Fprint(w, chunk); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+// TestWithMaxResponseSize tests that responses larger than the limit fail
+// with ErrResponseTooLarge instead of being read whole, for generated and
+// hand-written endpoints.
+func TestWithMaxResponseSize(t *testing.T) {
+	// Arrange
+	server := endlessJSONServer()
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithMaxResponseSize(1024*1024))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, runErr := client.Run(ctx, &stromboli.RunRequest{Prompt: "hi"})
+	_, messagesErr := client.GetMessages(ctx, "sess-1", nil)
+
+	// Assert
+	for _, err := range []error{runErr, messagesErr} {
+		var apiErr *stromboli.Error
+		require.True(t, errors.As(err, &apiErr), "got %v", err)
+		assert.Equal(t, "RESPONSE_TOO_LARGE", apiErr.Code)
+		assert.True(t, errors.Is(err, stromboli.ErrResponseTooLarge))
+	}
+}
+
+// TestWithMaxResponseSize_Limit tests that a body of exactly the limit is
+// read, that a larger declared Content-Length fails, and that the limit
+// applies to decompressed bodies.
+func TestWithMaxResponseSize_Limit(t *testing.T) {
+	// Arrange
+	session := `{"id":"` + strings.Repeat("s", 100) + `"}`
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write([]byte(`{"id":"` + strings.Repeat("s", 10000) + `"}`))
+	require.NoError(t, zw.Close())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/sessions/exact":
+			_, _ = fmt.Fprint(w, session)
+		case "/sessions/declared":
+			w.Header().Set("Content-Length", "100000")
+			_, _ = fmt.Fprint(w, session)
+		default:
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write(compressed.Bytes())
+		}
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithMaxResponseSize(int64(len(session))),
+		stromboli.WithCompression(),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	exact, exactErr := client.GetSession(ctx, "exact")
+	_, declaredErr := client.GetSession(ctx, "declared")
+	_, compressedErr := client.GetSession(ctx, "compressed")
+
+	// Assert
+	require.NoError(t, exactErr)
+	assert.Len(t, exact.ID, 100)
+	assert.True(t, errors.Is(declaredErr, stromboli.ErrResponseTooLarge), "got %v", declaredErr)
+	assert.True(t, errors.Is(compressedErr, stromboli.ErrResponseTooLarge), "got %v", compressedErr)
+}
+
+// TestWithMaxResponseSize_Disabled tests that 0 disables the limit, that
+// clones keep it, and that streams aren't limited.
+func TestWithMaxResponseSize_Disabled(t *testing.T) {
+	// Arrange
+	output := strings.Repeat("o", 4096)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/run/stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprintf(w, "data: %s\n\nevent: done\ndata: \n\n", output)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed", "output": output})
+	}))
+	defer server.Close()
+
+	limited, err := stromboli.NewClient(server.URL, stromboli.WithMaxResponseSize(1024))
+	require.NoError(t, err)
+	unlimited := limited.Clone(stromboli.WithMaxResponseSize(0))
+	ctx := context.Background()
+
+	// Act
+	_, limitedErr := limited.Clone().Run(ctx, &stromboli.RunRequest{Prompt: "hi"})
+	result, unlimitedErr := unlimited.Run(ctx, &stromboli.RunRequest{Prompt: "hi"})
+	stream, streamErr := limited.Stream(ctx, &stromboli.StreamRequest{Prompt: "hi"})
+	require.NoError(t, streamErr)
+	streamed, collectErr := stream.Collect(ctx)
+
+	// Assert
+	assert.True(t, errors.Is(limitedErr, stromboli.ErrResponseTooLarge), "got %v", limitedErr)
+	require.NoError(t, unlimitedErr)
+	assert.Equal(t, output, result.Output)
+	require.NoError(t, collectErr)
+	assert.Equal(t, output, streamed)
+}
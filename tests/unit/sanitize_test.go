@@ -0,0 +1,142 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// rawJSONString quotes s as a JSON string without replacing invalid UTF-8,
+// mimicking servers that pass tool output through byte for byte.
+func rawJSONString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20:
+			fmt.Fprintf(&b, `\u%04x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// outputServer serves output on /run, /jobs/{id} and /run/stream.
+func outputServer(output string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/run":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id":"run-1","status":"completed","output":%s}`, rawJSONString(output))
+		case strings.HasPrefix(r.URL.Path, "/jobs/"):
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id":"job-1","status":"completed","output":%s}`, rawJSONString(output))
+		case r.URL.Path == "/run/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: %s\n\n", output)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// outputSurfaces returns the output of server as seen through Run, GetJob
+// and Stream.
+func outputSurfaces(t *testing.T, client *stromboli.Client) map[string]string {
+	t.Helper()
+	ctx := context.Background()
+
+	run, err := client.Run(ctx, &stromboli.RunRequest{Prompt: "hi"})
+	require.NoError(t, err)
+
+	job, err := client.GetJob(ctx, "job-1")
+	require.NoError(t, err)
+
+	stream, err := client.Stream(ctx, &stromboli.StreamRequest{Prompt: "hi"})
+	require.NoError(t, err)
+	defer func() { _ = stream.Close() }()
+	require.True(t, stream.Next())
+
+	return map[string]string{
+		"run":    run.Output,
+		"job":    job.Output,
+		"stream": stream.Event().Data,
+	}
+}
+
+// TestOutputSanitization tests that every sanitization mode normalizes
+// Run, Job and stream output consistently.
+func TestOutputSanitization(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		mode   stromboli.OutputSanitization
+		want   string
+	}{
+		{"replace invalid utf8", "caf\xe9 ok", stromboli.OutputReplaceInvalid, "caf\ufffd ok"},
+		{"replace strips bom", "\ufeffhello", stromboli.OutputReplaceInvalid, "hello"},
+		{"replace keeps ansi", "\x1b[31mred\x1b[0m", stromboli.OutputReplaceInvalid, "\x1b[31mred\x1b[0m"},
+		{"strip ansi colors", "\x1b[1;31mred\x1b[0m text", stromboli.OutputStripANSI, "red text"},
+		{"strip ansi title", "\x1b]0;title\x07done", stromboli.OutputStripANSI, "done"},
+		{"strip ansi keeps bom", "\ufeffhello", stromboli.OutputStripANSI, "\ufeffhello"},
+		{
+			"combined",
+			"\ufeff\x1b[32mcaf\xe9\x1b[0m",
+			stromboli.OutputReplaceInvalid | stromboli.OutputStripANSI,
+			"caf\ufffd",
+		},
+		{"preserve bom", "\ufeffhello", stromboli.OutputPreserve, "\ufeffhello"},
+		{"preserve ansi", "\x1b[31mred\x1b[0m", stromboli.OutputPreserve, "\x1b[31mred\x1b[0m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := outputServer(tt.output)
+			defer server.Close()
+
+			client, err := stromboli.NewClient(server.URL, stromboli.WithOutputSanitization(tt.mode))
+			require.NoError(t, err)
+
+			// Act
+			got := outputSurfaces(t, client)
+
+			// Assert
+			for surface, output := range got {
+				assert.Equal(t, tt.want, output, surface)
+			}
+		})
+	}
+}
+
+// TestOutputSanitization_DefaultPreserves tests that invalid UTF-8 is left
+// as received by default.
+func TestOutputSanitization_DefaultPreserves(t *testing.T) {
+	// Arrange
+	server := outputServer("caf\xe9")
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	got := outputSurfaces(t, client)
+
+	// Assert: JSON decoding replaces invalid bytes, streams pass them through
+	assert.Equal(t, "caf\ufffd", got["run"])
+	assert.Equal(t, "caf\ufffd", got["job"])
+	assert.Equal(t, "caf\xe9", got["stream"])
+}
@@ -0,0 +1,279 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolischema"
+)
+
+// schemaAuthor is a nested struct of schemaReview.
+type schemaAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+// schemaBase is embedded in schemaReview to test field promotion.
+type schemaBase struct {
+	ID string `json:"id"`
+}
+
+// schemaReview is the struct derived in TestSchemaFor.
+type schemaReview struct {
+	schemaBase
+	Summary   string          `json:"summary" desc:"One-sentence summary"`
+	Score     int             `json:"score" enum:"1,2,3"`
+	Tags      []string        `json:"tags" enum:"bug,style"`
+	Author    *schemaAuthor   `json:"author"`
+	Labels    map[string]int  `json:"labels,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Extra     json.RawMessage `json:"extra,omitempty"`
+	Ignored   string          `json:"-"`
+	internal  string          //nolint:unused // Testing unexported fields are skipped
+	Untagged  bool
+	Nested    []schemaAuthor    `json:"nested"`
+	Scores    map[string]string `json:"scores" enum:"low,high"`
+}
+
+// TestSchemaFor tests the schema derived from a struct covering each
+// supported kind of field and tag.
+func TestSchemaFor(t *testing.T) {
+	// Act
+	schema, err := stromboli.SchemaFor[schemaReview](stromboli.WithSchemaTitle("Review"))
+
+	// Assert
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"title": "Review",
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"summary": {"type": "string", "description": "One-sentence summary"},
+			"score": {"type": "integer", "enum": [1, 2, 3]},
+			"tags": {"type": "array", "items": {"type": "string", "enum": ["bug", "style"]}},
+			"author": {
+				"type": "object",
+				"properties": {"name": {"type": "string"}, "email": {"type": "string"}},
+				"required": ["name"]
+			},
+			"labels": {"type": "object", "additionalProperties": {"type": "integer"}},
+			"created_at": {"type": "string", "format": "date-time"},
+			"extra": {},
+			"Untagged": {"type": "boolean"},
+			"nested": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {"name": {"type": "string"}, "email": {"type": "string"}},
+					"required": ["name"]
+				}
+			},
+			"scores": {"type": "object", "additionalProperties": {"type": "string", "enum": ["low", "high"]}}
+		},
+		"required": ["id", "summary", "score", "tags", "created_at", "Untagged", "nested", "scores"]
+	}`, schema)
+}
+
+// TestSchemaFor_Strict tests that WithStrictSchema forbids additional
+// properties on every struct object, and that properties keep field order.
+func TestSchemaFor_Strict(t *testing.T) {
+	// Arrange
+	type wrapper struct {
+		Zeta  schemaAuthor `json:"zeta"`
+		Alpha string       `json:"alpha"`
+	}
+
+	// Act
+	schema, err := stromboli.SchemaFor[*wrapper](stromboli.WithStrictSchema())
+
+	// Assert
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"zeta": {
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {"name": {"type": "string"}, "email": {"type": "string"}},
+				"required": ["name"]
+			},
+			"alpha": {"type": "string"}
+		},
+		"required": ["zeta", "alpha"]
+	}`, schema)
+	assert.Less(t, strings.Index(schema, `"zeta"`), strings.Index(schema, `"alpha"`))
+}
+
+// schemaNode is a recursive struct, which SchemaFor rejects.
+type schemaNode struct {
+	Children []schemaNode `json:"children"`
+}
+
+// TestSchemaFor_Unsupported tests that unsupported types are rejected with
+// a BAD_REQUEST error naming the field.
+func TestSchemaFor_Unsupported(t *testing.T) {
+	type withChan struct {
+		Events chan string `json:"events"`
+	}
+	type withIntMap struct {
+		Counts map[int]string `json:"counts"`
+	}
+	type withBadEnum struct {
+		Level int `json:"level" enum:"low"`
+	}
+
+	tests := []struct {
+		name    string
+		schema  func(opts ...stromboli.SchemaOption) (string, error)
+		wantMsg string
+	}{
+		{"not a struct", stromboli.SchemaFor[[]string], "not a struct"},
+		{"channel", stromboli.SchemaFor[withChan], "withChan.Events: unsupported type chan string"},
+		{"interface", stromboli.SchemaFor[struct{ Any interface{} }], ".Any: unsupported type interface {}"},
+		{"map key", stromboli.SchemaFor[withIntMap], "keys must be strings"},
+		{"enum value", stromboli.SchemaFor[withBadEnum], `invalid enum value "low"`},
+		{"recursive", stromboli.SchemaFor[schemaNode], "recursive type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := tt.schema()
+
+			// Assert
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+			assert.Contains(t, err.Error(), tt.wantMsg)
+		})
+	}
+}
+
+// TestWithSchemaValidator tests that Run and RunAsync check JSON schemas
+// with the configured validator before sending, subject to the validation
+// mode.
+func TestWithSchemaValidator(t *testing.T) {
+	// Arrange
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/run/async" {
+			w.WriteHeader(http.StatusAccepted)
+			mustEncode(w, map[string]interface{}{"job_id": "job-1"})
+			return
+		}
+		mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed"})
+	}))
+	defer server.Close()
+
+	var validated []string
+	validator := stromboli.WithSchemaValidator(func(schema string) error {
+		validated = append(validated, schema)
+		return errors.New("properties must be an object")
+	})
+	strict, err := stromboli.NewClient(server.URL, validator)
+	require.NoError(t, err)
+	lenient, err := stromboli.NewClient(server.URL, validator,
+		stromboli.WithValidationMode(stromboli.ValidationWarn),
+	)
+	require.NoError(t, err)
+	withSchema := func(schema string) *stromboli.RunRequest {
+		return &stromboli.RunRequest{Prompt: "test", Claude: &stromboli.ClaudeOptions{JSONSchema: schema}}
+	}
+	ctx := context.Background()
+
+	// Act
+	_, runErr := strict.Run(ctx, withSchema(`{"type":"object","properties":[]}`))
+	_, asyncErr := strict.RunAsync(ctx, withSchema(`{"type":"object","properties":[]}`))
+	_, notJSONErr := strict.Run(ctx, withSchema(`{`))
+	_, noSchemaErr := strict.Run(ctx, &stromboli.RunRequest{Prompt: "test"})
+	_, lenientErr := lenient.Run(ctx, withSchema(`{"type":"object","properties":[]}`))
+
+	// Assert
+	require.Error(t, runErr)
+	assert.True(t, errors.Is(runErr, stromboli.ErrBadRequest))
+	assert.Contains(t, runErr.Error(), "invalid JSON schema: properties must be an object")
+	assert.True(t, errors.Is(asyncErr, stromboli.ErrBadRequest))
+	assert.True(t, errors.Is(notJSONErr, stromboli.ErrBadRequest))
+	assert.NoError(t, noSchemaErr)
+	assert.NoError(t, lenientErr)
+	assert.Len(t, validated, 3, "the validator only sees schemas passing the minimal check")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+// TestStrombolischemaValidator tests that the draft-07 validator rejects
+// schemas that are invalid or don't compile, without loading references.
+func TestStrombolischemaValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantMsg string
+	}{
+		{"valid", `{"type":"object","properties":{"a":{"type":"string"}},"required":["a"]}`, ""},
+		{"local reference", `{"definitions":{"s":{"type":"string"}},"properties":{"a":{"$ref":"#/definitions/s"}}}`, ""},
+		{"derived by SchemaFor", mustSchemaFor(t), ""},
+		{"draft-07 keywords", `{
+			"$schema": "http://json-schema.org/draft-07/schema#",
+			"type": "object",
+			"properties": {
+				"kind": {"const": "refund"},
+				"amount": {"type": "number", "exclusiveMinimum": 0},
+				"reason": {"type": "string", "pattern": "^[a-z ]+$"}
+			},
+			"if": {"properties": {"kind": {"const": "refund"}}},
+			"then": {"required": ["reason"]},
+			"else": false
+		}`, ""},
+		{"named anchor", `{"definitions":{"s":{"$id":"#str","type":"string"}},"items":{"$ref":"#str"}}`, ""},
+		{"draft-04 exclusiveMinimum", `{"type":"number","minimum":0,"exclusiveMinimum":true}`, "not a valid JSON schema"},
+		{"if not a schema", `{"if":5,"then":{"required":["a"]}}`, "not a valid JSON schema"},
+		{"invalid pattern", `{"type":"string","pattern":"(unclosed"}`, "not a valid JSON schema"},
+		{"negative maxLength", `{"type":"string","maxLength":-1}`, "not a valid JSON schema"},
+		{"empty anyOf", `{"anyOf":[]}`, "not a valid JSON schema"},
+		{"unknown type", `{"type":"objekt"}`, "not a valid JSON schema"},
+		{"required not a list", `{"type":"object","required":"a"}`, "not a valid JSON schema"},
+		{"unresolved reference", `{"properties":{"a":{"$ref":"#/definitions/missing"}}}`, "doesn't compile"},
+		{"remote reference", `{"properties":{"a":{"$ref":"http://127.0.0.1:1/schema.json"}}}`, "not within the schema"},
+		{"file reference", `{"properties":{"a":{"$ref":"/etc/hosts"}}}`, "not within the schema"},
+		{"unresolved anchor", `{"items":{"$ref":"#missing"}}`, "doesn't compile"},
+		{"reference in const", `{"const":{"$ref":"http://127.0.0.1:1/schema.json"}}`, ""},
+	}
+
+	validate := strombolischema.Validator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := validate(tt.schema)
+
+			// Assert
+			if tt.wantMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantMsg)
+		})
+	}
+}
+
+// mustSchemaFor returns the schema derived from schemaReview.
+func mustSchemaFor(t *testing.T) string {
+	t.Helper()
+	schema, err := stromboli.SchemaFor[schemaReview](stromboli.WithStrictSchema())
+	require.NoError(t, err)
+	return schema
+}
@@ -0,0 +1,214 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// newFakeServer starts a fake server closed at the end of the test, and a
+// client of it.
+func newFakeServer(t *testing.T, opts ...stromboli.Option) (*strombolitest.Server, *stromboli.Client) {
+	t.Helper()
+	server := strombolitest.NewServer()
+	t.Cleanup(server.Close)
+	client, err := stromboli.NewClient(server.URL, opts...)
+	require.NoError(t, err)
+	return server, client
+}
+
+// messageJSON returns the JSON encoding of a message's content.
+func messageJSON(t *testing.T, msg *stromboli.Message) string {
+	t.Helper()
+	data, err := json.Marshal(msg.Content)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// TestServer_RunSessions tests that runs use the run handler, and that
+// resumed runs append to the session's messages.
+func TestServer_RunSessions(t *testing.T) {
+	// Arrange
+	server, client := newFakeServer(t)
+	server.HandleRun(func(run *strombolitest.Run) *strombolitest.Result {
+		return &strombolitest.Result{
+			Output: "answer to " + run.Prompt,
+			Usage:  &stromboli.Usage{TotalCostUSD: 0.01},
+		}
+	})
+	ctx := context.Background()
+
+	// Act
+	first, err := client.Run(ctx, &stromboli.RunRequest{Prompt: "one"})
+	require.NoError(t, err)
+	second, err := client.Run(ctx, &stromboli.RunRequest{
+		Prompt: "two",
+		Claude: &stromboli.ClaudeOptions{SessionID: first.SessionID, Resume: true},
+	})
+	require.NoError(t, err)
+	messages, msgErr := client.AllMessages(ctx, first.SessionID, nil)
+	_, unknownErr := client.Run(ctx, &stromboli.RunRequest{
+		Prompt: "three",
+		Claude: &stromboli.ClaudeOptions{SessionID: "unknown", Resume: true},
+	})
+
+	// Assert
+	assert.Equal(t, "answer to one", first.Output)
+	assert.Equal(t, first.SessionID, second.SessionID)
+	cost, ok := second.CostUSD()
+	assert.True(t, ok)
+	assert.InDelta(t, 0.01, cost, 1e-9)
+	require.NoError(t, msgErr)
+	require.Len(t, messages, 4)
+	assert.Equal(t, "user", messages[2].Type)
+	assert.Contains(t, messageJSON(t, messages[2]), `"text":"two"`)
+	assert.Equal(t, "assistant", messages[3].Type)
+	assert.Contains(t, messageJSON(t, messages[3]), `"text":"answer to two"`)
+	assert.ErrorIs(t, unknownErr, stromboli.ErrNotFound)
+}
+
+// TestServer_FailNext tests that FailNext fails the next requests only.
+func TestServer_FailNext(t *testing.T) {
+	// Arrange
+	server, client := newFakeServer(t)
+	server.FailNext(http.StatusInternalServerError)
+	server.FailNext(http.StatusNotFound)
+	ctx := context.Background()
+
+	// Act
+	_, firstErr := client.Run(ctx, &stromboli.RunRequest{Prompt: "Hi"})
+	_, secondErr := client.GetJob(ctx, "job-1")
+	_, thirdErr := client.Health(ctx)
+
+	// Assert
+	assert.ErrorIs(t, firstErr, stromboli.ErrInternal)
+	assert.ErrorIs(t, secondErr, stromboli.ErrNotFound)
+	assert.NoError(t, thirdErr)
+}
+
+// TestServer_Latency tests that latency makes requests time out.
+func TestServer_Latency(t *testing.T) {
+	// Arrange
+	server, client := newFakeServer(t, stromboli.WithTimeout(50*time.Millisecond))
+	server.SetLatency(time.Second)
+
+	// Act
+	_, err := client.Health(context.Background())
+
+	// Assert
+	assert.ErrorIs(t, err, stromboli.ErrTimeout)
+}
+
+// TestServer_Jobs tests that jobs run for the job duration on the server's
+// clock, and that running jobs can be cancelled and streamed.
+func TestServer_Jobs(t *testing.T) {
+	// Arrange
+	server, client := newFakeServer(t)
+	clock := strombolitest.NewFakeClock(time.Now())
+	server.SetClock(clock)
+	server.SetJobDuration(time.Minute)
+	ctx := context.Background()
+	done, err := client.RunAsync(ctx, &stromboli.RunRequest{Prompt: "done"})
+	require.NoError(t, err)
+	cancelled, err := client.RunAsync(ctx, &stromboli.RunRequest{Prompt: "cancelled"})
+	require.NoError(t, err)
+
+	// Act
+	running, err := client.GetJob(ctx, done.JobID)
+	require.NoError(t, err)
+	stream, err := client.StreamJob(ctx, done.JobID)
+	require.NoError(t, err)
+	defer stream.Close()
+	require.NoError(t, client.CancelJob(ctx, cancelled.JobID))
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	var output string
+	for stream.Next() {
+		output += stream.Event().Data
+	}
+	finished, err := client.GetJob(ctx, done.JobID)
+	require.NoError(t, err)
+	cancelErr := client.CancelJob(ctx, done.JobID)
+	jobs, err := client.ListJobsFiltered(ctx, &stromboli.ListJobsOptions{Status: []string{stromboli.JobStatusCancelled}})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, stromboli.JobStatusRunning, running.Status)
+	require.NoError(t, stream.Err())
+	assert.Equal(t, "done", output)
+	assert.Equal(t, stromboli.JobStatusCompleted, finished.Status)
+	assert.Equal(t, "done", finished.Output)
+	assert.Error(t, cancelErr, "finished jobs can't be cancelled")
+	require.Len(t, jobs, 1)
+	assert.Equal(t, cancelled.JobID, jobs[0].ID)
+}
+
+// TestServer_Stream tests that streams send the scripted events and report
+// their session.
+func TestServer_Stream(t *testing.T) {
+	// Arrange
+	server, client := newFakeServer(t)
+	server.HandleRun(func(run *strombolitest.Run) *strombolitest.Result {
+		return &strombolitest.Result{Events: []stromboli.StreamEvent{
+			{Data: "Hel"},
+			{Data: "lo"},
+			{Type: stromboli.EventTypeError, Data: "rate limited"},
+		}}
+	})
+
+	// Act
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Hi"})
+	require.NoError(t, err)
+	defer stream.Close()
+	var events []*stromboli.StreamEvent
+	for stream.Next() {
+		events = append(events, stream.Event())
+	}
+
+	// Assert
+	require.Len(t, events, 3)
+	assert.Equal(t, "Hel", events[0].Data)
+	assert.Equal(t, stromboli.EventTypeError, events[2].Type)
+	assert.NotEmpty(t, stream.SessionID())
+	sessions, err := client.ListSessions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{stream.SessionID()}, sessions)
+}
+
+// TestServer_SecretsAndImages tests the secret and image CRUD operations,
+// including image names containing slashes.
+func TestServer_SecretsAndImages(t *testing.T) {
+	// Arrange
+	server, client := newFakeServer(t)
+	ctx := context.Background()
+	secret := &stromboli.CreateSecretRequest{Name: "api-key", Value: "v1"}
+	const image = "ghcr.io/acme/agent:1.0"
+
+	// Act
+	require.NoError(t, client.CreateSecret(ctx, secret))
+	existsErr := client.CreateSecret(ctx, secret)
+	require.NoError(t, client.EnsureSecret(ctx, &stromboli.CreateSecretRequest{Name: "api-key", Value: "v2"}))
+	value, _ := server.Secret("api-key")
+	_, err := client.PullImage(ctx, &stromboli.PullImageRequest{Image: image})
+	require.NoError(t, err)
+	got, getErr := client.GetImage(ctx, image)
+	deleteErr := client.DeleteImage(ctx, image, nil)
+	missingErr := client.DeleteImage(ctx, image, nil)
+
+	// Assert
+	assert.ErrorIs(t, existsErr, stromboli.ErrSecretExists)
+	assert.Equal(t, "v2", value)
+	require.NoError(t, getErr)
+	assert.Equal(t, "ghcr.io/acme/agent", got.Repository)
+	assert.Equal(t, "1.0", got.Tag)
+	assert.NoError(t, deleteErr)
+	assert.ErrorIs(t, missingErr, stromboli.ErrImageNotFound)
+}
@@ -0,0 +1,241 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// sessionServer is a fake server logging the start and end of each run and
+// stream request, by session. Runs take a few milliseconds so that
+// concurrent requests would overlap; streams send one event and end.
+type sessionServer struct {
+	mu  sync.Mutex
+	log map[string][]string
+}
+
+// record appends entry to the log of sessionID.
+func (s *sessionServer) record(sessionID, entry string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log[sessionID] = append(s.log[sessionID], entry)
+}
+
+// entries returns the log of sessionID.
+func (s *sessionServer) entries(sessionID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.log[sessionID]...)
+}
+
+// newSessionServer starts a sessionServer.
+func newSessionServer(t *testing.T) (*sessionServer, *httptest.Server) {
+	t.Helper()
+	s := &sessionServer{log: map[string][]string{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/run":
+			var body struct {
+				Prompt string `json:"prompt"`
+				Claude struct {
+					SessionID string `json:"session_id"`
+				} `json:"claude"`
+			}
+			mustDecode(r, &body)
+			s.record(body.Claude.SessionID, "start "+body.Prompt)
+			time.Sleep(5 * time.Millisecond)
+			s.record(body.Claude.SessionID, "end "+body.Prompt)
+			w.Header().Set("Content-Type", "application/json")
+			mustEncode(w, map[string]string{"id": "run-1", "status": "completed", "session_id": body.Claude.SessionID})
+		case "/run/stream":
+			s.record(r.URL.Query().Get("session_id"), "stream "+r.URL.Query().Get("prompt"))
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: Hello\n\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+// resumeRequest returns a run request resuming sessionID with prompt.
+func resumeRequest(sessionID, prompt string) *stromboli.RunRequest {
+	return &stromboli.RunRequest{
+		Prompt: prompt,
+		Claude: &stromboli.ClaudeOptions{SessionID: sessionID, Resume: true},
+	}
+}
+
+// TestSessionSerialization_ConcurrentResumes tests that concurrent runs
+// resuming a session reach the server one at a time, each run ending
+// before the next one starts.
+func TestSessionSerialization_ConcurrentResumes(t *testing.T) {
+	// Arrange
+	log, server := newSessionServer(t)
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSessionSerialization())
+	require.NoError(t, err)
+	const turns = 8
+
+	// Act
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*turns)
+	for i := 0; i < turns; i++ {
+		for _, sessionID := range []string{"sess-a", "sess-b"} {
+			wg.Add(1)
+			go func(sessionID, prompt string) {
+				defer wg.Done()
+				_, err := client.Run(context.Background(), resumeRequest(sessionID, prompt))
+				errs <- err
+			}(sessionID, fmt.Sprintf("turn %d", i))
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	// Assert
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	for _, sessionID := range []string{"sess-a", "sess-b"} {
+		entries := log.entries(sessionID)
+		require.Len(t, entries, 2*turns, sessionID)
+		for i := 0; i < len(entries); i += 2 {
+			var prompt string
+			_, err := fmt.Sscanf(entries[i], "start turn %s", &prompt)
+			require.NoError(t, err, "%s: %v", sessionID, entries)
+			assert.Equal(t, "end turn "+prompt, entries[i+1], "%s: turns interleaved: %v", sessionID, entries)
+		}
+	}
+}
+
+// TestSessionSerialization_Stream tests that a stream holds its session
+// until it is closed: runs wait for it, and TryRun reports the session
+// busy without contacting the server.
+func TestSessionSerialization_Stream(t *testing.T) {
+	// Arrange
+	log, server := newSessionServer(t)
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSessionSerialization())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	stream, err := client.Stream(ctx, &stromboli.StreamRequest{Prompt: "first", SessionID: "sess-a"})
+	require.NoError(t, err)
+	_, busyErr := client.TryRun(ctx, resumeRequest("sess-a", "second"))
+	_, otherErr := client.TryRun(ctx, resumeRequest("sess-b", "other"))
+	_, newErr := client.TryRun(ctx, &stromboli.RunRequest{Prompt: "new"})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Run(ctx, resumeRequest("sess-a", "third"))
+		done <- err
+	}()
+	var blocked bool
+	select {
+	case <-done:
+	case <-time.After(50 * time.Millisecond):
+		blocked = true
+	}
+	require.NoError(t, stream.Close())
+	var runErr error
+	select {
+	case runErr = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not proceed after the stream was closed")
+	}
+
+	// Assert
+	assert.True(t, errors.Is(busyErr, stromboli.ErrSessionBusy))
+	assert.NoError(t, otherErr)
+	assert.NoError(t, newErr)
+	assert.True(t, blocked, "Run waits for the stream")
+	assert.NoError(t, runErr)
+	assert.Equal(t, []string{"stream first", "start third", "end third"}, log.entries("sess-a"))
+}
+
+// TestSessionSerialization_StreamEnd tests that a stream releases its
+// session when it ends, even if it isn't closed.
+func TestSessionSerialization_StreamEnd(t *testing.T) {
+	// Arrange
+	_, server := newSessionServer(t)
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSessionSerialization())
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "first", SessionID: "sess-a"})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	// Act
+	for stream.Next() {
+	}
+	_, err = client.TryRun(context.Background(), resumeRequest("sess-a", "second"))
+
+	// Assert
+	require.NoError(t, stream.Err())
+	assert.NoError(t, err)
+}
+
+// TestSessionSerialization_CancelledWhileWaiting tests that a run waiting
+// for its session returns when its context is done, without contacting
+// the server or holding the session.
+func TestSessionSerialization_CancelledWhileWaiting(t *testing.T) {
+	// Arrange
+	log, server := newSessionServer(t)
+	client, err := stromboli.NewClient(server.URL, stromboli.WithSessionSerialization())
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "first", SessionID: "sess-a"})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Act
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Run(ctx, resumeRequest("sess-a", "second"))
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	var runErr error
+	select {
+	case runErr = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+	require.NoError(t, stream.Close())
+	_, tryErr := client.TryRun(context.Background(), resumeRequest("sess-a", "third"))
+
+	// Assert
+	var apiErr *stromboli.Error
+	require.True(t, errors.As(runErr, &apiErr))
+	assert.Equal(t, "CANCELLED", apiErr.Code)
+	assert.NoError(t, tryErr)
+	assert.Equal(t, []string{"stream first", "start third", "end third"}, log.entries("sess-a"))
+}
+
+// TestSessionSerialization_Disabled tests that without the option, TryRun
+// doesn't check sessions and runs of a session may overlap.
+func TestSessionSerialization_Disabled(t *testing.T) {
+	// Arrange
+	_, server := newSessionServer(t)
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "first", SessionID: "sess-a"})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	// Act
+	_, err = client.TryRun(context.Background(), resumeRequest("sess-a", "second"))
+
+	// Assert
+	assert.NoError(t, err)
+}
@@ -0,0 +1,211 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// sessionDetails is the metadata of a session as sent by the server.
+var sessionDetails = map[string]interface{}{
+	"id":            "sess-abc123",
+	"created_at":    "2024-01-15T10:30:00Z",
+	"updated_at":    "2024-01-15T11:02:00Z",
+	"message_count": 12,
+	"last_prompt":   "Add tests for the parser",
+	"workdir":       "/workspace",
+	"model":         "sonnet",
+}
+
+// TestGetSession_Success tests fetching the metadata of a session.
+func TestGetSession_Success(t *testing.T) {
+	// Arrange
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, sessionDetails)
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	info, err := client.GetSession(context.Background(), "sess-abc123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "/sessions/sess-abc123", path)
+	assert.Equal(t, &stromboli.SessionInfo{
+		ID:           "sess-abc123",
+		CreatedAt:    "2024-01-15T10:30:00Z",
+		UpdatedAt:    "2024-01-15T11:02:00Z",
+		MessageCount: 12,
+		LastPrompt:   "Add tests for the parser",
+		Workdir:      "/workspace",
+		Model:        stromboli.ModelSonnet,
+	}, info)
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), info.CreatedAtTime())
+	assert.Equal(t, time.Date(2024, 1, 15, 11, 2, 0, 0, time.UTC), info.UpdatedAtTime())
+}
+
+// TestGetSession_Errors tests the errors of GetSession: missing sessions,
+// servers without the endpoint and an empty ID.
+func TestGetSession_Errors(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/sessions/old-server" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		mustEncode(w, map[string]string{"error": "session not found"})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	_, notFound := client.GetSession(context.Background(), "sess-missing")
+	_, unsupported := client.GetSession(context.Background(), "old-server")
+	_, empty := client.GetSession(context.Background(), "")
+
+	// Assert
+	assert.True(t, errors.Is(notFound, stromboli.ErrNotFound))
+	var apiErr *stromboli.Error
+	require.True(t, errors.As(unsupported, &apiErr))
+	assert.Equal(t, "UNSUPPORTED", apiErr.Code)
+	assert.True(t, errors.Is(empty, stromboli.ErrBadRequest))
+}
+
+// TestListSessionsDetailed_Success tests listing sessions with their
+// metadata and server-side pagination.
+func TestListSessionsDetailed_Success(t *testing.T) {
+	// Arrange
+	var query map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = map[string]string{
+			"detailed": r.URL.Query().Get("detailed"),
+			"limit":    r.URL.Query().Get("limit"),
+			"offset":   r.URL.Query().Get("offset"),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{
+			"sessions": []interface{}{sessionDetails, map[string]interface{}{"id": "sess-def456", "message_count": 3}},
+			"total":    6,
+		})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	sessions, err := client.ListSessionsDetailed(context.Background(), &stromboli.ListSessionsOptions{Limit: 2, Offset: 4})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"detailed": "true", "limit": "2", "offset": "4"}, query)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "sess-abc123", sessions[0].ID)
+	assert.Equal(t, "Add tests for the parser", sessions[0].LastPrompt)
+	assert.Equal(t, "sess-def456", sessions[1].ID)
+	assert.Equal(t, int64(3), sessions[1].MessageCount)
+}
+
+// TestListSessionsDetailed_IDsOnly tests that servers listing bare session
+// IDs are supported, with pagination applied client-side.
+func TestListSessionsDetailed_IDsOnly(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"sessions": []string{"sess-1", "sess-2", "sess-3", "sess-4"}})
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// Act
+	all, err := client.ListSessionsDetailed(context.Background(), nil)
+	require.NoError(t, err)
+	page, err := client.ListSessionsDetailed(context.Background(), &stromboli.ListSessionsOptions{Limit: 2, Offset: 1})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Len(t, all, 4)
+	assert.Equal(t, []*stromboli.SessionInfo{{ID: "sess-2"}, {ID: "sess-3"}}, page)
+}
+
+// newUnpaginatedSessionServer returns a server listing n detailed sessions,
+// sess-0 to sess-<n-1>, whatever the pagination parameters.
+func newUnpaginatedSessionServer(n int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sessions" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		sessions := make([]map[string]interface{}, n)
+		for i := range sessions {
+			sessions[i] = map[string]interface{}{"id": fmt.Sprintf("sess-%d", i), "message_count": i}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"sessions": sessions})
+	}))
+}
+
+// TestListSessionsDetailed_UnpaginatedServer tests that a detailed server
+// ignoring pagination gets it applied client-side, with an offset alone
+// and with a page extending past the last session.
+func TestListSessionsDetailed_UnpaginatedServer(t *testing.T) {
+	// Arrange
+	server := newUnpaginatedSessionServer(15)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	offsetOnly, err := client.ListSessionsDetailed(ctx, &stromboli.ListSessionsOptions{Offset: 12})
+	require.NoError(t, err)
+	shortPage, err := client.ListSessionsDetailed(ctx, &stromboli.ListSessionsOptions{Offset: 10, Limit: 20})
+	require.NoError(t, err)
+
+	// Assert
+	ids := func(sessions []*stromboli.SessionInfo) []string {
+		var ids []string
+		for _, s := range sessions {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"sess-12", "sess-13", "sess-14"}, ids(offsetOnly))
+	assert.Equal(t, []string{"sess-10", "sess-11", "sess-12", "sess-13", "sess-14"}, ids(shortPage))
+}
+
+// TestListSessionsDetailed_InvalidOptions tests that negative pagination
+// values are rejected.
+func TestListSessionsDetailed_InvalidOptions(t *testing.T) {
+	// Arrange
+	client, err := stromboli.NewClient("http://localhost:8585")
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.ListSessionsDetailed(context.Background(), &stromboli.ListSessionsOptions{Offset: -1})
+
+	// Assert
+	assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+}
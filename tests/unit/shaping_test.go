@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// fullRunRequest returns a RunRequest with every optional field set.
+func fullRunRequest() *stromboli.RunRequest {
+	return &stromboli.RunRequest{
+		Prompt:     "Hello",
+		Workdir:    "/workspace",
+		WebhookURL: "https://example.com/webhook",
+		Claude: &stromboli.ClaudeOptions{
+			Model:          stromboli.ModelHaiku,
+			SessionID:      "sess-1",
+			Resume:         true,
+			MaxBudgetUSD:   1.5,
+			AllowedTools:   []string{"Read"},
+			OutputFormat:   "json",
+			Agents:         map[string]interface{}{"reviewer": map[string]interface{}{}},
+			SettingSources: []string{"user"},
+		},
+		Podman: &stromboli.PodmanOptions{
+			Memory:     "1g",
+			Timeout:    "5m",
+			Image:      "python:3.12",
+			SecretsEnv: map[string]string{"GH_TOKEN": "github-token"},
+			Lifecycle: &stromboli.LifecycleHooks{
+				PostStart: []string{"echo ready"},
+			},
+			Environment: &stromboli.EnvironmentConfig{
+				Type: "compose",
+				Path: "compose.yaml",
+			},
+		},
+	}
+}
+
+// runShapingServer returns a server reporting version via /capabilities
+// that records the body of each /run request into bodies.
+func runShapingServer(t *testing.T, version string, bodies *[]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/capabilities":
+			mustEncode(w, map[string]interface{}{"version": version})
+		case "/run":
+			var body map[string]interface{}
+			mustDecode(r, &body)
+			*bodies = append(*bodies, body)
+			mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// deletePath removes a dotted JSON path (e.g. "podman.environment") from body.
+func deletePath(body map[string]interface{}, path string) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := body[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		body = next
+	}
+	delete(body, parts[len(parts)-1])
+}
+
+// TestVersionAwareRequests_FieldsPerServerVersion pins which run request
+// fields are omitted for each server version.
+//
+// Update the expectations together with the field table in shaping.go when
+// regenerated models add fields that older servers reject.
+func TestVersionAwareRequests_FieldsPerServerVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		dropped []string
+	}{
+		{version: "0.3.0-alpha", dropped: nil},
+		{version: "0.3.5", dropped: nil},
+		{version: "0.4.0-alpha", dropped: nil},
+		{version: "0.4.2", dropped: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			// Arrange
+			var bodies []map[string]interface{}
+			server := runShapingServer(t, tt.version, &bodies)
+			defer server.Close()
+
+			plain, err := stromboli.NewClient(server.URL)
+			require.NoError(t, err)
+			aware, err := stromboli.NewClient(server.URL, stromboli.WithVersionAwareRequests())
+			require.NoError(t, err)
+
+			// Act
+			_, err = plain.Run(context.Background(), fullRunRequest())
+			require.NoError(t, err)
+			_, err = aware.Run(context.Background(), fullRunRequest())
+			require.NoError(t, err)
+
+			// Assert
+			require.Len(t, bodies, 2)
+			want := bodies[0]
+			for _, path := range tt.dropped {
+				deletePath(want, path)
+			}
+			assert.Equal(t, want, bodies[1])
+		})
+	}
+}
+
+// TestVersionAwareRequests_UnknownVersion tests that requests are sent
+// unchanged when the server version can't be determined.
+func TestVersionAwareRequests_UnknownVersion(t *testing.T) {
+	// Arrange
+	var bodies []map[string]interface{}
+	server := runShapingServer(t, "not-a-version", &bodies)
+	defer server.Close()
+
+	plain, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	aware, err := stromboli.NewClient(server.URL, stromboli.WithVersionAwareRequests())
+	require.NoError(t, err)
+
+	// Act
+	_, err = plain.Run(context.Background(), fullRunRequest())
+	require.NoError(t, err)
+	_, err = aware.Run(context.Background(), fullRunRequest())
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, bodies, 2)
+	assert.Equal(t, bodies[0], bodies[1])
+}
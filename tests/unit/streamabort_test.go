@@ -0,0 +1,193 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/strombolitest"
+)
+
+// abortableStreamServer serves a stream with the given ID that sends one
+// event, then waits until it is aborted, sends finalEvent (unless empty)
+// and ends. Aborts are answered with abortStatus, and their paths are
+// recorded.
+type abortableStreamServer struct {
+	streamID    string
+	finalEvent  string
+	abortStatus int
+
+	mu      sync.Mutex
+	aborts  []string
+	aborted chan struct{}
+}
+
+// start starts the server.
+func (s *abortableStreamServer) start(t *testing.T) *httptest.Server {
+	t.Helper()
+	s.aborted = make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			s.mu.Lock()
+			s.aborts = append(s.aborts, r.URL.Path)
+			s.mu.Unlock()
+			close(s.aborted)
+			w.WriteHeader(s.abortStatus)
+			return
+		}
+
+		if s.streamID != "" {
+			w.Header().Set("X-Stream-ID", s.streamID)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: working\n\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-s.aborted:
+		case <-r.Context().Done():
+			return
+		}
+		if s.finalEvent == "" {
+			<-r.Context().Done() // never ends on its own
+			return
+		}
+		_, _ = w.Write([]byte(s.finalEvent))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// abortPaths returns the paths of the abort requests received.
+func (s *abortableStreamServer) abortPaths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.aborts...)
+}
+
+// openAbortableStream opens a stream on server and reads its first event.
+func openAbortableStream(t *testing.T, server *httptest.Server, opts ...stromboli.Option) *stromboli.Stream {
+	t.Helper()
+	client, err := stromboli.NewClient(server.URL, opts...)
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Hello"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = stream.Close() })
+	require.True(t, stream.Next())
+	return stream
+}
+
+// TestStream_Abort tests that Abort sends the abort request with the
+// stream's ID, reads the final events and closes the stream.
+func TestStream_Abort(t *testing.T) {
+	// Arrange
+	s := &abortableStreamServer{
+		streamID:    "str-abc123",
+		finalEvent:  "event: aborted\ndata: stopped by client\n\n",
+		abortStatus: http.StatusNoContent,
+	}
+	stream := openAbortableStream(t, s.start(t))
+	stream.EnableReplay(10)
+
+	// Act
+	err := stream.Abort(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "str-abc123", stream.ID())
+	assert.Equal(t, []string{"/run/stream/str-abc123"}, s.abortPaths())
+	assert.Equal(t, "aborted", stream.Event().Type)
+	assert.Equal(t, "stopped by client", stream.Event().Data)
+	assert.Len(t, stream.Replay(), 1)
+	assert.NoError(t, stream.Err())
+	assert.False(t, stream.Next(), "the stream is closed")
+	assert.NoError(t, stream.Abort(context.Background()), "aborting a closed stream does nothing")
+	assert.Len(t, s.abortPaths(), 1)
+}
+
+// TestStream_Abort_DrainTimeout tests that Abort stops reading final events
+// after the drain deadline, without reporting an error.
+func TestStream_Abort_DrainTimeout(t *testing.T) {
+	// Arrange
+	clock := strombolitest.NewFakeClock(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	s := &abortableStreamServer{streamID: "str-abc123", abortStatus: http.StatusOK}
+	stream := openAbortableStream(t, s.start(t), stromboli.WithClock(clock))
+
+	// Act
+	done := make(chan error, 1)
+	go func() { done <- stream.Abort(context.Background()) }()
+	clock.BlockUntil(1)
+	clock.Advance(5 * time.Second)
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Abort did not return after the drain deadline")
+	}
+
+	// Assert
+	require.NoError(t, err)
+	assert.NoError(t, stream.Err())
+	assert.False(t, stream.Next())
+}
+
+// TestStream_Abort_Errors tests that the stream is closed when it can't be
+// aborted, and that a stream unknown to the server is only closed.
+func TestStream_Abort_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		streamID    string
+		abortStatus int
+		wantCode    string
+		wantAborts  int
+	}{
+		{name: "no stream ID", wantCode: "UNSUPPORTED"},
+		{name: "abort not allowed", streamID: "str-1", abortStatus: http.StatusMethodNotAllowed, wantCode: "UNSUPPORTED", wantAborts: 1},
+		{name: "server error", streamID: "str-1", abortStatus: http.StatusInternalServerError, wantCode: "INTERNAL", wantAborts: 1},
+		{name: "already ended", streamID: "str-1", abortStatus: http.StatusNotFound, wantAborts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			s := &abortableStreamServer{streamID: tt.streamID, abortStatus: tt.abortStatus, finalEvent: "data: bye\n\n"}
+			stream := openAbortableStream(t, s.start(t), stromboli.WithRetries(0))
+
+			// Act
+			err := stream.Abort(context.Background())
+
+			// Assert
+			if tt.wantCode == "" {
+				assert.NoError(t, err)
+			} else {
+				var apiErr *stromboli.Error
+				require.True(t, errors.As(err, &apiErr), "got %v", err)
+				assert.Equal(t, tt.wantCode, apiErr.Code)
+			}
+			assert.Len(t, s.abortPaths(), tt.wantAborts)
+			assert.False(t, stream.Next(), "the stream is closed")
+		})
+	}
+}
+
+// TestStream_Close_DoesNotAbort tests that Close only drops the connection.
+func TestStream_Close_DoesNotAbort(t *testing.T) {
+	// Arrange
+	s := &abortableStreamServer{streamID: "str-abc123", abortStatus: http.StatusNoContent}
+	stream := openAbortableStream(t, s.start(t))
+
+	// Act
+	err := stream.Close()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, s.abortPaths())
+	assert.False(t, stream.Next())
+}
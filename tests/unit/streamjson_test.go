@@ -0,0 +1,356 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// streamJSONFixtures are stream-json messages of each type, as sent in the
+// data of stream events.
+var streamJSONFixtures = map[string]string{
+	"system": `{"type":"system","subtype":"init","session_id":"sess-abc123","model":"claude-sonnet-4-20250514",` +
+		`"cwd":"/workspace","tools":["Bash","Read"],"mcp_servers":[]}`,
+	"assistant": `{"type":"assistant","session_id":"sess-abc123","message":{"id":"msg_01","role":"assistant",` +
+		`"content":[{"type":"thinking","thinking":"hmm"},{"type":"text","text":"Let me check. "},` +
+		`{"type":"tool_use","id":"toolu_01","name":"Bash","input":{"command":"ls"}},{"type":"text","text":"Done."}]}}`,
+	"tool_use":    `{"type":"tool_use","id":"toolu_02","name":"Read","input":{"file_path":"go.mod"},"session_id":"sess-abc123"}`,
+	"text_delta":  `{"type":"stream_event","session_id":"sess-abc123","event":{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}}`,
+	"tool_start":  `{"type":"stream_event","session_id":"sess-abc123","event":{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_03","name":"Grep","input":{}}}}`,
+	"input_delta": `{"type":"stream_event","session_id":"sess-abc123","event":{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"pat"}}}`,
+	"user":        `{"type":"user","session_id":"sess-abc123","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_01","content":"go.mod"}]}}`,
+	"result": `{"type":"result","subtype":"success","is_error":false,"duration_ms":2310,"duration_api_ms":1980,` +
+		`"num_turns":3,"result":"Let me check. Done.","session_id":"sess-abc123","total_cost_usd":0.0123,` +
+		`"usage":{"input_tokens":120,"output_tokens":45,"cache_creation_input_tokens":10,"cache_read_input_tokens":900}}`,
+}
+
+// TestParseStreamMessage tests that each fixture is parsed into its typed
+// message.
+func TestParseStreamMessage(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		wantType string
+		check    func(t *testing.T, msg stromboli.StreamMessage)
+	}{
+		{"system", stromboli.StreamMessageSystem, func(t *testing.T, msg stromboli.StreamMessage) {
+			m := msg.(*stromboli.SystemEvent)
+			assert.Equal(t, "init", m.Subtype)
+			assert.Equal(t, "sess-abc123", m.SessionID)
+			assert.Equal(t, "claude-sonnet-4-20250514", m.Model)
+			assert.Equal(t, "/workspace", m.CWD)
+			assert.Equal(t, []string{"Bash", "Read"}, m.Tools)
+		}},
+		{"assistant", stromboli.StreamMessageAssistant, func(t *testing.T, msg stromboli.StreamMessage) {
+			m := msg.(*stromboli.AssistantDelta)
+			assert.Equal(t, "msg_01", m.MessageID)
+			assert.Equal(t, "sess-abc123", m.SessionID)
+			assert.Equal(t, "Let me check. Done.", m.Text)
+			assert.False(t, m.Partial)
+			require.Len(t, m.ToolUses, 1)
+			assert.Equal(t, &stromboli.ToolUseBlock{
+				ID: "toolu_01", Name: "Bash", Input: map[string]interface{}{"command": "ls"},
+			}, m.ToolUses[0])
+		}},
+		{"tool_use", stromboli.StreamMessageToolUse, func(t *testing.T, msg stromboli.StreamMessage) {
+			m := msg.(*stromboli.ToolUseEvent)
+			assert.Equal(t, "toolu_02", m.ID)
+			assert.Equal(t, "Read", m.Name)
+			assert.Equal(t, map[string]interface{}{"file_path": "go.mod"}, m.Input)
+			assert.Equal(t, "sess-abc123", m.SessionID)
+		}},
+		{"text_delta", stromboli.StreamMessagePartial, func(t *testing.T, msg stromboli.StreamMessage) {
+			m := msg.(*stromboli.AssistantDelta)
+			assert.Equal(t, "Hel", m.Text)
+			assert.True(t, m.Partial)
+			assert.Equal(t, "sess-abc123", m.SessionID)
+		}},
+		{"tool_start", stromboli.StreamMessagePartial, func(t *testing.T, msg stromboli.StreamMessage) {
+			m := msg.(*stromboli.ToolUseEvent)
+			assert.Equal(t, "toolu_03", m.ID)
+			assert.Equal(t, "Grep", m.Name)
+			assert.True(t, m.Partial)
+		}},
+		{"input_delta", stromboli.StreamMessagePartial, func(t *testing.T, msg stromboli.StreamMessage) {
+			assert.IsType(t, &stromboli.RawStreamMessage{}, msg)
+		}},
+		{"user", "user", func(t *testing.T, msg stromboli.StreamMessage) {
+			assert.IsType(t, &stromboli.RawStreamMessage{}, msg)
+		}},
+		{"result", stromboli.StreamMessageResult, func(t *testing.T, msg stromboli.StreamMessage) {
+			m := msg.(*stromboli.ResultEvent)
+			assert.Equal(t, "success", m.Subtype)
+			assert.False(t, m.IsError)
+			assert.Equal(t, "Let me check. Done.", m.Result)
+			assert.Equal(t, "sess-abc123", m.SessionID)
+			assert.InDelta(t, 0.0123, m.CostUSD, 1e-9)
+			assert.Equal(t, int64(2310), m.DurationMS)
+			assert.Equal(t, int64(1980), m.DurationAPIMS)
+			assert.Equal(t, &stromboli.Usage{
+				InputTokens:              120,
+				OutputTokens:             45,
+				CacheCreationInputTokens: 10,
+				CacheReadInputTokens:     900,
+				TotalCostUSD:             0.0123,
+				NumTurns:                 3,
+			}, m.Usage)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			// Act
+			msg, err := stromboli.ParseStreamMessage(streamJSONFixtures[tt.fixture])
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantType, msg.MessageType())
+			tt.check(t, msg)
+		})
+	}
+}
+
+// TestParseStreamMessage_PreservesRaw tests that every message keeps the
+// JSON it was parsed from.
+func TestParseStreamMessage_PreservesRaw(t *testing.T) {
+	for name, fixture := range streamJSONFixtures {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			msg, err := stromboli.ParseStreamMessage(fixture)
+			require.NoError(t, err)
+
+			// Assert
+			var raw json.RawMessage
+			switch m := msg.(type) {
+			case *stromboli.SystemEvent:
+				raw = m.Raw
+			case *stromboli.AssistantDelta:
+				raw = m.Raw
+			case *stromboli.ToolUseEvent:
+				raw = m.Raw
+			case *stromboli.ResultEvent:
+				raw = m.Raw
+			case *stromboli.RawStreamMessage:
+				raw = m.Raw
+			default:
+				t.Fatalf("unexpected message type %T", msg)
+			}
+			assert.JSONEq(t, fixture, string(raw))
+		})
+	}
+}
+
+// TestParseStreamMessage_Invalid tests that data that isn't a JSON object,
+// or a known message with the wrong shape, is rejected.
+func TestParseStreamMessage_Invalid(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"Hello",
+		`"text"`,
+		"null",
+		`[{"type":"result"}]`,
+		`{"type":"result","total_cost_usd":"free"}`,
+		`{"type":"assistant","message":{"content":"text"}}`,
+	} {
+		t.Run(data, func(t *testing.T) {
+			// Act
+			msg, err := stromboli.ParseStreamMessage(data)
+
+			// Assert
+			assert.Nil(t, msg)
+			var apiErr *stromboli.Error
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, "INVALID_RESPONSE", apiErr.Code)
+		})
+	}
+}
+
+// TestParseStreamMessage_UnknownType tests that unknown message types are
+// preserved instead of failing.
+func TestParseStreamMessage_UnknownType(t *testing.T) {
+	// Act
+	msg, err := stromboli.ParseStreamMessage(`{"type":"compaction","summary":"..."}`)
+
+	// Assert
+	require.NoError(t, err)
+	raw, ok := msg.(*stromboli.RawStreamMessage)
+	require.True(t, ok)
+	assert.Equal(t, "compaction", raw.MessageType())
+	assert.JSONEq(t, `{"type":"compaction","summary":"..."}`, string(raw.Raw))
+}
+
+// TestStreamEvent_Decode tests decoding event data into a value.
+func TestStreamEvent_Decode(t *testing.T) {
+	// Arrange
+	var payload struct {
+		Type  string `json:"type"`
+		Count int    `json:"count"`
+	}
+
+	// Act
+	err := (&stromboli.StreamEvent{Data: `{"type":"progress","count":3}`}).Decode(&payload)
+	badErr := (&stromboli.StreamEvent{Data: "Hello"}).Decode(&payload)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "progress", payload.Type)
+	assert.Equal(t, 3, payload.Count)
+	assert.True(t, errors.Is(badErr, &stromboli.Error{Code: "INVALID_RESPONSE"}))
+}
+
+// TestStream_StreamJSON tests reading the session ID and cost of a stream
+// from its result message.
+func TestStream_StreamJSON(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, name := range []string{"system", "assistant", "user", "result"} {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", streamJSONFixtures[name])
+		}
+		_, _ = fmt.Fprint(w, "event: done\ndata: \n\n")
+	}))
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "List files"})
+	require.NoError(t, err)
+	defer func() { _ = stream.Close() }()
+
+	// Act
+	var text string
+	var result *stromboli.ResultEvent
+	for stream.Next() {
+		event := stream.Event()
+		if event.IsDone() {
+			break
+		}
+		msg, err := stromboli.ParseStreamMessage(event.Data)
+		require.NoError(t, err)
+		switch m := msg.(type) {
+		case *stromboli.AssistantDelta:
+			text += m.Text
+		case *stromboli.ResultEvent:
+			result = m
+		}
+	}
+
+	// Assert
+	require.NoError(t, stream.Err())
+	assert.Equal(t, "Let me check. Done.", text)
+	require.NotNil(t, result)
+	assert.Equal(t, "sess-abc123", result.SessionID)
+	assert.InDelta(t, 0.0123, result.CostUSD, 1e-9)
+}
+
+// openStreamJSON opens a stream on a server sending the given raw events,
+// in order.
+func openStreamJSON(t *testing.T, events ...string) *stromboli.Stream {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, event := range events {
+			_, _ = fmt.Fprint(w, event)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "List files"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = stream.Close() })
+	return stream
+}
+
+// TestStream_NextMessage tests that stream-json messages are decoded into
+// Messages, skipping keepalives, non-JSON data and the done event.
+func TestStream_NextMessage(t *testing.T) {
+	// Arrange
+	stream := openStreamJSON(t,
+		"data: "+streamJSONFixtures["system"]+"\n\n",
+		"data: \n\n",
+		"data: "+streamJSONFixtures["assistant"]+"\n\n",
+		"data: plain text\n\n",
+		`data: {"progress":50}`+"\n\n",
+		"data: "+streamJSONFixtures["user"]+"\n\n",
+		"data: "+streamJSONFixtures["result"]+"\n\n",
+		"event: done\ndata: {\"session_id\":\"sess-abc123\"}\n\n",
+	)
+
+	// Act
+	var messages []*stromboli.Message
+	for {
+		msg, ok := stream.NextMessage()
+		if !ok {
+			break
+		}
+		messages = append(messages, msg)
+	}
+
+	// Assert
+	require.NoError(t, stream.Err())
+	require.Len(t, messages, 4)
+	assert.Equal(t, "system", messages[0].Type)
+	assert.Equal(t, "sess-abc123", messages[0].SessionID)
+	assert.Equal(t, "/workspace", messages[0].Cwd)
+	assert.Nil(t, messages[0].Content)
+
+	assert.Equal(t, "assistant", messages[1].Type)
+	blocks, ok := messages[1].Content.([]interface{})
+	require.True(t, ok)
+	assert.Len(t, blocks, 4)
+
+	assert.Equal(t, "user", messages[2].Type)
+	assert.Equal(t, "result", messages[3].Type)
+	assert.Equal(t, "Let me check. Done.", messages[3].Content)
+}
+
+// TestStream_NextMessage_Errors tests that a malformed message or an error
+// event ends the stream with an error.
+func TestStream_NextMessage_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		wantCode string
+	}{
+		{name: "malformed JSON", event: "data: {\"type\":\"assistant\",\n\n", wantCode: "INVALID_RESPONSE"},
+		{name: "error event", event: "event: error\ndata: {\"message\":\"container crashed\"}\n\n", wantCode: "STREAM_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			stream := openStreamJSON(t,
+				"data: "+streamJSONFixtures["system"]+"\n\n",
+				tt.event,
+				"data: "+streamJSONFixtures["result"]+"\n\n",
+			)
+
+			// Act
+			first, firstOK := stream.NextMessage()
+			second, secondOK := stream.NextMessage()
+
+			// Assert
+			require.True(t, firstOK)
+			assert.Equal(t, "system", first.Type)
+			assert.False(t, secondOK)
+			assert.Nil(t, second)
+			var apiErr *stromboli.Error
+			require.True(t, errors.As(stream.Err(), &apiErr), "got %v", stream.Err())
+			assert.Equal(t, tt.wantCode, apiErr.Code)
+			_, ok := stream.NextMessage()
+			assert.False(t, ok, "the stream stays failed")
+		})
+	}
+}
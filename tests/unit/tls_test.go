@@ -0,0 +1,261 @@
+package unit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// newTLSHealthServer starts a TLS server answering health checks, whose
+// certificate is signed by its own CA, and returns it with a TLS config
+// trusting that CA. The User-Agent of the last request is stored in
+// userAgent.
+func newTLSHealthServer(t *testing.T, userAgent *string) (*httptest.Server, *tls.Config) {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*userAgent = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok", "version": "0.3.0"})
+	}))
+	t.Cleanup(server.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	return server, &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+}
+
+// TestWithTLSConfig tests that the TLS config is used to connect, with the
+// SDK's transport features kept.
+func TestWithTLSConfig(t *testing.T) {
+	// Arrange
+	var userAgent string
+	server, config := newTLSHealthServer(t, &userAgent)
+	var hooked bool
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithTLSConfig(config),
+		stromboli.WithUserAgent("my-app/1.0"),
+		stromboli.WithRequestHook(func(*http.Request) { hooked = true }),
+	)
+	require.NoError(t, err)
+
+	// Act
+	health, err := client.Health(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "ok", health.Status)
+	assert.Equal(t, "my-app/1.0", userAgent)
+	assert.True(t, hooked)
+}
+
+// TestWithTLSConfig_LastOptionWins tests how WithTLSConfig composes with
+// WithHTTPClient.
+func TestWithTLSConfig_LastOptionWins(t *testing.T) {
+	var userAgent string
+	server, config := newTLSHealthServer(t, &userAgent)
+
+	tests := []struct {
+		name    string
+		opts    []stromboli.Option
+		wantErr bool
+	}{
+		{
+			name: "TLS config after HTTP client",
+			opts: []stromboli.Option{stromboli.WithHTTPClient(&http.Client{}), stromboli.WithTLSConfig(config)},
+		},
+		{
+			name:    "HTTP client after TLS config",
+			opts:    []stromboli.Option{stromboli.WithTLSConfig(config), stromboli.WithHTTPClient(&http.Client{})},
+			wantErr: true,
+		},
+		{
+			name:    "nil TLS config",
+			opts:    []stromboli.Option{stromboli.WithTLSConfig(config), stromboli.WithTLSConfig(nil)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			client, err := stromboli.NewClient(server.URL, tt.opts...)
+			require.NoError(t, err)
+
+			// Act
+			_, err = client.Health(context.Background())
+
+			// Assert
+			if tt.wantErr {
+				assert.Error(t, err, "the server's certificate is not trusted")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// writePEM writes a PEM block of the given type to name in dir and returns
+// its path.
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+// newMTLSServer starts a TLS server answering health checks and streams
+// that requires a client certificate, and writes a self-signed client
+// certificate it accepts, its key, and the server's CA to a temporary
+// directory.
+func newMTLSServer(t *testing.T) (server *httptest.Server, certFile, keyFile, caFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "stromboli-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDER)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	server = httptest.NewUnstartedServer(healthAndStreamHandler())
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	certFile = writePEM(t, dir, "client.crt", "CERTIFICATE", certDER)
+	keyFile = writePEM(t, dir, "client.key", "EC PRIVATE KEY", keyDER)
+	caFile = writePEM(t, dir, "ca.crt", "CERTIFICATE", server.Certificate().Raw)
+	return server, certFile, keyFile, caFile
+}
+
+// TestWithClientCertificate tests that requests and streams present the
+// client certificate and trust the given CA.
+func TestWithClientCertificate(t *testing.T) {
+	// Arrange
+	server, certFile, keyFile, caFile := newMTLSServer(t)
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClientCertificate(certFile, keyFile, caFile))
+	require.NoError(t, err)
+
+	// Act
+	health, healthErr := client.Health(context.Background())
+	stream, streamErr := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Hi"})
+	require.NoError(t, streamErr)
+	defer stream.Close()
+	var events []string
+	for stream.Next() {
+		events = append(events, stream.Event().Data)
+	}
+
+	// Assert
+	require.NoError(t, healthErr)
+	assert.Equal(t, "ok", health.Status)
+	require.NoError(t, stream.Err())
+	assert.Equal(t, []string{"Hello"}, events)
+}
+
+// TestWithClientCertificate_NoCertificate tests that the server rejects a
+// client without the certificate.
+func TestWithClientCertificate_NoCertificate(t *testing.T) {
+	// Arrange
+	server, _, _, _ := newMTLSServer(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	client, err := stromboli.NewClient(server.URL,
+		stromboli.WithTLSConfig(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}))
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.Health(context.Background())
+
+	// Assert
+	assert.Error(t, err)
+}
+
+// TestWithClientCertificate_InvalidFiles tests that NewClient fails with
+// an error naming the file that couldn't be loaded.
+func TestWithClientCertificate_InvalidFiles(t *testing.T) {
+	server, certFile, keyFile, _ := newMTLSServer(t)
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.pem")
+	notPEM := filepath.Join(dir, "not.pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name    string
+		cert    string
+		key     string
+		ca      string
+		wantErr string
+	}{
+		{"missing certificate", missing, keyFile, "", missing},
+		{"missing key", certFile, missing, "", missing},
+		{"invalid key", certFile, notPEM, "", notPEM},
+		{"missing CA", certFile, keyFile, missing, missing},
+		{"invalid CA", certFile, keyFile, notPEM, notPEM},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			client, err := stromboli.NewClient(server.URL, stromboli.WithClientCertificate(tt.cert, tt.key, tt.ca))
+
+			// Assert
+			assert.Nil(t, client)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// TestWithClientCertificate_Clone tests that Clone ignores a client
+// certificate that can't be loaded, with a warning.
+func TestWithClientCertificate_Clone(t *testing.T) {
+	// Arrange
+	server, certFile, keyFile, caFile := newMTLSServer(t)
+	client, err := stromboli.NewClient(server.URL, stromboli.WithClientCertificate(certFile, keyFile, caFile))
+	require.NoError(t, err)
+	logger := &captureLogger{}
+	stromboli.SetLogger(logger)
+	defer stromboli.SetLogger(nil)
+
+	// Act
+	clone := client.Clone(stromboli.WithClientCertificate(certFile, keyFile, filepath.Join(t.TempDir(), "missing.pem")))
+	_, err = clone.Health(context.Background())
+
+	// Assert
+	require.NoError(t, err, "the clone keeps the original certificate")
+	require.Len(t, logger.Messages(), 1)
+	assert.Contains(t, logger.Messages()[0], "Clone option ignored")
+}
@@ -0,0 +1,221 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/tomblancdev/stromboli-go"
+	"github.com/tomblancdev/stromboli-go/stromboliotel"
+)
+
+// recordedSpan is a span started by a recordingTracerProvider.
+type recordedSpan struct {
+	noop.Span
+	mu         sync.Mutex
+	name       string
+	parent     *recordedSpan
+	attributes map[attribute.Key]attribute.Value
+	status     codes.Code
+	errs       []error
+	ends       int
+}
+
+// SetAttributes implements trace.Span.
+func (s *recordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, attr := range kv {
+		s.attributes[attr.Key] = attr.Value
+	}
+}
+
+// RecordError implements trace.Span.
+func (s *recordedSpan) RecordError(err error, _ ...trace.EventOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs = append(s.errs, err)
+}
+
+// SetStatus implements trace.Span.
+func (s *recordedSpan) SetStatus(code codes.Code, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = code
+}
+
+// End implements trace.Span.
+func (s *recordedSpan) End(...trace.SpanEndOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ends++
+}
+
+// attribute returns the value of the attribute key as a string.
+func (s *recordedSpan) attribute(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attributes[attribute.Key(key)].Emit()
+}
+
+// recordingTracerProvider is an OpenTelemetry tracer provider recording
+// the spans it starts.
+type recordingTracerProvider struct {
+	noop.TracerProvider
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+// Tracer implements trace.TracerProvider.
+func (p *recordingTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{provider: p}
+}
+
+// span returns the first span named name, failing the test if none was
+// started.
+func (p *recordingTracerProvider) span(t *testing.T, name string) *recordedSpan {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range p.spans {
+		if s.name == name {
+			return s
+		}
+	}
+	require.Failf(t, "span not started", "no span named %q", name)
+	return nil
+}
+
+// recordingTracer starts spans for a recordingTracerProvider.
+type recordingTracer struct {
+	noop.Tracer
+	provider *recordingTracerProvider
+}
+
+// Start implements trace.Tracer.
+func (tr recordingTracer) Start(ctx context.Context, name string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	parent, _ := trace.SpanFromContext(ctx).(*recordedSpan)
+	s := &recordedSpan{name: name, parent: parent, attributes: make(map[attribute.Key]attribute.Value)}
+	tr.provider.mu.Lock()
+	tr.provider.spans = append(tr.provider.spans, s)
+	tr.provider.mu.Unlock()
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+// TestWithTracerProvider tests that operations get a span recording their
+// status, the IDs they are about and their error.
+func TestWithTracerProvider(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/run":
+			mustEncode(w, map[string]interface{}{"id": "run-1", "status": "completed", "session_id": "sess-1"})
+		case "/run/async":
+			w.WriteHeader(http.StatusAccepted)
+			mustEncode(w, map[string]string{"job_id": "job-1"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			mustEncode(w, map[string]string{"error": "not found"})
+		}
+	}))
+	defer server.Close()
+
+	tp := &recordingTracerProvider{}
+	client, err := stromboli.NewClient(server.URL, stromboliotel.WithTracerProvider(tp))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, runErr := client.Run(ctx, &stromboli.RunRequest{Prompt: "Hello"})
+	_, asyncErr := client.RunAsync(ctx, &stromboli.RunRequest{Prompt: "Hello"})
+	_, jobErr := client.GetJob(ctx, "job-missing")
+
+	// Assert
+	require.NoError(t, runErr)
+	require.NoError(t, asyncErr)
+	assert.True(t, errors.Is(jobErr, stromboli.ErrNotFound))
+
+	run := tp.span(t, "stromboli.Run")
+	assert.Equal(t, "200", run.attribute("http.response.status_code"))
+	assert.Equal(t, "sess-1", run.attribute(stromboli.AttributeSessionID))
+	assert.Equal(t, codes.Unset, run.status)
+	assert.Equal(t, 1, run.ends)
+
+	assert.Equal(t, "job-1", tp.span(t, "stromboli.RunAsync").attribute(stromboli.AttributeJobID))
+
+	getJob := tp.span(t, "stromboli.GetJob")
+	assert.Equal(t, "404", getJob.attribute("http.response.status_code"))
+	assert.Equal(t, "job-missing", getJob.attribute(stromboli.AttributeJobID))
+	assert.Equal(t, codes.Error, getJob.status)
+	assert.Equal(t, []error{jobErr}, getJob.errs)
+	assert.Equal(t, 1, getJob.ends)
+}
+
+// TestWithTracerProvider_Stream tests that a stream's span covers opening
+// it, and that a child span covers its event loop until it ends.
+func TestWithTracerProvider_Stream(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("X-Session-ID", "sess-1")
+		_, _ = w.Write([]byte("data: Hello\n\nevent: done\ndata:\n\n"))
+	}))
+	defer server.Close()
+
+	tp := &recordingTracerProvider{}
+	client, err := stromboli.NewClient(server.URL, stromboliotel.WithTracerProvider(tp))
+	require.NoError(t, err)
+
+	// Act
+	stream, err := client.Stream(context.Background(), &stromboli.StreamRequest{Prompt: "Hello"})
+	require.NoError(t, err)
+	open := tp.span(t, "stromboli.Stream")
+	events := tp.span(t, "stromboli.Stream.events")
+	endsWhileOpen := events.ends
+	for stream.Next() {
+	}
+	require.NoError(t, stream.Close())
+
+	// Assert
+	require.NoError(t, stream.Err())
+	assert.Equal(t, 1, open.ends)
+	assert.Equal(t, "200", open.attribute("http.response.status_code"))
+	assert.Equal(t, "sess-1", open.attribute(stromboli.AttributeSessionID))
+	assert.Same(t, open, events.parent)
+	assert.Equal(t, 0, endsWhileOpen)
+	assert.Equal(t, 1, events.ends, "ended once, by the end of the stream")
+	assert.Equal(t, codes.Unset, events.status)
+}
+
+// TestWithTracer_Clone tests that clones keep the tracer, and that it can
+// be removed.
+func TestWithTracer_Clone(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustEncode(w, map[string]interface{}{"status": "ok", "version": "0.4.0"})
+	}))
+	defer server.Close()
+
+	tp := &recordingTracerProvider{}
+	client, err := stromboli.NewClient(server.URL, stromboliotel.WithTracerProvider(tp))
+	require.NoError(t, err)
+
+	// Act
+	require.NoError(t, client.Clone().Ping(context.Background()))
+	require.NoError(t, client.Clone(stromboli.WithTracer(nil)).Ping(context.Background()))
+
+	// Assert
+	assert.Len(t, tp.spans, 1)
+}
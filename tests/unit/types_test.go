@@ -0,0 +1,489 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// TestMessage_TypedContent tests parsing of mixed content blocks as they
+// are decoded from the API.
+func TestMessage_TypedContent(t *testing.T) {
+	// Arrange
+	var msg stromboli.Message
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "assistant",
+		"content": [
+			{"type": "text", "text": "Listing files"},
+			{"type": "tool_use", "id": "toolu_1", "name": "Bash", "input": {"command": "ls"}},
+			{"type": "tool_result", "tool_use_id": "toolu_1", "content": "main.go", "is_error": true}
+		]
+	}`), &msg))
+
+	// Act
+	blocks, err := msg.TypedContent()
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, blocks, 3)
+
+	text, ok := blocks[0].(*stromboli.TextBlock)
+	require.True(t, ok)
+	assert.Equal(t, stromboli.BlockTypeText, text.BlockType())
+	assert.Equal(t, "Listing files", text.Text)
+
+	toolUse, ok := blocks[1].(*stromboli.ToolUseBlock)
+	require.True(t, ok)
+	assert.Equal(t, stromboli.BlockTypeToolUse, toolUse.BlockType())
+	assert.Equal(t, "toolu_1", toolUse.ID)
+	assert.Equal(t, "Bash", toolUse.Name)
+	assert.Equal(t, "ls", toolUse.Input["command"])
+
+	result, ok := blocks[2].(*stromboli.ToolResultBlock)
+	require.True(t, ok)
+	assert.Equal(t, stromboli.BlockTypeToolResult, result.BlockType())
+	assert.Equal(t, "toolu_1", result.ToolUseID)
+	assert.Equal(t, "main.go", result.Content)
+	assert.True(t, result.IsError)
+}
+
+// TestMessage_TypedContent_String tests that string content becomes a
+// single text block.
+func TestMessage_TypedContent_String(t *testing.T) {
+	msg := &stromboli.Message{Content: "hello"}
+
+	blocks, err := msg.TypedContent()
+
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	assert.Equal(t, &stromboli.TextBlock{Text: "hello"}, blocks[0])
+}
+
+// TestMessage_TypedContent_Nil tests that nil content returns no blocks.
+func TestMessage_TypedContent_Nil(t *testing.T) {
+	msg := &stromboli.Message{}
+
+	blocks, err := msg.TypedContent()
+
+	require.NoError(t, err)
+	assert.Nil(t, blocks)
+}
+
+// TestMessage_TypedContent_Unrecognized tests that unknown structures are
+// reported instead of dropped.
+func TestMessage_TypedContent_Unrecognized(t *testing.T) {
+	tests := []struct {
+		name    string
+		content interface{}
+	}{
+		{
+			name:    "unknown block type",
+			content: []interface{}{map[string]interface{}{"type": "thinking", "thinking": "..."}},
+		},
+		{
+			name:    "missing block type",
+			content: []interface{}{map[string]interface{}{"text": "hello"}},
+		},
+		{
+			name:    "non-object block",
+			content: []interface{}{"hello"},
+		},
+		{
+			name:    "non-list content",
+			content: map[string]interface{}{"type": "text"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &stromboli.Message{Content: tt.content}
+
+			blocks, err := msg.TypedContent()
+
+			require.Error(t, err)
+			assert.Nil(t, blocks)
+			var apiErr *stromboli.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, "INVALID_CONTENT", apiErr.Code)
+		})
+	}
+}
+
+// TestModel_IsKnown tests recognition of the Model constants.
+func TestModel_IsKnown(t *testing.T) {
+	assert.True(t, stromboli.ModelHaiku.IsKnown())
+	assert.True(t, stromboli.ModelSonnet.IsKnown())
+	assert.True(t, stromboli.ModelOpus.IsKnown())
+	assert.False(t, stromboli.Model("sonet").IsKnown())
+	assert.False(t, stromboli.Model("Sonnet").IsKnown())
+	assert.False(t, stromboli.Model("").IsKnown())
+}
+
+// TestPermissionMode_IsValid tests that only the PermissionMode constants
+// are valid.
+func TestPermissionMode_IsValid(t *testing.T) {
+	assert.True(t, stromboli.PermissionModeDefault.IsValid())
+	assert.True(t, stromboli.PermissionModeAcceptEdits.IsValid())
+	assert.True(t, stromboli.PermissionModeBypassPermissions.IsValid())
+	assert.True(t, stromboli.PermissionModePlan.IsValid())
+	assert.True(t, stromboli.PermissionModeDontAsk.IsValid())
+	assert.False(t, stromboli.PermissionMode("acceptedits").IsValid())
+	assert.False(t, stromboli.PermissionMode("").IsValid())
+}
+
+// TestJob_State tests the typed job state and its terminal states.
+func TestJob_State(t *testing.T) {
+	tests := []struct {
+		status   string
+		want     stromboli.JobState
+		terminal bool
+		known    bool
+	}{
+		{"pending", stromboli.JobStatePending, false, true},
+		{"running", stromboli.JobStateRunning, false, true},
+		{"completed", stromboli.JobStateCompleted, true, true},
+		{"failed", stromboli.JobStateFailed, true, true},
+		{"cancelled", stromboli.JobStateCancelled, true, true},
+		{"paused", stromboli.JobState("paused"), false, false},
+		{"", stromboli.JobState(""), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			// Arrange
+			job := &stromboli.Job{Status: tt.status}
+
+			// Act
+			state := job.State()
+
+			// Assert
+			assert.Equal(t, tt.want, state)
+			assert.Equal(t, tt.terminal, state.IsTerminal())
+			assert.Equal(t, tt.terminal, job.IsTerminal())
+			assert.Equal(t, tt.known, state.IsKnown())
+		})
+	}
+}
+
+// TestJob_ParseTimestamps tests that timestamps with and without fractional
+// seconds parse, and that missing or invalid ones are errors.
+func TestJob_ParseTimestamps(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"RFC3339", "2024-01-15T10:30:00Z", time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), false},
+		{"RFC3339Nano", "2024-01-15T10:30:00.123456789Z", time.Date(2024, 1, 15, 10, 30, 0, 123456789, time.UTC), false},
+		{"offset", "2024-01-15T11:30:00+01:00", time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), false},
+		{"no timezone", "2024-01-15T10:30:00", time.Time{}, true},
+		{"empty", "", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			job := &stromboli.Job{CreatedAt: tt.value, UpdatedAt: tt.value}
+
+			// Act
+			created, createdErr := job.ParseCreatedAt()
+			updated, updatedErr := job.ParseUpdatedAt()
+
+			// Assert
+			if tt.wantErr {
+				assert.Contains(t, createdErr.Error(), "INVALID_RESPONSE")
+				assert.Contains(t, updatedErr.Error(), "updated_at")
+				assert.True(t, created.IsZero())
+				return
+			}
+			require.NoError(t, createdErr)
+			require.NoError(t, updatedErr)
+			assert.True(t, tt.want.Equal(created))
+			assert.True(t, tt.want.Equal(updated))
+		})
+	}
+}
+
+// TestJob_Duration tests that the duration is only reported for terminal
+// jobs with valid timestamps.
+func TestJob_Duration(t *testing.T) {
+	tests := []struct {
+		name   string
+		job    stromboli.Job
+		want   time.Duration
+		wantOK bool
+	}{
+		{"completed", stromboli.Job{Status: "completed", CreatedAt: "2024-01-15T10:30:00Z", UpdatedAt: "2024-01-15T10:31:30.5Z"}, 90500 * time.Millisecond, true},
+		{"failed", stromboli.Job{Status: "failed", CreatedAt: "2024-01-15T10:30:00Z", UpdatedAt: "2024-01-15T10:30:05Z"}, 5 * time.Second, true},
+		{"running", stromboli.Job{Status: "running", CreatedAt: "2024-01-15T10:30:00Z", UpdatedAt: "2024-01-15T10:31:00Z"}, 0, false},
+		{"missing update", stromboli.Job{Status: "completed", CreatedAt: "2024-01-15T10:30:00Z"}, 0, false},
+		{"invalid", stromboli.Job{Status: "completed", CreatedAt: "yesterday", UpdatedAt: "2024-01-15T10:31:00Z"}, 0, false},
+		{"clock skew", stromboli.Job{Status: "completed", CreatedAt: "2024-01-15T10:31:00Z", UpdatedAt: "2024-01-15T10:30:00Z"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			d, ok := tt.job.Duration()
+
+			// Assert
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, d)
+		})
+	}
+}
+
+// TestRunResponse_Err tests IsError and Err for each run status.
+func TestRunResponse_Err(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    *stromboli.RunResponse
+		isError bool
+		wantMsg string
+	}{
+		{name: "completed", resp: &stromboli.RunResponse{Status: "completed", Output: "Hi"}},
+		{name: "error", resp: &stromboli.RunResponse{Status: "error", Error: "budget exceeded"}, isError: true, wantMsg: "budget exceeded"},
+		{name: "error without message", resp: &stromboli.RunResponse{Status: "error"}, isError: true, wantMsg: "execution failed"},
+		{name: "dry run", resp: &stromboli.RunResponse{Status: "dry_run"}},
+		{name: "unknown", resp: &stromboli.RunResponse{Status: "unknown", RawStatus: "success"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			isError := tt.resp.IsError()
+			err := tt.resp.Err()
+
+			// Assert
+			assert.Equal(t, tt.isError, isError)
+			if !tt.isError {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, stromboli.ErrExecutionFailed))
+			var apiErr *stromboli.Error
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tt.wantMsg, apiErr.Message)
+			assert.Zero(t, apiErr.Status)
+		})
+	}
+}
+
+// TestRunResponse_AsJob tests the mapping of run responses to jobs.
+func TestRunResponse_AsJob(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.FixedZone("CET", 3600))
+	usage := &stromboli.Usage{InputTokens: 10, OutputTokens: 20, TotalCostUSD: 0.01}
+
+	tests := []struct {
+		name string
+		resp *stromboli.RunResponse
+		want *stromboli.Job
+	}{
+		{
+			name: "completed",
+			resp: &stromboli.RunResponse{
+				ID: "run-1", Status: "completed", Output: "Hi", SessionID: "sess-1", Usage: usage,
+				ModelUsed: stromboli.ModelSonnet, StartedAt: start, CompletedAt: start.Add(1500 * time.Millisecond),
+			},
+			want: &stromboli.Job{
+				ID: "run-1", Status: "completed", Output: "Hi", SessionID: "sess-1", Usage: usage,
+				CreatedAt: "2024-01-15T09:30:00Z", UpdatedAt: "2024-01-15T09:30:01.5Z",
+			},
+		},
+		{
+			name: "error",
+			resp: &stromboli.RunResponse{ID: "run-2", Status: "error", Error: "budget exceeded"},
+			want: &stromboli.Job{ID: "run-2", Status: "failed", Error: "budget exceeded"},
+		},
+		{
+			name: "unknown",
+			resp: &stromboli.RunResponse{ID: "run-3", Status: "unknown", RawStatus: "success"},
+			want: &stromboli.Job{ID: "run-3", Status: "unknown", RawStatus: "success"},
+		},
+		{
+			name: "dry run",
+			resp: &stromboli.RunResponse{Status: "dry_run", Resolved: &stromboli.ResolvedRequest{}},
+			want: &stromboli.Job{Status: "unknown", RawStatus: "dry_run"},
+		},
+		{name: "nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			job := tt.resp.AsJob()
+
+			// Assert
+			assert.Equal(t, tt.want, job)
+			if job != nil && job.Usage != nil {
+				assert.NotSame(t, tt.resp.Usage, job.Usage)
+			}
+		})
+	}
+}
+
+// TestJob_AsRunResponse tests the mapping of finished jobs to run
+// responses, and that unfinished jobs aren't mapped.
+func TestJob_AsRunResponse(t *testing.T) {
+	usage := &stromboli.Usage{InputTokens: 10}
+
+	tests := []struct {
+		name   string
+		job    *stromboli.Job
+		want   *stromboli.RunResponse
+		wantOK bool
+	}{
+		{
+			name: "completed",
+			job: &stromboli.Job{
+				ID: "job-1", Status: "completed", Output: "Hi", SessionID: "sess-1", Usage: usage,
+				CreatedAt: "2024-01-15T10:30:00Z", UpdatedAt: "2024-01-15T10:31:00Z",
+			},
+			want: &stromboli.RunResponse{
+				ID: "job-1", Status: "completed", Output: "Hi", SessionID: "sess-1", Usage: usage,
+				StartedAt:   time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+				CompletedAt: time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC),
+			},
+			wantOK: true,
+		},
+		{
+			name:   "failed with invalid timestamps",
+			job:    &stromboli.Job{ID: "job-2", Status: "failed", Error: "crashed", CreatedAt: "yesterday"},
+			want:   &stromboli.RunResponse{ID: "job-2", Status: "error", Error: "crashed"},
+			wantOK: true,
+		},
+		{name: "running", job: &stromboli.Job{ID: "job-3", Status: "running"}},
+		{name: "cancelled", job: &stromboli.Job{ID: "job-4", Status: "cancelled"}},
+		{name: "nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			resp, ok := tt.job.AsRunResponse()
+
+			// Assert
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, resp)
+		})
+	}
+}
+
+// TestRunResponse_AsJob_RoundTrip tests that converting a response to a
+// job and back keeps its fields.
+func TestRunResponse_AsJob_RoundTrip(t *testing.T) {
+	// Arrange
+	start := time.Date(2024, 1, 15, 10, 30, 0, 123456789, time.UTC)
+	resp := &stromboli.RunResponse{
+		ID: "run-1", Status: "completed", Output: "Hi", SessionID: "sess-1",
+		Usage:     &stromboli.Usage{OutputTokens: 5},
+		StartedAt: start, CompletedAt: start.Add(time.Minute),
+	}
+
+	// Act
+	back, ok := resp.AsJob().AsRunResponse()
+
+	// Assert
+	require.True(t, ok)
+	assert.Equal(t, resp, back)
+}
+
+// TestCrashInfo_Classification tests the OOM, killed and timeout helpers.
+func TestCrashInfo_Classification(t *testing.T) {
+	tests := []struct {
+		name    string
+		crash   *stromboli.CrashInfo
+		oom     bool
+		killed  bool
+		timeout bool
+	}{
+		{"nil", nil, false, false, false},
+		{"exit 137", &stromboli.CrashInfo{ExitCode: 137}, true, true, false},
+		{"OOM reason", &stromboli.CrashInfo{Reason: "Container OOM killed", ExitCode: 1}, true, false, false},
+		{"OOMKilled reason", &stromboli.CrashInfo{Reason: "state: OOMKilled"}, true, false, false},
+		{"out of memory", &stromboli.CrashInfo{Reason: "Out of memory"}, true, false, false},
+		{"room is not OOM", &stromboli.CrashInfo{Reason: "no room left on device"}, false, false, false},
+		{"SIGTERM", &stromboli.CrashInfo{Signal: "SIGTERM"}, false, true, false},
+		{"TERM without prefix", &stromboli.CrashInfo{Signal: "term"}, false, true, false},
+		{"exit 143", &stromboli.CrashInfo{ExitCode: 143}, false, true, false},
+		{"SIGKILL", &stromboli.CrashInfo{Signal: "SIGKILL"}, false, true, false},
+		{"SIGSEGV", &stromboli.CrashInfo{Signal: "SIGSEGV", ExitCode: 139}, false, false, false},
+		{"timeout", &stromboli.CrashInfo{Reason: "Timeout exceeded", ExitCode: 143}, false, true, true},
+		{"timed out", &stromboli.CrashInfo{Reason: "container timed out"}, false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act & Assert
+			assert.Equal(t, tt.oom, tt.crash.IsOOM())
+			assert.Equal(t, tt.killed, tt.crash.WasKilled())
+			assert.Equal(t, tt.timeout, tt.crash.IsTimeout())
+		})
+	}
+}
+
+// TestParseJobState tests that only known job statuses parse.
+func TestParseJobState(t *testing.T) {
+	tests := []struct {
+		status  string
+		want    stromboli.JobState
+		wantErr bool
+	}{
+		{"completed", stromboli.JobStateCompleted, false},
+		{"pending", stromboli.JobStatePending, false},
+		{"complete", stromboli.JobStateUnknown, true},
+		{"unknown", stromboli.JobStateUnknown, true},
+		{"", stromboli.JobStateUnknown, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			// Act
+			state, err := stromboli.ParseJobState(tt.status)
+
+			// Assert
+			assert.Equal(t, tt.want, state)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestParseRunState tests that only known run statuses parse.
+func TestParseRunState(t *testing.T) {
+	tests := []struct {
+		status  string
+		want    stromboli.RunState
+		wantErr bool
+	}{
+		{"completed", stromboli.RunStateCompleted, false},
+		{"error", stromboli.RunStateError, false},
+		{"dry_run", stromboli.RunStateDryRun, false},
+		{"success", stromboli.RunStateUnknown, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			// Act
+			state, err := stromboli.ParseRunState(tt.status)
+
+			// Assert
+			assert.Equal(t, tt.want, state)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package unit
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -205,3 +210,131 @@ func TestCompatibilityResult_Fields(t *testing.T) {
 	assert.Equal(t, stromboli.APIVersionRange, result.SupportedRange)
 	assert.NotEmpty(t, result.Message)
 }
+
+// compatServer returns a server that reports the given version from
+// /health, counting health and session requests.
+func compatServer(version *atomic.Value, healthCalls, sessionCalls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/health" {
+			healthCalls.Add(1)
+			v := version.Load().(string)
+			if v == "down" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				mustEncode(w, map[string]string{"error": "starting"})
+				return
+			}
+			mustEncode(w, map[string]interface{}{"name": "stromboli", "status": "ok", "version": v})
+			return
+		}
+		sessionCalls.Add(1)
+		mustEncode(w, map[string]interface{}{"id": "sess-1"})
+	}))
+}
+
+// TestWithCompatibilityCheck_Fail tests that calls to an incompatible
+// server fail without a request, that the result is cached, and that a
+// Health call reporting a compatible version lifts the refusal.
+func TestWithCompatibilityCheck_Fail(t *testing.T) {
+	// Arrange
+	var version atomic.Value
+	version.Store("9.0.0")
+	var healthCalls, sessionCalls atomic.Int32
+	server := compatServer(&version, &healthCalls, &sessionCalls)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithCompatibilityCheck(stromboli.CompatFail))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, firstErr := client.GetSession(ctx, "sess-1")
+	_, secondErr := client.GetSession(ctx, "sess-1")
+	refusedCalls := sessionCalls.Load()
+	_, healthErr := client.Health(ctx)
+	version.Store(stromboli.APIVersion)
+	_, _ = client.Health(ctx)
+	_, upgradedErr := client.GetSession(ctx, "sess-1")
+
+	// Assert
+	for _, err := range []error{firstErr, secondErr} {
+		assert.True(t, errors.Is(err, stromboli.ErrIncompatibleServer), "got %v", err)
+		assert.Contains(t, err.Error(), "9.0.0")
+	}
+	assert.Zero(t, refusedCalls)
+	require.NoError(t, healthErr, "Health is never refused")
+	require.NoError(t, upgradedErr)
+	assert.Equal(t, int32(3), healthCalls.Load())
+}
+
+// TestWithCompatibilityCheck_Warn tests that an incompatible server is
+// logged once and that calls go ahead.
+func TestWithCompatibilityCheck_Warn(t *testing.T) {
+	// Arrange
+	logger := &captureLogger{}
+	stromboli.SetLogger(logger)
+	defer stromboli.SetLogger(nil)
+
+	var version atomic.Value
+	version.Store("9.0.0")
+	var healthCalls, sessionCalls atomic.Int32
+	server := compatServer(&version, &healthCalls, &sessionCalls)
+	defer server.Close()
+
+	client, err := stromboli.NewClient(server.URL, stromboli.WithCompatibilityCheck(stromboli.CompatWarn))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, firstErr := client.GetSession(ctx, "sess-1")
+	_, secondErr := client.GetSession(ctx, "sess-1")
+
+	// Assert
+	require.NoError(t, firstErr)
+	require.NoError(t, secondErr)
+	assert.Equal(t, int32(1), healthCalls.Load())
+	assert.Equal(t, int32(2), sessionCalls.Load())
+	messages := logger.Messages()
+	require.Len(t, messages, 1)
+	assert.Contains(t, messages[0], "server version 9.0.0 is not compatible")
+}
+
+// TestWithCompatibilityCheck_Lookup tests that the check is off by
+// default, and that a failed version lookup lets the call go ahead and is
+// retried on the next call.
+func TestWithCompatibilityCheck_Lookup(t *testing.T) {
+	// Arrange
+	var version atomic.Value
+	version.Store("down")
+	var healthCalls, sessionCalls atomic.Int32
+	server := compatServer(&version, &healthCalls, &sessionCalls)
+	defer server.Close()
+
+	plain, err := stromboli.NewClient(server.URL)
+	require.NoError(t, err)
+	client, err := stromboli.NewClient(server.URL, stromboli.WithCompatibilityCheck(stromboli.CompatFail))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	_, plainErr := plain.GetSession(ctx, "sess-1")
+	plainHealthCalls := healthCalls.Load()
+	_, downErr := client.GetSession(ctx, "sess-1")
+	version.Store("10.0.0")
+	_, upErr := client.GetSession(ctx, "sess-1")
+
+	// Assert
+	require.NoError(t, plainErr)
+	assert.Zero(t, plainHealthCalls)
+	require.NoError(t, downErr)
+	assert.True(t, errors.Is(upErr, stromboli.ErrIncompatibleServer), "got %v", upErr)
+	assert.Equal(t, int32(2), healthCalls.Load())
+}
+
+// TestCompatMode_String tests the string form of compatibility modes.
+func TestCompatMode_String(t *testing.T) {
+	assert.Equal(t, "off", stromboli.CompatOff.String())
+	assert.Equal(t, "warn", stromboli.CompatWarn.String())
+	assert.Equal(t, "fail", stromboli.CompatFail.String())
+	assert.Equal(t, "unknown", stromboli.CompatMode(42).String())
+}
@@ -0,0 +1,168 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// TestVolumeMount_String tests formatting volumes in Podman format.
+func TestVolumeMount_String(t *testing.T) {
+	tests := []struct {
+		name   string
+		volume stromboli.VolumeMount
+		want   string
+	}{
+		{"read-write", stromboli.VolumeMount{Host: "/data", Container: "/data"}, "/data:/data"},
+		{"read-only", stromboli.VolumeMount{Host: "/home/user/project", Container: "/workspace", ReadOnly: true}, "/home/user/project:/workspace:ro"},
+		{"options", stromboli.VolumeMount{Host: "/a", Container: "/b", Options: []string{"z", "noexec"}}, "/a:/b:z,noexec"},
+		{"read-only with options", stromboli.VolumeMount{Host: "/a", Container: "/b", ReadOnly: true, Options: []string{"z"}}, "/a:/b:ro,z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.volume.String())
+		})
+	}
+}
+
+// TestParseVolume tests parsing volumes and round-tripping them.
+func TestParseVolume(t *testing.T) {
+	tests := []struct {
+		input string
+		want  stromboli.VolumeMount
+	}{
+		{"/data:/data", stromboli.VolumeMount{Host: "/data", Container: "/data"}},
+		{"/code:/workspace:ro", stromboli.VolumeMount{Host: "/code", Container: "/workspace", ReadOnly: true}},
+		{"/a:/b:ro,z", stromboli.VolumeMount{Host: "/a", Container: "/b", ReadOnly: true, Options: []string{"z"}}},
+		{"/a:/b:rw", stromboli.VolumeMount{Host: "/a", Container: "/b", Options: []string{"rw"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := stromboli.ParseVolume(tt.input)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.input, got.String())
+		})
+	}
+}
+
+// TestParseVolume_Invalid tests that malformed volumes are rejected.
+func TestParseVolume_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"relative host path", "data:/data"},
+		{"relative container path", "/data:data"},
+		{"empty container path", "/data:"},
+		{"missing container path", "/data"},
+		{"windows path", `C:\Users\me:/workspace`},
+		{"empty options", "/a:/b:"},
+		{"empty option", "/a:/b:ro,"},
+		{"too many parts", "/a:/b:ro:z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := stromboli.ParseVolume(tt.input)
+
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+		})
+	}
+}
+
+// TestVolumeMount_Validate tests validation of typed volumes.
+func TestVolumeMount_Validate(t *testing.T) {
+	assert.NoError(t, stromboli.VolumeMount{Host: "/a", Container: "/b"}.Validate())
+
+	invalid := []stromboli.VolumeMount{
+		{Host: "relative", Container: "/b"},
+		{Host: "/a", Container: ""},
+		{Host: "/a:b", Container: "/b"},
+		{Host: "/a", Container: "/b:c"},
+		{Host: "/a", Container: "/b", Options: []string{"ro,z"}},
+	}
+	for _, v := range invalid {
+		err := v.Validate()
+		assert.True(t, errors.Is(err, stromboli.ErrBadRequest), v.String())
+	}
+}
+
+// TestPodmanOptions_AddVolume tests appending typed volumes.
+func TestPodmanOptions_AddVolume(t *testing.T) {
+	// Arrange
+	podman := &stromboli.PodmanOptions{Volumes: []string{"/data:/data"}}
+
+	// Act
+	podman.AddVolume(stromboli.VolumeMount{Host: "/code", Container: "/workspace", ReadOnly: true})
+
+	// Assert
+	assert.Equal(t, []string{"/data:/data", "/code:/workspace:ro"}, podman.Volumes)
+}
+
+// TestPodmanOptions_AddVolume_Dedup tests that adding a volume replaces the
+// volumes mounted at the same container path, with a warning if they
+// differ.
+func TestPodmanOptions_AddVolume_Dedup(t *testing.T) {
+	tests := []struct {
+		name         string
+		volumes      []string
+		add          stromboli.VolumeMount
+		want         []string
+		wantWarnings int
+	}{
+		{
+			name:    "same volume",
+			volumes: []string{"/code:/workspace:ro", "/data:/data"},
+			add:     stromboli.VolumeMount{Host: "/code", Container: "/workspace", ReadOnly: true},
+			want:    []string{"/data:/data", "/code:/workspace:ro"},
+		},
+		{
+			name:         "conflicting volume",
+			volumes:      []string{"/code:/workspace", "/data:/data"},
+			add:          stromboli.VolumeMount{Host: "/other", Container: "/workspace/"},
+			want:         []string{"/data:/data", "/other:/workspace/"},
+			wantWarnings: 1,
+		},
+		{
+			name:         "several conflicting volumes",
+			volumes:      []string{"/a:/workspace", "/b:/workspace:ro"},
+			add:          stromboli.VolumeMount{Host: "/c", Container: "/workspace"},
+			want:         []string{"/c:/workspace"},
+			wantWarnings: 2,
+		},
+		{
+			name:    "no container path",
+			volumes: []string{"invalid"},
+			add:     stromboli.VolumeMount{Host: "/data", Container: "/data"},
+			want:    []string{"invalid", "/data:/data"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			logger := &captureLogger{}
+			stromboli.SetLogger(logger)
+			defer stromboli.SetLogger(nil)
+			podman := &stromboli.PodmanOptions{Volumes: tt.volumes}
+			original := append([]string(nil), tt.volumes...)
+
+			// Act
+			podman.AddVolume(tt.add)
+
+			// Assert
+			assert.Equal(t, tt.want, podman.Volumes)
+			assert.Len(t, logger.Messages(), tt.wantWarnings)
+			assert.Equal(t, original, tt.volumes, "the original slice is not modified")
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tomblancdev/stromboli-go"
+)
+
+// TestWebhookHandler_RoundTrip tests receiving a payload through an HTTP server.
+func TestWebhookHandler_RoundTrip(t *testing.T) {
+	// Arrange
+	var received *stromboli.WebhookPayload
+	server := httptest.NewServer(stromboli.WebhookHandler(
+		func(ctx context.Context, p *stromboli.WebhookPayload) error {
+			received = p
+			return nil
+		}))
+	defer server.Close()
+
+	sent := stromboli.WebhookPayload{Job: stromboli.Job{
+		ID:        "job-abc123",
+		Status:    stromboli.JobStatusFailed,
+		Output:    "partial",
+		Error:     "container crashed",
+		SessionID: "sess-1",
+		CreatedAt: "2024-01-15T10:30:00Z",
+		UpdatedAt: "2024-01-15T10:31:00Z",
+		CrashInfo: &stromboli.CrashInfo{
+			Reason:   "Container OOM killed",
+			ExitCode: 137,
+			Signal:   "SIGKILL",
+		},
+	}}
+	body, err := json.Marshal(sent)
+	require.NoError(t, err)
+
+	// Act
+	resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.NotNil(t, received)
+	assert.Equal(t, sent, *received)
+	assert.True(t, received.IsFailed())
+	updated, err := received.ParseUpdatedAt()
+	require.NoError(t, err)
+	assert.Equal(t, 2024, updated.Year())
+}
+
+// TestParseWebhook_JSONFieldNames tests decoding the field names sent by the server.
+func TestParseWebhook_JSONFieldNames(t *testing.T) {
+	// Arrange
+	body := `{
+		"id": "job-1",
+		"status": "completed",
+		"output": "done",
+		"session_id": "sess-1",
+		"created_at": "2024-01-15T10:30:00Z",
+		"updated_at": "2024-01-15T10:31:00Z",
+		"crash_info": {"reason": "timeout", "exit_code": 143, "partial_output": "half", "task_completed": true}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+
+	// Act
+	payload, err := stromboli.ParseWebhook(req)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "job-1", payload.ID)
+	assert.True(t, payload.IsCompleted())
+	assert.Equal(t, "done", payload.Output)
+	assert.Equal(t, "sess-1", payload.SessionID)
+	require.NotNil(t, payload.CrashInfo)
+	assert.Equal(t, int64(143), payload.CrashInfo.ExitCode)
+	assert.Equal(t, "half", payload.CrashInfo.PartialOutput)
+	assert.True(t, payload.CrashInfo.TaskCompleted)
+}
+
+// TestParseWebhook_Invalid tests rejecting invalid webhook requests.
+func TestParseWebhook_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   string
+	}{
+		{"wrong method", http.MethodGet, `{"id":"job-1"}`},
+		{"invalid json", http.MethodPost, `{"id":`},
+		{"missing job id", http.MethodPost, `{"status":"completed"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/webhook", strings.NewReader(tt.body))
+
+			payload, err := stromboli.ParseWebhook(req)
+
+			require.Error(t, err)
+			assert.Nil(t, payload)
+			assert.True(t, errors.Is(err, stromboli.ErrBadRequest))
+		})
+	}
+}
+
+// TestWebhookHandler_Errors tests the status codes of the webhook handler.
+func TestWebhookHandler_Errors(t *testing.T) {
+	handler := stromboli.WebhookHandler(func(ctx context.Context, p *stromboli.WebhookPayload) error {
+		return errors.New("database unavailable")
+	})
+
+	// Invalid payload
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader("nope")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Handler failure
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"id":"job-1"}`)))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "database")
+}
@@ -0,0 +1,35 @@
+package stromboli
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// loadClientTLSConfig returns the TLS configuration of
+// WithClientCertificate: the client certificate in certFile and keyFile,
+// and the CA certificates in caFile (the system roots if empty).
+func loadClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("stromboli: failed to load client certificate %q and key %q: %w", certFile, keyFile, err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("stromboli: failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("stromboli: no PEM certificates found in CA file %q", caFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
@@ -1,6 +1,12 @@
 package stromboli
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
 
 // ----------------------------------------------------------------------------
 // System Types
@@ -142,6 +148,22 @@ type RunRequest struct {
 	// Podman contains container configuration options.
 	// See [PodmanOptions] for available settings.
 	Podman *PodmanOptions `json:"podman,omitempty"`
+
+	// DryRun makes [Client.Run] resolve the request without executing it:
+	// it returns a response with Status "dry_run" and the request as it
+	// would be sent in Resolved (see [Client.ResolveRequest]). It is never
+	// sent to the server.
+	DryRun bool `json:"-"`
+
+	// IdempotencyKey identifies the run for the server, which executes
+	// requests with the same key at most once. It is sent in the
+	// Idempotency-Key header, so retrying a run whose response was lost
+	// (e.g. after a network error) with the same key is safe when the
+	// server supports idempotency keys; other servers ignore it.
+	//
+	// Set it to a unique value, such as a UUID, or let [WithIdempotency]
+	// generate one. Empty (the default) sends no key.
+	IdempotencyKey string `json:"-"`
 }
 
 // ClaudeOptions configures Claude's behavior during execution.
@@ -198,8 +220,8 @@ type ClaudeOptions struct {
 	DangerouslySkipPermissions bool `json:"dangerously_skip_permissions,omitempty"`
 
 	// PermissionMode controls how permissions are handled.
-	// Values: "default", "acceptEdits", "bypassPermissions", "plan", "dontAsk"
-	PermissionMode string `json:"permission_mode,omitempty"`
+	// See the PermissionMode constants for the valid values.
+	PermissionMode PermissionMode `json:"permission_mode,omitempty"`
 
 	// OutputFormat controls the response format.
 	// Values: "text", "json", "stream-json"
@@ -344,10 +366,12 @@ type PodmanOptions struct {
 	// Format: "host_path:container_path" or "host_path:container_path:options"
 	// Options: "ro" (read-only), "rw" (read-write, default)
 	// Example: []string{"/data:/data:ro", "/workspace:/workspace"}
+	// Use [PodmanOptions.AddVolume] to add a typed [VolumeMount].
 	Volumes []string `json:"volumes,omitempty"`
 
 	// Image overrides the container image.
-	// Must match server-configured allowed patterns.
+	// Must match server-configured allowed patterns. Use [ImageRef.String]
+	// to set it from a parsed reference.
 	// Example: "python:3.12"
 	Image string `json:"image,omitempty"`
 
@@ -355,6 +379,10 @@ type PodmanOptions struct {
 	// Key: environment variable name, Value: Podman secret name.
 	// The secret must exist (created via `podman secret create`).
 	// Example: map[string]string{"GH_TOKEN": "github-token"}
+	//
+	// The API has no field for plain environment variables, so
+	// non-sensitive values such as NODE_ENV=production must also be stored
+	// as secrets (see [Client.CreateSecret]) and injected here.
 	SecretsEnv map[string]string `json:"secrets_env,omitempty"`
 
 	// Lifecycle configures commands to run at specific container lifecycle stages.
@@ -452,15 +480,31 @@ type EnvironmentConfig struct {
 //	    // Execution failed - check result.Error for details
 //	    fmt.Printf("Execution failed: %s\n", result.Error)
 //	}
+//
+// Or use [RunResponse.Err] to handle both failures as errors:
+//
+//	result, err := client.Run(ctx, req)
+//	if err == nil {
+//	    err = result.Err()
+//	}
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
 type RunResponse struct {
 	// ID is the unique execution identifier.
 	// Example: "run-abc123def456"
 	ID string `json:"id"`
 
 	// Status indicates execution result.
-	// Values: "completed" (success) or "error" (failure).
+	// Values: "completed" (success), "error" (failure) or "dry_run", or
+	// "unknown" for statuses the SDK doesn't know (see RawStatus).
+	// Use [RunResponse.State] for a typed value.
 	Status string `json:"status"`
 
+	// RawStatus is the status reported by the server when the SDK doesn't
+	// know it (Status is then "unknown"). Empty otherwise.
+	RawStatus string `json:"raw_status,omitempty"`
+
 	// Output contains Claude's response when Status is "completed".
 	Output string `json:"output,omitempty"`
 
@@ -470,11 +514,157 @@ type RunResponse struct {
 	// SessionID can be used to continue this conversation.
 	// Pass this to RunRequest.Claude.SessionID for follow-up requests.
 	SessionID string `json:"session_id,omitempty"`
+
+	// Usage reports the tokens and cost of the execution.
+	// Nil if the server didn't report it.
+	Usage *Usage `json:"usage,omitempty"`
+
+	// ModelUsed is the model that produced the response, if the server
+	// reports it. [Client.RunWithFallbacks] always sets it.
+	// Example: "sonnet"
+	ModelUsed Model `json:"model_used,omitempty"`
+
+	// Resolved is the request as it would have been sent, for dry runs
+	// (see [RunRequest.DryRun]). Nil otherwise.
+	Resolved *ResolvedRequest `json:"resolved,omitempty"`
+
+	// StartedAt is when [Client.Run] sent the request, by the client's
+	// [Clock]. Zero for dry runs.
+	StartedAt time.Time `json:"started_at,omitzero"`
+
+	// CompletedAt is when [Client.Run] received the response, by the
+	// client's [Clock]. Zero for dry runs.
+	CompletedAt time.Time `json:"completed_at,omitzero"`
 }
 
 // IsSuccess returns true if the execution completed successfully.
 func (r *RunResponse) IsSuccess() bool {
-	return r.Status == RunStatusCompleted
+	return r.State() == RunStateCompleted
+}
+
+// IsError returns true if the execution failed (Status is "error").
+func (r *RunResponse) IsError() bool {
+	return r.Status == RunStatusError
+}
+
+// Err returns an [ErrExecutionFailed] error carrying the response's Error
+// if the execution failed, or nil otherwise. Other statuses, such as
+// "dry_run" or "unknown", return nil: check [RunResponse.IsSuccess] to
+// require a completed execution.
+//
+// Example:
+//
+//	if err := result.Err(); err != nil {
+//	    log.Printf("run %s failed: %v", result.ID, err)
+//	}
+func (r *RunResponse) Err() error {
+	if !r.IsError() {
+		return nil
+	}
+	message := r.Error
+	if message == "" {
+		message = ErrExecutionFailed.Message
+	}
+	return newError(ErrExecutionFailed.Code, message, 0, nil)
+}
+
+// State returns the response's Status as a [RunState].
+func (r *RunResponse) State() RunState {
+	return RunState(r.Status)
+}
+
+// CostUSD returns the total cost of the execution in USD, and false if the
+// server didn't report usage.
+func (r *RunResponse) CostUSD() (float64, bool) {
+	if r.Usage == nil {
+		return 0, false
+	}
+	return r.Usage.TotalCostUSD, true
+}
+
+// AsJob returns the response as a finished [Job], e.g. to store the
+// results of Run and RunAsync in the same way. It returns nil if r is nil.
+//
+// The job's CreatedAt and UpdatedAt are StartedAt and CompletedAt in
+// RFC 3339 format (empty if zero), and its status is "completed" or
+// "failed". Statuses without a job equivalent, such as "dry_run", become
+// "unknown" with the run status in RawStatus. The job shares nothing with
+// r.
+//
+// Example:
+//
+//	result, err := client.Run(ctx, req)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	store.SaveJob(result.AsJob())
+func (r *RunResponse) AsJob() *Job {
+	if r == nil {
+		return nil
+	}
+
+	job := &Job{
+		ID:        r.ID,
+		RawStatus: r.RawStatus,
+		Output:    r.Output,
+		Error:     r.Error,
+		SessionID: r.SessionID,
+		CreatedAt: formatJobTime(r.StartedAt),
+		UpdatedAt: formatJobTime(r.CompletedAt),
+		Usage:     copyUsage(r.Usage),
+	}
+	switch r.State() {
+	case RunStateCompleted:
+		job.Status = JobStatusCompleted
+	case RunStateError:
+		job.Status = JobStatusFailed
+	case RunStateUnknown:
+		job.Status = JobStatusUnknown
+	default:
+		job.Status, job.RawStatus = JobStatusUnknown, r.Status
+	}
+	return job
+}
+
+// Usage reports the token usage and cost of an execution, as computed by
+// the server (which also enforces ClaudeOptions.MaxBudgetUSD).
+//
+// It is nil in [RunResponse] and [Job] when the server doesn't report it,
+// so "not reported" can be told apart from a zero cost.
+//
+// Example:
+//
+//	result, err := client.Run(ctx, req)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if cost, ok := result.CostUSD(); ok {
+//	    fmt.Printf("cost: $%.4f (%d turns)\n", cost, result.Usage.NumTurns)
+//	}
+type Usage struct {
+	// InputTokens is the number of input tokens.
+	InputTokens int64 `json:"input_tokens"`
+
+	// OutputTokens is the number of output tokens.
+	OutputTokens int64 `json:"output_tokens"`
+
+	// CacheCreationInputTokens is the number of input tokens written to
+	// the prompt cache.
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+
+	// CacheReadInputTokens is the number of input tokens read from the
+	// prompt cache.
+	CacheReadInputTokens int64 `json:"cache_read_input_tokens"`
+
+	// TotalCostUSD is the total cost of the execution in USD.
+	TotalCostUSD float64 `json:"total_cost_usd"`
+
+	// NumTurns is the number of agentic turns Claude took.
+	NumTurns int64 `json:"num_turns"`
+
+	// DurationMS is the wall-clock duration of the execution in
+	// milliseconds, or 0 if the server doesn't report it.
+	DurationMS int64 `json:"duration_ms"`
 }
 
 // AsyncRunResponse represents the result of starting an async execution.
@@ -516,12 +706,12 @@ type AsyncRunResponse struct {
 //	    log.Fatal(err)
 //	}
 //
-//	switch job.Status {
-//	case stromboli.JobStatusCompleted:
+//	switch job.State() {
+//	case stromboli.JobStateCompleted:
 //	    fmt.Println(job.Output)
-//	case stromboli.JobStatusRunning:
+//	case stromboli.JobStateRunning:
 //	    fmt.Println("Still running...")
-//	case stromboli.JobStatusFailed:
+//	case stromboli.JobStateFailed:
 //	    fmt.Printf("Failed: %s\n", job.Error)
 //	}
 type Job struct {
@@ -530,9 +720,15 @@ type Job struct {
 	ID string `json:"id"`
 
 	// Status indicates the current job state.
-	// Values: "pending", "running", "completed", "failed", "cancelled"
+	// Values: "pending", "running", "completed", "failed", "cancelled", or
+	// "unknown" for states the SDK doesn't know (see RawStatus).
+	// Use [Job.State] for a typed value.
 	Status string `json:"status"`
 
+	// RawStatus is the status reported by the server when the SDK doesn't
+	// know it (Status is then "unknown"). Empty otherwise.
+	RawStatus string `json:"raw_status,omitempty"`
+
 	// Output contains Claude's response when Status is "completed".
 	Output string `json:"output,omitempty"`
 
@@ -553,59 +749,169 @@ type Job struct {
 
 	// CrashInfo contains crash details if the job crashed.
 	CrashInfo *CrashInfo `json:"crash_info,omitempty"`
+
+	// Usage reports the tokens and cost of the job's execution.
+	// Nil if the server didn't report it (e.g. the job is still running).
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 // IsCompleted returns true if the job completed successfully.
 func (j *Job) IsCompleted() bool {
-	return j.Status == JobStatusCompleted
+	return j.State() == JobStateCompleted
 }
 
 // IsRunning returns true if the job is still running.
 func (j *Job) IsRunning() bool {
-	return j.Status == JobStatusRunning || j.Status == JobStatusPending
+	state := j.State()
+	return state == JobStateRunning || state == JobStatePending
 }
 
 // IsFailed returns true if the job failed.
 func (j *Job) IsFailed() bool {
-	return j.Status == JobStatusFailed
+	return j.State() == JobStateFailed
 }
 
 // IsCancelled returns true if the job was cancelled.
 func (j *Job) IsCancelled() bool {
-	return j.Status == JobStatusCancelled
+	return j.State() == JobStateCancelled
 }
 
 // IsPending returns true if the job is pending (queued but not yet started).
 func (j *Job) IsPending() bool {
-	return j.Status == JobStatusPending
+	return j.State() == JobStatePending
+}
+
+// State returns the job's Status as a [JobState].
+func (j *Job) State() JobState {
+	return JobState(j.Status)
+}
+
+// IsTerminal returns true if the job is completed, failed or cancelled, so
+// its status won't change anymore.
+func (j *Job) IsTerminal() bool {
+	return j.State().IsTerminal()
+}
+
+// ParseCreatedAt parses CreatedAt, in RFC 3339 format with optional
+// fractional seconds. It returns an INVALID_RESPONSE [Error] if CreatedAt
+// is empty or malformed.
+func (j *Job) ParseCreatedAt() (time.Time, error) {
+	return parseJobTime("created_at", j.CreatedAt)
+}
+
+// ParseUpdatedAt parses UpdatedAt, in RFC 3339 format with optional
+// fractional seconds. It returns an INVALID_RESPONSE [Error] if UpdatedAt
+// is empty or malformed.
+func (j *Job) ParseUpdatedAt() (time.Time, error) {
+	return parseJobTime("updated_at", j.UpdatedAt)
+}
+
+// Duration returns the wall time of a finished job, from its creation to
+// its last update, and false if the job isn't terminal or either timestamp
+// is missing or invalid.
+//
+// The server doesn't report when a job started running, so the duration
+// includes the time the job was pending.
+//
+// Example:
+//
+//	if d, ok := job.Duration(); ok {
+//	    fmt.Printf("job took %s\n", d.Round(time.Second))
+//	}
+func (j *Job) Duration() (time.Duration, bool) {
+	if !j.IsTerminal() {
+		return 0, false
+	}
+	created, err := j.ParseCreatedAt()
+	if err != nil {
+		return 0, false
+	}
+	updated, err := j.ParseUpdatedAt()
+	if err != nil || updated.Before(created) {
+		return 0, false
+	}
+	return updated.Sub(created), true
 }
 
 // CreatedAtTime parses CreatedAt as time.Time.
 // Returns zero time if CreatedAt is empty or parsing fails.
 //
-// NOTE: Parsing errors are silently ignored. If you need to validate
-// the timestamp format, use time.Parse(time.RFC3339, j.CreatedAt) directly.
+// Deprecated: Use [Job.ParseCreatedAt], which reports parse errors.
 func (j *Job) CreatedAtTime() time.Time {
-	if j.CreatedAt == "" {
-		return time.Time{}
-	}
-	t, _ := time.Parse(time.RFC3339, j.CreatedAt)
+	t, _ := j.ParseCreatedAt()
 	return t
 }
 
 // UpdatedAtTime parses UpdatedAt as time.Time.
 // Returns zero time if UpdatedAt is empty or parsing fails.
 //
-// NOTE: Parsing errors are silently ignored. If you need to validate
-// the timestamp format, use time.Parse(time.RFC3339, j.UpdatedAt) directly.
+// Deprecated: Use [Job.ParseUpdatedAt], which reports parse errors.
 func (j *Job) UpdatedAtTime() time.Time {
-	if j.UpdatedAt == "" {
-		return time.Time{}
-	}
-	t, _ := time.Parse(time.RFC3339, j.UpdatedAt)
+	t, _ := j.ParseUpdatedAt()
 	return t
 }
 
+// AsRunResponse returns a completed or failed job as a [RunResponse], as
+// if it had been run with [Client.Run]. StartedAt and CompletedAt are
+// CreatedAt and UpdatedAt (zero if invalid). It returns false if the job
+// isn't completed or failed. The response shares nothing with j.
+func (j *Job) AsRunResponse() (*RunResponse, bool) {
+	if j == nil {
+		return nil, false
+	}
+
+	var status string
+	switch j.State() {
+	case JobStateCompleted:
+		status = RunStatusCompleted
+	case JobStateFailed:
+		status = RunStatusError
+	default:
+		return nil, false
+	}
+	started, _ := j.ParseCreatedAt()
+	completed, _ := j.ParseUpdatedAt()
+	return &RunResponse{
+		ID:          j.ID,
+		Status:      status,
+		Output:      j.Output,
+		Error:       j.Error,
+		SessionID:   j.SessionID,
+		Usage:       copyUsage(j.Usage),
+		StartedAt:   started,
+		CompletedAt: completed,
+	}, true
+}
+
+// formatJobTime formats t as a job timestamp, or returns "" if t is zero.
+func formatJobTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// copyUsage returns a copy of u, or nil if u is nil.
+func copyUsage(u *Usage) *Usage {
+	if u == nil {
+		return nil
+	}
+	usage := *u
+	return &usage
+}
+
+// parseJobTime parses the timestamp value of a job's field.
+func parseJobTime(field, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, newError("INVALID_RESPONSE", field+" is not set", 0, nil)
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, newError("INVALID_RESPONSE", fmt.Sprintf("invalid %s %q", field, value), 0, err)
+	}
+	return t, nil
+}
+
 // CrashInfo contains details about a job crash.
 //
 // This is populated when a job terminates unexpectedly due to
@@ -631,10 +937,206 @@ type CrashInfo struct {
 	TaskCompleted bool `json:"task_completed,omitempty"`
 }
 
+// Exit codes of a container process killed by a signal (128 + signal).
+const (
+	exitCodeSIGKILL = 137
+	exitCodeSIGTERM = 143
+)
+
+// IsOOM reports whether the job ran out of memory: the process exited with
+// code 137 (killed by the OOM killer) or Reason mentions OOM or "out of
+// memory". Such jobs may succeed when retried with more memory (see
+// [PodmanOptions.Memory]).
+//
+// It returns false for a nil CrashInfo.
+func (ci *CrashInfo) IsOOM() bool {
+	if ci == nil {
+		return false
+	}
+	if ci.ExitCode == exitCodeSIGKILL {
+		return true
+	}
+	reason := strings.ToLower(ci.Reason)
+	if strings.Contains(reason, "out of memory") {
+		return true
+	}
+	words := strings.FieldsFunc(reason, func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, word := range words {
+		if strings.HasPrefix(word, "oom") { // "OOM", "OOMKilled"
+			return true
+		}
+	}
+	return false
+}
+
+// WasKilled reports whether the process was killed by a signal: Signal is
+// SIGKILL or SIGTERM, or the exit code is 137 or 143. This includes jobs
+// killed for running out of memory or stopped by a cancellation.
+//
+// It returns false for a nil CrashInfo.
+func (ci *CrashInfo) WasKilled() bool {
+	if ci == nil {
+		return false
+	}
+	switch strings.TrimPrefix(strings.ToUpper(ci.Signal), "SIG") {
+	case "KILL", "TERM":
+		return true
+	}
+	return ci.ExitCode == exitCodeSIGKILL || ci.ExitCode == exitCodeSIGTERM
+}
+
+// IsTimeout reports whether Reason says the job timed out (see
+// [PodmanOptions.Timeout]).
+//
+// It returns false for a nil CrashInfo.
+func (ci *CrashInfo) IsTimeout() bool {
+	if ci == nil {
+		return false
+	}
+	reason := strings.ToLower(ci.Reason)
+	return strings.Contains(reason, "timeout") || strings.Contains(reason, "timed out")
+}
+
+// ListJobsOptions configures the filtering and pagination for
+// [Client.ListJobsFiltered].
+//
+// Example:
+//
+//	jobs, _ := client.ListJobsFiltered(ctx, &stromboli.ListJobsOptions{
+//	    Status: []string{stromboli.JobStatusRunning, stromboli.JobStatusPending},
+//	    Limit:  20,
+//	})
+type ListJobsOptions struct {
+	// Status restricts the result to jobs in one of these states.
+	// Each value must be one of the JobStatus* constants. Empty means all.
+	Status []string `json:"status,omitempty"`
+
+	// Limit is the maximum number of jobs to return (0 means no limit).
+	Limit int64 `json:"limit,omitempty"`
+
+	// Offset is the number of jobs to skip (for pagination).
+	Offset int64 `json:"offset,omitempty"`
+}
+
 // ----------------------------------------------------------------------------
 // Session Types
 // ----------------------------------------------------------------------------
 
+// SessionInfo describes a session, as returned by [Client.GetSession] and
+// [Client.ListSessionsDetailed].
+//
+// Example:
+//
+//	info, _ := client.GetSession(ctx, "sess-abc123")
+//	fmt.Printf("%s: %d messages, last: %q\n", info.ID, info.MessageCount, info.LastPrompt)
+type SessionInfo struct {
+	// ID is the session identifier.
+	// Example: "sess-abc123"
+	ID string `json:"id"`
+
+	// CreatedAt is when the session was created (RFC3339 format).
+	// Example: "2024-01-15T10:30:00Z"
+	CreatedAt string `json:"created_at,omitempty"`
+
+	// UpdatedAt is when the session was last used (RFC3339 format).
+	// Example: "2024-01-15T11:02:00Z"
+	UpdatedAt string `json:"updated_at,omitempty"`
+
+	// MessageCount is the number of messages in the session.
+	MessageCount int64 `json:"message_count,omitempty"`
+
+	// LastPrompt is a preview of the last user prompt (possibly truncated
+	// by the server).
+	LastPrompt string `json:"last_prompt,omitempty"`
+
+	// Workdir is the working directory of the session.
+	// Example: "/workspace"
+	Workdir string `json:"workdir,omitempty"`
+
+	// Model is the model of the session's last run.
+	Model Model `json:"model,omitempty"`
+}
+
+// CreatedAtTime parses CreatedAt as time.Time.
+// Returns zero time if CreatedAt is empty or parsing fails.
+func (s *SessionInfo) CreatedAtTime() time.Time {
+	if s.CreatedAt == "" {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, s.CreatedAt)
+	return t
+}
+
+// UpdatedAtTime parses UpdatedAt as time.Time.
+// Returns zero time if UpdatedAt is empty or parsing fails.
+func (s *SessionInfo) UpdatedAtTime() time.Time {
+	if s.UpdatedAt == "" {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, s.UpdatedAt)
+	return t
+}
+
+// ListSessionsOptions configures the pagination for
+// [Client.ListSessionsDetailed].
+//
+// Example:
+//
+//	sessions, _ := client.ListSessionsDetailed(ctx, &stromboli.ListSessionsOptions{
+//	    Limit:  20,
+//	    Offset: 40,
+//	})
+type ListSessionsOptions struct {
+	// Limit is the maximum number of sessions to return (0 means no limit).
+	Limit int64 `json:"limit,omitempty"`
+
+	// Offset is the number of sessions to skip (for pagination).
+	Offset int64 `json:"offset,omitempty"`
+}
+
+// CleanupOptions selects the sessions removed by [Client.CleanupSessions].
+//
+// Example:
+//
+//	result, err := client.CleanupSessions(ctx, &stromboli.CleanupOptions{
+//	    OlderThan:   30 * 24 * time.Hour,
+//	    Limit:       500,
+//	    Concurrency: 4,
+//	    DryRun:      true,
+//	})
+type CleanupOptions struct {
+	// OlderThan restricts the cleanup to sessions not used for at least
+	// this long, based on their UpdatedAt (or CreatedAt) time. Zero means
+	// all sessions.
+	OlderThan time.Duration
+
+	// Limit is the maximum number of sessions to destroy, the least
+	// recently used first (0 means no limit).
+	Limit int
+
+	// Concurrency is the maximum number of deletions in flight (values
+	// below 1 are treated as 1).
+	Concurrency int
+
+	// DryRun reports the sessions that would be destroyed without
+	// destroying them.
+	DryRun bool
+}
+
+// CleanupResult reports the outcome of [Client.CleanupSessions].
+type CleanupResult struct {
+	// Deleted are the IDs of the sessions destroyed (or, for a dry run,
+	// that would be destroyed), the least recently used first.
+	Deleted []string
+
+	// Errors holds the error of each session that couldn't be destroyed,
+	// keyed by session ID (nil if all succeeded).
+	Errors map[string]error
+
+	// DryRun indicates nothing was destroyed.
+	DryRun bool
+}
+
 // GetMessagesOptions configures the pagination for [Client.GetMessages].
 //
 // Example:
@@ -648,7 +1150,12 @@ type GetMessagesOptions struct {
 	Limit int64 `json:"limit,omitempty"`
 
 	// Offset is the number of messages to skip (for pagination).
+	// Ignored when Cursor is set.
 	Offset int64 `json:"offset,omitempty"`
+
+	// Cursor is the NextCursor of a previous page, for servers that support
+	// cursor-based pagination. It takes precedence over Offset.
+	Cursor string `json:"cursor,omitempty"`
 }
 
 // MessagesResponse represents a paginated list of session messages.
@@ -678,6 +1185,11 @@ type MessagesResponse struct {
 
 	// HasMore indicates if there are more messages to fetch.
 	HasMore bool `json:"has_more"`
+
+	// NextCursor is the cursor of the next page, set by servers that
+	// support cursor-based pagination. Empty on the last page and on
+	// servers that only support offsets.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // Message represents a single message from session history.
@@ -691,7 +1203,8 @@ type Message struct {
 	UUID string `json:"uuid,omitempty"`
 
 	// Type indicates the message type.
-	// Values: "user", "assistant", "queue-operation"
+	// Values: "user", "assistant", "queue-operation", or other types sent
+	// by newer servers, kept as-is.
 	Type string `json:"type,omitempty"`
 
 	// ParentUUID is the parent message UUID for threading.
@@ -723,7 +1236,8 @@ type Message struct {
 	//   - For "user" messages: string or []ContentBlock
 	//   - For "assistant" messages: []ContentBlock with text and tool_use
 	//
-	// Use type assertions or json.Marshal/Unmarshal to work with this field.
+	// Use [Message.TypedContent] to parse it into typed blocks, or type
+	// assertions or json.Marshal/Unmarshal to work with it directly.
 	//
 	// Example:
 	//
@@ -785,11 +1299,7 @@ func (m *Message) ContentAsString() (string, bool) {
 // NOT whether any blocks were found. An empty content array returns ok=true with
 // an empty blocks slice. Use ok=false to detect non-array content formats.
 //
-// For more precise typing, use json.Marshal/Unmarshal:
-//
-//	data, _ := json.Marshal(msg.Content)
-//	var blocks []YourBlockType
-//	json.Unmarshal(data, &blocks)
+// For typed blocks, use [Message.TypedContent].
 func (m *Message) ContentAsBlocks() (blocks []map[string]interface{}, skipped int, ok bool) {
 	items, isArray := m.Content.([]interface{})
 	if !isArray {
@@ -807,6 +1317,153 @@ func (m *Message) ContentAsBlocks() (blocks []map[string]interface{}, skipped in
 	return blocks, skipped, true
 }
 
+// ContentBlock is a typed block of message content.
+//
+// Use [Message.TypedContent] to parse a message's content and a type switch
+// to handle each kind of block:
+//
+//	blocks, err := msg.TypedContent()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, block := range blocks {
+//	    switch b := block.(type) {
+//	    case *stromboli.TextBlock:
+//	        fmt.Println(b.Text)
+//	    case *stromboli.ToolUseBlock:
+//	        fmt.Printf("tool call %s(%v)\n", b.Name, b.Input)
+//	    case *stromboli.ToolResultBlock:
+//	        fmt.Printf("tool result for %s\n", b.ToolUseID)
+//	    }
+//	}
+type ContentBlock interface {
+	// BlockType returns the block's type, e.g. "text" or "tool_use".
+	BlockType() string
+}
+
+// Content block types returned by [ContentBlock.BlockType].
+const (
+	// BlockTypeText identifies a [TextBlock].
+	BlockTypeText = "text"
+
+	// BlockTypeToolUse identifies a [ToolUseBlock].
+	BlockTypeToolUse = "tool_use"
+
+	// BlockTypeToolResult identifies a [ToolResultBlock].
+	BlockTypeToolResult = "tool_result"
+)
+
+// TextBlock is a block of plain text.
+type TextBlock struct {
+	// Text is the text content.
+	Text string `json:"text"`
+}
+
+// BlockType implements [ContentBlock].
+func (b *TextBlock) BlockType() string { return BlockTypeText }
+
+// ToolUseBlock is a tool call made by the assistant.
+type ToolUseBlock struct {
+	// ID is the tool use identifier, referenced by the matching
+	// [ToolResultBlock].
+	// Example: "toolu_01A09q90qw90lq917835lq9"
+	ID string `json:"id"`
+
+	// Name is the name of the tool.
+	// Example: "Bash"
+	Name string `json:"name"`
+
+	// Input contains the tool arguments.
+	Input map[string]interface{} `json:"input,omitempty"`
+}
+
+// BlockType implements [ContentBlock].
+func (b *ToolUseBlock) BlockType() string { return BlockTypeToolUse }
+
+// ToolResultBlock is the result of a tool call.
+type ToolResultBlock struct {
+	// ToolUseID is the ID of the [ToolUseBlock] this result responds to.
+	ToolUseID string `json:"tool_use_id"`
+
+	// Content is the result data: a string or a list of content blocks.
+	Content interface{} `json:"content,omitempty"`
+
+	// IsError indicates the tool call failed.
+	IsError bool `json:"is_error,omitempty"`
+}
+
+// BlockType implements [ContentBlock].
+func (b *ToolResultBlock) BlockType() string { return BlockTypeToolResult }
+
+// TypedContent parses the message content into typed content blocks.
+//
+// Simple string content is returned as a single [TextBlock]. Nil content
+// returns nil. Block content is dispatched on each block's "type" field to
+// [TextBlock], [ToolUseBlock] or [ToolResultBlock].
+//
+// An error is returned if the content is neither a string nor a list of
+// blocks, or if any block has an unrecognized type, so new block types
+// are never silently dropped.
+//
+// Example:
+//
+//	blocks, err := msg.TypedContent()
+//	if err != nil {
+//	    log.Printf("unsupported content: %v", err)
+//	    return
+//	}
+//	for _, block := range blocks {
+//	    if text, ok := block.(*stromboli.TextBlock); ok {
+//	        fmt.Println(text.Text)
+//	    }
+//	}
+func (m *Message) TypedContent() ([]ContentBlock, error) {
+	if m.Content == nil {
+		return nil, nil
+	}
+	if s, ok := m.Content.(string); ok {
+		return []ContentBlock{&TextBlock{Text: s}}, nil
+	}
+
+	data, err := json.Marshal(m.Content)
+	if err != nil {
+		return nil, newError("INVALID_CONTENT", "failed to encode message content", 0, err)
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, newError("INVALID_CONTENT", "message content is not a list of blocks", 0, err)
+	}
+
+	blocks := make([]ContentBlock, 0, len(raws))
+	for i, raw := range raws {
+		var header struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &header); err != nil {
+			return nil, newError("INVALID_CONTENT", fmt.Sprintf("content block %d is not an object", i), 0, err)
+		}
+
+		var block ContentBlock
+		switch header.Type {
+		case BlockTypeText:
+			block = &TextBlock{}
+		case BlockTypeToolUse:
+			block = &ToolUseBlock{}
+		case BlockTypeToolResult:
+			block = &ToolResultBlock{}
+		default:
+			return nil, newError("INVALID_CONTENT",
+				fmt.Sprintf("content block %d has unrecognized type %q", i, header.Type), 0, nil)
+		}
+		if err := json.Unmarshal(raw, block); err != nil {
+			return nil, newError("INVALID_CONTENT",
+				fmt.Sprintf("failed to decode %s content block %d", header.Type, i), 0, err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
 // ----------------------------------------------------------------------------
 // Secrets Types
 // ----------------------------------------------------------------------------
@@ -990,6 +1647,46 @@ type SearchImagesOptions struct {
 
 	// NoTrunc disables truncation of output.
 	NoTrunc bool
+
+	// Offset is the number of results to skip (for pagination with
+	// [Client.SearchImagesPage]).
+	Offset int64
+
+	// Deduplicate merges results for the same image found in several
+	// registries (e.g. docker.io and a mirror), keeping the entry with the
+	// most stars, and sorts the results by stars, most starred first.
+	Deduplicate bool
+}
+
+// SearchResultsPage is a page of image search results, returned by
+// [Client.SearchImagesPage].
+//
+// Example:
+//
+//	opts := &stromboli.SearchImagesOptions{Query: "python", Limit: 25}
+//	for {
+//	    page, err := client.SearchImagesPage(ctx, opts)
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    // Use page.Results...
+//	    if !page.HasMore {
+//	        break
+//	    }
+//	    opts.Offset = page.Offset + page.Limit
+//	}
+type SearchResultsPage struct {
+	// Results are the search results of this page.
+	Results []*ImageSearchResult `json:"results"`
+
+	// Offset is the number of results skipped.
+	Offset int64 `json:"offset"`
+
+	// Limit is the requested page size (0 means no limit).
+	Limit int64 `json:"limit"`
+
+	// HasMore indicates if there are more results to fetch.
+	HasMore bool `json:"has_more"`
 }
 
 // PullImageRequest represents a request to pull a container image.
@@ -1001,7 +1698,8 @@ type SearchImagesOptions struct {
 //	    Platform: "linux/amd64",
 //	})
 type PullImageRequest struct {
-	// Image is the image reference to pull (required).
+	// Image is the image reference to pull (required). Use
+	// [ImageRef.String] to set it from a parsed reference.
 	// Example: "python:3.12-slim"
 	Image string `json:"image"`
 
@@ -1027,6 +1725,18 @@ type PullImageResponse struct {
 	ImageID string `json:"image_id,omitempty"`
 }
 
+// DeleteImageOptions configures an image deletion request.
+//
+// Use with [Client.DeleteImage]:
+//
+//	err := client.DeleteImage(ctx, "python:3.12-slim", &stromboli.DeleteImageOptions{
+//	    Force: true,
+//	})
+type DeleteImageOptions struct {
+	// Force removes the image even if it is used by stopped containers.
+	Force bool
+}
+
 // ----------------------------------------------------------------------------
 // Constants
 // ----------------------------------------------------------------------------
@@ -1068,6 +1778,77 @@ func (m Model) String() string {
 	return string(m)
 }
 
+// knownModels lists the models defined as constants by the SDK.
+var knownModels = []Model{ModelHaiku, ModelSonnet, ModelOpus}
+
+// IsKnown reports whether m is one of the Model constants.
+//
+// An unknown model isn't necessarily invalid: the server may support models
+// that the SDK doesn't define yet. [Client.Run] and [Client.RunAsync] log a
+// warning for unknown models, or reject them with
+// [WithStrictModelValidation].
+func (m Model) IsKnown() bool {
+	for _, known := range knownModels {
+		if m == known {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionMode controls how Claude handles permission prompts, set with
+// [ClaudeOptions.PermissionMode].
+//
+// Any string can be converted to a PermissionMode, so modes added by newer
+// servers can be used before the SDK defines them; [Client.Run],
+// [Client.RunAsync] and [Client.ValidateRunRequest] reject modes for which
+// IsValid is false.
+type PermissionMode string
+
+// PermissionMode constants for [ClaudeOptions.PermissionMode].
+const (
+	// PermissionModeDefault prompts for permission as usual.
+	PermissionModeDefault PermissionMode = "default"
+
+	// PermissionModeAcceptEdits accepts file edits without prompting.
+	PermissionModeAcceptEdits PermissionMode = "acceptEdits"
+
+	// PermissionModeBypassPermissions skips all permission prompts.
+	// Only use in fully sandboxed environments.
+	PermissionModeBypassPermissions PermissionMode = "bypassPermissions"
+
+	// PermissionModePlan only plans, without editing files or running
+	// commands.
+	PermissionModePlan PermissionMode = "plan"
+
+	// PermissionModeDontAsk denies the tools that would need a prompt.
+	PermissionModeDontAsk PermissionMode = "dontAsk"
+)
+
+// String returns the string representation of the PermissionMode.
+func (p PermissionMode) String() string {
+	return string(p)
+}
+
+// knownPermissionModes lists the PermissionMode constants.
+var knownPermissionModes = []PermissionMode{
+	PermissionModeDefault,
+	PermissionModeAcceptEdits,
+	PermissionModeBypassPermissions,
+	PermissionModePlan,
+	PermissionModeDontAsk,
+}
+
+// IsValid reports whether p is one of the PermissionMode constants.
+func (p PermissionMode) IsValid() bool {
+	for _, known := range knownPermissionModes {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
 // RunStatus constants for execution results.
 const (
 	// RunStatusCompleted indicates successful execution.
@@ -1075,8 +1856,59 @@ const (
 
 	// RunStatusError indicates execution failed.
 	RunStatusError = "error"
+
+	// RunStatusDryRun indicates the request was resolved but not executed
+	// (see [RunRequest.DryRun]).
+	RunStatusDryRun = "dry_run"
+
+	// RunStatusUnknown replaces statuses the SDK doesn't know in responses;
+	// the server's value is kept in [RunResponse.RawStatus].
+	RunStatusUnknown = "unknown"
+)
+
+// RunState is the status of a synchronous execution, as returned by
+// [RunResponse.State].
+type RunState string
+
+// RunState values. They have the same values as the RunStatus* constants.
+const (
+	// RunStateCompleted indicates successful execution.
+	RunStateCompleted RunState = RunStatusCompleted
+
+	// RunStateError indicates execution failed.
+	RunStateError RunState = RunStatusError
+
+	// RunStateDryRun indicates the request was resolved but not executed.
+	RunStateDryRun RunState = RunStatusDryRun
+
+	// RunStateUnknown indicates a status the SDK doesn't know.
+	RunStateUnknown RunState = RunStatusUnknown
 )
 
+// IsKnown reports whether s is one of the RunState constants other than
+// RunStateUnknown.
+func (s RunState) IsKnown() bool {
+	switch s {
+	case RunStateCompleted, RunStateError, RunStateDryRun:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseRunState parses a run status. Unknown statuses return
+// RunStateUnknown and a BAD_REQUEST [Error].
+//
+// Example:
+//
+//	state, err := stromboli.ParseRunState("completed") // RunStateCompleted, nil
+func ParseRunState(s string) (RunState, error) {
+	if state := RunState(s); state.IsKnown() {
+		return state, nil
+	}
+	return RunStateUnknown, newError("BAD_REQUEST", fmt.Sprintf("unknown run status %q", s), 400, nil)
+}
+
 // HealthStatus constants for convenience.
 const (
 	// StatusOK indicates the service or component is healthy.
@@ -1108,8 +1940,84 @@ const (
 
 	// JobStatusCancelled indicates the job was cancelled.
 	JobStatusCancelled = "cancelled"
+
+	// JobStatusUnknown replaces states the SDK doesn't know in jobs
+	// returned by the client; the server's value is kept in
+	// [Job.RawStatus].
+	JobStatusUnknown = "unknown"
+)
+
+// JobState is the state of an async job, as returned by [Job.State].
+//
+// Unlike the string [Job.Status], it makes switches over job states
+// explicit and type-checked:
+//
+//	switch job.State() {
+//	case stromboli.JobStateCompleted:
+//	    fmt.Println(job.Output)
+//	case stromboli.JobStateFailed:
+//	    fmt.Printf("Failed: %s\n", job.Error)
+//	case stromboli.JobStatePending, stromboli.JobStateRunning:
+//	    fmt.Println("Still running...")
+//	}
+type JobState string
+
+// JobState values. They have the same values as the JobStatus* constants.
+const (
+	// JobStatePending indicates the job is queued but not yet started.
+	JobStatePending JobState = JobStatusPending
+
+	// JobStateRunning indicates the job is currently executing.
+	JobStateRunning JobState = JobStatusRunning
+
+	// JobStateCompleted indicates the job completed successfully.
+	JobStateCompleted JobState = JobStatusCompleted
+
+	// JobStateFailed indicates the job failed with an error.
+	JobStateFailed JobState = JobStatusFailed
+
+	// JobStateCancelled indicates the job was cancelled.
+	JobStateCancelled JobState = JobStatusCancelled
+
+	// JobStateUnknown indicates a state the SDK doesn't know.
+	JobStateUnknown JobState = JobStatusUnknown
 )
 
+// IsTerminal reports whether the job is in a final state: completed,
+// failed or cancelled.
+func (s JobState) IsTerminal() bool {
+	switch s {
+	case JobStateCompleted, JobStateFailed, JobStateCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsKnown reports whether s is one of the JobState constants other than
+// JobStateUnknown. Servers newer than the SDK may report other states.
+func (s JobState) IsKnown() bool {
+	switch s {
+	case JobStatePending, JobStateRunning, JobStateCompleted, JobStateFailed, JobStateCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseJobState parses a job status. Unknown statuses return
+// JobStateUnknown and a BAD_REQUEST [Error].
+//
+// Example:
+//
+//	state, err := stromboli.ParseJobState("complete") // JobStateUnknown, error
+func ParseJobState(s string) (JobState, error) {
+	if state := JobState(s); state.IsKnown() {
+		return state, nil
+	}
+	return JobStateUnknown, newError("BAD_REQUEST", fmt.Sprintf("unknown job status %q", s), 400, nil)
+}
+
 // ----------------------------------------------------------------------------
 // Auth Types
 // ----------------------------------------------------------------------------
@@ -0,0 +1,92 @@
+package stromboli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// The generated models don't declare the usage (and other newer fields)
+// reported by the server, so the generated client drops them while
+// decoding. Methods that return these fields attach a responseCapture to
+// the request context instead: the transport (see userAgentTransport)
+// copies successful response bodies into it as they are read, and the
+// fields are decoded from the copy afterwards.
+
+// responseCaptureKey is the context key of a *responseCapture.
+type responseCaptureKey struct{}
+
+// responseCapture holds a copy of a successful response body.
+type responseCapture struct {
+	body bytes.Buffer
+}
+
+// withResponseCapture returns a context whose successful response body is
+// copied into the returned capture.
+func withResponseCapture(ctx context.Context) (context.Context, *responseCapture) {
+	capture := &responseCapture{}
+	return context.WithValue(ctx, responseCaptureKey{}, capture), capture
+}
+
+// responseCaptureFrom returns the capture attached to ctx, or nil.
+func responseCaptureFrom(ctx context.Context) *responseCapture {
+	capture, _ := ctx.Value(responseCaptureKey{}).(*responseCapture)
+	return capture
+}
+
+// capturedBody is a response body copied into a responseCapture as it is
+// read.
+type capturedBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+// Close implements io.Closer.
+func (b *capturedBody) Close() error {
+	return b.closer.Close()
+}
+
+// usageFromBody decodes the usage of a run or job response body. It returns
+// nil if the body has no usage.
+func usageFromBody(data []byte) *Usage {
+	var body struct {
+		Usage *Usage `json:"usage"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		return nil
+	}
+	return body.Usage
+}
+
+// modelUsedFromBody decodes the model reported in a run response body, or
+// returns "" if it has none.
+func modelUsedFromBody(data []byte) Model {
+	var body struct {
+		ModelUsed Model `json:"model_used"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		return ""
+	}
+	return body.ModelUsed
+}
+
+// jobUsagesFromBody decodes the usage of every job of a job list response
+// body, in order. It returns nil if the body can't be decoded.
+func jobUsagesFromBody(data []byte) []*Usage {
+	var body struct {
+		Jobs []*struct {
+			Usage *Usage `json:"usage"`
+		} `json:"jobs"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		return nil
+	}
+	usages := make([]*Usage, len(body.Jobs))
+	for i, job := range body.Jobs {
+		if job != nil {
+			usages[i] = job.Usage
+		}
+	}
+	return usages
+}
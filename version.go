@@ -1,7 +1,9 @@
 package stromboli
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 
 	"github.com/Masterminds/semver/v3"
 )
@@ -193,3 +195,62 @@ func MustBeCompatible(serverVersion string) {
 			result.Message, Version, APIVersionRange))
 	}
 }
+
+// checkCompatibility returns an [ErrIncompatibleServer] error if the
+// compatibility check fails the server (see [WithCompatibilityCheck]). The
+// first call looks the server version up with Health.
+func (c *Client) checkCompatibility(ctx context.Context) error {
+	if c.compatMode == CompatOff {
+		return nil
+	}
+
+	result := c.compatibility()
+	if result == nil {
+		// Health records the result; if it fails, the call goes ahead and
+		// the next one tries again
+		if _, err := c.Health(ctx); err != nil {
+			return nil
+		}
+		if result = c.compatibility(); result == nil {
+			return nil
+		}
+	}
+
+	if c.compatMode == CompatFail && result.Status == Incompatible {
+		return newError(ErrIncompatibleServer.Code, result.Message, 0, nil)
+	}
+	return nil
+}
+
+// compatibility returns the recorded compatibility result, or nil if the
+// server version wasn't checked yet.
+func (c *Client) compatibility() *CompatibilityResult {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	return c.compat
+}
+
+// recordCompatibility checks the server version reported by Health, if the
+// compatibility check is enabled and the version changed, and logs a
+// warning if it isn't compatible.
+func (c *Client) recordCompatibility(version string) {
+	if c.compatMode == CompatOff {
+		return
+	}
+
+	c.capsMu.Lock()
+	if c.compat != nil && c.compat.ServerVersion == version {
+		c.capsMu.Unlock()
+		return
+	}
+	result := CheckCompatibility(version)
+	c.compat = result
+	c.capsMu.Unlock()
+
+	switch {
+	case result.Status == Unknown:
+		c.logf(slog.LevelWarn, "could not determine API compatibility: %s", result.Message)
+	case result.Status == Incompatible && c.compatMode == CompatWarn:
+		c.logf(slog.LevelWarn, "%s", result.Message)
+	}
+}
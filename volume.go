@@ -0,0 +1,171 @@
+package stromboli
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// VolumeMount is a typed form of a [PodmanOptions.Volumes] entry.
+//
+// Use [PodmanOptions.AddVolume] to add it to a request instead of building
+// the "host:container[:options]" string by hand:
+//
+//	podman := &stromboli.PodmanOptions{}
+//	podman.AddVolume(stromboli.VolumeMount{
+//	    Host:      "/home/user/project",
+//	    Container: "/workspace",
+//	    ReadOnly:  true,
+//	})
+//	// podman.Volumes == []string{"/home/user/project:/workspace:ro"}
+type VolumeMount struct {
+	// Host is the absolute path on the host.
+	Host string
+
+	// Container is the absolute path inside the container.
+	Container string
+
+	// ReadOnly mounts the volume read-only ("ro").
+	ReadOnly bool
+
+	// Options are additional Podman mount options, e.g. "z" or "noexec".
+	Options []string
+}
+
+// String returns the volume in Podman format: "host:container[:options]",
+// with "ro" as the first option if ReadOnly is set.
+func (v VolumeMount) String() string {
+	s := v.Host + ":" + v.Container
+	options := v.Options
+	if v.ReadOnly {
+		options = append([]string{"ro"}, options...)
+	}
+	if len(options) > 0 {
+		s += ":" + strings.Join(options, ",")
+	}
+	return s
+}
+
+// Validate checks that both paths are absolute and representable in the
+// Podman format (paths can't contain ":", options can't contain ":" or ",").
+// It returns a BAD_REQUEST [Error] describing the first problem.
+func (v VolumeMount) Validate() error {
+	if err := v.validate(); err != nil {
+		return newError("BAD_REQUEST", fmt.Sprintf("invalid volume %q: %v", v.String(), err), 400, nil)
+	}
+	return nil
+}
+
+// validate is Validate without the [Error] wrapping.
+func (v VolumeMount) validate() error {
+	switch {
+	case v.Host == "":
+		return errors.New("host path is empty")
+	case !strings.HasPrefix(v.Host, "/"):
+		return errors.New("host path must be absolute")
+	case strings.Contains(v.Host, ":"):
+		return errors.New("host path must not contain ':'")
+	case v.Container == "":
+		return errors.New("container path is empty")
+	case !strings.HasPrefix(v.Container, "/"):
+		return errors.New("container path must be absolute")
+	case strings.Contains(v.Container, ":"):
+		return errors.New("container path must not contain ':'")
+	}
+	for _, option := range v.Options {
+		if option == "" || strings.ContainsAny(option, ":,") {
+			return fmt.Errorf("invalid option %q", option)
+		}
+	}
+	return nil
+}
+
+// ParseVolume parses a volume in Podman format ("host:container[:options]")
+// and validates it (see [VolumeMount.Validate]). The "ro" option sets
+// ReadOnly; other options are kept in Options.
+//
+// Example:
+//
+//	v, err := stromboli.ParseVolume("/data:/data:ro,z")
+//	// v == VolumeMount{Host: "/data", Container: "/data", ReadOnly: true, Options: []string{"z"}}
+func ParseVolume(s string) (VolumeMount, error) {
+	v, err := parseVolume(s)
+	if err != nil {
+		return VolumeMount{}, newError("BAD_REQUEST", fmt.Sprintf("invalid volume %q: %v", s, err), 400, nil)
+	}
+	return v, nil
+}
+
+// parseVolume is ParseVolume without the [Error] wrapping.
+func parseVolume(s string) (VolumeMount, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return VolumeMount{}, errors.New("expected host:container[:options]")
+	}
+
+	v := VolumeMount{Host: parts[0], Container: parts[1]}
+	if len(parts) == 3 {
+		if parts[2] == "" {
+			return VolumeMount{}, errors.New("options must not be empty")
+		}
+		for _, option := range strings.Split(parts[2], ",") {
+			if option == "ro" {
+				v.ReadOnly = true
+				continue
+			}
+			v.Options = append(v.Options, option)
+		}
+	}
+	if err := v.validate(); err != nil {
+		return VolumeMount{}, err
+	}
+	return v, nil
+}
+
+// AddVolume appends v to Volumes in Podman format.
+//
+// A container path can only be mounted once, so an existing entry for the
+// same container path is removed: the last volume added wins. Replacing a
+// different volume logs a warning; adding the same volume again doesn't.
+//
+// The volume isn't validated here; like other Volumes entries, it is checked
+// by [Client.Run], [Client.RunAsync] and [Client.TrySubmit] before sending.
+func (p *PodmanOptions) AddVolume(v VolumeMount) {
+	entry := v.String()
+	var replaced []string
+	p.Volumes, replaced = addVolume(p.Volumes, entry)
+	for _, existing := range replaced {
+		getLogger().Printf("stromboli: WARNING: volume %q replaces %q mounted at %s", entry, existing, path.Clean(v.Container))
+	}
+}
+
+// addVolume returns a copy of the Volumes entries volumes with entry
+// appended and the entries mounted at the same container path removed, and
+// the removed entries that differ from entry. Without a container path,
+// only an identical entry is removed.
+func addVolume(volumes []string, entry string) (result, replaced []string) {
+	container, hasContainer := volumeContainerPath(entry)
+	result = make([]string, 0, len(volumes)+1)
+	for _, existing := range volumes {
+		if existing == entry {
+			continue
+		}
+		if c, ok := volumeContainerPath(existing); ok && hasContainer && c == container {
+			replaced = append(replaced, existing)
+			continue
+		}
+		result = append(result, existing)
+	}
+	return append(result, entry), replaced
+}
+
+// volumeContainerPath returns the cleaned container path of a Volumes
+// entry, and false if the entry has none.
+func volumeContainerPath(s string) (string, bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || parts[1] == "" {
+		return "", false
+	}
+	return path.Clean(parts[1]), true
+}
@@ -0,0 +1,103 @@
+package stromboli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxWebhookSize limits the size of webhook bodies read by [ParseWebhook].
+// Payloads carry the full job output, so this is larger than maxEventSize.
+const maxWebhookSize = 16 * 1024 * 1024 // 16MB
+
+// WebhookPayload is the notification Stromboli posts to
+// [RunRequest.WebhookURL] when an async job finishes.
+//
+// The payload has the same shape as a job returned by [Client.GetJob], so
+// the [Job] helpers (IsCompleted, IsFailed, CreatedAtTime, ...) can be used:
+//
+//	payload, err := stromboli.ParseWebhook(r)
+//	if err != nil {
+//	    http.Error(w, err.Error(), http.StatusBadRequest)
+//	    return
+//	}
+//	if payload.IsFailed() {
+//	    log.Printf("job %s failed: %s", payload.ID, payload.Error)
+//	}
+//
+// Stromboli does not sign webhook payloads; if the endpoint is reachable
+// by others, protect it with a secret URL or network policy.
+type WebhookPayload struct {
+	Job
+}
+
+// ParseWebhook decodes the [WebhookPayload] of a webhook request.
+//
+// The request must be a POST with a JSON body of at most 16MB that
+// includes the job ID. Otherwise, a BAD_REQUEST [Error] is returned.
+// ParseWebhook reads but does not close the request body; the
+// [http.Server] closes it.
+//
+// Example:
+//
+//	http.HandleFunc("/webhook", func(w http.ResponseWriter, r *http.Request) {
+//	    payload, err := stromboli.ParseWebhook(r)
+//	    if err != nil {
+//	        http.Error(w, err.Error(), http.StatusBadRequest)
+//	        return
+//	    }
+//	    fmt.Printf("job %s is %s\n", payload.ID, payload.Status)
+//	})
+func ParseWebhook(r *http.Request) (*WebhookPayload, error) {
+	if r.Method != http.MethodPost {
+		return nil, newError("BAD_REQUEST", fmt.Sprintf("webhook method must be POST, got %s", r.Method), 400, nil)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookSize+1))
+	if err != nil {
+		return nil, newError("BAD_REQUEST", "failed to read webhook body", 400, err)
+	}
+	if len(data) > maxWebhookSize {
+		return nil, newError("BAD_REQUEST", fmt.Sprintf("webhook body exceeds maximum size of %d bytes", maxWebhookSize), 400, nil)
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, newError("BAD_REQUEST", "invalid webhook payload", 400, err)
+	}
+	if payload.ID == "" {
+		return nil, newError("BAD_REQUEST", "webhook payload has no job ID", 400, nil)
+	}
+
+	return &payload, nil
+}
+
+// WebhookHandler returns an [http.Handler] that parses webhook requests with
+// [ParseWebhook] and passes the payload to fn.
+//
+// The handler responds with 400 Bad Request if the request is not a valid
+// webhook, 500 Internal Server Error if fn returns an error (so the sender
+// can retry), and 204 No Content otherwise. fn receives the request context.
+//
+// Example:
+//
+//	http.Handle("/webhook", stromboli.WebhookHandler(
+//	    func(ctx context.Context, p *stromboli.WebhookPayload) error {
+//	        return store.SaveResult(ctx, p.ID, p.Output)
+//	    }))
+func WebhookHandler(fn func(context.Context, *WebhookPayload) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, err := ParseWebhook(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := fn(r.Context(), payload); err != nil {
+			http.Error(w, "webhook handler failed", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}